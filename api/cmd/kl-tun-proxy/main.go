@@ -22,6 +22,7 @@ type Config struct {
 	RemoteAddr  string // Remote UDP address on server side (e.g., 127.0.0.1:51820)
 	InsecureTLS bool   // Skip TLS certificate verification
 	AuthToken   string // Optional auth token for server
+	Transport   string // auto (QUIC with WebSocket fallback), quic or websocket
 	Verbose     bool   // Enable verbose logging
 }
 
@@ -32,6 +33,7 @@ func main() {
 	flag.StringVar(&cfg.RemoteAddr, "remote", "127.0.0.1:51820", "Remote UDP address on server side")
 	flag.BoolVar(&cfg.InsecureTLS, "insecure", false, "Skip TLS certificate verification")
 	flag.StringVar(&cfg.AuthToken, "auth-token", os.Getenv("AUTH_TOKEN"), "Auth token for server (can also use AUTH_TOKEN env var)")
+	flag.StringVar(&cfg.Transport, "transport", "auto", "Tunnel transport: auto (QUIC, falling back to WebSocket), quic or websocket")
 	flag.BoolVar(&cfg.Verbose, "verbose", false, "Enable verbose logging")
 	version := flag.Bool("version", false, "Show version information")
 	flag.Parse()
@@ -45,9 +47,13 @@ func main() {
 		log.Fatal("server URL is required (use -server flag)")
 	}
 
+	transportMode, err := tunnel.ParseTransportMode(cfg.Transport)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Setup logger
 	var logger *zap.Logger
-	var err error
 	if cfg.Verbose {
 		logger, err = zap.NewDevelopment()
 	} else {
@@ -76,6 +82,9 @@ func main() {
 
 	// Create UDP tunnel client
 	client := tunnel.NewUDPClient(cfg.LocalAddr, cfg.ServerURL, cfg.RemoteAddr, dialer, logger)
+	if transportMode != tunnel.TransportWebSocket {
+		client.SetDatagramDialer(transport.NewQUICDialer(transportConfig, tlsConfig, headers, logger), transportMode)
+	}
 
 	// Setup context and signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -91,7 +100,8 @@ func main() {
 		logger.Info("starting UDP tunnel client",
 			zap.String("local", cfg.LocalAddr),
 			zap.String("server", cfg.ServerURL),
-			zap.String("remote", cfg.RemoteAddr))
+			zap.String("remote", cfg.RemoteAddr),
+			zap.String("transport", string(transportMode)))
 		clientErrChan <- client.Start(ctx)
 	}()
 
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	machinesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	backupFull           bool
+	backupRestoreAll     bool
+	backupRestoreWs      string
+	backupRestoreMachine string
+)
+
+var backupCmd = &cobra.Command{
+	Use:     "backup",
+	Aliases: []string{"backups", "bk"},
+	Short:   "Manage WorkMachine backups",
+	Long: `Manage the backups of the WorkMachine of the workspace.
+
+Backups copy every workspace home and environment volume of the WorkMachine to
+object storage. They are taken on the schedule of the WorkMachine, or on demand.`,
+	Example: `  # List backups
+  kl backup list
+
+  # Take a backup now
+  kl backup create
+
+  # Restore the home of a workspace, or the whole machine
+  kl backup restore wm-alice-20260101-020000 --workspace api
+  kl backup restore wm-alice-20260101-020000 --all`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the backups of the WorkMachine",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleBackupList()
+	},
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Take a backup now",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleBackupCreate()
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <backup>",
+	Short: "Restore a backup",
+	Long: `Restore a backup, either the home of one workspace or every volume.
+
+Volumes are only replaced while nothing uses them: the restore waits until the
+workspace is suspended and the environment deactivated. The previous content is
+kept on the machine until the volume is restored again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleBackupRestore(args[0])
+	},
+}
+
+func init() {
+	backupCreateCmd.Flags().BoolVar(&backupFull, "full", false, "Send every volume in full instead of incrementally")
+
+	backupRestoreCmd.Flags().StringVarP(&backupRestoreWs, "workspace", "w", "", "Restore only the home of this workspace")
+	backupRestoreCmd.Flags().BoolVar(&backupRestoreAll, "all", false, "Restore every volume of the backup")
+	backupRestoreCmd.Flags().StringVar(&backupRestoreMachine, "workmachine", "", "Restore onto this WorkMachine (defaults to the WorkMachine of the backup)")
+	backupRestoreCmd.MarkFlagsMutuallyExclusive("workspace", "all")
+	backupRestoreCmd.MarkFlagsOneRequired("workspace", "all")
+
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+	RootCmd.AddCommand(backupCmd)
+}
+
+// currentWorkMachine returns the WorkMachine of the workspace
+func currentWorkMachine(ctx context.Context) (string, error) {
+	if err := InitClient(); err != nil {
+		return "", err
+	}
+
+	workspace, err := WsClient.Get(ctx)
+	if err != nil {
+		return "", err
+	}
+	if workspace.Spec.WorkmachineName == "" {
+		return "", fmt.Errorf("workspace %s has no WorkMachine", workspace.Name)
+	}
+	return workspace.Spec.WorkmachineName, nil
+}
+
+func handleBackupList() error {
+	ctx := context.Background()
+	wm, err := currentWorkMachine(ctx)
+	if err != nil {
+		return err
+	}
+
+	backups := &machinesv1.BackupList{}
+	if err := WsClient.K8sClient.List(ctx, backups); err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTYPE\tREASON\tSTATE\tVOLUMES\tSIZE\tAGE")
+	for _, b := range backups.Items {
+		if b.Spec.WorkMachine != wm {
+			continue
+		}
+		kind := "incremental"
+		if b.Spec.Full {
+			kind = "full"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			b.Name, kind, b.Spec.Reason, b.Status.State, len(b.Status.Volumes),
+			resource.NewQuantity(b.Status.SizeBytes, resource.BinarySI), time.Since(b.CreationTimestamp.Time).Round(time.Minute))
+	}
+	return tw.Flush()
+}
+
+func handleBackupCreate() error {
+	ctx := context.Background()
+	wm, err := currentWorkMachine(ctx)
+	if err != nil {
+		return err
+	}
+
+	b := &machinesv1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s-%s", wm, time.Now().UTC().Format("20060102-150405")),
+		},
+		Spec: machinesv1.BackupSpec{
+			WorkMachine: wm,
+			Full:        backupFull,
+			Reason:      machinesv1.BackupReasonManual,
+		},
+	}
+	if err := WsClient.K8sClient.Create(ctx, b); err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	fmt.Printf("Backup %s created, follow it with: kl backup list\n", b.Name)
+	return nil
+}
+
+func handleBackupRestore(backupName string) error {
+	ctx := context.Background()
+	if _, err := currentWorkMachine(ctx); err != nil {
+		return err
+	}
+
+	b := &machinesv1.Backup{}
+	if err := WsClient.K8sClient.Get(ctx, client.ObjectKey{Name: backupName}, b); err != nil {
+		return fmt.Errorf("failed to get backup %s: %w", backupName, err)
+	}
+	if b.Status.State != machinesv1.BackupStateCompleted {
+		return fmt.Errorf("backup %s is %s, only completed backups can be restored", b.Name, b.Status.State)
+	}
+
+	restore := &machinesv1.BackupRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: b.Name + "-restore-",
+		},
+		Spec: machinesv1.BackupRestoreSpec{
+			Backup:      b.Name,
+			WorkMachine: backupRestoreMachine,
+			Workspace:   backupRestoreWs,
+		},
+	}
+	if err := WsClient.K8sClient.Create(ctx, restore); err != nil {
+		return fmt.Errorf("failed to create restore: %w", err)
+	}
+
+	fmt.Printf("Restore %s created\n", restore.Name)
+	fmt.Println("Volumes are replaced once their workspace is suspended and their environment deactivated")
+	return nil
+}
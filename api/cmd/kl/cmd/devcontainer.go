@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workspace/devcontainer"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	devcontainerWaitTimeout time.Duration
+)
+
+// postCreateMarkerFile records the hash of the last postCreateCommand that ran,
+// so the command runs once per workspace (and again only when it changes)
+const postCreateMarkerFile = "/home/kl/.kloudlite/devcontainer-post-create"
+
+var devcontainerCmd = &cobra.Command{
+	Use:     "devcontainer",
+	Aliases: []string{"dc"},
+	Short:   "Manage devcontainer.json integration",
+	Long: `Manage how .devcontainer/devcontainer.json in the workspace folder is applied.
+
+When the workspace is created from a git repository, the workspace controller reads
+devcontainer.json and maps it to Kloudlite settings:
+  - features and customizations.kloudlite.packages -> Nix packages
+  - forwardPorts -> exposed ports
+  - containerEnv / remoteEnv -> environment variables
+  - postCreateCommand / postStartCommand -> lifecycle hooks
+  - customizations.vscode.extensions -> VS Code extensions`,
+	Example: `  # Re-apply devcontainer.json after editing it
+  kl devcontainer apply
+
+  # Show what was applied
+  kl devcontainer status`,
+}
+
+var devcontainerApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Re-sync devcontainer.json into the workspace",
+	Long: `Ask the workspace controller to re-read devcontainer.json and apply it.
+
+Settings previously applied from devcontainer.json that are no longer present are removed.
+Packages, ports and variables added by hand are kept.`,
+	Example: `  kl devcontainer apply
+  kl dc apply --timeout 2m`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleDevcontainerApply(devcontainerWaitTimeout)
+	},
+}
+
+var devcontainerStatusCmd = &cobra.Command{
+	Use:     "status",
+	Aliases: []string{"st"},
+	Short:   "Show the applied devcontainer.json",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleDevcontainerStatus()
+	},
+}
+
+var devcontainerHooksCmd = &cobra.Command{
+	Use:    "hooks",
+	Short:  "Run devcontainer lifecycle hooks",
+	Long:   `Install VS Code extensions and run postCreateCommand / postStartCommand. Invoked on workspace start.`,
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleDevcontainerHooks(devcontainerWaitTimeout, true)
+	},
+}
+
+func init() {
+	devcontainerApplyCmd.Flags().DurationVar(&devcontainerWaitTimeout, "timeout", 60*time.Second, "How long to wait for the controller to apply the changes")
+	devcontainerHooksCmd.Flags().DurationVar(&devcontainerWaitTimeout, "timeout", 60*time.Second, "How long to wait for devcontainer.json to be applied")
+
+	devcontainerCmd.AddCommand(devcontainerApplyCmd)
+	devcontainerCmd.AddCommand(devcontainerStatusCmd)
+	devcontainerCmd.AddCommand(devcontainerHooksCmd)
+
+	RootCmd.AddCommand(devcontainerCmd)
+}
+
+func handleDevcontainerApply(timeout time.Duration) error {
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	workspace, err := WsClient.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if workspace.Spec.Devcontainer == nil {
+		workspace.Spec.Devcontainer = &workspacev1.DevcontainerSpec{}
+	}
+	if workspace.Spec.Devcontainer.Disabled {
+		return fmt.Errorf("devcontainer.json integration is disabled for this workspace")
+	}
+
+	// Validate locally first so syntax errors are reported right away
+	relPath := workspace.Spec.Devcontainer.Path
+	if relPath == "" {
+		relPath = devcontainer.DefaultPath
+	}
+	data, err := os.ReadFile(filepath.Join(workspaceFolder(), relPath))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", relPath, err)
+	}
+	if _, err := devcontainer.Parse(data); err != nil {
+		return err
+	}
+
+	workspace.Spec.Devcontainer.SyncRequest++
+	syncRequest := workspace.Spec.Devcontainer.SyncRequest
+	if err := WsClient.Update(ctx, workspace); err != nil {
+		return err
+	}
+
+	fmt.Printf("Applying %s...\n", relPath)
+	status, err := waitForDevcontainerSync(ctx, syncRequest, timeout)
+	if err != nil {
+		return err
+	}
+	printDevcontainerStatus(status)
+
+	if status.Phase != workspacev1.DevcontainerPhaseApplied {
+		return nil
+	}
+
+	// Lifecycle hooks: install new extensions and re-run postCreateCommand if it changed
+	return handleDevcontainerHooks(timeout, false)
+}
+
+func handleDevcontainerStatus() error {
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	workspace, err := WsClient.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if workspace.Status.Devcontainer == nil {
+		fmt.Println("devcontainer.json has not been applied to this workspace")
+		fmt.Println("\nTo apply it, run:")
+		fmt.Println("  kl devcontainer apply")
+		return nil
+	}
+
+	printDevcontainerStatus(workspace.Status.Devcontainer)
+	return nil
+}
+
+// handleDevcontainerHooks installs VS Code extensions and runs lifecycle commands
+// runPostStart is false when re-applying from an existing session, where the
+// workspace has already started
+func handleDevcontainerHooks(timeout time.Duration, runPostStart bool) error {
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	workspace, err := WsClient.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	// On first start the controller applies devcontainer.json shortly after the pod is running
+	if workspace.Status.Devcontainer == nil && (workspace.Spec.GitRepository != nil || len(workspace.Spec.GitRepositories) > 0) &&
+		(workspace.Spec.Devcontainer == nil || !workspace.Spec.Devcontainer.Disabled) {
+		if _, err := waitForDevcontainerSync(ctx, 0, timeout); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		if workspace, err = WsClient.Get(ctx); err != nil {
+			return err
+		}
+	}
+
+	settings := workspace.Spec.Settings
+	if settings == nil {
+		return nil
+	}
+
+	installVSCodeExtensions(settings.VSCodeExtensions)
+
+	if settings.PostCreateCommand != "" {
+		sum := sha256.Sum256([]byte(settings.PostCreateCommand))
+		hash := hex.EncodeToString(sum[:])
+		if prev, _ := os.ReadFile(postCreateMarkerFile); strings.TrimSpace(string(prev)) != hash {
+			fmt.Println("==> Running postCreateCommand")
+			if err := runLifecycleCommand(settings.PostCreateCommand); err != nil {
+				fmt.Printf("Warning: postCreateCommand failed: %v\n", err)
+			} else {
+				_ = os.MkdirAll(filepath.Dir(postCreateMarkerFile), 0o755)
+				_ = os.WriteFile(postCreateMarkerFile, []byte(hash+"\n"), 0o644)
+			}
+		}
+	}
+
+	if runPostStart && settings.PostStartCommand != "" {
+		fmt.Println("==> Running postStartCommand")
+		if err := runLifecycleCommand(settings.PostStartCommand); err != nil {
+			fmt.Printf("Warning: postStartCommand failed: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// waitForDevcontainerSync polls the workspace until the controller has handled the given sync request
+func waitForDevcontainerSync(ctx context.Context, syncRequest int64, timeout time.Duration) (*workspacev1.DevcontainerStatus, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		workspace, err := WsClient.Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if st := workspace.Status.Devcontainer; st != nil && st.ObservedSyncRequest == syncRequest {
+			return st, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for devcontainer.json to be applied")
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func installVSCodeExtensions(extensions []string) {
+	if len(extensions) == 0 {
+		return
+	}
+	codeServer, err := exec.LookPath("code-server")
+	if err != nil {
+		fmt.Println("Warning: code-server not found, skipping VS Code extensions")
+		return
+	}
+
+	installed := map[string]bool{}
+	if out, err := exec.Command(codeServer, "--list-extensions").Output(); err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			installed[strings.ToLower(strings.TrimSpace(line))] = true
+		}
+	}
+
+	for _, ext := range extensions {
+		if installed[strings.ToLower(ext)] {
+			continue
+		}
+		fmt.Printf("==> Installing VS Code extension %s\n", ext)
+		cmd := exec.Command(codeServer, "--install-extension", ext)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Printf("Warning: failed to install extension %s: %v\n", ext, err)
+		}
+	}
+}
+
+func runLifecycleCommand(command string) error {
+	cmd := exec.Command("bash", "-lc", command)
+	cmd.Dir = workspaceFolder()
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	return cmd.Run()
+}
+
+// workspaceFolder returns the project folder of the current workspace
+func workspaceFolder() string {
+	return filepath.Join("/home/kl/workspaces", WsClient.Name)
+}
+
+func printDevcontainerStatus(st *workspacev1.DevcontainerStatus) {
+	fmt.Printf("Devcontainer: %s\n", st.Phase)
+	if st.Path != "" {
+		fmt.Printf("  Path:       %s\n", st.Path)
+	}
+	if st.Message != "" {
+		fmt.Printf("  Message:    %s\n", st.Message)
+	}
+	if st.AppliedAt != nil {
+		fmt.Printf("  Applied at: %s\n", st.AppliedAt.Format(time.RFC3339))
+	}
+	if len(st.Packages) > 0 {
+		fmt.Printf("  Packages:   %s\n", strings.Join(st.Packages, ", "))
+	}
+	if len(st.Ports) > 0 {
+		ports := make([]string, len(st.Ports))
+		for i, p := range st.Ports {
+			ports[i] = fmt.Sprintf("%d", p)
+		}
+		fmt.Printf("  Ports:      %s\n", strings.Join(ports, ", "))
+	}
+	if len(st.EnvironmentVariables) > 0 {
+		fmt.Printf("  Env vars:   %s\n", strings.Join(st.EnvironmentVariables, ", "))
+	}
+	if len(st.Extensions) > 0 {
+		fmt.Printf("  Extensions: %s\n", strings.Join(st.Extensions, ", "))
+	}
+	if len(st.UnsupportedFeatures) > 0 {
+		fmt.Printf("  Unsupported features: %s\n", strings.Join(st.UnsupportedFeatures, ", "))
+	}
+}
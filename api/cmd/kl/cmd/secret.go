@@ -0,0 +1,466 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// secretEnvMountDir is where the workspace controller mounts envFrom sources and the
+	// `kl secret` Secret, one directory per source named <index>_<prefix>
+	secretEnvMountDir = "/var/run/kloudlite/env"
+
+	// secretEnvFile and secretEnvFishFile are sourced by the workspace shells
+	secretEnvFile     = "/home/kl/.kloudlite/secrets.env"
+	secretEnvFishFile = "/home/kl/.kloudlite/secrets.fish"
+
+	secretEnvHeader     = "# Generated by kl secret, do not edit"
+	secretEnvKeysPrefix = "# vars: "
+)
+
+var envVarNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var secretWatchInterval time.Duration
+
+var secretCmd = &cobra.Command{
+	Use:     "secret",
+	Aliases: []string{"secrets", "sec"},
+	Short:   "Manage workspace secrets",
+	Long: `Manage secret environment variables for the workspace.
+
+Secrets are stored in a Kubernetes Secret owned by the workspace and never in the
+Workspace resource. Together with settings.envFrom sources they are loaded into
+every shell, and changes reach running shells at the next prompt without restarting
+the workspace (the kubelet refreshes mounted Secrets within about a minute).`,
+	Example: `  # Set secrets
+  kl secret set DATABASE_URL=postgres://localhost/app
+  echo -n "$TOKEN" | kl secret set API_TOKEN
+
+  # Read and list secrets
+  kl secret get DATABASE_URL
+  kl secret list
+
+  # Remove a secret
+  kl secret unset API_TOKEN`,
+}
+
+var secretSetCmd = &cobra.Command{
+	Use:   "set <KEY=VALUE|KEY>...",
+	Short: "Set secret environment variables",
+	Long: `Set one or more secret environment variables.
+
+When a single KEY is given without a value, the value is read from stdin so that it
+does not end up in the shell history.`,
+	Example: `  kl secret set DATABASE_URL=postgres://localhost/app LOG_LEVEL=debug
+  kl secret set API_TOKEN < token.txt`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleSecretSet(args, cmd.InOrStdin())
+	},
+}
+
+var secretGetCmd = &cobra.Command{
+	Use:   "get <KEY>",
+	Short: "Print the value of a secret",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleSecretGet(args[0])
+	},
+}
+
+var secretListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List secret environment variables (names only)",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleSecretList()
+	},
+}
+
+var secretUnsetCmd = &cobra.Command{
+	Use:     "unset <KEY>...",
+	Aliases: []string{"rm", "delete"},
+	Short:   "Remove secret environment variables",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleSecretUnset(args)
+	},
+}
+
+var secretEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Refresh the shell env file from mounted secrets",
+	Long: `Regenerate ~/.kloudlite/secrets.env (and secrets.fish) from the mounted secrets.
+
+Shells load the file at startup and reload it at each prompt when it changes.
+This normally runs in the background; run it manually to refresh immediately.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		changed, err := writeSecretEnvFiles(secretEnvMountDir)
+		if err != nil {
+			return err
+		}
+		if changed {
+			fmt.Printf("Updated %s\n", secretEnvFile)
+		}
+		return nil
+	},
+}
+
+var secretWatchCmd = &cobra.Command{
+	Use:    "watch",
+	Short:  "Keep the shell env file in sync with mounted secrets",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for {
+			if _, err := writeSecretEnvFiles(secretEnvMountDir); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			time.Sleep(secretWatchInterval)
+		}
+	},
+}
+
+func init() {
+	secretWatchCmd.Flags().DurationVar(&secretWatchInterval, "interval", 5*time.Second, "How often to check mounted secrets for changes")
+
+	secretCmd.AddCommand(secretSetCmd)
+	secretCmd.AddCommand(secretGetCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretUnsetCmd)
+	secretCmd.AddCommand(secretEnvCmd)
+	secretCmd.AddCommand(secretWatchCmd)
+
+	RootCmd.AddCommand(secretCmd)
+}
+
+func handleSecretSet(args []string, stdin io.Reader) error {
+	values := map[string]string{}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			if len(args) > 1 {
+				return fmt.Errorf("%s: expected KEY=VALUE (a value is only read from stdin for a single key)", arg)
+			}
+			data, err := io.ReadAll(stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read value from stdin: %w", err)
+			}
+			value = strings.TrimSuffix(string(data), "\n")
+		}
+		if !envVarNameRegex.MatchString(key) {
+			return fmt.Errorf("invalid variable name: %s", key)
+		}
+		values[key] = value
+	}
+
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	secret, err := WsClient.GetSecret(ctx)
+	if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for k, v := range values {
+		secret.Data[k] = []byte(v)
+	}
+	if err := WsClient.UpdateSecret(ctx, secret); err != nil {
+		return err
+	}
+
+	for _, k := range sortedKeys(values) {
+		fmt.Printf("✓ Set %s\n", k)
+	}
+	fmt.Println("\nNew shells pick up the change within a minute, running shells at their next prompt.")
+	return nil
+}
+
+func handleSecretGet(key string) error {
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	// The workspace Secret is read from the API so that a value set a moment ago is returned
+	secret, err := WsClient.GetSecret(context.Background())
+	if err != nil {
+		return err
+	}
+	if v, ok := secret.Data[key]; ok {
+		fmt.Println(string(v))
+		return nil
+	}
+
+	// Fall back to envFrom sources, which are only readable through their mounts
+	vars, err := readSecretEnv(secretEnvMountDir)
+	if err != nil {
+		return err
+	}
+	if v, ok := vars[key]; ok {
+		fmt.Println(v.value)
+		return nil
+	}
+
+	return fmt.Errorf("secret %s not found", key)
+}
+
+func handleSecretList() error {
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	secret, err := WsClient.GetSecret(ctx)
+	if err != nil {
+		return err
+	}
+	workspace, err := WsClient.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	vars, err := readSecretEnv(secretEnvMountDir)
+	if err != nil {
+		return err
+	}
+
+	// Describe each mounted source by the object it references
+	sources := map[string]string{}
+	if workspace.Spec.Settings != nil {
+		for i, source := range workspace.Spec.Settings.EnvFrom {
+			sources[fmt.Sprintf("%02d", i)] = strings.ToLower(source.Type) + "/" + source.Name
+		}
+	}
+
+	rows := map[string]string{}
+	for k, v := range vars {
+		if src, ok := sources[v.source]; ok {
+			rows[k] = src
+		}
+	}
+	for k := range secret.Data {
+		rows[k] = "kl secret"
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No secrets set")
+		fmt.Println("\nTo add one, run:")
+		fmt.Println("  kl secret set KEY=VALUE")
+		return nil
+	}
+
+	fmt.Println("Secrets:")
+	for _, k := range sortedKeys(rows) {
+		fmt.Printf("  %-30s %s\n", k, rows[k])
+	}
+	return nil
+}
+
+func handleSecretUnset(keys []string) error {
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	secret, err := WsClient.GetSecret(ctx)
+	if err != nil {
+		return err
+	}
+
+	var removed []string
+	for _, k := range keys {
+		if _, ok := secret.Data[k]; ok {
+			delete(secret.Data, k)
+			removed = append(removed, k)
+		} else {
+			fmt.Printf("Secret %s is not set\n", k)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if err := WsClient.UpdateSecret(ctx, secret); err != nil {
+		return err
+	}
+	for _, k := range removed {
+		fmt.Printf("✓ Removed %s\n", k)
+	}
+	return nil
+}
+
+type secretEnvValue struct {
+	value  string
+	source string
+}
+
+// readSecretEnv reads the mounted environment sources
+// Directories are applied in name order, so later sources override earlier ones
+func readSecretEnv(dir string) (map[string]secretEnvValue, error) {
+	vars := map[string]secretEnvValue{}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return vars, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		index, prefix, ok := strings.Cut(entry.Name(), "_")
+		if !ok || !entry.IsDir() {
+			continue
+		}
+		sourceDir := filepath.Join(dir, entry.Name())
+		files, err := os.ReadDir(sourceDir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			// Skip the kubelet's ..data and timestamped directories
+			if strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			key := prefix + f.Name()
+			if !envVarNameRegex.MatchString(key) {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(sourceDir, f.Name()))
+			if err != nil {
+				continue
+			}
+			vars[key] = secretEnvValue{value: string(data), source: index}
+		}
+	}
+	return vars, nil
+}
+
+// writeSecretEnvFiles regenerates the shell env files and reports whether they changed
+// Variables that were present in a previous version are unset so running shells drop them
+func writeSecretEnvFiles(dir string) (bool, error) {
+	vars, err := readSecretEnv(dir)
+	if err != nil {
+		return false, err
+	}
+
+	values := make(map[string]string, len(vars))
+	for k, v := range vars {
+		values[k] = v.value
+	}
+
+	var removed []string
+	if prev, err := os.ReadFile(secretEnvFile); err == nil {
+		for _, k := range secretEnvFileKeys(string(prev)) {
+			if _, ok := values[k]; !ok {
+				removed = append(removed, k)
+			}
+		}
+	}
+
+	sh, fish := renderSecretEnv(values, removed)
+	if prev, err := os.ReadFile(secretEnvFile); err == nil && string(prev) == sh {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(secretEnvFile), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", filepath.Dir(secretEnvFile), err)
+	}
+	// Write the fish file first, shells detect changes through the sh file
+	if err := writeFileAtomic(secretEnvFishFile, []byte(fish), 0o600); err != nil {
+		return false, err
+	}
+	if err := writeFileAtomic(secretEnvFile, []byte(sh), 0o600); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// renderSecretEnv renders the env file for POSIX shells and for fish
+// The second line lists the variables the file sets, which is how the next
+// version knows which variables to unset
+func renderSecretEnv(values map[string]string, removed []string) (string, string) {
+	keys := sortedKeys(values)
+	sort.Strings(removed)
+
+	var sh, fish strings.Builder
+	for _, b := range []*strings.Builder{&sh, &fish} {
+		b.WriteString(secretEnvHeader + "\n")
+		fmt.Fprintf(b, "%s%s\n", secretEnvKeysPrefix, strings.Join(keys, " "))
+	}
+
+	for _, k := range removed {
+		fmt.Fprintf(&sh, "unset %s\n", k)
+		fmt.Fprintf(&fish, "set -e %s\n", k)
+	}
+	for _, k := range keys {
+		fmt.Fprintf(&sh, "export %s=%s\n", k, shSingleQuote(values[k]))
+		fmt.Fprintf(&fish, "set -gx %s %s\n", k, fishSingleQuote(values[k]))
+	}
+	return sh.String(), fish.String()
+}
+
+// secretEnvFileKeys returns the variables managed by an env file
+func secretEnvFileKeys(content string) []string {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), secretEnvKeysPrefix)
+		if !ok {
+			continue
+		}
+		var keys []string
+		for _, key := range strings.Fields(line) {
+			if envVarNameRegex.MatchString(key) {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+	return nil
+}
+
+func shSingleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishSingleQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSecretEnv(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(source, key, value string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, source), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, source, key), []byte(value), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("00_", "DATABASE_URL", "postgres://shared")
+	write("00_", "..data", "ignored")
+	write("01_APP_", "MODE", "dev")
+	write("01_APP_", "invalid-name", "ignored")
+	write("99_", "DATABASE_URL", "postgres://mine")
+
+	vars, err := readSecretEnv(dir)
+	if err != nil {
+		t.Fatalf("readSecretEnv() error = %v", err)
+	}
+
+	got := map[string]string{}
+	for k, v := range vars {
+		got[k] = v.value
+	}
+	want := map[string]string{
+		"DATABASE_URL": "postgres://mine",
+		"APP_MODE":     "dev",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readSecretEnv() = %v, want %v", got, want)
+	}
+	if vars["APP_MODE"].source != "01" {
+		t.Errorf("APP_MODE source = %q, want %q", vars["APP_MODE"].source, "01")
+	}
+}
+
+func TestRenderSecretEnv(t *testing.T) {
+	sh, fish := renderSecretEnv(map[string]string{"TOKEN": `it's "quoted" \n`}, []string{"OLD"})
+
+	if !strings.Contains(sh, `export TOKEN='it'\''s "quoted" \n'`) {
+		t.Errorf("sh output does not quote value:\n%s", sh)
+	}
+	if !strings.Contains(sh, "unset OLD\n") {
+		t.Errorf("sh output does not unset removed variable:\n%s", sh)
+	}
+	if !strings.Contains(fish, `set -gx TOKEN 'it\'s "quoted" \\n'`) {
+		t.Errorf("fish output does not quote value:\n%s", fish)
+	}
+
+	// The managed list only holds the current variables, removed ones are not carried forward
+	if got, want := secretEnvFileKeys(sh), []string{"TOKEN"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("secretEnvFileKeys() = %v, want %v", got, want)
+	}
+}
@@ -59,6 +59,28 @@ func handleStatus() error {
 		}
 	}
 
+	// Display git repositories
+	if len(workspace.Status.GitRepositories) > 0 {
+		fmt.Println("\nRepositories:")
+		for _, repo := range workspace.Status.GitRepositories {
+			repoPath := repo.Path
+			if repoPath == "" {
+				repoPath = "."
+			}
+			fmt.Printf("  %s: %s (%s)\n", repoPath, repo.URL, repo.Phase)
+			if repo.Branch != "" {
+				commit := repo.Commit
+				if len(commit) > 12 {
+					commit = commit[:12]
+				}
+				fmt.Printf("    Branch: %s @ %s\n", repo.Branch, commit)
+			}
+			if repo.Message != "" {
+				fmt.Printf("    Message: %s\n", repo.Message)
+			}
+		}
+	}
+
 	// Display timing information
 	if workspace.Status.StartTime != nil {
 		fmt.Printf("\nStart Time: %s\n", workspace.Status.StartTime.Format(time.RFC3339))
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	wstemplate "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/template"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var templateCmd = &cobra.Command{
+	Use:     "template",
+	Aliases: []string{"tpl"},
+	Short:   "Manage the workspace template",
+	Long: `Show and update the WorkspaceTemplate this workspace is based on.
+
+A template bundles Nix packages, settings, dotfiles, exposed ports, a default
+environment connection and git repositories. Values you change in the workspace
+are kept when the workspace is rebased onto a newer template revision.`,
+	Example: `  # Show whether the workspace is on the latest template revision
+  kl template status
+
+  # Apply the latest template revision
+  kl template rebase`,
+}
+
+var templateStatusCmd = &cobra.Command{
+	Use:     "status",
+	Aliases: []string{"st"},
+	Short:   "Show the template revision of the workspace",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleTemplateStatus()
+	},
+}
+
+var templateRebaseCmd = &cobra.Command{
+	Use:   "rebase",
+	Short: "Rebase the workspace onto the latest template revision",
+	Long: `Apply the latest revision of the workspace template.
+
+Values still matching the previously applied revision are updated; values you
+changed in the workspace are kept.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleTemplateRebase()
+	},
+}
+
+var templateListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List available workspace templates",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleTemplateList()
+	},
+}
+
+func init() {
+	templateCmd.AddCommand(templateStatusCmd)
+	templateCmd.AddCommand(templateRebaseCmd)
+	templateCmd.AddCommand(templateListCmd)
+
+	RootCmd.AddCommand(templateCmd)
+}
+
+// getWorkspaceTemplate returns the workspace and the template it is based on
+func getWorkspaceTemplate(ctx context.Context) (*workspacev1.Workspace, *workspacev1.WorkspaceTemplate, error) {
+	workspace, err := WsClient.Get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if workspace.Spec.TemplateRef == nil {
+		return workspace, nil, fmt.Errorf("workspace %s is not based on a template", workspace.Name)
+	}
+
+	tmpl := &workspacev1.WorkspaceTemplate{}
+	if err := WsClient.K8sClient.Get(ctx, client.ObjectKey{Name: workspace.Spec.TemplateRef.Name}, tmpl); err != nil {
+		return workspace, nil, fmt.Errorf("failed to get workspace template %q: %w", workspace.Spec.TemplateRef.Name, err)
+	}
+	return workspace, tmpl, nil
+}
+
+// pendingTemplateChanges returns the spec fields a rebase onto tmpl would change
+func pendingTemplateChanges(workspace *workspacev1.Workspace, tmpl *workspacev1.WorkspaceTemplate) ([]string, error) {
+	applied, err := wstemplate.DecodeApplied(workspace.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	var base *workspacev1.WorkspaceTemplateSpec
+	if applied != nil {
+		base = &applied.Spec
+	}
+
+	before, err := wstemplate.ToMap(&workspace.Spec)
+	if err != nil {
+		return nil, err
+	}
+	after, err := wstemplate.Merge(before, &tmpl.Spec, base)
+	if err != nil {
+		return nil, err
+	}
+	changes := wstemplate.ChangedFields(before, after)
+
+	// Packages live in the PackageRequest, compare them by name
+	var prevPackages []string
+	if applied != nil {
+		for _, p := range applied.Spec.Packages {
+			prevPackages = append(prevPackages, p.Name)
+		}
+	}
+	var packages []string
+	for _, p := range tmpl.Spec.Packages {
+		packages = append(packages, p.Name)
+	}
+	if strings.Join(prevPackages, ",") != strings.Join(packages, ",") {
+		changes = append(changes, "packages")
+	}
+	return changes, nil
+}
+
+func handleTemplateStatus() error {
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	workspace, tmpl, err := getWorkspaceTemplate(context.Background())
+	if err != nil {
+		return err
+	}
+
+	latest := wstemplate.Revision(&tmpl.Spec)
+	fmt.Printf("Template: %s (%s)\n", tmpl.Name, tmpl.Spec.DisplayName)
+	fmt.Printf("Applied revision: %s\n", workspace.Spec.TemplateRef.Revision)
+	fmt.Printf("Latest revision:  %s\n", latest)
+
+	if workspace.Spec.TemplateRef.Revision == latest {
+		fmt.Println("\nWorkspace is up to date")
+		return nil
+	}
+
+	changes, err := pendingTemplateChanges(workspace, tmpl)
+	if err != nil {
+		return err
+	}
+	fmt.Println("\nWorkspace is out of date")
+	if len(changes) > 0 {
+		fmt.Println("Rebasing would update:")
+		for _, c := range changes {
+			fmt.Printf("  - %s\n", c)
+		}
+	}
+	fmt.Println("\nTo apply the latest revision, run:")
+	fmt.Println("  kl template rebase")
+	return nil
+}
+
+func handleTemplateRebase() error {
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	workspace, tmpl, err := getWorkspaceTemplate(ctx)
+	if err != nil {
+		return err
+	}
+
+	if workspace.Spec.TemplateRef.Revision == wstemplate.Revision(&tmpl.Spec) {
+		fmt.Println("Workspace is already on the latest template revision")
+		return nil
+	}
+
+	changes, err := pendingTemplateChanges(workspace, tmpl)
+	if err != nil {
+		return err
+	}
+
+	// Clearing the revision makes the webhook merge in the latest template
+	workspace.Spec.TemplateRef.Revision = ""
+	if err := WsClient.Update(ctx, workspace); err != nil {
+		return err
+	}
+
+	fmt.Printf("Rebased onto template %s revision %s\n", tmpl.Name, workspace.Spec.TemplateRef.Revision)
+	for _, c := range changes {
+		fmt.Printf("  - updated %s\n", c)
+	}
+	return nil
+}
+
+func handleTemplateList() error {
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	templates := &workspacev1.WorkspaceTemplateList{}
+	if err := WsClient.K8sClient.List(ctx, templates); err != nil {
+		return fmt.Errorf("failed to list workspace templates: %w", err)
+	}
+	if len(templates.Items) == 0 {
+		fmt.Println("No workspace templates found")
+		return nil
+	}
+
+	var current string
+	if workspace, err := WsClient.Get(ctx); err == nil && workspace.Spec.TemplateRef != nil {
+		current = workspace.Spec.TemplateRef.Name
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDISPLAY NAME\tREVISION\tPACKAGES\t")
+	for _, t := range templates.Items {
+		name := t.Name
+		if name == current {
+			name += " *"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t\n", name, t.Spec.DisplayName, wstemplate.Revision(&t.Spec), len(t.Spec.Packages))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cost"
+	machinesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:     "usage",
+	Aliases: []string{"cost"},
+	Short:   "Show the cost of the current month",
+	Long: `Show the cost of the current month for the owner of the workspace.
+
+When a budget covers the owner, its limit and the cost per user, WorkMachine
+and environment are shown. Otherwise the usage of the owner's WorkMachines is
+listed. Costs are estimates based on the prices configured for the installation.`,
+	Example: `  kl usage`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleUsage()
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(usageCmd)
+}
+
+func handleUsage() error {
+	if err := InitClient(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	workspace, err := WsClient.Get(ctx)
+	if err != nil {
+		return err
+	}
+	owner := workspace.Spec.OwnedBy
+
+	budgets := &machinesv1.BudgetList{}
+	if err := WsClient.K8sClient.List(ctx, budgets); err != nil {
+		return fmt.Errorf("failed to list budgets: %w", err)
+	}
+
+	found := false
+	for i := range budgets.Items {
+		budget := &budgets.Items[i]
+		if !slices.Contains(budget.Spec.Users, owner) {
+			continue
+		}
+		found = true
+		printBudget(budget)
+	}
+
+	if found {
+		return nil
+	}
+
+	return printWorkMachineUsage(ctx, owner)
+}
+
+func printBudget(budget *machinesv1.Budget) {
+	name := budget.Spec.DisplayName
+	if name == "" {
+		name = budget.Name
+	}
+
+	fmt.Printf("Budget: %s\n", name)
+	fmt.Printf("Period: %s\n", budget.Status.Period)
+	fmt.Printf("Spent: %s of %s %s (%d%%)\n", budget.Status.Spent, budget.Spec.MonthlyLimit, budget.Status.Currency, budget.Status.Percent)
+	fmt.Printf("Phase: %s\n", budget.Status.Phase)
+	if budget.Status.Message != "" {
+		fmt.Printf("Message: %s\n", budget.Status.Message)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(budget.Status.Users) > 1 {
+		fmt.Fprintln(tw, "\nUSER\tCOST")
+		for _, u := range budget.Status.Users {
+			fmt.Fprintf(tw, "%s\t%s\n", u.Name, u.Cost)
+		}
+	}
+
+	if len(budget.Status.WorkMachines) > 0 {
+		fmt.Fprintln(tw, "\nWORKMACHINE\tOWNER\tHOURS\tCOST")
+		for _, wm := range budget.Status.WorkMachines {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", wm.Name, wm.Owner, wm.RuntimeHours, wm.Cost)
+		}
+	}
+
+	if len(budget.Status.Environments) > 0 {
+		fmt.Fprintln(tw, "\nENVIRONMENT\tOWNER\tCOST")
+		for _, env := range budget.Status.Environments {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", env.Name, env.Owner, env.Cost)
+		}
+	}
+	tw.Flush()
+
+	if len(budget.Status.StoppedWorkMachines) > 0 {
+		fmt.Printf("\nStopped over budget: %v\n", budget.Status.StoppedWorkMachines)
+	}
+	fmt.Println()
+}
+
+func printWorkMachineUsage(ctx context.Context, owner string) error {
+	wms := &machinesv1.WorkMachineList{}
+	if err := WsClient.K8sClient.List(ctx, wms); err != nil {
+		return fmt.Errorf("failed to list work machines: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKMACHINE\tPERIOD\tHOURS\tCOST")
+	for _, wm := range wms.Items {
+		if wm.Spec.OwnedBy != owner || wm.Status.Usage == nil {
+			continue
+		}
+		usage := wm.Status.Usage
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", wm.Name, usage.Period, cost.FormatHours(usage.RuntimeSeconds), usage.Cost)
+	}
+	return tw.Flush()
+}
@@ -11,6 +11,7 @@ import (
 
 	environmentsv1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
 	packagesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/packages/v1"
+	machinesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
 	workspacesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -48,6 +49,11 @@ func New() (*Client, error) {
 		return nil, fmt.Errorf("failed to add packages types to scheme: %w", err)
 	}
 
+	// Register the machine API types with the scheme
+	if err := machinesv1.AddToScheme(scheme.Scheme); err != nil {
+		return nil, fmt.Errorf("failed to add machine types to scheme: %w", err)
+	}
+
 	// Get workspace name and namespace from environment variables
 	workspaceName := os.Getenv("WORKSPACE_NAME")
 	workspaceNamespace := os.Getenv("WORKSPACE_NAMESPACE")
@@ -227,6 +233,31 @@ func (c *Client) GetOrCreatePackageRequest(ctx context.Context) (*packagesv1.Pac
 	return c.CreatePackageRequest(ctx, []packagesv1.PackageSpec{})
 }
 
+// GetSecret retrieves the workspace Secret managed by `kl secret`
+// Secret is created by the workspace controller with name format: {workspace-name}-secrets
+func (c *Client) GetSecret(ctx context.Context) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := c.K8sClient.Get(ctx, types.NamespacedName{
+		Name:      fmt.Sprintf("%s-secrets", c.Name),
+		Namespace: c.Namespace,
+	}, secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("workspace secret not found, restart the workspace to create it")
+		}
+		return nil, fmt.Errorf("failed to get workspace secret: %w", err)
+	}
+	return secret, nil
+}
+
+// UpdateSecret updates the workspace Secret managed by `kl secret`
+func (c *Client) UpdateSecret(ctx context.Context, secret *corev1.Secret) error {
+	if err := c.K8sClient.Update(ctx, secret); err != nil {
+		return fmt.Errorf("failed to update workspace secret: %w", err)
+	}
+	return nil
+}
+
 // getKubeConfig returns the Kubernetes config
 func getKubeConfig() (*rest.Config, error) {
 	// Try in-cluster config first
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	machinesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	backupKubeconfig     string
+	backupWorkMachine    string
+	backupRestoreAll     bool
+	backupRestoreWs      string
+	backupRestoreMachine string
+)
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:     "backup",
+	Aliases: []string{"backups", "bk"},
+	Short:   "Manage WorkMachine backups of an installation",
+	Long: `Manage the WorkMachine backups of a Kloudlite installation as its administrator.
+
+Unlike kl backup, which runs in a workspace, these commands use a kubeconfig of the
+installation, so a machine can be restored when none of its workspaces can run,
+e.g. after its disk was lost or the WorkMachine was deleted.`,
+	Example: `  # List the backups of a WorkMachine
+  kli backup list --workmachine wm-alice
+
+  # Restore a whole machine, or the home of one workspace
+  kli backup restore wm-alice-20260101-020000 --all
+  kli backup restore wm-alice-20260101-020000 --workspace api
+
+  # Restore onto another WorkMachine of the same user
+  kli backup restore wm-alice-20260101-020000 --all --workmachine wm-alice-2`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List backups",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBackupList(cmd.Context())
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <backup>",
+	Short: "Restore a backup",
+	Long: `Restore a backup, either the home of one workspace or every volume.
+
+Volumes are only replaced while nothing uses them: the restore waits until the
+workspace is suspended and the environment deactivated.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBackupRestore(cmd.Context(), args[0])
+	},
+}
+
+func init() {
+	backupCmd.PersistentFlags().StringVar(&backupKubeconfig, "kubeconfig", "", "Kubeconfig of the installation (defaults to $KUBECONFIG or ~/.kube/config)")
+
+	backupListCmd.Flags().StringVar(&backupWorkMachine, "workmachine", "", "List only the backups of this WorkMachine")
+
+	backupRestoreCmd.Flags().StringVarP(&backupRestoreWs, "workspace", "w", "", "Restore only the home of this workspace")
+	backupRestoreCmd.Flags().BoolVar(&backupRestoreAll, "all", false, "Restore every volume of the backup")
+	backupRestoreCmd.Flags().StringVar(&backupRestoreMachine, "workmachine", "", "Restore onto this WorkMachine (defaults to the WorkMachine of the backup)")
+	backupRestoreCmd.MarkFlagsMutuallyExclusive("workspace", "all")
+	backupRestoreCmd.MarkFlagsOneRequired("workspace", "all")
+
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+}
+
+// backupClient returns a client of the installation from the kubeconfig
+func backupClient() (client.Client, error) {
+	kubeconfig := backupKubeconfig
+	if kubeconfig == "" {
+		kubeconfig = os.Getenv("KUBECONFIG")
+	}
+	if kubeconfig == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		kubeconfig = filepath.Join(home, ".kube", "config")
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig %s: %w", kubeconfig, err)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := machinesv1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add machines scheme: %w", err)
+	}
+	return client.New(config, client.Options{Scheme: scheme})
+}
+
+func runBackupList(ctx context.Context) error {
+	k8sClient, err := backupClient()
+	if err != nil {
+		return err
+	}
+
+	backups := &machinesv1.BackupList{}
+	if err := k8sClient.List(ctx, backups); err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tWORKMACHINE\tTYPE\tREASON\tSTATE\tVOLUMES\tSIZE\tAGE")
+	for _, b := range backups.Items {
+		if backupWorkMachine != "" && b.Spec.WorkMachine != backupWorkMachine {
+			continue
+		}
+		kind := "incremental"
+		if b.Spec.Full {
+			kind = "full"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			b.Name, b.Spec.WorkMachine, kind, b.Spec.Reason, b.Status.State, len(b.Status.Volumes),
+			resource.NewQuantity(b.Status.SizeBytes, resource.BinarySI), time.Since(b.CreationTimestamp.Time).Round(time.Minute))
+	}
+	return tw.Flush()
+}
+
+func runBackupRestore(ctx context.Context, backupName string) error {
+	k8sClient, err := backupClient()
+	if err != nil {
+		return err
+	}
+
+	b := &machinesv1.Backup{}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: backupName}, b); err != nil {
+		return fmt.Errorf("failed to get backup %s: %w", backupName, err)
+	}
+	if b.Status.State != machinesv1.BackupStateCompleted {
+		return fmt.Errorf("backup %s is %s, only completed backups can be restored", b.Name, b.Status.State)
+	}
+
+	restore := &machinesv1.BackupRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: b.Name + "-restore-",
+		},
+		Spec: machinesv1.BackupRestoreSpec{
+			Backup:      b.Name,
+			WorkMachine: backupRestoreMachine,
+			Workspace:   backupRestoreWs,
+		},
+	}
+	if err := k8sClient.Create(ctx, restore); err != nil {
+		return fmt.Errorf("failed to create restore: %w", err)
+	}
+
+	fmt.Printf("Restore %s created\n", restore.Name)
+	fmt.Println("Volumes are replaced once their workspace is suspended and their environment deactivated")
+	return nil
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// localCmd represents the local command
+var localCmd = &cobra.Command{
+	Use:   "local",
+	Short: "Local (Docker) installation commands",
+	Long: `Manage a Kloudlite installation running on the local Docker engine.
+
+The control plane runs as a k3s server container and WorkMachines are created
+as k3s agent containers, so the full WorkMachine lifecycle can be exercised
+in CI and on laptops without a cloud account.`,
+	Example: `  # Install Kloudlite on the local Docker engine
+  kli local install
+
+  # Remove the local installation and all its WorkMachines
+  kli local uninstall`,
+}
+
+func init() {
+	// Add local subcommands
+	localCmd.AddCommand(localInstallCmd)
+	localCmd.AddCommand(localUninstallCmd)
+}
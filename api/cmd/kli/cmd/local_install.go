@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kli/internal"
+	localinternal "github.com/kloudlite/kloudlite/api/cmd/kli/internal/local"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var localInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install Kloudlite on the local Docker engine",
+	Long: `Install Kloudlite on the local Docker engine for development and testing.
+
+This command will:
+  - Create the kloudlite-local docker network
+  - Start a docker proxy so the api-server can manage WorkMachine containers
+  - Write Kloudlite manifests and a local api-server configuration
+  - Start a k3s server container that auto-applies the manifests
+  - Write a kubeconfig to ~/.kloudlite/local/kubeconfig
+
+WorkMachines are created by the api-server as k3s agent containers
+(CLOUD_PROVIDER=local). Starting, stopping, resizing and growing volumes work
+like on a cloud provider.`,
+	Example: `  # Install with defaults (https://kloudlite.localhost:8443)
+  kli local install
+
+  # Install on other ports
+  kli local install --https-port 9443 --api-port 7443`,
+	Run: runLocalInstall,
+}
+
+var (
+	localDomain    string
+	localHTTPPort  int
+	localHTTPSPort int
+	localAPIPort   int
+)
+
+func init() {
+	localInstallCmd.Flags().StringVar(&localDomain, "domain", "kloudlite.localhost", "Domain of the local installation")
+	localInstallCmd.Flags().IntVar(&localHTTPPort, "http-port", 8080, "Host port for HTTP")
+	localInstallCmd.Flags().IntVar(&localHTTPSPort, "https-port", 8443, "Host port for HTTPS")
+	localInstallCmd.Flags().IntVar(&localAPIPort, "api-port", 6443, "Host port for the Kubernetes API")
+}
+
+func runLocalInstall(cmd *cobra.Command, args []string) {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow, color.Bold)
+	cyan := color.New(color.FgCyan, color.Bold)
+	bold := color.New(color.Bold)
+
+	fail := func(err error) {
+		red.Printf(" x\n")
+		yellow.Printf("    Error: %v\n\n", err)
+		os.Exit(1)
+	}
+
+	// Header
+	fmt.Println()
+	cyan.Println("+-----------------------------------------+")
+	cyan.Println("|   Kloudlite Local Installation          |")
+	cyan.Println("+-----------------------------------------+")
+	fmt.Println()
+
+	// Prerequisites
+	bold.Println("Prerequisites")
+	bold.Println("-------------")
+	fmt.Printf("  o Checking docker...")
+	dockerVersion, err := localinternal.CheckDocker()
+	if err != nil {
+		fail(err)
+	}
+	green.Printf(" +\n")
+	fmt.Printf("    Docker Engine %s\n", dockerVersion)
+	fmt.Println()
+
+	stateDir, err := localinternal.Dir()
+	if err != nil {
+		fail(err)
+	}
+	manifestsDir := filepath.Join(stateDir, "manifests")
+
+	// Docker resources
+	bold.Println("Docker Setup")
+	bold.Println("------------")
+
+	fmt.Printf("  o Creating network %s...", localinternal.NetworkName)
+	if err := localinternal.EnsureNetwork(); err != nil {
+		fail(err)
+	}
+	green.Printf(" +\n")
+
+	fmt.Printf("  o Starting docker proxy...")
+	dockerHost, err := localinternal.EnsureDockerProxy()
+	if err != nil {
+		fail(err)
+	}
+	green.Printf(" +\n")
+	fmt.Printf("    %s\n", dockerHost)
+	fmt.Println()
+
+	// Manifests
+	bold.Println("Manifests")
+	bold.Println("---------")
+
+	configPath := filepath.Join(manifestsDir, "local-config.yaml")
+	agentToken := ""
+	if _, err := os.Stat(configPath); err == nil {
+		// Keep the secrets of an existing installation
+		fmt.Printf("  o Local configuration already exists at %s (skipping regeneration)\n", configPath)
+		if data, err := os.ReadFile(filepath.Join(stateDir, "agent-token")); err == nil {
+			agentToken = string(data)
+		}
+	}
+	if agentToken == "" {
+		fmt.Printf("  o Generating local configuration...")
+		if agentToken, err = localinternal.GenerateToken(); err != nil {
+			fail(err)
+		}
+		installationSecret, err := localinternal.GenerateToken()
+		if err != nil {
+			fail(err)
+		}
+		jwtSecret, err := localinternal.GenerateSecret()
+		if err != nil {
+			fail(err)
+		}
+
+		if err := os.MkdirAll(manifestsDir, 0o755); err != nil {
+			fail(err)
+		}
+		config := localinternal.ConfigManifests(localinternal.ConfigArgs{
+			InstallationKey:    "local",
+			InstallationSecret: installationSecret,
+			JWTSecret:          jwtSecret,
+			K3sVersion:         internal.K3sVersion,
+			K3sAgentToken:      agentToken,
+			DockerHost:         dockerHost,
+			Domain:             localDomain,
+		})
+		if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+			fail(err)
+		}
+		if err := os.WriteFile(filepath.Join(stateDir, "agent-token"), []byte(agentToken), 0o600); err != nil {
+			fail(err)
+		}
+		green.Printf(" +\n")
+	}
+
+	os.Setenv("AUTH_COOKIE_DOMAIN", localDomain)
+	os.Setenv("KLOUDLITE_REGISTRY_HOST", "cr."+localDomain)
+	os.Setenv("INSTALLATION_TYPE", "local")
+	if err := writeManifests(manifestsDir); err != nil {
+		fail(err)
+	}
+	fmt.Println()
+
+	// Control plane
+	bold.Println("Control Plane")
+	bold.Println("-------------")
+
+	fmt.Printf("  o Starting k3s server (%s)...", internal.K3sVersion)
+	if err := localinternal.StartServer(localinternal.ServerArgs{
+		K3sVersion:   internal.K3sVersion,
+		AgentToken:   agentToken,
+		ManifestsDir: manifestsDir,
+		HTTPPort:     localHTTPPort,
+		HTTPSPort:    localHTTPSPort,
+		APIPort:      localAPIPort,
+	}); err != nil {
+		fail(err)
+	}
+	green.Printf(" +\n")
+
+	fmt.Printf("  o Waiting for k3s server to be ready...")
+	deadline := time.Now().Add(5 * time.Minute)
+	for !localinternal.ServerReady() {
+		if time.Now().After(deadline) {
+			fail(fmt.Errorf("k3s server not ready after 5 minutes, see: docker logs %s", localinternal.ServerContainer))
+		}
+		time.Sleep(3 * time.Second)
+	}
+	green.Printf(" +\n")
+
+	fmt.Printf("  o Writing kubeconfig...")
+	kubeconfig, err := localinternal.Kubeconfig(localAPIPort)
+	if err != nil {
+		fail(err)
+	}
+	kubeconfigPath := filepath.Join(stateDir, "kubeconfig")
+	if err := os.WriteFile(kubeconfigPath, []byte(kubeconfig), 0o600); err != nil {
+		fail(err)
+	}
+	green.Printf(" +\n")
+
+	// Success Summary
+	fmt.Println()
+	green.Println("+-----------------------------------------+")
+	green.Println("|   + Installation Complete!              |")
+	green.Println("+-----------------------------------------+")
+	fmt.Println()
+
+	bold.Println("Cluster Access")
+	bold.Println("--------------")
+	cyan.Printf("    export KUBECONFIG=%s\n", kubeconfigPath)
+	cyan.Printf("    kubectl get workmachines\n")
+
+	fmt.Println()
+	bold.Println("Web Access")
+	bold.Println("----------")
+	cyan.Printf("    https://%s:%d\n", localDomain, localHTTPSPort)
+
+	fmt.Println()
+	fmt.Println("  WorkMachines run as containers named kl-workmachine-<name>:")
+	cyan.Printf("    docker ps --filter label=%s\n", localinternal.LabelManagedBy)
+	fmt.Println()
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	localinternal "github.com/kloudlite/kloudlite/api/cmd/kli/internal/local"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var localUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Uninstall the local Kloudlite installation",
+	Long: `Uninstall the local Kloudlite installation.
+
+This command will:
+  - Remove all WorkMachine containers and their volumes
+  - Remove the k3s server container and its state
+  - Remove the docker proxy and the kloudlite-local network
+  - Delete ~/.kloudlite/local`,
+	Example: `  # Remove the local installation
+  kli local uninstall`,
+	Run: runLocalUninstall,
+}
+
+func runLocalUninstall(cmd *cobra.Command, args []string) {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow, color.Bold)
+	cyan := color.New(color.FgCyan, color.Bold)
+
+	// Header
+	fmt.Println()
+	cyan.Println("+-----------------------------------------+")
+	cyan.Println("|   Kloudlite Local Uninstallation        |")
+	cyan.Println("+-----------------------------------------+")
+	fmt.Println()
+
+	fmt.Printf("  o Removing containers, volumes and network...")
+	if err := localinternal.RemoveAll(); err != nil {
+		red.Printf(" x\n")
+		yellow.Printf("    Error: %v\n\n", err)
+		os.Exit(1)
+	}
+	green.Printf(" +\n")
+
+	fmt.Printf("  o Removing local state...")
+	stateDir, err := localinternal.Dir()
+	if err == nil {
+		err = os.RemoveAll(stateDir)
+	}
+	if err != nil {
+		red.Printf(" x\n")
+		yellow.Printf("    Error: %v\n\n", err)
+		os.Exit(1)
+	}
+	green.Printf(" +\n")
+
+	fmt.Println()
+	green.Println("Local installation removed")
+	fmt.Println()
+}
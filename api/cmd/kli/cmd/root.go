@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Version is set via ldflags during build
+var Version = "dev"
+
+// RootCmd represents the base command
+var RootCmd = &cobra.Command{
+	Use:   "kli",
+	Short: "Kloudlite Installer CLI",
+	Long: `kli is a command-line tool for managing Kloudlite installations.
+
+It provides commands to create, configure, and manage Kloudlite installations
+from the command line.`,
+	Example: `  # Show version
+  kli version
+  kli v
+
+  # Check AWS prerequisites
+  kli aws doctor
+
+  # Check GCP prerequisites
+  kli gcp doctor
+
+  # Check Azure prerequisites
+  kli azure doctor
+  kli az doctor
+
+  # Check OCI prerequisites
+  kli oci doctor
+
+  # Install on the local Docker engine
+  kli local install
+
+  # Restore a WorkMachine from a backup
+  kli backup restore wm-alice-20260101-020000 --all`,
+}
+
+func init() {
+	// Add commands
+	RootCmd.AddCommand(versionCmd)
+	RootCmd.AddCommand(awsCmd)
+	RootCmd.AddCommand(gcpCmd)
+	RootCmd.AddCommand(azureCmd)
+	RootCmd.AddCommand(ociCmd)
+	RootCmd.AddCommand(localCmd)
+	RootCmd.AddCommand(backupCmd)
+}
+
+// Execute runs the root command
+func Execute() error {
+	return RootCmd.Execute()
+}
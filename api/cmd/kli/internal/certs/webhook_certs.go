@@ -0,0 +1,298 @@
+package certs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// WebhookCertificates holds the generated certificates for webhooks
+type WebhookCertificates struct {
+	CACert     []byte // PEM encoded CA certificate
+	ServerCert []byte // PEM encoded server certificate
+	ServerKey  []byte // PEM encoded server private key
+	CABundle   string // Base64 encoded CA certificate for webhook config
+}
+
+// GenerateWebhookCertificates generates self-signed certificates for webhook server
+func GenerateWebhookCertificates(serviceName, namespace string) (*WebhookCertificates, error) {
+	// Generate CA private key
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	// Create CA certificate template
+	caTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:   "Kloudlite Webhook CA",
+			Organization: []string{"Kloudlite"},
+		},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().AddDate(10, 0, 0), // Valid for 10 years
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	// Self-sign the CA certificate
+	caCertBytes, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+
+	// PEM encode CA certificate
+	caCertPEM := new(bytes.Buffer)
+	if err := pem.Encode(caCertPEM, &pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: caCertBytes,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode CA certificate: %w", err)
+	}
+
+	// Generate server private key
+	serverKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate server key: %w", err)
+	}
+
+	// DNS names for the webhook service
+	dnsNames := []string{
+		serviceName,
+		fmt.Sprintf("%s.%s", serviceName, namespace),
+		fmt.Sprintf("%s.%s.svc", serviceName, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, namespace),
+	}
+
+	// Create server certificate template
+	serverTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject: pkix.Name{
+			CommonName:   fmt.Sprintf("%s.%s.svc", serviceName, namespace),
+			Organization: []string{"Kloudlite"},
+		},
+		NotBefore:   time.Now(),
+		NotAfter:    time.Now().AddDate(10, 0, 0), // Valid for 10 years
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:    dnsNames,
+	}
+
+	// Sign server certificate with CA
+	serverCertBytes, err := x509.CreateCertificate(rand.Reader, serverTemplate, caTemplate, &serverKey.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create server certificate: %w", err)
+	}
+
+	// PEM encode server certificate
+	serverCertPEM := new(bytes.Buffer)
+	if err := pem.Encode(serverCertPEM, &pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: serverCertBytes,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode server certificate: %w", err)
+	}
+
+	// PEM encode server private key
+	serverKeyPEM := new(bytes.Buffer)
+	if err := pem.Encode(serverKeyPEM, &pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(serverKey),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode server key: %w", err)
+	}
+
+	// Base64 encode CA certificate for webhook configuration
+	caBundle := base64.StdEncoding.EncodeToString(caCertPEM.Bytes())
+
+	return &WebhookCertificates{
+		CACert:     caCertPEM.Bytes(),
+		ServerCert: serverCertPEM.Bytes(),
+		ServerKey:  serverKeyPEM.Bytes(),
+		CABundle:   caBundle,
+	}, nil
+}
+
+// WildcardCertificates holds the generated certificates for wildcard TLS
+type WildcardCertificates struct {
+	CACert []byte // PEM encoded CA certificate
+	Cert   []byte // PEM encoded certificate
+	Key    []byte // PEM encoded private key
+}
+
+// GenerateWildcardCertificates generates a self-signed wildcard certificate for a domain
+// The domain parameter should be the base domain (e.g., "khost.dev")
+// This will create a certificate valid for *.domain and domain itself
+func GenerateWildcardCertificates(domain string) (*WildcardCertificates, error) {
+	// Generate CA private key
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	// Create CA certificate template
+	// CommonName includes the domain so kltun can identify and remove stale CAs
+	// when the server regenerates certificates (e.g., "Kloudlite CA for *.maska.khost.dev")
+	caTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:   fmt.Sprintf("Kloudlite CA for *.%s", domain),
+			Organization: []string{"Kloudlite"},
+		},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().AddDate(10, 0, 0), // Valid for 10 years
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	// Self-sign the CA certificate
+	caCertBytes, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+
+	// PEM encode CA certificate
+	caCertPEM := new(bytes.Buffer)
+	if err := pem.Encode(caCertPEM, &pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: caCertBytes,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode CA certificate: %w", err)
+	}
+
+	// Generate wildcard certificate private key
+	certKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate certificate key: %w", err)
+	}
+
+	// DNS names for wildcard certificate
+	dnsNames := []string{
+		domain,
+		"*." + domain,
+	}
+
+	// Create wildcard certificate template
+	certTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject: pkix.Name{
+			CommonName:   "*." + domain,
+			Organization: []string{"Kloudlite"},
+		},
+		NotBefore:   time.Now(),
+		NotAfter:    time.Now().AddDate(10, 0, 0), // Valid for 10 years
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:    dnsNames,
+	}
+
+	// Sign certificate with CA
+	certBytes, err := x509.CreateCertificate(rand.Reader, certTemplate, caTemplate, &certKey.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	// PEM encode certificate
+	certPEM := new(bytes.Buffer)
+	if err := pem.Encode(certPEM, &pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: certBytes,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode certificate: %w", err)
+	}
+
+	// Create full chain: server cert + CA cert
+	// This is required for browsers to verify the certificate chain
+	fullChainPEM := new(bytes.Buffer)
+	fullChainPEM.Write(certPEM.Bytes())
+	fullChainPEM.Write(caCertPEM.Bytes())
+
+	// PEM encode private key
+	keyPEM := new(bytes.Buffer)
+	if err := pem.Encode(keyPEM, &pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(certKey),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode certificate key: %w", err)
+	}
+
+	return &WildcardCertificates{
+		CACert: caCertPEM.Bytes(),
+		Cert:   fullChainPEM.Bytes(), // Full chain for tls.crt
+		Key:    keyPEM.Bytes(),
+	}, nil
+}
+
+// RegistryAuthCertificates holds the RSA key pair for registry token authentication
+type RegistryAuthCertificates struct {
+	PrivateKey  []byte // PEM encoded RSA private key (for API server to sign tokens)
+	Certificate []byte // PEM encoded certificate (for registry to verify tokens)
+}
+
+// GenerateRegistryAuthCertificates generates an RSA key pair for Docker Registry token authentication.
+// The private key is used by the API server to sign JWT tokens.
+// The certificate is used by the registry to verify the tokens.
+func GenerateRegistryAuthCertificates() (*RegistryAuthCertificates, error) {
+	// Generate RSA private key
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+	}
+
+	// Create self-signed certificate template
+	certTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:   "kloudlite-registry-auth",
+			Organization: []string{"Kloudlite"},
+		},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().AddDate(10, 0, 0), // Valid for 10 years
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	// Self-sign the certificate
+	certBytes, err := x509.CreateCertificate(rand.Reader, certTemplate, certTemplate, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	// PEM encode certificate
+	certPEM := new(bytes.Buffer)
+	if err := pem.Encode(certPEM, &pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: certBytes,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode certificate: %w", err)
+	}
+
+	// PEM encode private key (PKCS8 format for compatibility)
+	pkcs8Key, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	keyPEM := new(bytes.Buffer)
+	if err := pem.Encode(keyPEM, &pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: pkcs8Key,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	return &RegistryAuthCertificates{
+		PrivateKey:  keyPEM.Bytes(),
+		Certificate: certPEM.Bytes(),
+	}, nil
+}
@@ -0,0 +1,6 @@
+package internal
+
+// K3sVersion is the version of k3s to install for both server and agents.
+// This ensures version consistency across the entire cluster.
+// Update this when upgrading k3s across the platform.
+const K3sVersion = "v1.33.6+k3s1"
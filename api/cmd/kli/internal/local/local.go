@@ -0,0 +1,327 @@
+// Package local runs a Kloudlite installation on the local Docker engine
+//
+// The control plane is a k3s server container; WorkMachines are created by the api-server
+// as k3s agent containers (CLOUD_PROVIDER=local) on the same docker network. The api-server
+// reaches the Docker engine through a socat proxy container on that network.
+package local
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// NetworkName is the docker network shared by the server, the docker proxy and WorkMachines
+	NetworkName = "kloudlite-local"
+
+	// ServerContainer runs the k3s server (control plane)
+	ServerContainer = "kloudlite-local-server"
+
+	// DockerProxyContainer exposes the Docker engine to the api-server on NetworkName
+	DockerProxyContainer = "kloudlite-local-docker"
+
+	// ServerVolume holds the k3s server state
+	ServerVolume = "kloudlite-local-server"
+
+	// LabelInstallation marks all docker resources of the local installation
+	LabelInstallation = "kloudlite.io/local-installation=true"
+
+	// LabelManagedBy marks WorkMachine containers and volumes created by the api-server
+	LabelManagedBy = "kloudlite.io/managed-by=kloudlite-local"
+
+	dockerProxyImage = "alpine/socat:latest"
+	dockerProxyPort  = 2375
+)
+
+// Dir returns the directory holding the local installation state (~/.kloudlite/local)
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".kloudlite", "local"), nil
+}
+
+// Docker runs a docker CLI command and returns its trimmed stdout
+func Docker(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// CheckDocker verifies that the docker CLI is installed and the engine is reachable
+func CheckDocker() (string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", fmt.Errorf("docker CLI not found in PATH")
+	}
+	return Docker("version", "--format", "{{.Server.Version}}")
+}
+
+// Exists reports whether a docker object (container, network or volume) exists
+func Exists(kind, name string) bool {
+	_, err := Docker(kind, "inspect", name)
+	return err == nil
+}
+
+// EnsureNetwork creates the local docker network
+func EnsureNetwork() error {
+	if Exists("network", NetworkName) {
+		return nil
+	}
+	_, err := Docker("network", "create", "--label", LabelInstallation, NetworkName)
+	return err
+}
+
+// EnsureDockerProxy starts the docker proxy container and returns its address on the network
+// The api-server uses it as LOCAL_DOCKER_HOST to manage WorkMachine containers.
+func EnsureDockerProxy() (string, error) {
+	if !Exists("container", DockerProxyContainer) {
+		if _, err := Docker("run", "-d",
+			"--name", DockerProxyContainer,
+			"--label", LabelInstallation,
+			"--network", NetworkName,
+			"--restart", "unless-stopped",
+			"-v", "/var/run/docker.sock:/var/run/docker.sock",
+			dockerProxyImage,
+			fmt.Sprintf("tcp-listen:%d,fork,reuseaddr", dockerProxyPort),
+			"unix-connect:/var/run/docker.sock",
+		); err != nil {
+			return "", err
+		}
+	}
+
+	ip, err := ContainerIP(DockerProxyContainer)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tcp://%s:%d", ip, dockerProxyPort), nil
+}
+
+// ContainerIP returns the address of a container on the local network
+// Pods in the k3s server reach containers by IP, docker DNS is not available to them.
+func ContainerIP(name string) (string, error) {
+	ip, err := Docker("inspect", "-f", fmt.Sprintf("{{(index .NetworkSettings.Networks %q).IPAddress}}", NetworkName), name)
+	if err != nil {
+		return "", err
+	}
+	if ip == "" {
+		return "", fmt.Errorf("container %s has no address on network %s", name, NetworkName)
+	}
+	return ip, nil
+}
+
+// ServerArgs are the options of the k3s server container
+type ServerArgs struct {
+	K3sVersion   string
+	AgentToken   string
+	ManifestsDir string
+	HTTPPort     int
+	HTTPSPort    int
+	APIPort      int
+}
+
+// StartServer runs the k3s server container
+// Kloudlite manifests are mounted into the server's auto-apply manifests directory.
+func StartServer(args ServerArgs) error {
+	if Exists("container", ServerContainer) {
+		_, err := Docker("start", ServerContainer)
+		return err
+	}
+
+	image := "rancher/k3s:" + strings.ReplaceAll(args.K3sVersion, "+", "-")
+	_, err := Docker("run", "-d",
+		"--name", ServerContainer,
+		"--hostname", ServerContainer,
+		"--label", LabelInstallation,
+		"--network", NetworkName,
+		"--privileged",
+		"--restart", "unless-stopped",
+		"--tmpfs", "/run",
+		"--tmpfs", "/var/run",
+		"-p", fmt.Sprintf("127.0.0.1:%d:6443", args.APIPort),
+		"-p", fmt.Sprintf("127.0.0.1:%d:80", args.HTTPPort),
+		"-p", fmt.Sprintf("127.0.0.1:%d:443", args.HTTPSPort),
+		"-v", ServerVolume+":/var/lib/rancher/k3s",
+		"-v", args.ManifestsDir+":/var/lib/rancher/k3s/server/manifests/kloudlite",
+		image,
+		"server",
+		"--disable", "traefik",
+		"--agent-token", args.AgentToken,
+		"--tls-san", "127.0.0.1",
+		"--tls-san", ServerContainer,
+		"--write-kubeconfig-mode", "644",
+	)
+	return err
+}
+
+// Kubeconfig returns the server kubeconfig, rewritten to the published API port
+func Kubeconfig(apiPort int) (string, error) {
+	kubeconfig, err := Docker("exec", ServerContainer, "cat", "/etc/rancher/k3s/k3s.yaml")
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(kubeconfig, "https://127.0.0.1:6443", fmt.Sprintf("https://127.0.0.1:%d", apiPort)), nil
+}
+
+// ServerReady reports whether the k3s server node is ready
+func ServerReady() bool {
+	out, err := Docker("exec", ServerContainer, "kubectl", "get", "node", ServerContainer,
+		"-o", `jsonpath={.status.conditions[?(@.type=="Ready")].status}`)
+	return err == nil && out == "True"
+}
+
+// RemoveAll deletes the local installation: WorkMachine containers and volumes, the server,
+// the docker proxy and the network
+func RemoveAll() error {
+	for _, label := range []string{LabelManagedBy, LabelInstallation} {
+		containers, err := Docker("ps", "-aq", "--filter", "label="+label)
+		if err != nil {
+			return err
+		}
+		if containers != "" {
+			if _, err := Docker(append([]string{"rm", "-f", "-v"}, strings.Fields(containers)...)...); err != nil {
+				return err
+			}
+		}
+
+		volumes, err := Docker("volume", "ls", "-q", "--filter", "label="+label)
+		if err != nil {
+			return err
+		}
+		if volumes != "" {
+			if _, err := Docker(append([]string{"volume", "rm", "-f"}, strings.Fields(volumes)...)...); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The server volume is created implicitly by docker run, without labels
+	if Exists("volume", ServerVolume) {
+		if _, err := Docker("volume", "rm", "-f", ServerVolume); err != nil {
+			return err
+		}
+	}
+
+	if Exists("network", NetworkName) {
+		if _, err := Docker("network", "rm", NetworkName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateToken generates a random hex token
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateSecret generates a random base64 secret
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// ConfigArgs are the values of the api-server configuration for a local installation
+type ConfigArgs struct {
+	InstallationKey    string
+	InstallationSecret string
+	JWTSecret          string
+	K3sVersion         string
+	K3sAgentToken      string
+	DockerHost         string
+	Domain             string
+}
+
+// ConfigManifests returns the namespace, secrets, api-server config and machine types
+// for a local installation, the counterpart of the cloud startup scripts
+func ConfigManifests(args ConfigArgs) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: kloudlite
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: api-server-secret
+  namespace: kloudlite
+type: Opaque
+stringData:
+  INSTALLATION_SECRET: "%[2]s"
+  K3S_AGENT_TOKEN: "%[5]s"
+  JWT_SECRET: "%[3]s"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: frontend-secrets
+  namespace: kloudlite
+type: Opaque
+stringData:
+  jwt-secret: "%[3]s"
+  installation-secret: "%[2]s"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: api-server-config
+  namespace: kloudlite
+data:
+  PORT: "8080"
+  CLOUD_PROVIDER: "local"
+  INSTALLATION_KEY: "%[1]s"
+  LOCAL_DOCKER_HOST: "%[6]s"
+  LOCAL_DOCKER_NETWORK: "%[8]s"
+  K3S_VERSION: "%[4]s"
+  K3S_SERVER_URL: "https://%[9]s:6443"
+  HOSTED_SUBDOMAIN: "%[7]s"
+  REGISTRY_SERVICE_NAME: "cr.%[7]s"
+---
+apiVersion: machines.kloudlite.io/v1
+kind: MachineType
+metadata:
+  name: local-small
+spec:
+  displayName: "Local Small (1 CPU, 2GB)"
+  category: development
+  resources:
+    cpu: "1"
+    memory: 2Gi
+  active: true
+  isDefault: true
+  priority: 10
+---
+apiVersion: machines.kloudlite.io/v1
+kind: MachineType
+metadata:
+  name: local-medium
+spec:
+  displayName: "Local Medium (2 CPU, 4GB)"
+  category: development
+  resources:
+    cpu: "2"
+    memory: 4Gi
+  active: true
+  priority: 20
+`, args.InstallationKey, args.InstallationSecret, args.JWTSecret, args.K3sVersion, args.K3sAgentToken,
+		args.DockerHost, args.Domain, NetworkName, ServerContainer)
+}
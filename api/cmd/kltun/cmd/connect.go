@@ -14,8 +14,10 @@ import (
 )
 
 var (
-	connectToken  string
-	connectServer string
+	connectToken     string
+	connectServer    string
+	connectName      string
+	connectTransport string
 )
 
 var connectCmd = &cobra.Command{
@@ -27,9 +29,19 @@ The connection runs in the background via the kltun daemon. Once connected,
 you can access your Kloudlite workspace and services.
 
 IMPORTANT: For security reasons, credentials are NOT saved to disk. You must
-provide --token and --server flags on every connection.`,
+provide --token and --server flags on every connection.
+
+Several connections can run at the same time, one per WorkMachine. Each one is
+identified by its name (the subdomain of the server by default); connecting
+again with the same name replaces that connection only.`,
 	Example: `  # Connect with token and server (required every time)
-  kltun connect --token YOUR_TOKEN --server https://subdomain.khost.dev`,
+  kltun connect --token YOUR_TOKEN --server https://subdomain.khost.dev
+
+  # Connect to a second WorkMachine alongside the first one
+  kltun connect --token OTHER_TOKEN --server https://other.khost.dev --name other
+
+  # Tunnel over WebSocket only, e.g. on networks where QUIC is throttled
+  kltun connect --token YOUR_TOKEN --server https://subdomain.khost.dev --transport websocket`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runConnect()
 	},
@@ -38,6 +50,8 @@ provide --token and --server flags on every connection.`,
 func init() {
 	connectCmd.Flags().StringVar(&connectToken, "token", "", "Authentication token")
 	connectCmd.Flags().StringVar(&connectServer, "server", "", "Server URL (e.g., https://subdomain.khost.dev)")
+	connectCmd.Flags().StringVar(&connectName, "name", "", "Connection name (defaults to the subdomain of the server)")
+	connectCmd.Flags().StringVar(&connectTransport, "transport", "auto", "Tunnel transport: auto (QUIC, falling back to WebSocket when UDP is blocked), quic or websocket")
 
 	RootCmd.AddCommand(connectCmd)
 }
@@ -82,12 +96,12 @@ func runConnect() error {
 	sp = spinner.New("Establishing VPN connection...")
 	sp.Start()
 
-	result, err := client.VPNConnect(connectToken, connectServer)
+	result, err := client.VPNConnect(connectToken, connectServer, connectName, connectTransport)
 	if err != nil {
 		sp.Stop(false)
 		return fmt.Errorf("failed to connect: %w", err)
 	}
-	sp.StopWithMessage(true, fmt.Sprintf("Connected (Session: %s)", result.SessionID))
+	sp.StopWithMessage(true, fmt.Sprintf("Connected %s (Session: %s)", result.Name, result.SessionID))
 
 	// Step 3: Install CA certificate
 	if result.TunnelEndpoint != "" && result.PermanentToken != "" {
@@ -145,7 +159,7 @@ func runConnect() error {
 
 	fmt.Println()
 	fmt.Println("VPN connection is running in the background.")
-	fmt.Println("Use 'kltun quit' to disconnect.")
+	fmt.Printf("Use 'kltun quit --name %s' to disconnect, 'kltun status' to list connections.\n", result.Name)
 	fmt.Println()
 
 	return nil
@@ -113,9 +113,20 @@ var daemonStatusCmd = &cobra.Command{
 				fmt.Printf("\nDaemon Status:\n")
 				fmt.Printf("  Active Connections: %d\n", len(status.Connections))
 				for _, conn := range status.Connections {
-					fmt.Printf("    - Session: %s\n", conn.SessionID)
+					fmt.Printf("    - Name: %s\n", conn.Name)
+					fmt.Printf("      Session: %s\n", conn.SessionID)
 					fmt.Printf("      Server: %s\n", conn.Server)
+					fmt.Printf("      State: %s\n", conn.State)
+					if conn.Reason != "" {
+						fmt.Printf("      Reason: %s\n", conn.Reason)
+					}
 					fmt.Printf("      Uptime: %d seconds\n", conn.Uptime)
+					if conn.Transport != "" {
+						fmt.Printf("      Transport: %s\n", conn.Transport)
+					}
+					if conn.TunnelError != "" {
+						fmt.Printf("      Tunnel: %s\n", conn.TunnelError)
+					}
 				}
 			}
 		}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var devicesName string
+
+var devicesCmd = &cobra.Command{
+	Use:     "devices",
+	Aliases: []string{"device"},
+	Short:   "List and revoke the devices registered on the tunnel server",
+	Long: `List the devices (WireGuard peers) registered on the tunnel server of a connection,
+with their last handshake and transferred bytes. Admins see the devices of all users.
+
+Devices without a handshake for a while are removed by the tunnel server, and
+each user can register a limited number of devices. Revoke the devices you no
+longer use (e.g., a lost laptop) to free them.`,
+	Example: `  # List your devices
+  kltun devices
+
+  # List the devices on the tunnel server of connection "teacup"
+  kltun devices --name teacup
+
+  # Revoke a device by name or public key prefix
+  kltun devices revoke old-laptop`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := forwardDaemonClient()
+		if err != nil {
+			return err
+		}
+
+		result, err := client.DevicesList(devicesName)
+		if err != nil {
+			return fmt.Errorf("failed to list devices: %w", err)
+		}
+
+		if len(result.Devices) == 0 {
+			fmt.Println("No devices")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "DEVICE\tOWNER\tIP\tPUBLIC KEY\tLAST HANDSHAKE\tRX\tTX")
+		fmt.Fprintln(w, "------\t-----\t--\t----------\t--------------\t--\t--")
+
+		for _, d := range result.Devices {
+			name := d.DeviceName
+			if d.Current {
+				name += " *"
+			}
+			handshake := "never"
+			if d.LastHandshake != nil {
+				handshake = time.Since(*d.LastHandshake).Round(time.Second).String() + " ago"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name, orDash(d.Owner), d.IP, shortKey(d.PublicKey),
+				handshake, formatBytes(d.RxBytes), formatBytes(d.TxBytes))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		fmt.Println("* this device")
+		if result.IdleTimeoutSeconds > 0 {
+			fmt.Printf("Devices without a handshake for %s are removed.\n", time.Duration(result.IdleTimeoutSeconds)*time.Second)
+		}
+		if result.MaxDevices > 0 {
+			fmt.Printf("Each user can register up to %d devices.\n", result.MaxDevices)
+		}
+		return nil
+	},
+}
+
+var devicesRevokeCmd = &cobra.Command{
+	Use:     "revoke <device|public-key>",
+	Aliases: []string{"rm", "remove"},
+	Short:   "Revoke a device",
+	Long: `Revoke a device registered on the tunnel server, by device name or public key
+(prefix). The device loses access until it connects again with 'kltun connect'.
+Users revoke their own devices, admins any device.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := forwardDaemonClient()
+		if err != nil {
+			return err
+		}
+
+		device, err := client.DevicesRevoke(devicesName, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to revoke device: %w", err)
+		}
+
+		fmt.Printf("✓ Revoked device %s (%s)\n", device.DeviceName, shortKey(device.PublicKey))
+		return nil
+	},
+}
+
+func init() {
+	devicesCmd.PersistentFlags().StringVar(&devicesName, "name", "", "Connection whose tunnel server is asked (defaults to the only connection)")
+
+	devicesCmd.AddCommand(devicesRevokeCmd)
+
+	RootCmd.AddCommand(devicesCmd)
+}
+
+// shortKey shortens a WireGuard public key for display
+func shortKey(key string) string {
+	if len(key) <= 12 {
+		return key
+	}
+	return key[:12] + "…"
+}
+
+// formatBytes formats a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
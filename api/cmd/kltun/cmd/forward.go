@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/daemon"
+	"github.com/spf13/cobra"
+)
+
+var forwardCmd = &cobra.Command{
+	Use:   "forward <workspace|service>:<port> [local-port]",
+	Short: "Forward a local port to a workspace or environment service",
+	Long: `Forward a port on 127.0.0.1 to a workspace or environment service over the VPN.
+
+The target is a workspace or environment service name (or its full hostname as
+shown by 'kltun hosts list'). Traffic goes through the WireGuard tunnel; no
+public route is created. Workspaces only accept connections on the ports of
+their service (ssh, code-server, terminals and ports exposed with 'kl expose');
+environment services accept connections on any of their service ports.
+
+Forwards are kept by the daemon and come back when kltun reconnects or restarts.
+If local-port is omitted, the remote port is used.`,
+	Example: `  # Forward localhost:5432 to the postgres service of the connected environment
+  kltun forward postgres:5432
+
+  # Forward localhost:3001 to port 3000 of workspace "api"
+  kltun forward api:3000 3001
+
+  # List forwards
+  kltun forward list
+
+  # Stop the forward on localhost:3001
+  kltun forward remove 3001`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, port, err := parseForwardTarget(args[0])
+		if err != nil {
+			return err
+		}
+
+		localPort := port
+		if len(args) == 2 {
+			if localPort, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid local port %q", args[1])
+			}
+		}
+
+		client, err := forwardDaemonClient()
+		if err != nil {
+			return err
+		}
+
+		forward, err := client.ForwardAdd(target, port, localPort)
+		if err != nil {
+			return fmt.Errorf("failed to add port forward: %w", err)
+		}
+
+		fmt.Printf("✓ Forwarding 127.0.0.1:%d → %s:%d\n", forward.LocalPort, forward.Hostname, forward.Port)
+		return nil
+	},
+}
+
+var forwardListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List port forwards",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := forwardDaemonClient()
+		if err != nil {
+			return err
+		}
+
+		forwards, err := client.ForwardList()
+		if err != nil {
+			return fmt.Errorf("failed to list port forwards: %w", err)
+		}
+
+		if len(forwards) == 0 {
+			fmt.Println("No port forwards")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "LOCAL\tTARGET\tHOSTNAME\tCONNECTIONS\tSTATUS")
+		fmt.Fprintln(w, "-----\t------\t--------\t-----------\t------")
+
+		for _, f := range forwards {
+			hostname := f.Hostname
+			if hostname == "" {
+				hostname = "-"
+			}
+			status := "listening"
+			if !f.Listening {
+				status = "stopped"
+			}
+			if f.Error != "" {
+				status += ": " + f.Error
+			}
+			fmt.Fprintf(w, "127.0.0.1:%d\t%s:%d\t%s\t%d\t%s\n", f.LocalPort, f.Target, f.Port, hostname, f.Connections, status)
+		}
+
+		return w.Flush()
+	},
+}
+
+var forwardRemoveCmd = &cobra.Command{
+	Use:     "remove <local-port>",
+	Aliases: []string{"rm", "stop"},
+	Short:   "Stop a port forward",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		localPort, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid local port %q", args[0])
+		}
+
+		client, err := forwardDaemonClient()
+		if err != nil {
+			return err
+		}
+
+		if err := client.ForwardRemove(localPort); err != nil {
+			return fmt.Errorf("failed to remove port forward: %w", err)
+		}
+
+		fmt.Printf("✓ Stopped forwarding 127.0.0.1:%d\n", localPort)
+		return nil
+	},
+}
+
+func init() {
+	forwardCmd.AddCommand(forwardListCmd)
+	forwardCmd.AddCommand(forwardRemoveCmd)
+
+	RootCmd.AddCommand(forwardCmd)
+}
+
+// forwardDaemonClient ensures the daemon is running and connects to it
+func forwardDaemonClient() (*daemon.Client, error) {
+	sm, err := daemon.NewServiceManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service manager: %w", err)
+	}
+
+	if err := sm.EnsureRunning(); err != nil {
+		return nil, fmt.Errorf("failed to start daemon: %w", err)
+	}
+
+	return daemon.NewClient(sm.GetSocketPath()), nil
+}
+
+// parseForwardTarget splits "<target>:<port>"
+func parseForwardTarget(arg string) (string, int, error) {
+	idx := strings.LastIndex(arg, ":")
+	if idx <= 0 || idx == len(arg)-1 {
+		return "", 0, fmt.Errorf("invalid target %q, expected <workspace|service>:<port>", arg)
+	}
+	port, err := strconv.Atoi(arg[idx+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in %q", arg)
+	}
+	return arg[:idx], port, nil
+}
@@ -7,21 +7,48 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	quitName string
+	quitAll  bool
+)
+
 var quitCmd = &cobra.Command{
-	Use:   "quit",
+	Use:   "quit [name]",
 	Short: "Disconnect from Kloudlite VPN",
-	Long: `Disconnect from the active Kloudlite VPN connection.
+	Long: `Disconnect from a Kloudlite VPN connection.
+
+Without a name, the only active connection is stopped. When several connections
+are active, name the one to stop or use --all.
 
 This will stop the VPN connection, remove host entries, but keep the CA certificate
 installed for future connections.`,
 	Example: `  # Disconnect from VPN
-  kltun quit`,
+  kltun quit
+
+  # Disconnect one of several connections
+  kltun quit --name other
+
+  # Disconnect all connections
+  kltun quit --all`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			if quitName != "" && quitName != args[0] {
+				return fmt.Errorf("connection name given twice (%q and %q)", args[0], quitName)
+			}
+			quitName = args[0]
+		}
+		if quitAll && quitName != "" {
+			return fmt.Errorf("--all and a connection name are mutually exclusive")
+		}
 		return runQuit()
 	},
 }
 
 func init() {
+	quitCmd.Flags().StringVar(&quitName, "name", "", "Name of the connection to stop")
+	quitCmd.Flags().BoolVar(&quitAll, "all", false, "Stop all connections")
+
 	RootCmd.AddCommand(quitCmd)
 }
 
@@ -42,7 +69,7 @@ func runQuit() error {
 	client := daemon.NewClient(sm.GetSocketPath())
 
 	// Stop VPN connection via daemon
-	if err := client.VPNQuit(); err != nil {
+	if err := client.VPNQuit(quitName, quitAll); err != nil {
 		return fmt.Errorf("failed to disconnect: %w", err)
 	}
 
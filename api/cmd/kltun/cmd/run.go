@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/daemon"
+	"github.com/kloudlite/kloudlite/api/pkg/udptunnel/tunnel"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runToken     string
+	runServer    string
+	runName      string
+	runTransport string
+	runSOCKS5    string
+	runHTTP      string
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [flags] [-- command [args...]]",
+	Short: "Join an environment's network without the daemon or root (CI mode)",
+	Long: `Join the network of a WorkMachine from a CI job, with a service token.
+
+Unlike 'kltun connect', run needs neither the daemon nor root: WireGuard runs
+on a userspace network stack inside this process, and programs reach the
+environment through local SOCKS5 and HTTP proxies. Routes, DNS and /etc/hosts
+of the host are left untouched.
+
+The proxies resolve the services of the environment, by their full hostname or
+by their name alone (e.g., postgres:5432). Everything else is dialed directly.
+
+With a command, run starts it with ALL_PROXY, HTTP_PROXY and HTTPS_PROXY set
+to the proxies, and exits with its exit code once it is done. Without one, run
+keeps the proxies up until interrupted.
+
+Service tokens are issued per environment from the dashboard and can be passed
+with KLTUN_TOKEN and KLTUN_SERVER instead of flags, to keep them out of logs.`,
+	Example: `  # Run the integration tests against the services of the environment
+  KLTUN_TOKEN=$SERVICE_TOKEN kltun run --server https://subdomain.khost.dev -- go test ./integration/...
+
+  # Keep the proxies up in the background of a job
+  kltun run --server https://subdomain.khost.dev --socks5 127.0.0.1:1080 --http "" &
+  curl --socks5-hostname 127.0.0.1:1080 http://api:8080/health`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHeadless(args)
+	},
+}
+
+func init() {
+	// The defaults stay empty so that --help never prints the token; runHeadless reads the environment
+	runCmd.Flags().StringVar(&runToken, "token", "", "Service token of the environment (defaults to $KLTUN_TOKEN)")
+	runCmd.Flags().StringVar(&runServer, "server", "", "Server URL, e.g., https://subdomain.khost.dev (defaults to $KLTUN_SERVER)")
+	runCmd.Flags().StringVar(&runName, "name", "", "Device name of the job (defaults to the hostname)")
+	runCmd.Flags().StringVar(&runTransport, "transport", "auto", "Tunnel transport: auto (QUIC, falling back to WebSocket when UDP is blocked), quic or websocket")
+	runCmd.Flags().StringVar(&runSOCKS5, "socks5", "127.0.0.1:1080", "Listen address of the SOCKS5 proxy, empty to disable")
+	runCmd.Flags().StringVar(&runHTTP, "http", "127.0.0.1:8080", "Listen address of the HTTP proxy, empty to disable")
+
+	RootCmd.AddCommand(runCmd)
+}
+
+func runHeadless(args []string) error {
+	if runToken == "" {
+		runToken = os.Getenv("KLTUN_TOKEN")
+	}
+	if runServer == "" {
+		runServer = os.Getenv("KLTUN_SERVER")
+	}
+	if runToken == "" || runServer == "" {
+		return errors.New("--token and --server (or KLTUN_TOKEN and KLTUN_SERVER) are required")
+	}
+	transportMode, err := tunnel.ParseTransportMode(runTransport)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	conn, err := daemon.StartHeadless(ctx, daemon.HeadlessConfig{
+		Server:     runServer,
+		Token:      runToken,
+		DeviceName: runName,
+		Transport:  transportMode,
+		SOCKS5Addr: runSOCKS5,
+		HTTPAddr:   runHTTP,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	fmt.Printf("✓ Connected (IP: %s)\n", conn.IP)
+	if conn.SOCKS5Addr != "" {
+		fmt.Printf("  SOCKS5 proxy: socks5h://%s\n", conn.SOCKS5Addr)
+	}
+	if conn.HTTPAddr != "" {
+		fmt.Printf("  HTTP proxy:   http://%s\n", conn.HTTPAddr)
+	}
+
+	if len(args) == 0 {
+		<-ctx.Done()
+		fmt.Println("Disconnecting...")
+		return nil
+	}
+
+	// The command gets the signals of the terminal or CI runner itself, run waits for it to exit
+	command := exec.Command(args[0], args[1:]...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	command.Env = append(os.Environ(), conn.ProxyEnv()...)
+	if err := command.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("failed to run %s: %w", args[0], err)
+		}
+		conn.Close()
+		os.Exit(exitErr.ExitCode())
+	}
+	return nil
+}
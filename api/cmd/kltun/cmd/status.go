@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/daemon"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List VPN connections",
+	Long: `List the VPN connections of the kltun daemon with their tunnel subnet and interface.
+
+The connection marked with * routes the cluster services.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sm, err := daemon.NewServiceManager()
+		if err != nil {
+			return fmt.Errorf("failed to create service manager: %w", err)
+		}
+
+		running, err := sm.Status()
+		if err != nil {
+			return fmt.Errorf("failed to get daemon status: %w", err)
+		}
+		if !running {
+			fmt.Println("Daemon is not running")
+			return nil
+		}
+
+		client := daemon.NewClient(sm.GetSocketPath())
+		status, err := client.Status()
+		if err != nil {
+			return fmt.Errorf("failed to get status: %w", err)
+		}
+
+		if len(status.Connections) == 0 {
+			fmt.Println("No VPN connections")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSERVER\tSTATE\tIP\tSUBNET\tINTERFACE\tUPTIME")
+		fmt.Fprintln(w, "----\t------\t-----\t--\t------\t---------\t------")
+
+		for _, conn := range status.Connections {
+			name := conn.Name
+			if conn.Services {
+				name += " *"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name, conn.Server, conn.State,
+				orDash(conn.IP), orDash(conn.CIDR), orDash(conn.Interface), time.Duration(conn.Uptime)*time.Second)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		for _, conn := range status.Connections {
+			if conn.Reason != "" {
+				fmt.Printf("\n%s: reconnecting, %s\n", conn.Name, conn.Reason)
+			}
+			if conn.TunnelError != "" {
+				fmt.Printf("\n%s: %s\n", conn.Name, conn.TunnelError)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(statusCmd)
+}
+
+// orDash returns s, or "-" when it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
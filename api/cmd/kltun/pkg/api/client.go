@@ -13,6 +13,11 @@ import (
 type HostEntry struct {
 	Hostname string `json:"hostname"`
 	IP       string `json:"ip"`
+
+	// Service details, only from tunnel servers
+	Type        string `json:"type,omitempty"` // "ingress", "service" or "workspace"
+	Environment string `json:"environment,omitempty"`
+	Service     string `json:"service,omitempty"`
 }
 
 // ConnectResponse represents the response from the connect API
@@ -46,7 +46,11 @@ func (c *TunnelClient) setAuthHeader(req *http.Request) {
 // CreatePeerRequest represents the request to create a WireGuard peer
 type CreatePeerRequest struct {
 	DeviceName string `json:"deviceName"`
-	PublicKey  string `json:"publicKey"` // Client's WireGuard public key
+	PublicKey  string `json:"publicKey"`          // Client's WireGuard public key
+	DeviceID   string `json:"deviceId,omitempty"` // Stable ID of the device, its older peers are replaced
+
+	// ExcludeCIDRs are the subnets routed through the tunnels to other servers
+	ExcludeCIDRs []string `json:"excludeCidrs,omitempty"`
 }
 
 // CreatePeerResponse represents the response from creating a peer
@@ -55,15 +59,18 @@ type CreatePeerResponse struct {
 	IP              string `json:"ip"`
 	ServerPublicKey string `json:"serverPublicKey"` // Server's WireGuard public key
 	CIDR            string `json:"cidr"`            // VPN CIDR (e.g., "10.17.0.0/24")
+	ServerAddress   string `json:"serverAddress"`   // Gateway and DNS server in CIDR (e.g., "10.17.0.1"), empty on older servers
 	AlreadyExists   bool   `json:"alreadyExists"`   // True if peer already existed
 }
 
 // CreatePeer creates a new WireGuard peer on the tunnel server
-// deviceName is used to identify the device, publicKey is the client's WireGuard public key
-func (c *TunnelClient) CreatePeer(deviceName, publicKey string) (*CreatePeerResponse, error) {
+// deviceName names the device, deviceID identifies it across keys (the peers it registered before
+// are replaced, none when empty), publicKey is the client's WireGuard public key, and excludeCIDRs
+// are the subnets of the other tunnels of the device, which the peer's subnet must not overlap
+func (c *TunnelClient) CreatePeer(deviceName, deviceID, publicKey string, excludeCIDRs []string) (*CreatePeerResponse, error) {
 	url := fmt.Sprintf("%s/wg/peer", c.BaseURL)
 
-	reqBody := CreatePeerRequest{DeviceName: deviceName, PublicKey: publicKey}
+	reqBody := CreatePeerRequest{DeviceName: deviceName, DeviceID: deviceID, PublicKey: publicKey, ExcludeCIDRs: excludeCIDRs}
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -131,6 +138,56 @@ func (c *TunnelClient) DeletePeer(publicKey string) error {
 	return nil
 }
 
+// PeerStatus represents a WireGuard peer (a device) registered on the tunnel server
+type PeerStatus struct {
+	PublicKey     string     `json:"publicKey"`
+	DeviceName    string     `json:"deviceName"`
+	Owner         string     `json:"owner,omitempty"`
+	IP            string     `json:"ip"`
+	CIDR          string     `json:"cidr"`
+	Endpoint      string     `json:"endpoint,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	LastHandshake *time.Time `json:"lastHandshake,omitempty"`
+	RxBytes       int64      `json:"rxBytes"`
+	TxBytes       int64      `json:"txBytes"`
+}
+
+// ListPeersResponse represents the response from listing peers
+type ListPeersResponse struct {
+	Peers              []PeerStatus `json:"peers"`
+	IdleTimeoutSeconds int64        `json:"idleTimeoutSeconds,omitempty"` // Idle peers are removed after this long
+	MaxPeersPerUser    int          `json:"maxPeersPerUser,omitempty"`
+}
+
+// ListPeers lists the peers of the user on the tunnel server (all peers for admins)
+func (c *TunnelClient) ListPeers() (*ListPeersResponse, error) {
+	url := fmt.Sprintf("%s/wg/peers", c.BaseURL)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setAuthHeader(req)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("tunnel server returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result ListPeersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
 // GetPublicKeyResponse represents the response from getting the server's public key
 type GetPublicKeyResponse struct {
 	PublicKey string `json:"publicKey"`
@@ -279,11 +279,14 @@ func (c *Client) HostsFlush() error {
 	return nil
 }
 
-// VPNConnect starts a VPN connection and returns the full result including CA cert status
-func (c *Client) VPNConnect(token, server string) (*VPNConnectResult, error) {
+// VPNConnect starts a named VPN connection, its tunnel over the given transport (empty for auto),
+// and returns the full result including CA cert status
+func (c *Client) VPNConnect(token, server, name, transport string) (*VPNConnectResult, error) {
 	params := VPNConnectParams{
-		Token:  token,
-		Server: server,
+		Token:     token,
+		Server:    server,
+		Name:      name,
+		Transport: transport,
 	}
 	var result VPNConnectResult
 
@@ -298,11 +301,11 @@ func (c *Client) VPNConnect(token, server string) (*VPNConnectResult, error) {
 	return &result, nil
 }
 
-// VPNQuit stops the active VPN connection
-func (c *Client) VPNQuit() error {
+// VPNQuit stops the named VPN connection, the only one when name is empty, or all of them
+func (c *Client) VPNQuit(name string, all bool) error {
 	var result VPNQuitResult
 
-	if err := c.call(MethodVPNQuit, VPNQuitParams{}, &result); err != nil {
+	if err := c.call(MethodVPNQuit, VPNQuitParams{Name: name, All: all}, &result); err != nil {
 		return err
 	}
 
@@ -328,3 +331,77 @@ func (c *Client) Status() (*StatusResult, error) {
 func (c *Client) IsRunning() bool {
 	return c.Ping() == nil
 }
+
+// ForwardAdd forwards localPort on 127.0.0.1 to target:port over the VPN
+func (c *Client) ForwardAdd(target string, port, localPort int) (*ForwardEntry, error) {
+	params := ForwardAddParams{
+		Target:    target,
+		Port:      port,
+		LocalPort: localPort,
+	}
+	var result ForwardAddResult
+
+	if err := c.call(MethodForwardAdd, params, &result); err != nil {
+		return nil, err
+	}
+
+	if !result.Success {
+		return nil, fmt.Errorf("%s", result.Message)
+	}
+
+	return &result.Forward, nil
+}
+
+// ForwardRemove removes the port forward on localPort
+func (c *Client) ForwardRemove(localPort int) error {
+	params := ForwardRemoveParams{LocalPort: localPort}
+	var result ForwardRemoveResult
+
+	if err := c.call(MethodForwardRemove, params, &result); err != nil {
+		return err
+	}
+
+	if !result.Success {
+		return fmt.Errorf("%s", result.Message)
+	}
+
+	return nil
+}
+
+// ForwardList lists all port forwards
+func (c *Client) ForwardList() ([]ForwardEntry, error) {
+	var result ForwardListResult
+
+	if err := c.call(MethodForwardList, ForwardListParams{}, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Forwards, nil
+}
+
+// DevicesList lists the devices registered on the tunnel server of a connection
+func (c *Client) DevicesList(name string) (*DevicesListResult, error) {
+	var result DevicesListResult
+
+	if err := c.call(MethodDevicesList, DevicesListParams{Name: name}, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// DevicesRevoke revokes a device registered on the tunnel server of a connection
+func (c *Client) DevicesRevoke(name, target string) (*DeviceEntry, error) {
+	params := DevicesRevokeParams{Name: name, Target: target}
+	var result DevicesRevokeResult
+
+	if err := c.call(MethodDevicesRevoke, params, &result); err != nil {
+		return nil, err
+	}
+
+	if !result.Success {
+		return nil, fmt.Errorf("%s", result.Message)
+	}
+
+	return &result.Device, nil
+}
@@ -0,0 +1,223 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/api"
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/netconfig"
+)
+
+// Several named connections run side by side, each to its own tunnel server. A connection gets a
+// slot, which gives it its own WireGuard interface and local ports, and a tunnel subnet the tunnel
+// server picks so that it does not overlap the subnets of the other connections. All tunnel servers
+// of an installation reach the same service CIDR, so it is routed through one connection only and
+// handed over to another one when that connection stops.
+const (
+	// defaultGatewayIP is the gateway of tunnel servers that do not report the gateway of the peer's subnet
+	defaultGatewayIP = "10.17.0.1"
+
+	// serviceCIDR is the ClusterIP range of the cluster, reached through the tunnel servers
+	serviceCIDR = "10.43.0.0/16"
+
+	// wireGuardBasePort is the WireGuard listen port of slot 0, the local UDP proxy listens on the next port
+	wireGuardBasePort = 51820
+)
+
+// slotPorts returns the WireGuard listen port and the local UDP proxy port of a connection slot
+func slotPorts(slot int) (wgPort, proxyPort int) {
+	wgPort = wireGuardBasePort + 2*slot
+	return wgPort, wgPort + 1
+}
+
+// slotInterfaceName returns the name of the WireGuard interface of a connection slot
+func slotInterfaceName(slot int) string {
+	switch runtime.GOOS {
+	case "linux":
+		return fmt.Sprintf("wg%d", slot)
+	case "windows":
+		if slot == 0 {
+			return "Kloudlite"
+		}
+		return fmt.Sprintf("Kloudlite%d", slot+1)
+	default:
+		// macOS picks the next free utun interface
+		return "utun"
+	}
+}
+
+// isSlotInterface reports whether name is the WireGuard interface of a connection slot
+func isSlotInterface(name string) bool {
+	if name == slotInterfaceName(0) {
+		return true
+	}
+	prefix := strings.TrimRightFunc(name, unicode.IsDigit)
+	n, err := strconv.Atoi(name[len(prefix):])
+	if err != nil {
+		return false
+	}
+	// Slot n is wg<n> on Linux, Kloudlite<n+1> on Windows
+	return slotInterfaceName(n) == name || (n > 0 && slotInterfaceName(n-1) == name)
+}
+
+// defaultConnectionName names a connection after the subdomain of its dashboard
+// (e.g., "beanbag" for https://beanbag.khost.dev)
+func defaultConnectionName(server string) string {
+	host := server
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	if label, _, _ := strings.Cut(host, "."); label != "" {
+		return label
+	}
+	return "default"
+}
+
+// peerGateway returns the gateway of the peer's subnet, which is also the DNS server of the tunnel server
+func peerGateway(peer *api.CreatePeerResponse) string {
+	if peer.ServerAddress != "" {
+		return peer.ServerAddress
+	}
+	return defaultGatewayIP
+}
+
+// tunnelRoutes returns the routes of a connection's interface
+func tunnelRoutes(cidr string, services bool) []string {
+	routes := []string{cidr}
+	if services {
+		routes = append(routes, serviceCIDR)
+	}
+	return routes
+}
+
+// cidrsOverlap reports whether two CIDRs overlap
+func cidrsOverlap(a, b string) bool {
+	_, netA, errA := net.ParseCIDR(a)
+	_, netB, errB := net.ParseCIDR(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return netA.Contains(netB.IP) || netB.Contains(netA.IP)
+}
+
+// freeSlot returns the lowest slot no connection uses; must be called with s.connMutex held
+func (s *Server) freeSlot() int {
+	used := make(map[int]bool, len(s.connections))
+	for _, conn := range s.connections {
+		used[conn.Slot] = true
+	}
+	slot := 0
+	for used[slot] {
+		slot++
+	}
+	return slot
+}
+
+// connectionByName returns the connection with the given name
+func (s *Server) connectionByName(name string) *VPNConnection {
+	s.connMutex.RLock()
+	defer s.connMutex.RUnlock()
+
+	for _, conn := range s.connections {
+		if conn.Name == name {
+			return conn
+		}
+	}
+	return nil
+}
+
+// sortedConnections returns the connections ordered by name
+func (s *Server) sortedConnections() []*VPNConnection {
+	s.connMutex.RLock()
+	defer s.connMutex.RUnlock()
+
+	conns := make([]*VPNConnection, 0, len(s.connections))
+	for _, conn := range s.connections {
+		conns = append(conns, conn)
+	}
+	sort.Slice(conns, func(i, j int) bool { return conns[i].Name < conns[j].Name })
+	return conns
+}
+
+// otherTunnelCIDRs returns the tunnel subnets of the connections other than sessionID
+func (s *Server) otherTunnelCIDRs(sessionID string) []string {
+	s.connMutex.RLock()
+	defer s.connMutex.RUnlock()
+
+	var cidrs []string
+	for id, conn := range s.connections {
+		if id == sessionID {
+			continue
+		}
+		if cidr := conn.tunnelCIDR(); cidr != "" {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+	return cidrs
+}
+
+// checkTunnelCIDR fails when the subnet handed out by a tunnel server overlaps another connection,
+// which happens with tunnel servers that do not support subnet negotiation
+func (s *Server) checkTunnelCIDR(sessionID, cidr string) error {
+	for _, other := range s.otherTunnelCIDRs(sessionID) {
+		if cidrsOverlap(cidr, other) {
+			return fmt.Errorf("tunnel subnet %s overlaps %s of another connection, disconnect it first or upgrade the tunnel server", cidr, other)
+		}
+	}
+	return nil
+}
+
+// claimServiceRoute makes conn route the service CIDR when no other connection does
+func (s *Server) claimServiceRoute(conn *VPNConnection) bool {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+
+	for _, other := range s.connections {
+		if other != conn && other.RoutesServices {
+			return conn.RoutesServices
+		}
+	}
+	conn.RoutesServices = true
+	return true
+}
+
+// handOverServiceRoute routes the service CIDR through another connection once conn stopped routing it
+func (s *Server) handOverServiceRoute(conn *VPNConnection) {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+
+	if !conn.RoutesServices {
+		return
+	}
+	conn.RoutesServices = false
+
+	for _, other := range s.connections {
+		if other == conn || other.GetState() != StateConnected {
+			continue
+		}
+
+		other.WGMutex.Lock()
+		netCfg := other.NetConfig
+		if netCfg != nil {
+			route := &netconfig.InterfaceConfig{InterfaceName: netCfg.InterfaceName, Routes: []string{serviceCIDR}}
+			if err := netconfig.AddRoutes(route); err != nil {
+				fmt.Printf("[Session %s] Warning: Failed to take over service route: %v\n", other.SessionID, err)
+			} else {
+				netCfg.Routes = append(netCfg.Routes, serviceCIDR)
+				other.RoutesServices = true
+			}
+		}
+		other.WGMutex.Unlock()
+
+		if other.RoutesServices {
+			fmt.Printf("[Session %s] ✓ Routing %s (taken over from %s)\n", other.SessionID, serviceCIDR, conn.Name)
+			return
+		}
+	}
+}
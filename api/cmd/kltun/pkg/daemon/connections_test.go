@@ -0,0 +1,61 @@
+package daemon
+
+import "testing"
+
+func TestDefaultConnectionName(t *testing.T) {
+	tests := map[string]string{
+		"https://beanbag.khost.dev":      "beanbag",
+		"https://beanbag.khost.dev:8443": "beanbag",
+		"teacup.khost.dev":               "teacup",
+		"":                               "default",
+	}
+
+	for server, want := range tests {
+		if got := defaultConnectionName(server); got != want {
+			t.Errorf("defaultConnectionName(%q) = %q, want %q", server, got, want)
+		}
+	}
+}
+
+func TestFreeSlot(t *testing.T) {
+	s := &Server{connections: map[string]*VPNConnection{
+		"a": {Slot: 0},
+		"b": {Slot: 2},
+	}}
+
+	if slot := s.freeSlot(); slot != 1 {
+		t.Errorf("freeSlot() = %d, want 1", slot)
+	}
+}
+
+func TestIsSlotInterface(t *testing.T) {
+	for slot := 0; slot < 4; slot++ {
+		if name := slotInterfaceName(slot); !isSlotInterface(name) {
+			t.Errorf("isSlotInterface(%q) = false for slot %d", name, slot)
+		}
+	}
+	for _, name := range []string{"eth0", "wlan0", "en0", "Ethernet 2"} {
+		if isSlotInterface(name) {
+			t.Errorf("isSlotInterface(%q) = true", name)
+		}
+	}
+}
+
+func TestCIDRsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "10.17.0.0/24", b: "10.17.1.0/24", want: false},
+		{a: "10.17.0.0/24", b: "10.17.0.0/24", want: true},
+		{a: "10.17.0.0/16", b: "10.17.3.0/24", want: true},
+		{a: "10.17.3.0/24", b: "10.17.0.0/16", want: true},
+		{a: "invalid", b: "10.17.0.0/24", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := cidrsOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("cidrsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
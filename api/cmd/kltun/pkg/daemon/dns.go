@@ -0,0 +1,225 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/hosts"
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/resolver"
+)
+
+// sessionDNS resolves the hosts of a VPN connection with a split-DNS resolver bound to the tunnel
+// interface, instead of writing them to /etc/hosts
+//
+// The resolver answers the Kloudlite domain (and wildcard hosts) from the hosts polled from the
+// tunnel server, asks the tunnel server for the names it does not know, and forwards everything
+// else to the upstream servers of the system. The system resolver routes the Kloudlite domain to it
+// through systemd-resolved or resolvconf.
+type sessionDNS struct {
+	mu          sync.Mutex
+	sessionID   string
+	zone        string
+	zoneServer  string            // DNS server of the tunnel server, on its address in the tunnel subnet
+	static      map[string]string // hosts of the session itself (vpn-connect, vpn-check)
+	hosts       map[string]string // hosts polled from the tunnel server
+	resolver    *resolver.Resolver
+	integration resolver.Integration
+	domains     []string
+}
+
+// tunnelZone returns the Kloudlite domain of a tunnel endpoint hostname (vpn-connect.{subdomain}.{domain})
+func tunnelZone(tunnelHostname string) string {
+	_, zone, found := strings.Cut(tunnelHostname, ".")
+	if !found {
+		return ""
+	}
+	return zone
+}
+
+// newSessionDNS creates the split-DNS state of a session, started with start
+func newSessionDNS(sessionID, zone, zoneServer string, static map[string]string) *sessionDNS {
+	return &sessionDNS{
+		sessionID:  sessionID,
+		zone:       zone,
+		zoneServer: zoneServer,
+		static:     static,
+		hosts:      make(map[string]string),
+	}
+}
+
+// start starts the resolver on the tunnel interface and registers it with the system resolver
+//
+// resolver.ErrNoIntegration is returned when the system resolver cannot be configured, the hosts
+// must then be written to /etc/hosts.
+func (d *sessionDNS) start(interfaceName, vpnIP string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.zone == "" {
+		return resolver.ErrNoIntegration
+	}
+
+	integration, err := resolver.Detect(interfaceName, vpnIP)
+	if err != nil {
+		return err
+	}
+
+	upstreams := resolver.SystemUpstreams(vpnIP)
+	if len(upstreams) == 0 {
+		upstreams = []string{d.zoneServer}
+	}
+
+	r := resolver.New(resolver.Config{
+		ListenAddr: net.JoinHostPort(vpnIP, "53"),
+		Zone:       d.zone,
+		ZoneServer: d.zoneServer,
+		Upstreams:  upstreams,
+	})
+	r.SetHosts(d.merged())
+	if err := r.Start(); err != nil {
+		return fmt.Errorf("failed to start resolver: %w", err)
+	}
+
+	domains := r.Domains()
+	if err := integration.Apply(domains); err != nil {
+		r.Stop()
+		return fmt.Errorf("failed to register resolver with %s: %w", integration.Name(), err)
+	}
+
+	d.resolver = r
+	d.integration = integration
+	d.domains = domains
+	fmt.Printf("[Session %s] ✓ Split DNS for %s via %s (resolver %s)\n", d.sessionID, d.zone, integration.Name(), r.ListenAddr())
+	return nil
+}
+
+// stop unregisters the resolver from the system resolver and stops it
+func (d *sessionDNS) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.integration != nil {
+		if err := d.integration.Revert(); err != nil {
+			fmt.Printf("[Session %s] Warning: Failed to revert DNS configuration: %v\n", d.sessionID, err)
+		}
+		d.integration = nil
+	}
+	if d.resolver != nil {
+		if err := d.resolver.Stop(); err != nil {
+			fmt.Printf("[Session %s] Warning: Failed to stop resolver: %v\n", d.sessionID, err)
+		}
+		d.resolver = nil
+	}
+	d.domains = nil
+}
+
+// update replaces the hosts polled from the tunnel server, and the routing domains when hosts
+// outside of the Kloudlite domain were added or removed
+func (d *sessionDNS) update(polled map[string]string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.hosts = make(map[string]string, len(polled))
+	for hostname, ip := range polled {
+		d.hosts[hostname] = ip
+	}
+	if d.resolver == nil {
+		return nil
+	}
+
+	d.resolver.SetHosts(d.merged())
+	domains := d.resolver.Domains()
+	if slices.Equal(domains, d.domains) {
+		return nil
+	}
+	if err := d.integration.Apply(domains); err != nil {
+		return fmt.Errorf("failed to update routing domains: %w", err)
+	}
+	d.domains = domains
+	return nil
+}
+
+// setZoneServer changes the DNS server of the tunnel server (the tunnel subnet changed on
+// reconnection), used on the next start
+func (d *sessionDNS) setZoneServer(zoneServer string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.zoneServer = zoneServer
+}
+
+// setStatic sets a host of the session itself (e.g., vpn-connect once the WorkMachine has a new IP)
+func (d *sessionDNS) setStatic(hostname, ip string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.static[hostname] = ip
+	if d.resolver != nil {
+		d.resolver.SetHosts(d.merged())
+	}
+}
+
+// entries returns the hosts answered by the resolver, for port forwards
+func (d *sessionDNS) entries() []hosts.Entry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	merged := d.merged()
+	entries := make([]hosts.Entry, 0, len(merged))
+	for hostname, ip := range merged {
+		entries = append(entries, hosts.Entry{IP: ip, Hostname: hostname, Comment: fmt.Sprintf("# kltun session %s", d.sessionID)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Hostname < entries[j].Hostname })
+	return entries
+}
+
+// merged returns the polled and static hosts, static ones first; must be called with d.mu held
+func (d *sessionDNS) merged() map[string]string {
+	merged := make(map[string]string, len(d.hosts)+len(d.static))
+	for hostname, ip := range d.hosts {
+		merged[hostname] = ip
+	}
+	for hostname, ip := range d.static {
+		merged[hostname] = ip
+	}
+	return merged
+}
+
+// startSessionDNS starts split DNS for a connection, which falls back to /etc/hosts when it cannot
+func (s *Server) startSessionDNS(conn *VPNConnection, dns *sessionDNS, interfaceName, vpnIP string) {
+	if err := dns.start(interfaceName, vpnIP); err != nil {
+		if errors.Is(err, resolver.ErrNoIntegration) {
+			fmt.Printf("[Session %s] No system resolver integration, using /etc/hosts\n", conn.SessionID)
+		} else {
+			fmt.Printf("[Session %s] Warning: Split DNS unavailable, using /etc/hosts: %v\n", conn.SessionID, err)
+		}
+		conn.setDNS(nil)
+
+		// The hosts of the session itself are then written to /etc/hosts as well
+		for hostname, ip := range dns.static {
+			if err := s.hostsManager.Add(hostname, ip, fmt.Sprintf("# kltun session %s", conn.SessionID)); err != nil {
+				fmt.Printf("[Session %s] Warning: Failed to add host %s: %v\n", conn.SessionID, hostname, err)
+			}
+		}
+		return
+	}
+	conn.setDNS(dns)
+}
+
+// resolverHosts returns the hosts answered by the split-DNS resolvers of the connections
+func (s *Server) resolverHosts() []hosts.Entry {
+	s.connMutex.RLock()
+	defer s.connMutex.RUnlock()
+
+	var entries []hosts.Entry
+	for _, conn := range s.connections {
+		if dns := conn.getDNS(); dns != nil {
+			entries = append(entries, dns.entries()...)
+		}
+	}
+	return entries
+}
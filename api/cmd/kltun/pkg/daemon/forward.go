@@ -0,0 +1,427 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/hosts"
+)
+
+const forwardDialTimeout = 10 * time.Second
+
+// Backoff of the binds of restored forwards whose local port is taken (e.g., by a previous daemon
+// still shutting down)
+var (
+	forwardRetryInitial = 1 * time.Second
+	forwardRetryMax     = 1 * time.Minute
+)
+
+// forwardSpec is the persisted form of a port forward
+type forwardSpec struct {
+	Target    string `json:"target"`
+	Port      int    `json:"port"`
+	LocalPort int    `json:"local_port"`
+}
+
+// portForward is a local listener forwarding connections to a target over the VPN
+type portForward struct {
+	spec        forwardSpec
+	listener    net.Listener
+	connections atomic.Int64
+
+	mu       sync.Mutex
+	hostname string
+	ip       string
+	lastErr  string
+	conns    map[net.Conn]struct{} // Local and remote connections, closed with the forward
+	stopped  bool
+	stop     chan struct{} // Closed when the forward is removed, ends bind retries
+}
+
+// newPortForward creates a forward that is not listening yet
+func newPortForward(spec forwardSpec) *portForward {
+	return &portForward{spec: spec, conns: make(map[net.Conn]struct{}), stop: make(chan struct{})}
+}
+
+// ForwardManager manages local port forwards to workspaces and environment services
+// Targets are resolved through the kltun-managed hosts entries on every new connection,
+// so forwards keep working when the VPN reconnects and service IPs change.
+type ForwardManager struct {
+	mu           sync.Mutex
+	forwards     map[int]*portForward // keyed by local port
+	statePath    string
+	hostsManager hosts.Manager
+	extraHosts   func() []hosts.Entry // hosts resolved without /etc/hosts (split DNS), optional
+}
+
+// NewForwardManager creates a forward manager persisting its forwards at statePath
+func NewForwardManager(statePath string, hostsManager hosts.Manager) *ForwardManager {
+	return &ForwardManager{
+		forwards:     make(map[int]*portForward),
+		statePath:    statePath,
+		hostsManager: hostsManager,
+	}
+}
+
+// Add starts forwarding 127.0.0.1:localPort to target:port and persists the forward
+func (m *ForwardManager) Add(target string, port, localPort int) (ForwardEntry, error) {
+	if localPort == 0 {
+		localPort = port
+	}
+	if err := validateForwardPort(port); err != nil {
+		return ForwardEntry{}, err
+	}
+	if err := validateForwardPort(localPort); err != nil {
+		return ForwardEntry{}, err
+	}
+
+	// Resolve now so typos are reported right away
+	hostname, ip, err := m.resolve(target)
+	if err != nil {
+		return ForwardEntry{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.forwards[localPort]; ok {
+		return ForwardEntry{}, fmt.Errorf("local port %d is already forwarded to %s:%d", localPort, existing.spec.Target, existing.spec.Port)
+	}
+
+	fwd := newPortForward(forwardSpec{Target: target, Port: port, LocalPort: localPort})
+	fwd.hostname, fwd.ip = hostname, ip
+	if err := m.start(fwd); err != nil {
+		return ForwardEntry{}, err
+	}
+	m.forwards[localPort] = fwd
+
+	if err := m.save(); err != nil {
+		fmt.Printf("[Forward] Warning: Failed to persist forwards: %v\n", err)
+	}
+	return fwd.entry(), nil
+}
+
+// Remove stops the forward on localPort and closes its open connections
+func (m *ForwardManager) Remove(localPort int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fwd, ok := m.forwards[localPort]
+	if !ok {
+		return fmt.Errorf("no forward on local port %d", localPort)
+	}
+	fwd.close()
+	delete(m.forwards, localPort)
+
+	return m.save()
+}
+
+// List returns all forwards sorted by local port
+func (m *ForwardManager) List() []ForwardEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := make([]ForwardEntry, 0, len(m.forwards))
+	for _, fwd := range m.forwards {
+		entries = append(entries, fwd.entry())
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LocalPort < entries[j].LocalPort })
+	return entries
+}
+
+// Restore starts the forwards persisted by a previous daemon run
+// Forwards whose local port cannot be bound are kept, reported in List and bound again with backoff
+func (m *ForwardManager) Restore() error {
+	data, err := os.ReadFile(m.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read forwards: %w", err)
+	}
+
+	var specs []forwardSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return fmt.Errorf("failed to parse forwards: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, spec := range specs {
+		fwd := newPortForward(spec)
+		if err := m.start(fwd); err != nil {
+			fmt.Printf("[Forward %d] Warning: Failed to restore forward, retrying: %v\n", spec.LocalPort, err)
+			fwd.setError(err)
+			go m.retry(fwd)
+		}
+		m.forwards[spec.LocalPort] = fwd
+	}
+
+	fmt.Printf("[Forward] Restored %d port forwards\n", len(specs))
+	return nil
+}
+
+// Close stops all listeners and connections, keeping the persisted forwards for the next daemon run
+func (m *ForwardManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, fwd := range m.forwards {
+		fwd.close()
+	}
+}
+
+// retry binds the listener of a forward with backoff, until it succeeds or the forward is removed
+func (m *ForwardManager) retry(fwd *portForward) {
+	delay := forwardRetryInitial
+	for {
+		select {
+		case <-fwd.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		err := m.start(fwd)
+		if err == nil {
+			fwd.mu.Lock()
+			fwd.lastErr = ""
+			fwd.mu.Unlock()
+			return
+		}
+		fwd.setError(err)
+		delay = min(2*delay, forwardRetryMax)
+	}
+}
+
+// start binds the local listener and serves connections in the background
+func (m *ForwardManager) start(fwd *portForward) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(fwd.spec.LocalPort)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on local port %d: %w", fwd.spec.LocalPort, err)
+	}
+
+	fwd.mu.Lock()
+	if fwd.stopped {
+		// Removed while the bind was retried
+		fwd.mu.Unlock()
+		listener.Close()
+		return nil
+	}
+	fwd.listener = listener
+	fwd.mu.Unlock()
+
+	fmt.Printf("[Forward %d] Forwarding 127.0.0.1:%d -> %s:%d\n", fwd.spec.LocalPort, fwd.spec.LocalPort, fwd.spec.Target, fwd.spec.Port)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				// Listener closed by Remove or Close
+				return
+			}
+			go m.serve(fwd, conn)
+		}
+	}()
+	return nil
+}
+
+// serve pipes a local connection to the forward target
+func (m *ForwardManager) serve(fwd *portForward, local net.Conn) {
+	defer local.Close()
+	if !fwd.track(local) {
+		return
+	}
+	defer fwd.untrack(local)
+
+	hostname, ip, err := m.resolve(fwd.spec.Target)
+	if err != nil {
+		fwd.setError(err)
+		fmt.Printf("[Forward %d] %v\n", fwd.spec.LocalPort, err)
+		return
+	}
+
+	remote, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(fwd.spec.Port)), forwardDialTimeout)
+	if err != nil {
+		fwd.setError(fmt.Errorf("failed to connect to %s:%d: %w", hostname, fwd.spec.Port, err))
+		fmt.Printf("[Forward %d] Failed to connect to %s:%d: %v\n", fwd.spec.LocalPort, hostname, fwd.spec.Port, err)
+		return
+	}
+	defer remote.Close()
+	if !fwd.track(remote) {
+		return
+	}
+	defer fwd.untrack(remote)
+
+	fwd.mu.Lock()
+	fwd.hostname, fwd.ip, fwd.lastErr = hostname, ip, ""
+	fwd.mu.Unlock()
+
+	fwd.connections.Add(1)
+	defer fwd.connections.Add(-1)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		if tcp, ok := remote.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		if tcp, ok := local.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+}
+
+// resolve looks up a forward target in the kltun-managed hosts entries
+func (m *ForwardManager) resolve(target string) (string, string, error) {
+	if ip := net.ParseIP(target); ip != nil {
+		return target, target, nil
+	}
+	entries, err := m.hostsManager.List()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read hosts entries: %w", err)
+	}
+	if m.extraHosts != nil {
+		entries = append(entries, m.extraHosts()...)
+	}
+	return resolveForwardTarget(entries, target)
+}
+
+// save persists the forward specs; must be called with m.mu held
+func (m *ForwardManager) save() error {
+	specs := make([]forwardSpec, 0, len(m.forwards))
+	for _, fwd := range m.forwards {
+		specs = append(specs, fwd.spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].LocalPort < specs[j].LocalPort })
+
+	data, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.statePath), 0o755); err != nil {
+		return err
+	}
+
+	tmpFile := m.statePath + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, m.statePath)
+}
+
+// track registers an open connection of the forward, false once the forward is removed
+func (f *portForward) track(conn net.Conn) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stopped {
+		return false
+	}
+	f.conns[conn] = struct{}{}
+	return true
+}
+
+func (f *portForward) untrack(conn net.Conn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.conns, conn)
+}
+
+// close stops the listener, bind retries and the open connections of the forward
+func (f *portForward) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stopped {
+		return
+	}
+	f.stopped = true
+	close(f.stop)
+	if f.listener != nil {
+		f.listener.Close()
+	}
+	for conn := range f.conns {
+		conn.Close()
+	}
+}
+
+func (f *portForward) setError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastErr = err.Error()
+}
+
+func (f *portForward) entry() ForwardEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return ForwardEntry{
+		Target:      f.spec.Target,
+		Port:        f.spec.Port,
+		LocalPort:   f.spec.LocalPort,
+		Hostname:    f.hostname,
+		IP:          f.ip,
+		Listening:   f.listener != nil,
+		Connections: f.connections.Load(),
+		Error:       f.lastErr,
+	}
+}
+
+// hostHashSuffix matches the "-{hash}" suffix of workspace and service hostnames
+// Hostnames are {name}-{hash}.{subdomain}.{domain}
+var hostHashSuffix = regexp.MustCompile(`-[0-9a-f]{8}$`)
+
+// resolveForwardTarget finds the hosts entry for a target given either as a full hostname
+// or as the bare workspace / environment service name
+func resolveForwardTarget(entries []hosts.Entry, target string) (string, string, error) {
+	target = strings.ToLower(strings.TrimSuffix(target, "."))
+
+	var matches []hosts.Entry
+	for _, e := range entries {
+		hostname := strings.ToLower(e.Hostname)
+		if hostname == target {
+			return e.Hostname, e.IP, nil
+		}
+		label, _, _ := strings.Cut(hostname, ".")
+		if loc := hostHashSuffix.FindStringIndex(label); loc != nil && label[:loc[0]] == target {
+			matches = append(matches, e)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", "", fmt.Errorf("no workspace or environment service named %q (is kltun connected?)", target)
+	case 1:
+		return matches[0].Hostname, matches[0].IP, nil
+	default:
+		names := make([]string, 0, len(matches))
+		for _, e := range matches {
+			names = append(names, e.Hostname)
+		}
+		sort.Strings(names)
+		return "", "", fmt.Errorf("%q matches several hosts, use one of: %s", target, strings.Join(names, ", "))
+	}
+}
+
+func validateForwardPort(port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("invalid port %d", port)
+	}
+	return nil
+}
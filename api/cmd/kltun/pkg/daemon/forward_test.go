@@ -0,0 +1,173 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/hosts"
+)
+
+func TestResolveForwardTarget(t *testing.T) {
+	entries := []hosts.Entry{
+		{IP: "10.43.0.10", Hostname: "api-1a2b3c4d.alice.khost.dev"},
+		{IP: "10.43.0.20", Hostname: "postgres-0f0f0f0f.alice.khost.dev"},
+		{IP: "10.43.0.21", Hostname: "redis-11111111.alice.khost.dev"},
+		{IP: "10.43.0.22", Hostname: "redis-22222222.alice.khost.dev"},
+		{IP: "10.43.0.30", Hostname: "api-gateway-abcdef01.alice.khost.dev"},
+	}
+
+	tests := []struct {
+		target  string
+		wantIP  string
+		wantErr string
+	}{
+		{target: "api", wantIP: "10.43.0.10"},
+		{target: "API", wantIP: "10.43.0.10"},
+		{target: "api-gateway", wantIP: "10.43.0.30"},
+		{target: "postgres-0f0f0f0f.alice.khost.dev", wantIP: "10.43.0.20"},
+		{target: "redis", wantErr: "matches several hosts"},
+		{target: "mysql", wantErr: "no workspace or environment service"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			_, ip, err := resolveForwardTarget(entries, tt.target)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ip != tt.wantIP {
+				t.Errorf("got IP %s, want %s", ip, tt.wantIP)
+			}
+		})
+	}
+}
+
+// startEchoServer starts a TCP server echoing lines back and returns its port
+func startEchoServer(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// freeTCPPort returns a local port nothing listens on
+func freeTCPPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestForwardRemoveClosesConnections(t *testing.T) {
+	port := startEchoServer(t)
+	m := NewForwardManager(filepath.Join(t.TempDir(), "forwards.json"), nil)
+	defer m.Close()
+
+	entry, err := m.Add("127.0.0.1", port, freeTCPPort(t))
+	if err != nil {
+		t.Fatalf("failed to add forward: %v", err)
+	}
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", entry.LocalPort))
+	if err != nil {
+		t.Fatalf("failed to dial forward: %v", err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprintln(conn, "ping")
+	if line, err := r.ReadString('\n'); err != nil || line != "ping\n" {
+		t.Fatalf("echo = %q, %v", line, err)
+	}
+
+	if err := m.Remove(entry.LocalPort); err != nil {
+		t.Fatalf("failed to remove forward: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := r.ReadString('\n'); err != io.EOF {
+		t.Errorf("read after remove = %v, want EOF", err)
+	}
+}
+
+func TestForwardRestoreRetriesBind(t *testing.T) {
+	forwardRetryInitial, forwardRetryMax = 10*time.Millisecond, 50*time.Millisecond
+	defer func() { forwardRetryInitial, forwardRetryMax = 1*time.Second, 1*time.Minute }()
+
+	port := startEchoServer(t)
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	localPort := busy.Addr().(*net.TCPAddr).Port
+
+	statePath := filepath.Join(t.TempDir(), "forwards.json")
+	data, _ := json.Marshal([]forwardSpec{{Target: "127.0.0.1", Port: port, LocalPort: localPort}})
+	if err := os.WriteFile(statePath, data, 0o644); err != nil {
+		t.Fatalf("failed to write forwards: %v", err)
+	}
+
+	m := NewForwardManager(statePath, nil)
+	defer m.Close()
+	if err := m.Restore(); err != nil {
+		t.Fatalf("failed to restore forwards: %v", err)
+	}
+	if entries := m.List(); len(entries) != 1 || entries[0].Listening || entries[0].Error == "" {
+		t.Fatalf("entries while the port is taken = %+v, want one failed forward", entries)
+	}
+
+	busy.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entries := m.List()
+		if entries[0].Listening && entries[0].Error == "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("forward not bound after the port was freed: %+v", entries[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	if err != nil {
+		t.Fatalf("failed to dial forward: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprintln(conn, "ping")
+	if line, err := bufio.NewReader(conn).ReadString('\n'); err != nil || line != "ping\n" {
+		t.Errorf("echo = %q, %v", line, err)
+	}
+}
@@ -0,0 +1,148 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/api"
+)
+
+// handleDevicesList handles listing the devices registered on the tunnel server of a connection
+func (s *Server) handleDevicesList(req *Request) *Response {
+	var params DevicesListParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return NewErrorResponse(req.ID, ErrCodeInvalidParams, "Invalid parameters", err.Error())
+		}
+	}
+
+	conn, err := s.selectConnection(params.Name)
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternal, "No connection", err.Error())
+	}
+
+	peers, err := api.NewTunnelClient(conn.TunnelEndpoint, conn.PermanentToken).ListPeers()
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternal, "Failed to list devices", err.Error())
+	}
+
+	result := DevicesListResult{
+		Name:               conn.Name,
+		Devices:            deviceEntries(peers.Peers, conn),
+		IdleTimeoutSeconds: peers.IdleTimeoutSeconds,
+		MaxDevices:         peers.MaxPeersPerUser,
+	}
+	resp, _ := NewSuccessResponse(req.ID, result)
+	return resp
+}
+
+// handleDevicesRevoke handles revoking a device registered on the tunnel server of a connection
+func (s *Server) handleDevicesRevoke(req *Request) *Response {
+	var params DevicesRevokeParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInvalidParams, "Invalid parameters", err.Error())
+	}
+
+	conn, err := s.selectConnection(params.Name)
+	if err != nil {
+		result := DevicesRevokeResult{Success: false, Message: err.Error()}
+		resp, _ := NewSuccessResponse(req.ID, result)
+		return resp
+	}
+
+	tunnelClient := api.NewTunnelClient(conn.TunnelEndpoint, conn.PermanentToken)
+	peers, err := tunnelClient.ListPeers()
+	if err != nil {
+		result := DevicesRevokeResult{Success: false, Message: fmt.Sprintf("failed to list devices: %v", err)}
+		resp, _ := NewSuccessResponse(req.ID, result)
+		return resp
+	}
+
+	device, err := resolveDevice(deviceEntries(peers.Peers, conn), params.Target)
+	if err == nil && device.Current {
+		err = fmt.Errorf("%s is the device of this connection, use 'kltun quit --name %s' to disconnect it", device.DeviceName, conn.Name)
+	}
+	if err != nil {
+		result := DevicesRevokeResult{Success: false, Message: err.Error()}
+		resp, _ := NewSuccessResponse(req.ID, result)
+		return resp
+	}
+
+	if err := tunnelClient.DeletePeer(device.PublicKey); err != nil {
+		result := DevicesRevokeResult{Success: false, Message: fmt.Sprintf("failed to revoke device: %v", err)}
+		resp, _ := NewSuccessResponse(req.ID, result)
+		return resp
+	}
+
+	fmt.Printf("[Session %s] Revoked device %s (%s)\n", conn.SessionID, device.DeviceName, device.PublicKey)
+	result := DevicesRevokeResult{Success: true, Message: "Device revoked successfully", Device: device}
+	resp, _ := NewSuccessResponse(req.ID, result)
+	return resp
+}
+
+// selectConnection returns the connection with the given name, or the only connection when name is empty
+func (s *Server) selectConnection(name string) (*VPNConnection, error) {
+	if name != "" {
+		if conn := s.connectionByName(name); conn != nil {
+			return conn, nil
+		}
+		return nil, fmt.Errorf("no VPN connection named %q", name)
+	}
+
+	conns := s.sortedConnections()
+	switch len(conns) {
+	case 0:
+		return nil, fmt.Errorf("no active VPN connection")
+	case 1:
+		return conns[0], nil
+	default:
+		return nil, fmt.Errorf("several VPN connections are active, specify a name")
+	}
+}
+
+// deviceEntries converts the peers of a tunnel server, marking the one of conn
+func deviceEntries(peers []api.PeerStatus, conn *VPNConnection) []DeviceEntry {
+	devices := make([]DeviceEntry, 0, len(peers))
+	for _, peer := range peers {
+		devices = append(devices, DeviceEntry{
+			PublicKey:     peer.PublicKey,
+			DeviceName:    peer.DeviceName,
+			Owner:         peer.Owner,
+			IP:            peer.IP,
+			Endpoint:      peer.Endpoint,
+			CreatedAt:     peer.CreatedAt,
+			LastHandshake: peer.LastHandshake,
+			RxBytes:       peer.RxBytes,
+			TxBytes:       peer.TxBytes,
+			Current:       conn.KeyPair != nil && peer.PublicKey == conn.KeyPair.PublicKey,
+		})
+	}
+	return devices
+}
+
+// resolveDevice finds a device by public key, public key prefix or device name
+func resolveDevice(devices []DeviceEntry, target string) (DeviceEntry, error) {
+	if target == "" {
+		return DeviceEntry{}, fmt.Errorf("device is required")
+	}
+
+	var matches []DeviceEntry
+	for _, device := range devices {
+		if device.PublicKey == target {
+			return device, nil
+		}
+		if strings.HasPrefix(device.PublicKey, target) || strings.EqualFold(device.DeviceName, target) {
+			matches = append(matches, device)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return DeviceEntry{}, fmt.Errorf("no device matches %q", target)
+	case 1:
+		return matches[0], nil
+	default:
+		return DeviceEntry{}, fmt.Errorf("%q matches several devices, use a longer public key prefix", target)
+	}
+}
@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveDevice(t *testing.T) {
+	devices := []DeviceEntry{
+		{PublicKey: "aGVsbG8gd29ybGQ=", DeviceName: "laptop"},
+		{PublicKey: "aGVsbG8gdGhlcmU=", DeviceName: "desktop"},
+		{PublicKey: "Ym9uam91cg==", DeviceName: "old-laptop"},
+	}
+
+	tests := []struct {
+		target  string
+		wantKey string
+		wantErr string
+	}{
+		{target: "laptop", wantKey: "aGVsbG8gd29ybGQ="},
+		{target: "OLD-LAPTOP", wantKey: "Ym9uam91cg=="},
+		{target: "aGVsbG8gdG", wantKey: "aGVsbG8gdGhlcmU="},
+		{target: "Ym9uam91cg==", wantKey: "Ym9uam91cg=="},
+		{target: "aGVsbG8g", wantErr: "matches several devices"},
+		{target: "tablet", wantErr: "no device matches"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			device, err := resolveDevice(devices, tt.target)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if device.PublicKey != tt.wantKey {
+				t.Errorf("resolveDevice(%q) = %s, want %s", tt.target, device.PublicKey, tt.wantKey)
+			}
+		})
+	}
+}
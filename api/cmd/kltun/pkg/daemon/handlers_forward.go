@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// handleForwardAdd handles adding a port forward
+func (s *Server) handleForwardAdd(req *Request) *Response {
+	var params ForwardAddParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInvalidParams, "Invalid parameters", err.Error())
+	}
+
+	entry, err := s.forwards.Add(params.Target, params.Port, params.LocalPort)
+	if err != nil {
+		result := ForwardAddResult{Success: false, Message: err.Error()}
+		resp, _ := NewSuccessResponse(req.ID, result)
+		return resp
+	}
+
+	result := ForwardAddResult{
+		Success: true,
+		Message: fmt.Sprintf("Forwarding 127.0.0.1:%d to %s:%d", entry.LocalPort, entry.Hostname, entry.Port),
+		Forward: entry,
+	}
+	resp, _ := NewSuccessResponse(req.ID, result)
+	return resp
+}
+
+// handleForwardRemove handles removing a port forward
+func (s *Server) handleForwardRemove(req *Request) *Response {
+	var params ForwardRemoveParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInvalidParams, "Invalid parameters", err.Error())
+	}
+
+	if err := s.forwards.Remove(params.LocalPort); err != nil {
+		result := ForwardRemoveResult{Success: false, Message: err.Error()}
+		resp, _ := NewSuccessResponse(req.ID, result)
+		return resp
+	}
+
+	result := ForwardRemoveResult{Success: true, Message: "Port forward removed successfully"}
+	resp, _ := NewSuccessResponse(req.ID, result)
+	return resp
+}
+
+// handleForwardList handles listing all port forwards
+func (s *Server) handleForwardList(req *Request) *Response {
+	result := ForwardListResult{Forwards: s.forwards.List()}
+	resp, _ := NewSuccessResponse(req.ID, result)
+	return resp
+}
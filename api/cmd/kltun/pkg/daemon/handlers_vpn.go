@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/kloudlite/kloudlite/api/pkg/udptunnel/tunnel"
 )
 
 // handleVPNConnect handles VPN connection request
@@ -25,24 +28,39 @@ func (s *Server) handleVPNConnect(req *Request) *Response {
 		return resp
 	}
 
+	name := params.Name
+	if name == "" {
+		name = defaultConnectionName(server)
+	}
+
+	transportMode, err := tunnel.ParseTransportMode(params.Transport)
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInvalidParams, "Invalid parameters", err.Error())
+	}
+
 	// Generate session ID
-	sessionID := fmt.Sprintf("conn-%d", time.Now().Unix())
+	sessionID := fmt.Sprintf("conn-%s-%d", name, time.Now().Unix())
 
 	// Create context for this connection
 	ctx, cancel := context.WithCancel(context.Background())
 
 	conn := &VPNConnection{
 		SessionID:  sessionID,
+		Name:       name,
 		Server:     server,
+		Transport:  transportMode,
 		StartTime:  time.Now(),
 		CancelFunc: cancel,
 		DoneChan:   make(chan struct{}),
 	}
 
 	s.connMutex.Lock()
-	// Disconnect all existing connections before starting new one
+	// Replace the connection of the same name, the others keep running
 	for existingSessionID, existingConn := range s.connections {
-		fmt.Printf("Disconnecting existing connection: %s\n", existingSessionID)
+		if existingConn.Name != name {
+			continue
+		}
+		fmt.Printf("Disconnecting existing connection %s: %s\n", name, existingSessionID)
 		existingConn.CancelFunc()
 		// Release lock temporarily to avoid deadlock while waiting
 		s.connMutex.Unlock()
@@ -56,18 +74,22 @@ func (s *Server) handleVPNConnect(req *Request) *Response {
 		s.connMutex.Lock()
 		delete(s.connections, existingSessionID)
 	}
+	others := len(s.connections)
 	s.connMutex.Unlock()
 
-	// Flush all state before starting new connection
-	fmt.Printf("Flushing all daemon state before establishing new connection\n")
-	if err := s.hostsManager.Clean(); err != nil {
-		fmt.Printf("Warning: Failed to clean hosts: %v\n", err)
-	} else {
-		fmt.Printf("Successfully flushed all hosts entries\n")
+	// Flush stale hosts entries (e.g., of a crashed daemon) unless other connections own them
+	if others == 0 {
+		fmt.Printf("Flushing all daemon state before establishing new connection\n")
+		if err := s.hostsManager.Clean(); err != nil {
+			fmt.Printf("Warning: Failed to clean hosts: %v\n", err)
+		} else {
+			fmt.Printf("Successfully flushed all hosts entries\n")
+		}
 	}
 
-	// Add the new connection
+	// Add the new connection, in the first free slot
 	s.connMutex.Lock()
+	conn.Slot = s.freeSlot()
 	s.connections[sessionID] = conn
 	s.connMutex.Unlock()
 
@@ -97,6 +119,7 @@ func (s *Server) handleVPNConnect(req *Request) *Response {
 		Success:        true,
 		Message:        "VPN connection established successfully",
 		SessionID:      sessionID,
+		Name:           name,
 		TunnelEndpoint: setupResult.TunnelEndpoint,
 		PermanentToken: setupResult.PermanentToken,
 	}
@@ -106,23 +129,56 @@ func (s *Server) handleVPNConnect(req *Request) *Response {
 
 // handleVPNQuit handles VPN disconnection request
 func (s *Server) handleVPNQuit(req *Request) *Response {
-	// Find the active connection (we only support one connection at a time for now)
-	s.connMutex.Lock()
-	var sessionID string
-	var conn *VPNConnection
-	for id, c := range s.connections {
-		sessionID = id
-		conn = c
-		break
+	var params VPNQuitParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return NewErrorResponse(req.ID, ErrCodeInvalidParams, "Invalid parameters", err.Error())
+		}
 	}
-	s.connMutex.Unlock()
 
-	if conn == nil {
+	// Select the connections to stop
+	conns := s.sortedConnections()
+	switch {
+	case params.All:
+	case params.Name != "":
+		conn := s.connectionByName(params.Name)
+		if conn == nil {
+			result := VPNQuitResult{Success: false, Message: fmt.Sprintf("No VPN connection named %q", params.Name)}
+			resp, _ := NewSuccessResponse(req.ID, result)
+			return resp
+		}
+		conns = []*VPNConnection{conn}
+	case len(conns) > 1:
+		names := make([]string, 0, len(conns))
+		for _, conn := range conns {
+			names = append(names, conn.Name)
+		}
+		result := VPNQuitResult{Success: false, Message: fmt.Sprintf("Several VPN connections are active (%s), specify a name or all", strings.Join(names, ", "))}
+		resp, _ := NewSuccessResponse(req.ID, result)
+		return resp
+	}
+
+	if len(conns) == 0 {
 		result := VPNQuitResult{Success: false, Message: "No active VPN connection"}
 		resp, _ := NewSuccessResponse(req.ID, result)
 		return resp
 	}
 
+	for _, conn := range conns {
+		s.stopConnection(conn)
+	}
+
+	message := "VPN connection stopped successfully"
+	if len(conns) > 1 {
+		message = fmt.Sprintf("%d VPN connections stopped successfully", len(conns))
+	}
+	result := VPNQuitResult{Success: true, Message: message}
+	resp, _ := NewSuccessResponse(req.ID, result)
+	return resp
+}
+
+// stopConnection cancels a connection, waits for its cleanup and removes it
+func (s *Server) stopConnection(conn *VPNConnection) {
 	// Cancel the connection
 	if conn.CancelFunc != nil {
 		conn.CancelFunc()
@@ -131,26 +187,24 @@ func (s *Server) handleVPNQuit(req *Request) *Response {
 	// Wait for cleanup to complete with timeout
 	select {
 	case <-conn.DoneChan:
-		fmt.Printf("Connection %s cleaned up successfully\n", sessionID)
+		fmt.Printf("Connection %s cleaned up successfully\n", conn.SessionID)
 	case <-time.After(10 * time.Second):
-		fmt.Printf("Warning: Timeout waiting for connection %s cleanup\n", sessionID)
+		fmt.Printf("Warning: Timeout waiting for connection %s cleanup\n", conn.SessionID)
 	}
 
 	// Remove from connections map
 	s.connMutex.Lock()
-	delete(s.connections, sessionID)
+	delete(s.connections, conn.SessionID)
 	s.connMutex.Unlock()
-
-	result := VPNQuitResult{Success: true, Message: "VPN connection stopped successfully"}
-	resp, _ := NewSuccessResponse(req.ID, result)
-	return resp
 }
 
-// handleStatus handles status request
+// handleStatus handles status request, connections ordered by name
 func (s *Server) handleStatus(req *Request) *Response {
+	conns := s.sortedConnections()
+
 	s.connMutex.RLock()
 	var connStatuses []ConnectionStatus
-	for _, conn := range s.connections {
+	for _, conn := range conns {
 		state := conn.GetState()
 		isConnected := state == StateConnected
 
@@ -158,6 +212,9 @@ func (s *Server) handleStatus(req *Request) *Response {
 		switch state {
 		case StateReconnecting:
 			message = "Connection lost, attempting to reconnect..."
+			if reason := conn.GetStateReason(); reason != "" {
+				message = fmt.Sprintf("Reconnecting (%s)...", reason)
+			}
 		case StateDisconnected:
 			message = "Disconnected"
 		case StateConnected:
@@ -166,13 +223,29 @@ func (s *Server) handleStatus(req *Request) *Response {
 			message = "Unknown state"
 		}
 
+		// A tunnel refused by the server does not recover by reconnecting, tell why
+		var tunnelError string
+		if rejected := conn.tunnelRejection(); rejected != nil {
+			tunnelError = rejected.Error()
+			message = fmt.Sprintf("%s, %s", message, tunnelError)
+		}
+
 		connStatuses = append(connStatuses, ConnectionStatus{
 			SessionID: conn.SessionID,
+			Name:      conn.Name,
 			Server:    conn.Server,
+			IP:        conn.VPNIP,
+			CIDR:      conn.tunnelCIDR(),
+			Interface: conn.interfaceName(),
+			Services:  conn.RoutesServices,
+			Transport: conn.tunnelTransport(),
 			Connected: isConnected,
 			State:     string(state),
+			Reason:    conn.GetStateReason(),
 			Uptime:    int64(time.Since(conn.StartTime).Seconds()),
 			Message:   message,
+
+			TunnelError: tunnelError,
 		})
 	}
 	s.connMutex.RUnlock()
@@ -0,0 +1,377 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/api"
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/proxy"
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/wgkeys"
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/wireguard"
+	"github.com/kloudlite/kloudlite/api/pkg/udptunnel/tunnel"
+	"go.uber.org/zap"
+)
+
+// A headless connection runs WireGuard on a userspace network stack inside the kltun process, for
+// CI jobs: it needs neither the daemon nor root, and leaves the routes, DNS and /etc/hosts of the
+// host untouched. Programs reach the tunnel through local SOCKS5 and HTTP proxies, which resolve
+// the hosts of the tunnel server (the services of the environment of a service token) themselves.
+const (
+	headlessReadyTimeout = 30 * time.Second // Time for the tunnel to carry traffic after setup
+	headlessHostsPoll    = 10 * time.Second // Interval of hosts refreshes, as for daemon connections
+)
+
+// HeadlessConfig configures a headless connection
+type HeadlessConfig struct {
+	Server     string               // Dashboard URL (e.g., https://subdomain.khost.dev)
+	Token      string               // Service token of an environment, or permanent VPN token
+	DeviceName string               // Name of the WireGuard peer, jobs sharing it keep their own peers
+	Transport  tunnel.TransportMode // Tunnel transport
+	SOCKS5Addr string               // Listen address of the SOCKS5 proxy, empty disables
+	HTTPAddr   string               // Listen address of the HTTP proxy, empty disables
+}
+
+// HeadlessConnection is a running headless connection
+type HeadlessConnection struct {
+	IP         string // IP of the peer in the tunnel
+	SOCKS5Addr string // Address of the SOCKS5 proxy, empty when disabled
+	HTTPAddr   string // Address of the HTTP proxy, empty when disabled
+
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	device       *wireguard.NetstackDevice
+	stopUDP      func()
+	tunnelClient *api.TunnelClient
+	publicKey    string
+}
+
+// StartHeadless connects to the tunnel server of the dashboard with a userspace WireGuard device
+// and starts the proxies, returning once they accept connections through the tunnel
+func StartHeadless(ctx context.Context, cfg HeadlessConfig) (*HeadlessConnection, error) {
+	if cfg.SOCKS5Addr == "" && cfg.HTTPAddr == "" {
+		return nil, errors.New("at least one of the SOCKS5 and HTTP proxies is required")
+	}
+	if cfg.DeviceName == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname for the device name: %w", err)
+		}
+		cfg.DeviceName = hostname
+	}
+
+	// Service tokens are long-lived already, they are used as they are
+	fmt.Printf("[Headless] Getting tunnel endpoint from Dashboard...\n")
+	tunnelInfo, err := api.NewClient(cfg.Server, cfg.Token).GetTunnelEndpoint()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tunnel endpoint: %w", err)
+	}
+	tunnelEndpoint := headlessEndpoint(ctx, tunnelInfo)
+	fmt.Printf("[Headless] Tunnel endpoint: %s\n", tunnelEndpoint)
+
+	// The key only lives as long as the job, the peer is removed on Close
+	keyPair, err := wgkeys.GenerateKeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate WireGuard keys: %w", err)
+	}
+
+	// Without a device ID, concurrent jobs on runners sharing a hostname do not replace each other's peers
+	tunnelClient := api.NewTunnelClient(tunnelEndpoint, cfg.Token)
+	peerResp, err := tunnelClient.CreatePeer(cfg.DeviceName, "", keyPair.PublicKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register WireGuard peer: %w", err)
+	}
+	fmt.Printf("[Headless] WireGuard peer %s created - IP: %s\n", cfg.DeviceName, peerResp.IP)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	c := &HeadlessConnection{
+		IP:           peerResp.IP,
+		cancel:       cancel,
+		tunnelClient: tunnelClient,
+		publicKey:    keyPair.PublicKey,
+	}
+	if err := c.start(runCtx, cfg, tunnelEndpoint, keyPair, peerResp); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// start brings the tunnel up and starts the proxies
+func (c *HeadlessConnection) start(ctx context.Context, cfg HeadlessConfig, tunnelEndpoint string, keyPair *wgkeys.KeyPair, peerResp *api.CreatePeerResponse) error {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+
+	// Local UDP proxy of the tunnel, on any free port since several jobs may share the host
+	proxyPort, err := freeUDPPort()
+	if err != nil {
+		return err
+	}
+	udpClient := newTunnelClient(fmt.Sprintf("127.0.0.1:%d", proxyPort), tunnelEndpoint, cfg.Token, cfg.Transport, logger)
+	c.stopUDP = startUDPClient(ctx, "headless", udpClient)
+
+	gateway := peerGateway(peerResp)
+	c.device, err = wireguard.NewNetstackDevice(peerResp.IP, gateway, 1420, nil)
+	if err != nil {
+		return err
+	}
+	// WireGuard listens on any free port as well
+	if err := c.device.SetConfig(buildWireGuardConfig(keyPair.PrivateKey, peerResp.IP, peerResp.ServerPublicKey, peerResp.CIDR, 0, proxyPort)); err != nil {
+		return fmt.Errorf("failed to set WireGuard config: %w", err)
+	}
+
+	dialer, err := newTunnelDialer(c.device.Net().DialContext, peerResp.CIDR, serviceCIDR)
+	if err != nil {
+		return err
+	}
+	if err := waitForTunnel(ctx, dialer, net.JoinHostPort(gateway, "443")); err != nil {
+		return err
+	}
+	fmt.Printf("[Headless] ✓ Tunnel up (transport: %s)\n", udpClient.ActiveTransport())
+
+	hosts, err := c.tunnelClient.GetHosts()
+	if err != nil {
+		return fmt.Errorf("failed to get hosts: %w", err)
+	}
+	dialer.setHosts(hosts)
+	fmt.Printf("[Headless] %d hosts reachable through the tunnel\n", len(hosts))
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.pollHosts(ctx, dialer)
+	}()
+
+	if cfg.SOCKS5Addr != "" {
+		if c.SOCKS5Addr, err = c.serve(ctx, cfg.SOCKS5Addr, proxy.ServeSOCKS5, dialer.DialContext); err != nil {
+			return fmt.Errorf("failed to start SOCKS5 proxy: %w", err)
+		}
+	}
+	if cfg.HTTPAddr != "" {
+		if c.HTTPAddr, err = c.serve(ctx, cfg.HTTPAddr, proxy.ServeHTTP, dialer.DialContext); err != nil {
+			return fmt.Errorf("failed to start HTTP proxy: %w", err)
+		}
+	}
+	return nil
+}
+
+// serve starts a proxy on addr and returns the address it listens on
+func (c *HeadlessConnection) serve(ctx context.Context, addr string, serve func(context.Context, net.Listener, proxy.Dialer) error, dial proxy.Dialer) (string, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		if err := serve(ctx, ln, dial); err != nil {
+			fmt.Printf("[Headless] Proxy on %s stopped: %v\n", ln.Addr(), err)
+		}
+	}()
+	return ln.Addr().String(), nil
+}
+
+// pollHosts refreshes the hosts of the tunnel until ctx is done
+func (c *HeadlessConnection) pollHosts(ctx context.Context, dialer *tunnelDialer) {
+	ticker := time.NewTicker(headlessHostsPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			hosts, err := c.tunnelClient.GetHosts()
+			if err != nil {
+				fmt.Printf("[Headless] Warning: Failed to refresh hosts: %v\n", err)
+				continue
+			}
+			dialer.setHosts(hosts)
+		}
+	}
+}
+
+// Close stops the proxies and the tunnel, and removes the WireGuard peer from the tunnel server
+func (c *HeadlessConnection) Close() {
+	c.cancel()
+	c.wg.Wait()
+
+	if c.device != nil {
+		c.device.Close()
+	}
+	if c.stopUDP != nil {
+		c.stopUDP()
+	}
+	if err := c.tunnelClient.DeletePeer(c.publicKey); err != nil {
+		fmt.Printf("[Headless] Warning: Failed to remove WireGuard peer: %v\n", err)
+	}
+}
+
+// headlessEndpoint returns the tunnel endpoint, with the IP of the WorkMachine when its hostname
+// does not resolve: /etc/hosts is not managed without root
+func headlessEndpoint(ctx context.Context, info *api.TunnelEndpointResponse) string {
+	host, port, err := net.SplitHostPort(info.TunnelEndpoint)
+	if err != nil || info.IP == "" {
+		return info.TunnelEndpoint
+	}
+	if _, err := net.DefaultResolver.LookupHost(ctx, host); err == nil {
+		return info.TunnelEndpoint
+	}
+	return net.JoinHostPort(info.IP, port)
+}
+
+// freeUDPPort returns a free local UDP port
+func freeUDPPort() (int, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free UDP port: %w", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port, nil
+}
+
+// waitForTunnel waits until address (the tunnel server) is reachable through the tunnel
+func waitForTunnel(ctx context.Context, dialer *tunnelDialer, address string) error {
+	ctx, cancel := context.WithTimeout(ctx, headlessReadyTimeout)
+	defer cancel()
+
+	for {
+		dialCtx, dialCancel := context.WithTimeout(ctx, 5*time.Second)
+		conn, err := dialer.DialContext(dialCtx, "tcp", address)
+		dialCancel()
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("tunnel not reachable within %v: %w", headlessReadyTimeout, err)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// tunnelDialer dials the hosts of the tunnel server and the addresses routed through the tunnel
+// with the userspace network stack, and everything else directly
+type tunnelDialer struct {
+	viaTunnel proxy.Dialer
+	direct    net.Dialer
+	routes    []netip.Prefix
+
+	mu    sync.RWMutex
+	hosts map[string]string // IPs of hostnames, wildcards (*.apps...) and unambiguous service names
+}
+
+// newTunnelDialer creates a tunnel dialer routing the CIDRs through viaTunnel
+func newTunnelDialer(viaTunnel proxy.Dialer, cidrs ...string) (*tunnelDialer, error) {
+	d := &tunnelDialer{viaTunnel: viaTunnel, hosts: map[string]string{}}
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tunnel CIDR %q: %w", cidr, err)
+		}
+		d.routes = append(d.routes, prefix)
+	}
+	return d, nil
+}
+
+// setHosts replaces the hosts resolved through the tunnel
+//
+// Services are also reachable by their name alone (e.g., "postgres:5432") when no other
+// environment has a service with that name, which is always the case for service tokens.
+func (d *tunnelDialer) setHosts(entries []api.HostEntry) {
+	hosts := make(map[string]string, len(entries))
+	serviceIPs := map[string]map[string]string{} // service name -> environment -> IP
+	for _, entry := range entries {
+		hosts[strings.ToLower(entry.Hostname)] = entry.IP
+		if entry.Type == "service" && entry.Service != "" {
+			if serviceIPs[entry.Service] == nil {
+				serviceIPs[entry.Service] = map[string]string{}
+			}
+			serviceIPs[entry.Service][entry.Environment] = entry.IP
+		}
+	}
+	for name, ips := range serviceIPs {
+		if _, exists := hosts[name]; exists || len(ips) != 1 {
+			continue
+		}
+		for _, ip := range ips {
+			hosts[name] = ip
+		}
+	}
+
+	d.mu.Lock()
+	d.hosts = hosts
+	d.mu.Unlock()
+}
+
+// route returns the address to dial for address, and whether it goes through the tunnel
+func (d *tunnelDialer) route(address string) (string, bool, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", false, err
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil {
+		for _, prefix := range d.routes {
+			if prefix.Contains(ip.Unmap()) {
+				return address, true, nil
+			}
+		}
+		return address, false, nil
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if ip, ok := d.hosts[name]; ok {
+		return net.JoinHostPort(ip, port), true, nil
+	}
+	// Wildcard hosts of ingresses, the most specific first
+	for labels := strings.Split(name, "."); len(labels) > 1; labels = labels[1:] {
+		if ip, ok := d.hosts["*."+strings.Join(labels[1:], ".")]; ok {
+			return net.JoinHostPort(ip, port), true, nil
+		}
+	}
+	return address, false, nil
+}
+
+// DialContext dials address through the tunnel or directly
+func (d *tunnelDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	target, tunneled, err := d.route(address)
+	if err != nil {
+		return nil, err
+	}
+	if tunneled {
+		return d.viaTunnel(ctx, network, target)
+	}
+	return d.direct.DialContext(ctx, network, target)
+}
+
+// ProxyEnv returns the proxy environment variables of programs using the connection
+// (ALL_PROXY for SOCKS5, HTTP_PROXY and HTTPS_PROXY for HTTP)
+func (c *HeadlessConnection) ProxyEnv() []string {
+	var env []string
+	if c.SOCKS5Addr != "" {
+		// socks5h: the proxy resolves names, so that the names of the tunnel resolve
+		socks := "socks5h://" + c.SOCKS5Addr
+		env = append(env, "ALL_PROXY="+socks, "all_proxy="+socks)
+	}
+	if c.HTTPAddr != "" {
+		httpProxy := "http://" + c.HTTPAddr
+		env = append(env,
+			"HTTP_PROXY="+httpProxy, "http_proxy="+httpProxy,
+			"HTTPS_PROXY="+httpProxy, "https_proxy="+httpProxy)
+	}
+	return env
+}
@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/api"
+)
+
+func TestTunnelDialerRoute(t *testing.T) {
+	d, err := newTunnelDialer(nil, "10.17.1.0/24", serviceCIDR)
+	if err != nil {
+		t.Fatalf("newTunnelDialer() error = %v", err)
+	}
+	d.setHosts([]api.HostEntry{
+		{Hostname: "postgres-a1b2c3d4.beanbag.khost.dev", IP: "10.43.0.20", Type: "service", Environment: "staging", Service: "postgres"},
+		{Hostname: "api-a1b2c3d4.beanbag.khost.dev", IP: "10.43.0.21", Type: "service", Environment: "staging", Service: "api"},
+		{Hostname: "api-e5f6a7b8.beanbag.khost.dev", IP: "10.43.0.31", Type: "service", Environment: "prod", Service: "api"},
+		{Hostname: "*.apps.beanbag.khost.dev", IP: "10.43.0.5", Type: "ingress"},
+	})
+
+	tests := []struct {
+		address  string
+		want     string
+		tunneled bool
+	}{
+		{address: "postgres-a1b2c3d4.beanbag.khost.dev:5432", want: "10.43.0.20:5432", tunneled: true},
+		{address: "Postgres-A1B2C3D4.beanbag.khost.dev.:5432", want: "10.43.0.20:5432", tunneled: true},
+		{address: "postgres:5432", want: "10.43.0.20:5432", tunneled: true},
+		{address: "web.apps.beanbag.khost.dev:443", want: "10.43.0.5:443", tunneled: true},
+		{address: "10.43.0.99:80", want: "10.43.0.99:80", tunneled: true},
+		{address: "10.17.1.1:443", want: "10.17.1.1:443", tunneled: true},
+		// The service name is ambiguous across environments
+		{address: "api:8080", want: "api:8080"},
+		{address: "proxy.golang.org:443", want: "proxy.golang.org:443"},
+		{address: "192.168.1.10:22", want: "192.168.1.10:22"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got, tunneled, err := d.route(tt.address)
+			if err != nil {
+				t.Fatalf("route() error = %v", err)
+			}
+			if got != tt.want || tunneled != tt.tunneled {
+				t.Errorf("route() = %s, %v, want %s, %v", got, tunneled, tt.want, tt.tunneled)
+			}
+		})
+	}
+}
+
+func TestHeadlessProxyEnv(t *testing.T) {
+	c := &HeadlessConnection{SOCKS5Addr: "127.0.0.1:1080"}
+	env := c.ProxyEnv()
+	if len(env) != 2 || env[0] != "ALL_PROXY=socks5h://127.0.0.1:1080" {
+		t.Errorf("ProxyEnv() = %v, want ALL_PROXY only", env)
+	}
+
+	c.HTTPAddr = "127.0.0.1:8080"
+	if env := c.ProxyEnv(); len(env) != 6 {
+		t.Errorf("ProxyEnv() = %v, want ALL_PROXY, HTTP_PROXY and HTTPS_PROXY", env)
+	}
+}
@@ -17,7 +17,6 @@ const (
 	hostsPollInterval      = 10 * time.Second // Hosts polling interval
 	vpnCheckInterval       = 5 * time.Second  // VPN health check interval
 	vpnCheckTimeout        = 3 * time.Second  // Timeout for VPN connectivity check
-	vpnGatewayIP           = "10.17.0.1"      // VPN gateway IP to check connectivity
 )
 
 // ConnectionHealthMonitor tracks connection health and triggers reconnection
@@ -72,10 +71,10 @@ func (m *ConnectionHealthMonitor) MarkDisconnected() {
 	}
 }
 
-// checkVPNConnectivity checks if we can reach the VPN gateway (10.17.0.1) via ICMP ping
+// checkVPNConnectivity checks if we can reach the VPN gateway of a connection (e.g., 10.17.0.1) via ICMP ping
 // Returns true if VPN is connected, false otherwise
-func checkVPNConnectivity() bool {
-	pinger, err := ping.NewPinger(vpnGatewayIP)
+func checkVPNConnectivity(gateway string) bool {
+	pinger, err := ping.NewPinger(gateway)
 	if err != nil {
 		return false
 	}
@@ -161,7 +160,7 @@ func (s *Server) fetchAndUpdateHosts(ctx context.Context, sessionID string, apiC
 }
 
 // pollHostsFromTunnel polls the hosts from tunnel server and monitors VPN connectivity
-// When VPN goes down (10.17.0.1 unreachable), triggers reconnection via Dashboard API
+// When VPN goes down (gateway unreachable), triggers reconnection via Dashboard API
 func (s *Server) pollHostsFromTunnel(ctx context.Context, sessionID string, tunnelClient *api.TunnelClient, done chan<- struct{}) {
 	defer close(done)
 
@@ -178,19 +177,14 @@ func (s *Server) pollHostsFromTunnel(ctx context.Context, sessionID string, tunn
 	conn := s.connections[sessionID]
 	s.connMutex.RUnlock()
 
-	// Create health monitor for VPN connectivity (ping 10.17.0.1)
+	// Create health monitor for VPN connectivity (ping the gateway)
 	healthMonitor := NewConnectionHealthMonitor(func() {
-		fmt.Printf("[Session %s] VPN disconnected - 10.17.0.1 unreachable\n", sessionID)
+		fmt.Printf("[Session %s] VPN disconnected - gateway unreachable\n", sessionID)
 		fmt.Printf("[Session %s] Entering reconnection mode, polling Dashboard API...\n", sessionID)
 
 		if conn != nil {
-			conn.SetState(StateReconnecting)
 			// Signal the reconnection goroutine
-			select {
-			case conn.ReconnectChan <- struct{}{}:
-			default:
-				// Channel already has a signal pending
-			}
+			conn.reconnect("gateway unreachable")
 		}
 	})
 
@@ -207,13 +201,17 @@ func (s *Server) pollHostsFromTunnel(ctx context.Context, sessionID string, tunn
 				continue
 			}
 
-			// Check VPN connectivity by pinging 10.17.0.1
-			if checkVPNConnectivity() {
+			// Check VPN connectivity by pinging the gateway of the connection
+			gateway := defaultGatewayIP
+			if conn != nil {
+				gateway = conn.gateway()
+			}
+			if checkVPNConnectivity(gateway) {
 				healthMonitor.RecordSuccess()
 			} else {
 				failureCount := healthMonitor.GetFailureCount() + 1
-				fmt.Printf("[Session %s] VPN check failed (%d/%d): 10.17.0.1 unreachable\n",
-					sessionID, failureCount, maxConsecutiveFailures)
+				fmt.Printf("[Session %s] VPN check failed (%d/%d): %s unreachable\n",
+					sessionID, failureCount, maxConsecutiveFailures, gateway)
 				if healthMonitor.RecordFailure() {
 					healthMonitor.MarkDisconnected()
 				}
@@ -228,7 +226,7 @@ func (s *Server) pollHostsFromTunnel(ctx context.Context, sessionID string, tunn
 	}
 }
 
-// fetchAndUpdateHostsFromTunnel fetches hosts from tunnel server and updates /etc/hosts
+// fetchAndUpdateHostsFromTunnel fetches hosts from tunnel server and updates the split-DNS resolver or /etc/hosts
 func (s *Server) fetchAndUpdateHostsFromTunnel(ctx context.Context, sessionID string, tunnelClient *api.TunnelClient, currentHosts map[string]string) {
 	hosts, err := tunnelClient.GetHosts()
 	if err != nil {
@@ -242,6 +240,21 @@ func (s *Server) fetchAndUpdateHostsFromTunnel(ctx context.Context, sessionID st
 		newHosts[host.Hostname] = host.IP
 	}
 
+	// With split DNS the hosts are served by the resolver, currentHosts stays empty so that they
+	// are all written to /etc/hosts if split DNS is lost on reconnection
+	s.connMutex.RLock()
+	conn := s.connections[sessionID]
+	s.connMutex.RUnlock()
+	if conn != nil {
+		if dns := conn.getDNS(); dns != nil {
+			if err := dns.update(newHosts); err != nil {
+				fmt.Printf("[Session %s] Warning: Failed to update split DNS: %v\n", sessionID, err)
+			}
+			fmt.Printf("[Session %s] ✓ Hosts updated from tunnel server (%d entries, split DNS)\n", sessionID, len(newHosts))
+			return
+		}
+	}
+
 	// Remove hosts that no longer exist
 	for hostname := range currentHosts {
 		if _, exists := newHosts[hostname]; !exists {
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 )
@@ -37,6 +38,10 @@ type VPNStatus struct {
 	ConnectionUptimeSeconds int64  `json:"connection_uptime_seconds"`  // Connection uptime in seconds
 	TunnelEndpoint          string `json:"tunnel_endpoint,omitempty"`  // Tunnel server endpoint
 	DashboardServer         string `json:"dashboard_server,omitempty"` // Dashboard server URL
+	Name                    string `json:"name,omitempty"`             // Connection name
+
+	// Connections lists all connections when several are active, including this one
+	Connections []VPNStatus `json:"connections,omitempty"`
 }
 
 // StatusResponse represents the complete status response
@@ -152,6 +157,34 @@ func (h *HTTPSServer) Stop() error {
 	return err
 }
 
+// connectionVPNStatus returns the VPN status of a connection
+func connectionVPNStatus(conn *VPNConnection, now time.Time) VPNStatus {
+	vpnStatus := VPNStatus{
+		Name:            conn.Name,
+		SessionID:       conn.SessionID,
+		TunnelEndpoint:  conn.TunnelEndpoint,
+		DashboardServer: conn.DashboardServer,
+		VPNIP:           conn.VPNIP,
+	}
+
+	if !conn.StartTime.IsZero() {
+		vpnStatus.ConnectionUptimeSeconds = int64(now.Sub(conn.StartTime).Seconds())
+	}
+
+	switch conn.GetState() {
+	case StateConnected:
+		vpnStatus.Status = "connected"
+	case StateReconnecting:
+		vpnStatus.Status = "reconnecting"
+	case StateDisconnected:
+		vpnStatus.Status = "disconnected"
+	default:
+		vpnStatus.Status = "idle"
+	}
+	vpnStatus.StatusMessage = getStatusMessage(vpnStatus.Status)
+	return vpnStatus
+}
+
 // handleStatus handles GET /status requests
 func (h *HTTPSServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -171,46 +204,29 @@ func (h *HTTPSServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 		daemonStatus.UptimeSeconds = int64(now.Sub(h.daemonRef.startedAt).Seconds())
 	}
 
-	// Build VPN status
+	// Build VPN status of every connection, the primary one is the connection of the dashboard
+	// asking (matched by Origin), or the first one by name
 	vpnStatus := VPNStatus{
 		Status: "idle",
 	}
+	vpnStatus.StatusMessage = getStatusMessage(vpnStatus.Status)
 
+	var connections []VPNStatus
 	if h.daemonRef != nil {
-		h.daemonRef.connMutex.RLock()
-		if len(h.daemonRef.connections) == 0 {
-			vpnStatus.Status = "idle"
-		} else {
-			for _, conn := range h.daemonRef.connections {
-				state := conn.GetState()
-				vpnStatus.SessionID = conn.SessionID
-				vpnStatus.TunnelEndpoint = conn.TunnelEndpoint
-				vpnStatus.DashboardServer = conn.DashboardServer
-				vpnStatus.VPNIP = conn.VPNIP
-
-				if conn.StartTime.IsZero() {
-					vpnStatus.ConnectionUptimeSeconds = 0
-				} else {
-					vpnStatus.ConnectionUptimeSeconds = int64(now.Sub(conn.StartTime).Seconds())
-				}
-
-				switch state {
-				case StateConnected:
-					vpnStatus.Status = "connected"
-				case StateReconnecting:
-					vpnStatus.Status = "reconnecting"
-				case StateDisconnected:
-					vpnStatus.Status = "disconnected"
-				default:
-					vpnStatus.Status = "idle"
-				}
-				break // Only one connection
+		origin := strings.TrimSuffix(r.Header.Get("Origin"), "/")
+		primary := -1
+		for i, conn := range h.daemonRef.sortedConnections() {
+			status := connectionVPNStatus(conn, now)
+			if primary < 0 || (origin != "" && strings.TrimSuffix(conn.DashboardServer, "/") == origin) {
+				primary = i
+				vpnStatus = status
 			}
+			connections = append(connections, status)
 		}
-		h.daemonRef.connMutex.RUnlock()
 	}
-
-	vpnStatus.StatusMessage = getStatusMessage(vpnStatus.Status)
+	if len(connections) > 1 {
+		vpnStatus.Connections = connections
+	}
 
 	// Build complete response
 	response := StatusResponse{
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Network change detection constants
+const (
+	networkPollInterval = 5 * time.Second  // Interval of network state checks, and of sleep detection
+	networkSettleDelay  = 1 * time.Second  // Network events are coalesced for this long (link, then addresses)
+	sleepThreshold      = 15 * time.Second // Wall clock jumps over the poll interval by more than this when the host slept
+)
+
+// networkState is the addresses of the interfaces the tunnels may go through, by interface name
+//
+// Loopback and point-to-point interfaces (WireGuard, utun) are left out, as well as the interfaces
+// of the connection slots, which Wintun does not flag as point-to-point, so that the tunnels do not
+// change it themselves.
+type networkState map[string][]string
+
+// currentNetworkState returns the addresses of the interfaces that are up
+func currentNetworkState() networkState {
+	state := networkState{}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return state
+	}
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&(net.FlagLoopback|net.FlagPointToPoint) != 0 || isSlotInterface(iface.Name) {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		var ips []string
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			// Link-local addresses come with the interface, they do not reach the tunnel server
+			if !ok || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			ips = append(ips, ipNet.IP.String())
+		}
+		if len(ips) > 0 {
+			sort.Strings(ips)
+			state[iface.Name] = ips
+		}
+	}
+	return state
+}
+
+// diff describes how the network changed from n to other, empty when it did not
+func (n networkState) diff(other networkState) string {
+	var changes []string
+	for name, ips := range other {
+		previous, ok := n[name]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("%s up (%s)", name, strings.Join(ips, ", ")))
+		case !slices.Equal(previous, ips):
+			changes = append(changes, fmt.Sprintf("%s %s -> %s", name, strings.Join(previous, ", "), strings.Join(ips, ", ")))
+		}
+	}
+	for name := range n {
+		if _, ok := other[name]; !ok {
+			changes = append(changes, name+" down")
+		}
+	}
+	sort.Strings(changes)
+	return strings.Join(changes, "; ")
+}
+
+// sleptFor returns how long the host slept between two ticks of interval, 0 when it did not
+//
+// The monotonic clock stops while the host sleeps, the wall clock does not.
+func sleptFor(previous, now time.Time, interval time.Duration) time.Duration {
+	gap := now.Round(0).Sub(previous.Round(0)) - interval
+	if gap < sleepThreshold {
+		return 0
+	}
+	return gap
+}
+
+// watchNetwork calls onChange with its reason when the addresses of the host change (e.g., another
+// Wi-Fi network) or when it resumes from sleep, until ctx is done
+func watchNetwork(ctx context.Context, onChange func(reason string)) {
+	// OS network events (netlink on Linux) only make changes noticed sooner, the state is also polled
+	events := make(chan struct{}, 1)
+	go func() {
+		if err := subscribeNetworkEvents(ctx, events); err != nil && ctx.Err() == nil {
+			fmt.Printf("Network events unavailable, polling network state every %v: %v\n", networkPollInterval, err)
+		}
+	}()
+
+	state := currentNetworkState()
+	ticker := time.NewTicker(networkPollInterval)
+	defer ticker.Stop()
+	lastTick := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			slept := sleptFor(lastTick, now, networkPollInterval)
+			lastTick = now
+			if slept > 0 {
+				state = currentNetworkState()
+				onChange(fmt.Sprintf("resumed from sleep after %v", slept.Round(time.Second)))
+				continue
+			}
+		case <-events:
+			// Wait for the rest of the change, then drop the events it caused
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(networkSettleDelay):
+			}
+			select {
+			case <-events:
+			default:
+			}
+		}
+
+		next := currentNetworkState()
+		if change := state.diff(next); change != "" {
+			state = next
+			onChange("network changed: " + change)
+		}
+	}
+}
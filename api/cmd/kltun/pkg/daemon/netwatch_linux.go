@@ -0,0 +1,58 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// subscribeNetworkEvents signals events on link and address changes, read from a netlink socket,
+// until ctx is done
+func subscribeNetworkEvents(ctx context.Context, events chan<- struct{}) error {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		return fmt.Errorf("failed to create netlink socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	addr := &unix.SockaddrNetlink{
+		Family: unix.AF_NETLINK,
+		Groups: unix.RTMGRP_LINK | unix.RTMGRP_IPV4_IFADDR | unix.RTMGRP_IPV6_IFADDR,
+	}
+	if err := unix.Bind(fd, addr); err != nil {
+		return fmt.Errorf("failed to subscribe to netlink groups: %w", err)
+	}
+
+	// Reads time out to notice ctx
+	timeout := unix.NsecToTimeval(time.Second.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &timeout); err != nil {
+		return fmt.Errorf("failed to set netlink read timeout: %w", err)
+	}
+
+	// The messages only tell that something changed, the state is read again
+	buf := make([]byte, 64<<10)
+	for ctx.Err() == nil {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		switch {
+		case errors.Is(err, unix.EAGAIN), errors.Is(err, unix.EINTR):
+			continue
+		case errors.Is(err, unix.ENOBUFS):
+			// Events were dropped, the state changed anyway
+		case err != nil:
+			return fmt.Errorf("failed to read netlink socket: %w", err)
+		case n == 0:
+			continue
+		}
+
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
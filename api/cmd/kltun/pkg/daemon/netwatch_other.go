@@ -0,0 +1,14 @@
+//go:build !linux
+
+package daemon
+
+import (
+	"context"
+	"errors"
+)
+
+// subscribeNetworkEvents is not implemented on this platform, network changes are noticed by
+// polling the network state
+func subscribeNetworkEvents(ctx context.Context, events chan<- struct{}) error {
+	return errors.New("not supported on this platform")
+}
@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNetworkStateDiff(t *testing.T) {
+	home := networkState{"wlan0": {"192.168.1.20"}, "eth0": {"10.0.0.5"}}
+
+	tests := []struct {
+		name string
+		next networkState
+		want string
+	}{
+		{name: "unchanged", next: networkState{"eth0": {"10.0.0.5"}, "wlan0": {"192.168.1.20"}}},
+		{name: "roamed", next: networkState{"eth0": {"10.0.0.5"}, "wlan0": {"172.20.4.9"}}, want: "wlan0 192.168.1.20 -> 172.20.4.9"},
+		{name: "unplugged", next: networkState{"wlan0": {"192.168.1.20"}}, want: "eth0 down"},
+		{
+			name: "tethered",
+			next: networkState{"eth0": {"10.0.0.5"}, "wlan0": {"192.168.1.20"}, "usb0": {"172.20.10.2"}},
+			want: "usb0 up (172.20.10.2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := home.diff(tt.next); got != tt.want {
+				t.Errorf("diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSleptFor(t *testing.T) {
+	previous := time.Now()
+
+	if got := sleptFor(previous, previous.Add(networkPollInterval+time.Second), networkPollInterval); got != 0 {
+		t.Errorf("sleptFor() = %v for a late tick, want 0", got)
+	}
+	if got := sleptFor(previous, previous.Add(networkPollInterval+time.Hour), networkPollInterval); got != time.Hour {
+		t.Errorf("sleptFor() = %v, want 1h", got)
+	}
+}
+
+func TestHandleNetworkChange(t *testing.T) {
+	established := &VPNConnection{SessionID: "a", State: StateConnected, ReconnectChan: make(chan struct{}, 1)}
+	pending := &VPNConnection{SessionID: "b"}
+	s := &Server{connections: map[string]*VPNConnection{"a": established, "b": pending}}
+
+	s.handleNetworkChange("network changed: wlan0 down")
+	s.handleNetworkChange("resumed from sleep after 1h0m0s")
+
+	if state := established.GetState(); state != StateReconnecting {
+		t.Errorf("state = %s, want reconnecting", state)
+	}
+	if reason := established.GetStateReason(); reason != "resumed from sleep after 1h0m0s" {
+		t.Errorf("reason = %q, want the latest change", reason)
+	}
+	if got := len(established.ReconnectChan); got != 1 {
+		t.Errorf("pending reconnect signals = %d, want 1", got)
+	}
+	if state := pending.GetState(); state != "" {
+		t.Errorf("connection still being established entered state %s", state)
+	}
+
+	established.SetState(StateConnected)
+	if reason := established.GetStateReason(); reason != "" {
+		t.Errorf("reason = %q after reconnecting, want none", reason)
+	}
+}
@@ -3,6 +3,7 @@ package daemon
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // RPC Protocol - JSON-RPC 2.0 style
@@ -53,6 +54,13 @@ const (
 	MethodVPNConnect  = "vpn_connect"
 	MethodVPNQuit     = "vpn_quit"
 	MethodStatus      = "status"
+
+	MethodForwardAdd    = "forward_add"
+	MethodForwardRemove = "forward_remove"
+	MethodForwardList   = "forward_list"
+
+	MethodDevicesList   = "devices_list"
+	MethodDevicesRevoke = "devices_revoke"
 )
 
 // Request/Response Parameters
@@ -157,6 +165,10 @@ type HostsFlushResult struct {
 type VPNConnectParams struct {
 	Token  string `json:"token,omitempty"`
 	Server string `json:"server,omitempty"`
+	Name   string `json:"name,omitempty"` // Connection name, defaults to the subdomain of Server; replaces a connection of the same name
+
+	// Transport of the tunnel: "auto" (QUIC, falling back to WebSocket, the default), "quic" or "websocket"
+	Transport string `json:"transport,omitempty"`
 }
 
 // VPNConnectResult contains result of VPN connection
@@ -164,6 +176,7 @@ type VPNConnectResult struct {
 	Success        bool   `json:"success"`
 	Message        string `json:"message"`
 	SessionID      string `json:"session_id,omitempty"`
+	Name           string `json:"name,omitempty"`
 	TunnelEndpoint string `json:"tunnel_endpoint,omitempty"` // For CLI to fetch CA cert
 	PermanentToken string `json:"permanent_token,omitempty"` // For CLI to authenticate CA fetch
 }
@@ -175,8 +188,11 @@ type VPNConnectionSetupResult struct {
 	PermanentToken string // For CLI to authenticate CA fetch
 }
 
-// VPNQuitParams - no parameters needed
-type VPNQuitParams struct{}
+// VPNQuitParams selects the connections to stop, the only one when empty
+type VPNQuitParams struct {
+	Name string `json:"name,omitempty"`
+	All  bool   `json:"all,omitempty"`
+}
 
 // VPNQuitResult contains result of VPN disconnection
 type VPNQuitResult struct {
@@ -190,11 +206,22 @@ type StatusParams struct{}
 // ConnectionStatus represents the status of a VPN connection
 type ConnectionStatus struct {
 	SessionID string `json:"session_id"`
+	Name      string `json:"name"`
 	Server    string `json:"server"`
+	IP        string `json:"ip,omitempty"`        // VPN IP of the connection
+	CIDR      string `json:"cidr,omitempty"`      // Tunnel subnet
+	Interface string `json:"interface,omitempty"` // WireGuard interface
+	Services  bool   `json:"services,omitempty"`  // Whether the service CIDR is routed through this connection
+	Transport string `json:"transport,omitempty"` // Transport of the tunnel: "quic" or "websocket"
 	Connected bool   `json:"connected"`
-	State     string `json:"state"` // "connected", "reconnecting", "disconnected"
+	State     string `json:"state"`            // "connected", "reconnecting", "disconnected"
+	Reason    string `json:"reason,omitempty"` // Why the connection is reconnecting (e.g., "resumed from sleep after 2h0m0s")
 	Uptime    int64  `json:"uptime"`
 	Message   string `json:"message,omitempty"` // Human-readable state description
+
+	// TunnelError is why the tunnel server refused the tunnel of the connection (e.g.,
+	// "session_limit"), empty while it accepts it
+	TunnelError string `json:"tunnel_error,omitempty"`
 }
 
 // StatusResult contains daemon status
@@ -203,6 +230,91 @@ type StatusResult struct {
 	Connections []ConnectionStatus `json:"connections"`
 }
 
+// ForwardAddParams contains parameters for adding a port forward
+type ForwardAddParams struct {
+	Target    string `json:"target"`     // Workspace or environment service name, hostname or IP
+	Port      int    `json:"port"`       // Remote port
+	LocalPort int    `json:"local_port"` // Local port on 127.0.0.1, defaults to Port
+}
+
+// ForwardAddResult contains result of adding a port forward
+type ForwardAddResult struct {
+	Success bool         `json:"success"`
+	Message string       `json:"message"`
+	Forward ForwardEntry `json:"forward,omitempty"`
+}
+
+// ForwardRemoveParams contains parameters for removing a port forward
+type ForwardRemoveParams struct {
+	LocalPort int `json:"local_port"`
+}
+
+// ForwardRemoveResult contains result of removing a port forward
+type ForwardRemoveResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// ForwardListParams - no parameters
+type ForwardListParams struct{}
+
+// ForwardEntry represents a port forward
+type ForwardEntry struct {
+	Target      string `json:"target"`
+	Port        int    `json:"port"`
+	LocalPort   int    `json:"local_port"`
+	Hostname    string `json:"hostname,omitempty"` // Resolved hostname, empty until the target resolves
+	IP          string `json:"ip,omitempty"`
+	Listening   bool   `json:"listening"`
+	Connections int64  `json:"connections"` // Currently open connections
+	Error       string `json:"error,omitempty"`
+}
+
+// ForwardListResult contains list of port forwards
+type ForwardListResult struct {
+	Forwards []ForwardEntry `json:"forwards"`
+}
+
+// DevicesListParams selects the connection whose tunnel server is asked, the only one when empty
+type DevicesListParams struct {
+	Name string `json:"name,omitempty"`
+}
+
+// DeviceEntry represents a device (WireGuard peer) registered on a tunnel server
+type DeviceEntry struct {
+	PublicKey     string     `json:"public_key"`
+	DeviceName    string     `json:"device_name"`
+	Owner         string     `json:"owner,omitempty"`
+	IP            string     `json:"ip"`
+	Endpoint      string     `json:"endpoint,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastHandshake *time.Time `json:"last_handshake,omitempty"`
+	RxBytes       int64      `json:"rx_bytes"`
+	TxBytes       int64      `json:"tx_bytes"`
+	Current       bool       `json:"current"` // The device of this daemon
+}
+
+// DevicesListResult contains the devices registered on a tunnel server
+type DevicesListResult struct {
+	Name               string        `json:"name"` // Connection the devices were listed through
+	Devices            []DeviceEntry `json:"devices"`
+	IdleTimeoutSeconds int64         `json:"idle_timeout_seconds,omitempty"`
+	MaxDevices         int           `json:"max_devices,omitempty"`
+}
+
+// DevicesRevokeParams contains parameters for revoking a device
+type DevicesRevokeParams struct {
+	Name   string `json:"name,omitempty"` // Connection, the only one when empty
+	Target string `json:"target"`         // Device name or public key (prefix)
+}
+
+// DevicesRevokeResult contains result of revoking a device
+type DevicesRevokeResult struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Device  DeviceEntry `json:"device,omitempty"`
+}
+
 // Helper functions for creating requests/responses
 
 // NewRequest creates a new RPC request
@@ -17,12 +17,14 @@ import (
 	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/netconfig"
 	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/wgkeys"
 	"github.com/kloudlite/kloudlite/api/cmd/kltun/pkg/wireguard"
+	"github.com/kloudlite/kloudlite/api/pkg/udptunnel/tunnel"
 )
 
 // Server represents the daemon RPC server
 type Server struct {
 	listener     net.Listener
 	hostsManager hosts.Manager
+	forwards     *ForwardManager
 	connections  map[string]*VPNConnection
 	connMutex    sync.RWMutex
 	shutdownCh   chan struct{}
@@ -48,14 +50,18 @@ const (
 // VPNConnection represents an active VPN connection
 type VPNConnection struct {
 	SessionID  string
+	Name       string // Connection name, unique among the connections of the daemon
+	Slot       int    // Selects the WireGuard interface and local ports of the connection
 	Server     string
+	Transport  tunnel.TransportMode // Transport of the tunnel: auto, quic or websocket
 	StartTime  time.Time
 	CancelFunc context.CancelFunc
 	DoneChan   chan struct{} // Signals when cleanup is complete
 
 	// Connection state for auto-reconnect
-	State     ConnectionState
-	StateLock sync.RWMutex
+	State       ConnectionState
+	StateReason string // Why the connection is reconnecting (e.g., "network changed: ..."), empty otherwise
+	StateLock   sync.RWMutex
 
 	// Credentials for reconnection (stored after initial connect)
 	DashboardServer string // Dashboard server URL (e.g., https://beanbag.khost.dev)
@@ -68,7 +74,15 @@ type VPNConnection struct {
 	// WireGuard device and network config (for cleanup during reconnection)
 	WireGuardDevice *wireguard.Device
 	NetConfig       *netconfig.InterfaceConfig
-	WGMutex         sync.Mutex // Protects WireGuardDevice and NetConfig
+	DNS             *sessionDNS       // Split-DNS resolver, nil when hosts are written to /etc/hosts
+	CIDR            string            // Tunnel subnet handed out by the tunnel server (e.g., "10.17.0.0/24")
+	Gateway         string            // Tunnel server address in CIDR, checked for connectivity and asked for DNS
+	UDPClient       *tunnel.UDPClient // UDP-over-WebSocket client of the current tunnel
+	StopUDPClient   func()            // Stops UDPClient, returning once its local port is free
+	WGMutex         sync.Mutex        // Protects WireGuardDevice, NetConfig, DNS, CIDR, Gateway, UDPClient and StopUDPClient
+
+	// RoutesServices is set on the connection routing the service CIDR, protected by the server's connMutex
+	RoutesServices bool
 
 	// Reconnection control
 	ReconnectChan chan struct{} // Signal to trigger reconnection attempt
@@ -86,21 +100,139 @@ func (c *VPNConnection) GetState() ConnectionState {
 
 // SetState sets the connection state
 func (c *VPNConnection) SetState(state ConnectionState) {
+	c.SetStateWithReason(state, "")
+}
+
+// SetStateWithReason sets the connection state and why it was entered
+func (c *VPNConnection) SetStateWithReason(state ConnectionState, reason string) {
 	c.StateLock.Lock()
 	defer c.StateLock.Unlock()
 	c.State = state
+	c.StateReason = reason
+}
+
+// GetStateReason returns why the connection entered its current state, empty when unknown
+func (c *VPNConnection) GetStateReason() string {
+	c.StateLock.RLock()
+	defer c.StateLock.RUnlock()
+	return c.StateReason
+}
+
+// reconnect enters the reconnecting state for reason and wakes the reconnection loop, which retries
+// at once when it is already running
+func (c *VPNConnection) reconnect(reason string) {
+	c.SetStateWithReason(StateReconnecting, reason)
+	select {
+	case c.ReconnectChan <- struct{}{}:
+	default:
+		// Channel already has a signal pending
+	}
+}
+
+// replaceUDPClient stops the UDP tunnel client of the connection, if any, and starts udpClient instead
+func (c *VPNConnection) replaceUDPClient(ctx context.Context, udpClient *tunnel.UDPClient) {
+	c.WGMutex.Lock()
+	stop := c.StopUDPClient
+	c.WGMutex.Unlock()
+
+	// The new client listens on the same local port
+	if stop != nil {
+		stop()
+	}
+
+	stop = startUDPClient(ctx, c.SessionID, udpClient)
+	c.WGMutex.Lock()
+	c.UDPClient = udpClient
+	c.StopUDPClient = stop
+	c.WGMutex.Unlock()
+}
+
+// hasWireGuardDevice returns whether the WireGuard device of the connection is up
+func (c *VPNConnection) hasWireGuardDevice() bool {
+	c.WGMutex.Lock()
+	defer c.WGMutex.Unlock()
+	return c.WireGuardDevice != nil
+}
+
+// getDNS returns the split-DNS resolver of the connection, nil in /etc/hosts mode
+func (c *VPNConnection) getDNS() *sessionDNS {
+	c.WGMutex.Lock()
+	defer c.WGMutex.Unlock()
+	return c.DNS
+}
+
+// setDNS sets the split-DNS resolver of the connection
+func (c *VPNConnection) setDNS(dns *sessionDNS) {
+	c.WGMutex.Lock()
+	defer c.WGMutex.Unlock()
+	c.DNS = dns
+}
+
+// tunnelRejection returns why the tunnel server refused the tunnel of the connection, nil while it accepts it
+func (c *VPNConnection) tunnelRejection() *tunnel.RejectedError {
+	c.WGMutex.Lock()
+	udpClient := c.UDPClient
+	c.WGMutex.Unlock()
+
+	if udpClient == nil {
+		return nil
+	}
+	return udpClient.Rejection()
+}
+
+// tunnelTransport returns the transport the tunnel of the connection uses, empty until it is established
+func (c *VPNConnection) tunnelTransport() string {
+	c.WGMutex.Lock()
+	udpClient := c.UDPClient
+	c.WGMutex.Unlock()
+
+	if udpClient == nil {
+		return ""
+	}
+	return string(udpClient.ActiveTransport())
+}
+
+// tunnelCIDR returns the tunnel subnet of the connection, empty until it is established
+func (c *VPNConnection) tunnelCIDR() string {
+	c.WGMutex.Lock()
+	defer c.WGMutex.Unlock()
+	return c.CIDR
+}
+
+// gateway returns the tunnel server address of the connection
+func (c *VPNConnection) gateway() string {
+	c.WGMutex.Lock()
+	defer c.WGMutex.Unlock()
+	if c.Gateway == "" {
+		return defaultGatewayIP
+	}
+	return c.Gateway
+}
+
+// interfaceName returns the name of the WireGuard interface of the connection, empty while it is down
+func (c *VPNConnection) interfaceName() string {
+	c.WGMutex.Lock()
+	defer c.WGMutex.Unlock()
+	if c.NetConfig == nil {
+		return ""
+	}
+	return c.NetConfig.InterfaceName
 }
 
 // NewServer creates a new daemon server
 func NewServer() (*Server, error) {
 	hostsManager := hosts.NewManager()
 
-	return &Server{
+	s := &Server{
 		hostsManager: hostsManager,
+		forwards:     NewForwardManager(ForwardsPath, hostsManager),
 		connections:  make(map[string]*VPNConnection),
 		shutdownCh:   make(chan struct{}),
 		startedAt:    time.Now(),
-	}, nil
+	}
+	// Hosts served by split DNS are not in /etc/hosts
+	s.forwards.extraHosts = s.resolverHosts
+	return s, nil
 }
 
 // Start starts the RPC server
@@ -125,6 +257,16 @@ func (s *Server) Start(socketPath string) error {
 
 	fmt.Printf("Daemon server listening on %s\n", socketPath)
 
+	// Reconnect the VPN connections at once when the network changes or the host resumes from sleep
+	watchCtx, cancelWatch := context.WithCancel(context.Background())
+	defer cancelWatch()
+	go watchNetwork(watchCtx, s.handleNetworkChange)
+
+	// Restore port forwards from the previous daemon run
+	if err := s.forwards.Restore(); err != nil {
+		fmt.Printf("Warning: Failed to restore port forwards: %v\n", err)
+	}
+
 	// Accept connections
 	for {
 		select {
@@ -162,6 +304,9 @@ func (s *Server) Stop() {
 	}
 	s.connMutex.Unlock()
 
+	// Stop port forward listeners (forwards stay persisted)
+	s.forwards.Close()
+
 	// Stop HTTPS server if running
 	if s.httpsServer != nil {
 		fmt.Println("Stopping HTTPS status server...")
@@ -234,6 +379,16 @@ func (s *Server) handleRequest(req *Request) *Response {
 		return s.handleVPNQuit(req)
 	case MethodStatus:
 		return s.handleStatus(req)
+	case MethodForwardAdd:
+		return s.handleForwardAdd(req)
+	case MethodForwardRemove:
+		return s.handleForwardRemove(req)
+	case MethodForwardList:
+		return s.handleForwardList(req)
+	case MethodDevicesList:
+		return s.handleDevicesList(req)
+	case MethodDevicesRevoke:
+		return s.handleDevicesRevoke(req)
 	default:
 		return NewErrorResponse(req.ID, ErrCodeMethodNotFound, "Method not found", req.Method)
 	}
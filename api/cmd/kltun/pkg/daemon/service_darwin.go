@@ -19,6 +19,9 @@ const (
 
 	// SocketPath is the path to the Unix socket
 	SocketPath = "/var/run/kltund.sock"
+
+	// ForwardsPath is where port forwards are persisted across daemon restarts
+	ForwardsPath = "/etc/kltun/forwards.json"
 )
 
 // launchdPlistTemplate is the template for the launchd plist file
@@ -25,6 +25,9 @@ const (
 
 	// SocketPath is the path to the named pipe
 	SocketPath = `\\.\pipe\kltund`
+
+	// ForwardsPath is where port forwards are persisted across daemon restarts
+	ForwardsPath = `C:\kloudlite\forwards.json`
 )
 
 // ServiceManager manages the daemon service on Windows
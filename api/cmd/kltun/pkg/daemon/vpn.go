@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -25,6 +26,13 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 	defer close(done) // Signal completion when function returns
 	fmt.Printf("[Session %s] Starting VPN connection\n", sessionID)
 
+	// The slot of the connection selects its WireGuard interface and local ports
+	s.connMutex.RLock()
+	vpnConn := s.connections[sessionID]
+	s.connMutex.RUnlock()
+	slot := vpnConn.Slot
+	wgPort, proxyPort := slotPorts(slot)
+
 	// Create Dashboard API client (only for getting tunnel endpoint and token exchange)
 	dashboardClient := api.NewClient(server, token)
 
@@ -82,7 +90,8 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 
 	// 3. Create WireGuard peer on tunnel server (send our public key)
 	fmt.Printf("[Session %s] Registering WireGuard peer on tunnel server...\n", sessionID)
-	peerResp, err := tunnelClient.CreatePeer(deviceID, keyPair.PublicKey)
+	// The tunnel server picks a subnet that does not overlap the other connections
+	peerResp, err := tunnelClient.CreatePeer(deviceID, deviceID, keyPair.PublicKey, s.otherTunnelCIDRs(sessionID))
 	if err != nil {
 		fmt.Printf("[Session %s] Failed to register WireGuard peer: %v\n", sessionID, err)
 		resultChan <- VPNConnectionSetupResult{Error: fmt.Errorf("failed to register WireGuard peer: %w", err)}
@@ -93,10 +102,16 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 	} else {
 		fmt.Printf("[Session %s] WireGuard peer created - IP: %s\n", sessionID, peerResp.IP)
 	}
+	if err := s.checkTunnelCIDR(sessionID, peerResp.CIDR); err != nil {
+		fmt.Printf("[Session %s] %v\n", sessionID, err)
+		resultChan <- VPNConnectionSetupResult{Error: err}
+		return
+	}
+	gateway := peerGateway(peerResp)
 
 	// 4. Start UDP-over-WebSocket client
 	fmt.Printf("[Session %s] Starting UDP-over-WebSocket client...\n", sessionID)
-	fmt.Printf("[Session %s] Local: 127.0.0.1:51821 -> Remote: %s\n", sessionID, tunnelEndpoint)
+	fmt.Printf("[Session %s] Local: 127.0.0.1:%d -> Remote: %s\n", sessionID, proxyPort, tunnelEndpoint)
 
 	// Create logger for UDP tunnel
 	logger, err := zap.NewProduction()
@@ -107,50 +122,23 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 	}
 	defer logger.Sync()
 
-	// Create WebSocket dialer with TLS 1.3 (required by tunnel-server)
-	transportConfig := transport.DefaultConfig()
-	tlsConfig := &tls.Config{
-		MinVersion:         tls.VersionTLS13,
-		InsecureSkipVerify: true, // Tunnel server uses self-signed cert
-	}
-	// Add Authorization header for WebSocket connection (using permanent token)
-	wsHeaders := http.Header{
-		"Authorization": []string{"Bearer " + permanentToken},
-	}
-	dialer := transport.NewWebSocketDialer(
-		transportConfig,
-		tlsConfig,
-		wsHeaders,
-		logger,
-	)
-
 	// Create UDP tunnel client
-	// Local: 127.0.0.1:51821 (where WireGuard will connect)
-	// Server: wss://tunnel-endpoint (WebSocket server)
+	// Local: 127.0.0.1:<proxy port of the slot> (where WireGuard will connect)
+	// Server: the tunnel endpoint, over QUIC or WebSocket
 	// Remote: 127.0.0.1:51820 (WireGuard on server side)
-	serverURL := "wss://" + tunnelEndpoint + "/ws"
-	udpClient := tunnel.NewUDPClient(
-		"127.0.0.1:51821",
-		serverURL,
-		"127.0.0.1:51820",
-		dialer,
-		logger,
-	)
+	udpClient := newTunnelClient(fmt.Sprintf("127.0.0.1:%d", proxyPort), tunnelEndpoint, permanentToken, vpnConn.Transport, logger)
 
 	// Start UDP tunnel client in background
-	go func() {
-		if err := udpClient.Start(ctx); err != nil && ctx.Err() == nil {
-			fmt.Printf("[Session %s] UDP tunnel error: %v\n", sessionID, err)
-		}
-	}()
+	stopUDPClient := startUDPClient(ctx, sessionID, udpClient)
 
 	fmt.Printf("[Session %s] ✓ UDP-over-WebSocket client started\n", sessionID)
 
 	// 6. Start WireGuard device
 	fmt.Printf("[Session %s] Starting WireGuard device...\n", sessionID)
 	wgDeviceConfig := &wireguard.Config{
-		ListenPort: 51820,
-		MTU:        1420,
+		InterfaceName: slotInterfaceName(slot),
+		ListenPort:    wgPort,
+		MTU:           1420,
 	}
 
 	wgDevice, err := wireguard.NewDevice(ctx, wgDeviceConfig)
@@ -165,8 +153,8 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 	netCfg := &netconfig.InterfaceConfig{
 		InterfaceName: wgDevice.InterfaceName(),
 		IPAddress:     fmt.Sprintf("%s/32", peerResp.IP),
-		Routes:        []string{"10.17.0.0/24", "10.43.0.0/16"},
-		Gateway:       "10.17.0.1",
+		Routes:        tunnelRoutes(peerResp.CIDR, s.claimServiceRoute(vpnConn)),
+		Gateway:       gateway,
 		MTU:           1420, // WireGuard standard MTU
 	}
 
@@ -186,7 +174,7 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 	// at the end of this function via the connection object
 
 	// Build WireGuard config locally using our private key and server's response
-	wgConfig := buildWireGuardConfig(keyPair.PrivateKey, peerResp.IP, peerResp.ServerPublicKey, peerResp.CIDR)
+	wgConfig := buildWireGuardConfig(keyPair.PrivateKey, peerResp.IP, peerResp.ServerPublicKey, peerResp.CIDR, wgPort, proxyPort)
 	fmt.Printf("[WGConfig] %s", wgConfig)
 	if err := wgDevice.SetConfig(wgConfig); err != nil {
 		fmt.Printf("[Session %s] Failed to set WireGuard config: %v\n", sessionID, err)
@@ -197,6 +185,7 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 	fmt.Printf("[Session %s] ✓ WireGuard device started (IP: %s)\n", sessionID, peerResp.IP)
 
 	// Store credentials and device in connection for reconnection
+	var activeConn *VPNConnection
 	s.connMutex.Lock()
 	if conn, exists := s.connections[sessionID]; exists {
 		conn.DashboardServer = server // Store dashboard URL for reconnection
@@ -212,6 +201,10 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 		conn.WGMutex.Lock()
 		conn.WireGuardDevice = wgDevice
 		conn.NetConfig = netCfg
+		conn.CIDR = peerResp.CIDR
+		conn.Gateway = gateway
+		conn.UDPClient = udpClient
+		conn.StopUDPClient = stopUDPClient
 		conn.WGMutex.Unlock()
 
 		// Start reconnection loop goroutine
@@ -220,15 +213,7 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 		// Store VPN IP for reference
 		conn.VPNIP = peerResp.IP
 
-		// Add vpn-check hostname pointing to 127.0.0.1 (localhost)
-		// This allows dashboard to verify kltun HTTPS server is reachable
-		// We use 127.0.0.1 so the server is always accessible when daemon is running
-		vpnCheckHostname := strings.Replace(tunnelInfo.Hostname, "vpn-connect", "vpn-check", 1)
-		if err := s.hostsManager.Add(vpnCheckHostname, "127.0.0.1", fmt.Sprintf("# kltun session %s vpn-check", sessionID)); err != nil {
-			fmt.Printf("[Session %s] Warning: Failed to add vpn-check host: %v\n", sessionID, err)
-		} else {
-			fmt.Printf("[Session %s] ✓ Added vpn-check: %s -> 127.0.0.1\n", sessionID, vpnCheckHostname)
-		}
+		activeConn = conn
 
 		// Download TLS certs and start HTTPS server for status/health endpoints
 		// The HTTPS server runs on 127.0.0.1:443 for the lifetime of the daemon
@@ -243,6 +228,17 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 	}
 	s.connMutex.Unlock()
 
+	if activeConn != nil {
+		// Resolve the Kloudlite domain with split DNS on the tunnel interface, or /etc/hosts.
+		// vpn-check points to 127.0.0.1 so that the dashboard can verify the kltun HTTPS server is reachable
+		vpnCheckHostname := strings.Replace(tunnelInfo.Hostname, "vpn-connect", "vpn-check", 1)
+		dns := newSessionDNS(sessionID, tunnelZone(tunnelInfo.Hostname), net.JoinHostPort(gateway, "53"), map[string]string{
+			tunnelInfo.Hostname: tunnelInfo.IP,
+			vpnCheckHostname:    "127.0.0.1",
+		})
+		s.startSessionDNS(activeConn, dns, netCfg.InterfaceName, peerResp.IP)
+	}
+
 	// Signal success - connection is established, pass tunnel info for CLI to handle CA
 	resultChan <- VPNConnectionSetupResult{
 		Error:          nil,
@@ -277,6 +273,13 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 		// Note: HTTPS server is daemon-level and not stopped here
 		// It continues running to report status even after VPN disconnects
 
+		// Unregister split DNS before the tunnel interface goes away
+		if dns := conn.getDNS(); dns != nil {
+			fmt.Printf("[Session %s] Stopping split DNS...\n", sessionID)
+			dns.stop()
+			conn.setDNS(nil)
+		}
+
 		conn.WGMutex.Lock()
 		if conn.WireGuardDevice != nil {
 			fmt.Printf("[Session %s] Closing WireGuard device...\n", sessionID)
@@ -291,6 +294,9 @@ func (s *Server) runVPNConnectionWithResult(ctx context.Context, sessionID, serv
 			conn.NetConfig = nil
 		}
 		conn.WGMutex.Unlock()
+
+		// Another connection routes the service CIDR from now on
+		s.handOverServiceRoute(conn)
 	}
 
 	// Cleanup all hosts for this session
@@ -358,7 +364,7 @@ func (s *Server) runVPNConnection(ctx context.Context, sessionID, server, token
 
 	// 3. Create WireGuard peer on tunnel server (send our public key)
 	fmt.Printf("[Session %s] Registering WireGuard peer on tunnel server...\n", sessionID)
-	peerResp, err := tunnelClient.CreatePeer(deviceID, keyPair.PublicKey)
+	peerResp, err := tunnelClient.CreatePeer(deviceID, deviceID, keyPair.PublicKey, nil)
 	if err != nil {
 		fmt.Printf("[Session %s] Failed to register WireGuard peer: %v\n", sessionID, err)
 		return
@@ -455,7 +461,7 @@ func (s *Server) runVPNConnection(ctx context.Context, sessionID, server, token
 	defer wgDevice.Close()
 
 	// Build WireGuard config locally using our private key and server's response
-	wgConfig := buildWireGuardConfig(keyPair.PrivateKey, peerResp.IP, peerResp.ServerPublicKey, peerResp.CIDR)
+	wgConfig := buildWireGuardConfig(keyPair.PrivateKey, peerResp.IP, peerResp.ServerPublicKey, peerResp.CIDR, wireGuardBasePort, wireGuardBasePort+1)
 	fmt.Printf("[WGConfig] %s", wgConfig)
 	if err := wgDevice.SetConfig(wgConfig); err != nil {
 		fmt.Printf("[Session %s] Failed to set WireGuard config: %v\n", sessionID, err)
@@ -522,24 +528,24 @@ func (s *Server) cleanupSessionHosts(sessionID string) {
 }
 
 // buildWireGuardConfig generates a WireGuard configuration string
-// The endpoint is 127.0.0.1:51821 because the client runs a local UDP proxy
+// The endpoint is 127.0.0.1:<proxyPort> because the client runs a local UDP proxy
 // that tunnels traffic over WebSocket to the server
-func buildWireGuardConfig(privateKey, peerIP, serverPublicKey, cidr string) string {
+func buildWireGuardConfig(privateKey, peerIP, serverPublicKey, cidr string, listenPort, proxyPort int) string {
 	// AllowedIPs includes:
 	// - VPN CIDR (e.g., 10.17.0.0/24) for VPN gateway communication
 	// - Service CIDR (10.43.0.0/16) for ClusterIP service access
-	// ListenPort 51820 is used by WireGuard for sending/receiving UDP
+	// ListenPort (51820 for the first connection) is used by WireGuard for sending/receiving UDP
 	return fmt.Sprintf(`[Interface]
 PrivateKey = %s
 Address = %s/32
-ListenPort = 51820
+ListenPort = %d
 
 [Peer]
 PublicKey = %s
-AllowedIPs = %s, 10.43.0.0/16
-Endpoint = 127.0.0.1:51821
+AllowedIPs = %s, %s
+Endpoint = 127.0.0.1:%d
 PersistentKeepalive = 25
-`, privateKey, peerIP, serverPublicKey, cidr)
+`, privateKey, peerIP, listenPort, serverPublicKey, cidr, serviceCIDR, proxyPort)
 }
 
 // reconnectionLoop monitors for reconnection signals and attempts to reconnect
@@ -550,59 +556,120 @@ func (s *Server) reconnectionLoop(ctx context.Context, conn *VPNConnection, host
 			return
 		case <-conn.ReconnectChan:
 			// Received signal to start reconnection attempts
-			fmt.Printf("[Session %s] Starting reconnection attempts (polling every %v)...\n", conn.SessionID, reconnectPollInterval)
+			fmt.Printf("[Session %s] Starting reconnection attempts (%s), then polling every %v...\n",
+				conn.SessionID, conn.GetStateReason(), reconnectPollInterval)
 		}
 
-		// Reconnection loop with linear polling (every 5 seconds)
+		// Reconnection loop, at once and then with linear polling (every 5 seconds)
 		for conn.GetState() == StateReconnecting {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(reconnectPollInterval):
-				// Poll Dashboard API for new tunnel endpoint (WorkMachine may have new IP)
-				fmt.Printf("[Session %s] Polling dashboard for tunnel endpoint...\n", conn.SessionID)
-				newEndpoint, err := s.fetchNewTunnelEndpoint(conn.DashboardServer, conn.PermanentToken)
-				if err != nil {
-					fmt.Printf("[Session %s] Dashboard not ready: %v, retrying in %v...\n", conn.SessionID, err, reconnectPollInterval)
-					continue
-				}
-
-				// Update /etc/hosts with new IP if changed
-				if newEndpoint.IP != conn.TunnelInfo.IP {
-					fmt.Printf("[Session %s] IP changed: %s -> %s, updating /etc/hosts...\n",
-						conn.SessionID, conn.TunnelInfo.IP, newEndpoint.IP)
-					if err := hostsManager.Add(newEndpoint.Hostname, newEndpoint.IP,
-						fmt.Sprintf("# kltun session %s", conn.SessionID)); err != nil {
-						fmt.Printf("[Session %s] Warning: Failed to update /etc/hosts: %v\n", conn.SessionID, err)
-					}
-				}
-
-				// Update connection with new endpoint info
-				conn.TunnelEndpoint = newEndpoint.TunnelEndpoint
-				conn.TunnelInfo = newEndpoint
-
-				fmt.Printf("[Session %s] Tunnel server ready at %s, attempting reconnect...\n",
-					conn.SessionID, newEndpoint.TunnelEndpoint)
-				if err := s.reestablishVPN(ctx, conn); err != nil {
-					fmt.Printf("[Session %s] Reconnect failed: %v, will retry in %v...\n", conn.SessionID, err, reconnectPollInterval)
-					continue // Keep polling - never exit on errors
-				}
-
-				// VPN re-established, verify connectivity by pinging 10.17.0.1
-				fmt.Printf("[Session %s] VPN re-established, verifying connectivity...\n", conn.SessionID)
-				time.Sleep(2 * time.Second) // Give VPN a moment to stabilize
-
-				if !checkVPNConnectivity() {
-					fmt.Printf("[Session %s] Connectivity check failed (10.17.0.1 unreachable), will retry in %v...\n", conn.SessionID, reconnectPollInterval)
-					continue // Keep polling
-				}
-
+			if s.attemptReconnect(ctx, conn, hostsManager) {
 				// Success - VPN connected and verified
 				fmt.Printf("[Session %s] Successfully reconnected! VPN connectivity verified.\n", conn.SessionID)
 				conn.SetState(StateConnected)
 				break // Exit inner loop, wait for next disconnect signal
 			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-conn.ReconnectChan:
+				// The network changed again, retry on the new one without waiting
+			case <-time.After(reconnectPollInterval):
+			}
+		}
+	}
+}
+
+// attemptReconnect re-resolves the tunnel endpoint and restores the tunnel, returning true once the
+// gateway is reachable again
+//
+// When the tunnel server did not move (e.g., the host roamed to another network), restarting the UDP
+// tunnel client on sockets of the current network is enough; otherwise the VPN is re-established.
+func (s *Server) attemptReconnect(ctx context.Context, conn *VPNConnection, hostsManager hosts.Manager) bool {
+	// Poll Dashboard API for new tunnel endpoint (WorkMachine may have new IP)
+	fmt.Printf("[Session %s] Polling dashboard for tunnel endpoint...\n", conn.SessionID)
+	newEndpoint, err := s.fetchNewTunnelEndpoint(conn.DashboardServer, conn.PermanentToken)
+	if err != nil {
+		fmt.Printf("[Session %s] Dashboard not ready: %v, retrying in %v...\n", conn.SessionID, err, reconnectPollInterval)
+		return false
+	}
+
+	// Update /etc/hosts with new IP if changed
+	if newEndpoint.IP != conn.TunnelInfo.IP {
+		fmt.Printf("[Session %s] IP changed: %s -> %s, updating /etc/hosts...\n",
+			conn.SessionID, conn.TunnelInfo.IP, newEndpoint.IP)
+		if err := hostsManager.Add(newEndpoint.Hostname, newEndpoint.IP,
+			fmt.Sprintf("# kltun session %s", conn.SessionID)); err != nil {
+			fmt.Printf("[Session %s] Warning: Failed to update /etc/hosts: %v\n", conn.SessionID, err)
+		}
+		if dns := conn.getDNS(); dns != nil {
+			dns.setStatic(newEndpoint.Hostname, newEndpoint.IP)
+		}
+	}
+
+	moved := newEndpoint.TunnelEndpoint != conn.TunnelEndpoint || newEndpoint.IP != conn.TunnelInfo.IP
+
+	// Update connection with new endpoint info
+	conn.TunnelEndpoint = newEndpoint.TunnelEndpoint
+	conn.TunnelInfo = newEndpoint
+
+	if !moved && conn.hasWireGuardDevice() {
+		fmt.Printf("[Session %s] Tunnel server unchanged at %s, rebinding the UDP tunnel...\n",
+			conn.SessionID, newEndpoint.TunnelEndpoint)
+		if err := s.rebindTunnel(ctx, conn); err != nil {
+			fmt.Printf("[Session %s] Rebind failed: %v\n", conn.SessionID, err)
+		} else if checkVPNConnectivity(conn.gateway()) {
+			return true
 		}
+		fmt.Printf("[Session %s] Rebinding did not restore connectivity, re-establishing the VPN...\n", conn.SessionID)
+	}
+
+	fmt.Printf("[Session %s] Tunnel server ready at %s, attempting reconnect...\n",
+		conn.SessionID, newEndpoint.TunnelEndpoint)
+	if err := s.reestablishVPN(ctx, conn); err != nil {
+		fmt.Printf("[Session %s] Reconnect failed: %v, will retry in %v...\n", conn.SessionID, err, reconnectPollInterval)
+		return false // Keep polling - never exit on errors
+	}
+
+	// VPN re-established, verify connectivity by pinging the gateway
+	fmt.Printf("[Session %s] VPN re-established, verifying connectivity...\n", conn.SessionID)
+	time.Sleep(2 * time.Second) // Give VPN a moment to stabilize
+
+	if !checkVPNConnectivity(conn.gateway()) {
+		fmt.Printf("[Session %s] Connectivity check failed (%s unreachable), will retry in %v...\n", conn.SessionID, conn.gateway(), reconnectPollInterval)
+		return false // Keep polling
+	}
+	return true
+}
+
+// rebindTunnel replaces the UDP tunnel client of a connection, whose sockets may be bound to a
+// network the host left, with a new one on the same local port
+func (s *Server) rebindTunnel(ctx context.Context, conn *VPNConnection) error {
+	_, proxyPort := slotPorts(conn.Slot)
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+
+	udpClient := newTunnelClient(fmt.Sprintf("127.0.0.1:%d", proxyPort), conn.TunnelEndpoint, conn.PermanentToken, conn.Transport, logger)
+
+	conn.replaceUDPClient(ctx, udpClient)
+	return nil
+}
+
+// handleNetworkChange reconnects the established VPN connections after the network of the host
+// changed, without waiting for their gateway checks to fail
+func (s *Server) handleNetworkChange(reason string) {
+	s.connMutex.RLock()
+	defer s.connMutex.RUnlock()
+
+	for _, conn := range s.connections {
+		if conn.ReconnectChan == nil {
+			continue // Still being established
+		}
+		fmt.Printf("[Session %s] %s, reconnecting...\n", conn.SessionID, reason)
+		conn.reconnect(reason)
 	}
 }
 
@@ -615,6 +682,13 @@ func (s *Server) fetchNewTunnelEndpoint(dashboardServer, token string) (*api.Tun
 // reestablishVPN re-establishes the VPN connection after disconnection
 func (s *Server) reestablishVPN(ctx context.Context, conn *VPNConnection) error {
 	sessionID := conn.SessionID
+	wgPort, proxyPort := slotPorts(conn.Slot)
+
+	// Split DNS is bound to the tunnel interface, it is restarted on the new one
+	dns := conn.getDNS()
+	if dns != nil {
+		dns.stop()
+	}
 
 	// First, clean up old WireGuard device and network config before creating new ones
 	// This prevents duplicate utun interfaces from accumulating
@@ -638,7 +712,7 @@ func (s *Server) reestablishVPN(ctx context.Context, conn *VPNConnection) error
 
 	// 1. Re-register WireGuard peer (may already exist)
 	fmt.Printf("[Session %s] Re-registering WireGuard peer...\n", sessionID)
-	peerResp, err := tunnelClient.CreatePeer(conn.DeviceID, conn.KeyPair.PublicKey)
+	peerResp, err := tunnelClient.CreatePeer(conn.DeviceID, conn.DeviceID, conn.KeyPair.PublicKey, s.otherTunnelCIDRs(sessionID))
 	if err != nil {
 		return fmt.Errorf("failed to register WireGuard peer: %w", err)
 	}
@@ -647,6 +721,10 @@ func (s *Server) reestablishVPN(ctx context.Context, conn *VPNConnection) error
 	} else {
 		fmt.Printf("[Session %s] WireGuard peer created - IP: %s\n", sessionID, peerResp.IP)
 	}
+	if err := s.checkTunnelCIDR(sessionID, peerResp.CIDR); err != nil {
+		return err
+	}
+	gateway := peerGateway(peerResp)
 
 	// 2. Re-establish UDP-over-WebSocket tunnel
 	fmt.Printf("[Session %s] Re-establishing UDP-over-WebSocket tunnel...\n", sessionID)
@@ -658,46 +736,19 @@ func (s *Server) reestablishVPN(ctx context.Context, conn *VPNConnection) error
 	}
 	defer logger.Sync()
 
-	// Create WebSocket dialer with TLS 1.3
-	transportConfig := transport.DefaultConfig()
-	tlsConfig := &tls.Config{
-		MinVersion:         tls.VersionTLS13,
-		InsecureSkipVerify: true,
-	}
-	wsHeaders := http.Header{
-		"Authorization": []string{"Bearer " + conn.PermanentToken},
-	}
-	dialer := transport.NewWebSocketDialer(
-		transportConfig,
-		tlsConfig,
-		wsHeaders,
-		logger,
-	)
+	// Create UDP tunnel client, replacing the previous one on the same local port
+	udpClient := newTunnelClient(fmt.Sprintf("127.0.0.1:%d", proxyPort), conn.TunnelEndpoint, conn.PermanentToken, conn.Transport, logger)
 
-	// Create UDP tunnel client
-	serverURL := "wss://" + conn.TunnelEndpoint + "/ws"
-	udpClient := tunnel.NewUDPClient(
-		"127.0.0.1:51821",
-		serverURL,
-		"127.0.0.1:51820",
-		dialer,
-		logger,
-	)
-
-	// Start UDP tunnel client in background
-	go func() {
-		if err := udpClient.Start(ctx); err != nil && ctx.Err() == nil {
-			fmt.Printf("[Session %s] UDP tunnel error: %v\n", sessionID, err)
-		}
-	}()
+	conn.replaceUDPClient(ctx, udpClient)
 
 	fmt.Printf("[Session %s] ✓ UDP-over-WebSocket tunnel re-established\n", sessionID)
 
 	// 3. Re-configure WireGuard device
 	fmt.Printf("[Session %s] Re-configuring WireGuard...\n", sessionID)
 	wgDeviceConfig := &wireguard.Config{
-		ListenPort: 51820,
-		MTU:        1420,
+		InterfaceName: slotInterfaceName(conn.Slot),
+		ListenPort:    wgPort,
+		MTU:           1420,
 	}
 
 	wgDevice, err := wireguard.NewDevice(ctx, wgDeviceConfig)
@@ -709,8 +760,8 @@ func (s *Server) reestablishVPN(ctx context.Context, conn *VPNConnection) error
 	netCfg := &netconfig.InterfaceConfig{
 		InterfaceName: wgDevice.InterfaceName(),
 		IPAddress:     fmt.Sprintf("%s/32", peerResp.IP),
-		Routes:        []string{"10.17.0.0/24", "10.43.0.0/16"},
-		Gateway:       "10.17.0.1",
+		Routes:        tunnelRoutes(peerResp.CIDR, s.claimServiceRoute(conn)),
+		Gateway:       gateway,
 		MTU:           1420, // WireGuard standard MTU
 	}
 
@@ -723,7 +774,7 @@ func (s *Server) reestablishVPN(ctx context.Context, conn *VPNConnection) error
 	}
 
 	// Build WireGuard config
-	wgConfig := buildWireGuardConfig(conn.KeyPair.PrivateKey, peerResp.IP, peerResp.ServerPublicKey, peerResp.CIDR)
+	wgConfig := buildWireGuardConfig(conn.KeyPair.PrivateKey, peerResp.IP, peerResp.ServerPublicKey, peerResp.CIDR, wgPort, proxyPort)
 	if err := wgDevice.SetConfig(wgConfig); err != nil {
 		return fmt.Errorf("failed to set WireGuard config: %w", err)
 	}
@@ -732,9 +783,56 @@ func (s *Server) reestablishVPN(ctx context.Context, conn *VPNConnection) error
 	conn.WGMutex.Lock()
 	conn.WireGuardDevice = wgDevice
 	conn.NetConfig = netCfg
+	conn.CIDR = peerResp.CIDR
+	conn.Gateway = gateway
 	conn.WGMutex.Unlock()
 
 	fmt.Printf("[Session %s] ✓ WireGuard re-configured (IP: %s)\n", sessionID, peerResp.IP)
 
+	if dns != nil {
+		dns.setZoneServer(net.JoinHostPort(gateway, "53"))
+		s.startSessionDNS(conn, dns, netCfg.InterfaceName, peerResp.IP)
+	}
+
 	return nil
 }
+
+// startUDPClient starts a UDP tunnel client in background, until ctx is done or the returned
+// function is called; the function returns once the client released its local port
+func startUDPClient(ctx context.Context, sessionID string, udpClient *tunnel.UDPClient) func() {
+	clientCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := udpClient.Start(clientCtx); err != nil && clientCtx.Err() == nil {
+			fmt.Printf("[Session %s] UDP tunnel error: %v\n", sessionID, err)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// newTunnelClient creates the UDP tunnel client from localAddr to WireGuard on the tunnel server,
+// over QUIC datagrams or WebSocket depending on mode (auto prefers QUIC)
+func newTunnelClient(localAddr, tunnelEndpoint, token string, mode tunnel.TransportMode, logger *zap.Logger) *tunnel.UDPClient {
+	// TLS 1.3 is required by tunnel-server
+	transportConfig := transport.DefaultConfig()
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS13,
+		InsecureSkipVerify: true, // Tunnel server uses self-signed cert
+	}
+	// Authorization header of the WebSocket upgrade and CONNECT-UDP requests (using permanent token)
+	headers := http.Header{
+		"Authorization": []string{"Bearer " + token},
+	}
+
+	dialer := transport.NewWebSocketDialer(transportConfig, tlsConfig, headers, logger)
+	udpClient := tunnel.NewUDPClient(localAddr, "wss://"+tunnelEndpoint+"/ws", "127.0.0.1:51820", dialer, logger)
+	if mode != tunnel.TransportWebSocket {
+		udpClient.SetDatagramDialer(transport.NewQUICDialer(transportConfig, tlsConfig, headers, logger), mode)
+	}
+	return udpClient
+}
@@ -32,7 +32,12 @@ func configureDarwin(config *InterfaceConfig) error {
 		return fmt.Errorf("failed to configure IP address: %w\nOutput: %s", err, string(output))
 	}
 
-	// Add routes for each specified network
+	addRoutesDarwin(config)
+	return nil
+}
+
+// addRoutesDarwin adds routes for each specified network
+func addRoutesDarwin(config *InterfaceConfig) {
 	for _, routeNet := range config.Routes {
 		// route -n add -net <network> -interface <interface>
 		cmd := exec.Command("route", "-n", "add", "-net", routeNet, "-interface", config.InterfaceName)
@@ -41,8 +46,6 @@ func configureDarwin(config *InterfaceConfig) error {
 			fmt.Printf("Warning: failed to add route %s: %v\nOutput: %s\n", routeNet, err, string(output))
 		}
 	}
-
-	return nil
 }
 
 // removeDarwin removes network configuration from interface on macOS
@@ -20,7 +20,12 @@ func configureLinux(config *InterfaceConfig) error {
 		return fmt.Errorf("failed to bring up interface: %w\nOutput: %s", err, string(output))
 	}
 
-	// Add routes for each specified network
+	addRoutesLinux(config)
+	return nil
+}
+
+// addRoutesLinux adds routes for each specified network
+func addRoutesLinux(config *InterfaceConfig) {
 	for _, routeNet := range config.Routes {
 		// ip route add <network> dev <interface>
 		cmd := exec.Command("ip", "route", "add", routeNet, "dev", config.InterfaceName)
@@ -29,8 +34,6 @@ func configureLinux(config *InterfaceConfig) error {
 			fmt.Printf("Warning: failed to add route %s: %v\nOutput: %s\n", routeNet, err, string(output))
 		}
 	}
-
-	return nil
 }
 
 // removeLinux removes network configuration from interface on Linux
@@ -45,6 +45,32 @@ func ConfigureInterface(config *InterfaceConfig) error {
 	}
 }
 
+// AddRoutes adds the routes of config to its interface, which must already be configured
+// (e.g., a route handed over from a removed interface)
+func AddRoutes(config *InterfaceConfig) error {
+	if config.InterfaceName == "" {
+		return fmt.Errorf("interface name is required")
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		addRoutesDarwin(config)
+		return nil
+	case "linux":
+		addRoutesLinux(config)
+		return nil
+	case "windows":
+		ifIndex, err := getInterfaceIndex(config.InterfaceName)
+		if err != nil {
+			return fmt.Errorf("failed to get interface index: %w", err)
+		}
+		addRoutesWindows(config, ifIndex)
+		return nil
+	default:
+		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+}
+
 // RemoveInterface removes IP address and routes from a network interface
 func RemoveInterface(config *InterfaceConfig) error {
 	if config.InterfaceName == "" {
@@ -43,7 +43,12 @@ func configureWindows(config *InterfaceConfig) error {
 		}
 	}
 
-	// Add routes for each specified network
+	addRoutesWindows(config, ifIndex)
+	return nil
+}
+
+// addRoutesWindows adds routes for each specified network through the interface with index ifIndex
+func addRoutesWindows(config *InterfaceConfig, ifIndex string) {
 	for _, routeNet := range config.Routes {
 		// Parse route network and prefix
 		routeParts := strings.Split(routeNet, "/")
@@ -61,8 +66,6 @@ func configureWindows(config *InterfaceConfig) error {
 			fmt.Printf("Warning: failed to add route %s: %v\nOutput: %s\n", routeNet, err, string(output))
 		}
 	}
-
-	return nil
 }
 
 // removeWindows removes network configuration from interface on Windows
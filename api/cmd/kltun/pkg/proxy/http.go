@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// hopHeaders are the headers of a single connection, not forwarded by the HTTP proxy (RFC 9110)
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// ServeHTTP serves an HTTP proxy on ln until ctx is done: CONNECT tunnels (HTTPS) and plain HTTP
+// requests with absolute URLs, dialed with dial. Without authentication, the listener is meant to
+// be bound to the loopback interface.
+func ServeHTTP(ctx context.Context, ln net.Listener, dial Dialer) error {
+	transport := &http.Transport{
+		DialContext:           dial,
+		MaxIdleConnsPerHost:   4,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Minute,
+	}
+	defer transport.CloseIdleConnections()
+
+	server := &http.Server{
+		Handler:           &httpProxy{ctx: ctx, dial: dial, transport: transport},
+		ReadHeaderTimeout: 30 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	if err := server.Serve(ln); err != nil && err != http.ErrServerClosed && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// httpProxy is the handler of the HTTP proxy
+type httpProxy struct {
+	ctx       context.Context
+	dial      Dialer
+	transport *http.Transport
+}
+
+func (p *httpProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.serveConnect(w, r)
+		return
+	}
+
+	if !r.URL.IsAbs() || r.URL.Scheme != "http" {
+		http.Error(w, "only absolute http:// URLs and CONNECT are proxied", http.StatusBadRequest)
+		return
+	}
+
+	outReq := r.Clone(r.Context())
+	outReq.RequestURI = ""
+	removeHopHeaders(outReq.Header)
+
+	resp, err := p.transport.RoundTrip(outReq)
+	if err != nil {
+		fmt.Printf("[HTTP proxy] %s %s: %v\n", r.Method, r.URL.Host, err)
+		http.Error(w, fmt.Sprintf("failed to reach %s: %v", r.URL.Host, err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// serveConnect tunnels the connection of the client to the host of a CONNECT request
+func (p *httpProxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	dialCtx, cancel := context.WithTimeout(r.Context(), dialTimeout)
+	dest, err := p.dial(dialCtx, "tcp", r.Host)
+	cancel()
+	if err != nil {
+		fmt.Printf("[HTTP proxy] CONNECT %s: %v\n", r.Host, err)
+		http.Error(w, fmt.Sprintf("failed to reach %s: %v", r.Host, err), http.StatusBadGateway)
+		return
+	}
+	defer dest.Close()
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	client, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		return
+	}
+
+	// The client may have sent data along with the request
+	if n := rw.Reader.Buffered(); n > 0 {
+		buffered, _ := rw.Reader.Peek(n)
+		if _, err := dest.Write(buffered); err != nil {
+			return
+		}
+	}
+	pipe(p.ctx, client, dest)
+}
+
+// removeHopHeaders removes the hop-by-hop headers, including the ones listed in Connection
+func removeHopHeaders(header http.Header) {
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			header.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
@@ -0,0 +1,81 @@
+// Package proxy serves local SOCKS5 and HTTP proxies, which let programs reach the destinations of
+// a userspace tunnel without a TUN interface or system-wide routes
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Dialer dials the destinations of proxied connections, through the tunnel or directly
+type Dialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+// dialTimeout bounds the dial of a proxied connection
+const dialTimeout = 30 * time.Second
+
+// serve accepts connections on ln until ctx is done, and handles each one in its own goroutine
+func serve(ctx context.Context, ln net.Listener, handle func(ctx context.Context, conn net.Conn)) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			handle(ctx, conn)
+		}()
+	}
+}
+
+// pipe copies between the client and the destination until both directions are done, or ctx is
+func pipe(ctx context.Context, client, dest net.Conn) {
+	stop := context.AfterFunc(ctx, func() {
+		client.Close()
+		dest.Close()
+	})
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(dest, client)
+		closeWrite(dest)
+	}()
+	io.Copy(client, dest)
+	closeWrite(client)
+	<-done
+}
+
+// closeWrite half-closes conn, so that the other side reads EOF while responses still come back
+func closeWrite(conn net.Conn) {
+	if c, ok := conn.(interface{ CloseWrite() error }); ok {
+		c.CloseWrite()
+		return
+	}
+	conn.Close()
+}
+
+// isConnectionRefused reports whether a dial failed because nothing listens on the destination port
+func isConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
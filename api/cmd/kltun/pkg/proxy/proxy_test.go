@@ -0,0 +1,190 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// startEcho starts a TCP server echoing lines back
+func startEcho(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// fakeDialer dials the names of hosts to their addresses, like the tunnel resolves service names
+func fakeDialer(hosts map[string]string) Dialer {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		target, ok := hosts[address]
+		if !ok {
+			return nil, &net.DNSError{Err: "no such host", Name: address, IsNotFound: true}
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, target)
+	}
+}
+
+// startProxy serves a proxy on a local port until the test ends
+func startProxy(t *testing.T, serve func(context.Context, net.Listener, Dialer) error, dial Dialer) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serve(ctx, ln, dial)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return ln.Addr().String()
+}
+
+// socks5Connect sends a CONNECT request for a domain name and returns the reply status
+func socks5Connect(t *testing.T, conn net.Conn, r *bufio.Reader, host string, port int) byte {
+	t.Helper()
+	if _, err := conn.Write([]byte{socks5Version, 1, socks5NoAuth}); err != nil {
+		t.Fatalf("failed to write greeting: %v", err)
+	}
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(r, greeting); err != nil || greeting[1] != socks5NoAuth {
+		t.Fatalf("greeting reply = %v, %v", greeting, err)
+	}
+
+	request := []byte{socks5Version, socks5CmdConnect, 0, socks5AddrDomain, byte(len(host))}
+	request = append(request, host...)
+	request = append(request, byte(port>>8), byte(port))
+	if _, err := conn.Write(request); err != nil {
+		t.Fatalf("failed to write request: %v", err)
+	}
+	reply := make([]byte, 10)
+	if _, err := io.ReadFull(r, reply); err != nil {
+		t.Fatalf("failed to read reply: %v", err)
+	}
+	return reply[1]
+}
+
+func TestSOCKS5(t *testing.T) {
+	echo := startEcho(t)
+	addr := startProxy(t, ServeSOCKS5, fakeDialer(map[string]string{"db:5432": echo}))
+
+	t.Run("connect", func(t *testing.T) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("failed to dial proxy: %v", err)
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		if status := socks5Connect(t, conn, r, "db", 5432); status != socks5Succeeded {
+			t.Fatalf("status = %d, want success", status)
+		}
+		fmt.Fprintln(conn, "ping")
+		if line, err := r.ReadString('\n'); err != nil || line != "ping\n" {
+			t.Errorf("echo = %q, %v", line, err)
+		}
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("failed to dial proxy: %v", err)
+		}
+		defer conn.Close()
+
+		if status := socks5Connect(t, conn, bufio.NewReader(conn), "nope", 80); status != socks5HostUnreachable {
+			t.Errorf("status = %d, want host unreachable", status)
+		}
+	})
+}
+
+func TestHTTPProxy(t *testing.T) {
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Connection") != "" {
+			t.Error("hop-by-hop header forwarded")
+		}
+		fmt.Fprintf(w, "hello from %s", r.Host)
+	}))
+	defer web.Close()
+	echo := startEcho(t)
+
+	addr := startProxy(t, ServeHTTP, fakeDialer(map[string]string{
+		"api:80":  strings.TrimPrefix(web.URL, "http://"),
+		"db:5432": echo,
+	}))
+
+	t.Run("forward", func(t *testing.T) {
+		proxyURL, _ := url.Parse("http://" + addr)
+		client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		resp, err := client.Get("http://api/")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "hello from api" {
+			t.Errorf("response = %d %q", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		proxyURL, _ := url.Parse("http://" + addr)
+		client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		resp, err := client.Get("http://nope/")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadGateway {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadGateway)
+		}
+	})
+
+	t.Run("connect", func(t *testing.T) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("failed to dial proxy: %v", err)
+		}
+		defer conn.Close()
+
+		fmt.Fprint(conn, "CONNECT db:5432 HTTP/1.1\r\nHost: db:5432\r\n\r\n")
+		r := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(r, &http.Request{Method: http.MethodConnect})
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("CONNECT response = %v, %v", resp, err)
+		}
+		fmt.Fprintln(conn, "ping")
+		if line, err := r.ReadString('\n'); err != nil || line != "ping\n" {
+			t.Errorf("echo = %q, %v", line, err)
+		}
+	})
+}
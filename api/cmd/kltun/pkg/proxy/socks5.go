@@ -0,0 +1,174 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// SOCKS5 protocol constants (RFC 1928)
+const (
+	socks5Version = 0x05
+
+	socks5NoAuth       = 0x00
+	socks5NoAcceptable = 0xff
+
+	socks5CmdConnect = 0x01
+
+	socks5AddrIPv4   = 0x01
+	socks5AddrDomain = 0x03
+	socks5AddrIPv6   = 0x04
+
+	socks5Succeeded           = 0x00
+	socks5GeneralFailure      = 0x01
+	socks5HostUnreachable     = 0x04
+	socks5ConnectionRefused   = 0x05
+	socks5CmdNotSupported     = 0x07
+	socks5AddrTypeUnsupported = 0x08
+)
+
+// errUnsupportedAddrType is returned for SOCKS5 requests with an unknown address type
+var errUnsupportedAddrType = errors.New("unsupported address type")
+
+// ServeSOCKS5 serves a SOCKS5 proxy on ln until ctx is done. Only CONNECT without authentication
+// is supported: the listener is meant to be bound to the loopback interface. Domain names are
+// passed to dial unresolved, so that names of the tunnel resolve through it.
+func ServeSOCKS5(ctx context.Context, ln net.Listener, dial Dialer) error {
+	return serve(ctx, ln, func(ctx context.Context, conn net.Conn) {
+		if err := handleSOCKS5(ctx, conn, dial); err != nil {
+			fmt.Printf("[SOCKS5] %s: %v\n", conn.RemoteAddr(), err)
+		}
+	})
+}
+
+func handleSOCKS5(ctx context.Context, conn net.Conn, dial Dialer) error {
+	r := bufio.NewReader(conn)
+
+	// Greeting: version, then the authentication methods of the client
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("failed to read greeting: %w", err)
+	}
+	if header[0] != socks5Version {
+		return fmt.Errorf("unsupported SOCKS version %d", header[0])
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(r, methods); err != nil {
+		return fmt.Errorf("failed to read authentication methods: %w", err)
+	}
+	method := byte(socks5NoAcceptable)
+	for _, m := range methods {
+		if m == socks5NoAuth {
+			method = socks5NoAuth
+		}
+	}
+	if _, err := conn.Write([]byte{socks5Version, method}); err != nil {
+		return err
+	}
+	if method == socks5NoAcceptable {
+		return errors.New("client does not support connecting without authentication")
+	}
+
+	// Request: version, command, reserved, then the destination
+	request := make([]byte, 3)
+	if _, err := io.ReadFull(r, request); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	address, err := readSOCKS5Addr(r)
+	if err != nil {
+		if errors.Is(err, errUnsupportedAddrType) {
+			writeSOCKS5Reply(conn, socks5AddrTypeUnsupported)
+		}
+		return err
+	}
+	if request[1] != socks5CmdConnect {
+		writeSOCKS5Reply(conn, socks5CmdNotSupported)
+		return fmt.Errorf("unsupported command %d", request[1])
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	dest, err := dial(dialCtx, "tcp", address)
+	cancel()
+	if err != nil {
+		writeSOCKS5Reply(conn, socks5ReplyOf(err))
+		return fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	defer dest.Close()
+
+	if err := writeSOCKS5Reply(conn, socks5Succeeded); err != nil {
+		return err
+	}
+
+	// The client may have sent data along with the request
+	if n := r.Buffered(); n > 0 {
+		buffered, _ := r.Peek(n)
+		if _, err := dest.Write(buffered); err != nil {
+			return err
+		}
+	}
+	pipe(ctx, conn, dest)
+	return nil
+}
+
+// readSOCKS5Addr reads the destination of a request as host:port
+func readSOCKS5Addr(r io.Reader) (string, error) {
+	addrType := make([]byte, 1)
+	if _, err := io.ReadFull(r, addrType); err != nil {
+		return "", fmt.Errorf("failed to read address type: %w", err)
+	}
+
+	var host string
+	switch addrType[0] {
+	case socks5AddrIPv4, socks5AddrIPv6:
+		ip := make(net.IP, net.IPv4len)
+		if addrType[0] == socks5AddrIPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", fmt.Errorf("failed to read address: %w", err)
+		}
+		host = ip.String()
+	case socks5AddrDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(r, length); err != nil {
+			return "", fmt.Errorf("failed to read address: %w", err)
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(r, domain); err != nil {
+			return "", fmt.Errorf("failed to read address: %w", err)
+		}
+		host = string(domain)
+	default:
+		return "", fmt.Errorf("%w %d", errUnsupportedAddrType, addrType[0])
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return "", fmt.Errorf("failed to read port: %w", err)
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// writeSOCKS5Reply writes a reply without a bound address, which clients do not use for CONNECT
+func writeSOCKS5Reply(conn net.Conn, status byte) error {
+	_, err := conn.Write([]byte{socks5Version, status, 0x00, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
+
+// socks5ReplyOf returns the reply status of a failed dial
+func socks5ReplyOf(err error) byte {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr), errors.Is(err, context.DeadlineExceeded):
+		return socks5HostUnreachable
+	case isConnectionRefused(err):
+		return socks5ConnectionRefused
+	default:
+		return socks5GeneralFailure
+	}
+}
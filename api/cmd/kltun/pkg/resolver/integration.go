@@ -0,0 +1,46 @@
+package resolver
+
+import (
+	"errors"
+	"net"
+
+	"github.com/miekg/dns"
+)
+
+// ErrNoIntegration is returned by Detect when the system resolver cannot route domains to the
+// resolver, kltun then falls back to writing the hosts to /etc/hosts
+var ErrNoIntegration = errors.New("no system resolver integration available")
+
+// Integration routes domains of the system resolver to a Resolver
+type Integration interface {
+	// Name identifies the integration (e.g., "systemd-resolved")
+	Name() string
+
+	// Apply routes the domains to the resolver, replacing the domains applied before
+	Apply(domains []string) error
+
+	// Revert restores the configuration of the system resolver
+	Revert() error
+}
+
+// SystemUpstreams returns the DNS servers of the system, to forward the names the resolver does
+// not answer to; exclude is the address of the resolver itself
+func SystemUpstreams(exclude string) []string {
+	var upstreams []string
+	for _, path := range upstreamResolvConfPaths {
+		cfg, err := dns.ClientConfigFromFile(path)
+		if err != nil {
+			continue
+		}
+		for _, server := range cfg.Servers {
+			if server == exclude {
+				continue
+			}
+			upstreams = append(upstreams, net.JoinHostPort(server, cfg.Port))
+		}
+		if len(upstreams) > 0 {
+			return upstreams
+		}
+	}
+	return upstreams
+}
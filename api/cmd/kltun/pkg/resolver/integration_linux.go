@@ -0,0 +1,107 @@
+//go:build linux
+
+package resolver
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// upstreamResolvConfPaths lists the resolv.conf files with the upstream servers of the system, the
+// one of systemd-resolved first as /etc/resolv.conf usually points to its stub listener
+var upstreamResolvConfPaths = []string{"/run/systemd/resolve/resolv.conf", "/etc/resolv.conf"}
+
+// Detect returns the integration of the system resolver for the tunnel interface: the domains are
+// registered as routing domains of the interface with systemd-resolved, or the resolver is added as
+// the nameserver of the interface with resolvconf
+func Detect(interfaceName, resolverIP string) (Integration, error) {
+	if _, err := exec.LookPath("resolvectl"); err == nil {
+		if err := exec.Command("resolvectl", "status", "--no-pager").Run(); err == nil {
+			return &systemdResolved{interfaceName: interfaceName, resolverIP: resolverIP}, nil
+		}
+	}
+	if _, err := exec.LookPath("resolvconf"); err == nil {
+		return &resolvconf{interfaceName: interfaceName, resolverIP: resolverIP}, nil
+	}
+	return nil, ErrNoIntegration
+}
+
+// systemdResolved configures systemd-resolved through resolvectl (its D-Bus API)
+type systemdResolved struct {
+	interfaceName string
+	resolverIP    string
+}
+
+func (s *systemdResolved) Name() string {
+	return "systemd-resolved"
+}
+
+// Apply sets the resolver as the DNS server of the interface, and the domains as its routing
+// domains ("~domain"), so that only these domains are resolved through the tunnel
+func (s *systemdResolved) Apply(domains []string) error {
+	if output, err := exec.Command("resolvectl", "dns", s.interfaceName, s.resolverIP).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set DNS server of %s: %w\nOutput: %s", s.interfaceName, err, string(output))
+	}
+
+	args := []string{"domain", s.interfaceName}
+	for _, domain := range domains {
+		args = append(args, "~"+domain)
+	}
+	if output, err := exec.Command("resolvectl", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set routing domains of %s: %w\nOutput: %s", s.interfaceName, err, string(output))
+	}
+
+	// The tunnel interface must never become the default route for DNS
+	if output, err := exec.Command("resolvectl", "default-route", s.interfaceName, "false").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to unset default DNS route of %s: %w\nOutput: %s", s.interfaceName, err, string(output))
+	}
+	return nil
+}
+
+func (s *systemdResolved) Revert() error {
+	if output, err := exec.Command("resolvectl", "revert", s.interfaceName).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to revert DNS configuration of %s: %w\nOutput: %s", s.interfaceName, err, string(output))
+	}
+	return nil
+}
+
+// resolvconf registers the resolver as the nameserver of the interface with resolvconf
+//
+// resolvconf has no routing domains: every query goes to the resolver, which forwards the names
+// outside of the Kloudlite domain to the upstream servers read before it was registered.
+type resolvconf struct {
+	interfaceName string
+	resolverIP    string
+}
+
+func (r *resolvconf) Name() string {
+	return "resolvconf"
+}
+
+func (r *resolvconf) Apply(domains []string) error {
+	var conf strings.Builder
+	fmt.Fprintf(&conf, "nameserver %s\n", r.resolverIP)
+	if len(domains) > 0 {
+		fmt.Fprintf(&conf, "search %s\n", domains[0])
+	}
+
+	cmd := exec.Command("resolvconf", "-a", r.recordName())
+	cmd.Stdin = strings.NewReader(conf.String())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to register resolver with resolvconf: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+func (r *resolvconf) Revert() error {
+	if output, err := exec.Command("resolvconf", "-d", r.recordName(), "-f").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to unregister resolver from resolvconf: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// recordName is the resolvconf record of the interface
+func (r *resolvconf) recordName() string {
+	return r.interfaceName + ".kltun"
+}
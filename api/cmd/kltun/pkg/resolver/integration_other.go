@@ -0,0 +1,11 @@
+//go:build !linux
+
+package resolver
+
+// upstreamResolvConfPaths lists the resolv.conf files with the upstream servers of the system
+var upstreamResolvConfPaths = []string{"/etc/resolv.conf"}
+
+// Detect returns ErrNoIntegration: split DNS is only integrated with the system resolver on Linux
+func Detect(interfaceName, resolverIP string) (Integration, error) {
+	return nil, ErrNoIntegration
+}
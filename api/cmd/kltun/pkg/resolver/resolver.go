@@ -0,0 +1,268 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// recordTTL is the TTL of the answers served from the hosts of the tunnel server
+	recordTTL = 30
+
+	// exchangeTimeout bounds a query forwarded to the tunnel server or an upstream server
+	exchangeTimeout = 5 * time.Second
+)
+
+// Config holds the configuration of a Resolver
+type Config struct {
+	// ListenAddr is the address the resolver listens on (e.g., "10.17.0.2:53"), UDP and TCP
+	ListenAddr string
+
+	// Zone is the Kloudlite domain (e.g., "beanbag.khost.dev"), answered from the hosts or by ZoneServer
+	Zone string
+
+	// ZoneServer is the DNS server of the tunnel server (e.g., "10.17.0.1:53"), asked for the
+	// names of the zone that are not in the hosts
+	ZoneServer string
+
+	// Upstreams are the DNS servers names outside of the zone are forwarded to
+	Upstreams []string
+}
+
+// Resolver is a small DNS server answering the Kloudlite domain from the hosts of the tunnel
+// server, and forwarding everything else
+//
+// Hosts may be wildcards ("*.app.beanbag.khost.dev"), matching any name below them. Names of the
+// zone that are not in the hosts are asked to the tunnel server, which always has the current
+// records; names outside of the zone are forwarded to the upstream servers.
+type Resolver struct {
+	cfg Config
+
+	mu    sync.RWMutex
+	hosts map[string]string // lower-cased hostname (or wildcard) -> IP
+
+	udpServer *dns.Server
+	tcpServer *dns.Server
+}
+
+// New creates a resolver, Start must be called to serve queries
+func New(cfg Config) *Resolver {
+	cfg.Zone = normalize(cfg.Zone)
+	return &Resolver{
+		cfg:   cfg,
+		hosts: make(map[string]string),
+	}
+}
+
+// Start listens on the configured address, and serves queries until Stop is called
+func (r *Resolver) Start() error {
+	handler := dns.HandlerFunc(r.handleQuery)
+
+	udpConn, err := net.ListenPacket("udp", r.cfg.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on udp %s: %w", r.cfg.ListenAddr, err)
+	}
+	tcpListener, err := net.Listen("tcp", r.cfg.ListenAddr)
+	if err != nil {
+		udpConn.Close()
+		return fmt.Errorf("failed to listen on tcp %s: %w", r.cfg.ListenAddr, err)
+	}
+
+	r.udpServer = &dns.Server{PacketConn: udpConn, Handler: handler}
+	r.tcpServer = &dns.Server{Listener: tcpListener, Handler: handler}
+
+	go r.udpServer.ActivateAndServe()
+	go r.tcpServer.ActivateAndServe()
+	return nil
+}
+
+// Stop stops serving queries
+func (r *Resolver) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), exchangeTimeout)
+	defer cancel()
+
+	var lastErr error
+	for _, srv := range []*dns.Server{r.udpServer, r.tcpServer} {
+		if srv == nil {
+			continue
+		}
+		if err := srv.ShutdownContext(ctx); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// ListenAddr returns the address the resolver listens on
+func (r *Resolver) ListenAddr() string {
+	return r.cfg.ListenAddr
+}
+
+// SetHosts replaces the hosts answered by the resolver (hostname -> IP)
+func (r *Resolver) SetHosts(hosts map[string]string) {
+	next := make(map[string]string, len(hosts))
+	for hostname, ip := range hosts {
+		next[normalize(hostname)] = ip
+	}
+
+	r.mu.Lock()
+	r.hosts = next
+	r.mu.Unlock()
+}
+
+// Hosts returns the hosts answered by the resolver
+func (r *Resolver) Hosts() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hosts := make(map[string]string, len(r.hosts))
+	for hostname, ip := range r.hosts {
+		hosts[hostname] = ip
+	}
+	return hosts
+}
+
+// Domains returns the domains that must be routed to the resolver: the zone, and the hosts
+// outside of it (e.g., ingress hosts of custom domains)
+func (r *Resolver) Domains() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := map[string]bool{}
+	var outside []string
+	for hostname := range r.hosts {
+		domain := strings.TrimPrefix(hostname, "*.")
+		if seen[domain] || r.inZone(domain) {
+			continue
+		}
+		seen[domain] = true
+		outside = append(outside, domain)
+	}
+	sort.Strings(outside)
+
+	var domains []string
+	if r.cfg.Zone != "" {
+		domains = append(domains, r.cfg.Zone)
+	}
+	domains = append(domains, outside...)
+	return domains
+}
+
+// lookup finds the IP of a name in the hosts, exact names before wildcards, the most specific
+// wildcard first
+func (r *Resolver) lookup(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if ip, ok := r.hosts[name]; ok {
+		return ip, true
+	}
+	for parent := name; ; {
+		i := strings.IndexByte(parent, '.')
+		if i < 0 {
+			return "", false
+		}
+		parent = parent[i+1:]
+		if ip, ok := r.hosts["*."+parent]; ok {
+			return ip, true
+		}
+	}
+}
+
+// inZone reports whether name is the zone or below it; must be called with r.mu held
+func (r *Resolver) inZone(name string) bool {
+	if r.cfg.Zone == "" {
+		return false
+	}
+	return name == r.cfg.Zone || strings.HasSuffix(name, "."+r.cfg.Zone)
+}
+
+// isLocal reports whether name is answered by the resolver or the tunnel server
+func (r *Resolver) isLocal(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.inZone(name)
+}
+
+// handleQuery answers a query from the hosts, or forwards it
+func (r *Resolver) handleQuery(w dns.ResponseWriter, req *dns.Msg) {
+	if len(req.Question) != 1 {
+		m := new(dns.Msg)
+		m.SetRcode(req, dns.RcodeFormatError)
+		w.WriteMsg(m)
+		return
+	}
+
+	q := req.Question[0]
+	name := normalize(q.Name)
+
+	if ip, ok := r.lookup(name); ok && q.Qclass == dns.ClassINET {
+		w.WriteMsg(answer(req, q, ip))
+		return
+	}
+
+	if r.isLocal(name) && r.cfg.ZoneServer != "" {
+		r.forward(w, req, []string{r.cfg.ZoneServer})
+		return
+	}
+	r.forward(w, req, r.cfg.Upstreams)
+}
+
+// answer builds the reply to q for a host, without records when the type does not match the IP
+func answer(req *dns.Msg, q dns.Question, ip string) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(req)
+	m.Authoritative = true
+	m.RecursionAvailable = true
+
+	parsed := net.ParseIP(ip)
+	hdr := dns.RR_Header{Name: q.Name, Class: dns.ClassINET, Ttl: recordTTL}
+	switch {
+	case parsed == nil:
+	case q.Qtype == dns.TypeA && parsed.To4() != nil:
+		hdr.Rrtype = dns.TypeA
+		m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: parsed.To4()})
+	case q.Qtype == dns.TypeAAAA && parsed.To4() == nil:
+		hdr.Rrtype = dns.TypeAAAA
+		m.Answer = append(m.Answer, &dns.AAAA{Hdr: hdr, AAAA: parsed})
+	}
+	return m
+}
+
+// forward sends the query to the first of servers that answers, SERVFAIL when none does
+func (r *Resolver) forward(w dns.ResponseWriter, req *dns.Msg, servers []string) {
+	network := "udp"
+	if _, ok := w.RemoteAddr().(*net.TCPAddr); ok {
+		network = "tcp"
+	}
+
+	for _, server := range servers {
+		client := &dns.Client{Net: network, Timeout: exchangeTimeout}
+		resp, _, err := client.Exchange(req, server)
+		if err == nil && resp.Truncated && network == "udp" {
+			client.Net = "tcp"
+			resp, _, err = client.Exchange(req, server)
+		}
+		if err != nil {
+			continue
+		}
+		w.WriteMsg(resp)
+		return
+	}
+
+	m := new(dns.Msg)
+	m.SetRcode(req, dns.RcodeServerFailure)
+	w.WriteMsg(m)
+}
+
+// normalize lower-cases a name and removes its trailing dot
+func normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
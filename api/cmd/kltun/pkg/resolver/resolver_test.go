@@ -0,0 +1,89 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestLookup(t *testing.T) {
+	r := New(Config{Zone: "Beanbag.khost.dev."})
+	r.SetHosts(map[string]string{
+		"api-1a2b3c4d.beanbag.khost.dev":      "10.43.0.10",
+		"*.app.beanbag.khost.dev":             "10.43.0.20",
+		"*.preview.app.beanbag.khost.dev":     "10.43.0.21",
+		"Docs.Example.com":                    "10.43.0.30",
+		"exact.preview.app.beanbag.khost.dev": "10.43.0.22",
+	})
+
+	tests := []struct {
+		name   string
+		wantIP string
+	}{
+		{name: "api-1a2b3c4d.beanbag.khost.dev", wantIP: "10.43.0.10"},
+		{name: "web-8080.app.beanbag.khost.dev", wantIP: "10.43.0.20"},
+		{name: "a.b.app.beanbag.khost.dev", wantIP: "10.43.0.20"},
+		{name: "pr-1.preview.app.beanbag.khost.dev", wantIP: "10.43.0.21"},
+		{name: "exact.preview.app.beanbag.khost.dev", wantIP: "10.43.0.22"},
+		{name: "docs.example.com", wantIP: "10.43.0.30"},
+		{name: "app.beanbag.khost.dev"},
+		{name: "unknown.beanbag.khost.dev"},
+	}
+	for _, tt := range tests {
+		ip, ok := r.lookup(tt.name)
+		if tt.wantIP == "" {
+			if ok {
+				t.Errorf("lookup(%q) = %q, want no match", tt.name, ip)
+			}
+			continue
+		}
+		if !ok || ip != tt.wantIP {
+			t.Errorf("lookup(%q) = %q, %v, want %q", tt.name, ip, ok, tt.wantIP)
+		}
+	}
+
+	if !r.isLocal("unknown.beanbag.khost.dev") || r.isLocal("khost.dev") || r.isLocal("evilbeanbag.khost.dev") {
+		t.Errorf("isLocal does not match the zone and the names below it only")
+	}
+}
+
+func TestDomains(t *testing.T) {
+	r := New(Config{Zone: "beanbag.khost.dev"})
+	r.SetHosts(map[string]string{
+		"api-1a2b3c4d.beanbag.khost.dev": "10.43.0.10",
+		"*.apps.example.com":             "10.43.0.20",
+		"docs.example.com":               "10.43.0.30",
+		"*.app.beanbag.khost.dev":        "10.43.0.40",
+	})
+
+	got := r.Domains()
+	want := []string{"beanbag.khost.dev", "apps.example.com", "docs.example.com"}
+	if len(got) != len(want) {
+		t.Fatalf("Domains() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Domains() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestAnswer(t *testing.T) {
+	req := new(dns.Msg)
+	req.SetQuestion("api.beanbag.khost.dev.", dns.TypeA)
+
+	m := answer(req, req.Question[0], "10.43.0.10")
+	if !m.Authoritative || m.Rcode != dns.RcodeSuccess || len(m.Answer) != 1 {
+		t.Fatalf("A answer = %v", m)
+	}
+	if a, ok := m.Answer[0].(*dns.A); !ok || a.A.String() != "10.43.0.10" {
+		t.Errorf("A answer record = %v", m.Answer[0])
+	}
+
+	// An IPv4 host has no AAAA record, the name exists
+	req.SetQuestion("api.beanbag.khost.dev.", dns.TypeAAAA)
+	m = answer(req, req.Question[0], "10.43.0.10")
+	if m.Rcode != dns.RcodeSuccess || len(m.Answer) != 0 {
+		t.Errorf("AAAA answer = %v, want NOERROR without records", m)
+	}
+}
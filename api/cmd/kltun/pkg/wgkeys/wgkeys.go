@@ -63,8 +63,9 @@ func GetOrCreateKeyPair() (*KeyPair, error) {
 	return generateAndSaveKeyPair(privateKeyPath, publicKeyPath)
 }
 
-// generateAndSaveKeyPair generates a new WireGuard key pair and saves it
-func generateAndSaveKeyPair(privateKeyPath, publicKeyPath string) (*KeyPair, error) {
+// GenerateKeyPair generates a new WireGuard key pair without saving it, for ephemeral devices
+// such as CI jobs
+func GenerateKeyPair() (*KeyPair, error) {
 	// Generate private key (32 random bytes)
 	privateKeyBytes := make([]byte, 32)
 	if _, err := randomBytes(privateKeyBytes); err != nil {
@@ -83,8 +84,18 @@ func generateAndSaveKeyPair(privateKeyPath, publicKeyPath string) (*KeyPair, err
 	curve25519.ScalarBaseMult(&publicKeyBytes, &privateKeyArray)
 
 	// Encode keys to base64
-	privateKey := base64.StdEncoding.EncodeToString(privateKeyBytes)
-	publicKey := base64.StdEncoding.EncodeToString(publicKeyBytes[:])
+	return &KeyPair{
+		PrivateKey: base64.StdEncoding.EncodeToString(privateKeyBytes),
+		PublicKey:  base64.StdEncoding.EncodeToString(publicKeyBytes[:]),
+	}, nil
+}
+
+// generateAndSaveKeyPair generates a new WireGuard key pair and saves it
+func generateAndSaveKeyPair(privateKeyPath, publicKeyPath string) (*KeyPair, error) {
+	keyPair, err := GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
 
 	// Create directory if it doesn't exist
 	dir := filepath.Dir(privateKeyPath)
@@ -93,19 +104,16 @@ func generateAndSaveKeyPair(privateKeyPath, publicKeyPath string) (*KeyPair, err
 	}
 
 	// Write private key with restrictive permissions
-	if err := os.WriteFile(privateKeyPath, []byte(privateKey), 0o600); err != nil {
+	if err := os.WriteFile(privateKeyPath, []byte(keyPair.PrivateKey), 0o600); err != nil {
 		return nil, fmt.Errorf("failed to write private key: %w", err)
 	}
 
 	// Write public key
-	if err := os.WriteFile(publicKeyPath, []byte(publicKey), 0o644); err != nil {
+	if err := os.WriteFile(publicKeyPath, []byte(keyPair.PublicKey), 0o644); err != nil {
 		return nil, fmt.Errorf("failed to write public key: %w", err)
 	}
 
-	return &KeyPair{
-		PrivateKey: privateKey,
-		PublicKey:  publicKey,
-	}, nil
+	return keyPair, nil
 }
 
 // randomBytes fills the given byte slice with random data
@@ -0,0 +1,78 @@
+package wireguard
+
+import (
+	"fmt"
+	"net/netip"
+
+	"golang.zx2c4.com/wireguard/conn"
+	"golang.zx2c4.com/wireguard/device"
+	"golang.zx2c4.com/wireguard/tun/netstack"
+)
+
+// NetstackDevice is a WireGuard device on a userspace network stack instead of a TUN interface.
+// It needs neither root nor kernel support: connections through the tunnel are made with Net,
+// the host's routes and DNS are left untouched.
+type NetstackDevice struct {
+	wgDevice *device.Device
+	tnet     *netstack.Net
+	logger   *device.Logger
+}
+
+// NewNetstackDevice creates a WireGuard device with the address of the peer, lookups of Net go to
+// dnsServer (the tunnel gateway)
+func NewNetstackDevice(address, dnsServer string, mtu int, logger *device.Logger) (*NetstackDevice, error) {
+	if mtu == 0 {
+		mtu = device.DefaultMTU
+	}
+	if logger == nil {
+		logger = device.NewLogger(device.LogLevelError, "[WireGuard:netstack] ")
+	}
+
+	localAddr, err := netip.ParseAddr(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	var dnsServers []netip.Addr
+	if dnsServer != "" {
+		dns, err := netip.ParseAddr(dnsServer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS server %q: %w", dnsServer, err)
+		}
+		dnsServers = append(dnsServers, dns)
+	}
+
+	tunDevice, tnet, err := netstack.CreateNetTUN([]netip.Addr{localAddr}, dnsServers, mtu)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create userspace network stack: %w", err)
+	}
+
+	return &NetstackDevice{
+		// The device owns the network stack from now on, and closes it
+		wgDevice: device.NewDevice(tunDevice, conn.NewDefaultBind(), logger),
+		tnet:     tnet,
+		logger:   logger,
+	}, nil
+}
+
+// SetConfig sets WireGuard configuration from string (INI or IPC format) and brings the device up
+func (d *NetstackDevice) SetConfig(config string) error {
+	if err := d.wgDevice.IpcSet(convertINIToIPC(config)); err != nil {
+		return fmt.Errorf("failed to set configuration: %w", err)
+	}
+	if err := d.wgDevice.Up(); err != nil {
+		return fmt.Errorf("failed to bring device up: %w", err)
+	}
+
+	d.logger.Verbosef("Configuration applied successfully")
+	return nil
+}
+
+// Net returns the network stack of the tunnel, to dial its addresses
+func (d *NetstackDevice) Net() *netstack.Net {
+	return d.tnet
+}
+
+// Close shuts down the WireGuard device and its network stack
+func (d *NetstackDevice) Close() {
+	d.wgDevice.Close()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/miekg/dns"
@@ -16,15 +17,43 @@ type DNSServer struct {
 	hostsCache  *HostsCache
 	upstreamDNS string // CoreDNS address (e.g., "10.43.0.10:53")
 	listenAddr  string // e.g., ":53"
+	tcpAddr     string // DNS-over-TCP listen address, defaults to listenAddr
+
+	// Names of the zone that are always forwarded (public records, e.g., vpn-connect.{zone})
+	forwardNames map[string]bool
 
 	udpServer *dns.Server
 	tcpServer *dns.Server
+
+	// Query accounting
+	queries        atomic.Uint64
+	cacheAnswers   atomic.Uint64 // Answered from the hosts cache (including NXDOMAIN and NODATA for the zone)
+	forwarded      atomic.Uint64
+	upstreamErrors atomic.Uint64
+}
+
+// DNSStats holds the query counters of the DNS server
+type DNSStats struct {
+	Queries        uint64 `json:"queries"`
+	CacheAnswers   uint64 `json:"cacheAnswers"`
+	Forwarded      uint64 `json:"forwarded"`
+	UpstreamErrors uint64 `json:"upstreamErrors"`
+}
+
+// CacheHitRatio returns the share of queries answered from the hosts cache, 0 without queries
+func (s DNSStats) CacheHitRatio() float64 {
+	if s.Queries == 0 {
+		return 0
+	}
+	return float64(s.CacheAnswers) / float64(s.Queries)
 }
 
 // DNSServerConfig holds configuration for the DNS server
 type DNSServerConfig struct {
-	ListenAddr  string // Listen address (e.g., ":53")
-	UpstreamDNS string // Upstream DNS server (e.g., "10.43.0.10:53")
+	ListenAddr    string   // Listen address (e.g., ":53")
+	TCPListenAddr string   // DNS-over-TCP listen address, defaults to ListenAddr
+	UpstreamDNS   string   // Upstream DNS server (e.g., "10.43.0.10:53")
+	ForwardNames  []string // Names relative to the zone forwarded upstream instead of answered (e.g., "vpn-connect")
 }
 
 // NewDNSServer creates a new DNS server
@@ -36,11 +65,22 @@ func NewDNSServer(logger *zap.Logger, hostsCache *HostsCache, cfg DNSServerConfi
 		cfg.UpstreamDNS = "10.43.0.10:53"
 	}
 
+	if cfg.TCPListenAddr == "" {
+		cfg.TCPListenAddr = cfg.ListenAddr
+	}
+
+	forwardNames := make(map[string]bool, len(cfg.ForwardNames))
+	for _, name := range cfg.ForwardNames {
+		forwardNames[normalizeDNSName(name)] = true
+	}
+
 	return &DNSServer{
-		logger:      logger,
-		hostsCache:  hostsCache,
-		upstreamDNS: cfg.UpstreamDNS,
-		listenAddr:  cfg.ListenAddr,
+		logger:       logger,
+		hostsCache:   hostsCache,
+		upstreamDNS:  cfg.UpstreamDNS,
+		listenAddr:   cfg.ListenAddr,
+		tcpAddr:      cfg.TCPListenAddr,
+		forwardNames: forwardNames,
 	}
 }
 
@@ -58,7 +98,7 @@ func (d *DNSServer) Start(ctx context.Context) error {
 
 	// Start TCP server
 	d.tcpServer = &dns.Server{
-		Addr:    d.listenAddr,
+		Addr:    d.tcpAddr,
 		Net:     "tcp",
 		Handler: handler,
 	}
@@ -74,7 +114,7 @@ func (d *DNSServer) Start(ctx context.Context) error {
 	}()
 
 	go func() {
-		d.logger.Info("starting DNS server (TCP)", zap.String("addr", d.listenAddr))
+		d.logger.Info("starting DNS server (TCP)", zap.String("addr", d.tcpAddr))
 		if err := d.tcpServer.ListenAndServe(); err != nil {
 			errChan <- err
 		}
@@ -107,113 +147,166 @@ func (d *DNSServer) Stop() error {
 	return lastErr
 }
 
+// Stats returns the query counters of the DNS server
+func (d *DNSServer) Stats() DNSStats {
+	return DNSStats{
+		Queries:        d.queries.Load(),
+		CacheAnswers:   d.cacheAnswers.Load(),
+		Forwarded:      d.forwarded.Load(),
+		UpstreamErrors: d.upstreamErrors.Load(),
+	}
+}
+
 // handleQuery handles incoming DNS queries
+//
+// Names of the Kloudlite zone are answered authoritatively from the hosts cache: A, AAAA, TXT and
+// SRV records, NODATA for other types and NXDOMAIN for unknown names, so that they never leak to
+// public DNS. Hosts outside of the zone (ingresses of custom domains) are answered as well, the
+// zone apex, ForwardNames and everything else are forwarded upstream.
 func (d *DNSServer) handleQuery(w dns.ResponseWriter, r *dns.Msg) {
+	if len(r.Question) != 1 {
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeFormatError)
+		w.WriteMsg(m)
+		return
+	}
+
+	d.queries.Add(1)
+	q := r.Question[0]
+	d.logger.Debug("received DNS query",
+		zap.String("name", q.Name),
+		zap.String("type", dns.TypeToString[q.Qtype]))
+
+	if q.Qclass == dns.ClassINET {
+		if m := d.answerFromZone(r, q, d.hostsCache.getZone()); m != nil {
+			d.logger.Debug("responding from cache",
+				zap.String("name", q.Name),
+				zap.String("rcode", dns.RcodeToString[m.Rcode]),
+				zap.Int("answers", len(m.Answer)))
+			d.cacheAnswers.Add(1)
+			d.writeMsg(w, r, m)
+			return
+		}
+	}
+
+	d.forwarded.Add(1)
+	d.forwardToUpstream(w, r)
+}
+
+// answerFromZone answers a query from the hosts cache, nil when it must be forwarded upstream
+func (d *DNSServer) answerFromZone(r *dns.Msg, q dns.Question, zone *dnsZone) *dns.Msg {
+	name := normalizeDNSName(q.Name)
+	inZone := zone.contains(name) && name != zone.origin && !d.forwardNames[strings.TrimSuffix(name, "."+zone.origin)]
+
 	m := new(dns.Msg)
 	m.SetReply(r)
-	m.Authoritative = false
+	m.Authoritative = true
 	m.RecursionAvailable = true
 
-	for _, q := range r.Question {
-		d.logger.Debug("received DNS query",
-			zap.String("name", q.Name),
-			zap.String("type", dns.TypeToString[q.Qtype]))
-
-		switch q.Qtype {
-		case dns.TypeA:
-			d.handleARecord(m, q)
-		case dns.TypeAAAA:
-			// We don't serve AAAA records from cache, forward to upstream
-			d.forwardToUpstream(w, r)
-			return
-		default:
-			// Forward other query types to upstream
-			d.forwardToUpstream(w, r)
-			return
+	if host, port, found := zone.lookupSRV(name); found {
+		if port != nil && (q.Qtype == dns.TypeSRV || q.Qtype == dns.TypeANY) {
+			target := dns.Fqdn(host.Hostname)
+			m.Answer = append(m.Answer, &dns.SRV{
+				Hdr:    dns.RR_Header{Name: q.Name, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: dnsRecordTTL},
+				Port:   uint16(port.Port),
+				Target: target,
+			})
+			m.Extra = append(m.Extra, addressRecords(target, dns.TypeANY, host)...)
 		}
+		// A port the host does not have is NODATA like its other missing records, the host exists
+		return d.withAuthority(m, zone)
 	}
 
-	// If we found an answer, respond; otherwise forward to upstream
-	if len(m.Answer) > 0 {
-		d.logger.Debug("responding from cache",
-			zap.Int("answers", len(m.Answer)))
-		w.WriteMsg(m)
-	} else {
-		d.forwardToUpstream(w, r)
+	host, found := zone.lookup(name)
+	if !found {
+		if !inZone {
+			return nil
+		}
+		m.Rcode = dns.RcodeNameError
+		return d.withAuthority(m, zone)
 	}
-}
 
-// handleARecord handles A record queries
-func (d *DNSServer) handleARecord(m *dns.Msg, q dns.Question) {
-	// Normalize the query name (remove trailing dot)
-	queryName := strings.TrimSuffix(strings.ToLower(q.Name), ".")
-
-	// Look up in hosts cache
-	hosts := d.hostsCache.GetHosts()
-	for _, host := range hosts {
-		if strings.ToLower(host.Hostname) == queryName {
-			// Parse the IP
-			ip := net.ParseIP(host.IP)
-			if ip == nil {
-				d.logger.Warn("invalid IP in hosts cache",
-					zap.String("hostname", host.Hostname),
-					zap.String("ip", host.IP))
-				continue
-			}
-
-			// Only respond with IPv4 for A records
-			ipv4 := ip.To4()
-			if ipv4 == nil {
-				continue
-			}
-
-			rr := &dns.A{
-				Hdr: dns.RR_Header{
-					Name:   q.Name,
-					Rrtype: dns.TypeA,
-					Class:  dns.ClassINET,
-					Ttl:    60, // Short TTL since hosts can change
-				},
-				A: ipv4,
-			}
+	switch q.Qtype {
+	case dns.TypeA, dns.TypeAAAA:
+		m.Answer = addressRecords(q.Name, q.Qtype, host)
+	case dns.TypeTXT:
+		if rr := txtRecord(q.Name, host); rr != nil {
 			m.Answer = append(m.Answer, rr)
+		}
+	case dns.TypeANY:
+		m.Answer = addressRecords(q.Name, q.Qtype, host)
+		if rr := txtRecord(q.Name, host); rr != nil {
+			m.Answer = append(m.Answer, rr)
+		}
+	default:
+		if !inZone {
+			// Other record types of hosts outside of the zone are not ours to deny
+			return nil
+		}
+	}
+	return d.withAuthority(m, zone)
+}
 
-			d.logger.Debug("found in hosts cache",
-				zap.String("hostname", host.Hostname),
-				zap.String("ip", host.IP))
-			return
+// withAuthority adds the SOA of the zone to negative answers (NXDOMAIN and NODATA) for the zone
+func (d *DNSServer) withAuthority(m *dns.Msg, zone *dnsZone) *dns.Msg {
+	if len(m.Answer) == 0 && zone.origin != "" {
+		m.Ns = append(m.Ns, zone.soa())
+	}
+	return m
+}
+
+// writeMsg writes a response, truncated to the size the client accepts over UDP
+func (d *DNSServer) writeMsg(w dns.ResponseWriter, r *dns.Msg, m *dns.Msg) {
+	if _, ok := w.RemoteAddr().(*net.UDPAddr); ok {
+		size := dns.MinMsgSize
+		if opt := r.IsEdns0(); opt != nil {
+			size = int(opt.UDPSize())
 		}
+		m.Truncate(size)
+	}
+	if err := w.WriteMsg(m); err != nil {
+		d.logger.Debug("failed to write DNS response", zap.Error(err))
 	}
 }
 
-// forwardToUpstream forwards the query to the upstream DNS server
+// forwardToUpstream forwards the query to the upstream DNS server, over the protocol of the client
+// and over TCP when the UDP exchange fails or is truncated
 func (d *DNSServer) forwardToUpstream(w dns.ResponseWriter, r *dns.Msg) {
+	network := "udp"
+	if _, ok := w.RemoteAddr().(*net.TCPAddr); ok {
+		network = "tcp"
+	}
+
 	client := &dns.Client{
-		Net:     "udp",
+		Net:     network,
 		Timeout: 5 * time.Second,
 	}
 
 	resp, _, err := client.Exchange(r, d.upstreamDNS)
-	if err != nil {
-		d.logger.Debug("failed to forward to upstream",
-			zap.String("upstream", d.upstreamDNS),
-			zap.Error(err))
-
-		// Try TCP if UDP fails
-		client.Net = "tcp"
-		resp, _, err = client.Exchange(r, d.upstreamDNS)
+	if network == "udp" && (err != nil || resp.Truncated) {
 		if err != nil {
-			d.logger.Error("failed to forward to upstream (TCP)",
+			d.logger.Debug("failed to forward to upstream",
 				zap.String("upstream", d.upstreamDNS),
 				zap.Error(err))
-
-			// Send SERVFAIL response
-			m := new(dns.Msg)
-			m.SetRcode(r, dns.RcodeServerFailure)
-			w.WriteMsg(m)
-			return
 		}
+
+		// Try TCP if UDP fails or the answer does not fit
+		client.Net = "tcp"
+		resp, _, err = client.Exchange(r, d.upstreamDNS)
+	}
+	if err != nil {
+		d.upstreamErrors.Add(1)
+		d.logger.Error("failed to forward to upstream",
+			zap.String("upstream", d.upstreamDNS),
+			zap.String("net", client.Net),
+			zap.Error(err))
+
+		// Send SERVFAIL response
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeServerFailure)
+		w.WriteMsg(m)
+		return
 	}
 
-	w.WriteMsg(resp)
+	d.writeMsg(w, r, resp)
 }
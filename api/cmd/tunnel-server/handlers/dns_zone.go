@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// dnsRecordTTL is the TTL of records served from the hosts cache (short, since hosts can change)
+	dnsRecordTTL = 60
+
+	// dnsNegativeTTL is how long resolvers may cache NXDOMAIN and NODATA answers for the zone
+	dnsNegativeTTL = 30
+)
+
+// dnsZone is a snapshot of the names served authoritatively by the DNS server, built with the hosts cache
+//
+// Names are looked up exactly first, then as exposed-port hosts of a known workspace
+// (p{port}-{wsHash}.{zone}, served by the router even before their ingress is cached), then as
+// wildcard ingress hosts (*.parent), the most specific first.
+type dnsZone struct {
+	origin          string               // e.g., "beanbag.khost.dev", empty when HOSTED_SUBDOMAIN is not set
+	serial          uint32               // SOA serial, changes with every rebuild
+	hosts           map[string]HostEntry // lower-cased hostname (or wildcard) -> entry
+	router          *HostEntry           // router addresses, for exposed-port hosts
+	workspaceHashes map[string]bool
+}
+
+// newDNSZone indexes hosts for lookups; the first entry of a hostname wins
+func newDNSZone(origin string, serial uint32, hosts []HostEntry, router *HostEntry, workspaceHashes []string) *dnsZone {
+	z := &dnsZone{
+		origin:          normalizeDNSName(origin),
+		serial:          serial,
+		hosts:           make(map[string]HostEntry, len(hosts)),
+		router:          router,
+		workspaceHashes: make(map[string]bool, len(workspaceHashes)),
+	}
+	for _, host := range hosts {
+		name := normalizeDNSName(host.Hostname)
+		if _, exists := z.hosts[name]; !exists {
+			z.hosts[name] = host
+		}
+	}
+	for _, hash := range workspaceHashes {
+		z.workspaceHashes[hash] = true
+	}
+	return z
+}
+
+// contains reports whether name is the zone or below it
+func (z *dnsZone) contains(name string) bool {
+	if z.origin == "" {
+		return false
+	}
+	return name == z.origin || strings.HasSuffix(name, "."+z.origin)
+}
+
+// lookup finds the host entry of a name
+func (z *dnsZone) lookup(name string) (HostEntry, bool) {
+	if host, ok := z.hosts[name]; ok {
+		return host, true
+	}
+	if host, ok := z.lookupExposedPort(name); ok {
+		return host, true
+	}
+	for parent := name; ; {
+		i := strings.IndexByte(parent, '.')
+		if i < 0 {
+			return HostEntry{}, false
+		}
+		parent = parent[i+1:]
+		if host, ok := z.hosts["*."+parent]; ok {
+			return host, true
+		}
+	}
+}
+
+// lookupExposedPort matches p{port}-{wsHash}.{zone} for the workspaces in the cache
+func (z *dnsZone) lookupExposedPort(name string) (HostEntry, bool) {
+	if z.router == nil || z.origin == "" {
+		return HostEntry{}, false
+	}
+	label, ok := strings.CutSuffix(name, "."+z.origin)
+	if !ok || !strings.HasPrefix(label, "p") {
+		return HostEntry{}, false
+	}
+	port, hash, ok := strings.Cut(label[1:], "-")
+	if !ok || !z.workspaceHashes[hash] {
+		return HostEntry{}, false
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return HostEntry{}, false
+	}
+
+	host := *z.router
+	host.Hostname = name
+	return host, true
+}
+
+// lookupSRV resolves _{portName}._{proto}.{hostname} to the host entry and the matching port
+//
+// found reports whether the host exists, port is nil when it has no such port: that is answered
+// with NODATA, like the other records a host does not have.
+func (z *dnsZone) lookupSRV(name string) (host HostEntry, port *HostPort, found bool) {
+	labels := strings.SplitN(name, ".", 3)
+	if len(labels) != 3 || !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") {
+		return HostEntry{}, nil, false
+	}
+
+	host, found = z.hosts[labels[2]]
+	if !found {
+		return HostEntry{}, nil, false
+	}
+
+	portName := strings.TrimPrefix(labels[0], "_")
+	protocol := strings.TrimPrefix(labels[1], "_")
+	for i := range host.Ports {
+		if strings.EqualFold(host.Ports[i].Name, portName) && strings.EqualFold(host.Ports[i].Protocol, protocol) {
+			return host, &host.Ports[i], true
+		}
+	}
+	return host, nil, true
+}
+
+// soa is the SOA record of the zone, sent in the authority section of negative answers
+func (z *dnsZone) soa() dns.RR {
+	origin := dns.Fqdn(z.origin)
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: origin, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: dnsNegativeTTL},
+		Ns:      "ns." + origin,
+		Mbox:    "hostmaster." + origin,
+		Serial:  z.serial,
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  86400,
+		Minttl:  dnsNegativeTTL,
+	}
+}
+
+// addressRecords returns the A or AAAA records of a host (both for ANY)
+func addressRecords(name string, qtype uint16, host HostEntry) []dns.RR {
+	var rrs []dns.RR
+	for _, addr := range []string{host.IP, host.IPv6} {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		hdr := dns.RR_Header{Name: name, Class: dns.ClassINET, Ttl: dnsRecordTTL}
+		if ipv4 := ip.To4(); ipv4 != nil {
+			if qtype == dns.TypeA || qtype == dns.TypeANY {
+				hdr.Rrtype = dns.TypeA
+				rrs = append(rrs, &dns.A{Hdr: hdr, A: ipv4})
+			}
+			continue
+		}
+		if qtype == dns.TypeAAAA || qtype == dns.TypeANY {
+			hdr.Rrtype = dns.TypeAAAA
+			rrs = append(rrs, &dns.AAAA{Hdr: hdr, AAAA: ip})
+		}
+	}
+	return rrs
+}
+
+// txtRecord describes what a host is (kind, environment and namespace of services)
+func txtRecord(name string, host HostEntry) dns.RR {
+	var txt []string
+	if host.Type != "" {
+		txt = append(txt, "type="+host.Type)
+	}
+	if host.Environment != "" {
+		txt = append(txt, "environment="+host.Environment)
+	}
+	if host.Namespace != "" {
+		txt = append(txt, "namespace="+host.Namespace)
+	}
+	if host.Service != "" {
+		txt = append(txt, "service="+host.Service)
+	}
+	if len(txt) == 0 {
+		return nil
+	}
+	return &dns.TXT{
+		Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: dnsRecordTTL},
+		Txt: txt,
+	}
+}
+
+// normalizeDNSName lower-cases a name and removes its trailing dot
+func normalizeDNSName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
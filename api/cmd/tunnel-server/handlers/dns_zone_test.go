@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+func testZone() *dnsZone {
+	return newDNSZone("beanbag.khost.dev", 1, []HostEntry{
+		{
+			Hostname: "db-a1b2c3d4.beanbag.khost.dev",
+			IP:       "10.43.0.20",
+			IPv6:     "fd00::20",
+			Type:     "service",
+			Ports:    []HostPort{{Name: "port-0", Port: 5432, Protocol: "tcp"}},
+		},
+		{Hostname: "*.apps.beanbag.khost.dev", IP: "10.43.0.5", Type: "ingress"},
+		{Hostname: "shop.example.com", IP: "10.43.0.5", Type: "ingress"},
+	}, &HostEntry{IP: "10.43.0.5", Type: "ingress"}, []string{"deadbeef"})
+}
+
+func TestDNSZoneLookup(t *testing.T) {
+	zone := testZone()
+
+	tests := []struct {
+		name  string
+		query string
+		ip    string
+		found bool
+	}{
+		{name: "exact", query: "db-a1b2c3d4.beanbag.khost.dev", ip: "10.43.0.20", found: true},
+		{name: "wildcard", query: "web.apps.beanbag.khost.dev", ip: "10.43.0.5", found: true},
+		{name: "exposed port", query: "p8080-deadbeef.beanbag.khost.dev", ip: "10.43.0.5", found: true},
+		{name: "exposed port of unknown workspace", query: "p8080-cafebabe.beanbag.khost.dev"},
+		{name: "invalid exposed port", query: "p99999-deadbeef.beanbag.khost.dev"},
+		{name: "unknown", query: "nope.beanbag.khost.dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, found := zone.lookup(tt.query)
+			if found != tt.found || host.IP != tt.ip {
+				t.Errorf("lookup(%q) = %q, %v; want %q, %v", tt.query, host.IP, found, tt.ip, tt.found)
+			}
+		})
+	}
+}
+
+func TestAnswerFromZone(t *testing.T) {
+	d := NewDNSServer(zap.NewNop(), nil, DNSServerConfig{ForwardNames: []string{"vpn-connect"}})
+	zone := testZone()
+
+	tests := []struct {
+		name    string
+		qname   string
+		qtype   uint16
+		forward bool
+		rcode   int
+		answers int
+	}{
+		{name: "A", qname: "db-a1b2c3d4.beanbag.khost.dev.", qtype: dns.TypeA, answers: 1},
+		{name: "AAAA", qname: "db-a1b2c3d4.beanbag.khost.dev.", qtype: dns.TypeAAAA, answers: 1},
+		{name: "TXT", qname: "db-a1b2c3d4.beanbag.khost.dev.", qtype: dns.TypeTXT, answers: 1},
+		{name: "SRV", qname: "_port-0._tcp.db-a1b2c3d4.beanbag.khost.dev.", qtype: dns.TypeSRV, answers: 1},
+		{name: "SRV of unknown port is NODATA", qname: "_http._tcp.db-a1b2c3d4.beanbag.khost.dev.", qtype: dns.TypeSRV},
+		{name: "A of SRV name is NODATA", qname: "_port-0._tcp.db-a1b2c3d4.beanbag.khost.dev.", qtype: dns.TypeA},
+		{name: "AAAA without IPv6 is NODATA", qname: "shop.example.com.", qtype: dns.TypeAAAA},
+		{name: "SRV of unknown host", qname: "_port-0._tcp.nope.beanbag.khost.dev.", qtype: dns.TypeSRV, rcode: dns.RcodeNameError},
+		{name: "NODATA", qname: "db-a1b2c3d4.beanbag.khost.dev.", qtype: dns.TypeMX},
+		{name: "NXDOMAIN in zone", qname: "nope.beanbag.khost.dev.", qtype: dns.TypeA, rcode: dns.RcodeNameError},
+		{name: "forwarded name", qname: "vpn-connect.beanbag.khost.dev.", qtype: dns.TypeA, forward: true},
+		{name: "zone apex", qname: "beanbag.khost.dev.", qtype: dns.TypeA, forward: true},
+		{name: "host outside zone", qname: "shop.example.com.", qtype: dns.TypeA, answers: 1},
+		{name: "other type outside zone", qname: "shop.example.com.", qtype: dns.TypeMX, forward: true},
+		{name: "unknown outside zone", qname: "example.org.", qtype: dns.TypeA, forward: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := new(dns.Msg).SetQuestion(tt.qname, tt.qtype)
+			m := d.answerFromZone(req, req.Question[0], zone)
+			if tt.forward {
+				if m != nil {
+					t.Fatalf("expected query to be forwarded, got %v", m)
+				}
+				return
+			}
+			if m == nil {
+				t.Fatal("expected an answer, query was forwarded")
+			}
+			if m.Rcode != tt.rcode || len(m.Answer) != tt.answers {
+				t.Errorf("rcode = %s, answers = %d; want %s, %d",
+					dns.RcodeToString[m.Rcode], len(m.Answer), dns.RcodeToString[tt.rcode], tt.answers)
+			}
+			if len(m.Answer) == 0 && len(m.Ns) == 0 {
+				t.Error("negative answer without SOA")
+			}
+		})
+	}
+}
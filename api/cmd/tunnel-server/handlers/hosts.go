@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/kloudlite/kloudlite/api/cmd/tunnel-server/middleware"
 	"go.uber.org/zap"
 )
 
@@ -24,7 +25,21 @@ type HostsHandler struct {
 type HostEntry struct {
 	Hostname string `json:"hostname"`
 	IP       string `json:"ip"`
-	Type     string `json:"type,omitempty"` // "ingress" or "service"
+	IPv6     string `json:"ipv6,omitempty"` // Secondary IPv6 ClusterIP of dual-stack services
+	Type     string `json:"type,omitempty"` // "ingress", "service" or "workspace"
+
+	// Service details, served as SRV and TXT records by the DNS server
+	Environment string     `json:"environment,omitempty"`
+	Namespace   string     `json:"namespace,omitempty"`
+	Service     string     `json:"service,omitempty"`
+	Ports       []HostPort `json:"ports,omitempty"`
+}
+
+// HostPort is a port of a service host
+type HostPort struct {
+	Name     string `json:"name"`
+	Port     int32  `json:"port"`
+	Protocol string `json:"protocol"` // "tcp", "udp" or "sctp"
 }
 
 // HostsResponse represents the response from the hosts endpoint
@@ -50,6 +65,11 @@ func (h *HostsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Get hosts from cache - no K8s API calls!
 	hosts := h.cache.GetHosts()
 
+	// Service tokens only resolve the services of their environment
+	if claims, ok := middleware.GetUserFromContext(r.Context()); ok && claims.IsService() {
+		hosts = environmentHosts(hosts, claims.Environment)
+	}
+
 	h.logger.Debug("returning hosts from cache", zap.Int("count", len(hosts)))
 
 	response := HostsResponse{
@@ -61,3 +81,14 @@ func (h *HostsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.logger.Error("failed to encode hosts response", zap.Error(err))
 	}
 }
+
+// environmentHosts returns the service hosts of an environment
+func environmentHosts(hosts []HostEntry, environment string) []HostEntry {
+	scoped := make([]HostEntry, 0)
+	for _, host := range hosts {
+		if host.Type == "service" && host.Environment == environment {
+			scoped = append(scoped, host)
+		}
+	}
+	return scoped
+}
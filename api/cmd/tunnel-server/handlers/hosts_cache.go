@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"net"
 	"os"
 	"strings"
 	"sync"
@@ -26,6 +27,7 @@ import (
 type HostsCache struct {
 	mu    sync.RWMutex
 	hosts []HostEntry
+	zone  *dnsZone // names served by the DNS server, rebuilt with hosts
 
 	// Configuration
 	logger           *zap.Logger
@@ -37,6 +39,8 @@ type HostsCache struct {
 
 	// Controller-runtime cache for watches
 	cache cache.Cache
+
+	onRebuild []func() // Called after every rebuild, e.g., to refresh the filters of service-token peers
 }
 
 // HostsCacheConfig holds configuration for the hosts cache
@@ -66,6 +70,7 @@ func NewHostsCache(logger *zap.Logger, k8sClient client.Client, cfg HostsCacheCo
 
 	return &HostsCache{
 		hosts:            make([]HostEntry, 0),
+		zone:             newDNSZone("", 0, nil, nil, nil),
 		logger:           logger,
 		namespace:        cfg.Namespace,
 		routerServiceRef: cfg.RouterServiceRef,
@@ -191,8 +196,8 @@ func (hc *HostsCache) rebuild(ctx context.Context) {
 			zap.Error(err))
 	}
 
-	// Get the router service ClusterIP for ingresses
-	routerIP, err := hc.getRouterIP(ctx)
+	// Get the router service ClusterIPs for ingresses
+	routerIP, routerIPv6, err := hc.getRouterIP(ctx)
 	if err != nil {
 		hc.logger.Warn("failed to get router service IP, ingress hosts will not be added",
 			zap.String("service", hc.routerServiceRef),
@@ -201,7 +206,7 @@ func (hc *HostsCache) rebuild(ctx context.Context) {
 
 	// Get all ingresses cluster-wide and add their hosts
 	if routerIP != "" {
-		ingressHosts, err := hc.getIngressHosts(ctx, routerIP)
+		ingressHosts, err := hc.getIngressHosts(ctx, routerIP, routerIPv6)
 		if err != nil {
 			hc.logger.Error("failed to get ingress hosts", zap.Error(err))
 		} else {
@@ -210,6 +215,7 @@ func (hc *HostsCache) rebuild(ctx context.Context) {
 	}
 
 	// Get services from kloudlite environment namespaces
+	var workspaceHashes []string
 	if subdomain != "" && domain != "" {
 		serviceHosts, err := hc.getServiceHosts(ctx, subdomain, domain)
 		if err != nil {
@@ -219,28 +225,53 @@ func (hc *HostsCache) rebuild(ctx context.Context) {
 		}
 
 		// Get workspace services for VPN access (SSH, etc.)
-		workspaceHosts, err := hc.getWorkspaceHosts(ctx, subdomain, domain)
+		workspaceHosts, hashes, err := hc.getWorkspaceHosts(ctx, subdomain, domain)
 		if err != nil {
 			hc.logger.Error("failed to get workspace hosts", zap.Error(err))
 		} else {
 			hosts = append(hosts, workspaceHosts...)
+			workspaceHashes = hashes
 		}
 
 		// Note: vpn-check host entry is added dynamically in hosts.go
 		// with the client's VPN IP (from request source address)
 	}
 
+	// Index the hosts for the DNS server, exposed-port hosts resolve to the router
+	origin := ""
+	if subdomain != "" && domain != "" {
+		origin = subdomain + "." + domain
+	}
+	var router *HostEntry
+	if routerIP != "" {
+		router = &HostEntry{IP: routerIP, IPv6: routerIPv6, Type: "ingress"}
+	}
+	zone := newDNSZone(origin, uint32(time.Now().Unix()), hosts, router, workspaceHashes)
+
 	// Update cache
 	hc.mu.Lock()
 	hc.hosts = hosts
+	hc.zone = zone
+	onRebuild := hc.onRebuild
 	hc.mu.Unlock()
 
+	for _, fn := range onRebuild {
+		fn()
+	}
+
 	hc.logger.Info("hosts cache rebuilt",
 		zap.Int("count", len(hosts)),
 		zap.String("subdomain", subdomain),
 		zap.String("domain", domain))
 }
 
+// OnRebuild registers a function called after every rebuild of the cache
+func (hc *HostsCache) OnRebuild(fn func()) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.onRebuild = append(hc.onRebuild, fn)
+}
+
 // GetHosts returns the cached hosts entries (thread-safe)
 func (hc *HostsCache) GetHosts() []HostEntry {
 	hc.mu.RLock()
@@ -252,6 +283,13 @@ func (hc *HostsCache) GetHosts() []HostEntry {
 	return result
 }
 
+// getZone returns the names served authoritatively by the DNS server (thread-safe)
+func (hc *HostsCache) getZone() *dnsZone {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.zone
+}
+
 // getDomainInfo retrieves subdomain and domain from HOSTED_SUBDOMAIN env var
 // It extracts them from the env var value (e.g., "beanbag.khost.dev" -> subdomain="beanbag", domain="khost.dev")
 func (hc *HostsCache) getDomainInfo(ctx context.Context) (subdomain, domain string, err error) {
@@ -270,26 +308,59 @@ func (hc *HostsCache) getDomainInfo(ctx context.Context) (subdomain, domain stri
 	return parts[0], parts[1], nil
 }
 
-// getRouterIP gets the ClusterIP of the ingress controller service
-func (hc *HostsCache) getRouterIP(ctx context.Context) (string, error) {
+// getRouterIP gets the ClusterIPs of the ingress controller service
+func (hc *HostsCache) getRouterIP(ctx context.Context) (string, string, error) {
 	routerSvc := &corev1.Service{}
 	if err := hc.cache.Get(ctx, client.ObjectKey{
 		Namespace: hc.namespace,
 		Name:      hc.routerServiceRef,
 	}, routerSvc); err != nil {
-		return "", fmt.Errorf("failed to get router service: %w", err)
+		return "", "", fmt.Errorf("failed to get router service: %w", err)
 	}
 
 	clusterIP := routerSvc.Spec.ClusterIP
 	if clusterIP == "" || clusterIP == "None" {
-		return "", fmt.Errorf("router service has no ClusterIP")
+		return "", "", fmt.Errorf("router service has no ClusterIP")
 	}
 
-	return clusterIP, nil
+	return clusterIP, secondaryIPv6(&routerSvc.Spec), nil
+}
+
+// secondaryIPv6 returns the IPv6 ClusterIP of a dual-stack service, when the primary one is IPv4
+func secondaryIPv6(spec *corev1.ServiceSpec) string {
+	for _, ip := range spec.ClusterIPs {
+		if ip == spec.ClusterIP {
+			continue
+		}
+		if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+			return ip
+		}
+	}
+	return ""
+}
+
+// servicePorts returns the ports of a service, served as SRV records
+func servicePorts(spec *corev1.ServiceSpec) []HostPort {
+	ports := make([]HostPort, 0, len(spec.Ports))
+	for _, port := range spec.Ports {
+		if port.Name == "" {
+			continue
+		}
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		ports = append(ports, HostPort{
+			Name:     port.Name,
+			Port:     port.Port,
+			Protocol: strings.ToLower(string(protocol)),
+		})
+	}
+	return ports
 }
 
 // getIngressHosts gets all ingress hosts cluster-wide
-func (hc *HostsCache) getIngressHosts(ctx context.Context, routerIP string) ([]HostEntry, error) {
+func (hc *HostsCache) getIngressHosts(ctx context.Context, routerIP, routerIPv6 string) ([]HostEntry, error) {
 	var ingressList networkingv1.IngressList
 	if err := hc.cache.List(ctx, &ingressList); err != nil {
 		return nil, fmt.Errorf("failed to list ingresses: %w", err)
@@ -303,6 +374,7 @@ func (hc *HostsCache) getIngressHosts(ctx context.Context, routerIP string) ([]H
 				hosts = append(hosts, HostEntry{
 					Hostname: host,
 					IP:       routerIP,
+					IPv6:     routerIPv6,
 					Type:     "ingress",
 				})
 			}
@@ -389,9 +461,14 @@ func (hc *HostsCache) getServiceHosts(ctx context.Context, subdomain, domain str
 			hostname := fmt.Sprintf("%s-%s.%s.%s", svc.Name, envOwnerHash, subdomain, domain)
 
 			hosts = append(hosts, HostEntry{
-				Hostname: hostname,
-				IP:       svc.Spec.ClusterIP,
-				Type:     "service",
+				Hostname:    hostname,
+				IP:          svc.Spec.ClusterIP,
+				IPv6:        secondaryIPv6(&svc.Spec),
+				Type:        "service",
+				Environment: envName,
+				Namespace:   svc.Namespace,
+				Service:     svc.Name,
+				Ports:       servicePorts(&svc.Spec),
 			})
 		}
 	}
@@ -399,15 +476,17 @@ func (hc *HostsCache) getServiceHosts(ctx context.Context, subdomain, domain str
 	return hosts, nil
 }
 
-// getWorkspaceHosts gets all workspace services and creates host entries for VPN access
-func (hc *HostsCache) getWorkspaceHosts(ctx context.Context, subdomain, domain string) ([]HostEntry, error) {
+// getWorkspaceHosts gets all workspace services and creates host entries for VPN access,
+// along with the workspace hashes used by exposed-port hosts
+func (hc *HostsCache) getWorkspaceHosts(ctx context.Context, subdomain, domain string) ([]HostEntry, []string, error) {
 	// List workspaces in the current namespace only
 	var workspaceList workspacev1.WorkspaceList
 	if err := hc.cache.List(ctx, &workspaceList, client.InNamespace(hc.namespace)); err != nil {
-		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+		return nil, nil, fmt.Errorf("failed to list workspaces: %w", err)
 	}
 
 	hosts := make([]HostEntry, 0)
+	hashes := make([]string, 0, len(workspaceList.Items))
 	for i := range workspaceList.Items {
 		ws := &workspaceList.Items[i]
 
@@ -425,6 +504,7 @@ func (hc *HostsCache) getWorkspaceHosts(ctx context.Context, subdomain, domain s
 			// Compute hash if not in status yet
 			wsHash = generateHash(fmt.Sprintf("%s-%s", owner, ws.Name))
 		}
+		hashes = append(hashes, wsHash)
 
 		// Get workspace service ClusterIP
 		// The service is named ws-{workspaceName}
@@ -450,13 +530,17 @@ func (hc *HostsCache) getWorkspaceHosts(ctx context.Context, subdomain, domain s
 		hostname := fmt.Sprintf("%s-%s.%s.%s", ws.Name, wsHash, subdomain, domain)
 
 		hosts = append(hosts, HostEntry{
-			Hostname: hostname,
-			IP:       svc.Spec.ClusterIP,
-			Type:     "workspace",
+			Hostname:  hostname,
+			IP:        svc.Spec.ClusterIP,
+			IPv6:      secondaryIPv6(&svc.Spec),
+			Type:      "workspace",
+			Namespace: svc.Namespace,
+			Service:   svc.Name,
+			Ports:     servicePorts(&svc.Spec),
 		})
 	}
 
-	return hosts, nil
+	return hosts, hashes, nil
 }
 
 // generateHash generates an 8-character hash from the input string
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kloudlite/kloudlite/api/cmd/tunnel-server/middleware"
+	"go.uber.org/zap"
+)
+
+func TestHostsHandlerServiceToken(t *testing.T) {
+	cache := &HostsCache{hosts: []HostEntry{
+		{Hostname: "db-a1b2c3d4.beanbag.khost.dev", IP: "10.43.0.20", Type: "service", Environment: "staging", Service: "db"},
+		{Hostname: "db-e5f6a7b8.beanbag.khost.dev", IP: "10.43.0.21", Type: "service", Environment: "prod", Service: "db"},
+		{Hostname: "shop.example.com", IP: "10.43.0.5", Type: "ingress"},
+		{Hostname: "dev.beanbag.khost.dev", IP: "10.43.0.30", Type: "workspace"},
+	}}
+	handler := NewHostsHandler(zap.NewNop(), cache)
+
+	tests := []struct {
+		name   string
+		claims *middleware.UserClaims
+		want   []string
+	}{
+		{
+			name:   "user",
+			claims: &middleware.UserClaims{Username: "alice", Type: "vpn-permanent"},
+			want:   []string{"10.43.0.20", "10.43.0.21", "10.43.0.5", "10.43.0.30"},
+		},
+		{
+			name:   "service token",
+			claims: &middleware.UserClaims{Username: "alice", Type: middleware.ServiceTokenType, Environment: "staging"},
+			want:   []string{"10.43.0.20"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/hosts", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, tt.claims))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var resp HostsResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Hosts) != len(tt.want) {
+				t.Fatalf("got %d hosts, want %d: %+v", len(resp.Hosts), len(tt.want), resp.Hosts)
+			}
+			for i, host := range resp.Hosts {
+				if host.IP != tt.want[i] {
+					t.Errorf("host %d = %s, want %s", i, host.IP, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// wgActiveHandshakeWindow is how recent the latest handshake of an active peer is (WireGuard
+// renews the handshake every 2 minutes while traffic flows)
+const wgActiveHandshakeWindow = 3 * time.Minute
+
+var (
+	tunnelSessionsDesc = prometheus.NewDesc("tunnel_server_tunnel_sessions",
+		"Open WebSocket tunnel sessions.", nil, nil)
+	tunnelSessionsTotalDesc = prometheus.NewDesc("tunnel_server_tunnel_sessions_total",
+		"WebSocket tunnel sessions opened since start.", nil, nil)
+	tunnelBytesDesc = prometheus.NewDesc("tunnel_server_tunnel_bytes_total",
+		"UDP payload bytes forwarded through WebSocket tunnel sessions.", []string{"direction"}, nil)
+	tunnelPacketsDesc = prometheus.NewDesc("tunnel_server_tunnel_packets_total",
+		"UDP packets forwarded through WebSocket tunnel sessions.", []string{"direction"}, nil)
+	tunnelRejectedDesc = prometheus.NewDesc("tunnel_server_tunnel_rejected_total",
+		"WebSocket tunnel requests refused by the tunnel policy or failing to connect, by error code.", []string{"code"}, nil)
+
+	wgPeersDesc = prometheus.NewDesc("tunnel_server_wireguard_peers",
+		"WireGuard peers configured on the device.", nil, nil)
+	wgActivePeersDesc = prometheus.NewDesc("tunnel_server_wireguard_active_peers",
+		"WireGuard peers with a handshake in the last 3 minutes.", nil, nil)
+	wgBytesDesc = prometheus.NewDesc("tunnel_server_wireguard_bytes_total",
+		"Bytes received from and sent to all WireGuard peers.", []string{"direction"}, nil)
+	wgPeerBytesDesc = prometheus.NewDesc("tunnel_server_wireguard_peer_bytes_total",
+		"Bytes received from and sent to a WireGuard peer.", []string{"public_key", "direction"}, nil)
+	wgPeerHandshakeDesc = prometheus.NewDesc("tunnel_server_wireguard_peer_last_handshake_timestamp_seconds",
+		"Time of the latest handshake of a WireGuard peer.", []string{"public_key"}, nil)
+
+	dnsQueriesDesc = prometheus.NewDesc("tunnel_server_dns_queries_total",
+		"DNS queries, by whether they were answered from the hosts cache or forwarded upstream.", []string{"result"}, nil)
+	dnsUpstreamErrorsDesc = prometheus.NewDesc("tunnel_server_dns_upstream_errors_total",
+		"DNS queries the upstream server failed to answer.", nil, nil)
+	dnsCacheHitRatioDesc = prometheus.NewDesc("tunnel_server_dns_cache_hit_ratio",
+		"Share of DNS queries answered from the hosts cache since start.", nil, nil)
+)
+
+// Metrics exposes the traffic accounting of the tunnel server in the Prometheus format: WebSocket
+// tunnel sessions, WireGuard peers (read from the device on every scrape) and DNS queries
+//
+// The metrics port is reachable from the cluster without authentication, so peers are labelled by
+// public key only: the owners and device names of the keys are served to their users by /wg/peers.
+type Metrics struct {
+	logger    *zap.Logger
+	state     *ServerState
+	wireguard *WireGuardHandler
+	dns       *DNSServer
+	registry  *prometheus.Registry
+}
+
+// TrafficSummary aggregates the accounting of the tunnel server
+type TrafficSummary struct {
+	TunnelSessions      int64   `json:"tunnelSessions"`
+	TunnelSessionsTotal int64   `json:"tunnelSessionsTotal"`
+	Peers               int     `json:"peers"`
+	ActivePeers         int     `json:"activePeers"`
+	BytesReceived       int64   `json:"bytesReceived"` // From all WireGuard peers
+	BytesSent           int64   `json:"bytesSent"`     // To all WireGuard peers
+	DNSQueries          uint64  `json:"dnsQueries"`
+	DNSCacheHitRatio    float64 `json:"dnsCacheHitRatio"`
+}
+
+// NewMetrics creates the metrics of the tunnel server, wireguard and dns may be nil
+func NewMetrics(logger *zap.Logger, state *ServerState, wireguard *WireGuardHandler, dns *DNSServer) *Metrics {
+	m := &Metrics{
+		logger:    logger,
+		state:     state,
+		wireguard: wireguard,
+		dns:       dns,
+		registry:  prometheus.NewRegistry(),
+	}
+	m.registry.MustRegister(
+		m,
+		state.sessionDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler returns the http.Handler serving the metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Describe implements prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		tunnelSessionsDesc, tunnelSessionsTotalDesc, tunnelBytesDesc, tunnelPacketsDesc, tunnelRejectedDesc,
+		wgPeersDesc, wgActivePeersDesc, wgBytesDesc, wgPeerBytesDesc, wgPeerHandshakeDesc,
+		dnsQueriesDesc, dnsUpstreamErrorsDesc, dnsCacheHitRatioDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(tunnelSessionsDesc, prometheus.GaugeValue, float64(m.state.GetActiveConnections()))
+	ch <- prometheus.MustNewConstMetric(tunnelSessionsTotalDesc, prometheus.CounterValue, float64(m.state.GetTotalConnections()))
+	ch <- prometheus.MustNewConstMetric(tunnelBytesDesc, prometheus.CounterValue, float64(m.state.GetBytesReceived()), "received")
+	ch <- prometheus.MustNewConstMetric(tunnelBytesDesc, prometheus.CounterValue, float64(m.state.GetBytesSent()), "sent")
+	ch <- prometheus.MustNewConstMetric(tunnelPacketsDesc, prometheus.CounterValue, float64(m.state.GetPacketsReceived()), "received")
+	ch <- prometheus.MustNewConstMetric(tunnelPacketsDesc, prometheus.CounterValue, float64(m.state.GetPacketsSent()), "sent")
+	for code, count := range m.state.GetRejectedSessions() {
+		ch <- prometheus.MustNewConstMetric(tunnelRejectedDesc, prometheus.CounterValue, float64(count), code)
+	}
+
+	if m.wireguard != nil {
+		m.collectWireGuard(ch)
+	}
+
+	if m.dns != nil {
+		stats := m.dns.Stats()
+		ch <- prometheus.MustNewConstMetric(dnsQueriesDesc, prometheus.CounterValue, float64(stats.CacheAnswers), "cache")
+		ch <- prometheus.MustNewConstMetric(dnsQueriesDesc, prometheus.CounterValue, float64(stats.Forwarded), "forwarded")
+		ch <- prometheus.MustNewConstMetric(dnsUpstreamErrorsDesc, prometheus.CounterValue, float64(stats.UpstreamErrors))
+		ch <- prometheus.MustNewConstMetric(dnsCacheHitRatioDesc, prometheus.GaugeValue, stats.CacheHitRatio())
+	}
+}
+
+// collectWireGuard collects the aggregate and per-peer statistics of the WireGuard device
+func (m *Metrics) collectWireGuard(ch chan<- prometheus.Metric) {
+	stats, err := m.wireguard.peerStats(m.wireguard.device)
+	if err != nil {
+		m.logger.Warn("failed to collect WireGuard metrics", zap.Error(err))
+		return
+	}
+
+	summary := summarizePeers(stats, time.Now())
+	ch <- prometheus.MustNewConstMetric(wgPeersDesc, prometheus.GaugeValue, float64(summary.Peers))
+	ch <- prometheus.MustNewConstMetric(wgActivePeersDesc, prometheus.GaugeValue, float64(summary.ActivePeers))
+	ch <- prometheus.MustNewConstMetric(wgBytesDesc, prometheus.CounterValue, float64(summary.BytesReceived), "received")
+	ch <- prometheus.MustNewConstMetric(wgBytesDesc, prometheus.CounterValue, float64(summary.BytesSent), "sent")
+
+	for _, peer := range m.wireguard.listPeers(nil, stats) {
+		ch <- prometheus.MustNewConstMetric(wgPeerBytesDesc, prometheus.CounterValue, float64(peer.RxBytes),
+			peer.PublicKey, "received")
+		ch <- prometheus.MustNewConstMetric(wgPeerBytesDesc, prometheus.CounterValue, float64(peer.TxBytes),
+			peer.PublicKey, "sent")
+		if peer.LastHandshake != nil {
+			ch <- prometheus.MustNewConstMetric(wgPeerHandshakeDesc, prometheus.GaugeValue, float64(peer.LastHandshake.Unix()),
+				peer.PublicKey)
+		}
+	}
+}
+
+// Summary returns the aggregate accounting of the tunnel server
+func (m *Metrics) Summary() TrafficSummary {
+	summary := TrafficSummary{
+		TunnelSessions:      m.state.GetActiveConnections(),
+		TunnelSessionsTotal: m.state.GetTotalConnections(),
+	}
+
+	if m.wireguard != nil {
+		if stats, err := m.wireguard.peerStats(m.wireguard.device); err == nil {
+			peers := summarizePeers(stats, time.Now())
+			summary.Peers = peers.Peers
+			summary.ActivePeers = peers.ActivePeers
+			summary.BytesReceived = peers.BytesReceived
+			summary.BytesSent = peers.BytesSent
+		}
+	}
+
+	if m.dns != nil {
+		stats := m.dns.Stats()
+		summary.DNSQueries = stats.Queries
+		summary.DNSCacheHitRatio = stats.CacheHitRatio()
+	}
+	return summary
+}
+
+// summarizePeers aggregates the statistics of the WireGuard peers
+func summarizePeers(stats map[string]wgPeerStats, now time.Time) TrafficSummary {
+	var summary TrafficSummary
+	for _, s := range stats {
+		summary.Peers++
+		if !s.LatestHandshake.IsZero() && now.Sub(s.LatestHandshake) <= wgActiveHandshakeWindow {
+			summary.ActivePeers++
+		}
+		summary.BytesReceived += s.RxBytes
+		summary.BytesSent += s.TxBytes
+	}
+	return summary
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizePeers(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	stats := map[string]wgPeerStats{
+		"active": {LatestHandshake: now.Add(-time.Minute), RxBytes: 100, TxBytes: 200},
+		"idle":   {LatestHandshake: now.Add(-time.Hour), RxBytes: 10, TxBytes: 20},
+		"new":    {},
+	}
+
+	summary := summarizePeers(stats, now)
+	if summary.Peers != 3 || summary.ActivePeers != 1 || summary.BytesReceived != 110 || summary.BytesSent != 220 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
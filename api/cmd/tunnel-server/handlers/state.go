@@ -4,25 +4,82 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // ServerState holds the shared state for the tunnel server
+//
+// It observes the WebSocket tunnel sessions of the UDP server (tunnel.Observer): connections are
+// tunnel sessions, bytes are the UDP payloads forwarded through them.
 type ServerState struct {
 	startTime         time.Time
 	activeConnections atomic.Int64
 	totalConnections  atomic.Int64
 	bytesReceived     atomic.Uint64
 	bytesSent         atomic.Uint64
+	packetsReceived   atomic.Uint64
+	packetsSent       atomic.Uint64
+	sessionDuration   prometheus.Histogram
 	mu                sync.RWMutex
+	rejectedSessions  map[string]uint64 // Refused tunnel requests by error code, guarded by mu
 }
 
 // NewServerState creates a new ServerState
 func NewServerState() *ServerState {
 	return &ServerState{
-		startTime: time.Now(),
+		startTime:        time.Now(),
+		rejectedSessions: make(map[string]uint64),
+		sessionDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "tunnel_server_tunnel_session_duration_seconds",
+			Help:    "Duration of closed WebSocket tunnel sessions.",
+			Buckets: prometheus.ExponentialBuckets(1, 4, 10), // 1s to ~3 days
+		}),
 	}
 }
 
+// SessionStarted counts a new tunnel session (tunnel.Observer)
+func (s *ServerState) SessionStarted() {
+	s.IncrementConnections()
+}
+
+// SessionEnded counts a closed tunnel session and records its duration (tunnel.Observer)
+func (s *ServerState) SessionEnded(duration time.Duration) {
+	s.DecrementConnections()
+	s.sessionDuration.Observe(duration.Seconds())
+}
+
+// PacketReceived accounts a packet received through a tunnel session (tunnel.Observer)
+func (s *ServerState) PacketReceived(bytes int) {
+	s.packetsReceived.Add(1)
+	s.AddBytesReceived(uint64(bytes))
+}
+
+// PacketSent accounts a packet sent through a tunnel session (tunnel.Observer)
+func (s *ServerState) PacketSent(bytes int) {
+	s.packetsSent.Add(1)
+	s.AddBytesSent(uint64(bytes))
+}
+
+// SessionRejected counts a refused tunnel request (tunnel.Observer)
+func (s *ServerState) SessionRejected(code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejectedSessions[code]++
+}
+
+// GetRejectedSessions returns the refused tunnel requests by error code
+func (s *ServerState) GetRejectedSessions() map[string]uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rejected := make(map[string]uint64, len(s.rejectedSessions))
+	for code, count := range s.rejectedSessions {
+		rejected[code] = count
+	}
+	return rejected
+}
+
 // IncrementConnections increments the connection counters
 func (s *ServerState) IncrementConnections() {
 	s.activeConnections.Add(1)
@@ -73,3 +130,13 @@ func (s *ServerState) GetBytesReceived() uint64 {
 func (s *ServerState) GetBytesSent() uint64 {
 	return s.bytesSent.Load()
 }
+
+// GetPacketsReceived returns the total packets received
+func (s *ServerState) GetPacketsReceived() uint64 {
+	return s.packetsReceived.Load()
+}
+
+// GetPacketsSent returns the total packets sent
+func (s *ServerState) GetPacketsSent() uint64 {
+	return s.packetsSent.Load()
+}
@@ -12,6 +12,7 @@ import (
 // VPNStatusHandler handles VPN connectivity check requests
 type VPNStatusHandler struct {
 	logger    *zap.Logger
+	metrics   *Metrics
 	startTime time.Time
 }
 
@@ -21,12 +22,16 @@ type VPNStatusResponse struct {
 	Message   string `json:"message"`
 	Timestamp string `json:"timestamp"`
 	Uptime    string `json:"uptime"`
+
+	// Stats summarizes the traffic of the tunnel server (aggregates only, the endpoint is unauthenticated)
+	Stats *TrafficSummary `json:"stats,omitempty"`
 }
 
-// NewVPNStatusHandler creates a new VPNStatusHandler
-func NewVPNStatusHandler(logger *zap.Logger) *VPNStatusHandler {
+// NewVPNStatusHandler creates a new VPNStatusHandler, metrics may be nil
+func NewVPNStatusHandler(logger *zap.Logger, metrics *Metrics) *VPNStatusHandler {
 	return &VPNStatusHandler{
 		logger:    logger,
+		metrics:   metrics,
 		startTime: time.Now(),
 	}
 }
@@ -61,6 +66,10 @@ func (h *VPNStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Uptime:    uptime.String(),
 	}
+	if h.metrics != nil {
+		summary := h.metrics.Summary()
+		response.Stats = &summary
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -11,7 +12,9 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/kloudlite/kloudlite/api/cmd/tunnel-server/middleware"
 	"go.uber.org/zap"
 )
 
@@ -20,6 +23,14 @@ type PeerInfo struct {
 	PublicKey  string `json:"publicKey"`
 	IP         string `json:"ip"`
 	DeviceName string `json:"deviceName"`
+	DeviceID   string `json:"deviceId,omitempty"` // Stable ID of the device (~/.kltun/device-id), empty for ephemeral peers
+	CIDR       string `json:"cidr,omitempty"`     // Subnet the IP was allocated from, the default CIDR when empty
+
+	Owner       string    `json:"owner,omitempty"`       // User who registered the peer, empty for peers registered before owners were recorded
+	Environment string    `json:"environment,omitempty"` // Environment of the service token that registered the peer, empty for users
+	TokenID     string    `json:"tokenId,omitempty"`     // ID (jti) of the token that registered the peer, removed with it when revoked
+	CreatedAt   time.Time `json:"createdAt"`
+	LastSeen    time.Time `json:"lastSeen"` // Latest handshake seen by the stale-peer GC, kept across restarts
 }
 
 // WireGuardHandler handles WireGuard peer management requests
@@ -27,22 +38,36 @@ type WireGuardHandler struct {
 	logger        *zap.Logger
 	device        string
 	cidr          string
+	cidrPool      string // Subnets handed out when cidr overlaps the other tunnels of a client (e.g., 10.17.0.0/16)
 	serverAddress string // Server's WireGuard address (e.g., 10.17.0.1)
 	endpoint      string // Server's public endpoint (e.g., tunnel.example.com:443)
 	storagePath   string // Path to persist peers
 
+	// Peer lifecycle
+	idleTimeout     time.Duration // Peers without a handshake for longer are removed, 0 disables the GC
+	maxPeersPerUser int           // 0 for no limit
+
 	// IP allocation tracking
-	mu    sync.Mutex
-	peers map[string]*PeerInfo // publicKey -> PeerInfo
+	mu      sync.Mutex
+	peers   map[string]*PeerInfo // publicKey -> PeerInfo
+	subnets map[string]bool      // extra subnets configured on the device
+
+	// Filters of service-token peers
+	hosts    *HostsCache // Services reachable by service-token peers, none until set
+	filterMu sync.Mutex  // Serializes the iptables changes of the filters
 }
 
 // WireGuardHandlerConfig holds configuration for the WireGuard handler
 type WireGuardHandlerConfig struct {
 	Device        string
 	CIDR          string // e.g., "10.17.0.0/24"
+	CIDRPool      string // e.g., "10.17.0.0/16", split in subnets of the size of CIDR
 	ServerAddress string // e.g., "10.17.0.1"
 	Endpoint      string // e.g., "tunnel.example.com:443"
 	StoragePath   string // e.g., "/var/lib/tunnel-server/peers.json"
+
+	IdleTimeout     time.Duration // e.g., 720h, 0 keeps idle peers forever
+	MaxPeersPerUser int           // e.g., 10, 0 for no limit
 }
 
 // NewWireGuardHandler creates a new WireGuardHandler
@@ -53,6 +78,9 @@ func NewWireGuardHandler(logger *zap.Logger, cfg WireGuardHandlerConfig) *WireGu
 	if cfg.CIDR == "" {
 		cfg.CIDR = "10.17.0.0/24"
 	}
+	if cfg.CIDRPool == "" {
+		cfg.CIDRPool = cfg.CIDR
+	}
 	if cfg.ServerAddress == "" {
 		cfg.ServerAddress = "10.17.0.1"
 	}
@@ -61,13 +89,17 @@ func NewWireGuardHandler(logger *zap.Logger, cfg WireGuardHandlerConfig) *WireGu
 	}
 
 	h := &WireGuardHandler{
-		logger:        logger,
-		device:        cfg.Device,
-		cidr:          cfg.CIDR,
-		serverAddress: cfg.ServerAddress,
-		endpoint:      cfg.Endpoint,
-		storagePath:   cfg.StoragePath,
-		peers:         make(map[string]*PeerInfo),
+		logger:          logger,
+		device:          cfg.Device,
+		cidr:            cfg.CIDR,
+		cidrPool:        cfg.CIDRPool,
+		serverAddress:   cfg.ServerAddress,
+		endpoint:        cfg.Endpoint,
+		storagePath:     cfg.StoragePath,
+		idleTimeout:     cfg.IdleTimeout,
+		maxPeersPerUser: cfg.MaxPeersPerUser,
+		peers:           make(map[string]*PeerInfo),
+		subnets:         make(map[string]bool),
 	}
 
 	// Load persisted peers on startup
@@ -88,6 +120,14 @@ type PublicKeyResponse struct {
 type CreatePeerRequest struct {
 	DeviceName string `json:"deviceName"`
 	PublicKey  string `json:"publicKey"` // Client's WireGuard public key
+
+	// DeviceID is the stable ID of the device, its older peers are replaced by the new one (e.g.,
+	// after reinstalling kltun). Device names are not unique, peers without an ID are never replaced.
+	DeviceID string `json:"deviceId,omitempty"`
+
+	// ExcludeCIDRs are the subnets the client already routes through tunnels to other servers,
+	// the peer gets an IP from a subnet of the pool that does not overlap them
+	ExcludeCIDRs []string `json:"excludeCidrs,omitempty"`
 }
 
 // CreatePeerResponse represents the response for peer creation
@@ -96,6 +136,7 @@ type CreatePeerResponse struct {
 	IP              string `json:"ip"`
 	ServerPublicKey string `json:"serverPublicKey"` // Server's WireGuard public key
 	CIDR            string `json:"cidr"`            // VPN CIDR (e.g., "10.17.0.0/24")
+	ServerAddress   string `json:"serverAddress"`   // Server's address in CIDR, the gateway and DNS server of the peer
 	AlreadyExists   bool   `json:"alreadyExists"`   // True if peer already existed
 }
 
@@ -178,28 +219,119 @@ func (h *WireGuardHandler) handleCreatePeer(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	excluded, err := parseCIDRs(req.ExcludeCIDRs)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid excludeCidrs: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	claims, _ := middleware.GetUserFromContext(r.Context())
+	owner, environment, tokenID := "", "", ""
+	if claims != nil {
+		owner = claims.Owner()
+		tokenID = claims.ID
+		if claims.IsService() {
+			environment = claims.Environment
+		}
+	}
+
 	// Check if peer already exists
 	existingPeer, alreadyExists := h.getPeer(req.PublicKey)
+	if alreadyExists && !h.claimPeer(existingPeer, claims) {
+		http.Error(w, "public key is registered by another user", http.StatusForbidden)
+		return
+	}
+
+	// The client now routes the subnet of the peer through another tunnel, the peer moves to another subnet
+	reallocate := alreadyExists && overlapsAny(h.peerCIDR(existingPeer), excluded)
+	if reallocate {
+		h.logger.Info("peer subnet overlaps the other tunnels of the client, reallocating",
+			zap.String("deviceName", req.DeviceName),
+			zap.String("publicKey", req.PublicKey),
+			zap.String("cidr", h.peerCIDR(existingPeer)))
+		alreadyExists = false
+	}
 
-	var peerIP string
+	var peerIP, peerCIDR string
 	if alreadyExists {
 		// Peer already exists, return existing IP
 		peerIP = existingPeer.IP
+		peerCIDR = h.peerCIDR(existingPeer)
 		h.logger.Info("peer already exists, returning existing configuration",
 			zap.String("device", device),
 			zap.String("deviceName", req.DeviceName),
 			zap.String("publicKey", req.PublicKey),
 			zap.String("ip", peerIP))
 	} else {
+		// Pick a subnet that does not overlap the other tunnels of the client, before a reallocated
+		// peer is removed so that the client keeps it when none is left
+		peerCIDR, err = h.selectCIDR(excluded)
+		if err != nil {
+			h.logger.Warn("no subnet available for peer", zap.Strings("excludeCidrs", req.ExcludeCIDRs), zap.Error(err))
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		if reallocate {
+			if err := h.deletePeer(device, req.PublicKey); err != nil {
+				h.logger.Warn("failed to remove peer before reallocation", zap.Error(err))
+			}
+			h.removePeerFilter(device, existingPeer)
+			h.removePeer(req.PublicKey)
+			h.releaseSubnet(device, h.peerCIDR(existingPeer))
+		}
+
+		// A device registering a new key replaces its old peer (e.g., after reinstalling kltun)
+		for _, stale := range h.devicePeers(owner, req.DeviceID) {
+			h.logger.Info("replacing peer of device",
+				zap.String("deviceName", req.DeviceName),
+				zap.String("deviceId", req.DeviceID),
+				zap.String("owner", owner),
+				zap.String("publicKey", stale))
+			if err := h.revokePeer(device, stale); err != nil {
+				h.logger.Warn("failed to remove replaced peer", zap.String("publicKey", stale), zap.Error(err))
+			}
+		}
+
 		// Allocate an IP for the new peer
-		var err error
-		peerIP, err = h.allocateIP(req.PublicKey, req.DeviceName)
+		peerIP, err = h.allocateIP(PeerInfo{
+			PublicKey:   req.PublicKey,
+			DeviceName:  req.DeviceName,
+			DeviceID:    req.DeviceID,
+			CIDR:        peerCIDR,
+			Owner:       owner,
+			Environment: environment,
+			TokenID:     tokenID,
+		})
+		if errors.Is(err, errDeviceLimit) {
+			h.logger.Warn("device limit reached", zap.String("owner", owner), zap.Int("maxPeersPerUser", h.maxPeersPerUser))
+			http.Error(w, fmt.Sprintf("device limit reached (%d devices), revoke an unused device first", h.maxPeersPerUser), http.StatusConflict)
+			return
+		}
 		if err != nil {
 			h.logger.Error("failed to allocate IP", zap.Error(err))
 			http.Error(w, fmt.Sprintf("failed to allocate IP: %v", err), http.StatusInternalServerError)
 			return
 		}
 
+		// Configured once the peer is allocated, so that releasing its last other peer does not remove it
+		if err := h.ensureSubnet(device, peerCIDR); err != nil {
+			h.logger.Error("failed to configure subnet", zap.String("cidr", peerCIDR), zap.Error(err))
+			h.removePeer(req.PublicKey)
+			h.releaseSubnet(device, peerCIDR)
+			http.Error(w, fmt.Sprintf("failed to configure subnet: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Service-token peers only reach the services of their environment, filtered before the peer is added
+		if err := h.ensurePeerFilter(device, &PeerInfo{PublicKey: req.PublicKey, IP: peerIP, CIDR: peerCIDR, Environment: environment}); err != nil {
+			h.logger.Error("failed to configure peer filter", zap.String("environment", environment), zap.Error(err))
+			h.removePeer(req.PublicKey)
+			h.releaseSubnet(device, peerCIDR)
+			http.Error(w, fmt.Sprintf("failed to configure peer filter: %v", err), http.StatusInternalServerError)
+			return
+		}
+
 		// Add peer to WireGuard
 		if err := h.addPeer(device, req.PublicKey, peerIP); err != nil {
 			h.logger.Error("failed to add peer",
@@ -208,6 +340,7 @@ func (h *WireGuardHandler) handleCreatePeer(w http.ResponseWriter, r *http.Reque
 				zap.Error(err))
 			// Release the allocated IP on failure
 			h.removePeer(req.PublicKey)
+			h.releaseSubnet(device, peerCIDR)
 			http.Error(w, fmt.Sprintf("failed to add peer: %v", err), http.StatusInternalServerError)
 			return
 		}
@@ -220,6 +353,7 @@ func (h *WireGuardHandler) handleCreatePeer(w http.ResponseWriter, r *http.Reque
 		h.logger.Info("peer created successfully",
 			zap.String("device", device),
 			zap.String("deviceName", req.DeviceName),
+			zap.String("owner", owner),
 			zap.String("publicKey", req.PublicKey),
 			zap.String("ip", peerIP))
 	}
@@ -236,7 +370,8 @@ func (h *WireGuardHandler) handleCreatePeer(w http.ResponseWriter, r *http.Reque
 		Success:         true,
 		IP:              peerIP,
 		ServerPublicKey: serverPublicKey,
-		CIDR:            h.cidr,
+		CIDR:            peerCIDR,
+		ServerAddress:   h.gatewayOf(peerCIDR),
 		AlreadyExists:   alreadyExists,
 	}
 
@@ -258,7 +393,14 @@ func (h *WireGuardHandler) handleDeletePeer(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := h.deletePeer(device, req.PublicKey); err != nil {
+	// Users delete their own peers, admins revoke any peer
+	claims, _ := middleware.GetUserFromContext(r.Context())
+	if peer, exists := h.getPeer(req.PublicKey); exists && !canManagePeer(peer, claims) {
+		http.Error(w, "peer is registered by another user", http.StatusForbidden)
+		return
+	}
+
+	if err := h.revokePeer(device, req.PublicKey); err != nil {
 		h.logger.Error("failed to delete peer",
 			zap.String("device", device),
 			zap.String("publicKey", req.PublicKey),
@@ -267,15 +409,11 @@ func (h *WireGuardHandler) handleDeletePeer(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Remove peer from map and persist
-	h.removePeer(req.PublicKey)
-	if err := h.savePeers(); err != nil {
-		h.logger.Error("failed to persist peers after delete", zap.Error(err))
+	fields := []zap.Field{zap.String("device", device), zap.String("publicKey", req.PublicKey)}
+	if claims != nil {
+		fields = append(fields, zap.String("by", claims.Owner()))
 	}
-
-	h.logger.Info("peer deleted successfully",
-		zap.String("device", device),
-		zap.String("publicKey", req.PublicKey))
+	h.logger.Info("peer deleted successfully", fields...)
 
 	response := PeerResponse{
 		Success: true,
@@ -288,25 +426,35 @@ func (h *WireGuardHandler) handleDeletePeer(w http.ResponseWriter, r *http.Reque
 	}
 }
 
-// allocateIP allocates an IP address from the CIDR range and stores peer info
-func (h *WireGuardHandler) allocateIP(publicKey, deviceName string) (string, error) {
+// errDeviceLimit is returned by allocateIP when the owner of a new peer has maxPeersPerUser peers already
+var errDeviceLimit = errors.New("device limit reached")
+
+// allocateIP allocates an IP address from the CIDR of a new peer and stores its info
+//
+// The device limit of the owner is checked under the same lock, so that concurrent registrations
+// cannot exceed it.
+func (h *WireGuardHandler) allocateIP(info PeerInfo) (string, error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	// Check if this public key already has an IP
-	if peer, exists := h.peers[publicKey]; exists {
+	if peer, exists := h.peers[info.PublicKey]; exists {
 		return peer.IP, nil
 	}
+	if h.maxPeersPerUser > 0 && info.Owner != "" && h.ownerPeerCount(info.Owner) >= h.maxPeersPerUser {
+		return "", errDeviceLimit
+	}
+	cidr := info.CIDR
 
 	// Parse CIDR
-	_, ipNet, err := net.ParseCIDR(h.cidr)
+	_, ipNet, err := net.ParseCIDR(cidr)
 	if err != nil {
 		return "", fmt.Errorf("invalid CIDR: %w", err)
 	}
 
 	// Get used IPs
 	usedIPs := make(map[string]bool)
-	usedIPs[h.serverAddress] = true // Server's IP is always used
+	usedIPs[h.gatewayOf(cidr)] = true // Server's IP is always used
 
 	for _, peer := range h.peers {
 		usedIPs[peer.IP] = true
@@ -334,16 +482,193 @@ func (h *WireGuardHandler) allocateIP(publicKey, deviceName string) (string, err
 
 		ipStr := ip.String()
 		if !usedIPs[ipStr] {
-			h.peers[publicKey] = &PeerInfo{
-				PublicKey:  publicKey,
-				IP:         ipStr,
-				DeviceName: deviceName,
-			}
+			info.IP = ipStr
+			info.CreatedAt = time.Now().UTC()
+			h.peers[info.PublicKey] = &info
 			return ipStr, nil
 		}
 	}
 
-	return "", fmt.Errorf("no available IP addresses in CIDR %s", h.cidr)
+	return "", fmt.Errorf("no available IP addresses in CIDR %s", cidr)
+}
+
+// selectCIDR returns the default CIDR, or the first subnet of the pool that does not overlap excluded
+func (h *WireGuardHandler) selectCIDR(excluded []*net.IPNet) (string, error) {
+	_, defaultNet, err := net.ParseCIDR(h.cidr)
+	if err != nil {
+		return "", fmt.Errorf("invalid CIDR: %w", err)
+	}
+	if !overlapsAny(h.cidr, excluded) {
+		return h.cidr, nil
+	}
+
+	_, pool, err := net.ParseCIDR(h.cidrPool)
+	if err != nil {
+		return "", fmt.Errorf("invalid CIDR pool: %w", err)
+	}
+	poolBits, _ := pool.Mask.Size()
+	subnetBits, bits := defaultNet.Mask.Size()
+	if subnetBits < poolBits || subnetBits-poolBits > 16 {
+		return "", fmt.Errorf("CIDR pool %s cannot be split in subnets of %s", h.cidrPool, h.cidr)
+	}
+
+	subnet := &net.IPNet{IP: pool.IP.Mask(pool.Mask), Mask: net.CIDRMask(subnetBits, bits)}
+	for i := 0; i < 1<<(subnetBits-poolBits); i++ {
+		candidate := subnet.String()
+		if candidate != h.cidr && !overlapsAny(candidate, excluded) {
+			return candidate, nil
+		}
+		subnet.IP = nextSubnet(subnet)
+	}
+
+	return "", fmt.Errorf("every subnet of %s overlaps the tunnels of the client", h.cidrPool)
+}
+
+// ensureSubnet adds the gateway address of a subnet of the pool to the device, and masquerades its traffic
+func (h *WireGuardHandler) ensureSubnet(device, cidr string) error {
+	if cidr == h.cidr {
+		return nil // Configured by wg-quick
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subnets[cidr] {
+		return nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR: %w", err)
+	}
+	ones, _ := ipNet.Mask.Size()
+
+	// ip addr add <gateway>/<bits> dev <device>
+	address := fmt.Sprintf("%s/%d", h.gatewayOf(cidr), ones)
+	if output, err := exec.Command("ip", "addr", "add", address, "dev", device).CombinedOutput(); err != nil && !strings.Contains(string(output), "File exists") {
+		return fmt.Errorf("ip addr add failed: %s: %w", string(output), err)
+	}
+
+	// iptables -t nat -A POSTROUTING -s <cidr> -j MASQUERADE, unless the rule exists
+	rule := []string{"POSTROUTING", "-s", cidr, "-j", "MASQUERADE"}
+	if err := exec.Command("iptables", append([]string{"-t", "nat", "-C"}, rule...)...).Run(); err != nil {
+		if output, err := exec.Command("iptables", append([]string{"-t", "nat", "-A"}, rule...)...).CombinedOutput(); err != nil {
+			return fmt.Errorf("iptables failed: %s: %w", string(output), err)
+		}
+	}
+
+	h.subnets[cidr] = true
+	h.logger.Info("configured peer subnet", zap.String("cidr", cidr), zap.String("address", address))
+	return nil
+}
+
+// releaseSubnet removes a subnet of the pool from the device, its gateway address and masquerading,
+// once its last peer is gone
+func (h *WireGuardHandler) releaseSubnet(device, cidr string) {
+	if cidr == h.cidr {
+		return // Configured by wg-quick
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.subnets[cidr] {
+		return
+	}
+	for _, peer := range h.peers {
+		if h.peerCIDR(peer) == cidr {
+			return
+		}
+	}
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return
+	}
+	ones, _ := ipNet.Mask.Size()
+
+	// ip addr del <gateway>/<bits> dev <device>
+	address := fmt.Sprintf("%s/%d", h.gatewayOf(cidr), ones)
+	if output, err := exec.Command("ip", "addr", "del", address, "dev", device).CombinedOutput(); err != nil {
+		h.logger.Warn("failed to remove peer subnet address", zap.String("address", address), zap.String("output", string(output)), zap.Error(err))
+	}
+
+	// iptables -t nat -D POSTROUTING -s <cidr> -j MASQUERADE
+	rule := []string{"POSTROUTING", "-s", cidr, "-j", "MASQUERADE"}
+	if output, err := exec.Command("iptables", append([]string{"-t", "nat", "-D"}, rule...)...).CombinedOutput(); err != nil {
+		h.logger.Warn("failed to remove peer subnet masquerading", zap.String("cidr", cidr), zap.String("output", string(output)), zap.Error(err))
+	}
+
+	delete(h.subnets, cidr)
+	h.logger.Info("released peer subnet", zap.String("cidr", cidr))
+}
+
+// peerCIDR returns the subnet of a peer, derived from its IP for peers stored before subnets were recorded
+func (h *WireGuardHandler) peerCIDR(peer *PeerInfo) string {
+	if peer.CIDR != "" {
+		return peer.CIDR
+	}
+	_, defaultNet, err := net.ParseCIDR(h.cidr)
+	ip := net.ParseIP(peer.IP)
+	if err != nil || ip == nil {
+		return h.cidr
+	}
+	return (&net.IPNet{IP: ip.Mask(defaultNet.Mask), Mask: defaultNet.Mask}).String()
+}
+
+// gatewayOf returns the server's address in a subnet: the configured one for the default CIDR,
+// the first address of the others
+func (h *WireGuardHandler) gatewayOf(cidr string) string {
+	if cidr == h.cidr {
+		return h.serverAddress
+	}
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return h.serverAddress
+	}
+	return incrementIP(ipNet.IP.Mask(ipNet.Mask)).String()
+}
+
+// parseCIDRs parses a list of CIDRs
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// overlapsAny reports whether cidr overlaps one of nets
+func overlapsAny(cidr string, nets []*net.IPNet) bool {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	for _, other := range nets {
+		if ipNet.Contains(other.IP) || other.Contains(ipNet.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextSubnet returns the network address of the subnet following subnet
+func nextSubnet(subnet *net.IPNet) net.IP {
+	ip := subnet.IP.Mask(subnet.Mask)
+	ones, bits := subnet.Mask.Size()
+	step := 1 << (bits - ones)
+
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	carry := step
+	for i := len(next) - 1; i >= 0 && carry > 0; i-- {
+		sum := int(next[i]) + carry
+		next[i] = byte(sum)
+		carry = sum >> 8
+	}
+	return next
 }
 
 // removePeer removes a peer from the in-memory map
@@ -384,6 +709,7 @@ func (h *WireGuardHandler) getPeer(publicKey string) (*PeerInfo, bool) {
 						PublicKey:  publicKey,
 						IP:         ip,
 						DeviceName: "unknown",
+						CreatedAt:  time.Now().UTC(),
 					}
 					h.mu.Lock()
 					h.peers[publicKey] = peer
@@ -441,14 +767,32 @@ func (h *WireGuardHandler) loadPeers() error {
 		return fmt.Errorf("failed to parse peers file: %w", err)
 	}
 
+	// Peers stored before creation times were recorded get a full idle timeout from now
+	now := time.Now().UTC()
 	h.mu.Lock()
 	for _, peer := range peers {
+		if peer.CreatedAt.IsZero() {
+			peer.CreatedAt = now
+		}
 		h.peers[peer.PublicKey] = peer
 	}
 	h.mu.Unlock()
 
-	// Re-add peers to WireGuard
+	// Re-add peers to WireGuard, with the subnets they were allocated from
 	for _, peer := range peers {
+		if err := h.ensureSubnet(h.device, h.peerCIDR(peer)); err != nil {
+			h.logger.Warn("failed to restore peer subnet",
+				zap.String("cidr", h.peerCIDR(peer)),
+				zap.Error(err))
+		}
+		if err := h.ensurePeerFilter(h.device, peer); err != nil {
+			// Not re-added, the service token would otherwise reach the whole network
+			h.logger.Warn("failed to restore peer filter, skipping peer",
+				zap.String("publicKey", peer.PublicKey),
+				zap.String("environment", peer.Environment),
+				zap.Error(err))
+			continue
+		}
 		if err := h.addPeer(h.device, peer.PublicKey, peer.IP); err != nil {
 			h.logger.Warn("failed to re-add peer to WireGuard",
 				zap.String("publicKey", peer.PublicKey),
@@ -468,9 +812,9 @@ func (h *WireGuardHandler) loadPeers() error {
 // savePeers persists peers to the storage file
 func (h *WireGuardHandler) savePeers() error {
 	h.mu.Lock()
-	peers := make([]*PeerInfo, 0, len(h.peers))
+	peers := make([]PeerInfo, 0, len(h.peers))
 	for _, peer := range h.peers {
-		peers = append(peers, peer)
+		peers = append(peers, *peer)
 	}
 	h.mu.Unlock()
 
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Service-token peers are only forwarded to the services of their environment, and only reach the
+// tunnel server itself for DNS and its API: each one gets a chain accepting these and dropping the
+// rest, jumped to from FORWARD and INPUT for the traffic of its IP. The metrics listener and any
+// other port of the pod stay out of reach. The chains are rebuilt with the hosts cache, as services
+// come and go.
+
+// Ports of the tunnel server reachable by service-token peers on their gateway address
+const (
+	filterDNSPort = "53"
+	filterAPIPort = "443"
+)
+
+// SetHostsCache sets the hosts cache resolving the services reachable by service-token peers, and
+// refreshes their filters on every rebuild of the cache
+func (h *WireGuardHandler) SetHostsCache(hc *HostsCache) {
+	h.mu.Lock()
+	h.hosts = hc
+	h.mu.Unlock()
+
+	hc.OnRebuild(h.refreshPeerFilters)
+}
+
+// serviceIPs returns the IPv4 addresses of the services of an environment, the only ones the peers
+// of its service tokens are forwarded to. None until the hosts cache is set.
+func (h *WireGuardHandler) serviceIPs(environment string) []string {
+	h.mu.Lock()
+	hc := h.hosts
+	h.mu.Unlock()
+	if hc == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var ips []string
+	for _, host := range environmentHosts(hc.GetHosts(), environment) {
+		if ip := net.ParseIP(host.IP); ip != nil && ip.To4() != nil && !seen[host.IP] {
+			seen[host.IP] = true
+			ips = append(ips, host.IP)
+		}
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// refreshPeerFilters installs the filters of the service-token peers with the current service IPs
+func (h *WireGuardHandler) refreshPeerFilters() {
+	h.mu.Lock()
+	peers := make([]PeerInfo, 0)
+	for _, peer := range h.peers {
+		if peer.Environment != "" {
+			peers = append(peers, *peer)
+		}
+	}
+	h.mu.Unlock()
+
+	for i := range peers {
+		if err := h.ensurePeerFilter(h.device, &peers[i]); err != nil {
+			h.logger.Warn("failed to refresh peer filter",
+				zap.String("publicKey", peers[i].PublicKey),
+				zap.String("environment", peers[i].Environment),
+				zap.Error(err))
+		}
+	}
+}
+
+// ensurePeerFilter restricts the forwarded traffic of a service-token peer to the services of its
+// environment. Peers of users are left unfiltered.
+func (h *WireGuardHandler) ensurePeerFilter(device string, peer *PeerInfo) error {
+	if peer.Environment == "" {
+		return nil
+	}
+
+	h.filterMu.Lock()
+	defer h.filterMu.Unlock()
+
+	chain := peerFilterChain(peer.PublicKey)
+
+	// iptables -N <chain>, unless the chain exists
+	if output, err := exec.Command("iptables", "-N", chain).CombinedOutput(); err != nil && !strings.Contains(string(output), "already exists") {
+		return fmt.Errorf("iptables -N failed: %s: %w", string(output), err)
+	}
+	if output, err := exec.Command("iptables", "-F", chain).CombinedOutput(); err != nil {
+		return fmt.Errorf("iptables -F failed: %s: %w", string(output), err)
+	}
+	for _, rule := range peerFilterRules(chain, h.gatewayOf(h.peerCIDR(peer)), h.serviceIPs(peer.Environment)) {
+		if output, err := exec.Command("iptables", append([]string{"-A"}, rule...)...).CombinedOutput(); err != nil {
+			return fmt.Errorf("iptables -A failed: %s: %w", string(output), err)
+		}
+	}
+
+	// iptables -I FORWARD|INPUT -i <device> -s <ip>/32 -j <chain>, ahead of the ACCEPT of wg-quick, unless the rule exists
+	for _, jump := range peerFilterJumps(device, peer.IP, chain) {
+		if err := exec.Command("iptables", append([]string{"-C"}, jump...)...).Run(); err != nil {
+			if output, err := exec.Command("iptables", append([]string{"-I", jump[0], "1"}, jump[1:]...)...).CombinedOutput(); err != nil {
+				return fmt.Errorf("iptables -I failed: %s: %w", string(output), err)
+			}
+		}
+	}
+	return nil
+}
+
+// removePeerFilter removes the filter of a service-token peer
+func (h *WireGuardHandler) removePeerFilter(device string, peer *PeerInfo) {
+	if peer.Environment == "" {
+		return
+	}
+
+	h.filterMu.Lock()
+	defer h.filterMu.Unlock()
+
+	chain := peerFilterChain(peer.PublicKey)
+	var commands [][]string
+	for _, jump := range peerFilterJumps(device, peer.IP, chain) {
+		commands = append(commands, append([]string{"-D"}, jump...))
+	}
+	commands = append(commands, []string{"-F", chain}, []string{"-X", chain})
+	for _, args := range commands {
+		if output, err := exec.Command("iptables", args...).CombinedOutput(); err != nil {
+			h.logger.Debug("failed to remove peer filter rule",
+				zap.Strings("args", args),
+				zap.String("output", string(output)),
+				zap.Error(err))
+		}
+	}
+}
+
+// peerFilterChain returns the name of the filter chain of a peer, within the 28 characters of iptables
+func peerFilterChain(publicKey string) string {
+	sum := sha256.Sum256([]byte(publicKey))
+	return "KL-PEER-" + hex.EncodeToString(sum[:])[:16]
+}
+
+// peerFilterJumps returns the FORWARD and INPUT rules sending the traffic of a peer to its filter chain
+func peerFilterJumps(device, peerIP, chain string) [][]string {
+	return [][]string{
+		{"FORWARD", "-i", device, "-s", peerIP + "/32", "-j", chain},
+		{"INPUT", "-i", device, "-s", peerIP + "/32", "-j", chain},
+	}
+}
+
+// peerFilterRules returns the rules of a filter chain: DNS and the API on the gateway of the peer, and
+// the service IPs, are accepted, the rest is dropped
+func peerFilterRules(chain, gateway string, serviceIPs []string) [][]string {
+	rules := [][]string{
+		{chain, "-d", gateway + "/32", "-p", "udp", "--dport", filterDNSPort, "-j", "ACCEPT"},
+		{chain, "-d", gateway + "/32", "-p", "tcp", "--dport", filterDNSPort, "-j", "ACCEPT"},
+		{chain, "-d", gateway + "/32", "-p", "tcp", "--dport", filterAPIPort, "-j", "ACCEPT"},
+	}
+	for _, ip := range serviceIPs {
+		rules = append(rules, []string{chain, "-d", ip + "/32", "-j", "ACCEPT"})
+	}
+	return append(rules, []string{chain, "-j", "DROP"})
+}
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/cmd/tunnel-server/middleware"
+	"go.uber.org/zap"
+)
+
+// peerGCInterval is how often handshakes are checked for stale peers
+const peerGCInterval = 10 * time.Minute
+
+// PeerStatus describes a registered peer with its WireGuard statistics
+type PeerStatus struct {
+	PublicKey     string     `json:"publicKey"`
+	DeviceName    string     `json:"deviceName"`
+	Owner         string     `json:"owner,omitempty"`
+	IP            string     `json:"ip"`
+	CIDR          string     `json:"cidr"`
+	Endpoint      string     `json:"endpoint,omitempty"` // Source address of the latest handshake
+	CreatedAt     time.Time  `json:"createdAt"`
+	LastHandshake *time.Time `json:"lastHandshake,omitempty"` // nil when the peer never completed a handshake
+	RxBytes       int64      `json:"rxBytes"`
+	TxBytes       int64      `json:"txBytes"`
+}
+
+// ListPeersResponse represents the response of the peer list endpoint
+type ListPeersResponse struct {
+	Peers              []PeerStatus `json:"peers"`
+	IdleTimeoutSeconds int64        `json:"idleTimeoutSeconds,omitempty"` // Peers idle for longer are removed
+	MaxPeersPerUser    int          `json:"maxPeersPerUser,omitempty"`
+}
+
+// wgPeerStats holds the runtime statistics of a peer from `wg show <device> dump`
+type wgPeerStats struct {
+	Endpoint        string
+	LatestHandshake time.Time // zero when the peer never completed a handshake
+	RxBytes         int64
+	TxBytes         int64
+}
+
+// PeersHandler returns an http.HandlerFunc that lists peers (GET): all of them for admins, their own
+// for other users
+func (h *WireGuardHandler) PeersHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		device := r.URL.Query().Get("device")
+		if device == "" {
+			device = h.device
+		}
+
+		claims, _ := middleware.GetUserFromContext(r.Context())
+		if claims == nil {
+			http.Error(w, "missing user", http.StatusUnauthorized)
+			return
+		}
+
+		stats, err := h.peerStats(device)
+		if err != nil {
+			h.logger.Warn("failed to get peer statistics", zap.String("device", device), zap.Error(err))
+		}
+
+		response := ListPeersResponse{
+			Peers:              h.listPeers(claims, stats),
+			IdleTimeoutSeconds: int64(h.idleTimeout.Seconds()),
+			MaxPeersPerUser:    h.maxPeersPerUser,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			h.logger.Error("failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// listPeers returns the peers visible to a user (all peers for nil claims), ordered by owner and device name
+func (h *WireGuardHandler) listPeers(claims *middleware.UserClaims, stats map[string]wgPeerStats) []PeerStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	peers := make([]PeerStatus, 0, len(h.peers))
+	for _, peer := range h.peers {
+		if claims != nil && !claims.IsAdmin() && peer.Owner != claims.Owner() {
+			continue
+		}
+
+		status := PeerStatus{
+			PublicKey:  peer.PublicKey,
+			DeviceName: peer.DeviceName,
+			Owner:      peer.Owner,
+			IP:         peer.IP,
+			CIDR:       h.peerCIDR(peer),
+			CreatedAt:  peer.CreatedAt,
+		}
+		lastHandshake := peer.LastSeen
+		if s, ok := stats[peer.PublicKey]; ok {
+			status.Endpoint = s.Endpoint
+			status.RxBytes = s.RxBytes
+			status.TxBytes = s.TxBytes
+			if s.LatestHandshake.After(lastHandshake) {
+				lastHandshake = s.LatestHandshake
+			}
+		}
+		if !lastHandshake.IsZero() {
+			status.LastHandshake = &lastHandshake
+		}
+		peers = append(peers, status)
+	}
+
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].Owner != peers[j].Owner {
+			return peers[i].Owner < peers[j].Owner
+		}
+		return peers[i].DeviceName < peers[j].DeviceName
+	})
+	return peers
+}
+
+// claimPeer checks that a user may use an existing peer, and records the user as owner of peers
+// registered before owners were recorded
+func (h *WireGuardHandler) claimPeer(peer *PeerInfo, claims *middleware.UserClaims) bool {
+	if claims == nil {
+		return true
+	}
+
+	h.mu.Lock()
+	claimed := peer.Owner == ""
+	if claimed {
+		peer.Owner = claims.Owner()
+	}
+	owner := peer.Owner
+	h.mu.Unlock()
+
+	if claimed {
+		if err := h.savePeers(); err != nil {
+			h.logger.Error("failed to persist peer owner", zap.Error(err))
+		}
+	}
+	return owner == claims.Owner()
+}
+
+// canManagePeer reports whether a user may delete a peer: admins any peer, users their own ones.
+// Peers without owner are left to admins, as any user could otherwise delete them.
+func canManagePeer(peer *PeerInfo, claims *middleware.UserClaims) bool {
+	if claims == nil {
+		return false
+	}
+	return claims.IsAdmin() || (peer.Owner != "" && peer.Owner == claims.Owner())
+}
+
+// devicePeers returns the public keys of the peers an owner registered for a device ID
+func (h *WireGuardHandler) devicePeers(owner, deviceID string) []string {
+	if owner == "" || deviceID == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var keys []string
+	for key, peer := range h.peers {
+		if peer.Owner == owner && peer.DeviceID == deviceID {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// ownerPeerCount returns the number of peers of an owner, h.mu must be held
+func (h *WireGuardHandler) ownerPeerCount(owner string) int {
+	count := 0
+	for _, peer := range h.peers {
+		if peer.Owner == owner {
+			count++
+		}
+	}
+	return count
+}
+
+// revokePeer removes a peer from the WireGuard device and from storage
+func (h *WireGuardHandler) revokePeer(device, publicKey string) error {
+	if err := h.deletePeer(device, publicKey); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	peer, exists := h.peers[publicKey]
+	h.mu.Unlock()
+	if exists {
+		h.removePeerFilter(device, peer)
+	}
+
+	h.removePeer(publicKey)
+	if exists {
+		h.releaseSubnet(device, h.peerCIDR(peer))
+	}
+	if err := h.savePeers(); err != nil {
+		h.logger.Error("failed to persist peers after delete", zap.Error(err))
+	}
+	return nil
+}
+
+// RevokeTokenPeers removes the peers registered with revoked tokens, whose tunnels would otherwise
+// outlive the token
+func (h *WireGuardHandler) RevokeTokenPeers(isRevoked func(tokenID string) bool) {
+	h.mu.Lock()
+	var revoked []string
+	for key, peer := range h.peers {
+		if peer.TokenID != "" && isRevoked(peer.TokenID) {
+			revoked = append(revoked, key)
+		}
+	}
+	h.mu.Unlock()
+	sort.Strings(revoked)
+
+	for _, key := range revoked {
+		if err := h.revokePeer(h.device, key); err != nil {
+			h.logger.Warn("failed to remove peer of revoked token", zap.String("publicKey", key), zap.Error(err))
+			continue
+		}
+		h.logger.Info("removed peer of revoked token", zap.String("publicKey", key))
+	}
+}
+
+// RunPeerGC removes peers without a handshake for longer than the idle timeout, until ctx is done
+func (h *WireGuardHandler) RunPeerGC(ctx context.Context) error {
+	if h.idleTimeout <= 0 {
+		return nil
+	}
+
+	h.logger.Info("starting stale peer GC", zap.Duration("idleTimeout", h.idleTimeout))
+	ticker := time.NewTicker(peerGCInterval)
+	defer ticker.Stop()
+
+	for {
+		h.collectStalePeers(time.Now().UTC())
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectStalePeers records the latest handshakes of the peers and removes the stale ones
+func (h *WireGuardHandler) collectStalePeers(now time.Time) {
+	stats, err := h.peerStats(h.device)
+	if err != nil {
+		// Without handshakes every peer would look idle
+		h.logger.Warn("skipping stale peer GC, failed to get peer statistics", zap.Error(err))
+		return
+	}
+
+	h.mu.Lock()
+	for key, peer := range h.peers {
+		if s, ok := stats[key]; ok && s.LatestHandshake.After(peer.LastSeen) {
+			peer.LastSeen = s.LatestHandshake
+		}
+	}
+	stale := stalePeers(h.peers, now, h.idleTimeout)
+	h.mu.Unlock()
+
+	for _, key := range stale {
+		if err := h.revokePeer(h.device, key); err != nil {
+			h.logger.Warn("failed to remove stale peer", zap.String("publicKey", key), zap.Error(err))
+			continue
+		}
+		h.logger.Info("removed stale peer", zap.String("publicKey", key), zap.Duration("idleTimeout", h.idleTimeout))
+	}
+
+	// Persist the handshakes, so that restarts do not reset idle times
+	if len(stale) == 0 {
+		if err := h.savePeers(); err != nil {
+			h.logger.Error("failed to persist peers", zap.Error(err))
+		}
+	}
+}
+
+// stalePeers returns the public keys of the peers without a handshake (or since their creation)
+// for longer than idleTimeout
+func stalePeers(peers map[string]*PeerInfo, now time.Time, idleTimeout time.Duration) []string {
+	var stale []string
+	for key, peer := range peers {
+		lastActive := peer.CreatedAt
+		if peer.LastSeen.After(lastActive) {
+			lastActive = peer.LastSeen
+		}
+		if !lastActive.IsZero() && now.Sub(lastActive) > idleTimeout {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// peerStats returns the statistics of the peers of a device
+func (h *WireGuardHandler) peerStats(device string) (map[string]wgPeerStats, error) {
+	// wg show <device> dump
+	output, err := exec.Command("wg", "show", device, "dump").Output()
+	if err != nil {
+		return nil, fmt.Errorf("wg show failed: %w", err)
+	}
+	return parseWGDump(string(output)), nil
+}
+
+// parseWGDump parses the output of `wg show <device> dump`: a line for the interface, then one
+// per peer with public key, preshared key, endpoint, allowed IPs, latest handshake, rx bytes,
+// tx bytes and keepalive, tab separated
+func parseWGDump(output string) map[string]wgPeerStats {
+	stats := make(map[string]wgPeerStats)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 8 {
+			continue
+		}
+
+		var s wgPeerStats
+		if fields[2] != "(none)" {
+			s.Endpoint = fields[2]
+		}
+		if handshake, err := strconv.ParseInt(fields[4], 10, 64); err == nil && handshake > 0 {
+			s.LatestHandshake = time.Unix(handshake, 0).UTC()
+		}
+		s.RxBytes, _ = strconv.ParseInt(fields[5], 10, 64)
+		s.TxBytes, _ = strconv.ParseInt(fields[6], 10, 64)
+		stats[fields[0]] = s
+	}
+	return stats
+}
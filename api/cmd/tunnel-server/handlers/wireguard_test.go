@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/cmd/tunnel-server/middleware"
+	"go.uber.org/zap"
+)
+
+func TestSelectCIDR(t *testing.T) {
+	h := &WireGuardHandler{cidr: "10.17.0.0/24", cidrPool: "10.17.0.0/16", serverAddress: "10.17.0.1"}
+
+	tests := []struct {
+		name    string
+		exclude []string
+		want    string
+		gateway string
+		wantErr bool
+	}{
+		{name: "no other tunnels", want: "10.17.0.0/24", gateway: "10.17.0.1"},
+		{name: "unrelated tunnel", exclude: []string{"10.18.0.0/24"}, want: "10.17.0.0/24", gateway: "10.17.0.1"},
+		{name: "default overlaps", exclude: []string{"10.17.0.0/24"}, want: "10.17.1.0/24", gateway: "10.17.1.1"},
+		{name: "several overlap", exclude: []string{"10.17.0.0/24", "10.17.1.0/24", "10.17.2.0/25"}, want: "10.17.3.0/24", gateway: "10.17.3.1"},
+		{name: "pool exhausted", exclude: []string{"10.0.0.0/8"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			excluded, err := parseCIDRs(tt.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := h.selectCIDR(excluded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectCIDR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("selectCIDR() = %q, want %q", got, tt.want)
+			}
+			if tt.gateway != "" && h.gatewayOf(got) != tt.gateway {
+				t.Errorf("gatewayOf(%q) = %q, want %q", got, h.gatewayOf(got), tt.gateway)
+			}
+		})
+	}
+}
+
+func TestPeerCIDR(t *testing.T) {
+	h := &WireGuardHandler{cidr: "10.17.0.0/24"}
+
+	if got := h.peerCIDR(&PeerInfo{IP: "10.17.4.9"}); got != "10.17.4.0/24" {
+		t.Errorf("peerCIDR() of a legacy peer = %q, want 10.17.4.0/24", got)
+	}
+	if got := h.peerCIDR(&PeerInfo{IP: "10.17.4.9", CIDR: "10.17.4.0/24"}); got != "10.17.4.0/24" {
+		t.Errorf("peerCIDR() = %q, want 10.17.4.0/24", got)
+	}
+}
+
+func TestReleaseSubnet(t *testing.T) {
+	h := &WireGuardHandler{
+		logger:  zap.NewNop(),
+		cidr:    "10.17.0.0/24",
+		peers:   map[string]*PeerInfo{"a": {PublicKey: "a", IP: "10.17.1.2", CIDR: "10.17.1.0/24"}},
+		subnets: map[string]bool{"10.17.1.0/24": true, "10.17.2.0/24": true},
+	}
+
+	h.releaseSubnet("wg-test", "10.17.1.0/24")
+	if !h.subnets["10.17.1.0/24"] {
+		t.Error("subnet with a peer was released")
+	}
+
+	h.releaseSubnet("wg-test", "10.17.2.0/24")
+	if h.subnets["10.17.2.0/24"] {
+		t.Error("subnet without peers was kept")
+	}
+}
+
+func TestParseWGDump(t *testing.T) {
+	dump := "cHJpdmF0ZQ==\tc2VydmVy\t51820\toff\n" +
+		"YWxpY2U=\t(none)\t203.0.113.7:40312\t10.17.0.2/32\t1760880000\t1024\t2048\toff\n" +
+		"Ym9i\t(none)\t(none)\t10.17.0.3/32\t0\t0\t0\toff\n"
+
+	stats := parseWGDump(dump)
+	if len(stats) != 2 {
+		t.Fatalf("got %d peers, want 2", len(stats))
+	}
+
+	alice := stats["YWxpY2U="]
+	if alice.Endpoint != "203.0.113.7:40312" || alice.RxBytes != 1024 || alice.TxBytes != 2048 ||
+		!alice.LatestHandshake.Equal(time.Unix(1760880000, 0)) {
+		t.Errorf("unexpected stats for alice: %+v", alice)
+	}
+
+	bob := stats["Ym9i"]
+	if bob.Endpoint != "" || !bob.LatestHandshake.IsZero() {
+		t.Errorf("unexpected stats for bob: %+v", bob)
+	}
+}
+
+func TestStalePeers(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	peers := map[string]*PeerInfo{
+		"active":      {CreatedAt: now.Add(-90 * day), LastSeen: now.Add(-time.Hour)},
+		"idle":        {CreatedAt: now.Add(-90 * day), LastSeen: now.Add(-31 * day)},
+		"never-used":  {CreatedAt: now.Add(-31 * day)},
+		"new":         {CreatedAt: now.Add(-day)},
+		"no-creation": {},
+	}
+
+	got := stalePeers(peers, now, 30*day)
+	want := []string{"idle", "never-used"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("stalePeers() = %v, want %v", got, want)
+	}
+}
+
+func TestPeerFilterRules(t *testing.T) {
+	chain := peerFilterChain("peer-public-key")
+	if len(chain) > 28 {
+		t.Fatalf("chain %q is longer than the 28 characters of iptables", chain)
+	}
+
+	got := peerFilterRules(chain, "10.17.1.1", []string{"10.43.0.10", "10.43.0.11"})
+	want := []string{
+		chain + " -d 10.17.1.1/32 -p udp --dport 53 -j ACCEPT",
+		chain + " -d 10.17.1.1/32 -p tcp --dport 53 -j ACCEPT",
+		chain + " -d 10.17.1.1/32 -p tcp --dport 443 -j ACCEPT",
+		chain + " -d 10.43.0.10/32 -j ACCEPT",
+		chain + " -d 10.43.0.11/32 -j ACCEPT",
+		chain + " -j DROP",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("peerFilterRules() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if rule := strings.Join(got[i], " "); rule != want[i] {
+			t.Errorf("rule %d = %q, want %q", i, rule, want[i])
+		}
+	}
+
+	// Without services, only DNS and the API of the tunnel server are reachable
+	got = peerFilterRules(chain, "10.17.1.1", nil)
+	if len(got) != 4 || strings.Join(got[3], " ") != chain+" -j DROP" {
+		t.Errorf("peerFilterRules() without services = %v, want DNS, API and DROP", got)
+	}
+}
+
+func TestServiceIPs(t *testing.T) {
+	h := &WireGuardHandler{}
+	if got := h.serviceIPs("staging"); len(got) != 0 {
+		t.Errorf("serviceIPs() without hosts cache = %v, want none", got)
+	}
+
+	h.hosts = &HostsCache{hosts: []HostEntry{
+		{Hostname: "db.staging", IP: "10.43.0.11", IPv6: "fd00::11", Type: "service", Environment: "staging"},
+		{Hostname: "api.staging", IP: "10.43.0.10", Type: "service", Environment: "staging"},
+		{Hostname: "api.prod", IP: "10.43.0.20", Type: "service", Environment: "prod"},
+		{Hostname: "app.example.com", IP: "10.43.0.1", Type: "ingress"},
+	}}
+	got := h.serviceIPs("staging")
+	if want := "10.43.0.10,10.43.0.11"; strings.Join(got, ",") != want {
+		t.Errorf("serviceIPs() = %v, want %s", got, want)
+	}
+}
+
+func TestCanManagePeer(t *testing.T) {
+	alice := &middleware.UserClaims{Username: "alice"}
+	admin := &middleware.UserClaims{Username: "bob", Roles: []string{"admin"}}
+
+	tests := []struct {
+		name   string
+		peer   *PeerInfo
+		claims *middleware.UserClaims
+		want   bool
+	}{
+		{name: "own peer", peer: &PeerInfo{Owner: "alice"}, claims: alice, want: true},
+		{name: "peer of another user", peer: &PeerInfo{Owner: "carol"}, claims: alice, want: false},
+		{name: "peer without owner", peer: &PeerInfo{}, claims: alice, want: false},
+		{name: "admin, peer of another user", peer: &PeerInfo{Owner: "carol"}, claims: admin, want: true},
+		{name: "admin, peer without owner", peer: &PeerInfo{}, claims: admin, want: true},
+		{name: "no claims", peer: &PeerInfo{}, claims: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canManagePeer(tt.peer, tt.claims); got != tt.want {
+				t.Errorf("canManagePeer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDevicePeers(t *testing.T) {
+	h := &WireGuardHandler{peers: map[string]*PeerInfo{
+		"old":    {PublicKey: "old", Owner: "alice", DeviceName: "laptop", DeviceID: "id-1"},
+		"other":  {PublicKey: "other", Owner: "alice", DeviceName: "laptop", DeviceID: "id-2"},
+		"ci":     {PublicKey: "ci", Owner: "alice", DeviceName: "laptop"},
+		"carols": {PublicKey: "carols", Owner: "carol", DeviceName: "laptop", DeviceID: "id-1"},
+	}}
+
+	if got := h.devicePeers("alice", "id-1"); len(got) != 1 || got[0] != "old" {
+		t.Errorf("devicePeers(alice, id-1) = %v, want [old]", got)
+	}
+	if got := h.devicePeers("alice", ""); len(got) != 0 {
+		t.Errorf("devicePeers() without device ID = %v, want none", got)
+	}
+}
+
+func TestAllocateIPDeviceLimit(t *testing.T) {
+	h := &WireGuardHandler{
+		logger:          zap.NewNop(),
+		device:          "wg-test",
+		cidr:            "10.17.0.0/24",
+		serverAddress:   "10.17.0.1",
+		maxPeersPerUser: 1,
+		peers:           map[string]*PeerInfo{},
+	}
+
+	if _, err := h.allocateIP(PeerInfo{PublicKey: "a", Owner: "alice", CIDR: h.cidr}); err != nil {
+		t.Fatalf("allocateIP() of the first peer: %v", err)
+	}
+	if _, err := h.allocateIP(PeerInfo{PublicKey: "b", Owner: "alice", CIDR: h.cidr}); !errors.Is(err, errDeviceLimit) {
+		t.Errorf("allocateIP() beyond the limit = %v, want errDeviceLimit", err)
+	}
+	if _, err := h.allocateIP(PeerInfo{PublicKey: "c", Owner: "carol", CIDR: h.cidr}); err != nil {
+		t.Errorf("allocateIP() of another owner: %v", err)
+	}
+}
@@ -34,6 +34,7 @@ import (
 
 type Config struct {
 	ListenAddr      string
+	QUICListenAddr  string // UDP listen address of CONNECT-UDP over HTTP/3 (e.g., ":443"), empty disables
 	TLSSecretName   string // Kubernetes secret name containing tls.crt and tls.key
 	WireguardTarget string
 	WatchConfig     bool
@@ -42,9 +43,14 @@ type Config struct {
 	// WireGuard peer management config
 	WgDevice        string
 	WgCIDR          string
+	WgCIDRPool      string
 	WgServerAddress string
 	WgEndpoint      string
 
+	// WireGuard peer lifecycle config
+	WgPeerIdleTimeout time.Duration // Peers without a handshake for longer are removed, 0 disables
+	WgMaxPeersPerUser int           // Maximum number of peers (devices) per user, 0 for no limit
+
 	// CA certificate config
 	CACertSecretName string // Kubernetes secret name containing ca.crt
 
@@ -56,31 +62,53 @@ type Config struct {
 	RouterServiceRef string
 
 	// JWT authentication config
-	JWTSecret string
+	JWTSecret         string
+	RevokedTokensFile string // File of revoked token IDs (jti), one per line, e.g., a mounted ConfigMap
 
 	// DNS server config
-	DNSListenAddr string // DNS server listen address (e.g., ":53")
-	UpstreamDNS   string // Upstream DNS server (e.g., "10.43.0.10:53")
+	DNSListenAddr    string // DNS server listen address (e.g., ":53")
+	DNSTCPListenAddr string // DNS-over-TCP listen address, defaults to DNSListenAddr
+	UpstreamDNS      string // Upstream DNS server (e.g., "10.43.0.10:53")
+	DNSForwardNames  string // Comma-separated names of the zone always forwarded upstream
+
+	// Tunnel policy config
+	UDPAllow              string // Comma-separated destinations of tunnel sessions, defaults to WireguardTarget
+	UDPMaxSessionsPerUser int    // Maximum open tunnel sessions per user, 0 for no limit
+	UDPBandwidthPerUser   int64  // Bytes per second per user through tunnel sessions, 0 for no limit
+
+	// Metrics config
+	MetricsListenAddr string // Prometheus metrics listen address (plain HTTP, e.g., ":9090"), empty disables
 }
 
 func main() {
 	cfg := Config{}
 	flag.StringVar(&cfg.ListenAddr, "listen", ":443", "Listen address for TLS WebSocket server (e.g., :443)")
+	flag.StringVar(&cfg.QUICListenAddr, "quic-listen", ":443", "UDP listen address for tunnel sessions over QUIC (CONNECT-UDP over HTTP/3), empty disables")
 	flag.StringVar(&cfg.TLSSecretName, "tls-secret", "tunnel-server-tls", "Kubernetes secret name containing tls.crt and tls.key")
 	flag.StringVar(&cfg.WireguardTarget, "wireguard-target", "127.0.0.1:51820", "WireGuard UDP target")
 	flag.BoolVar(&cfg.WatchConfig, "watch-config", false, "Watch WireGuard config and reload peers dynamically")
 	flag.StringVar(&cfg.ConfigPath, "config-path", "/etc/wireguard/wg0.conf", "Path to WireGuard config file to watch")
 	flag.StringVar(&cfg.WgDevice, "wg-device", "wg0", "WireGuard device name")
 	flag.StringVar(&cfg.WgCIDR, "wg-cidr", "10.17.0.0/24", "WireGuard CIDR for peer IP allocation")
+	flag.StringVar(&cfg.WgCIDRPool, "wg-cidr-pool", "10.17.0.0/16", "Pool of subnets (of the size of --wg-cidr) for peers whose other tunnels overlap --wg-cidr")
 	flag.StringVar(&cfg.WgServerAddress, "wg-server-address", "10.17.0.1", "WireGuard server address")
+	flag.DurationVar(&cfg.WgPeerIdleTimeout, "wg-peer-idle-timeout", 30*24*time.Hour, "Remove WireGuard peers without a handshake for this long (0 keeps them forever)")
+	flag.IntVar(&cfg.WgMaxPeersPerUser, "wg-max-peers-per-user", 10, "Maximum number of WireGuard peers (devices) per user (0 for no limit)")
 	flag.StringVar(&cfg.WgEndpoint, "wg-endpoint", os.Getenv("PUBLIC_HOST"), "WireGuard server public endpoint (e.g., tunnel.example.com:443), can also be set via PUBLIC_HOST env var")
 	flag.StringVar(&cfg.CACertSecretName, "ca-cert-secret", "tunnel-server-ca", "Kubernetes secret name containing ca.crt")
 	flag.StringVar(&cfg.KltunTLSSecretName, "kltun-tls-secret", "kltun-tls", "Kubernetes secret name containing TLS cert for kltun HTTPS server")
 	flag.StringVar(&cfg.Namespace, "namespace", os.Getenv("POD_NAMESPACE"), "Namespace to query for ingresses (defaults to POD_NAMESPACE env var)")
 	flag.StringVar(&cfg.RouterServiceRef, "router-service", "wm-ingress-controller", "Name of the router service for hosts resolution")
 	flag.StringVar(&cfg.JWTSecret, "jwt-secret", os.Getenv("JWT_SECRET"), "JWT secret for token validation (can also be set via JWT_SECRET env var)")
+	flag.StringVar(&cfg.RevokedTokensFile, "revoked-tokens-file", os.Getenv("REVOKED_TOKENS_FILE"), "File of revoked token IDs (jti), one per line, reloaded on change (can also be set via REVOKED_TOKENS_FILE env var)")
 	flag.StringVar(&cfg.DNSListenAddr, "dns-listen", ":53", "DNS server listen address")
+	flag.StringVar(&cfg.DNSTCPListenAddr, "dns-tcp-listen", "", "DNS-over-TCP listen address (defaults to --dns-listen)")
 	flag.StringVar(&cfg.UpstreamDNS, "upstream-dns", "10.43.0.10:53", "Upstream DNS server for non-cached queries")
+	flag.StringVar(&cfg.DNSForwardNames, "dns-forward-names", "vpn-connect", "Comma-separated names of the Kloudlite zone forwarded upstream instead of answered")
+	flag.StringVar(&cfg.UDPAllow, "udp-allow", "", "Comma-separated destinations of tunnel sessions: host:port, CIDR or CIDR:port (defaults to --wireguard-target)")
+	flag.IntVar(&cfg.UDPMaxSessionsPerUser, "udp-max-sessions-per-user", 16, "Maximum open tunnel sessions per user (0 for no limit)")
+	flag.Int64Var(&cfg.UDPBandwidthPerUser, "udp-bandwidth-per-user", 0, "Maximum bytes per second per user through tunnel sessions, packets over it are dropped (0 for no limit)")
+	flag.StringVar(&cfg.MetricsListenAddr, "metrics-listen", ":9090", "Prometheus /metrics listen address, plain HTTP for in-cluster scraping (empty disables)")
 	version := flag.Bool("version", false, "Show version information")
 	flag.Parse()
 
@@ -153,22 +181,45 @@ func main() {
 		logger.Fatal("failed to create WebSocket listener", zap.Error(err))
 	}
 
-	// Create JWT middleware for authentication
-	jwtMiddleware := middleware.NewJWTMiddleware(cfg.JWTSecret, logger)
+	// Create JWT middleware for authentication, refusing revoked tokens
+	revokedTokens := middleware.NewRevocationList(cfg.RevokedTokensFile, logger)
+	if err := revokedTokens.Reload(); err != nil {
+		logger.Fatal("failed to load revoked tokens", zap.Error(err))
+	}
+	jwtMiddleware := middleware.NewJWTMiddleware(cfg.JWTSecret, revokedTokens, logger)
 
 	// Register handlers
 	mux.Handle("/ws", jwtMiddleware(http.HandlerFunc(listener.GetWebSocketUpgradeHandler()))) // WebSocket endpoint (protected)
 	mux.Handle("/health", handlers.NewHealthHandler(serverState, logger))                     // Health check endpoint (unauthenticated for K8s probes)
 
+	// Create QUIC listener serving the same handlers over HTTP/3, for tunnel sessions as QUIC datagrams
+	var quicListener *transport.QUICListener
+	if cfg.QUICListenAddr != "" {
+		quicListener, err = transport.NewQUICListener(cfg.QUICListenAddr, tlsConfig, mux, transportConfig, logger)
+		if err != nil {
+			logger.Fatal("failed to create QUIC listener", zap.Error(err))
+		}
+		// CONNECT-UDP endpoint (protected), only reachable over HTTP/3
+		mux.Handle(transport.ConnectUDPPath, jwtMiddleware(http.HandlerFunc(quicListener.GetConnectUDPHandler())))
+	}
+
 	// WireGuard peer management handlers
 	wgHandler := handlers.NewWireGuardHandler(logger, handlers.WireGuardHandlerConfig{
 		Device:        cfg.WgDevice,
 		CIDR:          cfg.WgCIDR,
+		CIDRPool:      cfg.WgCIDRPool,
 		ServerAddress: cfg.WgServerAddress,
 		Endpoint:      cfg.WgEndpoint,
+
+		IdleTimeout:     cfg.WgPeerIdleTimeout,
+		MaxPeersPerUser: cfg.WgMaxPeersPerUser,
 	})
+	// Peers of revoked tokens are removed, at startup and whenever the list changes
+	wgHandler.RevokeTokenPeers(revokedTokens.IsRevoked)
+	revokedTokens.OnReload(func() { wgHandler.RevokeTokenPeers(revokedTokens.IsRevoked) })
 	mux.Handle("/wg/public-key", jwtMiddleware(http.HandlerFunc(wgHandler.GetPublicKeyHandler()))) // GET
-	mux.Handle("/wg/peer", jwtMiddleware(http.HandlerFunc(wgHandler.PeerHandler())))               // POST (create), DELETE (delete)
+	mux.Handle("/wg/peer", jwtMiddleware(http.HandlerFunc(wgHandler.PeerHandler())))               // POST (create), DELETE (delete, own peers or admin)
+	mux.Handle("/wg/peers", jwtMiddleware(http.HandlerFunc(wgHandler.PeersHandler())))             // GET (own peers, all for admins)
 
 	// CA certificate handler (loads from K8s secret)
 	// Secret ref can be "namespace/secretName" or just "secretName" (uses cfg.Namespace)
@@ -188,7 +239,7 @@ func main() {
 		Namespace:  kltunTLSSecretNamespace,
 		SecretName: kltunTLSSecretName,
 	})
-	mux.Handle("/tls-cert", jwtMiddleware(tlsCertHandler))
+	mux.Handle("/tls-cert", jwtMiddleware(middleware.DenyServiceTokens(tlsCertHandler))) // Private key, not for service tokens
 
 	// Create hosts cache with watch-based updates
 	hostsCache, err := handlers.NewHostsCache(logger, k8sClient, handlers.HostsCacheConfig{
@@ -201,28 +252,75 @@ func main() {
 		logger.Fatal("failed to create hosts cache", zap.Error(err))
 	}
 
+	// Service-token peers are only forwarded to the services of their environment
+	wgHandler.SetHostsCache(hostsCache)
+
 	// Hosts handler (reads from cache)
 	hostsHandler := handlers.NewHostsHandler(logger, hostsCache)
-	mux.Handle("/hosts", jwtMiddleware(hostsHandler))
+	mux.Handle("/hosts", jwtMiddleware(hostsHandler)) // Services of their environment only for service tokens
+
+	// DNS server (answers from the hosts cache), started below
+	dnsServer := handlers.NewDNSServer(logger, hostsCache, handlers.DNSServerConfig{
+		ListenAddr:    cfg.DNSListenAddr,
+		TCPListenAddr: cfg.DNSTCPListenAddr,
+		UpstreamDNS:   cfg.UpstreamDNS,
+		ForwardNames:  strings.Split(cfg.DNSForwardNames, ","),
+	})
+
+	// Traffic accounting of tunnel sessions, WireGuard peers and DNS queries
+	metrics := handlers.NewMetrics(logger, serverState, wgHandler, dnsServer)
 
 	// VPN status handler (for vpn-check connectivity verification)
 	// No JWT auth needed - if you can reach this endpoint, you're connected to the VPN
-	vpnStatusHandler := handlers.NewVPNStatusHandler(logger)
+	vpnStatusHandler := handlers.NewVPNStatusHandler(logger, metrics)
 	mux.Handle("/", handlers.WithCORS(vpnStatusHandler))
 
 	logger.Info("registered HTTP endpoints",
 		zap.String("websocket", "/ws"),
+		zap.String("connect-udp", "CONNECT "+transport.ConnectUDPPath+" over HTTP/3 on "+cfg.QUICListenAddr),
 		zap.String("health", "/health"),
 		zap.String("wg-public-key", "GET /wg/public-key"),
 		zap.String("wg-peer", "POST|DELETE /wg/peer"),
+		zap.String("wg-peers", "GET /wg/peers"),
 		zap.String("ca-cert", "GET /ca-cert"),
 		zap.String("tls-cert", "GET /tls-cert"),
 		zap.String("hosts", "GET /hosts"),
 		zap.String("vpn-status", "GET /"),
-		zap.String("dns-server", cfg.DNSListenAddr))
+		zap.String("dns-server", cfg.DNSListenAddr),
+		zap.String("metrics", cfg.MetricsListenAddr))
+
+	// Create UDP tunnel server, accounted in the server state. Sessions only reach the allowed
+	// destinations (WireGuard by default) and are limited per user.
+	udpAllow := cfg.UDPAllow
+	if udpAllow == "" {
+		udpAllow = cfg.WireguardTarget
+	}
+	policy, err := tunnel.NewPolicy(tunnel.PolicyConfig{
+		Allow:              strings.Split(udpAllow, ","),
+		MaxSessionsPerUser: cfg.UDPMaxSessionsPerUser,
+		BandwidthPerUser:   cfg.UDPBandwidthPerUser,
+	})
+	if err != nil {
+		logger.Fatal("invalid tunnel policy", zap.Error(err))
+	}
+	identityFunc := func(r *http.Request) string {
+		if claims, ok := middleware.GetUserFromContext(r.Context()); ok && claims != nil {
+			return claims.Owner()
+		}
+		return ""
+	}
+	listener.SetIdentityFunc(identityFunc)
+	if quicListener != nil {
+		quicListener.SetIdentityFunc(identityFunc)
+	}
 
-	// Create UDP tunnel server
 	server := tunnel.NewUDPServer(listener, logger)
+	server.SetObserver(serverState)
+	server.SetPolicy(policy)
+	logger.Info("tunnel policy",
+		zap.String("allow", udpAllow),
+		zap.Int("max-sessions-per-user", cfg.UDPMaxSessionsPerUser),
+		zap.Int64("bandwidth-per-user", cfg.UDPBandwidthPerUser))
 
 	// Setup context and signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -239,11 +337,21 @@ func main() {
 		}
 	}()
 
-	// Create and start DNS server
-	dnsServer := handlers.NewDNSServer(logger, hostsCache, handlers.DNSServerConfig{
-		ListenAddr:  cfg.DNSListenAddr,
-		UpstreamDNS: cfg.UpstreamDNS,
-	})
+	// Remove stale WireGuard peers (lost or abandoned devices)
+	go func() {
+		if err := wgHandler.RunPeerGC(ctx); err != nil && err != context.Canceled {
+			logger.Error("peer GC error", zap.Error(err))
+		}
+	}()
+
+	// Reload the revoked tokens when their file changes
+	go func() {
+		if err := revokedTokens.Run(ctx); err != nil && err != context.Canceled {
+			logger.Error("revoked tokens reload error", zap.Error(err))
+		}
+	}()
+
+	// Start DNS server
 	go func() {
 		logger.Info("starting DNS server",
 			zap.String("listen", cfg.DNSListenAddr),
@@ -253,6 +361,23 @@ func main() {
 		}
 	}()
 
+	// Start metrics server, on its own listener so that per-peer metrics are not exposed publicly
+	if cfg.MetricsListenAddr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler())
+		metricsServer := &http.Server{Addr: cfg.MetricsListenAddr, Handler: metricsMux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			logger.Info("starting metrics server", zap.String("listen", cfg.MetricsListenAddr))
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("metrics server error", zap.Error(err))
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			metricsServer.Close()
+		}()
+	}
+
 	// Start config watcher if enabled
 	if cfg.WatchConfig {
 		go func() {
@@ -272,6 +397,16 @@ func main() {
 		serverErrChan <- server.Start(ctx)
 	}()
 
+	// Serve tunnel sessions over QUIC with the same policy and accounting
+	if quicListener != nil {
+		go func() {
+			logger.Info("starting UDP-over-QUIC server", zap.String("listen", cfg.QUICListenAddr))
+			if err := server.ServeDatagrams(ctx, quicListener); err != nil && err != context.Canceled {
+				logger.Error("QUIC server error", zap.Error(err))
+			}
+		}()
+	}
+
 	// Wait for shutdown signal or error
 	select {
 	case <-sigChan:
@@ -288,6 +423,9 @@ func main() {
 	if err := server.Close(); err != nil {
 		logger.Error("error closing server", zap.Error(err))
 	}
+	if quicListener != nil {
+		quicListener.Close()
+	}
 
 	logger.Info("shutdown complete")
 }
@@ -12,12 +12,53 @@ import (
 
 // UserClaims represents the JWT claims structure
 type UserClaims struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Name     string `json:"name"`
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Name     string   `json:"name"`
+	Roles    []string `json:"roles,omitempty"`
+
+	// Type is the kind of token: "vpn-permanent" for users, "vpn-service" for CI jobs
+	Type string `json:"type,omitempty"`
+	// Environment is the environment a service token is scoped to
+	Environment string `json:"environment,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ServiceTokenType is the type of the service tokens issued per environment, for CI jobs
+const ServiceTokenType = "vpn-service"
+
+// IsService reports whether the token is a service token, scoped to the services of its environment
+func (c *UserClaims) IsService() bool {
+	return c.Type == ServiceTokenType
+}
+
+// Owner identifies the user owning resources such as WireGuard peers: the username, or the
+// email for tokens without one. Service tokens own their peers apart from the user, per
+// environment, so that CI jobs neither see nor revoke the devices of the user.
+func (c *UserClaims) Owner() string {
+	owner := c.Username
+	if owner == "" {
+		owner = c.Email
+	}
+	if c.IsService() {
+		return owner + "/" + c.Environment
+	}
+	return owner
+}
+
+// IsAdmin reports whether the user has the admin or super-admin role, never for service tokens
+func (c *UserClaims) IsAdmin() bool {
+	if c.IsService() {
+		return false
+	}
+	for _, role := range c.Roles {
+		if role == "admin" || role == "super-admin" {
+			return true
+		}
+	}
+	return false
+}
+
 // ContextKey is the type for context keys
 type ContextKey string
 
@@ -26,8 +67,8 @@ const (
 	UserContextKey ContextKey = "user"
 )
 
-// NewJWTMiddleware creates a new JWT authentication middleware
-func NewJWTMiddleware(jwtSecret string, logger *zap.Logger) func(http.Handler) http.Handler {
+// NewJWTMiddleware creates a new JWT authentication middleware, refusing the tokens of the revocation list
+func NewJWTMiddleware(jwtSecret string, revoked *RevocationList, logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			var tokenString string
@@ -87,11 +128,28 @@ func NewJWTMiddleware(jwtSecret string, logger *zap.Logger) func(http.Handler) h
 				return
 			}
 
+			if revoked.IsRevoked(claims.ID) {
+				logger.Debug("revoked JWT token",
+					zap.String("path", r.URL.Path),
+					zap.String("jti", claims.ID))
+				http.Error(w, `{"error": "token revoked"}`, http.StatusUnauthorized)
+				return
+			}
+
+			// Service tokens are only valid within an environment
+			if claims.IsService() && claims.Environment == "" {
+				logger.Debug("service token without environment",
+					zap.String("path", r.URL.Path))
+				http.Error(w, `{"error": "invalid token"}`, http.StatusUnauthorized)
+				return
+			}
+
 			// Log authenticated request
 			logger.Debug("authenticated request",
 				zap.String("path", r.URL.Path),
 				zap.String("username", claims.Username),
-				zap.String("email", claims.Email))
+				zap.String("email", claims.Email),
+				zap.String("environment", claims.Environment))
 
 			// Add claims to request context
 			ctx := context.WithValue(r.Context(), UserContextKey, claims)
@@ -100,6 +158,17 @@ func NewJWTMiddleware(jwtSecret string, logger *zap.Logger) func(http.Handler) h
 	}
 }
 
+// DenyServiceTokens refuses service tokens on endpoints reserved to users (e.g., the TLS private key)
+func DenyServiceTokens(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := GetUserFromContext(r.Context()); ok && claims.IsService() {
+			http.Error(w, `{"error": "not allowed for service tokens"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // GetUserFromContext extracts user claims from context
 func GetUserFromContext(ctx context.Context) (*UserClaims, bool) {
 	claims, ok := ctx.Value(UserContextKey).(*UserClaims)
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServiceTokenClaims(t *testing.T) {
+	user := &UserClaims{Username: "alice", Roles: []string{"admin"}, Type: "vpn-permanent"}
+	service := &UserClaims{Username: "alice", Roles: []string{"admin"}, Type: ServiceTokenType, Environment: "staging"}
+
+	if got := user.Owner(); got != "alice" {
+		t.Errorf("user owner = %q, want alice", got)
+	}
+	if got := service.Owner(); got != "alice/staging" {
+		t.Errorf("service token owner = %q, want alice/staging", got)
+	}
+	if !user.IsAdmin() {
+		t.Error("user with the admin role is not an admin")
+	}
+	if service.IsAdmin() {
+		t.Error("service token is an admin")
+	}
+}
+
+func TestDenyServiceTokens(t *testing.T) {
+	handler := DenyServiceTokens(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name   string
+		claims *UserClaims
+		want   int
+	}{
+		{name: "user", claims: &UserClaims{Username: "alice"}, want: http.StatusOK},
+		{name: "service token", claims: &UserClaims{Username: "alice", Type: ServiceTokenType, Environment: "staging"}, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tls-cert", nil)
+			req = req.WithContext(context.WithValue(req.Context(), UserContextKey, tt.claims))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// revocationReloadInterval is how often the revocation file is checked for changes
+const revocationReloadInterval = 30 * time.Second
+
+// RevocationList holds the IDs (jti) of revoked tokens, read from a file with one ID per line (blank
+// lines and # comments ignored). The file is reloaded when it changes, e.g., as a mounted ConfigMap.
+type RevocationList struct {
+	path   string
+	logger *zap.Logger
+
+	mu       sync.RWMutex
+	ids      map[string]bool
+	modTime  time.Time
+	onReload []func()
+}
+
+// NewRevocationList creates a RevocationList reading path, an empty path revokes nothing
+func NewRevocationList(path string, logger *zap.Logger) *RevocationList {
+	return &RevocationList{
+		path:   path,
+		logger: logger,
+		ids:    make(map[string]bool),
+	}
+}
+
+// IsRevoked reports whether the token with the given ID is revoked, tokens without ID never are
+func (l *RevocationList) IsRevoked(id string) bool {
+	if l == nil || id == "" {
+		return false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.ids[id]
+}
+
+// OnReload registers a function called after the list changed, e.g., to remove the peers of revoked tokens
+func (l *RevocationList) OnReload(fn func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onReload = append(l.onReload, fn)
+}
+
+// Reload reads the file again if it changed since the last load. A missing file revokes nothing.
+func (l *RevocationList) Reload() error {
+	if l.path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(l.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat revocation file: %w", err)
+	}
+	var modTime time.Time
+	if info != nil {
+		modTime = info.ModTime()
+	}
+
+	l.mu.RLock()
+	unchanged := modTime.Equal(l.modTime)
+	l.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	ids := make(map[string]bool)
+	if info != nil {
+		data, err := os.ReadFile(l.path)
+		if err != nil {
+			return fmt.Errorf("failed to read revocation file: %w", err)
+		}
+		ids = parseRevocations(string(data))
+	}
+
+	l.mu.Lock()
+	l.ids = ids
+	l.modTime = modTime
+	onReload := l.onReload
+	l.mu.Unlock()
+
+	l.logger.Info("loaded revoked tokens", zap.String("path", l.path), zap.Int("count", len(ids)))
+	for _, fn := range onReload {
+		fn()
+	}
+	return nil
+}
+
+// Run reloads the file when it changes, until ctx is done
+func (l *RevocationList) Run(ctx context.Context) error {
+	if l.path == "" {
+		return nil
+	}
+
+	ticker := time.NewTicker(revocationReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.Reload(); err != nil {
+			// The previous list stays in force
+			l.logger.Warn("failed to reload revoked tokens", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseRevocations parses the token IDs of a revocation file
+func parseRevocations(data string) map[string]bool {
+	ids := make(map[string]bool)
+	for _, line := range strings.Split(data, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if id := strings.TrimSpace(line); id != "" {
+			ids[id] = true
+		}
+	}
+	return ids
+}
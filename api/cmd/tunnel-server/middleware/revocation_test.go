@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+func TestRevocationList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revoked")
+	list := NewRevocationList(path, zap.NewNop())
+
+	reloads := 0
+	list.OnReload(func() { reloads++ })
+
+	// A missing file revokes nothing
+	if err := list.Reload(); err != nil {
+		t.Fatalf("Reload() without file: %v", err)
+	}
+	if list.IsRevoked("token-1") {
+		t.Error("token revoked without revocation file")
+	}
+
+	if err := os.WriteFile(path, []byte("# revoked CI tokens\ntoken-1\n\n  token-2 # staging\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.Reload(); err != nil {
+		t.Fatalf("Reload(): %v", err)
+	}
+	for id, want := range map[string]bool{"token-1": true, "token-2": true, "token-3": false, "": false} {
+		if got := list.IsRevoked(id); got != want {
+			t.Errorf("IsRevoked(%q) = %v, want %v", id, got, want)
+		}
+	}
+
+	// Unchanged files are not read again
+	if err := list.Reload(); err != nil {
+		t.Fatalf("Reload(): %v", err)
+	}
+	if reloads != 1 {
+		t.Errorf("reloads = %d, want 1", reloads)
+	}
+
+	var nilList *RevocationList
+	if nilList.IsRevoked("token-1") {
+		t.Error("nil list revokes tokens")
+	}
+}
+
+func TestJWTMiddlewareRevokedTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revoked")
+	if err := os.WriteFile(path, []byte("revoked-id\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	list := NewRevocationList(path, zap.NewNop())
+	if err := list.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewJWTMiddleware("secret", list, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	sign := func(id string) string {
+		claims := &UserClaims{
+			Username:    "alice",
+			Type:        ServiceTokenType,
+			Environment: "staging",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        id,
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		want int
+	}{
+		{name: "valid token", id: "valid-id", want: http.StatusOK},
+		{name: "revoked token", id: "revoked-id", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/hosts", nil)
+			req.Header.Set("Authorization", "Bearer "+sign(tt.id))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,555 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	environmentv1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	wmv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/kloudlite/kloudlite/api/pkg/backup"
+	zap2 "go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// storageRootPath holds the workspace and environment volumes that are backed up
+	storageRootPath = "/var/lib/kloudlite/storage"
+
+	// backupSnapshotPath holds the read-only snapshot of the latest backup of every volume,
+	// the parent of the next incremental backup
+	backupSnapshotPath = "/var/lib/kloudlite/storage/.backups"
+)
+
+// backupVolumeDirs are the directories under storageRootPath whose subvolumes are backed up
+var backupVolumeDirs = []string{"workspaces", "environments"}
+
+// newBackupStoreFromEnv creates the backup store from the env vars set by the WorkMachine controller
+// Returns nil when no bucket is configured
+func newBackupStoreFromEnv() (*backup.Store, error) {
+	if os.Getenv("BACKUP_S3_BUCKET") == "" {
+		return nil, nil
+	}
+	return backup.NewStore(backup.StoreConfig{
+		Endpoint:        os.Getenv("BACKUP_S3_ENDPOINT"),
+		Region:          os.Getenv("BACKUP_S3_REGION"),
+		Bucket:          os.Getenv("BACKUP_S3_BUCKET"),
+		Prefix:          os.Getenv("BACKUP_S3_PREFIX"),
+		AccessKeyID:     os.Getenv("BACKUP_S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("BACKUP_S3_SECRET_ACCESS_KEY"),
+	})
+}
+
+// hostCommand returns a command running on the host, for btrfs send/receive whose streams are piped
+func hostCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "nsenter", append([]string{"-t", "1", "-m", "--"}, args...)...)
+	cmd.Env = append(os.Environ(), "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin")
+	return cmd
+}
+
+// BackupReconciler takes the backups of this WorkMachine
+type BackupReconciler struct {
+	client.Client
+	Logger          *zap2.Logger
+	HostCmdExec     CommandExecutor // For btrfs commands that must run on host
+	Store           *backup.Store
+	WorkMachineName string
+}
+
+func (r *BackupReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := r.Logger.With(zap2.String("backup", req.Name))
+
+	b := &wmv1.Backup{}
+	if err := r.Get(ctx, req.NamespacedName, b); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		logger.Error("Failed to get Backup", zap2.Error(err))
+		return reconcile.Result{}, err
+	}
+
+	// Only process backups of this machine
+	if b.Spec.WorkMachine != r.WorkMachineName || b.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	switch b.Status.State {
+	case wmv1.BackupStateCompleted, wmv1.BackupStateFailed:
+		return reconcile.Result{}, nil
+	case "", wmv1.BackupStatePending:
+		now := metav1.Now()
+		b.Status.State = wmv1.BackupStateRunning
+		b.Status.Message = "Backing up volumes"
+		b.Status.StartedAt = &now
+		if err := r.Status().Update(ctx, b); err != nil {
+			if apierrors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	// A backup interrupted by a restart of the host manager is taken again, object keys are stable
+	logger.Info("Taking backup", zap2.Bool("full", b.Spec.Full))
+
+	parent := ""
+	if !b.Spec.Full {
+		var err error
+		if parent, err = r.latestCompletedBackup(ctx); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	paths, err := listVolumePaths(r.HostCmdExec)
+	if err != nil {
+		return r.setBackupFailed(ctx, b, fmt.Sprintf("Failed to list volumes: %v", err), logger)
+	}
+
+	volumes := make([]wmv1.BackupVolume, 0, len(paths))
+	var total int64
+	for _, path := range paths {
+		vol, err := r.backupVolume(ctx, b.Name, path, parent)
+		if err != nil {
+			return r.setBackupFailed(ctx, b, fmt.Sprintf("Failed to back up %s: %v", path, err), logger)
+		}
+		logger.Info("Backed up volume",
+			zap2.String("path", path),
+			zap2.String("parent", vol.Parent),
+			zap2.Int64("sizeBytes", vol.SizeBytes))
+		volumes = append(volumes, vol)
+		total += vol.SizeBytes
+	}
+
+	// Only the snapshot of this backup is needed as the parent of the next one
+	for _, path := range paths {
+		r.pruneLocalSnapshots(path, b.Name, logger)
+	}
+
+	now := metav1.Now()
+	b.Status.State = wmv1.BackupStateCompleted
+	b.Status.Message = fmt.Sprintf("Backed up %d volumes", len(volumes))
+	b.Status.Volumes = volumes
+	b.Status.SizeBytes = total
+	b.Status.CompletedAt = &now
+	if err := r.Status().Update(ctx, b); err != nil {
+		logger.Error("Failed to update status", zap2.Error(err))
+		return reconcile.Result{}, err
+	}
+
+	logger.Info("Backup completed", zap2.Int("volumes", len(volumes)), zap2.Int64("sizeBytes", total))
+	return reconcile.Result{}, nil
+}
+
+// latestCompletedBackup returns the name of the newest completed backup of this machine
+func (r *BackupReconciler) latestCompletedBackup(ctx context.Context) (string, error) {
+	list := &wmv1.BackupList{}
+	if err := r.List(ctx, list); err != nil {
+		return "", fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var latest *wmv1.Backup
+	for i := range list.Items {
+		b := &list.Items[i]
+		if b.Spec.WorkMachine != r.WorkMachineName || b.Status.State != wmv1.BackupStateCompleted || b.DeletionTimestamp != nil {
+			continue
+		}
+		if latest == nil || b.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = b
+		}
+	}
+
+	if latest == nil {
+		return "", nil
+	}
+	return latest.Name, nil
+}
+
+// backupVolume snapshots a volume and uploads it, incrementally when the snapshot of parent is still on the machine
+func (r *BackupReconciler) backupVolume(ctx context.Context, backupName, path, parent string) (wmv1.BackupVolume, error) {
+	snapshotDir := fmt.Sprintf("%s/%s", backupSnapshotPath, path)
+	snapshot := fmt.Sprintf("%s/%s", snapshotDir, backupName)
+
+	snapshotScript := fmt.Sprintf(`
+		set -e
+		mkdir -p %s
+		btrfs subvolume show %s >/dev/null 2>&1 || btrfs subvolume snapshot -r %s/%s %s
+	`, snapshotDir, snapshot, storageRootPath, path, snapshot)
+	if output, err := r.HostCmdExec.Execute(snapshotScript); err != nil {
+		return wmv1.BackupVolume{}, fmt.Errorf("failed to snapshot volume: %w, output: %s", err, string(output))
+	}
+
+	args := []string{"btrfs", "send"}
+	if parent != "" {
+		parentSnapshot := fmt.Sprintf("%s/%s", snapshotDir, parent)
+		if _, err := r.HostCmdExec.Execute(fmt.Sprintf("btrfs subvolume show %s >/dev/null 2>&1", parentSnapshot)); err == nil {
+			args = append(args, "-p", parentSnapshot)
+		} else {
+			// New volume, or the snapshot was lost with the machine disk
+			parent = ""
+		}
+	}
+	args = append(args, snapshot)
+
+	key := r.Store.ObjectKey(r.WorkMachineName, backupName, path)
+
+	cmd := hostCommand(ctx, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return wmv1.BackupVolume{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return wmv1.BackupVolume{}, fmt.Errorf("failed to start btrfs send: %w", err)
+	}
+
+	size, uploadErr := r.Store.Upload(ctx, key, stdout)
+	if uploadErr != nil {
+		// Unblock btrfs send
+		_ = cmd.Process.Kill()
+	}
+	if err := cmd.Wait(); err != nil && uploadErr == nil {
+		return wmv1.BackupVolume{}, fmt.Errorf("btrfs send failed: %w, output: %s", err, stderr.String())
+	}
+	if uploadErr != nil {
+		return wmv1.BackupVolume{}, uploadErr
+	}
+
+	return wmv1.BackupVolume{
+		Path:      path,
+		ObjectKey: key,
+		Parent:    parent,
+		SizeBytes: size,
+	}, nil
+}
+
+// pruneLocalSnapshots deletes the local snapshots of a volume other than the one of keep
+func (r *BackupReconciler) pruneLocalSnapshots(path, keep string, logger *zap2.Logger) {
+	snapshotDir := fmt.Sprintf("%s/%s", backupSnapshotPath, path)
+	pruneScript := fmt.Sprintf(`
+		for s in %s/*; do
+			[ "$(basename "$s")" = %q ] && continue
+			btrfs subvolume delete "$s" >/dev/null 2>&1 || true
+		done
+	`, snapshotDir, keep)
+	if output, err := r.HostCmdExec.Execute(pruneScript); err != nil {
+		logger.Warn("Failed to prune local backup snapshots",
+			zap2.String("path", path),
+			zap2.Error(err),
+			zap2.String("output", string(output)))
+	}
+}
+
+func (r *BackupReconciler) setBackupFailed(ctx context.Context, b *wmv1.Backup, message string, logger *zap2.Logger) (reconcile.Result, error) {
+	logger.Error("Backup failed", zap2.String("message", message))
+
+	now := metav1.Now()
+	b.Status.State = wmv1.BackupStateFailed
+	b.Status.Message = message
+	b.Status.CompletedAt = &now
+	if err := r.Status().Update(ctx, b); err != nil {
+		logger.Error("Failed to update status", zap2.Error(err))
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *BackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&wmv1.Backup{}).
+		Complete(r)
+}
+
+// listVolumePaths returns the volumes under storageRootPath, relative to it (e.g. workspaces/my-ws)
+func listVolumePaths(cmdExec CommandExecutor) ([]string, error) {
+	var paths []string
+	for _, dir := range backupVolumeDirs {
+		output, err := cmdExec.Execute(fmt.Sprintf("ls -1 %s/%s 2>/dev/null || true", storageRootPath, dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+			if name = strings.TrimSpace(name); name != "" {
+				paths = append(paths, dir+"/"+name)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// BackupRestoreReconciler restores backups onto this WorkMachine
+type BackupRestoreReconciler struct {
+	client.Client
+	Reader          client.Reader // Environments are read without the cache
+	Logger          *zap2.Logger
+	HostCmdExec     CommandExecutor // For btrfs commands that must run on host
+	Store           *backup.Store
+	WorkMachineName string
+}
+
+func (r *BackupRestoreReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := r.Logger.With(zap2.String("backupRestore", req.Name))
+
+	restore := &wmv1.BackupRestore{}
+	if err := r.Get(ctx, req.NamespacedName, restore); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		logger.Error("Failed to get BackupRestore", zap2.Error(err))
+		return reconcile.Result{}, err
+	}
+
+	if restore.Status.State == wmv1.BackupStateCompleted || restore.Status.State == wmv1.BackupStateFailed {
+		return reconcile.Result{}, nil
+	}
+
+	b := &wmv1.Backup{}
+	if err := r.Get(ctx, client.ObjectKey{Name: restore.Spec.Backup}, b); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		// Only the target machine reports the missing backup
+		if restore.Spec.WorkMachine != r.WorkMachineName {
+			return reconcile.Result{}, nil
+		}
+		return r.setRestoreFailed(ctx, restore, fmt.Sprintf("Backup %q not found", restore.Spec.Backup), logger)
+	}
+
+	// Only process restores onto this machine
+	target := restore.Spec.WorkMachine
+	if target == "" {
+		target = b.Spec.WorkMachine
+	}
+	if target != r.WorkMachineName {
+		return reconcile.Result{}, nil
+	}
+
+	if b.Status.State != wmv1.BackupStateCompleted {
+		return r.setRestoreFailed(ctx, restore, fmt.Sprintf("Backup %q is not completed", b.Name), logger)
+	}
+
+	var volumes []wmv1.BackupVolume
+	for _, vol := range b.Status.Volumes {
+		if restore.Spec.Workspace == "" || vol.Path == "workspaces/"+restore.Spec.Workspace {
+			volumes = append(volumes, vol)
+		}
+	}
+	if len(volumes) == 0 {
+		return r.setRestoreFailed(ctx, restore, fmt.Sprintf("Backup %q has no volume for workspace %q", b.Name, restore.Spec.Workspace), logger)
+	}
+
+	// Volumes in use are not replaced
+	for _, vol := range volumes {
+		inUse, err := r.volumeInUse(ctx, vol.Path)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if inUse != "" {
+			return r.setRestoreMessage(ctx, restore, inUse)
+		}
+	}
+
+	if restore.Status.State != wmv1.BackupStateRunning {
+		now := metav1.Now()
+		restore.Status.State = wmv1.BackupStateRunning
+		restore.Status.Message = "Restoring volumes"
+		restore.Status.StartedAt = &now
+		if err := r.Status().Update(ctx, restore); err != nil {
+			if apierrors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	restored := make([]string, 0, len(volumes))
+	for _, vol := range volumes {
+		logger.Info("Restoring volume", zap2.String("path", vol.Path))
+		if err := r.restoreVolume(ctx, restore.Name, b, vol); err != nil {
+			return r.setRestoreFailed(ctx, restore, fmt.Sprintf("Failed to restore %s: %v", vol.Path, err), logger)
+		}
+		restored = append(restored, vol.Path)
+	}
+
+	now := metav1.Now()
+	restore.Status.State = wmv1.BackupStateCompleted
+	restore.Status.Message = fmt.Sprintf("Restored %d volumes from %s", len(restored), b.Name)
+	restore.Status.RestoredVolumes = restored
+	restore.Status.CompletedAt = &now
+	if err := r.Status().Update(ctx, restore); err != nil {
+		logger.Error("Failed to update status", zap2.Error(err))
+		return reconcile.Result{}, err
+	}
+
+	logger.Info("Backup restored", zap2.String("backup", b.Name), zap2.Strings("volumes", restored))
+	return reconcile.Result{}, nil
+}
+
+// volumeInUse returns why a volume can not be replaced yet, empty when it can
+func (r *BackupRestoreReconciler) volumeInUse(ctx context.Context, path string) (string, error) {
+	dir, name, _ := strings.Cut(path, "/")
+
+	switch dir {
+	case "workspaces":
+		ws := &workspacev1.Workspace{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: r.WorkMachineName, Name: name}, ws); err != nil {
+			return "", client.IgnoreNotFound(err)
+		}
+		if ws.Spec.Status != "suspended" && ws.Spec.Status != "archived" {
+			return fmt.Sprintf("Waiting for workspace %s to be suspended", name), nil
+		}
+	case "environments":
+		envList := &environmentv1.EnvironmentList{}
+		if err := r.Reader.List(ctx, envList); err != nil {
+			return "", err
+		}
+		for _, env := range envList.Items {
+			if env.Spec.TargetNamespace == name && env.Spec.Activated {
+				return fmt.Sprintf("Waiting for environment %s to be deactivated", env.Name), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// restoreVolume receives the chain of streams of a volume, then swaps it with the live volume
+func (r *BackupRestoreReconciler) restoreVolume(ctx context.Context, restoreName string, b *wmv1.Backup, vol wmv1.BackupVolume) error {
+	chain, err := r.resolveChain(ctx, b.Name, vol)
+	if err != nil {
+		return err
+	}
+
+	receiveDir := fmt.Sprintf("%s/restore/%s/%s", backupSnapshotPath, restoreName, vol.Path)
+	cleanupScript := fmt.Sprintf(`
+		for s in %s/*; do
+			btrfs subvolume delete "$s" >/dev/null 2>&1 || true
+		done
+		rm -rf %s
+	`, receiveDir, receiveDir)
+	// Leftovers of an interrupted attempt are received again
+	r.HostCmdExec.Execute(cleanupScript)
+	defer r.HostCmdExec.Execute(cleanupScript)
+
+	if output, err := r.HostCmdExec.Execute(fmt.Sprintf("mkdir -p %s", receiveDir)); err != nil {
+		return fmt.Errorf("failed to create receive directory: %w, output: %s", err, string(output))
+	}
+
+	// Full stream first, every incremental stream needs its parent received
+	for _, key := range chain {
+		if err := r.receive(ctx, key, receiveDir); err != nil {
+			return err
+		}
+	}
+
+	livePath := fmt.Sprintf("%s/%s", storageRootPath, vol.Path)
+	preRestorePath := fmt.Sprintf("%s/pre-restore/%s", backupSnapshotPath, vol.Path)
+	// The received subvolume is named after the snapshot it was sent from, the backup name
+	swapScript := fmt.Sprintf(`
+		set -e
+		mkdir -p $(dirname %[1]s) $(dirname %[2]s)
+		btrfs subvolume delete %[2]s >/dev/null 2>&1 || rm -rf %[2]s
+		if [ -e %[1]s ]; then mv %[1]s %[2]s; fi
+		btrfs subvolume snapshot %[3]s/%[4]s %[1]s
+	`, livePath, preRestorePath, receiveDir, b.Name)
+	if output, err := r.HostCmdExec.Execute(swapScript); err != nil {
+		return fmt.Errorf("failed to replace volume: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// resolveChain returns the object keys a volume is restored from, full stream first
+func (r *BackupRestoreReconciler) resolveChain(ctx context.Context, backupName string, vol wmv1.BackupVolume) ([]string, error) {
+	chain := []string{vol.ObjectKey}
+	seen := map[string]bool{backupName: true}
+
+	for parent := vol.Parent; parent != ""; {
+		if seen[parent] {
+			return nil, fmt.Errorf("backup %s is its own ancestor", parent)
+		}
+		seen[parent] = true
+
+		b := &wmv1.Backup{}
+		if err := r.Get(ctx, client.ObjectKey{Name: parent}, b); err != nil {
+			return nil, fmt.Errorf("failed to get parent backup %s: %w", parent, err)
+		}
+
+		found := false
+		for _, v := range b.Status.Volumes {
+			if v.Path == vol.Path {
+				chain = append(chain, v.ObjectKey)
+				parent = v.Parent
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("parent backup %s has no volume %s", b.Name, vol.Path)
+		}
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+// receive downloads a stream and pipes it into btrfs receive
+func (r *BackupRestoreReconciler) receive(ctx context.Context, key, receiveDir string) error {
+	body, err := r.Store.Download(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	cmd := hostCommand(ctx, "btrfs", "receive", receiveDir)
+	cmd.Stdin = body
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("btrfs receive of %s failed: %w, output: %s", key, err, string(output))
+	}
+	return nil
+}
+
+func (r *BackupRestoreReconciler) setRestoreMessage(ctx context.Context, restore *wmv1.BackupRestore, message string) (reconcile.Result, error) {
+	if restore.Status.Message != message {
+		restore.Status.State = wmv1.BackupStatePending
+		restore.Status.Message = message
+		if err := r.Status().Update(ctx, restore); err != nil && !apierrors.IsConflict(err) {
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+}
+
+func (r *BackupRestoreReconciler) setRestoreFailed(ctx context.Context, restore *wmv1.BackupRestore, message string, logger *zap2.Logger) (reconcile.Result, error) {
+	logger.Error("Backup restore failed", zap2.String("message", message))
+
+	now := metav1.Now()
+	restore.Status.State = wmv1.BackupStateFailed
+	restore.Status.Message = message
+	restore.Status.CompletedAt = &now
+	if err := r.Status().Update(ctx, restore); err != nil {
+		logger.Error("Failed to update status", zap2.Error(err))
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *BackupRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&wmv1.BackupRestore{}).
+		Complete(r)
+}
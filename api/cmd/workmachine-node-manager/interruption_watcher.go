@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	environmentv1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
+	wmv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	zap2 "go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InterruptionWatcher polls the cloud metadata service of a spot machine for interruption notices
+//
+// On the first notice it creates emergency SnapshotRequests for all active workspaces and
+// environments of the WorkMachine, then annotates the node so the WorkMachine controller starts
+// recovering the machine. OCI gives no notice, the controller detects the terminated instance.
+type InterruptionWatcher struct {
+	Client client.Client
+	// Reader is used to read resources directly from API server (bypasses cache)
+	Reader          client.Reader
+	Logger          *zap2.Logger
+	Provider        wmv1.CloudProvider
+	WorkMachineName string
+	NodeName        string
+	Interval        time.Duration
+
+	// MetadataURL overrides the metadata service address of the provider (for tests)
+	MetadataURL string
+	HTTPClient  *http.Client
+}
+
+// Run starts the interruption watch loop, it returns after handling a notice
+func (w *InterruptionWatcher) Run(ctx context.Context) {
+	if w.HTTPClient == nil {
+		w.HTTPClient = &http.Client{Timeout: 2 * time.Second}
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.Logger.Info("Interruption watcher stopped")
+			return
+		case <-ticker.C:
+			notice, err := w.checkNotice(ctx)
+			if err != nil {
+				w.Logger.Debug("Failed to check interruption notice", zap2.Error(err))
+				continue
+			}
+			if notice == "" {
+				continue
+			}
+
+			w.Logger.Warn("Spot interruption notice received, creating emergency snapshots", zap2.String("notice", notice))
+			if err := w.handleNotice(ctx, notice); err != nil {
+				w.Logger.Error("Failed to handle interruption notice", zap2.Error(err))
+				continue
+			}
+			return
+		}
+	}
+}
+
+// checkNotice returns an identifier of the notice if the cloud has announced that the instance
+// will be reclaimed, or "" without notice
+func (w *InterruptionWatcher) checkNotice(ctx context.Context) (string, error) {
+	switch w.Provider {
+	case wmv1.AWS:
+		return w.checkAWSNotice(ctx)
+	case wmv1.GCP:
+		return w.checkGCPNotice(ctx)
+	case wmv1.Azure:
+		return w.checkAzureNotice(ctx)
+	default:
+		return "", nil
+	}
+}
+
+func (w *InterruptionWatcher) metadataURL(defaultURL string) string {
+	if w.MetadataURL != "" {
+		return w.MetadataURL
+	}
+	return defaultURL
+}
+
+// checkAWSNotice reads spot/instance-action through IMDSv2, which returns 404 until a notice is issued
+// The notice is identified by the time of the action.
+func (w *InterruptionWatcher) checkAWSNotice(ctx context.Context) (string, error) {
+	base := w.metadataURL("http://169.254.169.254")
+
+	tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPut, base+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	tokenReq.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, status, err := w.do(tokenReq)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("failed to get IMDS token: status %d", status)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/latest/meta-data/spot/instance-action", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	body, status, err := w.do(req)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", nil
+	}
+
+	var action struct {
+		Action string `json:"action"`
+		Time   string `json:"time"`
+	}
+	if err := json.Unmarshal([]byte(body), &action); err != nil || action.Time == "" {
+		return "", fmt.Errorf("failed to parse instance action %q", body)
+	}
+	return action.Action + "@" + action.Time, nil
+}
+
+// checkGCPNotice reads instance/preempted, which turns TRUE when the instance is preempted
+// An instance is preempted at most once, the node UID tells notices of different instances apart.
+func (w *InterruptionWatcher) checkGCPNotice(ctx context.Context) (string, error) {
+	base := w.metadataURL("http://metadata.google.internal")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/computeMetadata/v1/instance/preempted", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	body, status, err := w.do(req)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("failed to read preempted flag: status %d", status)
+	}
+	if !strings.EqualFold(strings.TrimSpace(body), "TRUE") {
+		return "", nil
+	}
+	return "preempted", nil
+}
+
+// checkAzureNotice looks for a Preempt event in the Scheduled Events of the instance
+// The notice is identified by the EventId of the event.
+func (w *InterruptionWatcher) checkAzureNotice(ctx context.Context) (string, error) {
+	base := w.metadataURL("http://169.254.169.254")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/metadata/scheduledevents?api-version=2020-07-01", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+	body, status, err := w.do(req)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("failed to read scheduled events: status %d", status)
+	}
+
+	var events struct {
+		Events []struct {
+			EventId   string `json:"EventId"`
+			EventType string `json:"EventType"`
+		} `json:"Events"`
+	}
+	if err := json.Unmarshal([]byte(body), &events); err != nil {
+		return "", fmt.Errorf("failed to parse scheduled events: %w", err)
+	}
+	for _, event := range events.Events {
+		if event.EventType == "Preempt" {
+			if event.EventId == "" {
+				return "preempt", nil
+			}
+			return event.EventId, nil
+		}
+	}
+	return "", nil
+}
+
+func (w *InterruptionWatcher) do(req *http.Request) (string, int, error) {
+	resp, err := w.HTTPClient.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(body), resp.StatusCode, nil
+}
+
+// handleNotice creates the emergency snapshot requests, then annotates the node
+// The controller deletes the machine once the node is annotated, so requests must exist first.
+// Request names are derived from the notice, so a retried or restarted watcher reuses them.
+func (w *InterruptionWatcher) handleNotice(ctx context.Context, notice string) error {
+	node := &corev1.Node{}
+	if err := w.Reader.Get(ctx, client.ObjectKey{Name: w.NodeName}, node); err != nil {
+		return fmt.Errorf("failed to get node %s: %w", w.NodeName, err)
+	}
+	suffix := interruptionSuffix(node, notice)
+
+	envList := &environmentv1.EnvironmentList{}
+	if err := w.Reader.List(ctx, envList); err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+	for _, env := range envList.Items {
+		if env.Spec.WorkMachineName != w.WorkMachineName || !env.Spec.Activated {
+			continue
+		}
+
+		parentSnapshot := ""
+		if env.Status.LastRestoredSnapshot != nil {
+			parentSnapshot = env.Status.LastRestoredSnapshot.Name
+		}
+
+		labels := w.labels(env.Spec.OwnedBy)
+		labels["snapshots.kloudlite.io/environment"] = env.Name
+		labels["snapshots.kloudlite.io/type"] = "environment"
+
+		if err := w.createSnapshotRequest(ctx, env.Spec.TargetNamespace, env.Name+"-"+suffix, labels, snapshotv1.SnapshotRequestSpec{
+			SourcePath:     fmt.Sprintf("/var/lib/kloudlite/storage/environments/%s", env.Spec.TargetNamespace),
+			Owner:          env.Spec.OwnedBy,
+			ParentSnapshot: parentSnapshot,
+			Description:    fmt.Sprintf("Emergency snapshot of environment %s before spot interruption", env.Name),
+		}); err != nil {
+			return err
+		}
+	}
+
+	workspaceList := &workspacev1.WorkspaceList{}
+	if err := w.Reader.List(ctx, workspaceList); err != nil {
+		return fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	for _, ws := range workspaceList.Items {
+		if ws.Spec.WorkmachineName != w.WorkMachineName || ws.Spec.Status == "suspended" || ws.Spec.Status == "archived" {
+			continue
+		}
+
+		labels := w.labels(ws.Spec.OwnedBy)
+		labels["snapshots.kloudlite.io/workspace"] = ws.Name
+		labels["snapshots.kloudlite.io/type"] = "workspace"
+
+		if err := w.createSnapshotRequest(ctx, ws.Namespace, ws.Name+"-"+suffix, labels, snapshotv1.SnapshotRequestSpec{
+			SourcePath:  fmt.Sprintf("/var/lib/kloudlite/storage/workspaces/%s", ws.Name),
+			Owner:       ws.Spec.OwnedBy,
+			Description: fmt.Sprintf("Emergency snapshot of workspace %s before spot interruption", ws.Name),
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Fresh read, the node may have changed while the requests were created
+	if err := w.Reader.Get(ctx, client.ObjectKey{Name: w.NodeName}, node); err != nil {
+		return fmt.Errorf("failed to get node %s: %w", w.NodeName, err)
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[wmv1.AnnotationInterruptionNotice] = time.Now().UTC().Format(time.RFC3339)
+	if err := w.Client.Update(ctx, node); err != nil {
+		return fmt.Errorf("failed to annotate node %s: %w", w.NodeName, err)
+	}
+	return nil
+}
+
+// interruptionSuffix names the snapshots of a notice after the node instance and the notice
+func interruptionSuffix(node *corev1.Node, notice string) string {
+	sum := sha256.Sum256([]byte(node.Name + "\x00" + string(node.UID) + "\x00" + notice))
+	return "interrupted-" + hex.EncodeToString(sum[:])[:10]
+}
+
+func (w *InterruptionWatcher) labels(owner string) map[string]string {
+	return map[string]string{
+		"kloudlite.io/owned-by":    owner,
+		"kloudlite.io/workmachine": w.WorkMachineName,
+		wmv1.LabelSnapshotReason:   wmv1.SnapshotReasonInterruption,
+	}
+}
+
+func (w *InterruptionWatcher) createSnapshotRequest(ctx context.Context, namespace, snapshotName string, labels map[string]string, spec snapshotv1.SnapshotRequestSpec) error {
+	spec.SnapshotName = snapshotName
+	spec.NodeName = w.NodeName
+
+	req := &snapshotv1.SnapshotRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "req-" + snapshotName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: spec,
+	}
+	if err := w.Client.Create(ctx, req); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create snapshot request %s/%s: %w", namespace, req.Name, err)
+		}
+		// Created for the same notice by a previous attempt
+		w.Logger.Info("Emergency snapshot request already exists",
+			zap2.String("namespace", namespace),
+			zap2.String("snapshot", snapshotName))
+		return nil
+	}
+
+	w.Logger.Info("Created emergency snapshot request",
+		zap2.String("namespace", namespace),
+		zap2.String("snapshot", snapshotName))
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	environmentv1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
+	wmv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestInterruptionWatcher_CheckNotice(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider wmv1.CloudProvider
+		handler  http.HandlerFunc
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "aws without notice",
+			provider: wmv1.AWS,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/latest/api/token" {
+					w.Write([]byte("token"))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			expected: "",
+		},
+		{
+			name:     "aws with instance action",
+			provider: wmv1.AWS,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/latest/api/token" {
+					assert.Equal(t, http.MethodPut, r.Method)
+					w.Write([]byte("token"))
+					return
+				}
+				assert.Equal(t, "token", r.Header.Get("X-aws-ec2-metadata-token"))
+				w.Write([]byte(`{"action": "stop", "time": "2026-10-19T08:22:00Z"}`))
+			},
+			expected: "stop@2026-10-19T08:22:00Z",
+		},
+		{
+			name:     "gcp not preempted",
+			provider: wmv1.GCP,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("FALSE"))
+			},
+			expected: "",
+		},
+		{
+			name:     "gcp preempted",
+			provider: wmv1.GCP,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+				w.Write([]byte("TRUE"))
+			},
+			expected: "preempted",
+		},
+		{
+			name:     "azure with maintenance event only",
+			provider: wmv1.Azure,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"DocumentIncarnation": 1, "Events": [{"EventType": "Freeze"}]}`))
+			},
+			expected: "",
+		},
+		{
+			name:     "azure with preempt event",
+			provider: wmv1.Azure,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "true", r.Header.Get("Metadata"))
+				w.Write([]byte(`{"DocumentIncarnation": 2, "Events": [{"EventId": "A123BC45-1234-5678-AB90-ABCDEF123456", "EventType": "Preempt"}]}`))
+			},
+			expected: "A123BC45-1234-5678-AB90-ABCDEF123456",
+		},
+		{
+			name:     "azure metadata unavailable",
+			provider: wmv1.Azure,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantErr: true,
+		},
+		{
+			name:     "oci has no notice",
+			provider: wmv1.OCI,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				t.Error("metadata service should not be queried")
+			},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			w := &InterruptionWatcher{
+				Logger:      zap.NewNop(),
+				Provider:    tt.provider,
+				MetadataURL: server.URL,
+				HTTPClient:  server.Client(),
+			}
+
+			notice, err := w.checkNotice(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, notice)
+		})
+	}
+}
+
+func TestInterruptionWatcher_HandleNoticeIsIdempotent(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = environmentv1.AddToScheme(scheme)
+	_ = workspacev1.AddToScheme(scheme)
+	_ = snapshotv1.AddToScheme(scheme)
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "wm-alice", UID: "node-uid-1"}}
+	ws := &workspacev1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "wm-alice"},
+		Spec:       workspacev1.WorkspaceSpec{WorkmachineName: "wm-alice", OwnedBy: "alice"},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(node, ws).Build()
+
+	w := &InterruptionWatcher{
+		Client:          fakeClient,
+		Reader:          fakeClient,
+		Logger:          zap.NewNop(),
+		WorkMachineName: "wm-alice",
+		NodeName:        "wm-alice",
+	}
+
+	// A retried or restarted watcher handles the same notice again
+	require.NoError(t, w.handleNotice(context.Background(), "stop@2026-10-19T08:22:00Z"))
+	require.NoError(t, w.handleNotice(context.Background(), "stop@2026-10-19T08:22:00Z"))
+
+	requests := &snapshotv1.SnapshotRequestList{}
+	require.NoError(t, fakeClient.List(context.Background(), requests))
+	require.Len(t, requests.Items, 1)
+	assert.Equal(t, "req-dev-"+interruptionSuffix(node, "stop@2026-10-19T08:22:00Z"), requests.Items[0].Name)
+
+	// Another notice, or the same one on a new instance with the node name, gets new snapshots
+	assert.NotEqual(t, interruptionSuffix(node, "stop@2026-10-19T08:22:00Z"), interruptionSuffix(node, "stop@2026-10-20T08:22:00Z"))
+	recreated := node.DeepCopy()
+	recreated.UID = "node-uid-2"
+	assert.NotEqual(t, interruptionSuffix(node, "preempted"), interruptionSuffix(recreated, "preempted"))
+}
@@ -8,6 +8,8 @@ import (
 	environmentv1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
 	packagesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/packages/v1"
 	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud/byo"
+	wmv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
 	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
 	zap2 "go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -33,6 +35,20 @@ const (
 )
 
 func main() {
+	// One-shot Wake-on-LAN sender, run by the byo provider on a node of the network of the host
+	if len(os.Args) > 1 && os.Args[1] == "wake-on-lan" {
+		if len(os.Args) != 4 {
+			fmt.Println("usage: workmachine-node-manager wake-on-lan <mac> <address>")
+			os.Exit(2)
+		}
+		if err := byo.SendWakeOnLAN(os.Args[2], os.Args[3]); err != nil {
+			fmt.Printf("Failed to send wake-on-lan packet: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Sent wake-on-lan packet to %s via %s\n", os.Args[2], os.Args[3])
+		return
+	}
+
 	// Setup logger using controller-runtime's zap logger
 	opts := zap.Options{
 		Development: false,
@@ -113,6 +129,9 @@ func main() {
 	if err := environmentv1.AddToScheme(scheme); err != nil {
 		zapLogger.Fatal("Failed to add environment v1 scheme", zap2.Error(err))
 	}
+	if err := wmv1.AddToScheme(scheme); err != nil {
+		zapLogger.Fatal("Failed to add workmachine v1 scheme", zap2.Error(err))
+	}
 
 	// Get in-cluster config
 	config, err := rest.InClusterConfig()
@@ -255,6 +274,36 @@ func main() {
 		zapLogger.Fatal("Failed to setup snapshot restore controller", zap2.Error(err))
 	}
 
+	// Setup backup reconcilers (btrfs send/receive to the backup bucket), only when a bucket is configured
+	backupStore, err := newBackupStoreFromEnv()
+	if err != nil {
+		zapLogger.Fatal("Failed to create backup store", zap2.Error(err))
+	}
+	if backupStore != nil {
+		backupReconciler := &BackupReconciler{
+			Client:          mgr.GetClient(),
+			Logger:          zapLogger,
+			HostCmdExec:     &HostCommandExecutor{},
+			Store:           backupStore,
+			WorkMachineName: workmachineName,
+		}
+		if err := backupReconciler.SetupWithManager(mgr); err != nil {
+			zapLogger.Fatal("Failed to setup backup controller", zap2.Error(err))
+		}
+
+		backupRestoreReconciler := &BackupRestoreReconciler{
+			Client:          mgr.GetClient(),
+			Reader:          mgr.GetAPIReader(),
+			Logger:          zapLogger,
+			HostCmdExec:     &HostCommandExecutor{},
+			Store:           backupStore,
+			WorkMachineName: workmachineName,
+		}
+		if err := backupRestoreReconciler.SetupWithManager(mgr); err != nil {
+			zapLogger.Fatal("Failed to setup backup restore controller", zap2.Error(err))
+		}
+	}
+
 	zapLogger.Info("All reconcilers configured",
 		zap2.String("nodeName", nodeName))
 
@@ -271,6 +320,20 @@ func main() {
 	}
 	go storageGC.Run(ctx)
 
+	// Watch for spot interruption notices on spot machines
+	if wmv1.CapacityType(os.Getenv("WORKMACHINE_CAPACITY")).IsSpot() {
+		interruptionWatcher := &InterruptionWatcher{
+			Client:          mgr.GetClient(),
+			Reader:          mgr.GetAPIReader(),
+			Logger:          zapLogger,
+			Provider:        wmv1.CloudProvider(os.Getenv("CLOUD_PROVIDER")),
+			WorkMachineName: workmachineName,
+			NodeName:        nodeName,
+			Interval:        5 * time.Second, // AWS gives a 2 minute notice, GCP 30 seconds
+		}
+		go interruptionWatcher.Run(ctx)
+	}
+
 	// Start metrics HTTP server in a goroutine
 	metricsServer := &MetricsServer{
 		CmdExec: &HostCommandExecutor{},
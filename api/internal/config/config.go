@@ -26,6 +26,11 @@ type Config struct {
 
 	// Registry configuration
 	Registry RegistryConfig `envconfig:"REGISTRY"`
+
+	// WorkMachinePlacement and WorkMachinePlacements are the spec.placements accepted for
+	// WorkMachines, the ones the WorkMachine controller provisions machines in
+	WorkMachinePlacement  string   `envconfig:"WORKMACHINE_PLACEMENT" default:""`
+	WorkMachinePlacements []string `envconfig:"WORKMACHINE_PLACEMENTS"`
 }
 
 type RegistryConfig struct {
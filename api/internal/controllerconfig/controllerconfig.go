@@ -18,6 +18,9 @@ type ControllerConfig struct {
 
 	// Snapshot controller configuration
 	Snapshot SnapshotConfig
+
+	// Budget controller configuration
+	Budget BudgetConfig
 }
 
 // WorkspaceConfig contains workspace controller configuration
@@ -133,6 +136,38 @@ type WorkMachineConfig struct {
 	// Default: 5 seconds
 	MachineTypeChangeRetryInterval time.Duration
 
+	// MachineTypeChangeDrainPeriod is how long users are warned before a machine type change stops their workspaces
+	// Default: 5 minutes
+	MachineTypeChangeDrainPeriod time.Duration
+
+	// MachineTypeChangeSnapshotTimeout is how long to wait for the snapshots taken before a machine type change
+	// Default: 5 minutes
+	MachineTypeChangeSnapshotTimeout time.Duration
+
+	// MachineTypeChangeTimeout is how long the machine may take to be ready with the new type before it is rolled back
+	// Default: 15 minutes
+	MachineTypeChangeTimeout time.Duration
+
+	// MigrationRetryInterval is how long to wait between checks of a WorkMachine migration
+	// Default: 10 seconds
+	MigrationRetryInterval time.Duration
+
+	// MigrationProvisionTimeout is how long the target of a migration may take to be running before the migration fails
+	// Default: 20 minutes
+	MigrationProvisionTimeout time.Duration
+
+	// NodePoolCheckInterval is how long to wait between checks of pool nodes being created, started or stopped
+	// Default: 30 seconds
+	NodePoolCheckInterval time.Duration
+
+	// WarmPoolClaimCheckInterval is how long to wait between checks of a claimed warm machine being renamed
+	// Default: 10 seconds
+	WarmPoolClaimCheckInterval time.Duration
+
+	// WarmPoolReplenishInterval is how often warm pools are checked and replenished
+	// Default: 1 minute
+	WarmPoolReplenishInterval time.Duration
+
 	// AutoShutdownCheckInterval is how often to check for auto-shutdown
 	// Default: 5 minutes
 	AutoShutdownCheckInterval time.Duration
@@ -180,6 +215,18 @@ type WorkMachineConfig struct {
 	// AutoShutdownTriggerRetryInterval is how long to wait after triggering auto-shutdown
 	// Default: 5 seconds
 	AutoShutdownTriggerRetryInterval time.Duration
+
+	// InterruptionSnapshotTimeout is how long to wait for emergency snapshots of an interrupted spot machine
+	// Default: 5 minutes
+	InterruptionSnapshotTimeout time.Duration
+
+	// InterruptionRetryInterval is how long to wait between interruption recovery checks
+	// Default: 10 seconds
+	InterruptionRetryInterval time.Duration
+
+	// HibernationCheckInterval is how long to wait between hibernation snapshot progress checks
+	// Default: 30 seconds
+	HibernationCheckInterval time.Duration
 }
 
 // WMIngressConfig contains wm-ingress controller configuration
@@ -260,3 +307,22 @@ type SnapshotConfig struct {
 	// Default: 5 seconds
 	SnapshotRestoreStatusRetryInterval time.Duration
 }
+
+// BudgetConfig contains the cost model and budget controller configuration
+type BudgetConfig struct {
+	// Currency is the currency of all prices and budget amounts
+	// Default: USD
+	Currency string
+
+	// VolumePricePerGBMonth is the price of one GB of WorkMachine storage volume per month
+	// Default: 0 (storage is free)
+	VolumePricePerGBMonth float64
+
+	// SnapshotPricePerGBMonth is the price of one GB of snapshot storage per month
+	// Default: 0 (snapshots are free)
+	SnapshotPricePerGBMonth float64
+
+	// CheckInterval is how often budgets are recomputed
+	// Default: 5 minutes
+	CheckInterval time.Duration
+}
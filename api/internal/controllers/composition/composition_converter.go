@@ -8,6 +8,7 @@ import (
 
 	composego "github.com/compose-spec/compose-go/v2/types"
 	compositionsv1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	workmachinev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -25,6 +26,69 @@ func sanitizeK8sName(name string) string {
 	return name
 }
 
+// NodePoolAnnotation is set on the StatefulSets of services placed on a node pool of the WorkMachine
+const NodePoolAnnotation = "kloudlite.io/node-pool"
+
+// ServiceNodePool returns the node pool a service is placed on, set with
+//
+//	x-kloudlite:
+//	  node-pool: <pool>
+//
+// An empty pool places the service on the WorkMachine itself
+func ServiceNodePool(service composego.ServiceConfig) (string, error) {
+	ext, ok := service.Extensions["x-kloudlite"]
+	if !ok || ext == nil {
+		return "", nil
+	}
+
+	fields, ok := ext.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("x-kloudlite must be a mapping")
+	}
+
+	pool, ok := fields["node-pool"]
+	if !ok {
+		return "", nil
+	}
+
+	name, ok := pool.(string)
+	if !ok || name == "" {
+		return "", fmt.Errorf("x-kloudlite.node-pool must be a non-empty string")
+	}
+	return name, nil
+}
+
+// NodePlacement returns the node selector and tolerations that schedule a pod on a WorkMachine,
+// or on the nodes of one of its node pools when nodePool is set
+func NodePlacement(workMachine string, nodePool string) (map[string]string, []corev1.Toleration) {
+	if nodePool == "" {
+		nodeSelector := map[string]string{"kubernetes.io/hostname": workMachine}
+		tolerations := []corev1.Toleration{
+			{
+				Key:      "kloudlite.io/workmachine",
+				Operator: corev1.TolerationOpEqual,
+				Value:    workMachine,
+				Effect:   corev1.TaintEffectNoSchedule,
+			},
+		}
+		return nodeSelector, tolerations
+	}
+
+	// Every pool node is tainted with its own name, the selector keeps pods on the pool
+	nodeSelector := map[string]string{
+		workmachinev1.LabelNodePoolWorkMachine: workMachine,
+		workmachinev1.LabelNodePool:            nodePool,
+	}
+	tolerations := []corev1.Toleration{
+		{
+			Key:      "kloudlite.io/workmachine",
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+	}
+	return nodeSelector, tolerations
+}
+
 // ComposeResources holds all Kubernetes resources converted from docker-compose
 type ComposeResources struct {
 	StatefulSets []*appsv1.StatefulSet
@@ -68,9 +132,29 @@ func ConvertComposeToK8s(
 		"kloudlite.io/managed":            "true",
 	}
 
+	// Volumes of services placed on a node pool are provisioned on the pool node that first uses them
+	poolVolumes := make(map[string]bool)
+	for serviceName, service := range project.Services {
+		nodePool, err := ServiceNodePool(service)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert service %s: %w", serviceName, err)
+		}
+		if nodePool == "" {
+			continue
+		}
+		for _, vol := range service.Volumes {
+			if vol.Type == "volume" && vol.Source != "" {
+				poolVolumes[vol.Source] = true
+			}
+		}
+	}
+
 	// Convert volumes first (they need to exist before StatefulSets)
 	for volumeName, volume := range project.Volumes {
 		pvc := convertVolumeToPVC(volumeName, volume, composition, namespace, commonLabels, environment)
+		if poolVolumes[volumeName] {
+			delete(pvc.Annotations, "volume.kubernetes.io/selected-node")
+		}
 		resources.PVCs = append(resources.PVCs, pvc)
 	}
 
@@ -294,22 +378,16 @@ func convertServiceToStatefulSet(
 		Volumes:    volumes,
 	}
 
+	nodePool, err := ServiceNodePool(service)
+	if err != nil {
+		return nil, err
+	}
+
 	// Apply node selector and tolerations from environment if available
 	// Note: This code path is typically overridden in deployment.go when WorkMachine is used
 	// But we keep it here for compatibility with environments that set NodeName directly
 	if environment != nil && environment.Spec.NodeName != "" {
-		podSpec.NodeSelector = map[string]string{
-			"kubernetes.io/hostname": environment.Spec.NodeName,
-		}
-		// Add toleration for workmachine taint
-		podSpec.Tolerations = []corev1.Toleration{
-			{
-				Key:      "kloudlite.io/workmachine",
-				Operator: corev1.TolerationOpEqual,
-				Value:    environment.Spec.NodeName,
-				Effect:   corev1.TaintEffectNoSchedule,
-			},
-		}
+		podSpec.NodeSelector, podSpec.Tolerations = NodePlacement(environment.Spec.NodeName, nodePool)
 	}
 
 	// Create StatefulSet
@@ -334,6 +412,10 @@ func convertServiceToStatefulSet(
 		},
 	}
 
+	if nodePool != "" {
+		statefulSet.Annotations = map[string]string{NodePoolAnnotation: nodePool}
+	}
+
 	return statefulSet, nil
 }
 
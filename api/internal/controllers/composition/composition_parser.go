@@ -441,6 +441,11 @@ func validateServices(project *composego.Project) error {
 			}
 		}
 
+		// Validate the x-kloudlite extension
+		if _, err := ServiceNodePool(service); err != nil {
+			return fmt.Errorf("service %s: %w", serviceName, err)
+		}
+
 		// Validate environment variables
 		for envKey := range service.Environment {
 			if err := validateEnvVarName(envKey); err != nil {
@@ -14,6 +14,7 @@ type EnvironmentConfig = controllerconfig.EnvironmentConfig
 type WorkMachineConfig = controllerconfig.WorkMachineConfig
 type WMIngressConfig = controllerconfig.WMIngressConfig
 type SnapshotConfig = controllerconfig.SnapshotConfig
+type BudgetConfig = controllerconfig.BudgetConfig
 type ControllerConfig = controllerconfig.ControllerConfig
 
 // LoadConfig loads controller configuration from environment variables
@@ -64,6 +65,30 @@ func LoadConfig() (*ControllerConfig, error) {
 	if cfg.WorkMachine.MachineTypeChangeRetryInterval == 0 {
 		cfg.WorkMachine.MachineTypeChangeRetryInterval = 5 * time.Second
 	}
+	if cfg.WorkMachine.MachineTypeChangeDrainPeriod == 0 {
+		cfg.WorkMachine.MachineTypeChangeDrainPeriod = 5 * time.Minute
+	}
+	if cfg.WorkMachine.MachineTypeChangeSnapshotTimeout == 0 {
+		cfg.WorkMachine.MachineTypeChangeSnapshotTimeout = 5 * time.Minute
+	}
+	if cfg.WorkMachine.MachineTypeChangeTimeout == 0 {
+		cfg.WorkMachine.MachineTypeChangeTimeout = 15 * time.Minute
+	}
+	if cfg.WorkMachine.MigrationRetryInterval == 0 {
+		cfg.WorkMachine.MigrationRetryInterval = 10 * time.Second
+	}
+	if cfg.WorkMachine.MigrationProvisionTimeout == 0 {
+		cfg.WorkMachine.MigrationProvisionTimeout = 20 * time.Minute
+	}
+	if cfg.WorkMachine.NodePoolCheckInterval == 0 {
+		cfg.WorkMachine.NodePoolCheckInterval = 30 * time.Second
+	}
+	if cfg.WorkMachine.WarmPoolClaimCheckInterval == 0 {
+		cfg.WorkMachine.WarmPoolClaimCheckInterval = 10 * time.Second
+	}
+	if cfg.WorkMachine.WarmPoolReplenishInterval == 0 {
+		cfg.WorkMachine.WarmPoolReplenishInterval = time.Minute
+	}
 	if cfg.WorkMachine.AutoShutdownCheckInterval == 0 {
 		cfg.WorkMachine.AutoShutdownCheckInterval = 5 * time.Minute
 	}
@@ -94,6 +119,15 @@ func LoadConfig() (*ControllerConfig, error) {
 	if cfg.WorkMachine.AutoShutdownTriggerRetryInterval == 0 {
 		cfg.WorkMachine.AutoShutdownTriggerRetryInterval = 5 * time.Second
 	}
+	if cfg.WorkMachine.InterruptionSnapshotTimeout == 0 {
+		cfg.WorkMachine.InterruptionSnapshotTimeout = 5 * time.Minute
+	}
+	if cfg.WorkMachine.InterruptionRetryInterval == 0 {
+		cfg.WorkMachine.InterruptionRetryInterval = 10 * time.Second
+	}
+	if cfg.WorkMachine.HibernationCheckInterval == 0 {
+		cfg.WorkMachine.HibernationCheckInterval = 30 * time.Second
+	}
 
 	if cfg.WMIngress.ProxyTimeout == 0 {
 		cfg.WMIngress.ProxyTimeout = 30 * time.Second
@@ -127,6 +161,13 @@ func LoadConfig() (*ControllerConfig, error) {
 		cfg.Snapshot.SnapshotRestoreStatusRetryInterval = 5 * time.Second
 	}
 
+	if cfg.Budget.Currency == "" {
+		cfg.Budget.Currency = "USD"
+	}
+	if cfg.Budget.CheckInterval == 0 {
+		cfg.Budget.CheckInterval = 5 * time.Minute
+	}
+
 	// Set derived fields for Workspace config
 	cfg.Workspace.DefaultRequeueInterval = time.Duration(cfg.Workspace.RequeueIntervalMinutes) * time.Minute
 	cfg.Workspace.RBACCleanupRetryInterval = time.Duration(cfg.Workspace.RBACCleanupIntervalMinutes) * time.Minute
@@ -142,18 +142,13 @@ func (r *EnvironmentReconciler) reconcileCompose(ctx context.Context, environmen
 					zap.String("workmachine", environment.Spec.WorkMachineName),
 					zap.Error(err))
 			} else {
-				if statefulSet.Spec.Template.Spec.NodeSelector == nil {
-					statefulSet.Spec.Template.Spec.NodeSelector = make(map[string]string)
-				}
-				statefulSet.Spec.Template.Spec.NodeSelector["kubernetes.io/hostname"] = wm.Name
-				statefulSet.Spec.Template.Spec.Tolerations = []corev1.Toleration{
-					{
-						Key:      "kloudlite.io/workmachine",
-						Operator: corev1.TolerationOpEqual,
-						Value:    wm.Name,
-						Effect:   corev1.TaintEffectNoSchedule,
-					},
+				nodePool := statefulSet.Annotations[composition.NodePoolAnnotation]
+				if nodePool != "" && wm.Spec.GetNodePool(nodePool) == nil {
+					environment.Status.ComposeStatus.State = environmentsv1.CompositionStateFailed
+					environment.Status.ComposeStatus.Message = fmt.Sprintf("Service %s: node pool %s not found on WorkMachine %s", statefulSet.Name, nodePool, wm.Name)
+					return true, nil
 				}
+				statefulSet.Spec.Template.Spec.NodeSelector, statefulSet.Spec.Template.Spec.Tolerations = composition.NodePlacement(wm.Name, nodePool)
 			}
 		}
 
@@ -126,6 +126,54 @@ func NewManager(cfg *rest.Config, installationCfg *config.InstallationConfig, au
 		return nil, fmt.Errorf("unable to setup WorkMachine controller: %w", err)
 	}
 
+	// Setup WorkMachine backup controller
+	backupReconciler := &workmachine.BackupReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Logger: logger.With(zap.String("controller", "workmachine-backup")),
+	}
+
+	if err = backupReconciler.SetupWithManager(mgr); err != nil {
+		return nil, fmt.Errorf("unable to create WorkMachine backup controller: %w", err)
+	}
+
+	// Setup WorkMachine migration controller
+	migrationReconciler := &workmachine.MigrationReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Logger: logger.With(zap.String("controller", "workmachine-migration")),
+		Cfg:    controllerCfg,
+	}
+
+	if err = migrationReconciler.SetupWithManager(mgr); err != nil {
+		return nil, fmt.Errorf("unable to create WorkMachine migration controller: %w", err)
+	}
+
+	// Setup WorkMachine warm pool controller
+	warmPoolReconciler := &workmachine.WarmPoolReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Logger: logger.With(zap.String("controller", "warm-pool")),
+		Cfg:    controllerCfg,
+	}
+
+	if err = warmPoolReconciler.SetupWithManager(mgr); err != nil {
+		return nil, fmt.Errorf("unable to create warm pool controller: %w", err)
+	}
+
+	// Setup Budget controller
+	budgetReconciler := &workmachine.BudgetReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Logger:   logger.With(zap.String("controller", "budget")),
+		Recorder: mgr.GetEventRecorderFor("budget"),
+		Cfg:      controllerCfg,
+	}
+
+	if err = budgetReconciler.SetupWithManager(mgr); err != nil {
+		return nil, fmt.Errorf("unable to create Budget controller: %w", err)
+	}
+
 	// Setup Workspace controller
 	clientset, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
@@ -145,6 +193,17 @@ func NewManager(cfg *rest.Config, installationCfg *config.InstallationConfig, au
 		return nil, fmt.Errorf("unable to create Workspace controller: %w", err)
 	}
 
+	// Setup WorkspaceTemplate controller
+	workspaceTemplateReconciler := &workspace.WorkspaceTemplateReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Logger: logger.With(zap.String("controller", "workspacetemplate")),
+	}
+
+	if err = workspaceTemplateReconciler.SetupWithManager(mgr); err != nil {
+		return nil, fmt.Errorf("unable to create WorkspaceTemplate controller: %w", err)
+	}
+
 	// Setup Snapshot controller with operator for registry operations
 	snapshotOperator := snapshot.NewDefaultSnapshotOperator(logger.With(zap.String("component", "snapshot-operator")))
 	snapshotReconciler := &snapshot.SnapshotReconciler{
@@ -0,0 +1,233 @@
+package workmachine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/kloudlite/kloudlite/api/pkg/backup"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	"github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// WorkMachine backups
+//
+// The volumes under /var/lib/kloudlite/storage (workspace homes and environments) are backed up
+// to an S3 compatible bucket:
+// 1. The BackupReconciler creates a Backup every Spec.Backup.IntervalHours while the machine runs
+// 2. The host manager of the machine snapshots every volume and streams it to the bucket, either in
+//    full or incrementally to the previous backup
+// 3. Completed backups beyond Spec.Backup.KeepLast are deleted, along with their objects
+// 4. A BackupRestore replays the chain of streams of a volume onto a machine
+
+const (
+	// backupFinalizer removes the objects of a Backup from the bucket
+	backupFinalizer = "machines.kloudlite.io/backup-cleanup"
+
+	// backupCredentialsSecretName is the copy of the bucket credentials in the WorkMachine namespace
+	backupCredentialsSecretName = "backup-credentials"
+
+	// Keys of the bucket credentials secret
+	backupAccessKeyIDKey     = "BACKUP_S3_ACCESS_KEY_ID"
+	backupSecretAccessKeyKey = "BACKUP_S3_SECRET_ACCESS_KEY"
+)
+
+// backupEnv is the bucket configuration, backups are disabled when no bucket is set
+type backupEnv struct {
+	Endpoint string `env:"BACKUP_S3_ENDPOINT"`
+	Region   string `env:"BACKUP_S3_REGION" default:"us-east-1"`
+	Bucket   string `env:"BACKUP_S3_BUCKET"`
+	Prefix   string `env:"BACKUP_S3_PREFIX" default:"workmachine-backups"`
+
+	// CredentialsSecret holds the BACKUP_S3_ACCESS_KEY_ID and BACKUP_S3_SECRET_ACCESS_KEY keys
+	CredentialsSecret          string `env:"BACKUP_S3_CREDENTIALS_SECRET" default:"workmachine-backup-credentials"`
+	CredentialsSecretNamespace string `env:"BACKUP_S3_CREDENTIALS_NAMESPACE" default:"kloudlite"`
+}
+
+func (e backupEnv) enabled() bool {
+	return e.Bucket != ""
+}
+
+// hostManagerEnv returns the bucket env vars of the host manager, credentials come from backupCredentialsSecretName
+func (e backupEnv) hostManagerEnv() []corev1.EnvVar {
+	if !e.enabled() {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: "BACKUP_S3_ENDPOINT", Value: e.Endpoint},
+		{Name: "BACKUP_S3_REGION", Value: e.Region},
+		{Name: "BACKUP_S3_BUCKET", Value: e.Bucket},
+		{Name: "BACKUP_S3_PREFIX", Value: e.Prefix},
+	}
+}
+
+// newBackupStore creates a store with the credentials of the installation
+func newBackupStore(ctx context.Context, reader client.Reader, e backupEnv) (*backup.Store, error) {
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: e.CredentialsSecretNamespace, Name: e.CredentialsSecret}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get backup credentials secret %s/%s: %w", e.CredentialsSecretNamespace, e.CredentialsSecret, err)
+	}
+
+	return backup.NewStore(backup.StoreConfig{
+		Endpoint:        e.Endpoint,
+		Region:          e.Region,
+		Bucket:          e.Bucket,
+		Prefix:          e.Prefix,
+		AccessKeyID:     string(secret.Data[backupAccessKeyIDKey]),
+		SecretAccessKey: string(secret.Data[backupSecretAccessKeyKey]),
+	})
+}
+
+// syncBackupCredentials copies the bucket credentials into the WorkMachine namespace for the host manager
+func (r *WorkMachineReconciler) syncBackupCredentials(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) error {
+	if !r.backupEnv.enabled() {
+		return nil
+	}
+
+	source := &corev1.Secret{}
+	if err := r.Get(check.Context(), client.ObjectKey{Namespace: r.backupEnv.CredentialsSecretNamespace, Name: r.backupEnv.CredentialsSecret}, source); err != nil {
+		if apiErrors.IsNotFound(err) {
+			check.Logger().Warn("backup credentials secret not found, backups will fail", "secret", r.backupEnv.CredentialsSecret)
+			return nil
+		}
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backupCredentialsSecretName,
+			Namespace: obj.Spec.TargetNamespace,
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(check.Context(), r.Client, secret, func() error {
+		secret.Labels = fn.MapMerge(secret.Labels, map[string]string{
+			"kloudlite.io/workmachine": obj.Name,
+		})
+		if !fn.IsOwner(secret, obj) {
+			secret.SetOwnerReferences([]metav1.OwnerReference{fn.AsOwner(obj, true)})
+		}
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{
+			backupAccessKeyIDKey:     source.Data[backupAccessKeyIDKey],
+			backupSecretAccessKeyKey: source.Data[backupSecretAccessKeyKey],
+		}
+		return nil
+	})
+	return err
+}
+
+// sortBackupsNewestFirst sorts backups by creation time, newest first
+func sortBackupsNewestFirst(backups []v1.Backup) {
+	sort.SliceStable(backups, func(i, j int) bool {
+		ti, tj := backups[i].CreationTimestamp.Time, backups[j].CreationTimestamp.Time
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return backups[i].Name > backups[j].Name
+	})
+}
+
+// nextBackupAt returns when the next scheduled backup is due, backups sorted newest first
+// The zero time means a backup is due now
+func nextBackupAt(cfg *v1.BackupConfig, backups []v1.Backup) time.Time {
+	for i := range backups {
+		if backups[i].Status.State == v1.BackupStateFailed {
+			continue
+		}
+		interval := time.Duration(cfg.IntervalHours) * time.Hour
+		return backups[i].CreationTimestamp.Add(interval)
+	}
+	return time.Time{}
+}
+
+// backupInProgress reports whether a backup is waiting for, or being processed by the host manager
+func backupInProgress(backups []v1.Backup) bool {
+	for i := range backups {
+		if backups[i].DeletionTimestamp == nil && (backups[i].Status.State == "" ||
+			backups[i].Status.State == v1.BackupStatePending || backups[i].Status.State == v1.BackupStateRunning) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsFullBackup reports whether the next backup starts a new chain, backups sorted newest first
+func needsFullBackup(cfg *v1.BackupConfig, backups []v1.Backup) bool {
+	incrementals := int32(0)
+	for i := range backups {
+		if backups[i].Status.State != v1.BackupStateCompleted {
+			continue
+		}
+		if backups[i].Spec.Full {
+			return incrementals+1 >= cfg.FullEvery
+		}
+		incrementals++
+	}
+	// No full backup to be incremental to
+	return true
+}
+
+// expiredBackups returns the backups to delete, backups sorted newest first
+//
+// The keepLast newest completed backups are kept, along with the backups their incremental
+// volumes depend on. Failed backups older than the newest completed one are expired too.
+func expiredBackups(backups []v1.Backup, keepLast int32) []string {
+	byName := make(map[string]*v1.Backup, len(backups))
+	for i := range backups {
+		byName[backups[i].Name] = &backups[i]
+	}
+
+	keep := map[string]bool{}
+	var markParents func(b *v1.Backup)
+	markParents = func(b *v1.Backup) {
+		for _, vol := range b.Status.Volumes {
+			if vol.Parent == "" || keep[vol.Parent] {
+				continue
+			}
+			keep[vol.Parent] = true
+			if parent, ok := byName[vol.Parent]; ok {
+				markParents(parent)
+			}
+		}
+	}
+
+	completed := int32(0)
+	var expired []string
+	for i := range backups {
+		b := &backups[i]
+		if b.DeletionTimestamp != nil {
+			continue
+		}
+
+		switch b.Status.State {
+		case v1.BackupStateCompleted:
+			completed++
+			if completed <= keepLast {
+				keep[b.Name] = true
+				markParents(b)
+			}
+		case v1.BackupStateFailed:
+			if completed > 0 {
+				expired = append(expired, b.Name)
+			}
+		}
+	}
+
+	for i := range backups {
+		b := &backups[i]
+		if b.DeletionTimestamp == nil && b.Status.State == v1.BackupStateCompleted && !keep[b.Name] {
+			expired = append(expired, b.Name)
+		}
+	}
+
+	sort.Strings(expired)
+	return expired
+}
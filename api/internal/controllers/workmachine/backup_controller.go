@@ -0,0 +1,198 @@
+package workmachine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codingconcepts/env"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/kloudlite/kloudlite/api/pkg/backup"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// BackupReconciler schedules the backups of a WorkMachine, applies its retention policy and
+// removes the objects of deleted backups from the bucket
+//
+// Backups are taken by the host manager of the machine, see cmd/workmachine-node-manager.
+type BackupReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Logger *zap.Logger
+
+	env backupEnv
+}
+
+// Reconcile is called with the name of a WorkMachine, which may no longer exist
+func (r *BackupReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := r.Logger.With(zap.String("workMachine", req.Name))
+
+	backupList := &v1.BackupList{}
+	if err := r.List(ctx, backupList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []v1.Backup
+	for i := range backupList.Items {
+		if backupList.Items[i].Spec.WorkMachine == req.Name {
+			backups = append(backups, backupList.Items[i])
+		}
+	}
+	sortBackupsNewestFirst(backups)
+
+	// Backups outlive their WorkMachine
+	wm := &v1.WorkMachine{}
+	if err := r.Get(ctx, req.NamespacedName, wm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, r.finalizeBackups(ctx, backups, "", logger)
+	}
+
+	if err := r.finalizeBackups(ctx, backups, wm.Spec.OwnedBy, logger); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	cfg := wm.Spec.Backup
+	if cfg == nil || !cfg.Enabled || wm.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	if !r.env.enabled() {
+		logger.Debug("Backups are enabled for the WorkMachine, but no bucket is configured")
+		return reconcile.Result{}, nil
+	}
+
+	for _, name := range expiredBackups(backups, cfg.KeepLast) {
+		expired := &v1.Backup{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if err := r.Delete(ctx, expired); client.IgnoreNotFound(err) != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to delete expired backup %s: %w", name, err)
+		}
+		logger.Info("Deleted expired backup", zap.String("backup", name))
+	}
+
+	interval := time.Duration(cfg.IntervalHours) * time.Hour
+
+	// The host manager takes the backup, so only running machines are backed up
+	if wm.Status.State != v1.MachineStateRunning || backupInProgress(backups) {
+		return reconcile.Result{RequeueAfter: interval}, nil
+	}
+
+	now := time.Now()
+	if next := nextBackupAt(cfg, backups); now.Before(next) {
+		return reconcile.Result{RequeueAfter: next.Sub(now)}, nil
+	}
+
+	b := &v1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%s", wm.Name, now.UTC().Format("20060102-150405")),
+			Finalizers:  []string{backupFinalizer},
+			Annotations: map[string]string{v1.AnnotationBackupOwner: wm.Spec.OwnedBy},
+		},
+		Spec: v1.BackupSpec{
+			WorkMachine: wm.Name,
+			Full:        needsFullBackup(cfg, backups),
+			Reason:      v1.BackupReasonScheduled,
+		},
+	}
+	if err := r.Create(ctx, b); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	logger.Info("Scheduled backup", zap.String("backup", b.Name), zap.Bool("full", b.Spec.Full))
+	return reconcile.Result{RequeueAfter: interval}, nil
+}
+
+// finalizeBackups adds the cleanup finalizer and the owner of the WorkMachine to new backups, and
+// deletes the objects of deleted backups
+func (r *BackupReconciler) finalizeBackups(ctx context.Context, backups []v1.Backup, owner string, logger *zap.Logger) error {
+	var store *backup.Store
+
+	for i := range backups {
+		b := &backups[i]
+
+		if b.DeletionTimestamp == nil {
+			changed := controllerutil.AddFinalizer(b, backupFinalizer)
+			if owner != "" && b.Annotations[v1.AnnotationBackupOwner] == "" {
+				if b.Annotations == nil {
+					b.Annotations = map[string]string{}
+				}
+				b.Annotations[v1.AnnotationBackupOwner] = owner
+				changed = true
+			}
+			if changed {
+				if err := r.Update(ctx, b); err != nil {
+					return fmt.Errorf("failed to add finalizer to backup %s: %w", b.Name, err)
+				}
+			}
+			continue
+		}
+
+		if !controllerutil.ContainsFinalizer(b, backupFinalizer) {
+			continue
+		}
+
+		if len(b.Status.Volumes) > 0 && !r.env.enabled() {
+			// Without a bucket the objects cannot be deleted, the backup is released and they are left behind
+			logger.Warn("Backup bucket is not configured, leaving backup objects behind", zap.String("backup", b.Name), zap.Int("volumes", len(b.Status.Volumes)))
+		} else if len(b.Status.Volumes) > 0 {
+			if store == nil {
+				var err error
+				if store, err = newBackupStore(ctx, r.Client, r.env); err != nil {
+					return err
+				}
+			}
+
+			keys := make([]string, 0, len(b.Status.Volumes))
+			for _, vol := range b.Status.Volumes {
+				keys = append(keys, vol.ObjectKey)
+			}
+			if err := store.Delete(ctx, keys...); err != nil {
+				return fmt.Errorf("failed to delete objects of backup %s: %w", b.Name, err)
+			}
+		}
+
+		controllerutil.RemoveFinalizer(b, backupFinalizer)
+		if err := r.Update(ctx, b); err != nil {
+			return fmt.Errorf("failed to remove finalizer from backup %s: %w", b.Name, err)
+		}
+		logger.Info("Deleted backup objects", zap.String("backup", b.Name), zap.Int("volumes", len(b.Status.Volumes)))
+	}
+
+	return nil
+}
+
+// findWorkMachineForBackup maps a backup to its WorkMachine
+func (r *BackupReconciler) findWorkMachineForBackup(ctx context.Context, obj client.Object) []reconcile.Request {
+	b, ok := obj.(*v1.Backup)
+	if !ok || b.Spec.WorkMachine == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: b.Spec.WorkMachine}}}
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *BackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := env.Set(&r.env); err != nil {
+		return fmt.Errorf("failed to load backup env vars: %w", err)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("workmachine-backup").
+		// Status changes matter too, only running machines are backed up
+		For(&v1.WorkMachine{}).
+		Watches(
+			&v1.Backup{},
+			handler.EnqueueRequestsFromMapFunc(r.findWorkMachineForBackup),
+		).
+		Complete(r)
+}
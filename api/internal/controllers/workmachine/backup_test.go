@@ -0,0 +1,173 @@
+package workmachine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllers/testutil"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var backupEpoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testBackup returns a backup created hour hours after backupEpoch, incremental to parent when set
+func testBackup(name string, hour int, state v1.BackupState, parent string) v1.Backup {
+	b := v1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(backupEpoch.Add(time.Duration(hour) * time.Hour)),
+		},
+		Spec:   v1.BackupSpec{WorkMachine: "wm", Full: parent == ""},
+		Status: v1.BackupStatus{State: state},
+	}
+	if state == v1.BackupStateCompleted {
+		b.Status.Volumes = []v1.BackupVolume{{Path: "workspaces/api", Parent: parent}}
+	}
+	return b
+}
+
+// TestExpiredBackups tests the retention of completed backups and their parents
+func TestExpiredBackups(t *testing.T) {
+	tests := []struct {
+		name     string
+		backups  []v1.Backup
+		keepLast int32
+		expected []string
+	}{
+		{
+			name: "keeps the newest full backups",
+			backups: []v1.Backup{
+				testBackup("b1", 0, v1.BackupStateCompleted, ""),
+				testBackup("b2", 24, v1.BackupStateCompleted, ""),
+				testBackup("b3", 48, v1.BackupStateCompleted, ""),
+			},
+			keepLast: 2,
+			expected: []string{"b1"},
+		},
+		{
+			name: "keeps the parents of kept incremental backups",
+			backups: []v1.Backup{
+				testBackup("b1", 0, v1.BackupStateCompleted, ""),
+				testBackup("b2", 24, v1.BackupStateCompleted, "b1"),
+				testBackup("b3", 48, v1.BackupStateCompleted, "b2"),
+			},
+			keepLast: 1,
+			expected: nil,
+		},
+		{
+			name: "expires a chain once a newer full backup is kept",
+			backups: []v1.Backup{
+				testBackup("b1", 0, v1.BackupStateCompleted, ""),
+				testBackup("b2", 24, v1.BackupStateCompleted, "b1"),
+				testBackup("b3", 48, v1.BackupStateCompleted, ""),
+			},
+			keepLast: 1,
+			expected: []string{"b1", "b2"},
+		},
+		{
+			name: "expires failed backups older than the newest completed one",
+			backups: []v1.Backup{
+				testBackup("b1", 0, v1.BackupStateFailed, ""),
+				testBackup("b2", 24, v1.BackupStateCompleted, ""),
+				testBackup("b3", 48, v1.BackupStateFailed, ""),
+			},
+			keepLast: 7,
+			expected: []string{"b1"},
+		},
+		{
+			name: "keeps running backups",
+			backups: []v1.Backup{
+				testBackup("b1", 0, v1.BackupStateCompleted, ""),
+				testBackup("b2", 24, v1.BackupStateRunning, ""),
+			},
+			keepLast: 1,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortBackupsNewestFirst(tt.backups)
+			assert.Equal(t, tt.expected, expiredBackups(tt.backups, tt.keepLast))
+		})
+	}
+}
+
+// TestNeedsFullBackup tests when a new chain of backups is started
+func TestNeedsFullBackup(t *testing.T) {
+	cfg := &v1.BackupConfig{FullEvery: 3}
+
+	tests := []struct {
+		name     string
+		backups  []v1.Backup
+		expected bool
+	}{
+		{name: "no backups", backups: nil, expected: true},
+		{
+			name:     "only failed backups",
+			backups:  []v1.Backup{testBackup("b1", 0, v1.BackupStateFailed, "")},
+			expected: true,
+		},
+		{
+			name: "chain shorter than fullEvery",
+			backups: []v1.Backup{
+				testBackup("b1", 0, v1.BackupStateCompleted, ""),
+				testBackup("b2", 24, v1.BackupStateCompleted, "b1"),
+			},
+			expected: false,
+		},
+		{
+			name: "chain reached fullEvery",
+			backups: []v1.Backup{
+				testBackup("b1", 0, v1.BackupStateCompleted, ""),
+				testBackup("b2", 24, v1.BackupStateCompleted, "b1"),
+				testBackup("b3", 48, v1.BackupStateCompleted, "b2"),
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortBackupsNewestFirst(tt.backups)
+			assert.Equal(t, tt.expected, needsFullBackup(cfg, tt.backups))
+		})
+	}
+}
+
+// TestNextBackupAt tests the schedule of backups
+func TestNextBackupAt(t *testing.T) {
+	cfg := &v1.BackupConfig{IntervalHours: 24}
+
+	assert.True(t, nextBackupAt(cfg, nil).IsZero(), "first backup is due now")
+
+	backups := []v1.Backup{
+		testBackup("b1", 0, v1.BackupStateCompleted, ""),
+		testBackup("b2", 24, v1.BackupStateFailed, ""),
+	}
+	sortBackupsNewestFirst(backups)
+	assert.Equal(t, backupEpoch.Add(24*time.Hour), nextBackupAt(cfg, backups), "failed backups are retried")
+}
+
+// TestFinalizeBackupsWithoutBucket tests that deleted backups are released when no bucket is configured
+func TestFinalizeBackupsWithoutBucket(t *testing.T) {
+	ctx := context.Background()
+	deleted := metav1.Now()
+	b := testBackup("wm-1", 0, v1.BackupStateCompleted, "")
+	b.DeletionTimestamp = &deleted
+	b.Finalizers = []string{backupFinalizer}
+	c := testutil.NewFakeClient(testutil.NewTestScheme(), &b).Build()
+
+	r := &BackupReconciler{Client: c}
+	require.NoError(t, r.finalizeBackups(ctx, []v1.Backup{b}, "", zap.NewNop()))
+
+	err := c.Get(ctx, client.ObjectKey{Name: b.Name}, &v1.Backup{})
+	assert.True(t, apierrors.IsNotFound(err), "backup is deleted once its finalizer is removed")
+}
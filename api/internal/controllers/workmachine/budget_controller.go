@@ -0,0 +1,360 @@
+package workmachine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllerconfig"
+	environmentV1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cost"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/kloudlite/kloudlite/api/internal/pkg/statusutil"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// labelSnapshotEnvironment is set on snapshots of environments
+const labelSnapshotEnvironment = "snapshots.kloudlite.io/environment"
+
+// BudgetReconciler totals the monthly cost of the users of a Budget, warns when the limit is
+// approached and stops their WorkMachines once it is exceeded
+type BudgetReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Logger   *zap.Logger
+	Recorder record.EventRecorder
+	Cfg      *controllerconfig.ControllerConfig
+}
+
+// budgetTotals is the cost of a budget period, broken down per user, WorkMachine and environment
+type budgetTotals struct {
+	spent        float64
+	users        []v1.ResourceCost
+	workMachines []v1.ResourceCost
+	environments []v1.ResourceCost
+}
+
+// Reconcile computes the spending of the budget period and enforces the limit
+func (r *BudgetReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := r.Logger.With(zap.String("budget", req.Name))
+
+	budget := &v1.Budget{}
+	if err := r.Get(ctx, req.NamespacedName, budget); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if budget.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	limit, err := cost.ParsePrice(budget.Spec.MonthlyLimit)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("invalid monthly limit: %w", err)
+	}
+
+	now := time.Now()
+	period := cost.Period(now)
+
+	totals, err := r.computeTotals(ctx, budget.Spec.Users, now)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	phase, percent := budgetPhase(totals.spent, limit, budget.Spec.WarningThresholdPercent)
+
+	// A new period starts without warnings
+	status := budget.Status.DeepCopy()
+	if status.Period != period {
+		status.WarnedAt = nil
+		status.ExceededAt = nil
+	}
+
+	if phase != v1.BudgetPhaseOK && status.WarnedAt == nil {
+		status.WarnedAt = &metav1.Time{Time: now}
+		r.Recorder.Eventf(budget, corev1.EventTypeWarning, "BudgetWarning",
+			"%d%% of the monthly budget of %s %s has been spent", percent, budget.Spec.MonthlyLimit, r.Cfg.Budget.Currency)
+		logger.Info("Budget entered warning phase", zap.Int32("percent", percent))
+	}
+
+	if phase == v1.BudgetPhaseExceeded && status.ExceededAt == nil {
+		status.ExceededAt = &metav1.Time{Time: now}
+		r.Recorder.Eventf(budget, corev1.EventTypeWarning, "BudgetExceeded",
+			"The monthly budget of %s %s has been exceeded", budget.Spec.MonthlyLimit, r.Cfg.Budget.Currency)
+		logger.Info("Budget exceeded", zap.Int32("percent", percent))
+	}
+
+	stopped := status.StoppedWorkMachines
+	if status.Period != period {
+		stopped = nil
+	}
+
+	if phase == v1.BudgetPhaseExceeded && budget.Spec.StopOnExceeded {
+		stopped, err = r.stopWorkMachines(ctx, budget, stopped, logger)
+	} else {
+		err = r.releaseWorkMachines(ctx, budget)
+		stopped = nil
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	message := fmt.Sprintf("%s of %s %s spent in %s", cost.FormatAmount(totals.spent), budget.Spec.MonthlyLimit, r.Cfg.Budget.Currency, period)
+	if len(stopped) > 0 {
+		message = fmt.Sprintf("%s, %d WorkMachine(s) stopped", message, len(stopped))
+	}
+
+	if err := statusutil.UpdateStatusWithRetry(ctx, r.Client, budget, func() error {
+		budget.Status.Period = period
+		budget.Status.Currency = r.Cfg.Budget.Currency
+		budget.Status.Spent = cost.FormatAmount(totals.spent)
+		budget.Status.Percent = percent
+		budget.Status.Phase = phase
+		budget.Status.Message = message
+		budget.Status.Users = totals.users
+		budget.Status.WorkMachines = totals.workMachines
+		budget.Status.Environments = totals.environments
+		budget.Status.StoppedWorkMachines = stopped
+		budget.Status.WarnedAt = status.WarnedAt
+		budget.Status.ExceededAt = status.ExceededAt
+		budget.Status.LastUpdated = &metav1.Time{Time: now}
+		return nil
+	}, logger); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: r.Cfg.Budget.CheckInterval}, nil
+}
+
+// computeTotals prices the WorkMachines and snapshots of the users up to now
+func (r *BudgetReconciler) computeTotals(ctx context.Context, users []string, now time.Time) (*budgetTotals, error) {
+	totals := &budgetTotals{}
+	prices := storagePrices(r.Cfg)
+	perUser := make(map[string]float64, len(users))
+
+	for _, user := range users {
+		perUser[user] = 0
+
+		wms := &v1.WorkMachineList{}
+		if err := r.List(ctx, wms, client.MatchingFields{"spec.ownedBy": user}); err != nil {
+			return nil, fmt.Errorf("failed to list WorkMachines of user %s: %w", user, err)
+		}
+
+		for i := range wms.Items {
+			wm := &wms.Items[i]
+			usage := cost.Accumulate(wm, now)
+
+			hourlyPrice, err := machineHourlyPrice(ctx, r.Client, usageMachineType(wm))
+			if err != nil {
+				return nil, err
+			}
+
+			c := cost.WorkMachineCost(usage, hourlyPrice, prices)
+			perUser[user] += c
+			totals.workMachines = append(totals.workMachines, v1.ResourceCost{
+				Name:         wm.Name,
+				Owner:        user,
+				Cost:         cost.FormatAmount(c),
+				RuntimeHours: cost.FormatHours(usage.RuntimeSeconds),
+			})
+		}
+	}
+
+	// Snapshots are namespaced, environment snapshots are also reported per environment. Environment
+	// names are only unique per user, they are keyed by owner/name.
+	snapshots := &snapshotv1.SnapshotList{}
+	if err := r.List(ctx, snapshots); err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	perEnvironment := map[string]*v1.ResourceCost{}
+	envCosts := map[string]float64{}
+	for i := range snapshots.Items {
+		snap := &snapshots.Items[i]
+		if _, ok := perUser[snap.Spec.Owner]; !ok || snap.Status.CreatedAt == nil {
+			continue
+		}
+
+		c := cost.SnapshotCost(snap.Status.SizeBytes, snap.Status.CreatedAt.Time, now, prices)
+		perUser[snap.Spec.Owner] += c
+
+		envName := snap.Labels[labelSnapshotEnvironment]
+		if envName == "" {
+			continue
+		}
+		key := snap.Spec.Owner + "/" + envName
+		if _, ok := perEnvironment[key]; !ok {
+			perEnvironment[key] = &v1.ResourceCost{Name: envName, Owner: snap.Spec.Owner}
+		}
+		envCosts[key] += c
+	}
+
+	// Environments without snapshots are listed too, at no cost
+	for _, user := range users {
+		envs := &environmentV1.EnvironmentList{}
+		if err := r.List(ctx, envs, client.MatchingFields{"spec.ownedBy": user}); err != nil {
+			return nil, fmt.Errorf("failed to list environments of user %s: %w", user, err)
+		}
+		for i := range envs.Items {
+			key := user + "/" + envs.Items[i].Name
+			if _, ok := perEnvironment[key]; !ok {
+				perEnvironment[key] = &v1.ResourceCost{Name: envs.Items[i].Name, Owner: user}
+			}
+		}
+	}
+
+	for key, rc := range perEnvironment {
+		rc.Cost = cost.FormatAmount(envCosts[key])
+		totals.environments = append(totals.environments, *rc)
+	}
+
+	for _, user := range users {
+		totals.spent += perUser[user]
+		totals.users = append(totals.users, v1.ResourceCost{Name: user, Cost: cost.FormatAmount(perUser[user])})
+	}
+
+	sortResourceCosts(totals.workMachines)
+	sortResourceCosts(totals.environments)
+	return totals, nil
+}
+
+// stopWorkMachines stops the running WorkMachines of the users, and marks them as stopped by the budget
+func (r *BudgetReconciler) stopWorkMachines(ctx context.Context, budget *v1.Budget, stopped []string, logger *zap.Logger) ([]string, error) {
+	for _, user := range budget.Spec.Users {
+		wms := &v1.WorkMachineList{}
+		if err := r.List(ctx, wms, client.MatchingFields{"spec.ownedBy": user}); err != nil {
+			return stopped, fmt.Errorf("failed to list WorkMachines of user %s: %w", user, err)
+		}
+
+		for i := range wms.Items {
+			wm := &wms.Items[i]
+			if wm.DeletionTimestamp != nil || wm.Spec.State != v1.MachineStateRunning {
+				continue
+			}
+
+			wm.Spec.State = v1.MachineStateStopped
+			if wm.Annotations == nil {
+				wm.Annotations = map[string]string{}
+			}
+			wm.Annotations[v1.AnnotationBudgetStopped] = budget.Name
+			if err := r.Update(ctx, wm); err != nil {
+				return stopped, fmt.Errorf("failed to stop WorkMachine %s: %w", wm.Name, err)
+			}
+
+			r.Recorder.Eventf(budget, corev1.EventTypeWarning, "WorkMachineStopped",
+				"Stopped WorkMachine %s of %s, the monthly budget is exceeded", wm.Name, user)
+			logger.Info("Stopped WorkMachine, budget exceeded", zap.String("workMachine", wm.Name), zap.String("user", user))
+
+			if !slices.Contains(stopped, wm.Name) {
+				stopped = append(stopped, wm.Name)
+			}
+		}
+	}
+
+	sort.Strings(stopped)
+	return stopped, nil
+}
+
+// releaseWorkMachines removes the stop marker of the budget from WorkMachines, so they can be started again
+func (r *BudgetReconciler) releaseWorkMachines(ctx context.Context, budget *v1.Budget) error {
+	for _, user := range budget.Spec.Users {
+		wms := &v1.WorkMachineList{}
+		if err := r.List(ctx, wms, client.MatchingFields{"spec.ownedBy": user}); err != nil {
+			return fmt.Errorf("failed to list WorkMachines of user %s: %w", user, err)
+		}
+
+		for i := range wms.Items {
+			wm := &wms.Items[i]
+			if wm.Annotations[v1.AnnotationBudgetStopped] != budget.Name {
+				continue
+			}
+
+			patch := client.MergeFrom(wm.DeepCopy())
+			delete(wm.Annotations, v1.AnnotationBudgetStopped)
+			if err := r.Patch(ctx, wm, patch); err != nil {
+				return fmt.Errorf("failed to release WorkMachine %s: %w", wm.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// budgetPhase returns the phase of a budget and the share of the limit spent
+func budgetPhase(spent, limit float64, warningThresholdPercent int32) (v1.BudgetPhase, int32) {
+	var percent int32
+	switch {
+	case limit > 0:
+		percent = int32(spent / limit * 100)
+	case spent > 0:
+		percent = 100
+	}
+
+	switch {
+	case spent > limit || (limit == 0 && spent > 0):
+		return v1.BudgetPhaseExceeded, percent
+	case percent >= warningThresholdPercent:
+		return v1.BudgetPhaseWarning, percent
+	default:
+		return v1.BudgetPhaseOK, percent
+	}
+}
+
+func sortResourceCosts(costs []v1.ResourceCost) {
+	sort.Slice(costs, func(i, j int) bool {
+		if costs[i].Owner != costs[j].Owner {
+			return costs[i].Owner < costs[j].Owner
+		}
+		return costs[i].Name < costs[j].Name
+	})
+}
+
+// findBudgetsForWorkMachine maps a WorkMachine to the budgets of its owner
+func (r *BudgetReconciler) findBudgetsForWorkMachine(ctx context.Context, obj client.Object) []reconcile.Request {
+	wm, ok := obj.(*v1.WorkMachine)
+	if !ok {
+		return nil
+	}
+
+	budgets := &v1.BudgetList{}
+	if err := r.List(ctx, budgets); err != nil {
+		r.Logger.Warn("Failed to list budgets", zap.Error(err))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range budgets.Items {
+		if slices.Contains(budgets.Items[i].Spec.Users, wm.Spec.OwnedBy) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: budgets.Items[i].Name}})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *BudgetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.Budget{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&v1.WorkMachine{},
+			handler.EnqueueRequestsFromMapFunc(r.findBudgetsForWorkMachine),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Complete(r)
+}
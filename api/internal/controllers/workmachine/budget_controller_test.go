@@ -0,0 +1,81 @@
+package workmachine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllerconfig"
+	environmentV1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
+	"github.com/kloudlite/kloudlite/api/internal/controllers/testutil"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestBudgetPhase tests the phase of a budget for the amount spent
+func TestBudgetPhase(t *testing.T) {
+	tests := []struct {
+		name            string
+		spent           float64
+		limit           float64
+		threshold       int32
+		expectedPhase   v1.BudgetPhase
+		expectedPercent int32
+	}{
+		{name: "below threshold", spent: 50, limit: 100, threshold: 80, expectedPhase: v1.BudgetPhaseOK, expectedPercent: 50},
+		{name: "at threshold", spent: 80, limit: 100, threshold: 80, expectedPhase: v1.BudgetPhaseWarning, expectedPercent: 80},
+		{name: "at limit", spent: 100, limit: 100, threshold: 80, expectedPhase: v1.BudgetPhaseWarning, expectedPercent: 100},
+		{name: "over limit", spent: 100.01, limit: 100, threshold: 80, expectedPhase: v1.BudgetPhaseExceeded, expectedPercent: 100},
+		{name: "zero limit unused", spent: 0, limit: 0, threshold: 80, expectedPhase: v1.BudgetPhaseOK, expectedPercent: 0},
+		{name: "zero limit used", spent: 0.01, limit: 0, threshold: 80, expectedPhase: v1.BudgetPhaseExceeded, expectedPercent: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phase, percent := budgetPhase(tt.spent, tt.limit, tt.threshold)
+			assert.Equal(t, tt.expectedPhase, phase)
+			assert.Equal(t, tt.expectedPercent, percent)
+		})
+	}
+}
+
+// TestComputeTotalsSameEnvironmentName tests that same-named environments of different users are reported apart
+func TestComputeTotalsSameEnvironmentName(t *testing.T) {
+	now := time.Now()
+	created := metav1.NewTime(now.Add(-24 * time.Hour))
+	snapshot := func(namespace, owner string) *snapshotv1.Snapshot {
+		return &snapshotv1.Snapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "staging-1",
+				Namespace: namespace,
+				Labels:    map[string]string{labelSnapshotEnvironment: "staging"},
+			},
+			Spec:   snapshotv1.SnapshotSpec{Owner: owner},
+			Status: snapshotv1.SnapshotStatus{SizeBytes: 10 << 30, CreatedAt: &created},
+		}
+	}
+
+	byOwner := func(obj client.Object) []string { return []string{obj.(*v1.WorkMachine).Spec.OwnedBy} }
+	c := testutil.NewFakeClient(testutil.NewTestScheme(), snapshot("alice", "alice"), snapshot("bob", "bob")).
+		WithIndex(&v1.WorkMachine{}, "spec.ownedBy", byOwner).
+		WithIndex(&environmentV1.Environment{}, "spec.ownedBy", func(obj client.Object) []string {
+			return []string{obj.(*environmentV1.Environment).Spec.OwnedBy}
+		}).
+		Build()
+
+	cfg := &controllerconfig.ControllerConfig{}
+	cfg.Budget.SnapshotPricePerGBMonth = 0.05
+	r := &BudgetReconciler{Client: c, Cfg: cfg}
+
+	totals, err := r.computeTotals(context.Background(), []string{"alice", "bob"}, now)
+	require.NoError(t, err)
+	require.Len(t, totals.environments, 2)
+	assert.Equal(t, "alice", totals.environments[0].Owner)
+	assert.Equal(t, "bob", totals.environments[1].Owner)
+	assert.Equal(t, totals.users[0].Cost, totals.environments[0].Cost)
+	assert.Equal(t, totals.users[1].Cost, totals.environments[1].Cost)
+}
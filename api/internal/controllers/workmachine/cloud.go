@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud"
 	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
 	klerrors "github.com/kloudlite/kloudlite/api/internal/pkg/errors"
 	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
@@ -30,10 +31,28 @@ func (r *WorkMachineReconciler) setupCloudMachine(check *reconciler.Check[*v1.Wo
 		return r.createNewMachine(check, obj)
 	}
 
+	// Rename the node of a claimed warm machine before managing it
+	if obj.Status.WarmPoolClaim != nil && obj.Status.WarmPoolClaim.ReadyAt == nil {
+		return r.completeWarmClaim(check, obj)
+	}
+
 	// Fetch and cache machine status (with IP caching optimization)
 	node, nodeExists, nodeReady := r.fetchNodeState(check, obj)
 	machineInfo := r.fetchMachineStatus(check, obj, node, nodeExists, nodeReady)
 
+	// Account usage before any transition moves StartedAt/StoppedAt
+	r.accountUsage(check, obj)
+
+	// Handle spot machines reclaimed by the cloud provider
+	if r.isInterrupted(obj, machineInfo, node, nodeExists) {
+		return r.handleInterruption(check, obj)
+	}
+
+	// Hibernate machines stopped for long, restore the storage volume of hibernated machines being started
+	if result := r.handleHibernation(check, obj, machineInfo, nodeReady); !result.ShouldProceed() {
+		return result
+	}
+
 	// Handle state transitions (start/stop)
 	if result := r.handleStateTransitions(check, obj, machineInfo, node); !result.ShouldProceed() {
 		return result
@@ -51,12 +70,31 @@ func (r *WorkMachineReconciler) setupCloudMachine(check *reconciler.Check[*v1.Wo
 	r.updateNodeIPLabels(check, obj, node, nodeExists, machineInfo)
 
 	// Verify node readiness for running machines
-	return r.verifyNodeReadiness(check, obj, machineInfo, node, nodeExists, nodeReady)
+	if result := r.verifyNodeReadiness(check, obj, machineInfo, node, nodeExists, nodeReady); !result.ShouldProceed() {
+		return result
+	}
+
+	// Restore workspaces and environments saved when a spot machine was interrupted
+	return r.restoreAfterInterruption(check, obj)
 }
 
 // createNewMachine creates a new cloud machine via the cloud provider API
 func (r *WorkMachineReconciler) createNewMachine(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	claimed, err := r.claimWarmMachine(check, obj)
+	if err != nil {
+		return check.Failed(err)
+	}
+	if claimed {
+		return check.UpdateMsg("claimed warm machine").RequeueAfter(r.Cfg.WorkMachine.WarmPoolClaimCheckInterval)
+	}
+
 	mi, err := r.cloudProviderAPI.CreateMachine(check.Context(), obj)
+	if err != nil && obj.Spec.Capacity == v1.CapacitySpotWithFallback && errors.Is(err, cloud.ErrSpotCapacityUnavailable) {
+		check.Logger().Warn("spot capacity unavailable, falling back to on-demand", "error", err)
+		onDemand := obj.DeepCopy()
+		onDemand.Spec.Capacity = v1.CapacityOnDemand
+		mi, err = r.cloudProviderAPI.CreateMachine(check.Context(), onDemand)
+	}
 	if err != nil {
 		return check.Failed(err)
 	}
@@ -93,7 +131,8 @@ func (r *WorkMachineReconciler) fetchNodeState(check *reconciler.Check[*v1.WorkM
 // This optimization reduces AWS API calls when node is ready and has cached IPs
 func (r *WorkMachineReconciler) fetchMachineStatus(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine, node *corev1.Node, nodeExists bool, nodeReady bool) *v1.MachineInfo {
 	// Check if we can use cached IPs from node labels
-	canUseCache := nodeExists && nodeReady && node.Labels != nil &&
+	// A cordoned node is being stopped (byo hosts keep their node while stopped)
+	canUseCache := nodeExists && nodeReady && !node.Spec.Unschedulable && node.Labels != nil &&
 		node.Labels[NodeLabelPublicIP] != "" &&
 		node.Labels[NodeLabelPrivateIP] != ""
 
@@ -192,8 +231,11 @@ func (r *WorkMachineReconciler) stopMachineGracefully(check *reconciler.Check[*v
 	}
 
 	// Step 5: Delete the Kubernetes node object to prevent metrics-server timeouts
-	if err := r.deleteNodeObject(check, obj); err != nil {
-		return err
+	// byo hosts keep their node, it stays cordoned until the machine is started again
+	if r.env.CloudProvider != v1.BYO {
+		if err := r.deleteNodeObject(check, obj); err != nil {
+			return err
+		}
 	}
 
 	// Step 6: All pods have terminated, now safe to stop the VM
@@ -311,6 +353,12 @@ func (r *WorkMachineReconciler) handleVolumeIncrease(check *reconciler.Check[*v1
 		return check.Passed()
 	}
 
+	// Storage of byo hosts is managed on the host, the volume size is advisory
+	if r.env.CloudProvider == v1.BYO {
+		obj.Status.StorageVolumeSize = specVolume
+		return check.Passed()
+	}
+
 	check.Logger().Info("increasing storage volume size",
 		"from", obj.Status.StorageVolumeSize,
 		"to", obj.Spec.VolumeSize)
@@ -375,6 +423,10 @@ func (r *WorkMachineReconciler) verifyNodeReadiness(check *reconciler.Check[*v1.
 	// For non-running states, use cloud provider state directly
 	if machineInfo.State != v1.MachineStateRunning {
 		obj.Status.State = machineInfo.State
+		// The cloud reports a hibernated machine as stopped
+		if obj.Status.Hibernation != nil && obj.Status.Hibernation.VolumeReleased {
+			obj.Status.State = v1.MachineStateHibernated
+		}
 		return check.Passed()
 	}
 
@@ -456,6 +508,11 @@ func (r *WorkMachineReconciler) cleanupCloudMachine(check *reconciler.Check[*v1.
 		return check.Passed()
 	}
 
+	// The instance of a claimed warm machine now belongs to the claiming WorkMachine
+	if obj.Labels[v1.LabelWarmPoolClaimedBy] != "" {
+		return check.Passed()
+	}
+
 	// Step 1: Add NoExecute taint to evict pods
 	if result := r.addDeletionTaint(check, obj); !result.ShouldProceed() {
 		return result
@@ -582,6 +639,15 @@ func (r *WorkMachineReconciler) deleteCloudMachine(check *reconciler.Check[*v1.W
 		return check.Failed(fmt.Errorf("failed to delete cloud machine %s: %w", machineID, err))
 	}
 
+	if hibernation := obj.Status.Hibernation; hibernation != nil && hibernation.SnapshotID != "" {
+		if hibernation.VolumeReleased && !obj.Spec.DeleteVolumePostTermination {
+			// The snapshot holds the storage volume that was to be kept
+			check.Logger().Info("keeping hibernation snapshot of the storage volume", "machineID", machineID, "snapshotID", hibernation.SnapshotID)
+		} else if err := r.cloudProviderAPI.DeleteVolumeSnapshot(check.Context(), hibernation.SnapshotID); err != nil {
+			return check.Failed(fmt.Errorf("failed to delete hibernation snapshot %s: %w", hibernation.SnapshotID, err))
+		}
+	}
+
 	r.usageReporter.ReportEvent(check.Context(), UsageEvent{
 		EventType:    "workmachine.stopped",
 		ResourceID:   machineID,
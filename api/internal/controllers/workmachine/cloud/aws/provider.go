@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -310,8 +311,29 @@ func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.M
 		},
 	}
 
+	capacity := v1.CapacityOnDemand
+	if wm.Spec.Capacity.IsSpot() {
+		capacity = v1.CapacitySpot
+		// Persistent requests stopped on interruption keep working with the controller's stop/start flow
+		runInput.InstanceMarketOptions = &ec2types.InstanceMarketOptionsRequest{
+			MarketType: ec2types.MarketTypeSpot,
+			SpotOptions: &ec2types.SpotMarketOptions{
+				SpotInstanceType:             ec2types.SpotInstanceTypePersistent,
+				InstanceInterruptionBehavior: ec2types.InstanceInterruptionBehaviorStop,
+			},
+		}
+		// The node manager polls the interruption notice from a pod, one hop away from the host
+		runInput.MetadataOptions = &ec2types.InstanceMetadataOptionsRequest{
+			HttpEndpoint:            ec2types.InstanceMetadataEndpointStateEnabled,
+			HttpPutResponseHopLimit: fn.Ptr[int32](2),
+		}
+	}
+
 	runOutput, err := p.ec2Client.RunInstances(ctx, runInput)
 	if err != nil {
+		if capacity == v1.CapacitySpot && isSpotCapacityError(err) {
+			return nil, errors.Wrap("failed to create AWS spot instance", cloud.ErrSpotCapacityUnavailable, err)
+		}
 		return nil, errors.Wrap("failed to create AWS instance", err)
 	}
 
@@ -330,6 +352,7 @@ func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.M
 		Message:           "Instance created successfully",
 		Region:            p.Region,
 		StorageVolumeSize: *wm.Spec.VolumeSize,
+		Capacity:          capacity,
 	}, nil
 }
 
@@ -343,9 +366,14 @@ func (p *provider) GetMachineStatus(ctx context.Context, machineID string) (*v1.
 		return nil, err
 	}
 
+	state := mapEC2StateToMachineState(instance.State)
+	if isSpotInterruption(instance) {
+		state = v1.MachineStateInterrupted
+	}
+
 	return &v1.MachineInfo{
 		MachineID:        aws.ToString(instance.InstanceId),
-		State:            mapEC2StateToMachineState(instance.State),
+		State:            state,
 		PrivateIP:        aws.ToString(instance.PrivateIpAddress),
 		PublicIP:         aws.ToString(instance.PublicIpAddress),
 		AvailabilityZone: aws.ToString(instance.Placement.AvailabilityZone),
@@ -398,6 +426,15 @@ func (p *provider) DeleteMachine(ctx context.Context, machineID string) error {
 		return fmt.Errorf("must provide machineID, got (%s)", machineID)
 	}
 
+	// A persistent spot request relaunches terminated instances, cancel it first
+	if instance, err := p.getMachine(ctx, machineID); err == nil && instance.SpotInstanceRequestId != nil {
+		if _, err := p.ec2Client.CancelSpotInstanceRequests(ctx, &ec2.CancelSpotInstanceRequestsInput{
+			SpotInstanceRequestIds: []string{*instance.SpotInstanceRequestId},
+		}); err != nil {
+			return fmt.Errorf("failed to cancel spot request of machine: %w", err)
+		}
+	}
+
 	if _, err := p.ec2Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
 		InstanceIds: []string{machineID},
 	}); err != nil {
@@ -459,6 +496,247 @@ func (p *provider) ChangeMachine(ctx context.Context, machineID string, newInsta
 	return nil
 }
 
+func (p *provider) SnapshotVolume(ctx context.Context, machineID string) (string, error) {
+	if machineID == "" {
+		return "", errors.New("must provide machineID")
+	}
+
+	instance, err := p.getMachine(ctx, machineID)
+	if err != nil {
+		return "", err
+	}
+
+	volume, err := p.getStorageVolume(ctx, instance)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := p.ec2Client.CreateSnapshot(ctx, &ec2.CreateSnapshotInput{
+		VolumeId:    volume.VolumeId,
+		Description: fn.Ptr(fmt.Sprintf("kloudlite hibernation of %s", machineID)),
+		TagSpecifications: []ec2types.TagSpecification{
+			{ResourceType: ec2types.ResourceTypeSnapshot, Tags: volume.Tags},
+		},
+	})
+	if err != nil {
+		return "", errors.Wrap(fmt.Sprintf("failed to snapshot storage volume (ID: %s)", *volume.VolumeId), err)
+	}
+
+	return aws.ToString(output.SnapshotId), nil
+}
+
+func (p *provider) GetVolumeSnapshotProgress(ctx context.Context, snapshotID string) (int32, error) {
+	snapshot, err := p.getSnapshot(ctx, snapshotID)
+	if err != nil {
+		return 0, err
+	}
+
+	switch snapshot.State {
+	case ec2types.SnapshotStateCompleted:
+		return 100, nil
+	case ec2types.SnapshotStateError:
+		return 0, errors.New(fmt.Sprintf("snapshot (ID: %s) failed: %s", snapshotID, aws.ToString(snapshot.StateMessage)))
+	}
+
+	// Progress is reported as a percentage string, e.g. "45%"
+	progress, _ := strconv.Atoi(strings.TrimSuffix(aws.ToString(snapshot.Progress), "%"))
+	return int32(min(progress, 99)), nil
+}
+
+// Tags marking the volumes of a release or restore in progress, found again by the next call once
+// they are no longer in the block device mapping of the instance (detached) or not yet (created)
+const (
+	releasedFromTag = "kloudlite.io/released-from"
+	restoredForTag  = "kloudlite.io/restored-for"
+)
+
+func (p *provider) ReleaseVolume(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return errors.New("must provide machineID")
+	}
+
+	instance, err := p.getMachine(ctx, machineID)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range instance.BlockDeviceMappings {
+		if fn.ValueOf(m.DeviceName) != storageDeviceName || m.Ebs == nil {
+			continue
+		}
+		volumeID := aws.ToString(m.Ebs.VolumeId)
+		if m.Ebs.Status == ec2types.AttachmentStatusDetaching {
+			return fmt.Errorf("storage volume (ID: %s) is detaching: %w", volumeID, cloud.ErrVolumeOperationPending)
+		}
+
+		if _, err := p.ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: []string{volumeID},
+			Tags:      []ec2types.Tag{{Key: fn.Ptr(releasedFromTag), Value: &machineID}},
+		}); err != nil {
+			return errors.Wrap(fmt.Sprintf("failed to tag storage volume (ID: %s)", volumeID), err)
+		}
+		if _, err := p.ec2Client.DetachVolume(ctx, &ec2.DetachVolumeInput{
+			VolumeId:   &volumeID,
+			InstanceId: &machineID,
+		}); err != nil {
+			return errors.Wrap(fmt.Sprintf("failed to detach storage volume (ID: %s)", volumeID), err)
+		}
+		return fmt.Errorf("detaching storage volume (ID: %s): %w", volumeID, cloud.ErrVolumeOperationPending)
+	}
+
+	volumes, err := p.findVolumes(ctx, releasedFromTag, machineID)
+	if err != nil {
+		return err
+	}
+	for _, volume := range volumes {
+		switch volume.State {
+		case ec2types.VolumeStateDeleting, ec2types.VolumeStateDeleted:
+			continue
+		case ec2types.VolumeStateAvailable:
+		default:
+			return fmt.Errorf("storage volume (ID: %s) is %s: %w", aws.ToString(volume.VolumeId), volume.State, cloud.ErrVolumeOperationPending)
+		}
+		if _, err := p.ec2Client.DeleteVolume(ctx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId}); err != nil {
+			return errors.Wrap(fmt.Sprintf("failed to delete storage volume (ID: %s)", aws.ToString(volume.VolumeId)), err)
+		}
+	}
+
+	return nil
+}
+
+func (p *provider) RestoreVolume(ctx context.Context, wm *v1.WorkMachine, snapshotID string) error {
+	machineID := wm.Status.MachineID
+	if machineID == "" || snapshotID == "" {
+		return errors.New("must provide machineID and snapshotID")
+	}
+
+	instance, err := p.getMachine(ctx, machineID)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range instance.BlockDeviceMappings {
+		if fn.ValueOf(m.DeviceName) != storageDeviceName || m.Ebs == nil {
+			continue
+		}
+		if m.Ebs.Status != ec2types.AttachmentStatusAttached {
+			return fmt.Errorf("storage volume (ID: %s) is %s: %w", aws.ToString(m.Ebs.VolumeId), m.Ebs.Status, cloud.ErrVolumeOperationPending)
+		}
+		// Attached volumes are kept on termination unless told otherwise
+		return p.setStorageVolumeDeletion(ctx, machineID, wm.Spec.DeleteVolumePostTermination)
+	}
+
+	volumes, err := p.findVolumes(ctx, restoredForTag, machineID)
+	if err != nil {
+		return err
+	}
+	for _, volume := range volumes {
+		if aws.ToString(volume.SnapshotId) != snapshotID {
+			continue
+		}
+		if volume.State != ec2types.VolumeStateAvailable {
+			return fmt.Errorf("storage volume (ID: %s) is %s: %w", aws.ToString(volume.VolumeId), volume.State, cloud.ErrVolumeOperationPending)
+		}
+		if _, err := p.ec2Client.AttachVolume(ctx, &ec2.AttachVolumeInput{
+			Device:     fn.Ptr(storageDeviceName),
+			InstanceId: &machineID,
+			VolumeId:   volume.VolumeId,
+		}); err != nil {
+			return errors.Wrap(fmt.Sprintf("failed to attach storage volume (ID: %s)", aws.ToString(volume.VolumeId)), err)
+		}
+		return fmt.Errorf("attaching storage volume (ID: %s): %w", aws.ToString(volume.VolumeId), cloud.ErrVolumeOperationPending)
+	}
+
+	snapshot, err := p.getSnapshot(ctx, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	volumeType := ec2types.VolumeType(wm.Spec.VolumeType)
+	if volumeType == "" {
+		volumeType = ec2types.VolumeTypeGp3
+	}
+
+	output, err := p.ec2Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+		AvailabilityZone: instance.Placement.AvailabilityZone,
+		SnapshotId:       &snapshotID,
+		VolumeType:       volumeType,
+		TagSpecifications: []ec2types.TagSpecification{
+			{
+				ResourceType: ec2types.ResourceTypeVolume,
+				Tags:         append(snapshot.Tags, ec2types.Tag{Key: fn.Ptr(restoredForTag), Value: &machineID}),
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(fmt.Sprintf("failed to create storage volume from snapshot (ID: %s)", snapshotID), err)
+	}
+	return fmt.Errorf("creating storage volume (ID: %s): %w", aws.ToString(output.VolumeId), cloud.ErrVolumeOperationPending)
+}
+
+// findVolumes returns the volumes with a tag
+func (p *provider) findVolumes(ctx context.Context, key, value string) ([]ec2types.Volume, error) {
+	output, err := p.ec2Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		Filters: []ec2types.Filter{{Name: fn.Ptr("tag:" + key), Values: []string{value}}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(fmt.Sprintf("failed to list volumes tagged %s=%s", key, value), err)
+	}
+	return output.Volumes, nil
+}
+
+func (p *provider) SetVolumeDeletion(ctx context.Context, wm *v1.WorkMachine) error {
+	if wm.Status.MachineID == "" {
+		return errors.New("must provide machineID")
+	}
+	return p.setStorageVolumeDeletion(ctx, wm.Status.MachineID, wm.Spec.DeleteVolumePostTermination)
+}
+
+// setStorageVolumeDeletion sets whether the storage volume is deleted on termination of the instance
+func (p *provider) setStorageVolumeDeletion(ctx context.Context, machineID string, deleteOnTermination bool) error {
+	if _, err := p.ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+		InstanceId: &machineID,
+		BlockDeviceMappings: []ec2types.InstanceBlockDeviceMappingSpecification{
+			{
+				DeviceName: fn.Ptr(storageDeviceName),
+				Ebs:        &ec2types.EbsInstanceBlockDeviceSpecification{DeleteOnTermination: &deleteOnTermination},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap("failed to set storage volume deletion on termination", err)
+	}
+	return nil
+}
+
+func (p *provider) DeleteVolumeSnapshot(ctx context.Context, snapshotID string) error {
+	if snapshotID == "" {
+		return errors.New("must provide snapshotID")
+	}
+
+	if _, err := p.ec2Client.DeleteSnapshot(ctx, &ec2.DeleteSnapshotInput{SnapshotId: &snapshotID}); err != nil {
+		if strings.Contains(err.Error(), "InvalidSnapshot.NotFound") {
+			return nil
+		}
+		return errors.Wrap(fmt.Sprintf("failed to delete snapshot (ID: %s)", snapshotID), err)
+	}
+	return nil
+}
+
+func (p *provider) getSnapshot(ctx context.Context, snapshotID string) (*ec2types.Snapshot, error) {
+	output, err := p.ec2Client.DescribeSnapshots(ctx, &ec2.DescribeSnapshotsInput{
+		SnapshotIds: []string{snapshotID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(fmt.Sprintf("failed to get snapshot (ID: %s)", snapshotID), err)
+	}
+
+	if len(output.Snapshots) == 0 {
+		return nil, errors.New(fmt.Sprintf("snapshot (ID: %s) not found", snapshotID))
+	}
+
+	return &output.Snapshots[0], nil
+}
+
 func mapEC2StateToMachineState(state *ec2types.InstanceState) v1.MachineState {
 	if state == nil {
 		return v1.MachineStateErrored
@@ -476,3 +754,21 @@ func mapEC2StateToMachineState(state *ec2types.InstanceState) v1.MachineState {
 		return v1.MachineStateErrored
 	}
 }
+
+// isSpotCapacityError reports whether RunInstances failed for lack of spot capacity
+func isSpotCapacityError(err error) bool {
+	errStr := err.Error()
+	return strings.Contains(errStr, "InsufficientInstanceCapacity") ||
+		strings.Contains(errStr, "InsufficientCapacity") ||
+		strings.Contains(errStr, "SpotMaxPriceTooLow") ||
+		strings.Contains(errStr, "MaxSpotInstanceCountExceeded")
+}
+
+// isSpotInterruption reports whether a spot instance was stopped or terminated by EC2 reclaiming capacity
+func isSpotInterruption(instance *ec2types.Instance) bool {
+	if instance.InstanceLifecycle != ec2types.InstanceLifecycleTypeSpot || instance.StateReason == nil {
+		return false
+	}
+	code := aws.ToString(instance.StateReason.Code)
+	return code == "Server.SpotInstanceShutdown" || code == "Server.SpotInstanceTermination"
+}
@@ -5,8 +5,13 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
@@ -23,8 +28,10 @@ type provider struct {
 	credential             *azidentity.DefaultAzureCredential
 	vmClient               *armcompute.VirtualMachinesClient
 	diskClient             *armcompute.DisksClient
+	snapshotClient         *armcompute.SnapshotsClient
 	nicClient              *armnetwork.InterfacesClient
 	publicIPClient         *armnetwork.PublicIPAddressesClient
+	armClient              *arm.Client
 	subscriptionID         string
 	resourceGroup          string
 	location               string
@@ -157,6 +164,12 @@ func NewProvider(ctx context.Context, args ProviderArgs) (cloud.Provider, error)
 		return nil, errors.Wrap("failed to create disk client", err)
 	}
 
+	// Create Snapshot client
+	snapshotClient, err := armcompute.NewSnapshotsClient(args.SubscriptionID, cred, nil)
+	if err != nil {
+		return nil, errors.Wrap("failed to create snapshot client", err)
+	}
+
 	// Create NIC client
 	nicClient, err := armnetwork.NewInterfacesClient(args.SubscriptionID, cred, nil)
 	if err != nil {
@@ -169,12 +182,20 @@ func NewProvider(ctx context.Context, args ProviderArgs) (cloud.Provider, error)
 		return nil, errors.Wrap("failed to create public IP client", err)
 	}
 
+	// Generic ARM client for the activity log, which has no client in the compute and network SDKs
+	armClient, err := arm.NewClient("kloudlite.workmachine.azure", "v1.0.0", cred, nil)
+	if err != nil {
+		return nil, errors.Wrap("failed to create ARM client", err)
+	}
+
 	return &provider{
 		credential:             cred,
 		vmClient:               vmClient,
 		diskClient:             diskClient,
+		snapshotClient:         snapshotClient,
 		nicClient:              nicClient,
 		publicIPClient:         publicIPClient,
+		armClient:              armClient,
 		subscriptionID:         args.SubscriptionID,
 		resourceGroup:          args.ResourceGroup,
 		location:               args.Location,
@@ -404,15 +425,7 @@ func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.M
 	}
 
 	// Determine disk type (map from AWS to Azure)
-	osDiskType := armcompute.StorageAccountTypesPremiumLRS
-	switch wm.Spec.VolumeType {
-	case "gp3", "gp2":
-		osDiskType = armcompute.StorageAccountTypesPremiumLRS
-	case "io1", "io2":
-		osDiskType = armcompute.StorageAccountTypesUltraSSDLRS
-	case "standard":
-		osDiskType = armcompute.StorageAccountTypesStandardLRS
-	}
+	osDiskType := getStorageAccountType(wm.Spec.VolumeType)
 
 	// Convert Kubernetes-friendly machine type name (e.g., "Standard_B2ms" or "standard-b2ms") to Azure VM size
 	azureVMSize := strings.ReplaceAll(wm.Spec.MachineType, "-", "_")
@@ -421,80 +434,101 @@ func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.M
 		azureVMSize = "Standard_" + azureVMSize
 	}
 
-	// Create VM
-	vmResp, err := p.vmClient.BeginCreateOrUpdate(ctx, p.resourceGroup, vmName, armcompute.VirtualMachine{
-		Location: to.Ptr(p.location),
-		Tags:     tags,
-		Properties: &armcompute.VirtualMachineProperties{
-			HardwareProfile: &armcompute.HardwareProfile{
-				VMSize: to.Ptr(armcompute.VirtualMachineSizeTypes(azureVMSize)),
+	vmProperties := &armcompute.VirtualMachineProperties{
+		HardwareProfile: &armcompute.HardwareProfile{
+			VMSize: to.Ptr(armcompute.VirtualMachineSizeTypes(azureVMSize)),
+		},
+		StorageProfile: &armcompute.StorageProfile{
+			ImageReference: &armcompute.ImageReference{
+				Publisher: to.Ptr(imageRef.Publisher),
+				Offer:     to.Ptr(imageRef.Offer),
+				SKU:       to.Ptr(imageRef.SKU),
+				Version:   to.Ptr(imageRef.Version),
 			},
-			StorageProfile: &armcompute.StorageProfile{
-				ImageReference: &armcompute.ImageReference{
-					Publisher: to.Ptr(imageRef.Publisher),
-					Offer:     to.Ptr(imageRef.Offer),
-					SKU:       to.Ptr(imageRef.SKU),
-					Version:   to.Ptr(imageRef.Version),
+			OSDisk: &armcompute.OSDisk{
+				Name:         to.Ptr(vmName + "-osdisk"),
+				CreateOption: to.Ptr(armcompute.DiskCreateOptionTypesFromImage),
+				Caching:      to.Ptr(armcompute.CachingTypesReadWrite),
+				ManagedDisk: &armcompute.ManagedDiskParameters{
+					StorageAccountType: to.Ptr(osDiskType),
 				},
-				OSDisk: &armcompute.OSDisk{
-					Name:         to.Ptr(vmName + "-osdisk"),
-					CreateOption: to.Ptr(armcompute.DiskCreateOptionTypesFromImage),
-					Caching:      to.Ptr(armcompute.CachingTypesReadWrite),
+				DiskSizeGB:   to.Ptr(int32(50)), // Fixed OS disk size
+				DeleteOption: to.Ptr(armcompute.DiskDeleteOptionTypesDelete),
+			},
+			DataDisks: []*armcompute.DataDisk{
+				{
+					Name:         to.Ptr(vmName + "-datadisk"),
+					Lun:          to.Ptr(int32(0)),
+					DiskSizeGB:   to.Ptr(volumeSize), // User-specified storage size
+					CreateOption: to.Ptr(armcompute.DiskCreateOptionTypesEmpty),
 					ManagedDisk: &armcompute.ManagedDiskParameters{
 						StorageAccountType: to.Ptr(osDiskType),
 					},
-					DiskSizeGB:   to.Ptr(int32(50)), // Fixed OS disk size
+					Caching:      to.Ptr(armcompute.CachingTypesNone),
 					DeleteOption: to.Ptr(armcompute.DiskDeleteOptionTypesDelete),
 				},
-				DataDisks: []*armcompute.DataDisk{
-					{
-						Name:         to.Ptr(vmName + "-datadisk"),
-						Lun:          to.Ptr(int32(0)),
-						DiskSizeGB:   to.Ptr(volumeSize), // User-specified storage size
-						CreateOption: to.Ptr(armcompute.DiskCreateOptionTypesEmpty),
-						ManagedDisk: &armcompute.ManagedDiskParameters{
-							StorageAccountType: to.Ptr(osDiskType),
-						},
-						Caching:      to.Ptr(armcompute.CachingTypesNone),
-						DeleteOption: to.Ptr(armcompute.DiskDeleteOptionTypesDelete),
-					},
-				},
 			},
-			OSProfile: &armcompute.OSProfile{
-				ComputerName:  to.Ptr(vmName),
-				AdminUsername: to.Ptr("kloudlite"),
-				LinuxConfiguration: &armcompute.LinuxConfiguration{
-					DisablePasswordAuthentication: to.Ptr(true),
-					SSH: &armcompute.SSHConfiguration{
-						PublicKeys: []*armcompute.SSHPublicKey{
-							{
-								Path:    to.Ptr("/home/kloudlite/.ssh/authorized_keys"),
-								KeyData: to.Ptr(generateDummySSHKey()),
-							},
+		},
+		OSProfile: &armcompute.OSProfile{
+			ComputerName:  to.Ptr(vmName),
+			AdminUsername: to.Ptr("kloudlite"),
+			LinuxConfiguration: &armcompute.LinuxConfiguration{
+				DisablePasswordAuthentication: to.Ptr(true),
+				SSH: &armcompute.SSHConfiguration{
+					PublicKeys: []*armcompute.SSHPublicKey{
+						{
+							Path:    to.Ptr("/home/kloudlite/.ssh/authorized_keys"),
+							KeyData: to.Ptr(generateDummySSHKey()),
 						},
 					},
 				},
-				CustomData: to.Ptr(base64.StdEncoding.EncodeToString(userData)),
 			},
-			NetworkProfile: &armcompute.NetworkProfile{
-				NetworkInterfaces: []*armcompute.NetworkInterfaceReference{
-					{
-						ID: nic.ID,
-						Properties: &armcompute.NetworkInterfaceReferenceProperties{
-							Primary:      to.Ptr(true),
-							DeleteOption: to.Ptr(armcompute.DeleteOptionsDelete),
-						},
+			CustomData: to.Ptr(base64.StdEncoding.EncodeToString(userData)),
+		},
+		NetworkProfile: &armcompute.NetworkProfile{
+			NetworkInterfaces: []*armcompute.NetworkInterfaceReference{
+				{
+					ID: nic.ID,
+					Properties: &armcompute.NetworkInterfaceReferenceProperties{
+						Primary:      to.Ptr(true),
+						DeleteOption: to.Ptr(armcompute.DeleteOptionsDelete),
 					},
 				},
 			},
 		},
+	}
+
+	capacity := v1.CapacityOnDemand
+	if wm.Spec.Capacity.IsSpot() {
+		capacity = v1.CapacitySpot
+		// Evicted spot VMs are deallocated (disks kept), MaxPrice -1 caps the price at on-demand
+		vmProperties.Priority = to.Ptr(armcompute.VirtualMachinePriorityTypesSpot)
+		vmProperties.EvictionPolicy = to.Ptr(armcompute.VirtualMachineEvictionPolicyTypesDeallocate)
+		vmProperties.BillingProfile = &armcompute.BillingProfile{MaxPrice: to.Ptr(float64(-1))}
+	}
+
+	// Create VM
+	vmResp, err := p.vmClient.BeginCreateOrUpdate(ctx, p.resourceGroup, vmName, armcompute.VirtualMachine{
+		Location:   to.Ptr(p.location),
+		Tags:       tags,
+		Properties: vmProperties,
 	}, nil)
 	if err != nil {
+		if capacity == v1.CapacitySpot && isSpotCapacityError(err) {
+			return nil, errors.Wrap("failed to create Azure spot VM", cloud.ErrSpotCapacityUnavailable, err)
+		}
 		return nil, errors.Wrap("failed to create Azure VM", err)
 	}
 
 	vm, err := vmResp.PollUntilDone(ctx, nil)
 	if err != nil {
+		if capacity == v1.CapacitySpot && isSpotCapacityError(err) {
+			// The failed spot VM cannot be converted to a regular VM, remove it so it can be created again
+			if delErr := p.DeleteMachine(ctx, fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/virtualMachines/%s", p.subscriptionID, p.resourceGroup, vmName)); delErr != nil {
+				slog.Warn("[Azure Provider] failed to remove spot VM after allocation failure", "vm", vmName, "error", delErr)
+			}
+			return nil, errors.Wrap("failed to allocate Azure spot VM", cloud.ErrSpotCapacityUnavailable, err)
+		}
 		return nil, errors.Wrap("failed to wait for VM creation", err)
 	}
 
@@ -520,6 +554,7 @@ func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.M
 		Message:           "Instance created successfully",
 		Region:            p.location,
 		StorageVolumeSize: volumeSize,
+		Capacity:          capacity,
 	}, nil
 }
 
@@ -536,9 +571,21 @@ func (p *provider) GetMachineStatus(ctx context.Context, machineID string) (*v1.
 	// Get IP addresses
 	privateIP, publicIP := p.getVMIPAddresses(ctx, vm)
 
+	state := mapAzureStateToMachineState(vm)
+	if state == v1.MachineStateStopped && vm.Properties != nil &&
+		fn.ValueOf(vm.Properties.Priority) == armcompute.VirtualMachinePriorityTypesSpot {
+		evicted, err := p.wasEvicted(ctx, fn.ValueOf(vm.ID))
+		if err != nil {
+			return nil, err
+		}
+		if evicted {
+			state = v1.MachineStateInterrupted
+		}
+	}
+
 	return &v1.MachineInfo{
 		MachineID:        fn.ValueOf(vm.ID),
-		State:            mapAzureStateToMachineState(vm),
+		State:            state,
 		PrivateIP:        privateIP,
 		PublicIP:         publicIP,
 		AvailabilityZone: fn.ValueOf(vm.Location),
@@ -547,6 +594,67 @@ func (p *provider) GetMachineStatus(ctx context.Context, machineID string) (*v1.
 	}, nil
 }
 
+const (
+	activityLogEvictOperation = "Microsoft.Compute/virtualMachines/evictSpotVM/action"
+	activityLogStartOperation = "Microsoft.Compute/virtualMachines/start/action"
+
+	// activityLogWindow bounds the activity log query, evictions older than this are not detected
+	activityLogWindow = 7 * 24 * time.Hour
+)
+
+type activityLogEvents struct {
+	Value []struct {
+		EventTimestamp time.Time `json:"eventTimestamp"`
+		OperationName  struct {
+			Value string `json:"value"`
+		} `json:"operationName"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+// wasEvicted reports whether Azure evicted the spot VM since it was last started, from the
+// evictSpotVM operation Azure records in the activity log when it reclaims spot capacity
+func (p *provider) wasEvicted(ctx context.Context, vmID string) (bool, error) {
+	query := url.Values{}
+	query.Set("api-version", "2015-04-01")
+	query.Set("$filter", fmt.Sprintf("eventTimestamp ge '%s' and resourceUri eq '%s'",
+		time.Now().Add(-activityLogWindow).UTC().Format(time.RFC3339), vmID))
+	query.Set("$select", "eventTimestamp,operationName")
+	next := fmt.Sprintf("%s/subscriptions/%s/providers/Microsoft.Insights/eventtypes/management/values?%s",
+		p.armClient.Endpoint(), url.PathEscape(p.subscriptionID), query.Encode())
+
+	var lastEviction, lastStart time.Time
+	for next != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, next)
+		if err != nil {
+			return false, err
+		}
+		resp, err := p.armClient.Pipeline().Do(req)
+		if err != nil {
+			return false, errors.Wrap("failed to query activity log", err)
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return false, errors.Wrap("failed to query activity log", runtime.NewResponseError(resp))
+		}
+
+		var events activityLogEvents
+		if err := runtime.UnmarshalAsJSON(resp, &events); err != nil {
+			return false, errors.Wrap("failed to parse activity log", err)
+		}
+		for _, event := range events.Value {
+			switch {
+			case strings.EqualFold(event.OperationName.Value, activityLogEvictOperation) && event.EventTimestamp.After(lastEviction):
+				lastEviction = event.EventTimestamp
+			case strings.EqualFold(event.OperationName.Value, activityLogStartOperation) && event.EventTimestamp.After(lastStart):
+				lastStart = event.EventTimestamp
+			}
+		}
+		next = events.NextLink
+	}
+
+	return !lastEviction.IsZero() && lastEviction.After(lastStart), nil
+}
+
 func (p *provider) getVMIPAddresses(ctx context.Context, vm *armcompute.VirtualMachine) (privateIP, publicIP string) {
 	if vm.Properties == nil || vm.Properties.NetworkProfile == nil {
 		return "", ""
@@ -740,6 +848,237 @@ func (p *provider) ChangeMachine(ctx context.Context, machineID string, newInsta
 	return nil
 }
 
+// SnapshotVolume creates an incremental snapshot of the data disk that holds the btrfs storage
+func (p *provider) SnapshotVolume(ctx context.Context, machineID string) (string, error) {
+	if machineID == "" {
+		return "", errors.New("must provide machineID")
+	}
+
+	vmName := extractVMNameFromID(machineID)
+	disk, err := p.diskClient.Get(ctx, p.resourceGroup, vmName+"-datadisk", nil)
+	if err != nil {
+		return "", errors.Wrap(fmt.Sprintf("failed to get data disk of VM %s", vmName), err)
+	}
+
+	snapshotName := fmt.Sprintf("%s-hibernate-%d", vmName, time.Now().Unix())
+	// The snapshot is polled with GetVolumeSnapshotProgress, the poller is not awaited
+	if _, err := p.snapshotClient.BeginCreateOrUpdate(ctx, p.resourceGroup, snapshotName, armcompute.Snapshot{
+		Location: to.Ptr(p.location),
+		Tags:     disk.Tags,
+		Properties: &armcompute.SnapshotProperties{
+			CreationData: &armcompute.CreationData{
+				CreateOption:     to.Ptr(armcompute.DiskCreateOptionCopy),
+				SourceResourceID: disk.ID,
+			},
+			Incremental: to.Ptr(true),
+		},
+	}, nil); err != nil {
+		return "", errors.Wrap("failed to snapshot data disk", err)
+	}
+
+	return snapshotName, nil
+}
+
+func (p *provider) GetVolumeSnapshotProgress(ctx context.Context, snapshotID string) (int32, error) {
+	resp, err := p.snapshotClient.Get(ctx, p.resourceGroup, snapshotID, nil)
+	if err != nil {
+		return 0, errors.Wrap(fmt.Sprintf("failed to get snapshot %s", snapshotID), err)
+	}
+
+	if resp.Properties == nil {
+		return 0, nil
+	}
+
+	switch fn.ValueOf(resp.Properties.ProvisioningState) {
+	case "Failed", "Canceled":
+		return 0, errors.New(fmt.Sprintf("snapshot %s provisioning %s", snapshotID, fn.ValueOf(resp.Properties.ProvisioningState)))
+	case "Succeeded":
+		// Incremental snapshots are copied in the background after provisioning
+		if resp.Properties.CompletionPercent == nil {
+			return 100, nil
+		}
+	}
+
+	progress := int32(fn.ValueOf(resp.Properties.CompletionPercent))
+	if progress >= 100 && fn.ValueOf(resp.Properties.ProvisioningState) != "Succeeded" {
+		progress = 99
+	}
+	return progress, nil
+}
+
+func (p *provider) ReleaseVolume(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return errors.New("must provide machineID")
+	}
+
+	vm, err := p.getVMByID(ctx, machineID)
+	if err != nil {
+		return err
+	}
+
+	vmName := extractVMNameFromID(machineID)
+	diskName := vmName + "-datadisk"
+
+	if vm.Properties != nil && vm.Properties.StorageProfile != nil {
+		dataDisks := make([]*armcompute.DataDisk, 0, len(vm.Properties.StorageProfile.DataDisks))
+		for _, dd := range vm.Properties.StorageProfile.DataDisks {
+			if fn.ValueOf(dd.Name) != diskName {
+				dataDisks = append(dataDisks, dd)
+			}
+		}
+
+		if len(dataDisks) != len(vm.Properties.StorageProfile.DataDisks) {
+			poller, err := p.vmClient.BeginUpdate(ctx, p.resourceGroup, vmName, armcompute.VirtualMachineUpdate{
+				Properties: &armcompute.VirtualMachineProperties{
+					StorageProfile: &armcompute.StorageProfile{
+						DataDisks: dataDisks,
+					},
+				},
+			}, nil)
+			if err != nil {
+				return errors.Wrap("failed to detach data disk", err)
+			}
+			if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+				return errors.Wrap("failed to wait for data disk detach", err)
+			}
+		}
+	}
+
+	poller, err := p.diskClient.BeginDelete(ctx, p.resourceGroup, diskName, nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "ResourceNotFound") || strings.Contains(err.Error(), "NotFound") {
+			return nil
+		}
+		return errors.Wrap(fmt.Sprintf("failed to delete data disk %s", diskName), err)
+	}
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return errors.Wrap("failed to wait for data disk deletion", err)
+	}
+
+	return nil
+}
+
+func (p *provider) RestoreVolume(ctx context.Context, wm *v1.WorkMachine, snapshotID string) error {
+	machineID := wm.Status.MachineID
+	if machineID == "" || snapshotID == "" {
+		return errors.New("must provide machineID and snapshotID")
+	}
+
+	vm, err := p.getVMByID(ctx, machineID)
+	if err != nil {
+		return err
+	}
+
+	vmName := extractVMNameFromID(machineID)
+	diskName := vmName + "-datadisk"
+
+	var dataDisks []*armcompute.DataDisk
+	if vm.Properties != nil && vm.Properties.StorageProfile != nil {
+		dataDisks = vm.Properties.StorageProfile.DataDisks
+	}
+	for _, dd := range dataDisks {
+		if fn.ValueOf(dd.Name) == diskName {
+			return nil
+		}
+	}
+
+	snapshot, err := p.snapshotClient.Get(ctx, p.resourceGroup, snapshotID, nil)
+	if err != nil {
+		return errors.Wrap(fmt.Sprintf("failed to get snapshot %s", snapshotID), err)
+	}
+
+	diskPoller, err := p.diskClient.BeginCreateOrUpdate(ctx, p.resourceGroup, diskName, armcompute.Disk{
+		Location: to.Ptr(p.location),
+		Tags:     snapshot.Tags,
+		SKU: &armcompute.DiskSKU{
+			Name: to.Ptr(armcompute.DiskStorageAccountTypes(getStorageAccountType(wm.Spec.VolumeType))),
+		},
+		Properties: &armcompute.DiskProperties{
+			CreationData: &armcompute.CreationData{
+				CreateOption:     to.Ptr(armcompute.DiskCreateOptionCopy),
+				SourceResourceID: snapshot.ID,
+			},
+		},
+	}, nil)
+	if err != nil {
+		return errors.Wrap(fmt.Sprintf("failed to create data disk from snapshot %s", snapshotID), err)
+	}
+	disk, err := diskPoller.PollUntilDone(ctx, nil)
+	if err != nil {
+		return errors.Wrap("failed to wait for data disk creation", err)
+	}
+
+	poller, err := p.vmClient.BeginUpdate(ctx, p.resourceGroup, vmName, armcompute.VirtualMachineUpdate{
+		Properties: &armcompute.VirtualMachineProperties{
+			StorageProfile: &armcompute.StorageProfile{
+				DataDisks: append(dataDisks, &armcompute.DataDisk{
+					Name:         to.Ptr(diskName),
+					Lun:          to.Ptr(int32(0)),
+					CreateOption: to.Ptr(armcompute.DiskCreateOptionTypesAttach),
+					ManagedDisk: &armcompute.ManagedDiskParameters{
+						ID: disk.ID,
+					},
+					Caching:      to.Ptr(armcompute.CachingTypesNone),
+					DeleteOption: to.Ptr(armcompute.DiskDeleteOptionTypesDelete),
+				}),
+			},
+		},
+	}, nil)
+	if err != nil {
+		return errors.Wrap("failed to attach data disk", err)
+	}
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return errors.Wrap("failed to wait for data disk attach", err)
+	}
+
+	return nil
+}
+
+// The disks of Azure VMs are always deleted with the VM, spec.deleteVolumePostTermination is not supported
+func (p *provider) SetVolumeDeletion(ctx context.Context, wm *v1.WorkMachine) error {
+	return nil
+}
+
+func (p *provider) DeleteVolumeSnapshot(ctx context.Context, snapshotID string) error {
+	if snapshotID == "" {
+		return errors.New("must provide snapshotID")
+	}
+
+	poller, err := p.snapshotClient.BeginDelete(ctx, p.resourceGroup, snapshotID, nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "ResourceNotFound") || strings.Contains(err.Error(), "NotFound") {
+			return nil
+		}
+		return errors.Wrap(fmt.Sprintf("failed to delete snapshot %s", snapshotID), err)
+	}
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return errors.Wrap("failed to wait for snapshot deletion", err)
+	}
+
+	return nil
+}
+
+// getStorageAccountType maps the AWS style volume type of the spec to an Azure disk type
+func getStorageAccountType(volumeType string) armcompute.StorageAccountTypes {
+	switch volumeType {
+	case "io1", "io2":
+		return armcompute.StorageAccountTypesUltraSSDLRS
+	case "standard":
+		return armcompute.StorageAccountTypesStandardLRS
+	default:
+		return armcompute.StorageAccountTypesPremiumLRS
+	}
+}
+
+// isSpotCapacityError reports whether VM creation failed for lack of spot capacity or quota
+func isSpotCapacityError(err error) bool {
+	errStr := err.Error()
+	return strings.Contains(errStr, "SkuNotAvailable") ||
+		strings.Contains(errStr, "AllocationFailed") ||
+		strings.Contains(errStr, "OverconstrainedAllocationRequest") ||
+		strings.Contains(errStr, "LowPriorityCores")
+}
+
 func mapAzureStateToMachineState(vm *armcompute.VirtualMachine) v1.MachineState {
 	powerState := getVMPowerState(vm)
 
@@ -0,0 +1,549 @@
+// Package byo implements a WorkMachine provider for hosts registered by the admin
+// (bare-metal or on-prem servers) instead of instances created through a cloud API.
+//
+// A host joins the cluster by running a generated join script (the k3s agent setup also
+// used for cloud machines). Starting and stopping a machine cordons/drains its node, with
+// optional Wake-on-LAN and IPMI hooks to power the host on and off. Machine status comes from
+// Node readiness, and machine types are advisory limits instead of instance SKUs.
+//
+// The controller pod is not on the network of the hosts, so Wake-on-LAN packets are sent by a
+// pod on another node of that network, and hosts woken this way are powered off by a pod on the
+// host itself. Hosts without IPMI or Wake-on-LAN keep running while stopped, with their node
+// cordoned.
+package byo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os/exec"
+	"syscall"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud"
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/templates"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/kloudlite/kloudlite/api/internal/pkg/errors"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// JoinScriptKey is the key of the join script in the host secret
+	JoinScriptKey = "join.sh"
+
+	// AnnotationPowerState records whether the host should be running or stopped
+	AnnotationPowerState = "byo.machines.kloudlite.io/power-state"
+
+	// AnnotationWakeOnLANMAC is the MAC address used to wake the host with a Wake-on-LAN magic packet
+	AnnotationWakeOnLANMAC = "byo.machines.kloudlite.io/wake-on-lan-mac"
+
+	// AnnotationWakeOnLANAddress is the UDP address the magic packet is sent to (default 255.255.255.255:9)
+	AnnotationWakeOnLANAddress = "byo.machines.kloudlite.io/wake-on-lan-address"
+
+	// AnnotationWakeOnLANNode is the node sending the magic packet, on the same network as the host
+	AnnotationWakeOnLANNode = "byo.machines.kloudlite.io/wake-on-lan-node"
+
+	// AnnotationIPMIHost is the address of the host's BMC
+	AnnotationIPMIHost = "byo.machines.kloudlite.io/ipmi-host"
+
+	// AnnotationIPMISecret names a secret (in the provider namespace) with the BMC username and password
+	AnnotationIPMISecret = "byo.machines.kloudlite.io/ipmi-secret"
+
+	defaultWakeOnLANAddress = "255.255.255.255:9"
+
+	// powerPodLabel marks the pods sending Wake-on-LAN packets and powering off hosts
+	powerPodLabel = "byo.machines.kloudlite.io/power"
+)
+
+type provider struct {
+	client client.Client
+	ProviderArgs
+}
+
+var _ cloud.Provider = (*provider)(nil)
+
+type ProviderArgs struct {
+	// Namespace holds the per-host secrets with join scripts and IPMI credentials
+	Namespace string
+
+	// HostManagerImage runs the pods powering hosts on and off
+	HostManagerImage string
+
+	K3sVersion      string
+	K3sURL          string
+	K3sToken        string
+	HostedSubdomain string
+}
+
+func NewProvider(ctx context.Context, k8sClient client.Client, args ProviderArgs) (cloud.Provider, error) {
+	if args.Namespace == "" {
+		return nil, errors.New("must provide namespace for byo host secrets")
+	}
+	return &provider{client: k8sClient, ProviderArgs: args}, nil
+}
+
+// HostSecretName returns the name of the secret holding the join script of a host
+func HostSecretName(machineID string) string {
+	return "byo-host-" + machineID
+}
+
+// JoinCommand returns the command the admin runs to print the join script of a host
+func JoinCommand(namespace, machineID string) string {
+	return fmt.Sprintf(`kubectl get secret -n %s %s -o jsonpath='{.data.join\.sh}' | base64 -d | sudo bash`, namespace, HostSecretName(machineID))
+}
+
+func (p *provider) ValidatePermissions(ctx context.Context) error {
+	// Hosts are managed through the Kubernetes API only, covered by the controller RBAC
+	slog.Info("[BYO Provider] Permission checks passed")
+	return nil
+}
+
+// CreateMachine registers a host by generating its join script
+// The machine ID is the WorkMachine name, which is also the node name the host joins as.
+func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.MachineInfo, error) {
+	joinScript, err := templates.K3sAgentSetupBYO.Render(templates.K3sAgentSetupArgs{
+		K3sVersion:      p.K3sVersion,
+		K3sURL:          p.K3sURL,
+		K3sAgentToken:   p.K3sToken,
+		MachineName:     wm.Name,
+		MachineOwner:    fn.LabelValueEncoder(wm.Spec.OwnedBy),
+		HostedSubdomain: p.HostedSubdomain,
+	})
+	if err != nil {
+		return nil, errors.Wrap("failed to render k3s join script", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      HostSecretName(wm.Name),
+			Namespace: p.Namespace,
+			Labels: map[string]string{
+				"kloudlite.io/workmachine": wm.Name,
+				"kloudlite.io/managed-by":  "kloudlite-controller",
+			},
+			Annotations: map[string]string{
+				AnnotationPowerState: string(v1.MachineStateRunning),
+			},
+		},
+		Data: map[string][]byte{
+			JoinScriptKey: joinScript,
+		},
+	}
+	if err := p.client.Create(ctx, secret); err != nil && !apiErrors.IsAlreadyExists(err) {
+		return nil, errors.Wrap("failed to create byo host secret", err)
+	}
+
+	return &v1.MachineInfo{
+		MachineID: wm.Name,
+		State:     v1.MachineStateStarting,
+		Message:   "Waiting for host to join, run on the host: " + JoinCommand(p.Namespace, wm.Name),
+		// The storage volume lives on the host, its size is only advisory
+		StorageVolumeSize: fn.ValueOf(wm.Spec.VolumeSize),
+	}, nil
+}
+
+// GetMachineStatus derives the machine state from the power state and Node readiness
+func (p *provider) GetMachineStatus(ctx context.Context, machineID string) (*v1.MachineInfo, error) {
+	if machineID == "" {
+		return nil, errors.New("must provide machineID")
+	}
+
+	secret, err := p.getHostSecret(ctx, machineID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &v1.MachineInfo{MachineID: machineID}
+
+	node := &corev1.Node{}
+	nodeExists := true
+	if err := p.client.Get(ctx, client.ObjectKey{Name: machineID}, node); err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return nil, errors.Wrap(fmt.Sprintf("failed to get node %s", machineID), err)
+		}
+		nodeExists = false
+	}
+	if nodeExists {
+		info.PrivateIP, info.PublicIP = nodeAddresses(node)
+	}
+
+	switch {
+	case secret.Annotations[AnnotationPowerState] == string(v1.MachineStateStopped):
+		// Without a power hook the host keeps running, its node stays cordoned
+		info.State = v1.MachineStateStopped
+		info.Message = "Host is stopped"
+		if nodeExists && isNodeReady(node) {
+			info.Message = "Host is stopped, its node is cordoned but the host is still powered on"
+		}
+	case !nodeExists:
+		info.State = v1.MachineStateStarting
+		info.Message = "Waiting for host to join, run on the host: " + JoinCommand(p.Namespace, machineID)
+	case isNodeReady(node):
+		info.State = v1.MachineStateRunning
+		info.Message = "Host is ready"
+	default:
+		info.State = v1.MachineStateStarting
+		info.Message = "Waiting for host node to be ready"
+	}
+
+	return info, nil
+}
+
+// StartMachine marks the host as running and powers it on through the configured hooks
+// The controller uncordons the node once it is ready.
+func (p *provider) StartMachine(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return fmt.Errorf("must provide machineID, got (%s)", machineID)
+	}
+
+	if err := p.setPowerState(ctx, machineID, v1.MachineStateRunning); err != nil {
+		return err
+	}
+
+	wm, err := p.getWorkMachine(ctx, machineID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.wakeOnLAN(ctx, wm); err != nil {
+		return err
+	}
+
+	return p.ipmiPower(ctx, wm, "on")
+}
+
+// StopMachine marks the host as stopped and powers it off through IPMI or, for hosts woken with
+// Wake-on-LAN, from the host itself. Hosts without either keep running with their node cordoned.
+// The controller has already cordoned and drained the node.
+func (p *provider) StopMachine(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return fmt.Errorf("must provide machineID, got (%s)", machineID)
+	}
+
+	if err := p.setPowerState(ctx, machineID, v1.MachineStateStopped); err != nil {
+		return err
+	}
+
+	wm, err := p.getWorkMachine(ctx, machineID)
+	if err != nil {
+		return err
+	}
+	if wm.Annotations[AnnotationIPMIHost] != "" {
+		return p.ipmiPower(ctx, wm, "soft")
+	}
+	if wm.Annotations[AnnotationWakeOnLANMAC] != "" {
+		return p.powerOffFromHost(ctx, wm)
+	}
+
+	slog.Info("[BYO Provider] host has no power hooks, it keeps running with its node cordoned", "machine", machineID)
+	return nil
+}
+
+// RebootMachine power cycles the host through IPMI, if configured
+func (p *provider) RebootMachine(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return fmt.Errorf("must provide machineID, got (%s)", machineID)
+	}
+
+	wm, err := p.getWorkMachine(ctx, machineID)
+	if err != nil {
+		return err
+	}
+	if wm.Annotations[AnnotationIPMIHost] == "" {
+		return fmt.Errorf("host %s has no IPMI configured, reboot it manually", machineID)
+	}
+	return p.ipmiPower(ctx, wm, "cycle")
+}
+
+func (p *provider) IncreaseVolumeSize(ctx context.Context, machineID string, newSize int32) error {
+	return fmt.Errorf("storage of byo host %s is managed on the host, grow /var/lib/kloudlite/storage there", machineID)
+}
+
+// ChangeMachine is a no-op: machine types are advisory limits for byo hosts, not instance SKUs
+func (p *provider) ChangeMachine(ctx context.Context, machineID string, newInstanceType string) error {
+	if machineID == "" || newInstanceType == "" {
+		return errors.New("must provide machineID and newInstanceType")
+	}
+	slog.Info("[BYO Provider] machine type is advisory, host is unchanged", "machine", machineID, "machineType", newInstanceType)
+	return nil
+}
+
+// DeleteMachine removes the host secret; the controller has already removed the node
+// The k3s agent is left on the host for the admin to uninstall (k3s-agent-uninstall.sh).
+func (p *provider) DeleteMachine(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return fmt.Errorf("must provide machineID, got (%s)", machineID)
+	}
+
+	if err := p.deletePowerPods(ctx, machineID); err != nil {
+		return err
+	}
+	if err := p.client.Delete(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: HostSecretName(machineID), Namespace: p.Namespace},
+	}); err != nil && !apiErrors.IsNotFound(err) {
+		return errors.Wrap("failed to delete byo host secret", err)
+	}
+	return nil
+}
+
+// Storage of byo hosts stays on the host, it cannot be released while the host is stopped
+
+func (p *provider) SnapshotVolume(ctx context.Context, machineID string) (string, error) {
+	return "", cloud.ErrHibernationNotSupported
+}
+
+func (p *provider) GetVolumeSnapshotProgress(ctx context.Context, snapshotID string) (int32, error) {
+	return 0, cloud.ErrHibernationNotSupported
+}
+
+func (p *provider) ReleaseVolume(ctx context.Context, machineID string) error {
+	return cloud.ErrHibernationNotSupported
+}
+
+func (p *provider) RestoreVolume(ctx context.Context, wm *v1.WorkMachine, snapshotID string) error {
+	return cloud.ErrHibernationNotSupported
+}
+
+// Storage of byo hosts stays on the host, warm pools are not supported
+func (p *provider) SetVolumeDeletion(ctx context.Context, wm *v1.WorkMachine) error {
+	return nil
+}
+
+func (p *provider) DeleteVolumeSnapshot(ctx context.Context, snapshotID string) error {
+	return cloud.ErrHibernationNotSupported
+}
+
+// Helper functions
+
+func (p *provider) getHostSecret(ctx context.Context, machineID string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := p.client.Get(ctx, client.ObjectKey{Namespace: p.Namespace, Name: HostSecretName(machineID)}, secret); err != nil {
+		return nil, errors.Wrap(fmt.Sprintf("failed to get byo host secret for %s", machineID), err)
+	}
+	return secret, nil
+}
+
+func (p *provider) setPowerState(ctx context.Context, machineID string, state v1.MachineState) error {
+	secret, err := p.getHostSecret(ctx, machineID)
+	if err != nil {
+		return err
+	}
+	if secret.Annotations[AnnotationPowerState] == string(state) {
+		return nil
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[AnnotationPowerState] = string(state)
+	if err := p.client.Update(ctx, secret); err != nil {
+		return errors.Wrap("failed to update byo host power state", err)
+	}
+	return nil
+}
+
+func (p *provider) getWorkMachine(ctx context.Context, machineID string) (*v1.WorkMachine, error) {
+	wm := &v1.WorkMachine{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: machineID}, wm); err != nil {
+		return nil, errors.Wrap(fmt.Sprintf("failed to get workmachine %s", machineID), err)
+	}
+	return wm, nil
+}
+
+// ipmiPower runs an ipmitool chassis power action if the host has IPMI configured
+func (p *provider) ipmiPower(ctx context.Context, wm *v1.WorkMachine, action string) error {
+	host := wm.Annotations[AnnotationIPMIHost]
+	if host == "" {
+		return nil
+	}
+
+	secretName := wm.Annotations[AnnotationIPMISecret]
+	if secretName == "" {
+		return fmt.Errorf("annotation %s is required with %s", AnnotationIPMISecret, AnnotationIPMIHost)
+	}
+	creds := &corev1.Secret{}
+	if err := p.client.Get(ctx, client.ObjectKey{Namespace: p.Namespace, Name: secretName}, creds); err != nil {
+		return errors.Wrap(fmt.Sprintf("failed to get IPMI credentials secret %s", secretName), err)
+	}
+
+	ipmitool, err := exec.LookPath("ipmitool")
+	if err != nil {
+		return fmt.Errorf("ipmitool is required for %s but is not installed in the controller image: %w", AnnotationIPMIHost, err)
+	}
+
+	cmd := exec.CommandContext(ctx, ipmitool, "-I", "lanplus",
+		"-H", host,
+		"-U", string(creds.Data["username"]),
+		"-E",
+		"chassis", "power", action,
+	)
+	// -E reads the password from the environment, keeping it out of the process list
+	cmd.Env = append(cmd.Environ(), "IPMI_PASSWORD="+string(creds.Data["password"]))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrap(fmt.Sprintf("ipmitool power %s failed: %s", action, out), err)
+	}
+
+	slog.Info("[BYO Provider] ipmi power action", "machine", wm.Name, "action", action)
+	return nil
+}
+
+// wakeOnLAN sends a magic packet to the host, if it has Wake-on-LAN configured, from a pod on the
+// node named by AnnotationWakeOnLANNode
+func (p *provider) wakeOnLAN(ctx context.Context, wm *v1.WorkMachine) error {
+	mac := wm.Annotations[AnnotationWakeOnLANMAC]
+	if mac == "" {
+		return nil
+	}
+	if _, err := net.ParseMAC(mac); err != nil {
+		return errors.Wrap(fmt.Sprintf("invalid annotation %s", AnnotationWakeOnLANMAC), err)
+	}
+
+	nodeName := wm.Annotations[AnnotationWakeOnLANNode]
+	if nodeName == "" {
+		return fmt.Errorf("annotation %s is required with %s, the packet must be sent from the network of the host", AnnotationWakeOnLANNode, AnnotationWakeOnLANMAC)
+	}
+	addr := wm.Annotations[AnnotationWakeOnLANAddress]
+	if addr == "" {
+		addr = defaultWakeOnLANAddress
+	}
+
+	pod := p.powerPod(wm.Name, "wake", nodeName, []string{"/usr/local/bin/workmachine-node-manager", "wake-on-lan", mac, addr})
+	// Broadcasts only reach the network of the node, not the pod network
+	pod.Spec.HostNetwork = true
+	if err := p.runPowerPod(ctx, pod); err != nil {
+		return errors.Wrap("failed to send wake-on-lan packet", err)
+	}
+	slog.Info("[BYO Provider] sending wake-on-lan packet", "machine", wm.Name, "mac", mac, "node", nodeName)
+	return nil
+}
+
+// powerOffFromHost powers off the host with a pod on its own node, once the pod has completed
+func (p *provider) powerOffFromHost(ctx context.Context, wm *v1.WorkMachine) error {
+	pod := p.powerPod(wm.Name, "poweroff", wm.Name, []string{
+		"nsenter", "-t", "1", "-m", "-u", "-i", "-n", "-p", "--",
+		"systemd-run", "--on-active=5", "systemctl", "poweroff",
+	})
+	pod.Spec.HostPID = true
+	pod.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{Privileged: fn.Ptr(true)}
+	if err := p.runPowerPod(ctx, pod); err != nil {
+		return errors.Wrap("failed to power off host", err)
+	}
+	slog.Info("[BYO Provider] powering off host", "machine", wm.Name)
+	return nil
+}
+
+// powerPod returns a pod running command on a node, tolerating the taints of WorkMachines
+// including the one of a cordoned node
+func (p *provider) powerPod(machineID, action, nodeName string, command []string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("byo-%s-%s-", action, machineID),
+			Namespace:    p.Namespace,
+			Labels: map[string]string{
+				"kloudlite.io/workmachine": machineID,
+				"kloudlite.io/managed-by":  "kloudlite-controller",
+				powerPodLabel:              action,
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      nodeName,
+			RestartPolicy: corev1.RestartPolicyNever,
+			Tolerations:   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Containers: []corev1.Container{
+				{
+					Name:    action,
+					Image:   p.HostManagerImage,
+					Command: command,
+				},
+			},
+		},
+	}
+}
+
+// runPowerPod creates a power pod, replacing the pods of earlier power actions of the machine
+func (p *provider) runPowerPod(ctx context.Context, pod *corev1.Pod) error {
+	if p.HostManagerImage == "" {
+		return errors.New("the host manager image is not configured")
+	}
+	if err := p.deletePowerPods(ctx, pod.Labels["kloudlite.io/workmachine"]); err != nil {
+		return err
+	}
+	return p.client.Create(ctx, pod)
+}
+
+func (p *provider) deletePowerPods(ctx context.Context, machineID string) error {
+	if err := p.client.DeleteAllOf(ctx, &corev1.Pod{},
+		client.InNamespace(p.Namespace),
+		client.MatchingLabels{"kloudlite.io/workmachine": machineID},
+		client.HasLabels{powerPodLabel},
+	); err != nil && !apiErrors.IsNotFound(err) {
+		return errors.Wrap("failed to delete byo power pods", err)
+	}
+	return nil
+}
+
+// SendWakeOnLAN sends a magic packet (6 x 0xFF followed by 16 x the MAC address) to addr
+// It is run by the host manager, see cmd/workmachine-node-manager.
+func SendWakeOnLAN(mac, addr string) error {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return err
+	}
+
+	packet := make([]byte, 0, 102)
+	for range 6 {
+		packet = append(packet, 0xff)
+	}
+	for range 16 {
+		packet = append(packet, hw...)
+	}
+
+	// Broadcast addresses are only reachable with SO_BROADCAST set on the socket
+	dialer := net.Dialer{Control: func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		if err := c.Control(func(fd uintptr) {
+			sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_BROADCAST, 1)
+		}); err != nil {
+			return err
+		}
+		return sockErr
+	}}
+	conn, err := dialer.Dial("udp4", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write(packet)
+	return err
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// nodeAddresses returns the internal and external IP of a node
+// Hosts without a separate external address use the internal one for both.
+func nodeAddresses(node *corev1.Node) (string, string) {
+	var internalIP, externalIP string
+	for _, addr := range node.Status.Addresses {
+		switch addr.Type {
+		case corev1.NodeInternalIP:
+			internalIP = addr.Address
+		case corev1.NodeExternalIP:
+			externalIP = addr.Address
+		}
+	}
+	if externalIP == "" {
+		externalIP = internalIP
+	}
+	return internalIP, externalIP
+}
@@ -0,0 +1,140 @@
+package byo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllers/testutil"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestMachineLifecycle(t *testing.T) {
+	ctx := context.Background()
+	wm := &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "rack1-node3"},
+		Spec:       v1.WorkMachineSpec{OwnedBy: "alice", VolumeSize: testutil.Int32Ptr(500)},
+	}
+	c := testutil.NewFakeClient(testutil.NewTestScheme(), wm).Build()
+
+	p, err := NewProvider(ctx, c, ProviderArgs{Namespace: "kloudlite", K3sURL: "https://k3s:6443", K3sToken: "token"})
+	require.NoError(t, err)
+
+	info, err := p.CreateMachine(ctx, wm)
+	require.NoError(t, err)
+	assert.Equal(t, "rack1-node3", info.MachineID)
+	assert.Equal(t, v1.MachineStateStarting, info.State)
+	assert.Contains(t, info.Message, "byo-host-rack1-node3")
+
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "kloudlite", Name: HostSecretName(wm.Name)}, secret))
+	assert.Contains(t, string(secret.Data[JoinScriptKey]), "--node-name=rack1-node3")
+
+	// Host joined and is ready
+	require.NoError(t, c.Create(ctx, &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: wm.Name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.10.13"}},
+		},
+	}))
+	info, err = p.GetMachineStatus(ctx, wm.Name)
+	require.NoError(t, err)
+	assert.Equal(t, v1.MachineStateRunning, info.State)
+	assert.Equal(t, "10.0.10.13", info.PrivateIP)
+	assert.Equal(t, "10.0.10.13", info.PublicIP)
+
+	// Without power hooks the node stays ready, but the machine reports stopped
+	require.NoError(t, p.StopMachine(ctx, wm.Name))
+	info, err = p.GetMachineStatus(ctx, wm.Name)
+	require.NoError(t, err)
+	assert.Equal(t, v1.MachineStateStopped, info.State)
+
+	require.NoError(t, p.StartMachine(ctx, wm.Name))
+	info, err = p.GetMachineStatus(ctx, wm.Name)
+	require.NoError(t, err)
+	assert.Equal(t, v1.MachineStateRunning, info.State)
+
+	require.NoError(t, p.DeleteMachine(ctx, wm.Name))
+	_, err = p.GetMachineStatus(ctx, wm.Name)
+	assert.Error(t, err)
+}
+
+func TestIPMIPowerWithoutIpmitool(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	ctx := context.Background()
+	wm := &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rack1-node3",
+			Annotations: map[string]string{
+				AnnotationIPMIHost:   "10.0.20.13",
+				AnnotationIPMISecret: "rack1-bmc",
+			},
+		},
+	}
+	creds := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kloudlite", Name: "rack1-bmc"},
+		Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("secret")},
+	}
+	c := testutil.NewFakeClient(testutil.NewTestScheme(), wm, creds).Build()
+
+	p, err := NewProvider(ctx, c, ProviderArgs{Namespace: "kloudlite", K3sURL: "https://k3s:6443", K3sToken: "token"})
+	require.NoError(t, err)
+
+	err = p.(*provider).ipmiPower(ctx, wm, "on")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ipmitool is required")
+}
+
+func TestWakeOnLANPowerPods(t *testing.T) {
+	ctx := context.Background()
+	wm := &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "rack1-node3",
+			Annotations: map[string]string{AnnotationWakeOnLANMAC: "3c:ec:ef:12:34:56"},
+		},
+	}
+	c := testutil.NewFakeClient(testutil.NewTestScheme(), wm).Build()
+
+	p, err := NewProvider(ctx, c, ProviderArgs{Namespace: "kloudlite", HostManagerImage: "host-manager", K3sURL: "https://k3s:6443", K3sToken: "token"})
+	require.NoError(t, err)
+	_, err = p.CreateMachine(ctx, wm)
+	require.NoError(t, err)
+
+	// The packet cannot be sent from the controller pod, a node on the network of the host is required
+	err = p.StartMachine(ctx, wm.Name)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), AnnotationWakeOnLANNode)
+
+	wm.Annotations[AnnotationWakeOnLANNode] = "rack1-node1"
+	require.NoError(t, c.Update(ctx, wm))
+
+	powerPods := func() []corev1.Pod {
+		pods := &corev1.PodList{}
+		require.NoError(t, c.List(ctx, pods, client.InNamespace("kloudlite"), client.HasLabels{powerPodLabel}))
+		return pods.Items
+	}
+
+	require.NoError(t, p.StopMachine(ctx, wm.Name))
+	pods := powerPods()
+	require.Len(t, pods, 1)
+	assert.Equal(t, "poweroff", pods[0].Labels[powerPodLabel])
+	assert.Equal(t, wm.Name, pods[0].Spec.NodeName)
+	assert.True(t, pods[0].Spec.HostPID)
+
+	require.NoError(t, p.StartMachine(ctx, wm.Name))
+	pods = powerPods()
+	require.Len(t, pods, 1, "the pod of the previous power action is replaced")
+	assert.Equal(t, "wake", pods[0].Labels[powerPodLabel])
+	assert.Equal(t, "rack1-node1", pods[0].Spec.NodeName)
+	assert.True(t, pods[0].Spec.HostNetwork)
+	assert.Equal(t, []string{"/usr/local/bin/workmachine-node-manager", "wake-on-lan", "3c:ec:ef:12:34:56", defaultWakeOnLANAddress}, pods[0].Spec.Containers[0].Command)
+
+	require.NoError(t, p.DeleteMachine(ctx, wm.Name))
+	assert.Empty(t, powerPods())
+}
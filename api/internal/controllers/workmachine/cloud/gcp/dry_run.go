@@ -97,3 +97,22 @@ func (p *provider) checkListDisks(ctx context.Context) error {
 
 	return nil
 }
+
+// checkListZoneOperations validates compute.zoneOperations.list permission, used to detect spot preemptions
+func (p *provider) checkListZoneOperations(ctx context.Context) error {
+	req := &computepb.ListZoneOperationsRequest{
+		Project:    p.Project,
+		Zone:       p.Zone,
+		MaxResults: fn.Ptr(uint32(1)),
+	}
+
+	it := p.operationsClient.List(ctx, req)
+	_, err := it.Next()
+
+	// iterator.Done is not an error, just means empty list
+	if err != nil && !strings.Contains(err.Error(), "iterator done") {
+		return handlePermissionError(err, "compute.zoneOperations.list")
+	}
+
+	return nil
+}
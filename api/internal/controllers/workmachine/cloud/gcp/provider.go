@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	compute "cloud.google.com/go/compute/apiv1"
 	"cloud.google.com/go/compute/apiv1/computepb"
@@ -14,11 +15,14 @@ import (
 	"github.com/kloudlite/kloudlite/api/internal/pkg/errors"
 	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/iterator"
 )
 
 type provider struct {
-	instancesClient *compute.InstancesClient
-	disksClient     *compute.DisksClient
+	instancesClient  *compute.InstancesClient
+	disksClient      *compute.DisksClient
+	snapshotsClient  *compute.SnapshotsClient
+	operationsClient *compute.ZoneOperationsClient
 
 	ProviderArgs
 }
@@ -61,10 +65,27 @@ func NewProvider(ctx context.Context, args ProviderArgs) (cloud.Provider, error)
 		return nil, errors.Wrap("failed to create GCP disks client", err)
 	}
 
+	snapshotsClient, err := compute.NewSnapshotsRESTClient(ctx)
+	if err != nil {
+		instancesClient.Close()
+		disksClient.Close()
+		return nil, errors.Wrap("failed to create GCP snapshots client", err)
+	}
+
+	operationsClient, err := compute.NewZoneOperationsRESTClient(ctx)
+	if err != nil {
+		instancesClient.Close()
+		disksClient.Close()
+		snapshotsClient.Close()
+		return nil, errors.Wrap("failed to create GCP zone operations client", err)
+	}
+
 	return &provider{
-		instancesClient: instancesClient,
-		disksClient:     disksClient,
-		ProviderArgs:    args,
+		instancesClient:  instancesClient,
+		disksClient:      disksClient,
+		snapshotsClient:  snapshotsClient,
+		operationsClient: operationsClient,
+		ProviderArgs:     args,
 	}, nil
 }
 
@@ -73,6 +94,7 @@ func (p *provider) ValidatePermissions(ctx context.Context) error {
 	permissionChecks := []func(context.Context) error{
 		p.checkListInstances,
 		p.checkListDisks,
+		p.checkListZoneOperations,
 	}
 
 	for i := range permissionChecks {
@@ -172,21 +194,35 @@ func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.M
 		},
 	}
 
+	capacity := v1.CapacityOnDemand
+	if wm.Spec.Capacity.IsSpot() {
+		capacity = v1.CapacitySpot
+		// Spot VMs are stopped (not deleted) on preemption and cannot live migrate
+		instance.Scheduling = &computepb.Scheduling{
+			ProvisioningModel:         fn.Ptr("SPOT"),
+			InstanceTerminationAction: fn.Ptr("STOP"),
+			AutomaticRestart:          fn.Ptr(false),
+			OnHostMaintenance:         fn.Ptr("TERMINATE"),
+		}
+	}
+
 	// Create the instance
 	op, err := p.instancesClient.Insert(ctx, &computepb.InsertInstanceRequest{
 		Project:          p.Project,
 		Zone:             p.Zone,
 		InstanceResource: instance,
 	})
+	if err == nil {
+		// Wait for operation to complete
+		err = op.Wait(ctx)
+	}
 	if err != nil {
+		if capacity == v1.CapacitySpot && isSpotCapacityError(err) {
+			return nil, errors.Wrap("failed to create GCP spot instance", cloud.ErrSpotCapacityUnavailable, err)
+		}
 		return nil, errors.Wrap("failed to create GCP instance", err)
 	}
 
-	// Wait for operation to complete
-	if err := op.Wait(ctx); err != nil {
-		return nil, errors.Wrap("failed waiting for instance creation", err)
-	}
-
 	// Get the created instance details
 	createdInstance, err := p.instancesClient.Get(ctx, &computepb.GetInstanceRequest{
 		Project:  p.Project,
@@ -206,6 +242,7 @@ func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.M
 		Message:           "Instance created successfully",
 		Region:            p.Region,
 		StorageVolumeSize: *wm.Spec.VolumeSize,
+		Capacity:          capacity,
 	}, nil
 }
 
@@ -223,9 +260,20 @@ func (p *provider) GetMachineStatus(ctx context.Context, machineID string) (*v1.
 		return nil, errors.Wrap(fmt.Sprintf("failed to get instance %s", machineID), err)
 	}
 
+	state := mapGCPStateToMachineState(instance.Status)
+	if state == v1.MachineStateStopped && isSpotInstance(instance) {
+		preempted, err := p.wasPreempted(ctx, instance)
+		if err != nil {
+			return nil, err
+		}
+		if preempted {
+			state = v1.MachineStateInterrupted
+		}
+	}
+
 	return &v1.MachineInfo{
 		MachineID:        machineID,
-		State:            mapGCPStateToMachineState(instance.Status),
+		State:            state,
 		PrivateIP:        getPrivateIP(instance),
 		PublicIP:         getPublicIP(instance),
 		AvailabilityZone: p.Zone,
@@ -404,8 +452,247 @@ func (p *provider) ChangeMachine(ctx context.Context, machineID string, newInsta
 	return nil
 }
 
+// SnapshotVolume snapshots the boot disk, which also holds the btrfs storage on GCP
+func (p *provider) SnapshotVolume(ctx context.Context, machineID string) (string, error) {
+	if machineID == "" {
+		return "", errors.New("must provide machineID")
+	}
+
+	instance, err := p.instancesClient.Get(ctx, &computepb.GetInstanceRequest{
+		Project:  p.Project,
+		Zone:     p.Zone,
+		Instance: machineID,
+	})
+	if err != nil {
+		return "", errors.Wrap("failed to get instance", err)
+	}
+
+	bootDisk := getBootDisk(instance)
+	if bootDisk == nil {
+		return "", errors.New("boot disk not found")
+	}
+
+	snapshotName := fmt.Sprintf("%s-hibernate-%d", machineID, time.Now().Unix())
+	// The snapshot is polled with GetVolumeSnapshotProgress, the operation is not awaited
+	if _, err := p.disksClient.CreateSnapshot(ctx, &computepb.CreateSnapshotDiskRequest{
+		Project: p.Project,
+		Zone:    p.Zone,
+		Disk:    diskName(bootDisk),
+		SnapshotResource: &computepb.Snapshot{
+			Name:   fn.Ptr(snapshotName),
+			Labels: instance.Labels,
+		},
+	}); err != nil {
+		return "", errors.Wrap("failed to snapshot boot disk", err)
+	}
+
+	return snapshotName, nil
+}
+
+// GetVolumeSnapshotProgress maps the snapshot status to a progress, GCP reports no percentage
+func (p *provider) GetVolumeSnapshotProgress(ctx context.Context, snapshotID string) (int32, error) {
+	snapshot, err := p.snapshotsClient.Get(ctx, &computepb.GetSnapshotRequest{
+		Project:  p.Project,
+		Snapshot: snapshotID,
+	})
+	if err != nil {
+		// The snapshot resource shows up once the create operation has been accepted
+		if strings.Contains(err.Error(), "notFound") || strings.Contains(err.Error(), "404") {
+			return 0, nil
+		}
+		return 0, errors.Wrap(fmt.Sprintf("failed to get snapshot %s", snapshotID), err)
+	}
+
+	switch fn.ValueOf(snapshot.Status) {
+	case "READY":
+		return 100, nil
+	case "UPLOADING":
+		return 50, nil
+	case "FAILED", "DELETING":
+		return 0, errors.New(fmt.Sprintf("snapshot %s is %s", snapshotID, fn.ValueOf(snapshot.Status)))
+	default:
+		return 0, nil
+	}
+}
+
+func (p *provider) ReleaseVolume(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return errors.New("must provide machineID")
+	}
+
+	instance, err := p.instancesClient.Get(ctx, &computepb.GetInstanceRequest{
+		Project:  p.Project,
+		Zone:     p.Zone,
+		Instance: machineID,
+	})
+	if err != nil {
+		return errors.Wrap("failed to get instance", err)
+	}
+
+	bootDisk := getBootDisk(instance)
+	if bootDisk == nil {
+		return nil
+	}
+
+	op, err := p.instancesClient.DetachDisk(ctx, &computepb.DetachDiskInstanceRequest{
+		Project:    p.Project,
+		Zone:       p.Zone,
+		Instance:   machineID,
+		DeviceName: fn.ValueOf(bootDisk.DeviceName),
+	})
+	if err != nil {
+		return errors.Wrap("failed to detach boot disk", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return errors.Wrap("failed waiting for boot disk detach", err)
+	}
+
+	op, err = p.disksClient.Delete(ctx, &computepb.DeleteDiskRequest{
+		Project: p.Project,
+		Zone:    p.Zone,
+		Disk:    diskName(bootDisk),
+	})
+	if err != nil {
+		return errors.Wrap("failed to delete boot disk", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return errors.Wrap("failed waiting for boot disk deletion", err)
+	}
+
+	return nil
+}
+
+func (p *provider) RestoreVolume(ctx context.Context, wm *v1.WorkMachine, snapshotID string) error {
+	machineID := wm.Status.MachineID
+	if machineID == "" || snapshotID == "" {
+		return errors.New("must provide machineID and snapshotID")
+	}
+
+	instance, err := p.instancesClient.Get(ctx, &computepb.GetInstanceRequest{
+		Project:  p.Project,
+		Zone:     p.Zone,
+		Instance: machineID,
+	})
+	if err != nil {
+		return errors.Wrap("failed to get instance", err)
+	}
+
+	if getBootDisk(instance) != nil {
+		return nil
+	}
+
+	// The boot disk is named after the instance, as when it was created with the instance
+	op, err := p.disksClient.Insert(ctx, &computepb.InsertDiskRequest{
+		Project: p.Project,
+		Zone:    p.Zone,
+		DiskResource: &computepb.Disk{
+			Name:           fn.Ptr(machineID),
+			SourceSnapshot: fn.Ptr(fmt.Sprintf("projects/%s/global/snapshots/%s", p.Project, snapshotID)),
+			Type:           fn.Ptr(getDiskType(wm.Spec.VolumeType, p.Project, p.Zone)),
+			Labels:         instance.Labels,
+		},
+	})
+	if err == nil {
+		err = op.Wait(ctx)
+	}
+	if err != nil && !strings.Contains(err.Error(), "alreadyExists") {
+		return errors.Wrap(fmt.Sprintf("failed to create boot disk from snapshot %s", snapshotID), err)
+	}
+
+	op, err = p.instancesClient.AttachDisk(ctx, &computepb.AttachDiskInstanceRequest{
+		Project:  p.Project,
+		Zone:     p.Zone,
+		Instance: machineID,
+		AttachedDiskResource: &computepb.AttachedDisk{
+			Source:     fn.Ptr(fmt.Sprintf("projects/%s/zones/%s/disks/%s", p.Project, p.Zone, machineID)),
+			Boot:       fn.Ptr(true),
+			AutoDelete: fn.Ptr(wm.Spec.DeleteVolumePostTermination),
+		},
+	})
+	if err != nil {
+		return errors.Wrap("failed to attach boot disk", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return errors.Wrap("failed waiting for boot disk attach", err)
+	}
+
+	return nil
+}
+
+func (p *provider) SetVolumeDeletion(ctx context.Context, wm *v1.WorkMachine) error {
+	if wm.Status.MachineID == "" {
+		return errors.New("must provide machineID")
+	}
+
+	instance, err := p.instancesClient.Get(ctx, &computepb.GetInstanceRequest{
+		Project:  p.Project,
+		Zone:     p.Zone,
+		Instance: wm.Status.MachineID,
+	})
+	if err != nil {
+		return errors.Wrap("failed to get instance", err)
+	}
+
+	bootDisk := getBootDisk(instance)
+	if bootDisk == nil || fn.ValueOf(bootDisk.AutoDelete) == wm.Spec.DeleteVolumePostTermination {
+		return nil
+	}
+
+	op, err := p.instancesClient.SetDiskAutoDelete(ctx, &computepb.SetDiskAutoDeleteInstanceRequest{
+		Project:    p.Project,
+		Zone:       p.Zone,
+		Instance:   wm.Status.MachineID,
+		DeviceName: fn.ValueOf(bootDisk.DeviceName),
+		AutoDelete: wm.Spec.DeleteVolumePostTermination,
+	})
+	if err != nil {
+		return errors.Wrap("failed to set boot disk auto-delete", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return errors.Wrap("failed waiting for boot disk auto-delete", err)
+	}
+	return nil
+}
+
+func (p *provider) DeleteVolumeSnapshot(ctx context.Context, snapshotID string) error {
+	if snapshotID == "" {
+		return errors.New("must provide snapshotID")
+	}
+
+	op, err := p.snapshotsClient.Delete(ctx, &computepb.DeleteSnapshotRequest{
+		Project:  p.Project,
+		Snapshot: snapshotID,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "notFound") || strings.Contains(err.Error(), "404") {
+			return nil
+		}
+		return errors.Wrap(fmt.Sprintf("failed to delete snapshot %s", snapshotID), err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return errors.Wrap(fmt.Sprintf("failed waiting for snapshot %s deletion", snapshotID), err)
+	}
+
+	return nil
+}
+
 // Helper functions
 
+func getBootDisk(instance *computepb.Instance) *computepb.AttachedDisk {
+	for _, disk := range instance.Disks {
+		if fn.ValueOf(disk.Boot) {
+			return disk
+		}
+	}
+	return nil
+}
+
+// diskName extracts the disk name from the source URL of an attached disk
+func diskName(disk *computepb.AttachedDisk) string {
+	parts := strings.Split(fn.ValueOf(disk.Source), "/")
+	return parts[len(parts)-1]
+}
+
 func mapGCPStateToMachineState(status *string) v1.MachineState {
 	if status == nil {
 		return v1.MachineStateErrored
@@ -424,6 +711,42 @@ func mapGCPStateToMachineState(status *string) v1.MachineState {
 	}
 }
 
+func isSpotInstance(instance *computepb.Instance) bool {
+	return instance.Scheduling != nil && fn.ValueOf(instance.Scheduling.ProvisioningModel) == "SPOT"
+}
+
+// wasPreempted reports whether GCP preempted the instance since it was last started, from the
+// compute.instances.preempted operation GCP records when it reclaims spot capacity
+func (p *provider) wasPreempted(ctx context.Context, instance *computepb.Instance) (bool, error) {
+	it := p.operationsClient.List(ctx, &computepb.ListZoneOperationsRequest{
+		Project: p.Project,
+		Zone:    p.Zone,
+		Filter:  fn.Ptr(fmt.Sprintf(`(operationType = "compute.instances.preempted") AND (targetId = %d)`, fn.ValueOf(instance.Id))),
+	})
+
+	lastStart, _ := time.Parse(time.RFC3339, fn.ValueOf(instance.LastStartTimestamp))
+	for {
+		op, err := it.Next()
+		if err == iterator.Done {
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.Wrap(fmt.Sprintf("failed to list preemptions of instance %s", fn.ValueOf(instance.Name)), err)
+		}
+		if insertedAt, err := time.Parse(time.RFC3339, op.GetInsertTime()); err == nil && !insertedAt.Before(lastStart) {
+			return true, nil
+		}
+	}
+}
+
+// isSpotCapacityError reports whether instance creation failed for lack of spot capacity or quota
+func isSpotCapacityError(err error) bool {
+	errStr := err.Error()
+	return strings.Contains(errStr, "ZONE_RESOURCE_POOL_EXHAUSTED") ||
+		strings.Contains(errStr, "does not have enough resources available") ||
+		strings.Contains(errStr, "PREEMPTIBLE_CPUS")
+}
+
 func getPrivateIP(instance *computepb.Instance) string {
 	if instance.NetworkInterfaces != nil && len(instance.NetworkInterfaces) > 0 {
 		return fn.ValueOf(instance.NetworkInterfaces[0].NetworkIP)
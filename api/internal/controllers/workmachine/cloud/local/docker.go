@@ -0,0 +1,209 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// dockerAPIVersion is the Docker Engine API version used for requests (Docker 20.10+)
+const dockerAPIVersion = "v1.41"
+
+// dockerClient is a minimal Docker Engine API client covering the calls the provider needs
+type dockerClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// dockerAPIError is returned for non-2xx Docker API responses
+type dockerAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *dockerAPIError) Error() string {
+	return fmt.Sprintf("docker API error (%d): %s", e.StatusCode, e.Message)
+}
+
+func isDockerNotFound(err error) bool {
+	apiErr, ok := err.(*dockerAPIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+func isDockerConflict(err error) bool {
+	apiErr, ok := err.(*dockerAPIError)
+	return ok && apiErr.StatusCode == http.StatusConflict
+}
+
+// newDockerClient creates a client for host, either unix:///path/to/docker.sock or tcp://host:port
+func newDockerClient(host string) (*dockerClient, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	transport := &http.Transport{}
+	baseURL := ""
+	switch u.Scheme {
+	case "unix":
+		socketPath := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		}
+		baseURL = "http://docker"
+	case "tcp", "http":
+		baseURL = "http://" + u.Host
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme %q", u.Scheme)
+	}
+
+	return &dockerClient{
+		// Image pulls stream for a while, individual calls are bounded by their context
+		httpClient: &http.Client{Transport: transport, Timeout: 10 * time.Minute},
+		baseURL:    baseURL + "/" + dockerAPIVersion,
+	}, nil
+}
+
+// do sends a request and decodes a JSON response into out (if non-nil)
+func (c *dockerClient) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	reqURL := c.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		b, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(b, &msg) != nil || msg.Message == "" {
+			msg.Message = strings.TrimSpace(string(b))
+		}
+		return &dockerAPIError{StatusCode: resp.StatusCode, Message: msg.Message}
+	}
+
+	if out == nil {
+		// Drain streamed responses (e.g. image pulls) so the operation completes
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *dockerClient) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/_ping", nil, nil, nil)
+}
+
+func (c *dockerClient) PullImage(ctx context.Context, image, tag string) error {
+	return c.do(ctx, http.MethodPost, "/images/create", url.Values{"fromImage": {image}, "tag": {tag}}, nil, nil)
+}
+
+func (c *dockerClient) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	return c.do(ctx, http.MethodPost, "/volumes/create", nil, map[string]any{"Name": name, "Labels": labels}, nil)
+}
+
+func (c *dockerClient) RemoveVolume(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/volumes/"+name, url.Values{"force": {"true"}}, nil, nil)
+}
+
+// containerConfig is the subset of the container create request used by the provider
+type containerConfig struct {
+	Image      string            `json:"Image"`
+	Hostname   string            `json:"Hostname,omitempty"`
+	Cmd        []string          `json:"Cmd,omitempty"`
+	Env        []string          `json:"Env,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+	HostConfig hostConfig        `json:"HostConfig"`
+}
+
+type hostConfig struct {
+	Privileged    bool              `json:"Privileged"`
+	NetworkMode   string            `json:"NetworkMode,omitempty"`
+	Binds         []string          `json:"Binds,omitempty"`
+	Tmpfs         map[string]string `json:"Tmpfs,omitempty"`
+	RestartPolicy map[string]string `json:"RestartPolicy,omitempty"`
+	resources
+}
+
+// resources are the container limits that can be changed on a running container
+type resources struct {
+	NanoCpus   int64 `json:"NanoCpus,omitempty"`
+	Memory     int64 `json:"Memory,omitempty"`
+	MemorySwap int64 `json:"MemorySwap,omitempty"`
+}
+
+func (c *dockerClient) CreateContainer(ctx context.Context, name string, cfg containerConfig) error {
+	return c.do(ctx, http.MethodPost, "/containers/create", url.Values{"name": {name}}, cfg, nil)
+}
+
+func (c *dockerClient) StartContainer(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+name+"/start", nil, nil, nil)
+}
+
+func (c *dockerClient) StopContainer(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+name+"/stop", url.Values{"t": {"30"}}, nil, nil)
+}
+
+func (c *dockerClient) RestartContainer(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+name+"/restart", url.Values{"t": {"30"}}, nil, nil)
+}
+
+func (c *dockerClient) UpdateContainer(ctx context.Context, name string, res resources) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+name+"/update", nil, res, nil)
+}
+
+func (c *dockerClient) RemoveContainer(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/containers/"+name, url.Values{"force": {"true"}, "v": {"true"}}, nil, nil)
+}
+
+// containerInfo is the subset of the container inspect response used by the provider
+type containerInfo struct {
+	State struct {
+		Status  string `json:"Status"` // created, running, paused, restarting, removing, exited, dead
+		Running bool   `json:"Running"`
+	} `json:"State"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+func (c *dockerClient) InspectContainer(ctx context.Context, name string) (*containerInfo, error) {
+	var info containerInfo
+	if err := c.do(ctx, http.MethodGet, "/containers/"+name+"/json", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
@@ -0,0 +1,339 @@
+// Package local implements a WorkMachine provider backed by k3s-in-docker containers
+//
+// It is meant for developing and testing Kloudlite itself (CI and laptops, see `kli local install`):
+// every WorkMachine is a privileged rancher/k3s agent container joined to the control-plane cluster,
+// so the machine lifecycle (create, start/stop, resize, volume growth, auto-shutdown) runs end to end
+// without a cloud account.
+package local
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/kloudlite/kloudlite/api/internal/pkg/errors"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// LabelManagedBy marks containers and volumes created by the local provider
+	LabelManagedBy = "kloudlite.io/managed-by"
+
+	managedByValue = "kloudlite-local"
+
+	// Region and zone reported for local machines
+	localRegion = "local"
+)
+
+type provider struct {
+	docker *dockerClient
+	client client.Client
+
+	ProviderArgs
+}
+
+var _ cloud.Provider = (*provider)(nil)
+
+type ProviderArgs struct {
+	// DockerHost is the Docker Engine address (unix:///var/run/docker.sock or tcp://host:port)
+	DockerHost string
+
+	// Network is the docker network shared with the control-plane container
+	Network string
+
+	// K3sImage is the k3s image repository, tagged with the K3sVersion
+	K3sImage string
+
+	K3sVersion string
+	K3sURL     string
+	K3sToken   string
+}
+
+func NewProvider(ctx context.Context, k8sClient client.Client, args ProviderArgs) (cloud.Provider, error) {
+	if args.Network == "" {
+		return nil, errors.New("must provide docker network for local machines")
+	}
+	if args.K3sImage == "" {
+		args.K3sImage = "rancher/k3s"
+	}
+
+	docker, err := newDockerClient(args.DockerHost)
+	if err != nil {
+		return nil, errors.Wrap("failed to create docker client", err)
+	}
+
+	return &provider{
+		docker:       docker,
+		client:       k8sClient,
+		ProviderArgs: args,
+	}, nil
+}
+
+// ContainerName returns the name of the container backing a WorkMachine, also used as machine ID
+func ContainerName(wmName string) string {
+	return "kl-workmachine-" + wmName
+}
+
+func (p *provider) ValidatePermissions(ctx context.Context) error {
+	if err := p.docker.Ping(ctx); err != nil {
+		return errors.Wrap(fmt.Sprintf("failed to reach docker at %s", p.DockerHost), err)
+	}
+
+	slog.Info("[Local Provider] Docker is reachable", "host", p.DockerHost)
+	return nil
+}
+
+func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.MachineInfo, error) {
+	name := ContainerName(wm.Name)
+	labels := map[string]string{
+		LabelManagedBy:             managedByValue,
+		"kloudlite.io/workmachine": wm.Name,
+		"kloudlite.io/owner":       fn.LabelValueEncoder(wm.Spec.OwnedBy),
+	}
+
+	res, err := p.machineResources(ctx, wm.Spec.MachineType)
+	if err != nil {
+		return nil, err
+	}
+
+	// rancher/k3s tags use "-" where k3s versions use "+" (v1.31.4+k3s1 -> v1.31.4-k3s1)
+	tag := strings.ReplaceAll(p.K3sVersion, "+", "-")
+	if err := p.docker.PullImage(ctx, p.K3sImage, tag); err != nil {
+		return nil, errors.Wrap(fmt.Sprintf("failed to pull %s:%s", p.K3sImage, tag), err)
+	}
+
+	// Named volumes keep node identity and workspace data across stop/start
+	for _, volume := range []string{name + "-k3s", name + "-storage"} {
+		if err := p.docker.CreateVolume(ctx, volume, labels); err != nil {
+			return nil, errors.Wrap(fmt.Sprintf("failed to create volume %s", volume), err)
+		}
+	}
+
+	err = p.docker.CreateContainer(ctx, name, containerConfig{
+		Image:    p.K3sImage + ":" + tag,
+		Hostname: wm.Name,
+		Cmd: []string{
+			"agent",
+			"--node-name=" + wm.Name,
+			"--node-label=kloudlite.io/workmachine=" + wm.Name,
+			"--node-label=kloudlite.io/owner=" + fn.LabelValueEncoder(wm.Spec.OwnedBy),
+			"--node-taint=kloudlite.io/workmachine=" + wm.Name + ":NoSchedule",
+			"--kubelet-arg=eviction-hard=memory.available<200Mi,nodefs.available<5%",
+			"--kubelet-arg=fail-swap-on=false",
+		},
+		Env: []string{
+			"K3S_URL=" + p.K3sURL,
+			"K3S_TOKEN=" + p.K3sToken,
+		},
+		Labels: labels,
+		HostConfig: hostConfig{
+			Privileged:  true,
+			NetworkMode: p.Network,
+			Binds: []string{
+				name + "-k3s:/var/lib/rancher/k3s",
+				name + "-storage:/var/lib/kloudlite/storage",
+			},
+			Tmpfs:         map[string]string{"/run": "", "/var/run": ""},
+			RestartPolicy: map[string]string{"Name": "unless-stopped"},
+			resources:     res,
+		},
+	})
+	if err != nil && !isDockerConflict(err) {
+		return nil, errors.Wrap("failed to create machine container", err)
+	}
+
+	if err := p.docker.StartContainer(ctx, name); err != nil {
+		return nil, errors.Wrap("failed to start machine container", err)
+	}
+
+	return &v1.MachineInfo{
+		MachineID:         name,
+		State:             v1.MachineStateStarting,
+		AvailabilityZone:  localRegion,
+		Message:           "Container created successfully",
+		Region:            localRegion,
+		StorageVolumeSize: fn.ValueOf(wm.Spec.VolumeSize),
+	}, nil
+}
+
+func (p *provider) GetMachineStatus(ctx context.Context, machineID string) (*v1.MachineInfo, error) {
+	if machineID == "" {
+		return nil, errors.New("must provide machineID")
+	}
+
+	info, err := p.docker.InspectContainer(ctx, machineID)
+	if err != nil {
+		return nil, errors.Wrap(fmt.Sprintf("failed to inspect container %s", machineID), err)
+	}
+
+	ip := ""
+	if n, ok := info.NetworkSettings.Networks[p.Network]; ok {
+		ip = n.IPAddress
+	}
+
+	return &v1.MachineInfo{
+		MachineID:        machineID,
+		State:            mapContainerStateToMachineState(info.State.Status),
+		PrivateIP:        ip,
+		PublicIP:         ip,
+		AvailabilityZone: localRegion,
+		Message:          fmt.Sprintf("Container is %s", info.State.Status),
+		Region:           localRegion,
+	}, nil
+}
+
+func (p *provider) StartMachine(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return fmt.Errorf("must provide machineID, got (%s)", machineID)
+	}
+	if err := p.docker.StartContainer(ctx, machineID); err != nil {
+		return fmt.Errorf("failed to start machine: %w", err)
+	}
+	return nil
+}
+
+func (p *provider) StopMachine(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return fmt.Errorf("must provide machineID, got (%s)", machineID)
+	}
+	if err := p.docker.StopContainer(ctx, machineID); err != nil {
+		return fmt.Errorf("failed to stop machine: %w", err)
+	}
+	return nil
+}
+
+func (p *provider) RebootMachine(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return fmt.Errorf("must provide machineID, got (%s)", machineID)
+	}
+	if err := p.docker.RestartContainer(ctx, machineID); err != nil {
+		return fmt.Errorf("failed to reboot machine: %w", err)
+	}
+	return nil
+}
+
+// IncreaseVolumeSize accepts the new size without changes: docker volumes are not size limited,
+// so growing always succeeds and the controller flow (resize, reboot, wait) runs as on a cloud
+func (p *provider) IncreaseVolumeSize(ctx context.Context, machineID string, newSize int32) error {
+	if machineID == "" || newSize == 0 {
+		return errors.New("must provide machineID and newSize")
+	}
+	slog.Info("[Local Provider] volume size increased", "machine", machineID, "size", newSize)
+	return nil
+}
+
+// ChangeMachine updates the container CPU and memory limits to the new machine type
+func (p *provider) ChangeMachine(ctx context.Context, machineID string, newInstanceType string) error {
+	if machineID == "" || newInstanceType == "" {
+		return errors.New("must provide machineID and newInstanceType")
+	}
+
+	res, err := p.machineResources(ctx, newInstanceType)
+	if err != nil {
+		return err
+	}
+	if err := p.docker.UpdateContainer(ctx, machineID, res); err != nil {
+		return fmt.Errorf("failed to change machine type: %w", err)
+	}
+	return nil
+}
+
+func (p *provider) DeleteMachine(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return fmt.Errorf("must provide machineID, got (%s)", machineID)
+	}
+
+	if err := p.docker.RemoveContainer(ctx, machineID); err != nil && !isDockerNotFound(err) {
+		return fmt.Errorf("failed to delete machine: %w", err)
+	}
+	for _, volume := range []string{machineID + "-k3s", machineID + "-storage"} {
+		if err := p.docker.RemoveVolume(ctx, volume); err != nil && !isDockerNotFound(err) {
+			return fmt.Errorf("failed to delete volume %s: %w", volume, err)
+		}
+	}
+	return nil
+}
+
+// Docker volumes of local machines cost nothing while stopped, hibernation is not supported
+
+func (p *provider) SnapshotVolume(ctx context.Context, machineID string) (string, error) {
+	return "", cloud.ErrHibernationNotSupported
+}
+
+func (p *provider) GetVolumeSnapshotProgress(ctx context.Context, snapshotID string) (int32, error) {
+	return 0, cloud.ErrHibernationNotSupported
+}
+
+func (p *provider) ReleaseVolume(ctx context.Context, machineID string) error {
+	return cloud.ErrHibernationNotSupported
+}
+
+func (p *provider) RestoreVolume(ctx context.Context, wm *v1.WorkMachine, snapshotID string) error {
+	return cloud.ErrHibernationNotSupported
+}
+
+// Storage of local machines is deleted with the container, warm pools are not supported
+func (p *provider) SetVolumeDeletion(ctx context.Context, wm *v1.WorkMachine) error {
+	return nil
+}
+
+func (p *provider) DeleteVolumeSnapshot(ctx context.Context, snapshotID string) error {
+	return cloud.ErrHibernationNotSupported
+}
+
+// Helper functions
+
+// machineResources returns the container limits for a MachineType
+// Unknown machine types run without limits.
+func (p *provider) machineResources(ctx context.Context, machineType string) (resources, error) {
+	mt := &v1.MachineType{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: machineType}, mt); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			slog.Warn("[Local Provider] machine type not found, running without limits", "machineType", machineType)
+			return resources{}, nil
+		}
+		return resources{}, errors.Wrap(fmt.Sprintf("failed to get machine type %s", machineType), err)
+	}
+	return parseResources(mt.Spec.Resources)
+}
+
+func parseResources(r v1.MachineResources) (resources, error) {
+	var res resources
+	if r.CPU != "" {
+		cpu, err := resource.ParseQuantity(r.CPU)
+		if err != nil {
+			return res, errors.Wrap(fmt.Sprintf("invalid cpu %q", r.CPU), err)
+		}
+		res.NanoCpus = cpu.MilliValue() * 1_000_000
+	}
+	if r.Memory != "" {
+		memory, err := resource.ParseQuantity(r.Memory)
+		if err != nil {
+			return res, errors.Wrap(fmt.Sprintf("invalid memory %q", r.Memory), err)
+		}
+		res.Memory = memory.Value()
+		// Unlimited swap, so changing the memory limit never conflicts with the swap limit
+		res.MemorySwap = -1
+	}
+	return res, nil
+}
+
+func mapContainerStateToMachineState(status string) v1.MachineState {
+	switch status {
+	case "running":
+		return v1.MachineStateRunning
+	case "created", "restarting":
+		return v1.MachineStateStarting
+	case "removing":
+		return v1.MachineStateStopping
+	case "exited", "paused":
+		return v1.MachineStateStopped
+	default:
+		return v1.MachineStateErrored
+	}
+}
@@ -0,0 +1,56 @@
+package local
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllers/testutil"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResources(t *testing.T) {
+	res, err := parseResources(v1.MachineResources{CPU: "1500m", Memory: "4Gi"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1_500_000_000), res.NanoCpus)
+	assert.Equal(t, int64(4<<30), res.Memory)
+	assert.Equal(t, int64(-1), res.MemorySwap)
+
+	_, err = parseResources(v1.MachineResources{CPU: "lots"})
+	assert.Error(t, err)
+}
+
+func TestGetMachineStatus(t *testing.T) {
+	docker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + dockerAPIVersion + "/containers/kl-workmachine-dev/json":
+			w.Write([]byte(`{"State":{"Status":"exited"},"NetworkSettings":{"Networks":{"kloudlite-local":{"IPAddress":"172.20.0.5"}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"No such container"}`))
+		}
+	}))
+	defer docker.Close()
+
+	c := testutil.NewFakeClient(testutil.NewTestScheme()).Build()
+	p, err := NewProvider(context.Background(), c, ProviderArgs{
+		DockerHost: strings.Replace(docker.URL, "http://", "tcp://", 1),
+		Network:    "kloudlite-local",
+	})
+	require.NoError(t, err)
+
+	info, err := p.GetMachineStatus(context.Background(), ContainerName("dev"))
+	require.NoError(t, err)
+	assert.Equal(t, v1.MachineStateStopped, info.State)
+	assert.Equal(t, "172.20.0.5", info.PrivateIP)
+
+	_, err = p.GetMachineStatus(context.Background(), ContainerName("missing"))
+	assert.Error(t, err)
+
+	// Deleting a machine that is already gone succeeds
+	assert.NoError(t, p.DeleteMachine(context.Background(), ContainerName("missing")))
+}
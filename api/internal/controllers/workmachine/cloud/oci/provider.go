@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud"
 	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/templates"
@@ -29,6 +30,8 @@ type provider struct {
 
 var _ cloud.Provider = (*provider)(nil)
 
+const volumeWaitTimeout = 5 * time.Minute
+
 type Tag struct {
 	Key   string
 	Value string
@@ -167,6 +170,17 @@ func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.M
 	}
 
 	assignPublicIP := true
+	capacity := v1.CapacityOnDemand
+	var preemptibleConfig *core.PreemptibleInstanceConfigDetails
+	if wm.Spec.Capacity.IsSpot() {
+		capacity = v1.CapacitySpot
+		// OCI terminates preempted instances, the controller recreates the machine
+		preserveBootVolume := false
+		preemptibleConfig = &core.PreemptibleInstanceConfigDetails{
+			PreemptionAction: core.TerminatePreemptionAction{PreserveBootVolume: &preserveBootVolume},
+		}
+	}
+
 	launchResp, err := p.computeClient.LaunchInstance(ctx, core.LaunchInstanceRequest{
 		LaunchInstanceDetails: core.LaunchInstanceDetails{
 			AvailabilityDomain: &p.AvailabilityDomain,
@@ -186,11 +200,15 @@ func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.M
 				NsgIds:         []string{p.NSGID},
 				AssignPublicIp: &assignPublicIP,
 			},
-			Metadata:     metadata,
-			FreeformTags: tags,
+			Metadata:                  metadata,
+			FreeformTags:              tags,
+			PreemptibleInstanceConfig: preemptibleConfig,
 		},
 	})
 	if err != nil {
+		if capacity == v1.CapacitySpot && strings.Contains(err.Error(), "Out of host capacity") {
+			return nil, errors.Wrap("failed to create OCI preemptible instance", cloud.ErrSpotCapacityUnavailable, err)
+		}
 		return nil, errors.Wrap("failed to create OCI instance", err)
 	}
 
@@ -206,6 +224,7 @@ func (p *provider) CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.M
 		Message:           "Instance created successfully",
 		Region:            p.Region,
 		StorageVolumeSize: int32(bootVolumeSizeInGBs),
+		Capacity:          capacity,
 	}, nil
 }
 
@@ -223,9 +242,16 @@ func (p *provider) GetMachineStatus(ctx context.Context, machineID string) (*v1.
 
 	publicIP, privateIP := p.getInstanceIPs(ctx, machineID)
 
+	state := mapOCIStateToMachineState(resp.LifecycleState)
+	if resp.PreemptibleInstanceConfig != nil &&
+		(resp.LifecycleState == core.InstanceLifecycleStateTerminating || resp.LifecycleState == core.InstanceLifecycleStateTerminated) {
+		// OCI terminates preemptible instances when it reclaims the capacity
+		state = v1.MachineStateInterrupted
+	}
+
 	return &v1.MachineInfo{
 		MachineID:        machineID,
-		State:            mapOCIStateToMachineState(resp.LifecycleState),
+		State:            state,
 		PrivateIP:        privateIP,
 		PublicIP:         publicIP,
 		AvailabilityZone: p.AvailabilityDomain,
@@ -380,8 +406,241 @@ func (p *provider) DeleteMachine(ctx context.Context, machineID string) error {
 	return nil
 }
 
+// SnapshotVolume creates a full backup of the boot volume, which also holds the btrfs storage on OCI
+func (p *provider) SnapshotVolume(ctx context.Context, machineID string) (string, error) {
+	if machineID == "" {
+		return "", errors.New("must provide machineID")
+	}
+
+	attachment, err := p.getBootVolumeAttachment(ctx, machineID)
+	if err != nil {
+		return "", err
+	}
+	if attachment == nil {
+		return "", errors.New("boot volume not found")
+	}
+
+	blockClient, err := core.NewBlockstorageClientWithConfigurationProvider(p.configProvider)
+	if err != nil {
+		return "", errors.Wrap("failed to create block storage client", err)
+	}
+
+	bootVolume, err := blockClient.GetBootVolume(ctx, core.GetBootVolumeRequest{BootVolumeId: attachment.BootVolumeId})
+	if err != nil {
+		return "", errors.Wrap("failed to get boot volume", err)
+	}
+
+	displayName := fmt.Sprintf("%s-hibernate-%d", fn.ValueOf(bootVolume.DisplayName), time.Now().Unix())
+	resp, err := blockClient.CreateBootVolumeBackup(ctx, core.CreateBootVolumeBackupRequest{
+		CreateBootVolumeBackupDetails: core.CreateBootVolumeBackupDetails{
+			BootVolumeId: attachment.BootVolumeId,
+			DisplayName:  &displayName,
+			Type:         core.CreateBootVolumeBackupDetailsTypeFull,
+			FreeformTags: bootVolume.FreeformTags,
+		},
+	})
+	if err != nil {
+		return "", errors.Wrap("failed to create boot volume backup", err)
+	}
+
+	return fn.ValueOf(resp.Id), nil
+}
+
+// GetVolumeSnapshotProgress maps the backup lifecycle state to a progress, OCI reports no percentage
+func (p *provider) GetVolumeSnapshotProgress(ctx context.Context, snapshotID string) (int32, error) {
+	blockClient, err := core.NewBlockstorageClientWithConfigurationProvider(p.configProvider)
+	if err != nil {
+		return 0, errors.Wrap("failed to create block storage client", err)
+	}
+
+	resp, err := blockClient.GetBootVolumeBackup(ctx, core.GetBootVolumeBackupRequest{BootVolumeBackupId: &snapshotID})
+	if err != nil {
+		return 0, errors.Wrap(fmt.Sprintf("failed to get boot volume backup %s", snapshotID), err)
+	}
+
+	switch resp.LifecycleState {
+	case core.BootVolumeBackupLifecycleStateAvailable:
+		return 100, nil
+	case core.BootVolumeBackupLifecycleStateCreating:
+		return 50, nil
+	case core.BootVolumeBackupLifecycleStateRequestReceived:
+		return 0, nil
+	default:
+		return 0, errors.New(fmt.Sprintf("boot volume backup %s is %s", snapshotID, resp.LifecycleState))
+	}
+}
+
+func (p *provider) ReleaseVolume(ctx context.Context, machineID string) error {
+	if machineID == "" {
+		return errors.New("must provide machineID")
+	}
+
+	attachment, err := p.getBootVolumeAttachment(ctx, machineID)
+	if err != nil {
+		return err
+	}
+	if attachment == nil {
+		return nil
+	}
+
+	if _, err := p.computeClient.DetachBootVolume(ctx, core.DetachBootVolumeRequest{BootVolumeAttachmentId: attachment.Id}); err != nil {
+		return errors.Wrap("failed to detach boot volume", err)
+	}
+
+	if err := waitFor(ctx, func() (bool, error) {
+		resp, err := p.computeClient.GetBootVolumeAttachment(ctx, core.GetBootVolumeAttachmentRequest{BootVolumeAttachmentId: attachment.Id})
+		if err != nil {
+			return false, err
+		}
+		return resp.LifecycleState == core.BootVolumeAttachmentLifecycleStateDetached, nil
+	}); err != nil {
+		return errors.Wrap("failed to wait for boot volume detach", err)
+	}
+
+	blockClient, err := core.NewBlockstorageClientWithConfigurationProvider(p.configProvider)
+	if err != nil {
+		return errors.Wrap("failed to create block storage client", err)
+	}
+
+	if _, err := blockClient.DeleteBootVolume(ctx, core.DeleteBootVolumeRequest{BootVolumeId: attachment.BootVolumeId}); err != nil {
+		if strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return errors.Wrap("failed to delete boot volume", err)
+	}
+
+	return nil
+}
+
+func (p *provider) RestoreVolume(ctx context.Context, wm *v1.WorkMachine, snapshotID string) error {
+	machineID := wm.Status.MachineID
+	if machineID == "" || snapshotID == "" {
+		return errors.New("must provide machineID and snapshotID")
+	}
+
+	attachment, err := p.getBootVolumeAttachment(ctx, machineID)
+	if err != nil {
+		return err
+	}
+	if attachment != nil {
+		return nil
+	}
+
+	blockClient, err := core.NewBlockstorageClientWithConfigurationProvider(p.configProvider)
+	if err != nil {
+		return errors.Wrap("failed to create block storage client", err)
+	}
+
+	backup, err := blockClient.GetBootVolumeBackup(ctx, core.GetBootVolumeBackupRequest{BootVolumeBackupId: &snapshotID})
+	if err != nil {
+		return errors.Wrap(fmt.Sprintf("failed to get boot volume backup %s", snapshotID), err)
+	}
+
+	bootVolume, err := blockClient.CreateBootVolume(ctx, core.CreateBootVolumeRequest{
+		CreateBootVolumeDetails: core.CreateBootVolumeDetails{
+			AvailabilityDomain: &p.AvailabilityDomain,
+			CompartmentId:      &p.CompartmentID,
+			SourceDetails:      core.BootVolumeSourceFromBootVolumeBackupDetails{Id: &snapshotID},
+			FreeformTags:       backup.FreeformTags,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(fmt.Sprintf("failed to create boot volume from backup %s", snapshotID), err)
+	}
+
+	if err := waitFor(ctx, func() (bool, error) {
+		resp, err := blockClient.GetBootVolume(ctx, core.GetBootVolumeRequest{BootVolumeId: bootVolume.Id})
+		if err != nil {
+			return false, err
+		}
+		return resp.LifecycleState == core.BootVolumeLifecycleStateAvailable, nil
+	}); err != nil {
+		return errors.Wrap("failed to wait for boot volume restore", err)
+	}
+
+	if _, err := p.computeClient.AttachBootVolume(ctx, core.AttachBootVolumeRequest{
+		AttachBootVolumeDetails: core.AttachBootVolumeDetails{
+			BootVolumeId: bootVolume.Id,
+			InstanceId:   &machineID,
+		},
+	}); err != nil {
+		return errors.Wrap("failed to attach boot volume", err)
+	}
+
+	return nil
+}
+
+// The boot volume of OCI instances is always deleted with the instance, spec.deleteVolumePostTermination is not supported
+func (p *provider) SetVolumeDeletion(ctx context.Context, wm *v1.WorkMachine) error {
+	return nil
+}
+
+func (p *provider) DeleteVolumeSnapshot(ctx context.Context, snapshotID string) error {
+	if snapshotID == "" {
+		return errors.New("must provide snapshotID")
+	}
+
+	blockClient, err := core.NewBlockstorageClientWithConfigurationProvider(p.configProvider)
+	if err != nil {
+		return errors.Wrap("failed to create block storage client", err)
+	}
+
+	if _, err := blockClient.DeleteBootVolumeBackup(ctx, core.DeleteBootVolumeBackupRequest{BootVolumeBackupId: &snapshotID}); err != nil {
+		if strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return errors.Wrap(fmt.Sprintf("failed to delete boot volume backup %s", snapshotID), err)
+	}
+
+	return nil
+}
+
 // Helper functions
 
+// getBootVolumeAttachment returns the attached boot volume of the instance, or nil if it has none
+func (p *provider) getBootVolumeAttachment(ctx context.Context, machineID string) (*core.BootVolumeAttachment, error) {
+	resp, err := p.computeClient.ListBootVolumeAttachments(ctx, core.ListBootVolumeAttachmentsRequest{
+		AvailabilityDomain: &p.AvailabilityDomain,
+		CompartmentId:      &p.CompartmentID,
+		InstanceId:         &machineID,
+	})
+	if err != nil {
+		return nil, errors.Wrap("failed to list boot volume attachments", err)
+	}
+
+	for i := range resp.Items {
+		if resp.Items[i].BootVolumeId != nil && resp.Items[i].LifecycleState == core.BootVolumeAttachmentLifecycleStateAttached {
+			return &resp.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// waitFor polls done until it reports true, as the OCI SDK has no waiters
+func waitFor(ctx context.Context, done func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, volumeWaitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		ok, err := done()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
 // parseMachineType converts a MachineType name like "vm-standard-e4-flex-1-8"
 // to OCI shape name "VM.Standard.E4.Flex" with OCPU and memory values
 func parseMachineType(machineType string) (shapeName string, ocpus float32, memoryGB float32) {
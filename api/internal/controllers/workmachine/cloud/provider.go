@@ -2,10 +2,24 @@ package cloud
 
 import (
 	"context"
+	"errors"
 
 	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
 )
 
+// ErrSpotCapacityUnavailable is returned (wrapped) by CreateMachine when no spot/preemptible capacity
+// is available. Partially created resources are cleaned up, so the machine can be created again
+// with on-demand capacity (spec.capacity: spot-with-fallback).
+var ErrSpotCapacityUnavailable = errors.New("spot capacity unavailable")
+
+// ErrHibernationNotSupported is returned by the volume snapshot methods of providers
+// that cannot release the storage of a stopped machine (byo hosts, local containers)
+var ErrHibernationNotSupported = errors.New("hibernation not supported")
+
+// ErrVolumeOperationPending is returned (wrapped) by ReleaseVolume and RestoreVolume while the
+// storage volume is still changing state. The call is repeated until it returns nil.
+var ErrVolumeOperationPending = errors.New("volume operation pending")
+
 type Provider interface {
 	// ValidatePermissions checks if the configured credentials have all required permissions
 	// Returns nil if all required permissions are available, otherwise returns detailed wrapped error
@@ -14,9 +28,11 @@ type Provider interface {
 	// CreateMachine creates a new cloud instance for the WorkMachine
 	// Returns instance information including ID, IPs, and state
 	// Returns ResourceAlreadyExistsError if instance already exists
+	// Requests spot/preemptible capacity when wm.Spec.Capacity is spot, MachineInfo.Capacity reports what was launched
 	CreateMachine(ctx context.Context, wm *v1.WorkMachine) (*v1.MachineInfo, error)
 
 	// GetMachineStatus retrieves the current status of an instance by its ID
+	// Reports MachineStateInterrupted when the provider knows a spot instance was reclaimed
 	// Returns ResourceNotFoundError if instance doesn't exist
 	GetMachineStatus(ctx context.Context, machineID string) (*v1.MachineInfo, error)
 
@@ -37,4 +53,28 @@ type Provider interface {
 
 	// DeleteMachine permanently deletes the instance
 	DeleteMachine(ctx context.Context, machineID string) error
+
+	// SnapshotVolume starts a block-storage snapshot of the storage volume of a stopped instance
+	// Returns the provider's snapshot ID, progress is polled with GetVolumeSnapshotProgress
+	SnapshotVolume(ctx context.Context, machineID string) (string, error)
+
+	// GetVolumeSnapshotProgress returns the completion percentage (0-100) of a volume snapshot
+	// Returns an error if the snapshot failed
+	GetVolumeSnapshotProgress(ctx context.Context, snapshotID string) (int32, error)
+
+	// ReleaseVolume detaches and deletes the storage volume of a stopped instance
+	// Returns nil if the instance has no storage volume attached, ErrVolumeOperationPending while detaching
+	ReleaseVolume(ctx context.Context, machineID string) error
+
+	// RestoreVolume creates the storage volume from a snapshot and attaches it to the stopped instance
+	// Returns nil if the instance already has a storage volume attached, ErrVolumeOperationPending
+	// while the volume is created or attached
+	RestoreVolume(ctx context.Context, wm *v1.WorkMachine, snapshotID string) error
+
+	// DeleteVolumeSnapshot deletes a volume snapshot, missing snapshots are ignored
+	DeleteVolumeSnapshot(ctx context.Context, snapshotID string) error
+
+	// SetVolumeDeletion applies wm.Spec.DeleteVolumePostTermination to the storage volume of the
+	// instance wm.Status.MachineID, which was created for another WorkMachine (warm pool claims)
+	SetVolumeDeletion(ctx context.Context, wm *v1.WorkMachine) error
 }
@@ -12,7 +12,9 @@ import (
 	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud"
 	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud/aws"
 	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud/azure"
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud/byo"
 	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud/gcp"
+	localcloud "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud/local"
 	ocicloud "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud/oci"
 	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
 	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
@@ -46,6 +48,14 @@ type Env struct {
 
 	CloudProvider v1.CloudProvider `env:"CLOUD_PROVIDER" required:"true"`
 
+	// Placement is the spec.placement of the WorkMachines provisioned with CLOUD_PROVIDER and its env vars
+	Placement string `env:"WORKMACHINE_PLACEMENT"`
+
+	// Placements are the other placements machines are provisioned in, e.g. to migrate WorkMachines
+	// to another provider or region. Each one is configured by CLOUD_PROVIDER and the env vars of
+	// that provider, prefixed with placementEnvPrefix. The WorkMachine webhook rejects other placements.
+	Placements []string `env:"WORKMACHINE_PLACEMENTS"`
+
 	HostManagerImage  string `env:"HOST_MANAGER_IMAGE" required:"true"`
 	TunnelServerImage string `env:"TUNNEL_SERVER_IMAGE" required:"true"`
 	CodeAnalyzerImage string `env:"CODE_ANALYZER_IMAGE" required:"true"`
@@ -84,6 +94,16 @@ type gcpProviderEnv struct {
 	GCP_SUBNETWORK string `env:"GCP_SUBNETWORK" required:"true"`
 }
 
+type byoProviderEnv struct {
+	BYO_NAMESPACE string `env:"BYO_NAMESPACE" default:"kloudlite"`
+}
+
+type localProviderEnv struct {
+	LOCAL_DOCKER_HOST    string `env:"LOCAL_DOCKER_HOST" default:"unix:///var/run/docker.sock"`
+	LOCAL_DOCKER_NETWORK string `env:"LOCAL_DOCKER_NETWORK" required:"true"`
+	LOCAL_K3S_IMAGE      string `env:"LOCAL_K3S_IMAGE" default:"rancher/k3s"`
+}
+
 type ociProviderEnv struct {
 	OCI_COMPARTMENT string `env:"OCI_COMPARTMENT" required:"true"`
 	OCI_REGION      string `env:"OCI_REGION" required:"true"`
@@ -100,7 +120,9 @@ type WorkMachineReconciler struct {
 
 	// filled post initialization
 	env              Env
+	backupEnv        backupEnv
 	cloudProviderAPI cloud.Provider
+	placements       map[string]placement
 	usageReporter    *UsageReporter
 
 	// Cfg contains controller configuration
@@ -130,6 +152,17 @@ func (r *WorkMachineReconciler) Reconcile(ctx context.Context, request reconcile
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// Machines are provisioned with the cloud provider of their placement, unknown ones are rejected by the webhook
+	r, ok := r.forPlacement(req.Object.Spec.Placement)
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+
+	// Claimed warm machines are only waiting to be deleted
+	if req.Object.Labels[v1.LabelWarmPoolClaimedBy] != "" && req.Object.DeletionTimestamp == nil {
+		return reconcile.Result{}, nil
+	}
+
 	return reconciler.ReconcileSteps(req, []reconciler.Step[*v1.WorkMachine]{
 		{
 			Name:     "setup-namespace",
@@ -299,6 +332,12 @@ func (r *WorkMachineReconciler) Reconcile(ctx context.Context, request reconcile
 			OnCreate: r.setupCloudMachine,
 			OnDelete: r.cleanupCloudMachine,
 		},
+		{
+			Name:     "reconcile-node-pools",
+			Title:    "Create, start, stop and delete the worker nodes of the node pools",
+			OnCreate: r.reconcileNodePools,
+			OnDelete: r.cleanupNodePools,
+		},
 	})
 }
 
@@ -676,6 +715,10 @@ func (r *WorkMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return errors.Wrap("failed to load env vars", err)
 	}
 
+	if err := env.Set(&r.backupEnv); err != nil {
+		return errors.Wrap("failed to load backup env vars", err)
+	}
+
 	// Initialize the global pod deletion tracker if not already initialized
 	// This is shared with the workspace controller to prevent race conditions
 	if podDeletionTracker == nil {
@@ -700,13 +743,116 @@ func (r *WorkMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.usageReporter = NewUsageReporter(consoleBaseURL, r.env.KloudliteInstallationID, logger)
 	}
 
-	switch r.env.CloudProvider {
+	r.placements = map[string]placement{}
+	defaultProvider, err := r.newCloudProvider(mgr, r.env.CloudProvider, "")
+	if err != nil {
+		return err
+	}
+	r.placements[r.env.Placement] = placement{cloudProvider: r.env.CloudProvider, api: defaultProvider}
+
+	for _, name := range r.env.Placements {
+		if _, ok := r.placements[name]; ok {
+			continue
+		}
+		prefix := placementEnvPrefix(name)
+		cloudProvider := v1.CloudProvider(os.Getenv(prefix + "CLOUD_PROVIDER"))
+		p, err := r.newCloudProvider(mgr, cloudProvider, prefix)
+		if err != nil {
+			return errors.Wrap(fmt.Sprintf("failed to setup placement %s", name), err)
+		}
+		r.placements[name] = placement{cloudProvider: cloudProvider, api: p}
+	}
+
+	builder := ctrl.NewControllerManagedBy(mgr).For(&v1.WorkMachine{}).Named("workmachine")
+	builder.Owns(&corev1.Namespace{})
+	builder.Owns(&appsv1.StatefulSet{})
+	builder.Owns(&appsv1.Deployment{})
+	builder.Owns(&corev1.ServiceAccount{})
+	builder.Owns(&rbacv1.ClusterRole{})
+	builder.Owns(&rbacv1.ClusterRoleBinding{})
+	builder.Owns(&networkingv1.NetworkPolicy{})
+	builder.WithEventFilter(reconciler.ReconcileFilter(mgr.GetEventRecorderFor("workmachine")))
+
+	// Watch for workspaces and trigger reconciliation of their owning WorkMachine
+	builder.Watches(
+		&workspacev1.Workspace{},
+		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			workspace, ok := obj.(*workspacev1.Workspace)
+			if !ok {
+				return nil
+			}
+
+			// Use the WorkmachineName directly from the workspace spec
+			if workspace.Spec.WorkmachineName == "" {
+				return nil
+			}
+
+			return []reconcile.Request{
+				{NamespacedName: client.ObjectKey{Name: workspace.Spec.WorkmachineName}},
+			}
+		}),
+	)
+
+	// Watch for Nodes to trigger reconciliation when node joins/updates
+	// The reconciler will fetch fresh IPs from AWS when Node Ready state changes
+	builder.Watches(
+		&corev1.Node{},
+		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			node, ok := obj.(*corev1.Node)
+			if !ok {
+				return nil
+			}
+
+			// Node name matches WorkMachine name
+			// Trigger reconciliation to update WorkMachine status
+			return []reconcile.Request{
+				{NamespacedName: client.ObjectKey{Name: node.Name}},
+			}
+		}),
+	)
+
+	// Watch for host-manager Pods to recreate them if they crash
+	builder.Watches(
+		&corev1.Pod{},
+		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				return nil
+			}
+
+			// Get WorkMachine name from pod label (host-manager pods are labeled with workmachine name)
+			workmachineName, exists := pod.Labels["kloudlite.io/workmachine"]
+			if !exists {
+				return nil
+			}
+
+			// Trigger reconciliation to check and recreate pod if needed
+			return []reconcile.Request{
+				{NamespacedName: client.ObjectKey{Name: workmachineName}},
+			}
+		}),
+	)
+
+	// Add indexer for pod.spec.nodeName to efficiently query pods by node name
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+		pod := obj.(*corev1.Pod)
+		return []string{pod.Spec.NodeName}
+	}); err != nil {
+		return errors.Wrap("failed to setup field indexer for pod.spec.nodeName", err)
+	}
+
+	return builder.Complete(r)
+}
+
+// newCloudProvider creates the client of a cloud provider, configured by its env vars prefixed with envPrefix
+func (r *WorkMachineReconciler) newCloudProvider(mgr ctrl.Manager, cloudProvider v1.CloudProvider, envPrefix string) (cloud.Provider, error) {
+	switch cloudProvider {
 	case v1.AWS:
 		{
 
 			var awsEnv awsProviderEnv
-			if err := env.Set(&awsEnv); err != nil {
-				return errors.Wrap("failed to load env vars", err)
+			if err := env.SetPrefix(&awsEnv, envPrefix); err != nil {
+				return nil, errors.Wrap("failed to load env vars", err)
 			}
 
 			ctx, cf := context.WithTimeout(context.Background(), 5*time.Second)
@@ -728,20 +874,20 @@ func (r *WorkMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				HostedSubdomain: r.env.HostedSubdomain,
 			})
 			if err != nil {
-				return errors.Wrap("failed to create aws provider client", err)
+				return nil, errors.Wrap("failed to create aws provider client", err)
 			}
 
 			if err := p.ValidatePermissions(ctx); err != nil {
-				return err
+				return nil, err
 			}
 
-			r.cloudProviderAPI = p
+			return p, nil
 		}
 	case v1.Azure:
 		{
 			var azureEnv azureProviderEnv
-			if err := env.Set(&azureEnv); err != nil {
-				return errors.Wrap("failed to load Azure env vars", err)
+			if err := env.SetPrefix(&azureEnv, envPrefix); err != nil {
+				return nil, errors.Wrap("failed to load Azure env vars", err)
 			}
 
 			ctx, cf := context.WithTimeout(context.Background(), 30*time.Second)
@@ -765,20 +911,20 @@ func (r *WorkMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				HostedSubdomain: r.env.HostedSubdomain,
 			})
 			if err != nil {
-				return errors.Wrap("failed to create Azure provider client", err)
+				return nil, errors.Wrap("failed to create Azure provider client", err)
 			}
 
 			if err := p.ValidatePermissions(ctx); err != nil {
-				return err
+				return nil, err
 			}
 
-			r.cloudProviderAPI = p
+			return p, nil
 		}
 	case v1.GCP:
 		{
 			var gcpEnv gcpProviderEnv
-			if err := env.Set(&gcpEnv); err != nil {
-				return errors.Wrap("failed to load GCP env vars", err)
+			if err := env.SetPrefix(&gcpEnv, envPrefix); err != nil {
+				return nil, errors.Wrap("failed to load GCP env vars", err)
 			}
 
 			ctx, cf := context.WithTimeout(context.Background(), 30*time.Second)
@@ -802,20 +948,20 @@ func (r *WorkMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				HostedSubdomain: r.env.HostedSubdomain,
 			})
 			if err != nil {
-				return errors.Wrap("failed to create GCP provider client", err)
+				return nil, errors.Wrap("failed to create GCP provider client", err)
 			}
 
 			if err := p.ValidatePermissions(ctx); err != nil {
-				return err
+				return nil, err
 			}
 
-			r.cloudProviderAPI = p
+			return p, nil
 		}
 	case v1.OCI:
 		{
 			var ociEnv ociProviderEnv
-			if err := env.Set(&ociEnv); err != nil {
-				return errors.Wrap("failed to load OCI env vars", err)
+			if err := env.SetPrefix(&ociEnv, envPrefix); err != nil {
+				return nil, errors.Wrap("failed to load OCI env vars", err)
 			}
 
 			ctx, cf := context.WithTimeout(context.Background(), 30*time.Second)
@@ -838,98 +984,74 @@ func (r *WorkMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				HostedSubdomain: r.env.HostedSubdomain,
 			})
 			if err != nil {
-				return errors.Wrap("failed to create OCI provider client", err)
+				return nil, errors.Wrap("failed to create OCI provider client", err)
 			}
 
 			if err := p.ValidatePermissions(ctx); err != nil {
-				return err
+				return nil, err
 			}
 
-			r.cloudProviderAPI = p
+			return p, nil
 		}
-	default:
+	case v1.BYO:
 		{
-			return errors.New(fmt.Sprintf("unsupported cloud provider (%s)", r.env.CloudProvider))
-		}
-	}
-
-	builder := ctrl.NewControllerManagedBy(mgr).For(&v1.WorkMachine{}).Named("workmachine")
-	builder.Owns(&corev1.Namespace{})
-	builder.Owns(&appsv1.StatefulSet{})
-	builder.Owns(&appsv1.Deployment{})
-	builder.Owns(&corev1.ServiceAccount{})
-	builder.Owns(&rbacv1.ClusterRole{})
-	builder.Owns(&rbacv1.ClusterRoleBinding{})
-	builder.Owns(&networkingv1.NetworkPolicy{})
-	builder.WithEventFilter(reconciler.ReconcileFilter(mgr.GetEventRecorderFor("workmachine")))
-
-	// Watch for workspaces and trigger reconciliation of their owning WorkMachine
-	builder.Watches(
-		&workspacev1.Workspace{},
-		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
-			workspace, ok := obj.(*workspacev1.Workspace)
-			if !ok {
-				return nil
-			}
-
-			// Use the WorkmachineName directly from the workspace spec
-			if workspace.Spec.WorkmachineName == "" {
-				return nil
+			var byoEnv byoProviderEnv
+			if err := env.SetPrefix(&byoEnv, envPrefix); err != nil {
+				return nil, errors.Wrap("failed to load BYO env vars", err)
 			}
 
-			return []reconcile.Request{
-				{NamespacedName: client.ObjectKey{Name: workspace.Spec.WorkmachineName}},
-			}
-		}),
-	)
+			ctx, cf := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cf()
+			p, err := byo.NewProvider(ctx, mgr.GetClient(), byo.ProviderArgs{
+				Namespace:        byoEnv.BYO_NAMESPACE,
+				HostManagerImage: r.env.HostManagerImage,
 
-	// Watch for Nodes to trigger reconciliation when node joins/updates
-	// The reconciler will fetch fresh IPs from AWS when Node Ready state changes
-	builder.Watches(
-		&corev1.Node{},
-		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
-			node, ok := obj.(*corev1.Node)
-			if !ok {
-				return nil
+				K3sVersion:      r.env.K3sVersion,
+				K3sURL:          r.env.K3sServerURL,
+				K3sToken:        r.env.K3sAgentToken,
+				HostedSubdomain: r.env.HostedSubdomain,
+			})
+			if err != nil {
+				return nil, errors.Wrap("failed to create BYO provider", err)
 			}
 
-			// Node name matches WorkMachine name
-			// Trigger reconciliation to update WorkMachine status
-			return []reconcile.Request{
-				{NamespacedName: client.ObjectKey{Name: node.Name}},
+			if err := p.ValidatePermissions(ctx); err != nil {
+				return nil, err
 			}
-		}),
-	)
 
-	// Watch for host-manager Pods to recreate them if they crash
-	builder.Watches(
-		&corev1.Pod{},
-		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
-			pod, ok := obj.(*corev1.Pod)
-			if !ok {
-				return nil
+			return p, nil
+		}
+	case v1.Local:
+		{
+			var localEnv localProviderEnv
+			if err := env.SetPrefix(&localEnv, envPrefix); err != nil {
+				return nil, errors.Wrap("failed to load local provider env vars", err)
 			}
 
-			// Get WorkMachine name from pod label (host-manager pods are labeled with workmachine name)
-			workmachineName, exists := pod.Labels["kloudlite.io/workmachine"]
-			if !exists {
-				return nil
+			ctx, cf := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cf()
+			p, err := localcloud.NewProvider(ctx, mgr.GetClient(), localcloud.ProviderArgs{
+				DockerHost: localEnv.LOCAL_DOCKER_HOST,
+				Network:    localEnv.LOCAL_DOCKER_NETWORK,
+				K3sImage:   localEnv.LOCAL_K3S_IMAGE,
+
+				K3sVersion: r.env.K3sVersion,
+				K3sURL:     r.env.K3sServerURL,
+				K3sToken:   r.env.K3sAgentToken,
+			})
+			if err != nil {
+				return nil, errors.Wrap("failed to create local provider", err)
 			}
 
-			// Trigger reconciliation to check and recreate pod if needed
-			return []reconcile.Request{
-				{NamespacedName: client.ObjectKey{Name: workmachineName}},
+			if err := p.ValidatePermissions(ctx); err != nil {
+				return nil, err
 			}
-		}),
-	)
 
-	// Add indexer for pod.spec.nodeName to efficiently query pods by node name
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
-		pod := obj.(*corev1.Pod)
-		return []string{pod.Spec.NodeName}
-	}); err != nil {
-		return errors.Wrap("failed to setup field indexer for pod.spec.nodeName", err)
+			return p, nil
+		}
+	default:
+		{
+			return nil, errors.New(fmt.Sprintf("unsupported cloud provider (%s)", cloudProvider))
+		}
 	}
-
-	return builder.Complete(r)
 }
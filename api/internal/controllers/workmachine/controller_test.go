@@ -1 +1,102 @@
 package workmachine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllers/testutil"
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/reconciler"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeProvider records the cloud calls of a reconcile step, unexpected calls panic on the nil Provider
+type fakeProvider struct {
+	cloud.Provider
+
+	machineState     v1.MachineState
+	changeMachineErr error
+	snapshotProgress int32 // Progress of volume snapshots, 100 when unset
+	restorePending   bool  // RestoreVolume reports the volume is still being created
+
+	calls            []string
+	restoredVolumes  []string
+	deletedSnapshots []string
+	machineTypes     []string
+	volumeDeletions  []bool
+}
+
+func (p *fakeProvider) GetMachineStatus(ctx context.Context, machineID string) (*v1.MachineInfo, error) {
+	p.calls = append(p.calls, "GetMachineStatus")
+	return &v1.MachineInfo{MachineID: machineID, State: p.machineState}, nil
+}
+
+func (p *fakeProvider) StartMachine(ctx context.Context, machineID string) error {
+	p.calls = append(p.calls, "StartMachine")
+	return nil
+}
+
+func (p *fakeProvider) StopMachine(ctx context.Context, machineID string) error {
+	p.calls = append(p.calls, "StopMachine")
+	return nil
+}
+
+func (p *fakeProvider) ChangeMachine(ctx context.Context, machineID string, newInstanceType string) error {
+	p.calls = append(p.calls, "ChangeMachine")
+	if p.changeMachineErr != nil {
+		return p.changeMachineErr
+	}
+	p.machineTypes = append(p.machineTypes, newInstanceType)
+	return nil
+}
+
+func (p *fakeProvider) RestoreVolume(ctx context.Context, wm *v1.WorkMachine, snapshotID string) error {
+	p.calls = append(p.calls, "RestoreVolume")
+	if p.restorePending {
+		return fmt.Errorf("creating storage volume: %w", cloud.ErrVolumeOperationPending)
+	}
+	p.restoredVolumes = append(p.restoredVolumes, snapshotID)
+	return nil
+}
+
+func (p *fakeProvider) GetVolumeSnapshotProgress(ctx context.Context, snapshotID string) (int32, error) {
+	p.calls = append(p.calls, "GetVolumeSnapshotProgress")
+	if p.snapshotProgress == 0 {
+		return 100, nil
+	}
+	return p.snapshotProgress, nil
+}
+
+func (p *fakeProvider) DeleteVolumeSnapshot(ctx context.Context, snapshotID string) error {
+	p.calls = append(p.calls, "DeleteVolumeSnapshot")
+	p.deletedSnapshots = append(p.deletedSnapshots, snapshotID)
+	return nil
+}
+
+func (p *fakeProvider) DeleteMachine(ctx context.Context, machineID string) error {
+	p.calls = append(p.calls, "DeleteMachine")
+	return nil
+}
+
+func (p *fakeProvider) SetVolumeDeletion(ctx context.Context, wm *v1.WorkMachine) error {
+	p.calls = append(p.calls, "SetVolumeDeletion")
+	p.volumeDeletions = append(p.volumeDeletions, wm.Spec.DeleteVolumePostTermination)
+	return nil
+}
+
+// newTestCheck stores obj and objs in a fake client, and returns a running check of obj with the
+// object of the check, which steps update
+func newTestCheck(t *testing.T, obj *v1.WorkMachine, objs ...client.Object) (*reconciler.Check[*v1.WorkMachine], *v1.WorkMachine, client.Client) {
+	t.Helper()
+	c := testutil.NewFakeClient(testutil.NewTestScheme(), append(objs, obj)...).
+		WithStatusSubresource(&v1.WorkMachine{}).
+		Build()
+
+	req, err := reconciler.NewRequest(context.Background(), c, types.NamespacedName{Name: obj.Name}, &v1.WorkMachine{})
+	require.NoError(t, err)
+	return reconciler.NewRunningCheck("test", req), req.Object, c
+}
@@ -0,0 +1,146 @@
+// Package cost meters the usage of WorkMachines and prices it with the installation's cost model
+//
+// Usage is accumulated per calendar month (UTC) into WorkMachine.Status.Usage:
+// - runtime, from the StartedAt/StoppedAt interval of the machine
+// - storage volume size over time, while the volume is provisioned
+// - hibernation snapshot size over time, while the volume is released
+//
+// Prices come from MachineType.Spec.HourlyPrice and the per GB-month storage prices of the installation.
+package cost
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HoursPerMonth is the month length cloud providers use to prorate per GB-month storage prices
+const HoursPerMonth = 730
+
+// StoragePrices holds the per GB-month storage prices of the installation
+type StoragePrices struct {
+	VolumePerGBMonth   float64
+	SnapshotPerGBMonth float64
+}
+
+// Period returns the usage period (YYYY-MM) of t
+func Period(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// PeriodStart returns the first instant of the usage period of t
+func PeriodStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ParsePrice parses a decimal amount, an empty amount is free
+func ParsePrice(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return v, nil
+}
+
+// FormatAmount formats an amount with cent precision
+func FormatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// FormatHours formats a duration in seconds as hours with one decimal
+func FormatHours(seconds int64) string {
+	return strconv.FormatFloat(float64(seconds)/3600, 'f', 1, 64)
+}
+
+// Accumulate returns the usage of the machine up to now
+//
+// The persisted usage is extended by the interval since it was last accounted. A usage of an
+// earlier period is dropped and the current period is accounted from its start.
+func Accumulate(wm *v1.WorkMachine, now time.Time) *v1.UsageStatus {
+	periodStart := PeriodStart(now)
+
+	usage := &v1.UsageStatus{Period: Period(now)}
+	if wm.Status.Usage != nil && wm.Status.Usage.Period == usage.Period {
+		usage = wm.Status.Usage.DeepCopy()
+	}
+
+	from := periodStart
+	if usage.LastAccountedAt != nil && usage.LastAccountedAt.After(from) {
+		from = usage.LastAccountedAt.Time
+	}
+	if !now.After(from) {
+		return usage
+	}
+
+	usage.RuntimeSeconds += runtimeBetween(wm, from, now)
+
+	gbSeconds := int64(wm.Status.StorageVolumeSize) * int64(now.Sub(from).Seconds())
+	if wm.Status.Hibernation != nil && wm.Status.Hibernation.VolumeReleased {
+		// The snapshot is billed at its full size, as the volume was
+		usage.SnapshotGBSeconds += gbSeconds
+	} else if wm.Status.MachineID != "" {
+		usage.VolumeGBSeconds += gbSeconds
+	}
+
+	usage.LastAccountedAt = &metav1.Time{Time: now}
+	return usage
+}
+
+// runtimeBetween returns the seconds the latest run of the machine overlaps [from, to)
+func runtimeBetween(wm *v1.WorkMachine, from, to time.Time) int64 {
+	if wm.Status.StartedAt == nil {
+		return 0
+	}
+
+	runStart := wm.Status.StartedAt.Time
+	runEnd := to
+	if wm.Status.StoppedAt != nil && wm.Status.StoppedAt.After(runStart) {
+		runEnd = wm.Status.StoppedAt.Time
+	}
+
+	if runStart.Before(from) {
+		runStart = from
+	}
+	if runEnd.After(to) {
+		runEnd = to
+	}
+	if !runEnd.After(runStart) {
+		return 0
+	}
+	return int64(runEnd.Sub(runStart).Seconds())
+}
+
+// WorkMachineCost prices the usage of a machine
+func WorkMachineCost(usage *v1.UsageStatus, hourlyPrice float64, prices StoragePrices) float64 {
+	if usage == nil {
+		return 0
+	}
+	compute := float64(usage.RuntimeSeconds) / 3600 * hourlyPrice
+	volume := gbSecondsCost(usage.VolumeGBSeconds, prices.VolumePerGBMonth)
+	snapshot := gbSecondsCost(usage.SnapshotGBSeconds, prices.SnapshotPerGBMonth)
+	return compute + volume + snapshot
+}
+
+// SnapshotCost prices the storage of a snapshot from its creation (or the period start) until now
+func SnapshotCost(sizeBytes int64, createdAt, now time.Time, prices StoragePrices) float64 {
+	from := PeriodStart(now)
+	if createdAt.After(from) {
+		from = createdAt
+	}
+	if !now.After(from) {
+		return 0
+	}
+	gb := float64(sizeBytes) / (1 << 30)
+	return gb * now.Sub(from).Hours() / HoursPerMonth * prices.SnapshotPerGBMonth
+}
+
+func gbSecondsCost(gbSeconds int64, pricePerGBMonth float64) float64 {
+	return float64(gbSeconds) / 3600 / HoursPerMonth * pricePerGBMonth
+}
@@ -0,0 +1,145 @@
+package cost
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func mt(s string) *metav1.Time {
+	return &metav1.Time{Time: at(s)}
+}
+
+func TestAccumulate(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           v1.WorkMachineStatus
+		now              string
+		expectedRuntime  int64
+		expectedVolume   int64
+		expectedSnapshot int64
+	}{
+		{
+			name: "running since before the last accounting",
+			status: v1.WorkMachineStatus{
+				MachineInfo: v1.MachineInfo{MachineID: "i-1", StorageVolumeSize: 100},
+				StartedAt:   mt("2026-10-01T08:00:00Z"),
+				Usage: &v1.UsageStatus{
+					Period:          "2026-10",
+					RuntimeSeconds:  3600,
+					VolumeGBSeconds: 100 * 3600,
+					LastAccountedAt: mt("2026-10-01T09:00:00Z"),
+				},
+			},
+			now:             "2026-10-01T11:00:00Z",
+			expectedRuntime: 3 * 3600,
+			expectedVolume:  100 * 3 * 3600,
+		},
+		{
+			name: "stopped after the last accounting",
+			status: v1.WorkMachineStatus{
+				MachineInfo: v1.MachineInfo{MachineID: "i-1", StorageVolumeSize: 10},
+				StartedAt:   mt("2026-10-01T08:00:00Z"),
+				StoppedAt:   mt("2026-10-01T09:30:00Z"),
+				Usage: &v1.UsageStatus{
+					Period:          "2026-10",
+					LastAccountedAt: mt("2026-10-01T09:00:00Z"),
+				},
+			},
+			now:             "2026-10-01T10:00:00Z",
+			expectedRuntime: 1800,
+			expectedVolume:  10 * 3600,
+		},
+		{
+			name: "usage of the previous month is dropped",
+			status: v1.WorkMachineStatus{
+				MachineInfo: v1.MachineInfo{MachineID: "i-1", StorageVolumeSize: 10},
+				StartedAt:   mt("2026-09-30T22:00:00Z"),
+				Usage: &v1.UsageStatus{
+					Period:          "2026-09",
+					RuntimeSeconds:  7200,
+					LastAccountedAt: mt("2026-09-30T23:00:00Z"),
+				},
+			},
+			now:             "2026-10-01T01:00:00Z",
+			expectedRuntime: 3600,
+			expectedVolume:  10 * 3600,
+		},
+		{
+			name: "hibernated machine only keeps its snapshot",
+			status: v1.WorkMachineStatus{
+				MachineInfo: v1.MachineInfo{MachineID: "i-1", StorageVolumeSize: 50},
+				StartedAt:   mt("2026-09-01T08:00:00Z"),
+				StoppedAt:   mt("2026-09-02T08:00:00Z"),
+				Hibernation: &v1.HibernationStatus{SnapshotID: "snap-1", VolumeReleased: true},
+				Usage: &v1.UsageStatus{
+					Period:          "2026-10",
+					LastAccountedAt: mt("2026-10-10T00:00:00Z"),
+				},
+			},
+			now:              "2026-10-10T02:00:00Z",
+			expectedSnapshot: 50 * 2 * 3600,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wm := &v1.WorkMachine{Status: tt.status}
+			now := at(tt.now)
+
+			usage := Accumulate(wm, now)
+			assert.Equal(t, Period(now), usage.Period)
+			assert.Equal(t, tt.expectedRuntime, usage.RuntimeSeconds)
+			assert.Equal(t, tt.expectedVolume, usage.VolumeGBSeconds)
+			assert.Equal(t, tt.expectedSnapshot, usage.SnapshotGBSeconds)
+			assert.Equal(t, now, usage.LastAccountedAt.Time)
+		})
+	}
+}
+
+func TestWorkMachineCost(t *testing.T) {
+	usage := &v1.UsageStatus{
+		RuntimeSeconds:    10 * 3600,
+		VolumeGBSeconds:   100 * HoursPerMonth * 3600,
+		SnapshotGBSeconds: 20 * HoursPerMonth * 3600,
+	}
+	prices := StoragePrices{VolumePerGBMonth: 0.08, SnapshotPerGBMonth: 0.05}
+
+	assert.Equal(t, "13.00", FormatAmount(WorkMachineCost(usage, 0.4, prices)))
+	assert.Equal(t, "0.00", FormatAmount(WorkMachineCost(nil, 0.4, prices)))
+}
+
+func TestSnapshotCost(t *testing.T) {
+	prices := StoragePrices{SnapshotPerGBMonth: 0.05}
+	now := at("2026-10-31T10:00:00Z")
+
+	// Created in an earlier period, billed from the period start
+	full := SnapshotCost(10<<30, at("2026-08-01T00:00:00Z"), now, prices)
+	assert.Equal(t, "0.50", FormatAmount(full))
+
+	assert.Zero(t, SnapshotCost(10<<30, now.Add(time.Hour), now, prices))
+}
+
+func TestParsePrice(t *testing.T) {
+	v, err := ParsePrice("0.0832")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0832, v)
+
+	v, err = ParsePrice("")
+	assert.NoError(t, err)
+	assert.Zero(t, v)
+
+	_, err = ParsePrice("-1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,178 @@
+package workmachine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/reconciler"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkMachine hibernation
+//
+// A machine that stays stopped for longer than Spec.Hibernation.AfterStoppedDays is hibernated:
+// 1. The storage volume is snapshotted, progress is tracked in Status.Hibernation
+// 2. Once the snapshot completes, the volume is released (detached and deleted)
+// 3. When the machine is started again, the volume is recreated from the snapshot and reattached
+// 4. The machine is started as usual, the snapshot is deleted once the machine is ready
+//
+// The volume operations are polled: the provider starts them and returns cloud.ErrVolumeOperationPending
+// until the volume has reached its state, and the reconcile is requeued meanwhile.
+
+// handleHibernation hibernates long stopped machines, and wakes hibernated machines that should run
+func (r *WorkMachineReconciler) handleHibernation(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine, machineInfo *v1.MachineInfo, nodeReady bool) reconciler.StepResult {
+	if machineInfo == nil {
+		return check.Passed()
+	}
+
+	if obj.Spec.State == v1.MachineStateRunning {
+		if obj.Status.Hibernation == nil {
+			return check.Passed()
+		}
+		return r.wakeMachine(check, obj, machineInfo, nodeReady)
+	}
+
+	// Stopped again before the restored volume was in use, the snapshot is kept until the next start
+	if isWaking(obj) {
+		return check.Passed()
+	}
+
+	if obj.Status.Hibernation == nil {
+		if !r.shouldHibernate(obj, machineInfo) {
+			return check.Passed()
+		}
+		obj.Status.Hibernation = &v1.HibernationStatus{}
+	}
+
+	return r.hibernateMachine(check, obj)
+}
+
+// shouldHibernate reports whether the machine has been stopped for longer than its hibernation policy allows
+func (r *WorkMachineReconciler) shouldHibernate(obj *v1.WorkMachine, machineInfo *v1.MachineInfo) bool {
+	if r.env.CloudProvider == v1.BYO || r.env.CloudProvider == v1.Local {
+		return false
+	}
+
+	if obj.Spec.Hibernation == nil || !obj.Spec.Hibernation.Enabled {
+		return false
+	}
+
+	if obj.Spec.State != v1.MachineStateStopped || machineInfo.State != v1.MachineStateStopped || obj.Status.StoppedAt == nil {
+		return false
+	}
+
+	after := time.Duration(obj.Spec.Hibernation.AfterStoppedDays) * 24 * time.Hour
+	return time.Since(obj.Status.StoppedAt.Time) >= after
+}
+
+// hibernateMachine snapshots the storage volume, then releases it once the snapshot has completed
+func (r *WorkMachineReconciler) hibernateMachine(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	ctx := check.Context()
+	hibernation := obj.Status.Hibernation
+
+	if hibernation.VolumeReleased {
+		return check.Passed()
+	}
+
+	if hibernation.SnapshotID == "" {
+		snapshotID, err := r.cloudProviderAPI.SnapshotVolume(ctx, obj.Status.MachineID)
+		if err != nil {
+			return check.Failed(fmt.Errorf("failed to snapshot storage volume: %w", err))
+		}
+
+		check.Logger().Info("hibernating machine", "machineID", obj.Status.MachineID, "snapshotID", snapshotID)
+		hibernation.SnapshotID = snapshotID
+		obj.Status.State = v1.MachineStateHibernating
+		obj.Status.Message = "Snapshotting storage volume"
+		return check.UpdateMsg("snapshotting storage volume").RequeueAfter(r.Cfg.WorkMachine.HibernationCheckInterval)
+	}
+
+	progress, err := r.cloudProviderAPI.GetVolumeSnapshotProgress(ctx, hibernation.SnapshotID)
+	if err != nil {
+		return check.Failed(fmt.Errorf("failed to get progress of snapshot %s: %w", hibernation.SnapshotID, err))
+	}
+
+	hibernation.Progress = progress
+	if progress < 100 {
+		obj.Status.State = v1.MachineStateHibernating
+		obj.Status.Message = fmt.Sprintf("Snapshotting storage volume (%d%%)", progress)
+		return check.UpdateMsg(fmt.Sprintf("waiting for storage volume snapshot (%d%%)", progress)).RequeueAfter(r.Cfg.WorkMachine.HibernationCheckInterval)
+	}
+
+	if err := r.cloudProviderAPI.ReleaseVolume(ctx, obj.Status.MachineID); err != nil {
+		if errors.Is(err, cloud.ErrVolumeOperationPending) {
+			obj.Status.State = v1.MachineStateHibernating
+			obj.Status.Message = "Releasing storage volume"
+			return check.UpdateMsg(err.Error()).RequeueAfter(r.Cfg.WorkMachine.HibernationCheckInterval)
+		}
+		return check.Failed(fmt.Errorf("failed to release storage volume: %w", err))
+	}
+
+	r.usageReporter.ReportEvent(ctx, UsageEvent{
+		EventType:    "workmachine.hibernated",
+		ResourceID:   obj.Status.MachineID,
+		ResourceType: "workmachine." + obj.Spec.MachineType,
+		Timestamp:    time.Now(),
+	})
+
+	hibernation.VolumeReleased = true
+	hibernation.HibernatedAt = &metav1.Time{Time: time.Now()}
+	obj.Status.State = v1.MachineStateHibernated
+	check.Logger().Info("machine hibernated", "machineID", obj.Status.MachineID, "snapshotID", hibernation.SnapshotID)
+	return check.Passed()
+}
+
+// wakeMachine restores the storage volume from the hibernation snapshot, then removes the snapshot
+// once the machine is ready: until then the snapshot is the only copy of the data if the restore fails
+func (r *WorkMachineReconciler) wakeMachine(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine, machineInfo *v1.MachineInfo, nodeReady bool) reconciler.StepResult {
+	ctx := check.Context()
+	hibernation := obj.Status.Hibernation
+
+	if hibernation.VolumeReleased {
+		obj.Status.State = v1.MachineStateStarting
+		obj.Status.Message = "Restoring storage volume from hibernation snapshot"
+		if err := r.cloudProviderAPI.RestoreVolume(ctx, obj, hibernation.SnapshotID); err != nil {
+			if errors.Is(err, cloud.ErrVolumeOperationPending) {
+				return check.UpdateMsg(err.Error()).RequeueAfter(r.Cfg.WorkMachine.HibernationCheckInterval)
+			}
+			return check.Failed(fmt.Errorf("failed to restore storage volume from snapshot %s: %w", hibernation.SnapshotID, err))
+		}
+		hibernation.VolumeReleased = false
+		check.Logger().Info("restored storage volume", "machineID", obj.Status.MachineID, "snapshotID", hibernation.SnapshotID)
+	}
+
+	// Start the machine on the restored volume
+	if machineInfo.State != v1.MachineStateRunning || !nodeReady {
+		return check.Passed()
+	}
+
+	if hibernation.SnapshotID != "" {
+		// Started while the snapshot was still being taken, it is deleted once completed
+		progress, err := r.cloudProviderAPI.GetVolumeSnapshotProgress(ctx, hibernation.SnapshotID)
+		if err != nil {
+			check.Logger().Warn("hibernation snapshot did not complete, keeping it", "snapshotID", hibernation.SnapshotID, "error", err)
+			obj.Status.Hibernation = nil
+			return check.Passed()
+		}
+		if progress < 100 {
+			hibernation.Progress = progress
+			return check.UpdateMsg(fmt.Sprintf("waiting for hibernation snapshot to complete before deleting it (%d%%)", progress)).RequeueAfter(r.Cfg.WorkMachine.HibernationCheckInterval)
+		}
+		if err := r.cloudProviderAPI.DeleteVolumeSnapshot(ctx, hibernation.SnapshotID); err != nil {
+			return check.Failed(fmt.Errorf("failed to delete hibernation snapshot %s: %w", hibernation.SnapshotID, err))
+		}
+	}
+
+	obj.Status.Hibernation = nil
+	return check.Passed()
+}
+
+// isWaking reports whether the volume of a hibernated machine has been restored, but the machine
+// has not been ready on it yet
+func isWaking(obj *v1.WorkMachine) bool {
+	hibernation := obj.Status.Hibernation
+	return hibernation != nil && hibernation.HibernatedAt != nil && !hibernation.VolumeReleased
+}
@@ -0,0 +1,261 @@
+package workmachine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllerconfig"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestShouldHibernate tests when a stopped WorkMachine is due for hibernation
+func TestShouldHibernate(t *testing.T) {
+	stoppedAt := func(d time.Duration) *metav1.Time {
+		return &metav1.Time{Time: time.Now().Add(-d)}
+	}
+
+	tests := []struct {
+		name         string
+		provider     v1.CloudProvider
+		hibernation  *v1.HibernationConfig
+		specState    v1.MachineState
+		machineState v1.MachineState
+		stoppedAt    *metav1.Time
+		expected     bool
+	}{
+		{
+			name:         "stopped longer than policy",
+			provider:     v1.AWS,
+			hibernation:  &v1.HibernationConfig{Enabled: true, AfterStoppedDays: 7},
+			specState:    v1.MachineStateStopped,
+			machineState: v1.MachineStateStopped,
+			stoppedAt:    stoppedAt(8 * 24 * time.Hour),
+			expected:     true,
+		},
+		{
+			name:         "stopped shorter than policy",
+			provider:     v1.GCP,
+			hibernation:  &v1.HibernationConfig{Enabled: true, AfterStoppedDays: 7},
+			specState:    v1.MachineStateStopped,
+			machineState: v1.MachineStateStopped,
+			stoppedAt:    stoppedAt(6 * 24 * time.Hour),
+			expected:     false,
+		},
+		{
+			name:         "hibernation not configured",
+			provider:     v1.AWS,
+			specState:    v1.MachineStateStopped,
+			machineState: v1.MachineStateStopped,
+			stoppedAt:    stoppedAt(30 * 24 * time.Hour),
+			expected:     false,
+		},
+		{
+			name:         "hibernation disabled",
+			provider:     v1.Azure,
+			hibernation:  &v1.HibernationConfig{Enabled: false, AfterStoppedDays: 1},
+			specState:    v1.MachineStateStopped,
+			machineState: v1.MachineStateStopped,
+			stoppedAt:    stoppedAt(30 * 24 * time.Hour),
+			expected:     false,
+		},
+		{
+			name:         "machine still stopping",
+			provider:     v1.OCI,
+			hibernation:  &v1.HibernationConfig{Enabled: true, AfterStoppedDays: 1},
+			specState:    v1.MachineStateStopped,
+			machineState: v1.MachineStateStopping,
+			stoppedAt:    stoppedAt(2 * 24 * time.Hour),
+			expected:     false,
+		},
+		{
+			name:         "machine should run",
+			provider:     v1.AWS,
+			hibernation:  &v1.HibernationConfig{Enabled: true, AfterStoppedDays: 1},
+			specState:    v1.MachineStateRunning,
+			machineState: v1.MachineStateStopped,
+			stoppedAt:    stoppedAt(2 * 24 * time.Hour),
+			expected:     false,
+		},
+		{
+			name:         "stop time unknown",
+			provider:     v1.AWS,
+			hibernation:  &v1.HibernationConfig{Enabled: true, AfterStoppedDays: 1},
+			specState:    v1.MachineStateStopped,
+			machineState: v1.MachineStateStopped,
+			expected:     false,
+		},
+		{
+			name:         "byo hosts have no cloud volume",
+			provider:     v1.BYO,
+			hibernation:  &v1.HibernationConfig{Enabled: true, AfterStoppedDays: 1},
+			specState:    v1.MachineStateStopped,
+			machineState: v1.MachineStateStopped,
+			stoppedAt:    stoppedAt(2 * 24 * time.Hour),
+			expected:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &WorkMachineReconciler{env: Env{CloudProvider: tt.provider}}
+			obj := &v1.WorkMachine{
+				Spec: v1.WorkMachineSpec{
+					State:       tt.specState,
+					Hibernation: tt.hibernation,
+				},
+				Status: v1.WorkMachineStatus{
+					StoppedAt: tt.stoppedAt,
+				},
+			}
+
+			result := r.shouldHibernate(obj, &v1.MachineInfo{State: tt.machineState})
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestWakeMachine tests that the hibernation snapshot is kept until the machine is ready on the restored volume
+func TestWakeMachine(t *testing.T) {
+	hibernated := func() *v1.WorkMachine {
+		return &v1.WorkMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "wm-alice"},
+			Spec:       v1.WorkMachineSpec{State: v1.MachineStateRunning},
+			Status: v1.WorkMachineStatus{
+				MachineInfo: v1.MachineInfo{MachineID: "i-123"},
+				Hibernation: &v1.HibernationStatus{
+					SnapshotID:     "snap-1",
+					Progress:       100,
+					VolumeReleased: true,
+					HibernatedAt:   &metav1.Time{Time: time.Now().Add(-48 * time.Hour)},
+				},
+			},
+		}
+	}
+
+	t.Run("restores the volume and keeps the snapshot while starting", func(t *testing.T) {
+		provider := &fakeProvider{}
+		r := &WorkMachineReconciler{cloudProviderAPI: provider}
+		check, obj, _ := newTestCheck(t, hibernated())
+
+		result := r.handleHibernation(check, obj, &v1.MachineInfo{State: v1.MachineStateStopped}, false)
+		assert.True(t, result.ShouldProceed())
+		assert.Equal(t, []string{"snap-1"}, provider.restoredVolumes)
+		assert.Empty(t, provider.deletedSnapshots)
+		require.NotNil(t, obj.Status.Hibernation)
+		assert.False(t, obj.Status.Hibernation.VolumeReleased)
+		assert.True(t, isWaking(obj))
+
+		// Running, but the node has not joined yet
+		result = r.handleHibernation(check, obj, &v1.MachineInfo{State: v1.MachineStateRunning}, false)
+		assert.True(t, result.ShouldProceed())
+		assert.Len(t, provider.restoredVolumes, 1, "volume restored once")
+		assert.Empty(t, provider.deletedSnapshots)
+	})
+
+	t.Run("deletes the snapshot once the machine is ready", func(t *testing.T) {
+		provider := &fakeProvider{}
+		r := &WorkMachineReconciler{cloudProviderAPI: provider}
+		obj := hibernated()
+		obj.Status.Hibernation.VolumeReleased = false
+		check, obj, _ := newTestCheck(t, obj)
+
+		result := r.handleHibernation(check, obj, &v1.MachineInfo{State: v1.MachineStateRunning}, true)
+		assert.True(t, result.ShouldProceed())
+		assert.Empty(t, provider.restoredVolumes)
+		assert.Equal(t, []string{"snap-1"}, provider.deletedSnapshots)
+		assert.Nil(t, obj.Status.Hibernation)
+	})
+
+	t.Run("waits for the volume to be restored", func(t *testing.T) {
+		provider := &fakeProvider{restorePending: true}
+		r := &WorkMachineReconciler{
+			cloudProviderAPI: provider,
+			Cfg:              &controllerconfig.ControllerConfig{WorkMachine: controllerconfig.WorkMachineConfig{HibernationCheckInterval: time.Second}},
+		}
+		check, obj, _ := newTestCheck(t, hibernated())
+
+		result := r.handleHibernation(check, obj, &v1.MachineInfo{State: v1.MachineStateStopped}, false)
+		assert.False(t, result.ShouldProceed())
+		require.NotNil(t, obj.Status.Hibernation)
+		assert.True(t, obj.Status.Hibernation.VolumeReleased)
+	})
+
+	t.Run("keeps a snapshot still in progress until it completes", func(t *testing.T) {
+		provider := &fakeProvider{snapshotProgress: 40}
+		r := &WorkMachineReconciler{
+			cloudProviderAPI: provider,
+			Cfg:              &controllerconfig.ControllerConfig{WorkMachine: controllerconfig.WorkMachineConfig{HibernationCheckInterval: time.Second}},
+		}
+		obj := hibernated()
+		obj.Status.Hibernation = &v1.HibernationStatus{SnapshotID: "snap-1", Progress: 40}
+		check, obj, _ := newTestCheck(t, obj)
+
+		result := r.handleHibernation(check, obj, &v1.MachineInfo{State: v1.MachineStateRunning}, true)
+		assert.False(t, result.ShouldProceed())
+		assert.Empty(t, provider.deletedSnapshots)
+		require.NotNil(t, obj.Status.Hibernation)
+
+		provider.snapshotProgress = 100
+		result = r.handleHibernation(check, obj, &v1.MachineInfo{State: v1.MachineStateRunning}, true)
+		assert.True(t, result.ShouldProceed())
+		assert.Equal(t, []string{"snap-1"}, provider.deletedSnapshots)
+		assert.Nil(t, obj.Status.Hibernation)
+	})
+
+	t.Run("stopped again before ready keeps the snapshot", func(t *testing.T) {
+		provider := &fakeProvider{}
+		r := &WorkMachineReconciler{env: Env{CloudProvider: v1.AWS}, cloudProviderAPI: provider}
+		obj := hibernated()
+		obj.Spec.State = v1.MachineStateStopped
+		obj.Status.Hibernation.VolumeReleased = false
+		check, obj, _ := newTestCheck(t, obj)
+
+		result := r.handleHibernation(check, obj, &v1.MachineInfo{State: v1.MachineStateStopped}, false)
+		assert.True(t, result.ShouldProceed())
+		assert.Empty(t, provider.calls)
+		require.NotNil(t, obj.Status.Hibernation)
+		assert.Equal(t, "snap-1", obj.Status.Hibernation.SnapshotID)
+	})
+}
+
+// TestDeleteCloudMachineHibernationSnapshot tests that deleting a hibernated machine keeps the
+// snapshot when its storage volume is to be kept
+func TestDeleteCloudMachineHibernationSnapshot(t *testing.T) {
+	tests := []struct {
+		name           string
+		deleteVolume   bool
+		volumeReleased bool
+		wantDeleted    bool
+	}{
+		{name: "hibernated, volume kept", deleteVolume: false, volumeReleased: true, wantDeleted: false},
+		{name: "hibernated, volume deleted", deleteVolume: true, volumeReleased: true, wantDeleted: true},
+		{name: "volume restored, volume kept", deleteVolume: false, volumeReleased: false, wantDeleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{}
+			r := &WorkMachineReconciler{cloudProviderAPI: provider}
+			check, obj, _ := newTestCheck(t, &v1.WorkMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "wm-alice"},
+				Spec:       v1.WorkMachineSpec{DeleteVolumePostTermination: tt.deleteVolume},
+				Status: v1.WorkMachineStatus{
+					MachineInfo: v1.MachineInfo{MachineID: "i-123"},
+					Hibernation: &v1.HibernationStatus{SnapshotID: "snap-1", VolumeReleased: tt.volumeReleased},
+				},
+			})
+
+			result := r.deleteCloudMachine(check, obj)
+			assert.True(t, result.ShouldProceed())
+			assert.Contains(t, provider.calls, "DeleteMachine")
+			if tt.wantDeleted {
+				assert.Equal(t, []string{"snap-1"}, provider.deletedSnapshots)
+			} else {
+				assert.Empty(t, provider.deletedSnapshots)
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package workmachine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	environmentV1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Spot interruption handling
+//
+// When the cloud announces that a spot instance will be reclaimed, the node manager on the
+// machine creates SnapshotRequests (labeled with the WorkMachine and LabelSnapshotReason) for
+// every active workspace and environment, then annotates its Node with AnnotationInterruptionNotice.
+//
+// The controller then:
+// 1. Marks the machine interrupted, records and suspends the active workspaces and environments
+// 2. Waits for the emergency snapshots (bounded by InterruptionSnapshotTimeout)
+// 3. Deletes the cloud machine and node, so the next reconcile creates a new machine
+// 4. Once the new node is ready, restores the snapshots and resumes what was running, including
+//    what has no snapshot to restore
+//
+// Providers report MachineStateInterrupted from their own preemption signal, so machines without a
+// notice on their node (a reclaimed GCP or Azure machine is simply stopped) are detected as well.
+
+// isInterrupted reports whether the spot machine has been, or is about to be, reclaimed
+func (r *WorkMachineReconciler) isInterrupted(obj *v1.WorkMachine, machineInfo *v1.MachineInfo, node *corev1.Node, nodeExists bool) bool {
+	if !obj.Status.Capacity.IsSpot() || machineInfo == nil {
+		return false
+	}
+
+	if obj.Status.State == v1.MachineStateInterrupted || machineInfo.State == v1.MachineStateInterrupted {
+		return true
+	}
+
+	return nodeExists && node.Annotations[v1.AnnotationInterruptionNotice] != ""
+}
+
+// handleInterruption waits for the emergency snapshots, then deletes the interrupted machine
+func (r *WorkMachineReconciler) handleInterruption(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	ctx := check.Context()
+
+	if obj.Status.State != v1.MachineStateInterrupted {
+		check.Logger().Warn("spot machine interrupted", "machineID", obj.Status.MachineID)
+		if err := r.recordInterruptedWorkloads(ctx, obj); err != nil {
+			return check.Failed(err)
+		}
+		obj.Status.State = v1.MachineStateInterrupted
+		obj.Status.InterruptedAt = &metav1.Time{Time: time.Now()}
+		obj.Status.InterruptionCount++
+	}
+	obj.Status.Message = "Spot capacity reclaimed by the cloud provider, saving workspaces and environments"
+
+	// Nothing may run on the new machine before its data is restored
+	if err := r.suspendAllWorkspaces(ctx, obj.Name); err != nil {
+		return check.Failed(fmt.Errorf("failed to suspend workspaces of interrupted machine: %w", err))
+	}
+	if err := r.deactivateAllEnvironments(ctx, obj.Name); err != nil {
+		return check.Failed(fmt.Errorf("failed to deactivate environments of interrupted machine: %w", err))
+	}
+
+	requests := &snapshotv1.SnapshotRequestList{}
+	if err := r.List(ctx, requests, interruptionSelector(obj)); err != nil {
+		return check.Failed(fmt.Errorf("failed to list interruption snapshot requests: %w", err))
+	}
+
+	pending := 0
+	for _, req := range requests.Items {
+		if req.Status.State != snapshotv1.SnapshotRequestStateCompleted &&
+			req.Status.State != snapshotv1.SnapshotRequestStateFailed {
+			pending++
+		}
+	}
+
+	if pending > 0 && time.Since(obj.Status.InterruptedAt.Time) < r.Cfg.WorkMachine.InterruptionSnapshotTimeout {
+		return check.UpdateMsg(fmt.Sprintf("waiting for %d emergency snapshot(s)", pending)).RequeueAfter(r.Cfg.WorkMachine.InterruptionRetryInterval)
+	}
+	if pending > 0 {
+		check.Logger().Warn("emergency snapshots did not complete in time", "pending", pending)
+	}
+
+	if result := r.deleteKubernetesNode(check, obj); !result.ShouldProceed() {
+		return result
+	}
+
+	machineID := obj.Status.MachineID
+	if err := r.cloudProviderAPI.DeleteMachine(ctx, machineID); err != nil {
+		return check.Failed(fmt.Errorf("failed to delete interrupted machine %s: %w", machineID, err))
+	}
+
+	r.usageReporter.ReportEvent(ctx, UsageEvent{
+		EventType:    "workmachine.stopped",
+		ResourceID:   machineID,
+		ResourceType: "workmachine." + obj.Spec.MachineType,
+		Timestamp:    time.Now(),
+	})
+
+	// An empty machine ID makes the next reconcile create a new machine
+	obj.Status.MachineInfo = v1.MachineInfo{State: v1.MachineStateInterrupted}
+	obj.Status.Message = "Interrupted machine deleted, creating a new machine"
+	check.Logger().Info("deleted interrupted machine", "machineID", machineID)
+	return check.UpdateMsg("recreating interrupted machine").RequeueAfter(r.Cfg.WorkMachine.CloudMachineCreationRetryInterval)
+}
+
+// recordInterruptedWorkloads remembers the active workspaces and environments of the machine, to
+// resume them on the new machine
+func (r *WorkMachineReconciler) recordInterruptedWorkloads(ctx context.Context, obj *v1.WorkMachine) error {
+	workspaceList := &workspacev1.WorkspaceList{}
+	if err := r.List(ctx, workspaceList); err != nil {
+		return fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	obj.Status.InterruptedWorkspaces = nil
+	for _, ws := range workspaceList.Items {
+		if ws.Spec.WorkmachineName != obj.Name || ws.Spec.Status == "suspended" || ws.Spec.Status == "archived" {
+			continue
+		}
+		obj.Status.InterruptedWorkspaces = append(obj.Status.InterruptedWorkspaces, ws.Namespace+"/"+ws.Name)
+	}
+
+	envList := &environmentV1.EnvironmentList{}
+	if err := r.List(ctx, envList); err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+	obj.Status.InterruptedEnvironments = nil
+	for _, env := range envList.Items {
+		if env.Spec.WorkMachineName != obj.Name || !env.Spec.Activated {
+			continue
+		}
+		obj.Status.InterruptedEnvironments = append(obj.Status.InterruptedEnvironments, env.Namespace+"/"+env.Name)
+	}
+	return nil
+}
+
+// restoreAfterInterruption restores the emergency snapshots on the new machine
+// Once the restores are done, whether they succeeded or not, and also for workloads whose snapshot
+// did not complete, the workspaces and environments that were running are resumed.
+func (r *WorkMachineReconciler) restoreAfterInterruption(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	if obj.Status.InterruptedAt == nil || obj.Status.State != v1.MachineStateRunning {
+		return check.Passed()
+	}
+
+	ctx := check.Context()
+	selector := interruptionSelector(obj)
+
+	requests := &snapshotv1.SnapshotRequestList{}
+	if err := r.List(ctx, requests, selector); err != nil {
+		return check.Failed(fmt.Errorf("failed to list interruption snapshot requests: %w", err))
+	}
+
+	for i := range requests.Items {
+		req := &requests.Items[i]
+
+		if req.Status.State == snapshotv1.SnapshotRequestStateCompleted {
+			var err error
+			switch req.Labels["snapshots.kloudlite.io/type"] {
+			case "environment":
+				err = r.createEnvironmentRestore(check, obj, req)
+			case "workspace":
+				err = r.createWorkspaceRestore(check, obj, req)
+			}
+			if err != nil {
+				return check.Failed(err)
+			}
+		} else {
+			// Requests that did not complete before the old machine was deleted never will
+			check.Logger().Warn("emergency snapshot not available, skipping restore",
+				"request", req.Name, "namespace", req.Namespace, "state", req.Status.State)
+		}
+
+		if err := r.Delete(ctx, req); err != nil && !apiErrors.IsNotFound(err) {
+			return check.Failed(fmt.Errorf("failed to delete snapshot request %s: %w", req.Name, err))
+		}
+	}
+
+	inProgress := 0
+
+	restores := &snapshotv1.SnapshotRestoreList{}
+	if err := r.List(ctx, restores, selector); err != nil {
+		return check.Failed(fmt.Errorf("failed to list interruption snapshot restores: %w", err))
+	}
+	for i := range restores.Items {
+		restore := &restores.Items[i]
+		if restore.Status.State != snapshotv1.SnapshotRestoreStateCompleted &&
+			restore.Status.State != snapshotv1.SnapshotRestoreStateFailed {
+			inProgress++
+			continue
+		}
+
+		if restore.Status.State == snapshotv1.SnapshotRestoreStateFailed {
+			check.Logger().Warn("workspace restore failed", "restore", restore.Name, "message", restore.Status.Message)
+		}
+		if err := r.Delete(ctx, restore); err != nil && !apiErrors.IsNotFound(err) {
+			return check.Failed(fmt.Errorf("failed to delete snapshot restore %s: %w", restore.Name, err))
+		}
+	}
+
+	envRestores := &environmentV1.EnvironmentSnapshotRestoreList{}
+	if err := r.List(ctx, envRestores, selector); err != nil {
+		return check.Failed(fmt.Errorf("failed to list interruption environment restores: %w", err))
+	}
+	for i := range envRestores.Items {
+		restore := &envRestores.Items[i]
+		if restore.Status.Phase != environmentV1.EnvironmentSnapshotRestorePhaseCompleted &&
+			restore.Status.Phase != environmentV1.EnvironmentSnapshotRestorePhaseFailed {
+			inProgress++
+			continue
+		}
+
+		if restore.Status.Phase == environmentV1.EnvironmentSnapshotRestorePhaseFailed {
+			check.Logger().Warn("environment restore failed", "restore", restore.Name, "message", restore.Status.Message)
+		}
+		if err := r.Delete(ctx, restore); err != nil && !apiErrors.IsNotFound(err) {
+			return check.Failed(fmt.Errorf("failed to delete environment restore %s: %w", restore.Name, err))
+		}
+	}
+
+	if inProgress > 0 {
+		obj.Status.Message = "Restoring workspaces and environments after spot interruption"
+		return check.UpdateMsg(fmt.Sprintf("waiting for %d restore(s)", inProgress)).RequeueAfter(r.Cfg.WorkMachine.InterruptionRetryInterval)
+	}
+
+	for _, ref := range obj.Status.InterruptedWorkspaces {
+		namespace, name, _ := strings.Cut(ref, "/")
+		if err := r.resumeWorkspace(check, namespace, name); err != nil {
+			return check.Failed(err)
+		}
+	}
+	for _, ref := range obj.Status.InterruptedEnvironments {
+		namespace, name, _ := strings.Cut(ref, "/")
+		if err := r.reactivateEnvironment(ctx, namespace, name); err != nil {
+			return check.Failed(err)
+		}
+	}
+
+	check.Logger().Info("restored state after spot interruption",
+		"workspaces", len(obj.Status.InterruptedWorkspaces),
+		"environments", len(obj.Status.InterruptedEnvironments))
+	obj.Status.InterruptedAt = nil
+	obj.Status.InterruptedWorkspaces = nil
+	obj.Status.InterruptedEnvironments = nil
+	return check.Passed()
+}
+
+// createEnvironmentRestore restores an environment snapshot, activating the environment afterwards
+func (r *WorkMachineReconciler) createEnvironmentRestore(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine, req *snapshotv1.SnapshotRequest) error {
+	envName := req.Labels["snapshots.kloudlite.io/environment"]
+
+	// Environments live in the WorkMachine namespace, the request in the environment's target namespace
+	envList := &environmentV1.EnvironmentList{}
+	if err := r.List(check.Context(), envList); err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	for _, env := range envList.Items {
+		if env.Name != envName || env.Spec.TargetNamespace != req.Namespace {
+			continue
+		}
+
+		restore := &environmentV1.EnvironmentSnapshotRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "restore-" + req.Spec.SnapshotName,
+				Namespace: req.Namespace,
+				Labels:    interruptionLabels(obj),
+			},
+			Spec: environmentV1.EnvironmentSnapshotRestoreSpec{
+				EnvironmentName:      env.Name,
+				EnvironmentNamespace: env.Namespace,
+				SnapshotName:         req.Spec.SnapshotName,
+				SourceNamespace:      req.Namespace,
+				ActivateAfterRestore: true,
+			},
+		}
+		if err := r.Create(check.Context(), restore); err != nil && !apiErrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create restore for environment %s: %w", env.Name, err)
+		}
+		check.Logger().Info("restoring environment after spot interruption", "environment", env.Name, "snapshot", req.Spec.SnapshotName)
+		return nil
+	}
+
+	check.Logger().Warn("environment of emergency snapshot not found", "environment", envName, "namespace", req.Namespace)
+	return nil
+}
+
+// createWorkspaceRestore restores a workspace snapshot to the workspace storage on the new machine
+func (r *WorkMachineReconciler) createWorkspaceRestore(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine, req *snapshotv1.SnapshotRequest) error {
+	labels := interruptionLabels(obj)
+	labels["snapshots.kloudlite.io/workspace"] = req.Labels["snapshots.kloudlite.io/workspace"]
+
+	restore := &snapshotv1.SnapshotRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restore-" + req.Spec.SnapshotName,
+			Namespace: req.Namespace,
+			Labels:    labels,
+		},
+		Spec: snapshotv1.SnapshotRestoreSpec{
+			SnapshotName: req.Spec.SnapshotName,
+			TargetPath:   req.Spec.SourcePath,
+			NodeName:     obj.Name,
+		},
+	}
+	if err := r.Create(check.Context(), restore); err != nil && !apiErrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create restore for workspace %s: %w", labels["snapshots.kloudlite.io/workspace"], err)
+	}
+	check.Logger().Info("restoring workspace after spot interruption", "workspace", labels["snapshots.kloudlite.io/workspace"], "snapshot", req.Spec.SnapshotName)
+	return nil
+}
+
+// resumeWorkspace sets a workspace suspended by the interruption back to active
+func (r *WorkMachineReconciler) resumeWorkspace(check *reconciler.Check[*v1.WorkMachine], namespace, name string) error {
+	workspace := &workspacev1.Workspace{}
+	if err := r.Get(check.Context(), client.ObjectKey{Namespace: namespace, Name: name}, workspace); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get workspace %s: %w", name, err)
+	}
+
+	if workspace.Spec.Status != "suspended" {
+		return nil
+	}
+
+	workspace.Spec.Status = "active"
+	if err := r.Update(check.Context(), workspace); err != nil {
+		return fmt.Errorf("failed to resume workspace %s: %w", name, err)
+	}
+	return nil
+}
+
+// interruptionLabels are the labels of snapshot requests and restores created for an interruption
+func interruptionLabels(obj *v1.WorkMachine) map[string]string {
+	return map[string]string{
+		"kloudlite.io/workmachine": obj.Name,
+		v1.LabelSnapshotReason:     v1.SnapshotReasonInterruption,
+	}
+}
+
+func interruptionSelector(obj *v1.WorkMachine) client.MatchingLabels {
+	return client.MatchingLabels(interruptionLabels(obj))
+}
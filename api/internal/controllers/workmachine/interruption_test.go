@@ -0,0 +1,113 @@
+package workmachine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllerconfig"
+	environmentV1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestIsInterrupted tests that only the provider and the node manager report interruptions
+func TestIsInterrupted(t *testing.T) {
+	spot := &v1.WorkMachine{
+		Spec:   v1.WorkMachineSpec{State: v1.MachineStateRunning},
+		Status: v1.WorkMachineStatus{MachineInfo: v1.MachineInfo{State: v1.MachineStateRunning, Capacity: v1.CapacitySpot}},
+	}
+	noticed := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.AnnotationInterruptionNotice: "2026-10-19T12:00:00Z"}}}
+
+	tests := []struct {
+		name       string
+		obj        *v1.WorkMachine
+		info       *v1.MachineInfo
+		node       *corev1.Node
+		nodeExists bool
+		want       bool
+	}{
+		{name: "provider reports interrupted", obj: spot, info: &v1.MachineInfo{State: v1.MachineStateInterrupted}, want: true},
+		{name: "interruption notice on the node", obj: spot, info: &v1.MachineInfo{State: v1.MachineStateRunning}, node: noticed, nodeExists: true, want: true},
+		{name: "stopped outside of kloudlite", obj: spot, info: &v1.MachineInfo{State: v1.MachineStateStopped}, want: false},
+		{
+			name: "on-demand machine",
+			obj:  &v1.WorkMachine{Status: v1.WorkMachineStatus{MachineInfo: v1.MachineInfo{State: v1.MachineStateRunning}}},
+			info: &v1.MachineInfo{State: v1.MachineStateInterrupted},
+			want: false,
+		},
+	}
+
+	r := &WorkMachineReconciler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.isInterrupted(tt.obj, tt.info, tt.node, tt.nodeExists))
+		})
+	}
+}
+
+// TestRestoreAfterInterruptionResumesWithoutSnapshots tests that workloads without a restorable
+// snapshot are resumed on the new machine
+func TestRestoreAfterInterruptionResumesWithoutSnapshots(t *testing.T) {
+	workspace := &workspacev1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "wm-alice"},
+		Spec:       workspacev1.WorkspaceSpec{WorkmachineName: "wm-alice", OwnedBy: "alice", Status: "suspended"},
+	}
+	environment := &environmentV1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "wm-alice"},
+		Spec:       environmentV1.EnvironmentSpec{WorkMachineName: "wm-alice", TargetNamespace: "env-staging"},
+	}
+	failedRequest := &snapshotv1.SnapshotRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dev-interruption",
+			Namespace: "wm-alice",
+			Labels: map[string]string{
+				"kloudlite.io/workmachine":         "wm-alice",
+				v1.LabelSnapshotReason:             v1.SnapshotReasonInterruption,
+				"snapshots.kloudlite.io/type":      "workspace",
+				"snapshots.kloudlite.io/workspace": "dev",
+			},
+		},
+		Status: snapshotv1.SnapshotRequestStatus{State: snapshotv1.SnapshotRequestStateFailed},
+	}
+
+	wm := &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "wm-alice"},
+		Spec:       v1.WorkMachineSpec{OwnedBy: "alice", State: v1.MachineStateRunning},
+		Status: v1.WorkMachineStatus{
+			MachineInfo:             v1.MachineInfo{MachineID: "i-456", State: v1.MachineStateRunning},
+			InterruptedAt:           &metav1.Time{Time: time.Now().Add(-time.Hour)},
+			InterruptedWorkspaces:   []string{"wm-alice/dev"},
+			InterruptedEnvironments: []string{"wm-alice/staging"},
+		},
+	}
+	check, obj, c := newTestCheck(t, wm, workspace, environment, failedRequest)
+	r := &WorkMachineReconciler{
+		Client: c,
+		Cfg:    &controllerconfig.ControllerConfig{WorkMachine: controllerconfig.WorkMachineConfig{InterruptionRetryInterval: time.Second}},
+	}
+
+	result := r.restoreAfterInterruption(check, obj)
+	require.True(t, result.ShouldProceed())
+	assert.Nil(t, obj.Status.InterruptedAt)
+	assert.Empty(t, obj.Status.InterruptedWorkspaces)
+	assert.Empty(t, obj.Status.InterruptedEnvironments)
+
+	ws := &workspacev1.Workspace{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(workspace), ws))
+	assert.Equal(t, "active", ws.Spec.Status)
+
+	env := &environmentV1.Environment{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(environment), env))
+	assert.True(t, env.Spec.Activated)
+
+	requests := &snapshotv1.SnapshotRequestList{}
+	require.NoError(t, c.List(context.Background(), requests))
+	assert.Empty(t, requests.Items)
+}
@@ -3,9 +3,12 @@ package workmachine
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	environmentV1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
 	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
 	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
 	"github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/reconciler"
@@ -18,15 +21,18 @@ import (
 // handleMachineTypeChange handles the machine type change process
 // This is only applicable for cloud provider machines (AWS, GCP, Azure)
 //
-// The machine type change process follows these steps:
-// 1. Suspend all workspaces
-// 2. Deactivate all environments
-// 3. Verify workspaces are suspended
-// 4. Verify environments are deactivated
-// 5. Stop the machine
-// 6. Change the machine type (cloud provider API)
-// 7. Start the machine
-// 8. Wait for node to rejoin cluster and be ready
+// Changing the type of a running machine goes through the phases of Status.Resize, each one
+// reported in Status.MachineTypeChangeMessage:
+// 1. Snapshotting: snapshot the active workspaces and environments
+// 2. Draining: warn users with a countdown of MachineTypeChangeDrainPeriod
+// 3. Stopping: suspend workspaces, deactivate environments and stop the machine
+// 4. Changing: change the machine type (cloud provider API)
+// 5. Starting: start the machine and wait for its node to be ready
+// 6. Restoring: resume the workspaces and environments that were active
+//
+// A failed change, or a machine not ready within MachineTypeChangeTimeout, goes through
+// RollingBack: the machine is changed back to its previous type, restarted and restored,
+// and spec.machineType is reverted. A stopped machine is changed without being started.
 func (r *WorkMachineReconciler) handleMachineTypeChange(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
 	// Early return: Only applicable for cloud machines
 	if obj.Status.MachineID == "" {
@@ -39,18 +45,18 @@ func (r *WorkMachineReconciler) handleMachineTypeChange(check *reconciler.Check[
 		return check.Passed()
 	}
 
+	// Execute the machine type change state machine
+	if obj.Status.Resize != nil {
+		return r.executeMachineTypeChange(check, obj)
+	}
+
 	// Early return: No change requested
 	if !r.hasMachineTypeChanged(obj) {
 		return check.Passed()
 	}
 
-	// Early return: Initialize the change process
-	if !obj.Status.MachineTypeChanging {
-		return r.initiateMachineTypeChange(check, obj)
-	}
-
-	// Execute the machine type change state machine
-	return r.executeMachineTypeChange(check, obj)
+	// Initialize the change process
+	return r.initiateMachineTypeChange(check, obj)
 }
 
 // hasMachineTypeChanged checks if the machine type has changed from current state
@@ -72,7 +78,17 @@ func (r *WorkMachineReconciler) initiateMachineTypeChange(check *reconciler.Chec
 	newType := obj.Spec.MachineType
 
 	obj.Status.MachineTypeChanging = true
-	obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Starting machine type change from %s to %s", oldType, newType)
+	obj.Status.Resize = &v1.ResizeStatus{
+		FromMachineType: oldType,
+		ToMachineType:   newType,
+	}
+
+	// Nothing runs on a stopped machine, it only needs its type changed
+	if obj.Spec.State == v1.MachineStateRunning && obj.Status.State == v1.MachineStateRunning {
+		r.setResizePhase(obj, v1.ResizePhaseSnapshotting, fmt.Sprintf("Starting machine type change from %s to %s", oldType, newType))
+	} else {
+		r.setResizePhase(obj, v1.ResizePhaseStopping, fmt.Sprintf("Changing machine type of stopped machine from %s to %s", oldType, newType))
+	}
 
 	check.Logger().Info("Machine type change initiated",
 		"from", oldType,
@@ -84,9 +100,170 @@ func (r *WorkMachineReconciler) initiateMachineTypeChange(check *reconciler.Chec
 }
 
 // executeMachineTypeChange executes the state machine for machine type change
-// Each step is a separate function that returns early when not ready to proceed
+// Each phase returns early when not ready to proceed
 func (r *WorkMachineReconciler) executeMachineTypeChange(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
-	// Step 1-2: Suspend workspaces and deactivate environments
+	resize := obj.Status.Resize
+
+	// Until workloads are stopped, the change follows spec.machineType, or is cancelled
+	if resize.Phase == v1.ResizePhaseSnapshotting || resize.Phase == v1.ResizePhaseDraining {
+		if obj.Spec.MachineType == resize.FromMachineType {
+			return r.cancelMachineTypeChange(check, obj)
+		}
+		resize.ToMachineType = obj.Spec.MachineType
+	}
+
+	switch resize.Phase {
+	case v1.ResizePhaseSnapshotting:
+		return r.snapshotBeforeResize(check, obj)
+	case v1.ResizePhaseDraining:
+		return r.drainBeforeResize(check, obj)
+	case v1.ResizePhaseStopping:
+		return r.stopForResize(check, obj)
+	case v1.ResizePhaseChanging:
+		return r.changeMachineType(check, obj)
+	case v1.ResizePhaseStarting:
+		return r.waitForNodeReady(check, obj)
+	case v1.ResizePhaseRestoring:
+		return r.restoreAfterResize(check, obj)
+	case v1.ResizePhaseRollingBack:
+		return r.rollbackMachineType(check, obj)
+	}
+
+	return check.Failed(fmt.Errorf("unknown machine type change phase %q", resize.Phase))
+}
+
+// setResizePhase moves the machine type change to phase
+func (r *WorkMachineReconciler) setResizePhase(obj *v1.WorkMachine, phase v1.ResizePhase, message string) {
+	obj.Status.Resize.Phase = phase
+	obj.Status.Resize.PhaseStartedAt = &metav1.Time{Time: time.Now()}
+	r.setResizeMessage(obj, message)
+}
+
+// setResizeMessage reports the progress of the current phase
+func (r *WorkMachineReconciler) setResizeMessage(obj *v1.WorkMachine, message string) {
+	obj.Status.MachineTypeChangeMessage = fmt.Sprintf("%s: %s", obj.Status.Resize.Phase, message)
+}
+
+// resizeWait requeues the machine type change with the current message
+func (r *WorkMachineReconciler) resizeWait(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine, message string, after time.Duration) reconciler.StepResult {
+	r.setResizeMessage(obj, message)
+	return check.UpdateMsg(obj.Status.MachineTypeChangeMessage).RequeueAfter(after)
+}
+
+// snapshotBeforeResize snapshots the active workspaces and environments, and remembers them to restore their state
+func (r *WorkMachineReconciler) snapshotBeforeResize(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	ctx := check.Context()
+	resize := obj.Status.Resize
+	suffix := "resize-" + resize.PhaseStartedAt.UTC().Format("20060102150405")
+
+	envList := &environmentV1.EnvironmentList{}
+	if err := r.List(ctx, envList); err != nil {
+		return check.Failed(fmt.Errorf("failed to list environments: %w", err))
+	}
+
+	resize.ActiveEnvironments = nil
+	for _, env := range envList.Items {
+		if env.Spec.WorkMachineName != obj.Name || !env.Spec.Activated {
+			continue
+		}
+		resize.ActiveEnvironments = append(resize.ActiveEnvironments, env.Namespace+"/"+env.Name)
+
+		parentSnapshot := ""
+		if env.Status.LastRestoredSnapshot != nil {
+			parentSnapshot = env.Status.LastRestoredSnapshot.Name
+		}
+
+		labels := resizeLabels(obj)
+		labels["kloudlite.io/owned-by"] = env.Spec.OwnedBy
+		labels["snapshots.kloudlite.io/environment"] = env.Name
+		labels["snapshots.kloudlite.io/type"] = "environment"
+
+		if err := r.createResizeSnapshotRequest(ctx, obj, env.Spec.TargetNamespace, env.Name+"-"+suffix, labels, snapshotv1.SnapshotRequestSpec{
+			SourcePath:     fmt.Sprintf("/var/lib/kloudlite/storage/environments/%s", env.Spec.TargetNamespace),
+			Owner:          env.Spec.OwnedBy,
+			ParentSnapshot: parentSnapshot,
+			Description:    fmt.Sprintf("Snapshot of environment %s before changing the machine type to %s", env.Name, resize.ToMachineType),
+		}); err != nil {
+			return check.Failed(err)
+		}
+	}
+
+	workspaceList := &workspacev1.WorkspaceList{}
+	if err := r.List(ctx, workspaceList); err != nil {
+		return check.Failed(fmt.Errorf("failed to list workspaces: %w", err))
+	}
+
+	resize.ActiveWorkspaces = nil
+	for _, ws := range workspaceList.Items {
+		if ws.Spec.WorkmachineName != obj.Name || ws.Spec.Status == "suspended" || ws.Spec.Status == "archived" {
+			continue
+		}
+		resize.ActiveWorkspaces = append(resize.ActiveWorkspaces, ws.Namespace+"/"+ws.Name)
+
+		labels := resizeLabels(obj)
+		labels["kloudlite.io/owned-by"] = ws.Spec.OwnedBy
+		labels["snapshots.kloudlite.io/workspace"] = ws.Name
+		labels["snapshots.kloudlite.io/type"] = "workspace"
+
+		if err := r.createResizeSnapshotRequest(ctx, obj, ws.Namespace, ws.Name+"-"+suffix, labels, snapshotv1.SnapshotRequestSpec{
+			SourcePath:  fmt.Sprintf("/var/lib/kloudlite/storage/workspaces/%s", ws.Name),
+			Owner:       ws.Spec.OwnedBy,
+			Description: fmt.Sprintf("Snapshot of workspace %s before changing the machine type to %s", ws.Name, resize.ToMachineType),
+		}); err != nil {
+			return check.Failed(err)
+		}
+	}
+
+	requests := &snapshotv1.SnapshotRequestList{}
+	if err := r.List(ctx, requests, client.MatchingLabels(resizeLabels(obj))); err != nil {
+		return check.Failed(fmt.Errorf("failed to list resize snapshot requests: %w", err))
+	}
+
+	pending := 0
+	for _, req := range requests.Items {
+		if req.Status.State != snapshotv1.SnapshotRequestStateCompleted &&
+			req.Status.State != snapshotv1.SnapshotRequestStateFailed {
+			pending++
+		}
+	}
+
+	if pending > 0 && time.Since(resize.PhaseStartedAt.Time) < r.Cfg.WorkMachine.MachineTypeChangeSnapshotTimeout {
+		return r.resizeWait(check, obj, fmt.Sprintf("Waiting for %d snapshot(s)", pending), r.Cfg.WorkMachine.MachineTypeChangeRetryInterval)
+	}
+	if pending > 0 {
+		check.Logger().Warn("snapshots before machine type change did not complete in time", "pending", pending)
+	}
+
+	// Nobody to warn when nothing is running
+	if len(resize.ActiveWorkspaces) == 0 && len(resize.ActiveEnvironments) == 0 {
+		r.setResizePhase(obj, v1.ResizePhaseStopping, "Stopping machine")
+		return r.stopForResize(check, obj)
+	}
+
+	r.setResizePhase(obj, v1.ResizePhaseDraining, "Warning users")
+	resize.DrainDeadline = &metav1.Time{Time: time.Now().Add(r.Cfg.WorkMachine.MachineTypeChangeDrainPeriod)}
+	return r.drainBeforeResize(check, obj)
+}
+
+// drainBeforeResize counts down until workspaces and environments are stopped
+func (r *WorkMachineReconciler) drainBeforeResize(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	resize := obj.Status.Resize
+
+	if remaining := time.Until(resize.DrainDeadline.Time); remaining > 0 {
+		message := fmt.Sprintf("Workspaces and environments stop in %s for the change to %s", remaining.Round(time.Second), resize.ToMachineType)
+		return r.resizeWait(check, obj, message, min(remaining, 30*time.Second))
+	}
+
+	r.setResizePhase(obj, v1.ResizePhaseStopping, "Stopping workspaces and environments")
+	return r.stopForResize(check, obj)
+}
+
+// stopForResize suspends workspaces, deactivates environments and stops the machine
+func (r *WorkMachineReconciler) stopForResize(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	if err := r.recordResizeWorkloads(check.Context(), obj); err != nil {
+		return check.Failed(err)
+	}
+
 	if result := r.ensureWorkspacesSuspended(check, obj); !result.ShouldProceed() {
 		return result
 	}
@@ -95,7 +272,6 @@ func (r *WorkMachineReconciler) executeMachineTypeChange(check *reconciler.Check
 		return result
 	}
 
-	// Step 3-4: Verify all workspaces and environments are in desired state
 	if result := r.verifyWorkspacesSuspended(check, obj); !result.ShouldProceed() {
 		return result
 	}
@@ -104,42 +280,65 @@ func (r *WorkMachineReconciler) executeMachineTypeChange(check *reconciler.Check
 		return result
 	}
 
-	// Step 5: Stop the machine
 	if result := r.ensureMachineStopped(check, obj); !result.ShouldProceed() {
 		return result
 	}
 
-	// Step 6-7: Change machine type and start machine
-	if result := r.changeMachineType(check, obj); !result.ShouldProceed() {
-		return result
+	r.setResizePhase(obj, v1.ResizePhaseChanging, fmt.Sprintf("Changing machine type to %s", obj.Status.Resize.ToMachineType))
+	return r.changeMachineType(check, obj)
+}
+
+// recordResizeWorkloads adds the workspaces and environments started since the snapshots to the
+// workloads to resume, they are suspended with the others
+func (r *WorkMachineReconciler) recordResizeWorkloads(ctx context.Context, obj *v1.WorkMachine) error {
+	resize := obj.Status.Resize
+
+	workspaceList := &workspacev1.WorkspaceList{}
+	if err := r.List(ctx, workspaceList); err != nil {
+		return fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	for _, ws := range workspaceList.Items {
+		if ws.Spec.WorkmachineName != obj.Name || ws.Spec.Status == "suspended" || ws.Spec.Status == "archived" {
+			continue
+		}
+		if ref := ws.Namespace + "/" + ws.Name; !slices.Contains(resize.ActiveWorkspaces, ref) {
+			resize.ActiveWorkspaces = append(resize.ActiveWorkspaces, ref)
+		}
 	}
 
-	// Step 8: Wait for node to be ready
-	return r.waitForNodeReady(check, obj)
+	envList := &environmentV1.EnvironmentList{}
+	if err := r.List(ctx, envList); err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+	for _, env := range envList.Items {
+		if env.Spec.WorkMachineName != obj.Name || !env.Spec.Activated {
+			continue
+		}
+		if ref := env.Namespace + "/" + env.Name; !slices.Contains(resize.ActiveEnvironments, ref) {
+			resize.ActiveEnvironments = append(resize.ActiveEnvironments, ref)
+		}
+	}
+	return nil
 }
 
 // ensureWorkspacesSuspended ensures all workspaces are suspended
-// Returns early if suspension is in progress
 func (r *WorkMachineReconciler) ensureWorkspacesSuspended(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
 	if err := r.suspendAllWorkspaces(check.Context(), obj.Name); err != nil {
-		obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Failed to suspend workspaces: %v", err)
+		r.setResizeMessage(obj, fmt.Sprintf("Failed to suspend workspaces: %v", err))
 		return check.Failed(err)
 	}
 
-	obj.Status.MachineTypeChangeMessage = "Workspaces suspended"
 	check.Logger().Info("All workspaces suspended", "workMachine", obj.Name)
 	return check.Passed()
 }
 
 // ensureEnvironmentsDeactivated ensures all environments are deactivated
-// Returns early if deactivation is in progress
 func (r *WorkMachineReconciler) ensureEnvironmentsDeactivated(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
 	if err := r.deactivateAllEnvironments(check.Context(), obj.Name); err != nil {
-		obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Failed to deactivate environments: %v", err)
+		r.setResizeMessage(obj, fmt.Sprintf("Failed to deactivate environments: %v", err))
 		return check.Failed(err)
 	}
 
-	obj.Status.MachineTypeChangeMessage = "Environments deactivated, workspaces suspended"
 	check.Logger().Info("All environments deactivated", "workMachine", obj.Name)
 	return check.Passed()
 }
@@ -154,8 +353,7 @@ func (r *WorkMachineReconciler) verifyWorkspacesSuspended(check *reconciler.Chec
 
 	activeWorkspaceCount := r.countActiveWorkspaces(workspaceList, obj.Name)
 	if activeWorkspaceCount > 0 {
-		obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Waiting for %d workspaces to suspend", activeWorkspaceCount)
-		return check.UpdateMsg(obj.Status.MachineTypeChangeMessage).RequeueAfter(r.Cfg.WorkMachine.MachineTypeChangeRetryInterval)
+		return r.resizeWait(check, obj, fmt.Sprintf("Waiting for %d workspaces to suspend", activeWorkspaceCount), r.Cfg.WorkMachine.MachineTypeChangeRetryInterval)
 	}
 
 	return check.Passed()
@@ -171,8 +369,7 @@ func (r *WorkMachineReconciler) verifyEnvironmentsDeactivated(check *reconciler.
 
 	activeEnvironmentCount := r.countActiveEnvironments(envList, obj.Name)
 	if activeEnvironmentCount > 0 {
-		obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Waiting for %d environments to deactivate", activeEnvironmentCount)
-		return check.UpdateMsg(obj.Status.MachineTypeChangeMessage).RequeueAfter(r.Cfg.WorkMachine.MachineTypeChangeRetryInterval)
+		return r.resizeWait(check, obj, fmt.Sprintf("Waiting for %d environments to deactivate", activeEnvironmentCount), r.Cfg.WorkMachine.MachineTypeChangeRetryInterval)
 	}
 
 	return check.Passed()
@@ -192,45 +389,28 @@ func (r *WorkMachineReconciler) ensureMachineStopped(check *reconciler.Check[*v1
 			return check.Failed(fmt.Errorf("failed to stop machine: %w", err))
 		}
 		obj.Status.State = v1.MachineStateStopping
-		obj.Status.MachineTypeChangeMessage = "Stopping machine"
-		return check.UpdateMsg(obj.Status.MachineTypeChangeMessage).RequeueAfter(r.Cfg.WorkMachine.CloudMachineStopRetryInterval)
+		return r.resizeWait(check, obj, "Stopping machine", r.Cfg.WorkMachine.CloudMachineStopRetryInterval)
 	}
 
-	// If machine is stopping, wait for it to complete
-	if machineInfo.State == v1.MachineStateStopping {
-		obj.Status.MachineTypeChangeMessage = "Waiting for machine to stop"
-		return check.UpdateMsg(obj.Status.MachineTypeChangeMessage).RequeueAfter(r.Cfg.WorkMachine.CloudMachineStopRetryInterval)
+	// Wait for the machine to be stopped
+	if machineInfo.State != v1.MachineStateStopped {
+		return r.resizeWait(check, obj, "Waiting for machine to stop", r.Cfg.WorkMachine.CloudMachineStopRetryInterval)
 	}
 
-	// Machine is stopped, proceed to next step
 	return check.Passed()
 }
 
 // changeMachineType changes the machine type via cloud provider API and starts the machine
-// Returns with requeue after initiating the start
+// A failure rolls the machine back to its previous type
 func (r *WorkMachineReconciler) changeMachineType(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
-	// Only proceed if machine is stopped
-	machineInfo, err := r.cloudProviderAPI.GetMachineStatus(check.Context(), obj.Status.MachineID)
-	if err != nil {
-		return check.Failed(fmt.Errorf("failed to get machine status: %w", err))
-	}
-
-	if machineInfo.State != v1.MachineStateStopped {
-		// Machine not stopped yet, wait for ensureMachineStopped step
-		return check.UpdateMsg("Waiting for machine to stop before changing type").RequeueAfter(r.Cfg.WorkMachine.MachineTypeChangeRetryInterval)
-	}
+	resize := obj.Status.Resize
+	oldType := resize.FromMachineType
+	newType := resize.ToMachineType
 
-	// Change the machine type via cloud provider API
-	if err := r.cloudProviderAPI.ChangeMachine(check.Context(), obj.Status.MachineID, obj.Spec.MachineType); err != nil {
-		return check.Failed(fmt.Errorf("failed to change machine type: %w", err))
+	if err := r.cloudProviderAPI.ChangeMachine(check.Context(), obj.Status.MachineID, newType); err != nil {
+		return r.rollbackResize(check, obj, fmt.Errorf("failed to change machine type to %s: %w", newType, err))
 	}
 
-	// Update current machine type to reflect the change
-	oldType := obj.Status.CurrentMachineType
-	newType := obj.Spec.MachineType
-	obj.Status.CurrentMachineType = newType
-	obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Machine type changed to %s", newType)
-
 	r.usageReporter.ReportEvent(check.Context(), UsageEvent{
 		EventType:    "workmachine.resized",
 		ResourceID:   obj.Status.MachineID,
@@ -244,49 +424,246 @@ func (r *WorkMachineReconciler) changeMachineType(check *reconciler.Check[*v1.Wo
 		"newType", newType,
 		"workMachine", obj.Name)
 
-	// Start the machine with the new type
-	if err := r.cloudProviderAPI.StartMachine(check.Context(), obj.Status.MachineID); err != nil {
-		return check.Failed(fmt.Errorf("failed to start machine after type change: %w", err))
+	// A stopped machine stays stopped
+	if obj.Spec.State != v1.MachineStateRunning {
+		obj.Status.CurrentMachineType = newType
+		obj.Status.MachineTypeChanging = false
+		obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Machine type change complete: %s → %s", oldType, newType)
+		obj.Status.Resize = nil
+		return check.Passed()
 	}
 
-	obj.Status.State = v1.MachineStateStarting
-	obj.Status.MachineTypeChangeMessage = "Starting machine with new type"
-	return check.UpdateMsg(obj.Status.MachineTypeChangeMessage).RequeueAfter(10 * time.Second)
+	r.setResizePhase(obj, v1.ResizePhaseStarting, fmt.Sprintf("Starting machine with type %s", newType))
+	return r.waitForNodeReady(check, obj)
 }
 
-// waitForNodeReady waits for the Kubernetes node to rejoin and become ready
-// Returns passed when node is ready, otherwise requeues
+// waitForNodeReady starts the machine and waits for the Kubernetes node to rejoin and become ready
+// A machine not ready within MachineTypeChangeTimeout is rolled back to its previous type
 func (r *WorkMachineReconciler) waitForNodeReady(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
-	// Only proceed if we're in starting state
-	if obj.Status.State != v1.MachineStateStarting {
-		return check.Passed()
+	resize := obj.Status.Resize
+
+	machineType := resize.ToMachineType
+	if resize.RolledBack {
+		machineType = resize.FromMachineType
+	}
+
+	if time.Since(resize.PhaseStartedAt.Time) > r.Cfg.WorkMachine.MachineTypeChangeTimeout {
+		err := fmt.Errorf("machine not ready with type %s after %s", machineType, r.Cfg.WorkMachine.MachineTypeChangeTimeout)
+		if !resize.RolledBack {
+			return r.rollbackResize(check, obj, err)
+		}
+		// Nothing left to roll back to, keep waiting
+		check.Logger().Warn("machine not ready after rollback", "machineType", machineType, "workMachine", obj.Name)
+	}
+
+	machineInfo, err := r.cloudProviderAPI.GetMachineStatus(check.Context(), obj.Status.MachineID)
+	if err != nil {
+		return check.Failed(fmt.Errorf("failed to get machine status: %w", err))
+	}
+
+	if machineInfo.State == v1.MachineStateStopped {
+		if err := r.cloudProviderAPI.StartMachine(check.Context(), obj.Status.MachineID); err != nil {
+			if !resize.RolledBack {
+				return r.rollbackResize(check, obj, fmt.Errorf("failed to start machine with type %s: %w", machineType, err))
+			}
+			return check.Failed(fmt.Errorf("failed to start machine after rollback: %w", err))
+		}
+		obj.Status.State = v1.MachineStateStarting
+		return r.resizeWait(check, obj, fmt.Sprintf("Starting machine with type %s", machineType), r.Cfg.WorkMachine.CloudMachineStartRetryInterval)
 	}
 
 	// Get the node
 	node := &corev1.Node{}
 	if err := r.Get(check.Context(), client.ObjectKey{Name: obj.Name}, node); err != nil {
 		if apiErrors.IsNotFound(err) {
-			obj.Status.MachineTypeChangeMessage = "Waiting for node to rejoin cluster"
-			return check.UpdateMsg(obj.Status.MachineTypeChangeMessage).RequeueAfter(10 * time.Second)
+			return r.resizeWait(check, obj, "Waiting for node to rejoin cluster", r.Cfg.WorkMachine.NodeJoinCheckInterval)
 		}
 		return check.Failed(fmt.Errorf("failed to get node: %w", err))
 	}
 
 	// Check if node is ready
-	if !r.isNodeReady(node) {
-		obj.Status.MachineTypeChangeMessage = "Node joined, waiting for node to be ready"
-		return check.UpdateMsg(obj.Status.MachineTypeChangeMessage).RequeueAfter(5 * time.Second)
+	if machineInfo.State != v1.MachineStateRunning || !r.isNodeReady(node) {
+		return r.resizeWait(check, obj, "Node joined, waiting for node to be ready", r.Cfg.WorkMachine.NodeReadyRetryInterval)
+	}
+
+	obj.Status.State = v1.MachineStateRunning
+	r.setResizePhase(obj, v1.ResizePhaseRestoring, "Resuming workspaces and environments")
+	return r.restoreAfterResize(check, obj)
+}
+
+// restoreAfterResize resumes the workspaces and environments that were active before the change
+func (r *WorkMachineReconciler) restoreAfterResize(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	ctx := check.Context()
+	resize := obj.Status.Resize
+
+	for _, ref := range resize.ActiveWorkspaces {
+		namespace, name, _ := strings.Cut(ref, "/")
+		if err := r.resumeWorkspace(check, namespace, name); err != nil {
+			return check.Failed(err)
+		}
+	}
+
+	for _, ref := range resize.ActiveEnvironments {
+		namespace, name, _ := strings.Cut(ref, "/")
+		if err := r.reactivateEnvironment(ctx, namespace, name); err != nil {
+			return check.Failed(err)
+		}
 	}
 
-	// Machine type change complete - mark as running
-	r.markMachineTypeChangeComplete(obj, node)
+	// The snapshots stay available, only the requests are cleaned up
+	if err := r.deleteResizeSnapshotRequests(ctx, obj); err != nil {
+		return check.Failed(err)
+	}
+
+	if resize.Error != "" {
+		return r.finishResizeRollback(check, obj)
+	}
+
+	r.markMachineTypeChangeComplete(obj)
 	check.Logger().Info("Machine type change completed successfully",
-		"newType", obj.Spec.MachineType,
+		"newType", obj.Status.CurrentMachineType,
+		"workMachine", obj.Name)
+	return check.Passed()
+}
+
+// rollbackResize starts rolling the machine back to its previous type
+func (r *WorkMachineReconciler) rollbackResize(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine, err error) reconciler.StepResult {
+	resize := obj.Status.Resize
+	resize.Error = err.Error()
+
+	check.Logger().Error("Machine type change failed, rolling back",
+		"error", err,
+		"from", resize.FromMachineType,
+		"to", resize.ToMachineType,
 		"workMachine", obj.Name)
 
+	r.setResizePhase(obj, v1.ResizePhaseRollingBack, fmt.Sprintf("Rolling back to %s: %v", resize.FromMachineType, err))
+	return check.UpdateMsg(obj.Status.MachineTypeChangeMessage).RequeueAfter(r.Cfg.WorkMachine.MachineTypeChangeRetryInterval)
+}
+
+// rollbackMachineType stops the machine and changes it back to its previous type
+func (r *WorkMachineReconciler) rollbackMachineType(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	if result := r.ensureMachineStopped(check, obj); !result.ShouldProceed() {
+		return result
+	}
+
+	resize := obj.Status.Resize
+
+	if err := r.cloudProviderAPI.ChangeMachine(check.Context(), obj.Status.MachineID, resize.FromMachineType); err != nil {
+		r.setResizeMessage(obj, fmt.Sprintf("Failed to change machine back to %s: %v", resize.FromMachineType, err))
+		return check.Failed(fmt.Errorf("failed to roll back machine type to %s: %w", resize.FromMachineType, err))
+	}
+	resize.RolledBack = true
+
+	check.Logger().Info("Machine type rolled back", "machineType", resize.FromMachineType, "workMachine", obj.Name)
+
+	if obj.Spec.State != v1.MachineStateRunning {
+		return r.finishResizeRollback(check, obj)
+	}
+
+	r.setResizePhase(obj, v1.ResizePhaseStarting, fmt.Sprintf("Starting machine with type %s", resize.FromMachineType))
+	return r.waitForNodeReady(check, obj)
+}
+
+// finishResizeRollback reverts spec.machineType, so the failed change is not retried
+func (r *WorkMachineReconciler) finishResizeRollback(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	resize := obj.Status.Resize
+
+	if obj.Spec.MachineType == resize.ToMachineType {
+		// Patch a copy, the status of obj is written by the reconciler
+		wm := obj.DeepCopy()
+		patch := client.MergeFrom(obj.DeepCopy())
+		wm.Spec.MachineType = resize.FromMachineType
+		if err := r.Patch(check.Context(), wm, patch); err != nil {
+			return check.Failed(fmt.Errorf("failed to revert machine type to %s: %w", resize.FromMachineType, err))
+		}
+		obj.Spec.MachineType = resize.FromMachineType
+		obj.ResourceVersion = wm.ResourceVersion
+	}
+
+	obj.Status.CurrentMachineType = resize.FromMachineType
+	obj.Status.MachineTypeChanging = false
+	obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Machine type change to %s failed, rolled back to %s: %s",
+		resize.ToMachineType, resize.FromMachineType, resize.Error)
+	obj.Status.Resize = nil
 	return check.Passed()
 }
 
+// cancelMachineTypeChange stops a change that was reverted before anything was stopped
+func (r *WorkMachineReconciler) cancelMachineTypeChange(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	if err := r.deleteResizeSnapshotRequests(check.Context(), obj); err != nil {
+		return check.Failed(err)
+	}
+
+	check.Logger().Info("Machine type change cancelled", "machineType", obj.Spec.MachineType, "workMachine", obj.Name)
+	obj.Status.MachineTypeChanging = false
+	obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Machine type change to %s cancelled", obj.Status.Resize.ToMachineType)
+	obj.Status.Resize = nil
+	return check.Passed()
+}
+
+// createResizeSnapshotRequest asks the host manager of the machine for a snapshot
+func (r *WorkMachineReconciler) createResizeSnapshotRequest(ctx context.Context, obj *v1.WorkMachine, namespace, snapshotName string, labels map[string]string, spec snapshotv1.SnapshotRequestSpec) error {
+	spec.SnapshotName = snapshotName
+	spec.NodeName = obj.Name
+
+	req := &snapshotv1.SnapshotRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "req-" + snapshotName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: spec,
+	}
+	if err := r.Create(ctx, req); err != nil && !apiErrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create snapshot request %s/%s: %w", namespace, req.Name, err)
+	}
+	return nil
+}
+
+// deleteResizeSnapshotRequests deletes the snapshot requests of a machine type change
+func (r *WorkMachineReconciler) deleteResizeSnapshotRequests(ctx context.Context, obj *v1.WorkMachine) error {
+	requests := &snapshotv1.SnapshotRequestList{}
+	if err := r.List(ctx, requests, client.MatchingLabels(resizeLabels(obj))); err != nil {
+		return fmt.Errorf("failed to list resize snapshot requests: %w", err)
+	}
+	for i := range requests.Items {
+		if err := r.Delete(ctx, &requests.Items[i]); err != nil && !apiErrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete snapshot request %s: %w", requests.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// reactivateEnvironment activates an environment deactivated for the change
+func (r *WorkMachineReconciler) reactivateEnvironment(ctx context.Context, namespace, name string) error {
+	env := &environmentV1.Environment{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, env); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get environment %s: %w", name, err)
+	}
+
+	if env.Spec.Activated {
+		return nil
+	}
+
+	env.Spec.Activated = true
+	if err := r.Update(ctx, env); err != nil {
+		return fmt.Errorf("failed to reactivate environment %s: %w", name, err)
+	}
+	return nil
+}
+
+// resizeLabels are the labels of snapshot requests created for a machine type change
+func resizeLabels(obj *v1.WorkMachine) map[string]string {
+	return map[string]string{
+		"kloudlite.io/workmachine": obj.Name,
+		v1.LabelSnapshotReason:     v1.SnapshotReasonResize,
+	}
+}
+
 // countActiveWorkspaces counts the number of active workspaces for a given WorkMachine
 func (r *WorkMachineReconciler) countActiveWorkspaces(list *workspacev1.WorkspaceList, workMachineName string) int {
 	count := 0
@@ -327,13 +704,18 @@ func (r *WorkMachineReconciler) isNodeReady(node *corev1.Node) bool {
 }
 
 // markMachineTypeChangeComplete marks the machine type change as complete
-func (r *WorkMachineReconciler) markMachineTypeChangeComplete(obj *v1.WorkMachine, node *corev1.Node) {
+func (r *WorkMachineReconciler) markMachineTypeChangeComplete(obj *v1.WorkMachine) {
+	oldType, newType := obj.Status.CurrentMachineType, obj.Spec.MachineType
+	if obj.Status.Resize != nil {
+		oldType, newType = obj.Status.Resize.FromMachineType, obj.Status.Resize.ToMachineType
+	}
+
 	obj.Status.State = v1.MachineStateRunning
+	obj.Status.CurrentMachineType = newType
 	obj.Status.MachineTypeChanging = false
-	obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Machine type change complete: %s → %s",
-		obj.Status.CurrentMachineType,
-		obj.Spec.MachineType)
+	obj.Status.MachineTypeChangeMessage = fmt.Sprintf("Machine type change complete: %s → %s", oldType, newType)
 	obj.Status.StartedAt = &metav1.Time{Time: time.Now()}
+	obj.Status.Resize = nil
 }
 
 // suspendAllWorkspaces suspends all active workspaces on the WorkMachine
@@ -1,14 +1,20 @@
 package workmachine
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/kloudlite/kloudlite/api/internal/controllerconfig"
 	environmentV1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
 	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
 	"github.com/stretchr/testify/assert"
-	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // TestIsNodeReady tests the isNodeReady helper function
@@ -418,22 +424,8 @@ func TestMarkMachineTypeChangeComplete(t *testing.T) {
 		},
 	}
 
-	node := &corev1.Node{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "test-node",
-		},
-		Status: corev1.NodeStatus{
-			Conditions: []corev1.NodeCondition{
-				{
-					Type:   corev1.NodeReady,
-					Status: corev1.ConditionTrue,
-				},
-			},
-		},
-	}
-
 	r := &WorkMachineReconciler{}
-	r.markMachineTypeChangeComplete(obj, node)
+	r.markMachineTypeChangeComplete(obj)
 
 	assert.Equal(t, v1.MachineStateRunning, obj.Status.State, "State should be Running")
 	assert.False(t, obj.Status.MachineTypeChanging, "MachineTypeChanging should be false")
@@ -494,3 +486,270 @@ func TestMachineTypeChangeInitializeFirstTime(t *testing.T) {
 	assert.Equal(t, "t3.medium", obj.Status.CurrentMachineType)
 	assert.False(t, obj.Status.MachineTypeChanging)
 }
+
+// TestMachineTypeChangePhases tests the transition of each resize phase, from a reconcile of the WorkMachine in that phase
+func TestMachineTypeChangePhases(t *testing.T) {
+	ago := func(d time.Duration) *metav1.Time {
+		return &metav1.Time{Time: time.Now().Add(-d)}
+	}
+	resizing := func(phase v1.ResizePhase) *v1.ResizeStatus {
+		return &v1.ResizeStatus{
+			Phase:           phase,
+			FromMachineType: "t3.medium",
+			ToMachineType:   "t3.large",
+			PhaseStartedAt:  ago(time.Minute),
+		}
+	}
+	activeWorkspace := &workspacev1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "wm-alice"},
+		Spec:       workspacev1.WorkspaceSpec{WorkmachineName: "wm-alice", OwnedBy: "alice", Status: "active"},
+	}
+	suspendedWorkspace := &workspacev1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "wm-alice"},
+		Spec:       workspacev1.WorkspaceSpec{WorkmachineName: "wm-alice", OwnedBy: "alice", Status: "suspended"},
+	}
+	readyNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "wm-alice"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		resize       *v1.ResizeStatus
+		state        v1.MachineState // Spec and status state of the WorkMachine
+		objs         []client.Object
+		machineState v1.MachineState
+		changeErr    error
+
+		wantPhase        v1.ResizePhase // Empty once the change is over
+		wantCalls        []string
+		wantMachineTypes []string
+		verify           func(t *testing.T, obj *v1.WorkMachine, c client.Client)
+	}{
+		{
+			name:      "change requested on a running machine starts with snapshots",
+			state:     v1.MachineStateRunning,
+			wantPhase: v1.ResizePhaseSnapshotting,
+		},
+		{
+			name:      "change requested on a stopped machine skips to stopping",
+			state:     v1.MachineStateStopped,
+			wantPhase: v1.ResizePhaseStopping,
+		},
+		{
+			name:      "snapshotting with active workspaces drains",
+			resize:    resizing(v1.ResizePhaseSnapshotting),
+			state:     v1.MachineStateRunning,
+			objs:      []client.Object{activeWorkspace},
+			wantPhase: v1.ResizePhaseDraining,
+			verify: func(t *testing.T, obj *v1.WorkMachine, c client.Client) {
+				assert.Equal(t, []string{"wm-alice/dev"}, obj.Status.Resize.ActiveWorkspaces)
+				assert.NotNil(t, obj.Status.Resize.DrainDeadline)
+			},
+		},
+		{
+			name:         "snapshotting without workloads stops the machine",
+			resize:       resizing(v1.ResizePhaseSnapshotting),
+			state:        v1.MachineStateRunning,
+			machineState: v1.MachineStateRunning,
+			wantPhase:    v1.ResizePhaseStopping,
+			wantCalls:    []string{"GetMachineStatus", "StopMachine"},
+		},
+		{
+			name: "draining until the deadline",
+			resize: func() *v1.ResizeStatus {
+				resize := resizing(v1.ResizePhaseDraining)
+				resize.DrainDeadline = &metav1.Time{Time: time.Now().Add(time.Minute)}
+				return resize
+			}(),
+			state:     v1.MachineStateRunning,
+			objs:      []client.Object{activeWorkspace},
+			wantPhase: v1.ResizePhaseDraining,
+		},
+		{
+			name: "drained workspaces are suspended and the machine stopped",
+			resize: func() *v1.ResizeStatus {
+				resize := resizing(v1.ResizePhaseDraining)
+				resize.DrainDeadline = ago(time.Second)
+				return resize
+			}(),
+			state:        v1.MachineStateRunning,
+			objs:         []client.Object{activeWorkspace},
+			machineState: v1.MachineStateRunning,
+			wantPhase:    v1.ResizePhaseStopping,
+			wantCalls:    []string{"GetMachineStatus", "StopMachine"},
+			verify: func(t *testing.T, obj *v1.WorkMachine, c client.Client) {
+				ws := &workspacev1.Workspace{}
+				require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(activeWorkspace), ws))
+				assert.Equal(t, "suspended", ws.Spec.Status)
+			},
+		},
+		{
+			name: "workspaces started while draining are resumed too",
+			resize: func() *v1.ResizeStatus {
+				resize := resizing(v1.ResizePhaseDraining)
+				resize.DrainDeadline = ago(time.Second)
+				resize.ActiveWorkspaces = []string{"wm-alice/api"}
+				return resize
+			}(),
+			state:        v1.MachineStateRunning,
+			objs:         []client.Object{activeWorkspace},
+			machineState: v1.MachineStateRunning,
+			wantPhase:    v1.ResizePhaseStopping,
+			wantCalls:    []string{"GetMachineStatus", "StopMachine"},
+			verify: func(t *testing.T, obj *v1.WorkMachine, c client.Client) {
+				assert.Equal(t, []string{"wm-alice/api", "wm-alice/dev"}, obj.Status.Resize.ActiveWorkspaces)
+			},
+		},
+		{
+			name:             "stopped machine is changed and started",
+			resize:           resizing(v1.ResizePhaseStopping),
+			state:            v1.MachineStateRunning,
+			machineState:     v1.MachineStateStopped,
+			wantPhase:        v1.ResizePhaseStarting,
+			wantCalls:        []string{"GetMachineStatus", "ChangeMachine", "GetMachineStatus", "StartMachine"},
+			wantMachineTypes: []string{"t3.large"},
+		},
+		{
+			name:             "changing a stopped machine that stays stopped completes",
+			resize:           resizing(v1.ResizePhaseChanging),
+			state:            v1.MachineStateStopped,
+			wantCalls:        []string{"ChangeMachine"},
+			wantMachineTypes: []string{"t3.large"},
+			verify: func(t *testing.T, obj *v1.WorkMachine, c client.Client) {
+				assert.Equal(t, "t3.large", obj.Status.CurrentMachineType)
+				assert.False(t, obj.Status.MachineTypeChanging)
+			},
+		},
+		{
+			name:      "failed change rolls back",
+			resize:    resizing(v1.ResizePhaseChanging),
+			state:     v1.MachineStateRunning,
+			changeErr: errors.New("insufficient capacity"),
+			wantPhase: v1.ResizePhaseRollingBack,
+			wantCalls: []string{"ChangeMachine"},
+			verify: func(t *testing.T, obj *v1.WorkMachine, c client.Client) {
+				assert.Contains(t, obj.Status.Resize.Error, "insufficient capacity")
+				assert.False(t, obj.Status.Resize.RolledBack)
+			},
+		},
+		{
+			name:             "rolling back restores the original type and starts the machine",
+			resize:           resizing(v1.ResizePhaseRollingBack),
+			state:            v1.MachineStateRunning,
+			machineState:     v1.MachineStateStopped,
+			wantPhase:        v1.ResizePhaseStarting,
+			wantCalls:        []string{"GetMachineStatus", "ChangeMachine", "GetMachineStatus", "StartMachine"},
+			wantMachineTypes: []string{"t3.medium"},
+			verify: func(t *testing.T, obj *v1.WorkMachine, c client.Client) {
+				assert.True(t, obj.Status.Resize.RolledBack)
+			},
+		},
+		{
+			name:         "starting waits for the node",
+			resize:       resizing(v1.ResizePhaseStarting),
+			state:        v1.MachineStateRunning,
+			machineState: v1.MachineStateRunning,
+			wantPhase:    v1.ResizePhaseStarting,
+			wantCalls:    []string{"GetMachineStatus"},
+		},
+		{
+			name: "machine not ready in time rolls back",
+			resize: func() *v1.ResizeStatus {
+				resize := resizing(v1.ResizePhaseStarting)
+				resize.PhaseStartedAt = ago(time.Hour)
+				return resize
+			}(),
+			state:     v1.MachineStateRunning,
+			wantPhase: v1.ResizePhaseRollingBack,
+		},
+		{
+			name: "ready node restores workspaces and completes",
+			resize: func() *v1.ResizeStatus {
+				resize := resizing(v1.ResizePhaseStarting)
+				resize.ActiveWorkspaces = []string{"wm-alice/dev"}
+				return resize
+			}(),
+			state:        v1.MachineStateRunning,
+			objs:         []client.Object{suspendedWorkspace, readyNode},
+			machineState: v1.MachineStateRunning,
+			wantCalls:    []string{"GetMachineStatus"},
+			verify: func(t *testing.T, obj *v1.WorkMachine, c client.Client) {
+				assert.Equal(t, "t3.large", obj.Status.CurrentMachineType)
+				assert.False(t, obj.Status.MachineTypeChanging)
+
+				ws := &workspacev1.Workspace{}
+				require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(suspendedWorkspace), ws))
+				assert.Equal(t, "active", ws.Spec.Status)
+			},
+		},
+		{
+			name: "ready node after rollback reverts the machine type",
+			resize: func() *v1.ResizeStatus {
+				resize := resizing(v1.ResizePhaseStarting)
+				resize.RolledBack = true
+				resize.Error = "failed to change machine type to t3.large: insufficient capacity"
+				return resize
+			}(),
+			state:        v1.MachineStateRunning,
+			objs:         []client.Object{readyNode},
+			machineState: v1.MachineStateRunning,
+			wantCalls:    []string{"GetMachineStatus"},
+			verify: func(t *testing.T, obj *v1.WorkMachine, c client.Client) {
+				assert.Equal(t, "t3.medium", obj.Status.CurrentMachineType)
+				assert.Contains(t, obj.Status.MachineTypeChangeMessage, "rolled back to t3.medium")
+
+				wm := &v1.WorkMachine{}
+				require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "wm-alice"}, wm))
+				assert.Equal(t, "t3.medium", wm.Spec.MachineType)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{machineState: tt.machineState, changeMachineErr: tt.changeErr}
+			wm := &v1.WorkMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "wm-alice"},
+				Spec:       v1.WorkMachineSpec{OwnedBy: "alice", MachineType: "t3.large", State: tt.state},
+				Status: v1.WorkMachineStatus{
+					MachineInfo:         v1.MachineInfo{MachineID: "i-123", State: tt.state},
+					CurrentMachineType:  "t3.medium",
+					MachineTypeChanging: tt.resize != nil,
+					Resize:              tt.resize,
+				},
+			}
+			check, obj, c := newTestCheck(t, wm, tt.objs...)
+			r := &WorkMachineReconciler{
+				Client:           c,
+				cloudProviderAPI: provider,
+				Cfg: &controllerconfig.ControllerConfig{WorkMachine: controllerconfig.WorkMachineConfig{
+					MachineTypeChangeRetryInterval:   time.Second,
+					MachineTypeChangeDrainPeriod:     time.Hour,
+					MachineTypeChangeTimeout:         10 * time.Minute,
+					CloudMachineStopRetryInterval:    time.Second,
+					CloudMachineStartRetryInterval:   time.Second,
+					NodeJoinCheckInterval:            time.Second,
+					NodeReadyRetryInterval:           time.Second,
+					MachineTypeChangeSnapshotTimeout: 0, // Do not wait for snapshots
+				}},
+			}
+
+			r.handleMachineTypeChange(check, obj)
+
+			if tt.wantPhase == "" {
+				assert.Nil(t, obj.Status.Resize)
+			} else {
+				require.NotNil(t, obj.Status.Resize)
+				assert.Equal(t, tt.wantPhase, obj.Status.Resize.Phase)
+			}
+			assert.Equal(t, tt.wantCalls, provider.calls)
+			assert.Equal(t, tt.wantMachineTypes, provider.machineTypes)
+			if tt.verify != nil {
+				tt.verify(t, obj, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package workmachine
+
+import (
+	"fmt"
+	"strings"
+
+	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+)
+
+// A WorkMachineMigration moves the workspaces and environments of a WorkMachine to a new one:
+// 1. Provisioning: the target WorkMachine is created, in the placement of the migration, and both
+//    machines are started. The target is provisioned with the cloud provider of its placement.
+// 2. Stopping: the workspaces of the source are suspended and its environments deactivated
+// 3. Snapshotting: the host manager of the source pushes every workspace home and environment
+//    volume to the snapshot registry (SnapshotRequest)
+// 4. Restoring: the host manager of the target pulls them back into place (SnapshotRestore)
+// 5. SwitchingOver: workspaces and environments are repointed to the target, and the namespace of
+//    the source, where they live, is handed over to the target
+// 6. DeletingSource: the source WorkMachine is deleted, leaving the handed over namespace alone.
+//    The target then adopts it as its targetNamespace, so that workspace pods are created next to
+//    their workspaces, deletes the namespace it was created with and the workloads that were
+//    active are resumed.
+//
+// Both machines run their components (host-manager, tunnel-server...) under the same names in
+// their targetNamespace, so the target only adopts the namespace of the source once it is deleted.
+//
+// A migration failing before SwitchingOver deletes the target and resumes everything on the
+// source. The snapshots are kept, only their requests and restores are cleaned up.
+
+// labelMigration is set on the target WorkMachine, and the snapshot requests and restores of a migration
+const labelMigration = "machines.kloudlite.io/migration"
+
+// migrationTargetSpec is the spec of the target WorkMachine of a migration
+func migrationTargetSpec(source *v1.WorkMachine, m *v1.WorkMachineMigration) v1.WorkMachineSpec {
+	spec := *source.Spec.DeepCopy()
+
+	// The target gets its own namespace until the source is deleted, and then adopts the one of the source
+	spec.TargetNamespace = ""
+	spec.State = v1.MachineStateRunning
+
+	if m.Spec.MachineType != "" {
+		spec.MachineType = m.Spec.MachineType
+	}
+	if m.Spec.Placement != "" {
+		spec.Placement = m.Spec.Placement
+	}
+	if m.Spec.VolumeSize != nil {
+		spec.VolumeSize = m.Spec.VolumeSize
+	}
+	return spec
+}
+
+// migrationLabels are the labels of the snapshot requests and restores of a migration
+func migrationLabels(m *v1.WorkMachineMigration) map[string]string {
+	return map[string]string{
+		"kloudlite.io/workmachine": m.Spec.Source,
+		v1.LabelSnapshotReason:     v1.SnapshotReasonMigration,
+		labelMigration:             m.Name,
+	}
+}
+
+// migrationSnapshotName is the name of the snapshot of a volume moved by a migration
+func migrationSnapshotName(m *v1.WorkMachineMigration, name string) string {
+	return fmt.Sprintf("%s-migration-%s", name, m.Status.StartedAt.UTC().Format("20060102150405"))
+}
+
+// splitRef splits a namespace/name reference of the migration status
+func splitRef(ref string) (string, string) {
+	namespace, name, _ := strings.Cut(ref, "/")
+	return namespace, name
+}
+
+// snapshotRequestProgress counts the completed snapshot requests, and returns the message of the first failed one
+func snapshotRequestProgress(requests []snapshotv1.SnapshotRequest) (completed int, failure string) {
+	for _, req := range requests {
+		switch req.Status.State {
+		case snapshotv1.SnapshotRequestStateCompleted:
+			completed++
+		case snapshotv1.SnapshotRequestStateFailed:
+			if failure == "" {
+				failure = fmt.Sprintf("snapshot %s/%s failed: %s", req.Namespace, req.Spec.SnapshotName, req.Status.Message)
+			}
+		}
+	}
+	return completed, failure
+}
+
+// snapshotRestoreProgress counts the completed snapshot restores, and returns the message of the first failed one
+func snapshotRestoreProgress(restores []snapshotv1.SnapshotRestore) (completed int, failure string) {
+	for _, restore := range restores {
+		switch restore.Status.State {
+		case snapshotv1.SnapshotRestoreStateCompleted:
+			completed++
+		case snapshotv1.SnapshotRestoreStateFailed:
+			if failure == "" {
+				failure = fmt.Sprintf("restore of snapshot %s/%s failed: %s", restore.Namespace, restore.Spec.SnapshotName, restore.Status.Message)
+			}
+		}
+	}
+	return completed, failure
+}
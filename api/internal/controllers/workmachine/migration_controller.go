@@ -0,0 +1,645 @@
+package workmachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllerconfig"
+	environmentV1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// MigrationReconciler moves a WorkMachine to a new machine, see migration.go for the phases
+type MigrationReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Logger *zap.Logger
+	Cfg    *controllerconfig.ControllerConfig
+}
+
+// Reconcile advances the migration by one phase at a time
+func (r *MigrationReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := r.Logger.With(zap.String("migration", req.Name))
+
+	m := &v1.WorkMachineMigration{}
+	if err := r.Get(ctx, req.NamespacedName, m); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if m.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	switch m.Status.Phase {
+	case "", v1.MigrationPhasePending:
+		return r.startMigration(ctx, m, logger)
+	case v1.MigrationPhaseProvisioning:
+		return r.provisionTarget(ctx, m, logger)
+	case v1.MigrationPhaseStopping:
+		return r.stopSource(ctx, m, logger)
+	case v1.MigrationPhaseSnapshotting:
+		return r.snapshotSource(ctx, m, logger)
+	case v1.MigrationPhaseRestoring:
+		return r.restoreOnTarget(ctx, m, logger)
+	case v1.MigrationPhaseSwitchingOver:
+		return r.switchOver(ctx, m, logger)
+	case v1.MigrationPhaseDeletingSource:
+		return r.deleteSource(ctx, m, logger)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// startMigration validates the migration
+func (r *MigrationReconciler) startMigration(ctx context.Context, m *v1.WorkMachineMigration, logger *zap.Logger) (reconcile.Result, error) {
+	if m.Spec.Source == m.Spec.Target {
+		return r.failMigration(ctx, m, errors.New("source and target must be different WorkMachines"), logger)
+	}
+
+	source := &v1.WorkMachine{}
+	if err := r.Get(ctx, client.ObjectKey{Name: m.Spec.Source}, source); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.failMigration(ctx, m, fmt.Errorf("source WorkMachine %s not found", m.Spec.Source), logger)
+		}
+		return reconcile.Result{}, err
+	}
+
+	migrations := &v1.WorkMachineMigrationList{}
+	if err := r.List(ctx, migrations); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	for _, other := range migrations.Items {
+		if other.Name != m.Name && other.Spec.Source == m.Spec.Source && other.Status.Phase != "" &&
+			other.Status.Phase != v1.MigrationPhaseCompleted && other.Status.Phase != v1.MigrationPhaseFailed {
+			return r.failMigration(ctx, m, fmt.Errorf("WorkMachine %s is already being migrated by %s", m.Spec.Source, other.Name), logger)
+		}
+	}
+
+	logger.Info("Starting migration", zap.String("source", m.Spec.Source), zap.String("target", m.Spec.Target))
+	m.Status.StartedAt = &metav1.Time{Time: time.Now()}
+	m.Status.SourceRegion = source.Status.Region
+	m.Status.SourceNamespace = source.Spec.TargetNamespace
+	return r.setMigrationPhase(ctx, m, v1.MigrationPhaseProvisioning, fmt.Sprintf("Creating WorkMachine %s", m.Spec.Target))
+}
+
+// provisionTarget creates the target WorkMachine, and waits for both machines to be running
+func (r *MigrationReconciler) provisionTarget(ctx context.Context, m *v1.WorkMachineMigration, logger *zap.Logger) (reconcile.Result, error) {
+	source := &v1.WorkMachine{}
+	if err := r.Get(ctx, client.ObjectKey{Name: m.Spec.Source}, source); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.failMigration(ctx, m, fmt.Errorf("source WorkMachine %s not found", m.Spec.Source), logger)
+		}
+		return reconcile.Result{}, err
+	}
+
+	target := &v1.WorkMachine{}
+	if err := r.Get(ctx, client.ObjectKey{Name: m.Spec.Target}, target); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+
+		target = &v1.WorkMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   m.Spec.Target,
+				Labels: map[string]string{labelMigration: m.Name},
+			},
+			Spec: migrationTargetSpec(source, m),
+		}
+		if err := r.Create(ctx, target); err != nil {
+			return r.failMigration(ctx, m, fmt.Errorf("failed to create WorkMachine %s: %w", m.Spec.Target, err), logger)
+		}
+		logger.Info("Created target WorkMachine", zap.String("target", target.Name), zap.String("placement", target.Spec.Placement))
+		return r.waitMigration(ctx, m, fmt.Sprintf("Waiting for WorkMachine %s to be running", target.Name))
+	}
+
+	if target.Labels[labelMigration] != m.Name {
+		return r.failMigration(ctx, m, fmt.Errorf("WorkMachine %s already exists", m.Spec.Target), logger)
+	}
+
+	if time.Since(m.Status.StartedAt.Time) > r.Cfg.WorkMachine.MigrationProvisionTimeout {
+		return r.failMigration(ctx, m, fmt.Errorf("WorkMachine %s not running after %s", target.Name, r.Cfg.WorkMachine.MigrationProvisionTimeout), logger)
+	}
+
+	// The host manager of the source takes the snapshots
+	if source.Spec.State != v1.MachineStateRunning {
+		source.Spec.State = v1.MachineStateRunning
+		if err := r.Update(ctx, source); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to start WorkMachine %s: %w", source.Name, err)
+		}
+	}
+
+	if source.Status.State != v1.MachineStateRunning {
+		return r.waitMigration(ctx, m, fmt.Sprintf("Waiting for WorkMachine %s to be running", source.Name))
+	}
+	if target.Status.State != v1.MachineStateRunning {
+		return r.waitMigration(ctx, m, fmt.Sprintf("Waiting for WorkMachine %s to be running", target.Name))
+	}
+
+	m.Status.TargetRegion = target.Status.Region
+	m.Status.ProvisionedNamespace = target.Spec.TargetNamespace
+	if err := r.recordWorkloads(ctx, m); err != nil {
+		return reconcile.Result{}, err
+	}
+	return r.setMigrationPhase(ctx, m, v1.MigrationPhaseStopping, "Stopping workspaces and environments")
+}
+
+// recordWorkloads remembers the workspaces and environments to migrate, and the ones to resume on the target
+func (r *MigrationReconciler) recordWorkloads(ctx context.Context, m *v1.WorkMachineMigration) error {
+	workspaceList := &workspacev1.WorkspaceList{}
+	if err := r.List(ctx, workspaceList); err != nil {
+		return fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	m.Status.Workspaces, m.Status.ActiveWorkspaces = nil, nil
+	for _, ws := range workspaceList.Items {
+		if ws.Spec.WorkmachineName != m.Spec.Source {
+			continue
+		}
+		ref := ws.Namespace + "/" + ws.Name
+		m.Status.Workspaces = append(m.Status.Workspaces, ref)
+		if ws.Spec.Status != "suspended" && ws.Spec.Status != "archived" {
+			m.Status.ActiveWorkspaces = append(m.Status.ActiveWorkspaces, ref)
+		}
+	}
+
+	envList := &environmentV1.EnvironmentList{}
+	if err := r.List(ctx, envList); err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	m.Status.Environments, m.Status.ActiveEnvironments = nil, nil
+	for _, env := range envList.Items {
+		if env.Spec.WorkMachineName != m.Spec.Source {
+			continue
+		}
+		ref := env.Namespace + "/" + env.Name
+		m.Status.Environments = append(m.Status.Environments, ref)
+		if env.Spec.Activated {
+			m.Status.ActiveEnvironments = append(m.Status.ActiveEnvironments, ref)
+		}
+	}
+	return nil
+}
+
+// stopSource suspends the workspaces and deactivates the environments of the source, so their volumes stop changing
+func (r *MigrationReconciler) stopSource(ctx context.Context, m *v1.WorkMachineMigration, logger *zap.Logger) (reconcile.Result, error) {
+	for _, ref := range m.Status.ActiveWorkspaces {
+		if err := r.setWorkspaceStatus(ctx, ref, "suspended"); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	for _, ref := range m.Status.ActiveEnvironments {
+		if err := r.setEnvironmentActivated(ctx, ref, false); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	// New workspaces or environments may have been activated meanwhile
+	workspaceList := &workspacev1.WorkspaceList{}
+	if err := r.List(ctx, workspaceList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	envList := &environmentV1.EnvironmentList{}
+	if err := r.List(ctx, envList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	wmr := &WorkMachineReconciler{}
+	if active := wmr.countActiveWorkspaces(workspaceList, m.Spec.Source) + wmr.countActiveEnvironments(envList, m.Spec.Source); active > 0 {
+		if err := r.recordWorkloads(ctx, m); err != nil {
+			return reconcile.Result{}, err
+		}
+		return r.waitMigration(ctx, m, fmt.Sprintf("Waiting for %d workspaces and environments to stop", active))
+	}
+
+	logger.Info("Stopped source workloads", zap.Int("workspaces", len(m.Status.ActiveWorkspaces)), zap.Int("environments", len(m.Status.ActiveEnvironments)))
+	return r.setMigrationPhase(ctx, m, v1.MigrationPhaseSnapshotting, "Snapshotting volumes")
+}
+
+// snapshotSource has the host manager of the source push every volume to the snapshot registry
+func (r *MigrationReconciler) snapshotSource(ctx context.Context, m *v1.WorkMachineMigration, logger *zap.Logger) (reconcile.Result, error) {
+	for _, ref := range m.Status.Workspaces {
+		namespace, name := splitRef(ref)
+		ws := &workspacev1.Workspace{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, ws); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return reconcile.Result{}, fmt.Errorf("failed to get workspace %s: %w", name, err)
+		}
+
+		labels := migrationLabels(m)
+		labels["kloudlite.io/owned-by"] = ws.Spec.OwnedBy
+		labels["snapshots.kloudlite.io/workspace"] = ws.Name
+		labels["snapshots.kloudlite.io/type"] = "workspace"
+
+		if err := r.createMigrationSnapshotRequest(ctx, m, ws.Namespace, labels, snapshotv1.SnapshotRequestSpec{
+			SnapshotName: migrationSnapshotName(m, ws.Name),
+			SourcePath:   fmt.Sprintf("/var/lib/kloudlite/storage/workspaces/%s", ws.Name),
+			Owner:        ws.Spec.OwnedBy,
+			Description:  fmt.Sprintf("Snapshot of workspace %s for the migration to %s", ws.Name, m.Spec.Target),
+		}); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	for _, ref := range m.Status.Environments {
+		namespace, name := splitRef(ref)
+		env := &environmentV1.Environment{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, env); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return reconcile.Result{}, fmt.Errorf("failed to get environment %s: %w", name, err)
+		}
+
+		parentSnapshot := ""
+		if env.Status.LastRestoredSnapshot != nil {
+			parentSnapshot = env.Status.LastRestoredSnapshot.Name
+		}
+
+		labels := migrationLabels(m)
+		labels["kloudlite.io/owned-by"] = env.Spec.OwnedBy
+		labels["snapshots.kloudlite.io/environment"] = env.Name
+		labels["snapshots.kloudlite.io/type"] = "environment"
+
+		if err := r.createMigrationSnapshotRequest(ctx, m, env.Spec.TargetNamespace, labels, snapshotv1.SnapshotRequestSpec{
+			SnapshotName:   migrationSnapshotName(m, env.Name),
+			SourcePath:     fmt.Sprintf("/var/lib/kloudlite/storage/environments/%s", env.Spec.TargetNamespace),
+			Owner:          env.Spec.OwnedBy,
+			ParentSnapshot: parentSnapshot,
+			Description:    fmt.Sprintf("Snapshot of environment %s for the migration to %s", env.Name, m.Spec.Target),
+		}); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	requests := &snapshotv1.SnapshotRequestList{}
+	if err := r.List(ctx, requests, client.MatchingLabels(migrationLabels(m))); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list migration snapshot requests: %w", err)
+	}
+
+	completed, failure := snapshotRequestProgress(requests.Items)
+	if failure != "" {
+		return r.failMigration(ctx, m, errors.New(failure), logger)
+	}
+	if completed < len(requests.Items) {
+		return r.waitMigration(ctx, m, fmt.Sprintf("Snapshotting volumes: %d/%d done", completed, len(requests.Items)))
+	}
+
+	return r.setMigrationPhase(ctx, m, v1.MigrationPhaseRestoring, "Restoring volumes")
+}
+
+// restoreOnTarget has the host manager of the target pull every snapshot into place
+func (r *MigrationReconciler) restoreOnTarget(ctx context.Context, m *v1.WorkMachineMigration, logger *zap.Logger) (reconcile.Result, error) {
+	requests := &snapshotv1.SnapshotRequestList{}
+	if err := r.List(ctx, requests, client.MatchingLabels(migrationLabels(m))); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list migration snapshot requests: %w", err)
+	}
+
+	for _, req := range requests.Items {
+		restore := &snapshotv1.SnapshotRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "restore-" + req.Spec.SnapshotName,
+				Namespace: req.Namespace,
+				Labels:    migrationLabels(m),
+			},
+			Spec: snapshotv1.SnapshotRestoreSpec{
+				SnapshotName: req.Spec.SnapshotName,
+				TargetPath:   req.Spec.SourcePath,
+				NodeName:     m.Spec.Target,
+			},
+		}
+		if err := r.Create(ctx, restore); err != nil && !apierrors.IsAlreadyExists(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to create snapshot restore %s/%s: %w", restore.Namespace, restore.Name, err)
+		}
+	}
+
+	restores := &snapshotv1.SnapshotRestoreList{}
+	if err := r.List(ctx, restores, client.MatchingLabels(migrationLabels(m))); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list migration snapshot restores: %w", err)
+	}
+
+	completed, failure := snapshotRestoreProgress(restores.Items)
+	if failure != "" {
+		return r.failMigration(ctx, m, errors.New(failure), logger)
+	}
+	if completed < len(restores.Items) {
+		return r.waitMigration(ctx, m, fmt.Sprintf("Restoring volumes: %d/%d done", completed, len(restores.Items)))
+	}
+
+	return r.setMigrationPhase(ctx, m, v1.MigrationPhaseSwitchingOver, fmt.Sprintf("Moving workspaces and environments to %s", m.Spec.Target))
+}
+
+// switchOver repoints workspaces and environments to the target and hands the namespace of the
+// source over to it. It is retried until it succeeds.
+func (r *MigrationReconciler) switchOver(ctx context.Context, m *v1.WorkMachineMigration, logger *zap.Logger) (reconcile.Result, error) {
+	target := &v1.WorkMachine{}
+	if err := r.Get(ctx, client.ObjectKey{Name: m.Spec.Target}, target); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to get WorkMachine %s: %w", m.Spec.Target, err)
+	}
+
+	for _, ref := range m.Status.Workspaces {
+		namespace, name := splitRef(ref)
+		ws := &workspacev1.Workspace{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, ws); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return reconcile.Result{}, fmt.Errorf("failed to get workspace %s: %w", name, err)
+		}
+		if ws.Spec.WorkmachineName != target.Name {
+			ws.Spec.WorkmachineName = target.Name
+			if err := r.Update(ctx, ws); err != nil {
+				return reconcile.Result{}, fmt.Errorf("failed to move workspace %s: %w", name, err)
+			}
+		}
+	}
+
+	for _, ref := range m.Status.Environments {
+		namespace, name := splitRef(ref)
+		env := &environmentV1.Environment{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, env); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return reconcile.Result{}, fmt.Errorf("failed to get environment %s: %w", name, err)
+		}
+		if env.Spec.WorkMachineName != target.Name {
+			env.Spec.WorkMachineName = target.Name
+			if err := r.Update(ctx, env); err != nil {
+				return reconcile.Result{}, fmt.Errorf("failed to move environment %s: %w", name, err)
+			}
+		}
+	}
+
+	if err := r.handOverNamespace(ctx, m, target); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	logger.Info("Moved workspaces and environments", zap.String("target", target.Name),
+		zap.Int("workspaces", len(m.Status.Workspaces)), zap.Int("environments", len(m.Status.Environments)))
+	return r.setMigrationPhase(ctx, m, v1.MigrationPhaseDeletingSource, fmt.Sprintf("Deleting WorkMachine %s", m.Spec.Source))
+}
+
+// handOverNamespace makes the target the owner of the namespace of the source, where the
+// migrated workspaces and environments live, so it is not deleted with the source
+func (r *MigrationReconciler) handOverNamespace(ctx context.Context, m *v1.WorkMachineMigration, target *v1.WorkMachine) error {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: m.Status.SourceNamespace}, ns); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	// The target only cleans up its own namespace, the handed over one is garbage collected with it
+	ns.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(target, v1.GroupVersion.WithKind("WorkMachine"))})
+	controllerutil.RemoveFinalizer(ns, WorkMachineFinalizerName)
+	if err := r.Update(ctx, ns); err != nil {
+		return fmt.Errorf("failed to hand namespace %s over to %s: %w", ns.Name, target.Name, err)
+	}
+	return nil
+}
+
+// adoptSourceNamespace makes the handed over namespace the targetNamespace of the target, once
+// the source is deleted, and deletes the namespace the target was created with
+func (r *MigrationReconciler) adoptSourceNamespace(ctx context.Context, m *v1.WorkMachineMigration) error {
+	target := &v1.WorkMachine{}
+	if err := r.Get(ctx, client.ObjectKey{Name: m.Spec.Target}, target); err != nil {
+		return fmt.Errorf("failed to get WorkMachine %s: %w", m.Spec.Target, err)
+	}
+
+	if target.Spec.TargetNamespace != m.Status.SourceNamespace {
+		target.Spec.TargetNamespace = m.Status.SourceNamespace
+		if err := r.Update(ctx, target); err != nil {
+			return fmt.Errorf("failed to move WorkMachine %s to namespace %s: %w", target.Name, m.Status.SourceNamespace, err)
+		}
+	}
+
+	if m.Status.ProvisionedNamespace == "" || m.Status.ProvisionedNamespace == m.Status.SourceNamespace {
+		return nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: m.Status.ProvisionedNamespace}, ns); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if controllerutil.RemoveFinalizer(ns, WorkMachineFinalizerName) {
+		if err := r.Update(ctx, ns); err != nil {
+			return fmt.Errorf("failed to release namespace %s: %w", ns.Name, err)
+		}
+	}
+	if ns.DeletionTimestamp == nil {
+		if err := r.Delete(ctx, ns); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete namespace %s: %w", ns.Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteSource deletes the source WorkMachine. Once it is gone, the target adopts its namespace
+// and what was active is resumed, which completes the migration.
+func (r *MigrationReconciler) deleteSource(ctx context.Context, m *v1.WorkMachineMigration, logger *zap.Logger) (reconcile.Result, error) {
+	source := &v1.WorkMachine{}
+	if err := r.Get(ctx, client.ObjectKey{Name: m.Spec.Source}, source); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+
+		if err := r.adoptSourceNamespace(ctx, m); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		for _, ref := range m.Status.ActiveWorkspaces {
+			if err := r.setWorkspaceStatus(ctx, ref, "active"); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		for _, ref := range m.Status.ActiveEnvironments {
+			if err := r.setEnvironmentActivated(ctx, ref, true); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+
+		if err := r.cleanupMigration(ctx, m); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		logger.Info("Migration completed", zap.String("source", m.Spec.Source), zap.String("target", m.Spec.Target))
+		m.Status.CompletedAt = &metav1.Time{Time: time.Now()}
+		return r.setMigrationPhase(ctx, m, v1.MigrationPhaseCompleted, fmt.Sprintf("Moved %d workspaces and %d environments from %s to %s",
+			len(m.Status.Workspaces), len(m.Status.Environments), m.Spec.Source, m.Spec.Target))
+	}
+
+	if source.DeletionTimestamp == nil {
+		if err := r.Delete(ctx, source); err != nil && !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to delete WorkMachine %s: %w", source.Name, err)
+		}
+	}
+	return r.waitMigration(ctx, m, fmt.Sprintf("Waiting for WorkMachine %s to be deleted", source.Name))
+}
+
+// failMigration deletes the target and resumes the workspaces and environments on the source
+func (r *MigrationReconciler) failMigration(ctx context.Context, m *v1.WorkMachineMigration, cause error, logger *zap.Logger) (reconcile.Result, error) {
+	logger.Error("Migration failed, rolling back", zap.Error(cause))
+
+	target := &v1.WorkMachine{}
+	if err := r.Get(ctx, client.ObjectKey{Name: m.Spec.Target}, target); err == nil {
+		if target.Labels[labelMigration] == m.Name && target.DeletionTimestamp == nil {
+			if err := r.Delete(ctx, target); err != nil && !apierrors.IsNotFound(err) {
+				return reconcile.Result{}, fmt.Errorf("failed to delete WorkMachine %s: %w", target.Name, err)
+			}
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+
+	for _, ref := range m.Status.ActiveWorkspaces {
+		if err := r.setWorkspaceStatus(ctx, ref, "active"); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	for _, ref := range m.Status.ActiveEnvironments {
+		if err := r.setEnvironmentActivated(ctx, ref, true); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if err := r.cleanupMigration(ctx, m); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	m.Status.CompletedAt = &metav1.Time{Time: time.Now()}
+	return r.setMigrationPhase(ctx, m, v1.MigrationPhaseFailed, cause.Error())
+}
+
+// createMigrationSnapshotRequest asks the host manager of the source for a snapshot
+func (r *MigrationReconciler) createMigrationSnapshotRequest(ctx context.Context, m *v1.WorkMachineMigration, namespace string, labels map[string]string, spec snapshotv1.SnapshotRequestSpec) error {
+	spec.NodeName = m.Spec.Source
+
+	req := &snapshotv1.SnapshotRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "req-" + spec.SnapshotName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: spec,
+	}
+	if err := r.Create(ctx, req); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create snapshot request %s/%s: %w", namespace, req.Name, err)
+	}
+	return nil
+}
+
+// cleanupMigration deletes the snapshot requests and restores of a migration, the snapshots are kept
+func (r *MigrationReconciler) cleanupMigration(ctx context.Context, m *v1.WorkMachineMigration) error {
+	requests := &snapshotv1.SnapshotRequestList{}
+	if err := r.List(ctx, requests, client.MatchingLabels(migrationLabels(m))); err != nil {
+		return fmt.Errorf("failed to list migration snapshot requests: %w", err)
+	}
+	for i := range requests.Items {
+		if err := r.Delete(ctx, &requests.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete snapshot request %s: %w", requests.Items[i].Name, err)
+		}
+	}
+
+	restores := &snapshotv1.SnapshotRestoreList{}
+	if err := r.List(ctx, restores, client.MatchingLabels(migrationLabels(m))); err != nil {
+		return fmt.Errorf("failed to list migration snapshot restores: %w", err)
+	}
+	for i := range restores.Items {
+		if err := r.Delete(ctx, &restores.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete snapshot restore %s: %w", restores.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// setWorkspaceStatus suspends or resumes a workspace of the migration
+func (r *MigrationReconciler) setWorkspaceStatus(ctx context.Context, ref, status string) error {
+	namespace, name := splitRef(ref)
+	ws := &workspacev1.Workspace{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, ws); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get workspace %s: %w", name, err)
+	}
+
+	if ws.Spec.Status == status {
+		return nil
+	}
+
+	ws.Spec.Status = status
+	if err := r.Update(ctx, ws); err != nil {
+		return fmt.Errorf("failed to set workspace %s %s: %w", name, status, err)
+	}
+	return nil
+}
+
+// setEnvironmentActivated activates or deactivates an environment of the migration
+func (r *MigrationReconciler) setEnvironmentActivated(ctx context.Context, ref string, activated bool) error {
+	namespace, name := splitRef(ref)
+	env := &environmentV1.Environment{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, env); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get environment %s: %w", name, err)
+	}
+
+	if env.Spec.Activated == activated {
+		return nil
+	}
+
+	env.Spec.Activated = activated
+	if err := r.Update(ctx, env); err != nil {
+		return fmt.Errorf("failed to update environment %s: %w", name, err)
+	}
+	return nil
+}
+
+// setMigrationPhase moves the migration to phase
+func (r *MigrationReconciler) setMigrationPhase(ctx context.Context, m *v1.WorkMachineMigration, phase v1.MigrationPhase, message string) (reconcile.Result, error) {
+	m.Status.Phase = phase
+	m.Status.Message = message
+	if err := r.Status().Update(ctx, m); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if phase == v1.MigrationPhaseCompleted || phase == v1.MigrationPhaseFailed {
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{Requeue: true}, nil
+}
+
+// waitMigration reports the progress of the current phase and checks it again later
+func (r *MigrationReconciler) waitMigration(ctx context.Context, m *v1.WorkMachineMigration, message string) (reconcile.Result, error) {
+	m.Status.Message = message
+	if err := r.Status().Update(ctx, m); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: r.Cfg.WorkMachine.MigrationRetryInterval}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *MigrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("workmachine-migration").
+		For(&v1.WorkMachineMigration{}).
+		Complete(r)
+}
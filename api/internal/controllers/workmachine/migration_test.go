@@ -0,0 +1,169 @@
+package workmachine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllerconfig"
+	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
+	"github.com/kloudlite/kloudlite/api/internal/controllers/testutil"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestMigrationTargetSpec tests the spec of the WorkMachine created by a migration
+func TestMigrationTargetSpec(t *testing.T) {
+	source := &v1.WorkMachine{
+		Spec: v1.WorkMachineSpec{
+			OwnedBy:         "alice",
+			MachineType:     "medium",
+			Placement:       "aws-us-east-1",
+			VolumeSize:      fn.Ptr[int32](100),
+			TargetNamespace: "wm-alice",
+			State:           v1.MachineStateStopped,
+		},
+	}
+
+	t.Run("keeps the source settings by default", func(t *testing.T) {
+		spec := migrationTargetSpec(source, &v1.WorkMachineMigration{})
+		assert.Equal(t, "alice", spec.OwnedBy)
+		assert.Equal(t, "medium", spec.MachineType)
+		assert.Equal(t, "aws-us-east-1", spec.Placement)
+		assert.Equal(t, int32(100), *spec.VolumeSize)
+		assert.Empty(t, spec.TargetNamespace)
+		assert.Equal(t, v1.MachineStateRunning, spec.State)
+	})
+
+	t.Run("applies the overrides of the migration", func(t *testing.T) {
+		spec := migrationTargetSpec(source, &v1.WorkMachineMigration{
+			Spec: v1.WorkMachineMigrationSpec{MachineType: "large", Placement: "gcp-europe-west1", VolumeSize: fn.Ptr[int32](200)},
+		})
+		assert.Equal(t, "large", spec.MachineType)
+		assert.Equal(t, "gcp-europe-west1", spec.Placement)
+		assert.Equal(t, int32(200), *spec.VolumeSize)
+		assert.Equal(t, int32(100), *source.Spec.VolumeSize)
+	})
+}
+
+// TestMigrationAdoptsSourceNamespace tests that the target of a migration takes over the namespace
+// of the source once it is deleted, so that migrated workspaces get their pods in their own namespace
+func TestMigrationAdoptsSourceNamespace(t *testing.T) {
+	ctx := context.Background()
+	source := &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "wm-alice", UID: "source-uid"},
+		Spec:       v1.WorkMachineSpec{OwnedBy: "alice", TargetNamespace: "wm-alice"},
+	}
+	target := &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "wm-alice-large", UID: "target-uid", Labels: map[string]string{labelMigration: "alice-to-large"}},
+		Spec:       v1.WorkMachineSpec{OwnedBy: "alice", TargetNamespace: "wm-wm-alice-large"},
+	}
+	sourceNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:            "wm-alice",
+		OwnerReferences: []metav1.OwnerReference{fn.AsOwner(source, true)},
+		Finalizers:      []string{WorkMachineFinalizerName},
+	}}
+	provisionedNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:            "wm-wm-alice-large",
+		OwnerReferences: []metav1.OwnerReference{fn.AsOwner(target, true)},
+		Finalizers:      []string{WorkMachineFinalizerName},
+	}}
+	ws := &workspacev1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "wm-alice"},
+		Spec:       workspacev1.WorkspaceSpec{WorkmachineName: "wm-alice", OwnedBy: "alice", Status: "suspended"},
+	}
+	m := &v1.WorkMachineMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice-to-large"},
+		Spec:       v1.WorkMachineMigrationSpec{Source: "wm-alice", Target: "wm-alice-large"},
+		Status: v1.WorkMachineMigrationStatus{
+			Phase:                v1.MigrationPhaseSwitchingOver,
+			SourceNamespace:      "wm-alice",
+			ProvisionedNamespace: "wm-wm-alice-large",
+			Workspaces:           []string{"wm-alice/dev"},
+			ActiveWorkspaces:     []string{"wm-alice/dev"},
+			StartedAt:            &metav1.Time{Time: time.Now()},
+		},
+	}
+
+	c := testutil.NewFakeClient(testutil.NewTestScheme(), source, target, sourceNamespace, provisionedNamespace, ws, m).
+		WithStatusSubresource(&v1.WorkMachineMigration{}).
+		Build()
+	r := &MigrationReconciler{
+		Client: c,
+		Logger: zap.NewNop(),
+		Cfg:    &controllerconfig.ControllerConfig{WorkMachine: controllerconfig.WorkMachineConfig{MigrationRetryInterval: time.Second}},
+	}
+
+	// Workspaces are moved and the namespace handed over, nothing is resumed while the source exists
+	_, err := r.switchOver(ctx, m, r.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, v1.MigrationPhaseDeletingSource, m.Status.Phase)
+
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(ws), ws))
+	assert.Equal(t, "wm-alice-large", ws.Spec.WorkmachineName)
+	assert.Equal(t, "suspended", ws.Spec.Status)
+
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(sourceNamespace), sourceNamespace))
+	assert.True(t, metav1.IsControlledBy(sourceNamespace, target))
+	assert.NotContains(t, sourceNamespace.Finalizers, WorkMachineFinalizerName)
+
+	// The source is deleted
+	_, err = r.deleteSource(ctx, m, r.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, v1.MigrationPhaseDeletingSource, m.Status.Phase)
+
+	// Then the target adopts its namespace, and the workspace is resumed
+	_, err = r.deleteSource(ctx, m, r.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, v1.MigrationPhaseCompleted, m.Status.Phase)
+
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(target), target))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(ws), ws))
+	assert.Equal(t, ws.Namespace, target.Spec.TargetNamespace, "workspace pods are created in the targetNamespace of their WorkMachine")
+	assert.Equal(t, "active", ws.Spec.Status)
+
+	err = c.Get(ctx, client.ObjectKeyFromObject(provisionedNamespace), &corev1.Namespace{})
+	assert.True(t, apierrors.IsNotFound(err), "namespace the target was created with is deleted, got %v", err)
+}
+
+// TestMigrationSnapshotName tests that snapshots of a migration are named after its start
+func TestMigrationSnapshotName(t *testing.T) {
+	m := &v1.WorkMachineMigration{
+		Status: v1.WorkMachineMigrationStatus{StartedAt: &metav1.Time{Time: time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)}},
+	}
+	assert.Equal(t, "api-migration-20260304050607", migrationSnapshotName(m, "api"))
+}
+
+// TestSnapshotProgress tests the progress of the snapshot requests and restores of a migration
+func TestSnapshotProgress(t *testing.T) {
+	requests := []snapshotv1.SnapshotRequest{
+		{Status: snapshotv1.SnapshotRequestStatus{State: snapshotv1.SnapshotRequestStateCompleted}},
+		{Status: snapshotv1.SnapshotRequestStatus{State: snapshotv1.SnapshotRequestStateCompleted}},
+		{},
+	}
+	completed, failure := snapshotRequestProgress(requests)
+	assert.Equal(t, 2, completed)
+	assert.Empty(t, failure)
+
+	requests[2].Namespace = "wm-alice"
+	requests[2].Spec.SnapshotName = "api-migration"
+	requests[2].Status = snapshotv1.SnapshotRequestStatus{State: snapshotv1.SnapshotRequestStateFailed, Message: "registry unreachable"}
+	_, failure = snapshotRequestProgress(requests)
+	assert.Equal(t, "snapshot wm-alice/api-migration failed: registry unreachable", failure)
+
+	restores := []snapshotv1.SnapshotRestore{
+		{Status: snapshotv1.SnapshotRestoreStatus{State: snapshotv1.SnapshotRestoreStateCompleted}},
+		{},
+	}
+	completed, failure = snapshotRestoreProgress(restores)
+	assert.Equal(t, 1, completed)
+	assert.Empty(t, failure)
+}
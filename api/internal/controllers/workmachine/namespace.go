@@ -237,6 +237,11 @@ func (r *WorkMachineReconciler) deleteNamespace(check *reconciler.Check[*v1.Work
 	namespace := &corev1.Namespace{}
 	err := r.Get(check.Context(), client.ObjectKey{Name: namespaceName}, namespace)
 	if err == nil {
+		// The namespace was handed over to the target of a migration, see migration.go
+		if len(namespace.GetOwnerReferences()) > 0 && !fn.IsOwner(namespace, obj) {
+			return check.Passed()
+		}
+
 		// Namespace still exists
 		if namespace.DeletionTimestamp != nil {
 			// Namespace is being deleted - remove our finalizer to allow it to complete
@@ -0,0 +1,249 @@
+package workmachine
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	"github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WorkMachine node pools
+//
+// Each node pool of Spec.NodePools is a set of cloud machines created with the provider of the
+// WorkMachine, named <workmachine>-<pool>-<index>. They join the cluster like the WorkMachine
+// itself, and are labelled with LabelNodePool and LabelNodePoolWorkMachine so that compose
+// services with `x-kloudlite: {node-pool: <pool>}` can be scheduled on them.
+//
+// Pool nodes follow the WorkMachine: they are created and started once it is running, and stopped
+// as soon as its spec.state is no longer running. Nodes removed from the spec, and all nodes when
+// the WorkMachine is deleted, are deleted with their Node.
+
+// defaultPoolVolumeSize is the storage volume size of pool nodes in GB when the pool does not set one
+const defaultPoolVolumeSize int32 = 50
+
+// poolNode is a node the spec asks for
+type poolNode struct {
+	pool v1.NodePool
+	name string
+}
+
+// poolNodeName is the name of the index-th node of a pool, used for the cloud machine and the Node
+func poolNodeName(obj *v1.WorkMachine, pool string, index int32) string {
+	return v1.PoolNodeName(obj.Name, pool, index)
+}
+
+// desiredPoolNodes lists the nodes of every node pool of the spec
+func desiredPoolNodes(obj *v1.WorkMachine) []poolNode {
+	var nodes []poolNode
+	for _, pool := range obj.Spec.NodePools {
+		for i := int32(0); i < pool.Nodes; i++ {
+			nodes = append(nodes, poolNode{pool: pool, name: poolNodeName(obj, pool.Name, i)})
+		}
+	}
+	return nodes
+}
+
+// poolNodeWorkMachine is the WorkMachine passed to the cloud provider to create a pool node
+func poolNodeWorkMachine(obj *v1.WorkMachine, node poolNode) *v1.WorkMachine {
+	wm := obj.DeepCopy()
+	wm.Name = node.name
+	wm.Spec.MachineType = node.pool.MachineType
+	wm.Spec.VolumeSize = fn.Ptr(defaultPoolVolumeSize)
+	if node.pool.VolumeSize != nil {
+		wm.Spec.VolumeSize = fn.Ptr(*node.pool.VolumeSize)
+	}
+	wm.Spec.DeleteVolumePostTermination = true
+	wm.Spec.Capacity = v1.CapacityOnDemand
+	wm.Spec.NodePools = nil
+	return wm
+}
+
+// reconcileNodePools creates, starts, stops and deletes the pool nodes of the WorkMachine
+func (r *WorkMachineReconciler) reconcileNodePools(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	if len(obj.Spec.NodePools) == 0 && len(obj.Status.NodePools) == 0 {
+		return check.Passed()
+	}
+
+	// Pool nodes wait for the WorkMachine to be running, and stop once it has stopped
+	running := obj.Spec.State == v1.MachineStateRunning && obj.Status.State == v1.MachineStateRunning
+	if obj.Spec.State == v1.MachineStateRunning && !running {
+		return check.Passed()
+	}
+
+	current := make(map[string]v1.PoolNodeStatus, len(obj.Status.NodePools))
+	for _, st := range obj.Status.NodePools {
+		current[st.Name] = st
+	}
+
+	desired := desiredPoolNodes(obj)
+	wanted := make(map[string]bool, len(desired))
+	statuses := make([]v1.PoolNodeStatus, 0, len(desired))
+	pending := 0
+
+	for _, node := range desired {
+		wanted[node.name] = true
+		st, exists := current[node.name]
+		if !exists {
+			st = v1.PoolNodeStatus{Pool: node.pool.Name, Name: node.name, MachineType: node.pool.MachineType}
+		}
+
+		settled, err := r.reconcilePoolNode(check, obj, node, &st, running)
+		if err != nil {
+			obj.Status.NodePools = mergePoolNodeStatuses(obj.Status.NodePools, st)
+			return check.Failed(fmt.Errorf("node pool %s: %w", node.pool.Name, err))
+		}
+		if !settled {
+			pending++
+		}
+		statuses = append(statuses, st)
+	}
+
+	// Delete the nodes that are no longer in the spec
+	for _, st := range obj.Status.NodePools {
+		if wanted[st.Name] {
+			continue
+		}
+		if err := r.deletePoolNode(check, &st); err != nil {
+			return check.Failed(fmt.Errorf("node pool %s: %w", st.Pool, err))
+		}
+		check.Logger().Info("deleted pool node", "pool", st.Pool, "node", st.Name)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	obj.Status.NodePools = statuses
+
+	if pending > 0 {
+		return check.UpdateMsg(fmt.Sprintf("waiting for %d pool nodes", pending)).RequeueAfter(r.Cfg.WorkMachine.NodePoolCheckInterval)
+	}
+	return check.Passed()
+}
+
+// reconcilePoolNode brings a pool node to the state of the WorkMachine, and reports whether it is there
+func (r *WorkMachineReconciler) reconcilePoolNode(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine, node poolNode, st *v1.PoolNodeStatus, running bool) (bool, error) {
+	ctx := check.Context()
+
+	if st.MachineID == "" {
+		if !running {
+			return true, nil
+		}
+
+		mi, err := r.cloudProviderAPI.CreateMachine(ctx, poolNodeWorkMachine(obj, node))
+		if err != nil {
+			return false, fmt.Errorf("failed to create node %s: %w", node.name, err)
+		}
+		st.MachineInfo = *mi
+		st.State = v1.MachineStateStarting
+		check.Logger().Info("created pool node", "pool", node.pool.Name, "node", node.name, "machineID", mi.MachineID)
+		return false, nil
+	}
+
+	mi, err := r.cloudProviderAPI.GetMachineStatus(ctx, st.MachineID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get status of node %s: %w", node.name, err)
+	}
+	st.MachineInfo = *mi
+
+	if !running {
+		switch mi.State {
+		case v1.MachineStateStopped:
+			return true, nil
+		case v1.MachineStateRunning:
+			if err := r.cloudProviderAPI.StopMachine(ctx, st.MachineID); err != nil {
+				return false, fmt.Errorf("failed to stop node %s: %w", node.name, err)
+			}
+			st.State = v1.MachineStateStopping
+		}
+		return false, nil
+	}
+
+	switch mi.State {
+	case v1.MachineStateStopped:
+		if err := r.cloudProviderAPI.StartMachine(ctx, st.MachineID); err != nil {
+			return false, fmt.Errorf("failed to start node %s: %w", node.name, err)
+		}
+		st.State = v1.MachineStateStarting
+		return false, nil
+	case v1.MachineStateRunning:
+	default:
+		return false, nil
+	}
+
+	k8sNode := &corev1.Node{}
+	if err := r.Get(ctx, client.ObjectKey{Name: node.name}, k8sNode); err != nil {
+		if apiErrors.IsNotFound(err) {
+			st.State = v1.MachineStateStarting
+			st.Message = "Waiting for node to join"
+			return false, nil
+		}
+		return false, err
+	}
+
+	if k8sNode.Labels[v1.LabelNodePool] != node.pool.Name || k8sNode.Labels[v1.LabelNodePoolWorkMachine] != obj.Name {
+		if k8sNode.Labels == nil {
+			k8sNode.Labels = map[string]string{}
+		}
+		k8sNode.Labels[v1.LabelNodePool] = node.pool.Name
+		k8sNode.Labels[v1.LabelNodePoolWorkMachine] = obj.Name
+		if err := r.Update(ctx, k8sNode); err != nil {
+			return false, fmt.Errorf("failed to label node %s: %w", node.name, err)
+		}
+	}
+
+	if !r.isNodeReady(k8sNode) {
+		st.State = v1.MachineStateStarting
+		st.Message = "Waiting for node to be ready"
+		return false, nil
+	}
+	return true, nil
+}
+
+// deletePoolNode deletes the Node and the cloud machine of a pool node
+func (r *WorkMachineReconciler) deletePoolNode(check *reconciler.Check[*v1.WorkMachine], st *v1.PoolNodeStatus) error {
+	ctx := check.Context()
+
+	k8sNode := &corev1.Node{}
+	if err := r.Get(ctx, client.ObjectKey{Name: st.Name}, k8sNode); err == nil {
+		if err := r.Delete(ctx, k8sNode); err != nil && !apiErrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete node %s: %w", st.Name, err)
+		}
+	} else if !apiErrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get node %s: %w", st.Name, err)
+	}
+
+	if st.MachineID == "" {
+		return nil
+	}
+	if err := r.cloudProviderAPI.DeleteMachine(ctx, st.MachineID); err != nil {
+		return fmt.Errorf("failed to delete machine %s of node %s: %w", st.MachineID, st.Name, err)
+	}
+	return nil
+}
+
+// cleanupNodePools deletes every pool node of the WorkMachine
+func (r *WorkMachineReconciler) cleanupNodePools(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	for len(obj.Status.NodePools) > 0 {
+		st := obj.Status.NodePools[0]
+		if err := r.deletePoolNode(check, &st); err != nil {
+			return check.Failed(fmt.Errorf("node pool %s: %w", st.Pool, err))
+		}
+		check.Logger().Info("deleted pool node", "pool", st.Pool, "node", st.Name)
+		obj.Status.NodePools = obj.Status.NodePools[1:]
+	}
+	return check.Passed()
+}
+
+// mergePoolNodeStatuses replaces the status of a node in statuses, or appends it
+func mergePoolNodeStatuses(statuses []v1.PoolNodeStatus, st v1.PoolNodeStatus) []v1.PoolNodeStatus {
+	for i := range statuses {
+		if statuses[i].Name == st.Name {
+			statuses[i] = st
+			return statuses
+		}
+	}
+	return append(statuses, st)
+}
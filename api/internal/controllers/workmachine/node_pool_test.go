@@ -0,0 +1,63 @@
+package workmachine
+
+import (
+	"testing"
+
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestDesiredPoolNodes tests the node names derived from the node pools of the spec
+func TestDesiredPoolNodes(t *testing.T) {
+	obj := &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "wm"},
+		Spec: v1.WorkMachineSpec{
+			NodePools: []v1.NodePool{
+				{Name: "gpu", MachineType: "g4dn-xlarge", Nodes: 1},
+				{Name: "data", MachineType: "r5-large", Nodes: 2},
+				{Name: "idle", MachineType: "t3-small", Nodes: 0},
+			},
+		},
+	}
+
+	var names []string
+	for _, node := range desiredPoolNodes(obj) {
+		names = append(names, node.name)
+	}
+	assert.Equal(t, []string{"wm-gpu-0", "wm-data-0", "wm-data-1"}, names)
+}
+
+// TestPoolNodeWorkMachine tests the WorkMachine passed to the cloud provider for a pool node
+func TestPoolNodeWorkMachine(t *testing.T) {
+	obj := &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "wm"},
+		Spec: v1.WorkMachineSpec{
+			OwnedBy:     "alice",
+			MachineType: "m5-large",
+			VolumeSize:  fn.Ptr[int32](200),
+			Capacity:    v1.CapacitySpot,
+			NodePools: []v1.NodePool{
+				{Name: "gpu", MachineType: "g4dn-xlarge", Nodes: 1},
+				{Name: "data", MachineType: "r5-large", Nodes: 1, VolumeSize: fn.Ptr[int32](500)},
+			},
+		},
+	}
+
+	nodes := desiredPoolNodes(obj)
+
+	gpu := poolNodeWorkMachine(obj, nodes[0])
+	assert.Equal(t, "wm-gpu-0", gpu.Name)
+	assert.Equal(t, "alice", gpu.Spec.OwnedBy)
+	assert.Equal(t, "g4dn-xlarge", gpu.Spec.MachineType)
+	assert.Equal(t, defaultPoolVolumeSize, *gpu.Spec.VolumeSize)
+	assert.Equal(t, v1.CapacityOnDemand, gpu.Spec.Capacity)
+	assert.Empty(t, gpu.Spec.NodePools)
+
+	data := poolNodeWorkMachine(obj, nodes[1])
+	assert.Equal(t, int32(500), *data.Spec.VolumeSize)
+
+	assert.Equal(t, "wm", obj.Name)
+	assert.Len(t, obj.Spec.NodePools, 2)
+}
@@ -0,0 +1,34 @@
+package workmachine
+
+import (
+	"strings"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cloud"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+)
+
+// placement is a cloud provider and region machines are provisioned in (see WorkMachineSpec.Placement)
+type placement struct {
+	cloudProvider v1.CloudProvider
+	api           cloud.Provider
+}
+
+// placementEnvPrefix is the prefix of the env vars of a placement of WORKMACHINE_PLACEMENTS,
+// e.g. PLACEMENT_GCP_EUROPE_WEST1_ for gcp-europe-west1
+func placementEnvPrefix(name string) string {
+	return "PLACEMENT_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name)) + "_"
+}
+
+// forPlacement returns a reconciler provisioning machines with the cloud provider of a placement,
+// false when the placement is not provisioned by this controller
+func (r *WorkMachineReconciler) forPlacement(name string) (*WorkMachineReconciler, bool) {
+	p, ok := r.placements[name]
+	if !ok {
+		return nil, false
+	}
+
+	pr := *r
+	pr.env.CloudProvider = p.cloudProvider
+	pr.cloudProviderAPI = p.api
+	return &pr, true
+}
@@ -0,0 +1,33 @@
+package workmachine
+
+import (
+	"testing"
+
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestForPlacement tests that machines are provisioned with the cloud provider of their placement
+func TestForPlacement(t *testing.T) {
+	aws, gcp := &fakeProvider{}, &fakeProvider{}
+	r := &WorkMachineReconciler{
+		env:              Env{CloudProvider: v1.AWS, Placement: "aws-us-east-1"},
+		cloudProviderAPI: aws,
+		placements: map[string]placement{
+			"aws-us-east-1":    {cloudProvider: v1.AWS, api: aws},
+			"gcp-europe-west1": {cloudProvider: v1.GCP, api: gcp},
+		},
+	}
+
+	pr, ok := r.forPlacement("gcp-europe-west1")
+	require.True(t, ok)
+	assert.Equal(t, v1.GCP, pr.env.CloudProvider)
+	assert.Same(t, gcp, pr.cloudProviderAPI)
+	assert.Same(t, aws, r.cloudProviderAPI, "the reconciler of the default placement is left alone")
+
+	_, ok = r.forPlacement("azure-westeurope")
+	assert.False(t, ok)
+
+	assert.Equal(t, "PLACEMENT_GCP_EUROPE_WEST1_", placementEnvPrefix("gcp-europe-west1"))
+}
@@ -19,6 +19,7 @@ import (
 // - Workspaces (namespace-scoped, but needs cluster-wide access) - to manage SSH configuration
 // - Nodes (cluster-wide) - to update GPU status
 // - Environments (cluster-wide) - for garbage collection of orphaned storage
+// - Backups, BackupRestores (cluster-wide) - to back up and restore the storage volume
 // - Secrets (in workmachine namespace) - to manage SSH keys
 func (r *WorkMachineReconciler) createHostManagerRBAC(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
 	serviceAccountName := "host-manager"
@@ -76,11 +77,11 @@ func (r *WorkMachineReconciler) createHostManagerRBAC(check *reconciler.Check[*v
 				Resources: []string{"snapshots/status", "snapshotstores/status"},
 				Verbs:     []string{"get", "update", "patch"},
 			},
-			// SnapshotRequests - for creating snapshots
+			// SnapshotRequests - for creating snapshots (create: emergency snapshots on spot interruption)
 			{
 				APIGroups: []string{"snapshots.kloudlite.io"},
 				Resources: []string{"snapshotrequests"},
-				Verbs:     []string{"get", "list", "watch", "update", "patch"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch"},
 			},
 			{
 				APIGroups: []string{"snapshots.kloudlite.io"},
@@ -116,6 +117,17 @@ func (r *WorkMachineReconciler) createHostManagerRBAC(check *reconciler.Check[*v
 				Resources: []string{"environments"},
 				Verbs:     []string{"get", "list", "watch"},
 			},
+			// Backups, BackupRestores - for backing up and restoring the storage volume (cluster-scoped)
+			{
+				APIGroups: []string{"machines.kloudlite.io"},
+				Resources: []string{"backups", "backuprestores"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			{
+				APIGroups: []string{"machines.kloudlite.io"},
+				Resources: []string{"backups/status", "backuprestores/status"},
+				Verbs:     []string{"get", "update", "patch"},
+			},
 		}
 
 		if !fn.IsOwner(clusterRole, obj) {
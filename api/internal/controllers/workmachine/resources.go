@@ -20,6 +20,10 @@ func (r *WorkMachineReconciler) ensureHostManagerPod(check *reconciler.Check[*v1
 	namespace := obj.Spec.TargetNamespace
 	hostManagerName := "host-manager"
 
+	if err := r.syncBackupCredentials(check, obj); err != nil {
+		return check.Failed(fmt.Errorf("failed to sync backup credentials: %w", err))
+	}
+
 	labels := map[string]string{
 		"app":                       hostManagerName,
 		"kloudlite.io/package-mgmt": "true",
@@ -104,7 +108,7 @@ func (r *WorkMachineReconciler) ensureHostManagerPod(check *reconciler.Check[*v1
 							Name:            "host-manager",
 							Image:           r.env.HostManagerImage,
 							ImagePullPolicy: corev1.PullAlways,
-							Env: []corev1.EnvVar{
+							Env: append([]corev1.EnvVar{
 								{
 									Name:  "NAMESPACE",
 									Value: obj.Spec.TargetNamespace,
@@ -125,6 +129,23 @@ func (r *WorkMachineReconciler) ensureHostManagerPod(check *reconciler.Check[*v1
 									Name:  "SNAPSHOT_REGISTRY_INSECURE",
 									Value: r.env.SnapshotRegistryInsecure,
 								},
+								{
+									Name:  "CLOUD_PROVIDER",
+									Value: string(r.env.CloudProvider),
+								},
+								{
+									Name:  "WORKMACHINE_CAPACITY",
+									Value: string(obj.Status.Capacity),
+								},
+							}, r.backupEnv.hostManagerEnv()...),
+							EnvFrom: []corev1.EnvFromSource{
+								{
+									// Bucket credentials for backups, absent when backups are not configured
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{Name: backupCredentialsSecretName},
+										Optional:             fn.Ptr(true),
+									},
+								},
 							},
 							SecurityContext: &corev1.SecurityContext{
 								Privileged: fn.Ptr(true),
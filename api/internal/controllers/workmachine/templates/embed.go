@@ -31,6 +31,7 @@ const (
 	K3sAgentSetupAzure templateFile = "k3s-agent-setup-azure.yml" // Cloud-init for Azure
 	K3sAgentSetupGCP   templateFile = "k3s-agent-setup-gcp.yml"   // Cloud-init for GCP
 	K3sAgentSetupOCI   templateFile = "k3s-agent-setup-oci.yml"   // Cloud-init for OCI
+	K3sAgentSetupBYO   templateFile = "k3s-agent-setup-byo.yml"   // Join script run by the admin on a bring-your-own host
 )
 
 type K3sAgentSetupArgs struct {
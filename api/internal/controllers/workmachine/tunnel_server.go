@@ -189,6 +189,7 @@ func (r *WorkMachineReconciler) ensureTunnelServer(check *reconciler.Check[*v1.W
 							ImagePullPolicy: corev1.PullAlways,
 							Args: []string{
 								"--listen", ":443",
+								"--quic-listen", ":443",
 								// Use local namespace secret (POD_NAMESPACE env var is set below)
 								// The secret contains tls.crt, tls.key, and ca.crt
 								"--tls-secret", kloudliteWildcardCertName,
@@ -247,6 +248,12 @@ func (r *WorkMachineReconciler) ensureTunnelServer(check *reconciler.Check[*v1.W
 									HostPort:      443,
 									Protocol:      corev1.ProtocolTCP,
 								},
+								{
+									Name:          "quic",
+									ContainerPort: 443,
+									HostPort:      443,
+									Protocol:      corev1.ProtocolUDP,
+								},
 								{
 									Name:          "wireguard",
 									ContainerPort: 51820,
@@ -262,6 +269,11 @@ func (r *WorkMachineReconciler) ensureTunnelServer(check *reconciler.Check[*v1.W
 									ContainerPort: 53,
 									Protocol:      corev1.ProtocolTCP,
 								},
+								{
+									Name:          "metrics",
+									ContainerPort: 9090,
+									Protocol:      corev1.ProtocolTCP,
+								},
 							},
 							ReadinessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
@@ -353,6 +365,12 @@ func (r *WorkMachineReconciler) ensureTunnelServer(check *reconciler.Check[*v1.W
 				Port:       443,
 				TargetPort: intstr.FromInt32(443),
 			},
+			{
+				Name:       "quic",
+				Protocol:   corev1.ProtocolUDP,
+				Port:       443,
+				TargetPort: intstr.FromInt32(443),
+			},
 			{
 				Name:       "wireguard",
 				Protocol:   corev1.ProtocolUDP,
@@ -371,6 +389,12 @@ func (r *WorkMachineReconciler) ensureTunnelServer(check *reconciler.Check[*v1.W
 				Port:       53,
 				TargetPort: intstr.FromInt32(53),
 			},
+			{
+				Name:       "metrics",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       9090,
+				TargetPort: intstr.FromInt32(9090),
+			},
 		}
 
 		return nil
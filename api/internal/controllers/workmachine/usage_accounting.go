@@ -0,0 +1,66 @@
+package workmachine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllerconfig"
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cost"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/reconciler"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// accountUsage extends Status.Usage up to now and prices it
+//
+// Accounting is interval based, so it runs before any start/stop transition updates
+// StartedAt/StoppedAt. Pricing failures are logged and never block the reconciliation.
+func (r *WorkMachineReconciler) accountUsage(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) {
+	obj.Status.Usage = cost.Accumulate(obj, time.Now())
+
+	hourlyPrice, err := machineHourlyPrice(check.Context(), r.Client, usageMachineType(obj))
+	if err != nil {
+		check.Logger().Warn("failed to get hourly price of machine type", "error", err)
+	}
+
+	obj.Status.Usage.Cost = cost.FormatAmount(cost.WorkMachineCost(obj.Status.Usage, hourlyPrice, storagePrices(r.Cfg)))
+}
+
+// storagePrices returns the per GB-month storage prices of the installation
+func storagePrices(cfg *controllerconfig.ControllerConfig) cost.StoragePrices {
+	return cost.StoragePrices{
+		VolumePerGBMonth:   cfg.Budget.VolumePricePerGBMonth,
+		SnapshotPerGBMonth: cfg.Budget.SnapshotPricePerGBMonth,
+	}
+}
+
+// usageMachineType returns the machine type the machine is running as
+func usageMachineType(obj *v1.WorkMachine) string {
+	if obj.Status.CurrentMachineType != "" {
+		return obj.Status.CurrentMachineType
+	}
+	return obj.Spec.MachineType
+}
+
+// machineHourlyPrice returns the hourly price of a machine type, a missing type or price is free
+func machineHourlyPrice(ctx context.Context, reader client.Reader, name string) (float64, error) {
+	if name == "" {
+		return 0, nil
+	}
+
+	machineType := &v1.MachineType{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: name}, machineType); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	price, err := cost.ParsePrice(machineType.Spec.HourlyPrice)
+	if err != nil {
+		return 0, fmt.Errorf("machine type %s: %w", name, err)
+	}
+	return price, nil
+}
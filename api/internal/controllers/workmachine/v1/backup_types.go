@@ -0,0 +1,191 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="WorkMachine",type=string,JSONPath=`.spec.workMachine`
+// +kubebuilder:printcolumn:name="Full",type=boolean,JSONPath=`.spec.full`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.status.sizeBytes`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Backup is a point-in-time copy of the storage volume of a WorkMachine in object storage
+//
+// Every workspace home and environment volume is sent as a btrfs stream, either full or
+// incremental to the same volume in an earlier Backup. Backups are not owned by the WorkMachine,
+// so they outlive it and can be restored to another machine.
+type Backup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BackupSpec   `json:"spec,omitempty"`
+	Status BackupStatus `json:"status,omitempty"`
+}
+
+// BackupReason is why a backup was taken
+type BackupReason string
+
+const (
+	BackupReasonScheduled BackupReason = "scheduled"
+	BackupReasonManual    BackupReason = "manual"
+)
+
+// BackupSpec defines the desired state of Backup
+type BackupSpec struct {
+	// WorkMachine whose storage volume is backed up
+	// +kubebuilder:validation:Required
+	WorkMachine string `json:"workMachine"`
+
+	// Full sends every volume in full, instead of incrementally to the previous backup
+	// +optional
+	Full bool `json:"full,omitempty"`
+
+	// Reason is why the backup was taken
+	// +kubebuilder:validation:Enum=scheduled;manual
+	// +kubebuilder:default=manual
+	Reason BackupReason `json:"reason,omitempty"`
+}
+
+// BackupState represents the current state of a backup or a restore
+type BackupState string
+
+const (
+	BackupStatePending   BackupState = "Pending"
+	BackupStateRunning   BackupState = "Running"
+	BackupStateCompleted BackupState = "Completed"
+	BackupStateFailed    BackupState = "Failed"
+)
+
+// BackupStatus defines the observed state of Backup
+type BackupStatus struct {
+	// State is the current state of the backup
+	// +optional
+	State BackupState `json:"state,omitempty"`
+
+	// Message provides human-readable status information
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Volumes lists the backed up volumes
+	// +optional
+	Volumes []BackupVolume `json:"volumes,omitempty"`
+
+	// SizeBytes is the size of all volume streams of this backup
+	// +optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+
+	// StartedAt is when the node started the backup
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is when the backup completed or failed
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// BackupVolume is one volume of a backup
+type BackupVolume struct {
+	// Path of the volume, relative to the storage root (e.g. workspaces/my-ws, environments/env-dev)
+	Path string `json:"path"`
+
+	// ObjectKey is the key of the btrfs stream in the bucket
+	ObjectKey string `json:"objectKey"`
+
+	// Parent is the Backup this volume is incremental to, empty for a full stream
+	// +optional
+	Parent string `json:"parent,omitempty"`
+
+	// SizeBytes is the size of the btrfs stream
+	// +optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BackupList contains a list of Backup
+type BackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Backup `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Backup",type=string,JSONPath=`.spec.backup`
+// +kubebuilder:printcolumn:name="WorkMachine",type=string,JSONPath=`.spec.workMachine`
+// +kubebuilder:printcolumn:name="Workspace",type=string,JSONPath=`.spec.workspace`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BackupRestore restores a Backup onto a WorkMachine, either every volume or a single workspace home
+//
+// Volumes are only replaced while nothing uses them: the workspace must be suspended and the
+// environment deactivated. The previous content of a volume is kept on the machine under
+// .backups/pre-restore until the volume is restored again.
+type BackupRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BackupRestoreSpec   `json:"spec,omitempty"`
+	Status BackupRestoreStatus `json:"status,omitempty"`
+}
+
+// BackupRestoreSpec defines the desired state of BackupRestore
+type BackupRestoreSpec struct {
+	// Backup to restore
+	// +kubebuilder:validation:Required
+	Backup string `json:"backup"`
+
+	// WorkMachine to restore onto, defaults to the WorkMachine of the backup
+	// +optional
+	WorkMachine string `json:"workMachine,omitempty"`
+
+	// Workspace restores only the home of this workspace, all volumes are restored when empty
+	// +optional
+	Workspace string `json:"workspace,omitempty"`
+}
+
+// BackupRestoreStatus defines the observed state of BackupRestore
+type BackupRestoreStatus struct {
+	// State is the current state of the restore
+	// +optional
+	State BackupState `json:"state,omitempty"`
+
+	// Message provides human-readable status information
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// RestoredVolumes lists the paths of the restored volumes
+	// +optional
+	RestoredVolumes []string `json:"restoredVolumes,omitempty"`
+
+	// StartedAt is when the node started the restore
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is when the restore completed or failed
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BackupRestoreList contains a list of BackupRestore
+type BackupRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []BackupRestore `json:"items"`
+}
@@ -0,0 +1,147 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnnotationBudgetStopped is set on WorkMachines force-stopped by a Budget (value: the Budget name)
+// It is removed once the budget is no longer exceeded
+const AnnotationBudgetStopped = "kloudlite.io/budget-stopped"
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Limit",type=string,JSONPath=`.spec.monthlyLimit`
+// +kubebuilder:printcolumn:name="Spent",type=string,JSONPath=`.status.spent`
+// +kubebuilder:printcolumn:name="Percent",type=integer,JSONPath=`.status.percent`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Budget caps the monthly cost of the WorkMachines, environments and snapshots of a user or a team
+type Budget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BudgetSpec   `json:"spec,omitempty"`
+	Status BudgetStatus `json:"status,omitempty"`
+}
+
+// BudgetSpec defines the desired state of Budget
+type BudgetSpec struct {
+	// DisplayName is the human-friendly name shown to users
+	// +optional
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Users the budget applies to, a single user or the members of a team
+	// +kubebuilder:validation:MinItems=1
+	Users []string `json:"users"`
+
+	// MonthlyLimit is the budget of a calendar month (UTC), as a decimal amount (e.g. "250.00")
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	MonthlyLimit string `json:"monthlyLimit"`
+
+	// WarningThresholdPercent is the share of the limit at which the budget enters the warning phase
+	// +kubebuilder:default=80
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	WarningThresholdPercent int32 `json:"warningThresholdPercent,omitempty"`
+
+	// StopOnExceeded stops the running WorkMachines of the users once the limit is crossed
+	// Stopped machines can't be started again until the next month or until the limit is raised
+	// +kubebuilder:default=true
+	StopOnExceeded bool `json:"stopOnExceeded"`
+}
+
+// BudgetPhase is the spending level of a budget
+type BudgetPhase string
+
+const (
+	BudgetPhaseOK       BudgetPhase = "ok"
+	BudgetPhaseWarning  BudgetPhase = "warning"
+	BudgetPhaseExceeded BudgetPhase = "exceeded"
+)
+
+// BudgetStatus defines the observed state of Budget
+type BudgetStatus struct {
+	// Period is the month the totals belong to (YYYY-MM)
+	// +optional
+	Period string `json:"period,omitempty"`
+
+	// Currency of the amounts, as configured for the installation
+	// +optional
+	Currency string `json:"currency,omitempty"`
+
+	// Spent is the cost of the period so far, as a decimal amount
+	// +optional
+	Spent string `json:"spent,omitempty"`
+
+	// Percent is the share of the monthly limit spent
+	// +optional
+	Percent int32 `json:"percent,omitempty"`
+
+	// Phase is ok, warning or exceeded
+	// +optional
+	Phase BudgetPhase `json:"phase,omitempty"`
+
+	// Message provides human-readable status information
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Users lists the cost of the period per user
+	// +optional
+	Users []ResourceCost `json:"users,omitempty"`
+
+	// WorkMachines lists the cost of the period per WorkMachine
+	// +optional
+	WorkMachines []ResourceCost `json:"workMachines,omitempty"`
+
+	// Environments lists the snapshot storage cost of the period per environment
+	// +optional
+	Environments []ResourceCost `json:"environments,omitempty"`
+
+	// StoppedWorkMachines lists the WorkMachines force-stopped because the budget was exceeded
+	// +optional
+	StoppedWorkMachines []string `json:"stoppedWorkMachines,omitempty"`
+
+	// WarnedAt is when the budget entered the warning phase in the period
+	// +optional
+	WarnedAt *metav1.Time `json:"warnedAt,omitempty"`
+
+	// ExceededAt is when the budget was exceeded in the period
+	// +optional
+	ExceededAt *metav1.Time `json:"exceededAt,omitempty"`
+
+	// LastUpdated is when the totals were last computed
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+// ResourceCost is the cost of one user, WorkMachine or environment in a budget period
+type ResourceCost struct {
+	// Name of the user, WorkMachine or environment
+	Name string `json:"name"`
+
+	// Owner of the WorkMachine or environment
+	// +optional
+	Owner string `json:"owner,omitempty"`
+
+	// Cost is a decimal amount
+	Cost string `json:"cost"`
+
+	// RuntimeHours is how long the WorkMachine ran in the period
+	// +optional
+	RuntimeHours string `json:"runtimeHours,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BudgetList contains a list of Budget
+type BudgetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Budget `json:"items"`
+}
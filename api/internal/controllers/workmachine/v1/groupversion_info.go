@@ -20,5 +20,5 @@ var (
 )
 
 func init() {
-	SchemeBuilder.Register(&MachineType{}, &MachineTypeList{}, &WorkMachine{}, &WorkMachineList{})
+	SchemeBuilder.Register(&MachineType{}, &MachineTypeList{}, &WorkMachine{}, &WorkMachineList{}, &Budget{}, &BudgetList{}, &Backup{}, &BackupList{}, &BackupRestore{}, &BackupRestoreList{}, &WorkMachineMigration{}, &WorkMachineMigrationList{})
 }
@@ -54,6 +54,12 @@ type MachineTypeSpec struct {
 	// +kubebuilder:default=false
 	IsDefault bool `json:"isDefault"`
 
+	// HourlyPrice is the price of one hour of runtime, as a decimal amount (e.g. "0.0832")
+	// Used for cost tracking and budgets, the machine type is free when unset
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +optional
+	HourlyPrice string `json:"hourlyPrice,omitempty"`
+
 	// Priority for sorting in UI (lower numbers appear first)
 	// +kubebuilder:default=100
 	Priority int32 `json:"priority,omitempty"`
@@ -73,6 +79,42 @@ type MachineTypeSpec struct {
 	// Tolerations for pod scheduling
 	// +optional
 	Tolerations []Toleration `json:"tolerations,omitempty"`
+
+	// WarmPool keeps pre-provisioned WorkMachines of this type, claimed by new WorkMachines
+	// instead of launching an instance
+	// +optional
+	WarmPool *WarmPoolConfig `json:"warmPool,omitempty"`
+}
+
+// WarmPoolConfig defines the pre-provisioned WorkMachines of a machine type
+//
+// Warm machines are WorkMachines labelled with LabelWarmPool, owned by "system". A WorkMachine
+// created with the machine type, placement and on-demand capacity of a warm machine (and a
+// volume at least as large) takes over its instance: the node is renamed after the new
+// WorkMachine and re-owned, and the warm WorkMachine is deleted. The pool is then replenished.
+type WarmPoolConfig struct {
+	// Size is the number of warm machines to keep
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=50
+	Size int32 `json:"size"`
+
+	// State of the warm machines once provisioned: running machines are claimed faster,
+	// stopped ones only cost their storage
+	// +kubebuilder:validation:Enum=running;stopped
+	// +kubebuilder:default=stopped
+	// +optional
+	State MachineState `json:"state,omitempty"`
+
+	// VolumeSize of the warm machines in GB, WorkMachines asking for less cannot claim them
+	// +kubebuilder:default=100
+	// +kubebuilder:validation:Minimum=50
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	VolumeSize *int32 `json:"volumeSize,omitempty"`
+
+	// Placement of the warm machines (see WorkMachineSpec.Placement)
+	// +optional
+	Placement string `json:"placement,omitempty"`
 }
 
 // MachineResources defines the compute resources
@@ -124,6 +166,29 @@ type MachineTypeStatus struct {
 	// Conditions represent the latest available observations
 	// +optional
 	Conditions []MachineTypeCondition `json:"conditions,omitempty"`
+
+	// WarmPool reports the warm machines of this type
+	// +optional
+	WarmPool *WarmPoolStatus `json:"warmPool,omitempty"`
+}
+
+// WarmPoolStatus is the observed state of the warm pool of a machine type
+type WarmPoolStatus struct {
+	// Ready is the number of warm machines that can be claimed
+	// +optional
+	Ready int32 `json:"ready,omitempty"`
+
+	// Provisioning is the number of warm machines being created, started or stopped
+	// +optional
+	Provisioning int32 `json:"provisioning,omitempty"`
+
+	// Claimed is the number of existing WorkMachines of this type claimed from a warm machine
+	// +optional
+	Claimed int32 `json:"claimed,omitempty"`
+
+	// LastClaimLatency is how long the last WorkMachine claiming a warm machine took to be ready
+	// +optional
+	LastClaimLatency string `json:"lastClaimLatency,omitempty"`
 }
 
 // MachineTypeCondition represents a condition of the MachineType
@@ -0,0 +1,132 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.source`
+// +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.target`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// WorkMachineMigration moves the workspaces and environments of a WorkMachine to a new
+// WorkMachine, possibly with another machine type, region or provider
+//
+// Every workspace home and environment volume is snapshotted to the snapshot registry and
+// restored on the target. Workspaces and environments are then moved to the target, the source
+// is deleted and the target takes over its namespace.
+type WorkMachineMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WorkMachineMigrationSpec   `json:"spec,omitempty"`
+	Status WorkMachineMigrationStatus `json:"status,omitempty"`
+}
+
+// WorkMachineMigrationSpec defines the desired state of WorkMachineMigration
+type WorkMachineMigrationSpec struct {
+	// Source is the WorkMachine to migrate
+	// +kubebuilder:validation:Required
+	Source string `json:"source"`
+
+	// Target is the name of the WorkMachine created for the migration
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Target string `json:"target"`
+
+	// MachineType of the target, defaults to the machine type of the source
+	// +optional
+	MachineType string `json:"machineType,omitempty"`
+
+	// Placement of the target (see WorkMachineSpec.Placement), defaults to the placement of the source.
+	// Moving to another provider also requires a machine type of that provider.
+	// +optional
+	Placement string `json:"placement,omitempty"`
+
+	// VolumeSize of the target in GB, defaults to the volume size of the source
+	// +optional
+	VolumeSize *int32 `json:"volumeSize,omitempty"`
+}
+
+// MigrationPhase is a step of a WorkMachineMigration
+type MigrationPhase string
+
+const (
+	MigrationPhasePending        MigrationPhase = "Pending"
+	MigrationPhaseProvisioning   MigrationPhase = "Provisioning"
+	MigrationPhaseStopping       MigrationPhase = "Stopping"
+	MigrationPhaseSnapshotting   MigrationPhase = "Snapshotting"
+	MigrationPhaseRestoring      MigrationPhase = "Restoring"
+	MigrationPhaseSwitchingOver  MigrationPhase = "SwitchingOver"
+	MigrationPhaseDeletingSource MigrationPhase = "DeletingSource"
+	MigrationPhaseCompleted      MigrationPhase = "Completed"
+	MigrationPhaseFailed         MigrationPhase = "Failed"
+)
+
+// WorkMachineMigrationStatus defines the observed state of WorkMachineMigration
+type WorkMachineMigrationStatus struct {
+	// Phase is the current phase of the migration
+	// +optional
+	Phase MigrationPhase `json:"phase,omitempty"`
+
+	// Message provides human-readable status information
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// SourceRegion is the region of the source machine
+	// +optional
+	SourceRegion string `json:"sourceRegion,omitempty"`
+
+	// TargetRegion is the region of the target machine
+	// +optional
+	TargetRegion string `json:"targetRegion,omitempty"`
+
+	// SourceNamespace is the namespace of the source, adopted by the target once the source is deleted
+	// +optional
+	SourceNamespace string `json:"sourceNamespace,omitempty"`
+
+	// ProvisionedNamespace is the namespace the target was created with, deleted once it adopts
+	// the namespace of the source
+	// +optional
+	ProvisionedNamespace string `json:"provisionedNamespace,omitempty"`
+
+	// Workspaces lists the migrated workspaces (namespace/name)
+	// +optional
+	Workspaces []string `json:"workspaces,omitempty"`
+
+	// Environments lists the migrated environments (namespace/name)
+	// +optional
+	Environments []string `json:"environments,omitempty"`
+
+	// ActiveWorkspaces lists the workspaces stopped for the migration, resumed on the target
+	// +optional
+	ActiveWorkspaces []string `json:"activeWorkspaces,omitempty"`
+
+	// ActiveEnvironments lists the environments deactivated for the migration, activated on the target
+	// +optional
+	ActiveEnvironments []string `json:"activeEnvironments,omitempty"`
+
+	// StartedAt is when the migration started
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is when the migration completed or failed
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WorkMachineMigrationList contains a list of WorkMachineMigration
+type WorkMachineMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []WorkMachineMigration `json:"items"`
+}
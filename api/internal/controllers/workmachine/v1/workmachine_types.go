@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"fmt"
+
 	"github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/reconciler"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -63,6 +65,12 @@ type WorkMachineSpec struct {
 	// +kubebuilder:validation:Required
 	MachineType string `json:"machineType"`
 
+	// Placement selects the cloud provider and region the machine is provisioned in, one of the
+	// WORKMACHINE_PLACEMENT and WORKMACHINE_PLACEMENTS of the installation. It cannot be changed,
+	// a WorkMachineMigration moves a WorkMachine to another placement
+	// +optional
+	Placement string `json:"placement,omitempty"`
+
 	// VolumeSize is the size of the BTRFS storage volume in GB
 	// This volume stores environment PVCs, workspace data, and supports snapshots
 	// Root volume is fixed at 50GB for OS only
@@ -83,8 +91,135 @@ type WorkMachineSpec struct {
 	// Only applicable for cloud providers (AWS, GCP, Azure)
 	// +optional
 	AutoShutdown *AutoShutdownConfig `json:"autoShutdown,omitempty"`
+
+	// Capacity selects on-demand or spot/preemptible instances
+	// spot-with-fallback launches on-demand when no spot capacity is available
+	// Spot machines are recreated (and their workspaces/environments restored) after an interruption
+	// +kubebuilder:validation:Enum=on-demand;spot;spot-with-fallback
+	// +kubebuilder:default=on-demand
+	// +optional
+	Capacity CapacityType `json:"capacity,omitempty"`
+
+	// Hibernation snapshots and releases the storage volume of a machine stopped for long
+	// Only applicable for cloud providers (AWS, GCP, Azure, OCI)
+	// +optional
+	Hibernation *HibernationConfig `json:"hibernation,omitempty"`
+
+	// Backup periodically streams the workspace homes and environment volumes to object storage
+	// +optional
+	Backup *BackupConfig `json:"backup,omitempty"`
+
+	// NodePools are worker nodes owned by the machine, for compose services that do not fit on it
+	// A service is placed on a pool with `x-kloudlite: {node-pool: <name>}`, pool nodes are
+	// created and started with the machine and stopped after it (including by auto-shutdown)
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	NodePools []NodePool `json:"nodePools,omitempty"`
 }
 
+// NodePool is a group of worker nodes of one machine type, owned by a WorkMachine
+type NodePool struct {
+	// Name of the pool, referenced by x-kloudlite.node-pool in compose files
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=20
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// MachineType of the pool nodes
+	// +kubebuilder:validation:Required
+	MachineType string `json:"machineType"`
+
+	// Nodes is the number of nodes in the pool, 0 keeps the pool without nodes
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	Nodes int32 `json:"nodes"`
+
+	// VolumeSize is the size of the storage volume of each node in GB
+	// +kubebuilder:default=50
+	// +kubebuilder:validation:Minimum=50
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	VolumeSize *int32 `json:"volumeSize,omitempty"`
+}
+
+// PoolNodeName is the name of the index-th node of a node pool of a WorkMachine, used for its
+// cloud machine and its Node
+func PoolNodeName(workMachine, pool string, index int32) string {
+	return fmt.Sprintf("%s-%s-%d", workMachine, pool, index)
+}
+
+// NodeNames lists the Nodes of the WorkMachine, its own named after it and those of its node pools
+func (wm *WorkMachine) NodeNames() []string {
+	names := []string{wm.Name}
+	for _, pool := range wm.Spec.NodePools {
+		for i := int32(0); i < pool.Nodes; i++ {
+			names = append(names, PoolNodeName(wm.Name, pool.Name, i))
+		}
+	}
+	return names
+}
+
+// GetNodePool returns the node pool of the spec with the given name, nil if there is none
+func (s *WorkMachineSpec) GetNodePool(name string) *NodePool {
+	for i := range s.NodePools {
+		if s.NodePools[i].Name == name {
+			return &s.NodePools[i]
+		}
+	}
+	return nil
+}
+
+// CapacityType is the purchasing option of the cloud instance
+type CapacityType string
+
+const (
+	CapacityOnDemand         CapacityType = "on-demand"
+	CapacitySpot             CapacityType = "spot"
+	CapacitySpotWithFallback CapacityType = "spot-with-fallback"
+)
+
+// IsSpot reports whether spot/preemptible capacity should be requested
+func (c CapacityType) IsSpot() bool {
+	return c == CapacitySpot || c == CapacitySpotWithFallback
+}
+
+const (
+	// AnnotationInterruptionNotice is set on the Node by the node manager when the cloud
+	// announces that the spot instance will be reclaimed (value: RFC3339 time of the notice)
+	AnnotationInterruptionNotice = "kloudlite.io/interruption-notice"
+
+	// LabelSnapshotReason marks snapshot requests/restores created for an interruption, a resize or a migration
+	LabelSnapshotReason = "snapshots.kloudlite.io/reason"
+
+	// SnapshotReasonInterruption is the LabelSnapshotReason value for emergency snapshots
+	SnapshotReasonInterruption = "interruption"
+
+	// SnapshotReasonResize is the LabelSnapshotReason value for snapshots taken before a machine type change
+	SnapshotReasonResize = "resize"
+
+	// SnapshotReasonMigration is the LabelSnapshotReason value for snapshots moved by a WorkMachineMigration
+	SnapshotReasonMigration = "migration"
+
+	// LabelNodePool is set on the nodes of a node pool (value: the pool name)
+	LabelNodePool = "kloudlite.io/node-pool"
+
+	// LabelNodePoolWorkMachine is set on the nodes of a node pool (value: the WorkMachine owning the pool)
+	LabelNodePoolWorkMachine = "kloudlite.io/node-pool-workmachine"
+
+	// LabelWarmPool is set on the warm WorkMachines of a machine type (value: the machine type)
+	LabelWarmPool = "machines.kloudlite.io/warm-pool"
+
+	// LabelWarmPoolClaimedBy is set on a warm WorkMachine once it is claimed (value: the claiming WorkMachine)
+	LabelWarmPoolClaimedBy = "machines.kloudlite.io/claimed-by"
+
+	// AnnotationBackupOwner is set on a Backup to the owner of its WorkMachine, so that restores can
+	// be checked once the WorkMachine is deleted
+	AnnotationBackupOwner = "machines.kloudlite.io/backup-owner"
+)
+
 type CloudProvider string
 
 const (
@@ -92,6 +227,12 @@ const (
 	GCP   CloudProvider = "gcp"
 	Azure CloudProvider = "azure"
 	OCI   CloudProvider = "oci"
+
+	// BYO runs WorkMachines on hosts registered by the admin (bare-metal / on-prem)
+	BYO CloudProvider = "byo"
+
+	// Local runs WorkMachines as k3s-in-docker containers, for developing and testing Kloudlite
+	Local CloudProvider = "local"
 )
 
 // MachineConfiguration defines configuration options for the WorkMachine
@@ -139,6 +280,45 @@ type AutoShutdownConfig struct {
 	CheckIntervalMinutes int32 `json:"checkIntervalMinutes"`
 }
 
+// HibernationConfig defines when a stopped WorkMachine is hibernated
+//
+// A hibernated machine keeps its storage volume only as a snapshot in the provider's block-snapshot
+// storage, the volume is recreated from the snapshot when the machine is started again.
+type HibernationConfig struct {
+	// Enabled determines if hibernation is active
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled"`
+
+	// AfterStoppedDays is how long the machine stays stopped before it is hibernated
+	// +kubebuilder:default=7
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=365
+	AfterStoppedDays int32 `json:"afterStoppedDays"`
+}
+
+// BackupConfig defines the backup schedule and retention of a WorkMachine
+type BackupConfig struct {
+	// Enabled determines if scheduled backups are taken
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled"`
+
+	// IntervalHours is the time between two scheduled backups
+	// +kubebuilder:default=24
+	// +kubebuilder:validation:Minimum=1
+	IntervalHours int32 `json:"intervalHours"`
+
+	// KeepLast is the number of completed backups kept, older backups are deleted
+	// Backups needed to restore a kept incremental backup are kept as well
+	// +kubebuilder:default=7
+	// +kubebuilder:validation:Minimum=1
+	KeepLast int32 `json:"keepLast"`
+
+	// FullEvery makes every n-th backup a full one, the others are incremental to the previous backup
+	// +kubebuilder:default=7
+	// +kubebuilder:validation:Minimum=1
+	FullEvery int32 `json:"fullEvery"`
+}
+
 // MachineState represents the state of a WorkMachine
 type MachineState string
 
@@ -160,6 +340,16 @@ const (
 
 	// MachineStateDisabled means the machine is disabled (user inactive)
 	MachineStateDisabled MachineState = "disabled"
+
+	// MachineStateInterrupted means the cloud reclaimed the spot instance
+	// The controller recreates the machine and restores the emergency snapshots
+	MachineStateInterrupted MachineState = "interrupted"
+
+	// MachineStateHibernating means the storage volume of the stopped machine is being snapshotted
+	MachineStateHibernating MachineState = "hibernating"
+
+	// MachineStateHibernated means the storage volume was released, only its snapshot is kept
+	MachineStateHibernated MachineState = "hibernated"
 )
 
 // GPUInfo contains detailed information about GPU hardware
@@ -267,6 +457,187 @@ type WorkMachineStatus struct {
 	// MachineTypeChangeMessage provides status updates during machine type change
 	// +optional
 	MachineTypeChangeMessage string `json:"machineTypeChangeMessage,omitempty"`
+
+	// Resize tracks the phases of a machine type change, cleared once it completes or is rolled back
+	// +optional
+	Resize *ResizeStatus `json:"resize,omitempty"`
+
+	// --- Spot interruption tracking ---
+
+	// InterruptedAt is when the spot instance was interrupted
+	// Cleared once the recreated machine has restored the emergency snapshots
+	// +optional
+	InterruptedAt *metav1.Time `json:"interruptedAt,omitempty"`
+
+	// InterruptionCount is the number of spot interruptions of this machine
+	// +optional
+	InterruptionCount int32 `json:"interruptionCount,omitempty"`
+
+	// InterruptedWorkspaces are the workspaces (namespace/name) suspended by the interruption
+	// They are resumed on the new machine whether or not their emergency snapshot was restored.
+	// +optional
+	InterruptedWorkspaces []string `json:"interruptedWorkspaces,omitempty"`
+
+	// InterruptedEnvironments are the environments (namespace/name) deactivated by the interruption
+	// They are activated on the new machine whether or not their emergency snapshot was restored.
+	// +optional
+	InterruptedEnvironments []string `json:"interruptedEnvironments,omitempty"`
+
+	// Hibernation tracks the storage volume snapshot of a hibernating or hibernated machine
+	// Cleared once the volume has been restored for a start
+	// +optional
+	Hibernation *HibernationStatus `json:"hibernation,omitempty"`
+
+	// --- Cost tracking ---
+
+	// Usage accumulates the metered usage of the machine in the current month
+	// +optional
+	Usage *UsageStatus `json:"usage,omitempty"`
+
+	// --- Node pools ---
+
+	// NodePools reports the worker nodes of the machine's node pools
+	// +optional
+	NodePools []PoolNodeStatus `json:"nodePools,omitempty"`
+
+	// --- Warm pool ---
+
+	// WarmPoolClaim tracks the warm machine this machine was claimed from
+	// +optional
+	WarmPoolClaim *WarmPoolClaimStatus `json:"warmPoolClaim,omitempty"`
+}
+
+// WarmPoolClaimStatus is the observed state of the claim of a warm machine
+type WarmPoolClaimStatus struct {
+	// From is the name of the claimed warm WorkMachine, and of its node until it is renamed
+	From string `json:"from"`
+
+	// ClaimedAt is when the warm machine was claimed
+	ClaimedAt metav1.Time `json:"claimedAt"`
+
+	// ReadyAt is when the renamed node was ready
+	// +optional
+	ReadyAt *metav1.Time `json:"readyAt,omitempty"`
+
+	// Latency is how long the machine took to be ready after it was created
+	// +optional
+	Latency string `json:"latency,omitempty"`
+}
+
+// PoolNodeStatus is the observed state of a worker node of a node pool
+type PoolNodeStatus struct {
+	// Pool is the name of the node pool
+	Pool string `json:"pool"`
+
+	// Name is the node name, <workmachine>-<pool>-<index>
+	Name string `json:"name"`
+
+	// MachineType the node was created with
+	// +optional
+	MachineType string `json:"machineType,omitempty"`
+
+	MachineInfo `json:",inline"`
+}
+
+// UsageStatus holds the metered usage of a WorkMachine for one month (UTC)
+type UsageStatus struct {
+	// Period is the month the totals belong to (YYYY-MM)
+	Period string `json:"period"`
+
+	// RuntimeSeconds is how long the machine was running in the period
+	// +optional
+	RuntimeSeconds int64 `json:"runtimeSeconds,omitempty"`
+
+	// VolumeGBSeconds is the size of the provisioned storage volume integrated over the period
+	// +optional
+	VolumeGBSeconds int64 `json:"volumeGBSeconds,omitempty"`
+
+	// SnapshotGBSeconds is the size of the hibernation snapshot integrated over the period
+	// +optional
+	SnapshotGBSeconds int64 `json:"snapshotGBSeconds,omitempty"`
+
+	// Cost is the priced usage of the period, as a decimal amount (e.g. "12.34")
+	// +optional
+	Cost string `json:"cost,omitempty"`
+
+	// LastAccountedAt is when the usage was last accumulated
+	// +optional
+	LastAccountedAt *metav1.Time `json:"lastAccountedAt,omitempty"`
+}
+
+// HibernationStatus is the observed state of a hibernation
+type HibernationStatus struct {
+	// SnapshotID is the provider's ID of the storage volume snapshot
+	// +optional
+	SnapshotID string `json:"snapshotID,omitempty"`
+
+	// Progress is the completion percentage (0-100) of the snapshot
+	// +optional
+	Progress int32 `json:"progress,omitempty"`
+
+	// VolumeReleased is set once the storage volume has been deleted
+	// +optional
+	VolumeReleased bool `json:"volumeReleased,omitempty"`
+
+	// HibernatedAt is when the storage volume was released
+	// +optional
+	HibernatedAt *metav1.Time `json:"hibernatedAt,omitempty"`
+}
+
+// ResizePhase is a phase of a machine type change
+type ResizePhase string
+
+const (
+	// ResizePhaseSnapshotting snapshots the active workspaces and environments
+	ResizePhaseSnapshotting ResizePhase = "Snapshotting"
+	// ResizePhaseDraining counts down before workspaces and environments are stopped
+	ResizePhaseDraining ResizePhase = "Draining"
+	// ResizePhaseStopping stops workspaces, environments and the machine
+	ResizePhaseStopping ResizePhase = "Stopping"
+	// ResizePhaseChanging changes the machine type with the cloud provider
+	ResizePhaseChanging ResizePhase = "Changing"
+	// ResizePhaseStarting starts the machine and waits for its node to be ready
+	ResizePhaseStarting ResizePhase = "Starting"
+	// ResizePhaseRestoring resumes the workspaces and environments that were active
+	ResizePhaseRestoring ResizePhase = "Restoring"
+	// ResizePhaseRollingBack changes the machine back to its previous type after a failure
+	ResizePhaseRollingBack ResizePhase = "RollingBack"
+)
+
+// ResizeStatus is the observed state of a machine type change
+type ResizeStatus struct {
+	// Phase is the current phase of the change
+	Phase ResizePhase `json:"phase"`
+
+	// FromMachineType is the machine type before the change, restored on rollback
+	FromMachineType string `json:"fromMachineType"`
+
+	// ToMachineType is the requested machine type
+	ToMachineType string `json:"toMachineType"`
+
+	// PhaseStartedAt is when the current phase started
+	// +optional
+	PhaseStartedAt *metav1.Time `json:"phaseStartedAt,omitempty"`
+
+	// DrainDeadline is when workspaces and environments are stopped
+	// +optional
+	DrainDeadline *metav1.Time `json:"drainDeadline,omitempty"`
+
+	// ActiveWorkspaces are the workspaces (namespace/name) to resume after the change
+	// +optional
+	ActiveWorkspaces []string `json:"activeWorkspaces,omitempty"`
+
+	// ActiveEnvironments are the environments (namespace/name) to reactivate after the change
+	// +optional
+	ActiveEnvironments []string `json:"activeEnvironments,omitempty"`
+
+	// RolledBack is set once the machine is back on FromMachineType
+	// +optional
+	RolledBack bool `json:"rolledBack,omitempty"`
+
+	// Error is why the change is being rolled back
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 // MachineInfo contains information about a cloud instance
@@ -296,6 +667,11 @@ type MachineInfo struct {
 	// Message provides additional information about the instance state
 	Message string `json:"message,omitempty"`
 
+	// Capacity is the purchasing option the instance was launched with
+	// (on-demand after a spot-with-fallback fallback)
+	// +optional
+	Capacity CapacityType `json:"capacity,omitempty"`
+
 	// HasGPU indicates if this machine has a GPU (stored in status for quick filtering)
 	// +optional
 	HasGPU bool `json:"hasGPU,omitempty"`
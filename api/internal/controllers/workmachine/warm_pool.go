@@ -0,0 +1,318 @@
+package workmachine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	"github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Warm pool claims
+//
+// A WorkMachine being created first looks for a warm machine of its machine type (see
+// v1.WarmPoolConfig). The warm WorkMachine is labelled with LabelWarmPoolClaimedBy and its instance
+// is taken over. Once the claim is saved in the status of the WorkMachine, the storage volume of the
+// instance gets the deleteVolumePostTermination of the WorkMachine, instead of the one of the warm
+// machine, and the warm WorkMachine is deleted without deleting the instance. The node of the instance, still
+// named after the warm machine, is then renamed: a pod on that node rewrites the node name and the
+// owner label of the k3s agent and restarts it, so that it joins again as the new WorkMachine. The
+// old Node is deleted once the new one is ready, and the claim latency is recorded in status.
+
+// renameNodePodName is the pod renaming the node of a claimed warm machine, in the target namespace
+const renameNodePodName = "rename-node"
+
+// warmMachineCandidates lists the warm machines that obj may claim, running ones first
+func warmMachineCandidates(obj *v1.WorkMachine, warm []v1.WorkMachine) []v1.WorkMachine {
+	volumeSize := fn.ValueOf(obj.Spec.VolumeSize)
+
+	var candidates []v1.WorkMachine
+	for _, wm := range warm {
+		if wm.Labels[v1.LabelWarmPool] != obj.Spec.MachineType || wm.Labels[v1.LabelWarmPoolClaimedBy] != "" {
+			continue
+		}
+		if wm.DeletionTimestamp != nil || wm.Status.MachineID == "" {
+			continue
+		}
+		if wm.Spec.Placement != obj.Spec.Placement || wm.Spec.VolumeType != obj.Spec.VolumeType {
+			continue
+		}
+		if wm.Status.State != v1.MachineStateRunning && wm.Status.State != v1.MachineStateStopped {
+			continue
+		}
+		if wm.Status.State != wm.Spec.State || wm.Status.StorageVolumeSize < volumeSize {
+			continue
+		}
+		candidates = append(candidates, wm)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ri := candidates[i].Status.State == v1.MachineStateRunning
+		rj := candidates[j].Status.State == v1.MachineStateRunning
+		if ri != rj {
+			return ri
+		}
+		return candidates[i].CreationTimestamp.Before(&candidates[j].CreationTimestamp)
+	})
+	return candidates
+}
+
+// canClaimWarmMachine reports whether obj may be created from a warm machine
+func (r *WorkMachineReconciler) canClaimWarmMachine(obj *v1.WorkMachine) bool {
+	if r.env.CloudProvider == v1.BYO || r.env.CloudProvider == v1.Local {
+		return false
+	}
+	if obj.Labels[v1.LabelWarmPool] != "" {
+		return false
+	}
+	return obj.Spec.Capacity == "" || obj.Spec.Capacity == v1.CapacityOnDemand
+}
+
+// claimWarmMachine takes over the instance of a warm machine, and reports whether one was claimed
+func (r *WorkMachineReconciler) claimWarmMachine(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) (bool, error) {
+	if !r.canClaimWarmMachine(obj) {
+		return false, nil
+	}
+
+	ctx := check.Context()
+
+	var list v1.WorkMachineList
+	if err := r.List(ctx, &list, client.MatchingLabels{v1.LabelWarmPool: obj.Spec.MachineType}); err != nil {
+		return false, fmt.Errorf("failed to list warm machines: %w", err)
+	}
+
+	// A claim whose status was not saved is resumed, its instance is not usable by anyone else
+	for i := range list.Items {
+		if warm := &list.Items[i]; warm.Labels[v1.LabelWarmPoolClaimedBy] == obj.Name {
+			r.recordWarmClaim(check, obj, warm)
+			return true, nil
+		}
+	}
+
+	for _, candidate := range warmMachineCandidates(obj, list.Items) {
+		warm := candidate.DeepCopy()
+		warm.Labels[v1.LabelWarmPoolClaimedBy] = obj.Name
+		if err := r.Update(ctx, warm); err != nil {
+			if apiErrors.IsConflict(err) || apiErrors.IsNotFound(err) {
+				// claimed or deleted concurrently, try the next one
+				continue
+			}
+			return false, fmt.Errorf("failed to claim warm machine %s: %w", warm.Name, err)
+		}
+
+		r.recordWarmClaim(check, obj, warm)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// recordWarmClaim takes over the instance of the claimed warm machine in the status of obj
+// The warm machine is deleted by completeWarmClaim, once the status is saved.
+func (r *WorkMachineReconciler) recordWarmClaim(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine, warm *v1.WorkMachine) {
+	obj.Status.MachineInfo = warm.Status.MachineInfo
+	obj.Status.StartedAt = &metav1.Time{Time: time.Now()}
+	obj.Status.WarmPoolClaim = &v1.WarmPoolClaimStatus{
+		From:      warm.Name,
+		ClaimedAt: metav1.Now(),
+	}
+	if obj.Spec.State == v1.MachineStateRunning {
+		obj.Status.State = v1.MachineStateStarting
+		obj.Status.Message = fmt.Sprintf("Claimed warm machine %s, waiting for node to join", warm.Name)
+	}
+
+	check.Logger().Info("claimed warm machine", "warmMachine", warm.Name, "machineID", warm.Status.MachineID)
+}
+
+// releaseClaimedWarmMachine gives the storage volume of the claimed instance the deletion setting
+// of obj, and deletes the claimed warm WorkMachine, leaving its instance alone (see cleanupCloudMachine)
+func (r *WorkMachineReconciler) releaseClaimedWarmMachine(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) error {
+	ctx := check.Context()
+	claim := obj.Status.WarmPoolClaim
+
+	warm := &v1.WorkMachine{}
+	if err := r.Get(ctx, client.ObjectKey{Name: claim.From}, warm); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if warm.Labels[v1.LabelWarmPoolClaimedBy] != obj.Name {
+		return fmt.Errorf("warm machine %s is not claimed by %s", warm.Name, obj.Name)
+	}
+	if warm.DeletionTimestamp != nil {
+		return nil
+	}
+
+	// The warm machine was created with deleteVolumePostTermination, the volume now holds the data of obj
+	if err := r.cloudProviderAPI.SetVolumeDeletion(ctx, obj); err != nil {
+		return fmt.Errorf("failed to set storage volume deletion of claimed machine: %w", err)
+	}
+
+	if err := r.Delete(ctx, warm); err != nil && !apiErrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete claimed warm machine %s: %w", warm.Name, err)
+	}
+	return nil
+}
+
+// completeWarmClaim starts the claimed instance and renames its node after the WorkMachine
+func (r *WorkMachineReconciler) completeWarmClaim(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	ctx := check.Context()
+	claim := obj.Status.WarmPoolClaim
+
+	if err := r.releaseClaimedWarmMachine(check, obj); err != nil {
+		return check.Failed(err)
+	}
+
+	mi, err := r.cloudProviderAPI.GetMachineStatus(ctx, obj.Status.MachineID)
+	if err != nil {
+		return check.Failed(fmt.Errorf("failed to get status of claimed machine: %w", err))
+	}
+	obj.Status.MachineInfo = *mi
+
+	switch mi.State {
+	case v1.MachineStateStopped:
+		if err := r.cloudProviderAPI.StartMachine(ctx, obj.Status.MachineID); err != nil {
+			return check.Failed(fmt.Errorf("failed to start claimed machine: %w", err))
+		}
+		obj.Status.State = v1.MachineStateStarting
+		return check.UpdateMsg("starting claimed warm machine").RequeueAfter(r.Cfg.WorkMachine.WarmPoolClaimCheckInterval)
+	case v1.MachineStateRunning:
+	default:
+		return check.UpdateMsg("waiting for claimed warm machine to run").RequeueAfter(r.Cfg.WorkMachine.WarmPoolClaimCheckInterval)
+	}
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, client.ObjectKey{Name: obj.Name}, node); err == nil && r.isNodeReady(node) {
+		return r.finishWarmClaim(check, obj)
+	} else if err != nil && !apiErrors.IsNotFound(err) {
+		return check.Failed(err)
+	}
+
+	oldNode := &corev1.Node{}
+	if err := r.Get(ctx, client.ObjectKey{Name: claim.From}, oldNode); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return check.UpdateMsg("waiting for renamed node to join").RequeueAfter(r.Cfg.WorkMachine.WarmPoolClaimCheckInterval)
+		}
+		return check.Failed(err)
+	}
+	if !r.isNodeReady(oldNode) {
+		return check.UpdateMsg("waiting for node of claimed warm machine").RequeueAfter(r.Cfg.WorkMachine.WarmPoolClaimCheckInterval)
+	}
+
+	if err := r.ensureRenameNodePod(check, obj); err != nil {
+		return check.Failed(err)
+	}
+	return check.UpdateMsg(fmt.Sprintf("renaming node %s to %s", claim.From, obj.Name)).RequeueAfter(r.Cfg.WorkMachine.WarmPoolClaimCheckInterval)
+}
+
+// finishWarmClaim deletes what is left of the warm machine and records the claim latency
+func (r *WorkMachineReconciler) finishWarmClaim(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) reconciler.StepResult {
+	ctx := check.Context()
+	claim := obj.Status.WarmPoolClaim
+
+	if err := r.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: claim.From}}); err != nil && !apiErrors.IsNotFound(err) {
+		return check.Failed(fmt.Errorf("failed to delete node %s: %w", claim.From, err))
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: renameNodePodName, Namespace: obj.Spec.TargetNamespace}}
+	if err := r.Delete(ctx, pod); err != nil && !apiErrors.IsNotFound(err) {
+		return check.Failed(fmt.Errorf("failed to delete rename pod: %w", err))
+	}
+
+	now := metav1.Now()
+	claim.ReadyAt = &now
+	claim.Latency = now.Sub(obj.CreationTimestamp.Time).Round(time.Second).String()
+	check.Logger().Info("warm machine claim completed", "warmMachine", claim.From, "latency", claim.Latency)
+	return check.Passed()
+}
+
+// ensureRenameNodePod creates the pod renaming the node of the claimed warm machine
+func (r *WorkMachineReconciler) ensureRenameNodePod(check *reconciler.Check[*v1.WorkMachine], obj *v1.WorkMachine) error {
+	ctx := check.Context()
+	claim := obj.Status.WarmPoolClaim
+
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, client.ObjectKey{Name: renameNodePodName, Namespace: obj.Spec.TargetNamespace}, pod); err == nil {
+		if pod.Status.Phase != corev1.PodFailed {
+			return nil
+		}
+		if err := r.Delete(ctx, pod); err != nil && !apiErrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete failed rename pod: %w", err)
+		}
+		return nil
+	} else if !apiErrors.IsNotFound(err) {
+		return err
+	}
+
+	// The k3s agent restarts after the pod has completed, the unit is reloaded by then
+	script := fmt.Sprintf(`set -e
+unit=/etc/systemd/system/k3s-agent.service
+sed -i -e 's/=%[1]s\b/=%[2]s/g' -e 's/kloudlite.io\/owner=[^ '"'"'"]*/kloudlite.io\/owner=%[3]s/' "$unit"
+systemctl daemon-reload
+systemd-run --on-active=5 systemctl restart k3s-agent
+`, sedEscapePattern(claim.From), sedEscapeReplacement(obj.Name), sedEscapeReplacement(fn.LabelValueEncoder(obj.Spec.OwnedBy)))
+
+	pod = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            renameNodePodName,
+			Namespace:       obj.Spec.TargetNamespace,
+			Labels:          map[string]string{"kloudlite.io/workmachine": obj.Name},
+			OwnerReferences: []metav1.OwnerReference{fn.AsOwner(obj, true)},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      claim.From,
+			RestartPolicy: corev1.RestartPolicyNever,
+			HostPID:       true,
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "kloudlite.io/workmachine",
+					Operator: corev1.TolerationOpExists,
+					Effect:   corev1.TaintEffectNoSchedule,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:    "rename-node",
+					Image:   r.env.HostManagerImage,
+					Command: []string{"nsenter", "-t", "1", "-m", "-u", "-i", "-n", "-p", "--", "sh", "-c", script},
+					SecurityContext: &corev1.SecurityContext{
+						Privileged: fn.Ptr(true),
+					},
+				},
+			},
+		},
+	}
+	if err := r.Create(ctx, pod); err != nil && !apiErrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create rename pod: %w", err)
+	}
+	return nil
+}
+
+// sedEscapePattern escapes s to be matched literally in a basic regular expression of sed, delimited by /
+func sedEscapePattern(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`\/.*[]^$`, c) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// sedEscapeReplacement escapes s to be inserted literally by a replacement of sed, delimited by /
+func sedEscapeReplacement(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`\/&`, c) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,224 @@
+package workmachine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllerconfig"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/kloudlite/kloudlite/api/internal/pkg/statusutil"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// defaultWarmPoolVolumeSize is the storage volume size of warm machines in GB when the pool does not set one
+const defaultWarmPoolVolumeSize int32 = 100
+
+// WarmPoolReconciler keeps the warm pool of every MachineType at its configured size
+type WarmPoolReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Logger *zap.Logger
+	Cfg    *controllerconfig.ControllerConfig
+}
+
+// warmPoolState is the warm machines of a pool, sorted by how close they are to being claimable
+type warmPoolState struct {
+	ready        []v1.WorkMachine
+	provisioning []v1.WorkMachine
+}
+
+// Reconcile creates, settles and deletes the warm machines of a MachineType, and reports the pool in status
+func (r *WarmPoolReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := r.Logger.With(zap.String("machineType", req.Name))
+
+	machineType := &v1.MachineType{}
+	if err := r.Get(ctx, req.NamespacedName, machineType); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	var list v1.WorkMachineList
+	if err := r.List(ctx, &list, client.MatchingLabels{v1.LabelWarmPool: machineType.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list warm machines: %w", err)
+	}
+
+	pool := machineType.Spec.WarmPool
+	size := int32(0)
+	if pool != nil && machineType.Spec.Active && machineType.DeletionTimestamp == nil {
+		size = pool.Size
+	}
+
+	state := splitWarmPool(pool, list.Items)
+
+	// Stop the warm machines of a stopped pool once they have been provisioned
+	for i := range state.provisioning {
+		wm := &state.provisioning[i]
+		if wm.Status.State != v1.MachineStateRunning || wm.Spec.State == warmPoolMachineState(pool) {
+			continue
+		}
+		wm.Spec.State = warmPoolMachineState(pool)
+		if err := r.Update(ctx, wm); err != nil && !apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to stop warm machine %s: %w", wm.Name, err)
+		}
+	}
+
+	// Delete the extra warm machines, the least provisioned first
+	all := append(append([]v1.WorkMachine{}, state.ready...), state.provisioning...)
+	for i := len(all) - 1; i >= int(size); i-- {
+		if err := r.Delete(ctx, &all[i]); err != nil && !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to delete warm machine %s: %w", all[i].Name, err)
+		}
+		logger.Info("Deleted warm machine", zap.String("workMachine", all[i].Name))
+	}
+
+	// Replenish the pool
+	for i := len(all); i < int(size); i++ {
+		wm := newWarmMachine(machineType)
+		if err := r.Create(ctx, wm); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to create warm machine: %w", err)
+		}
+		logger.Info("Created warm machine", zap.String("workMachine", wm.Name))
+	}
+
+	status, err := r.poolStatus(ctx, machineType.Name, state, size)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := statusutil.UpdateStatusWithRetry(ctx, r.Client, machineType, func() error {
+		machineType.Status.WarmPool = status
+		return nil
+	}, logger); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if size == 0 {
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{RequeueAfter: r.Cfg.WorkMachine.WarmPoolReplenishInterval}, nil
+}
+
+// poolStatus counts the warm machines of the pool and the WorkMachines claimed from it
+func (r *WarmPoolReconciler) poolStatus(ctx context.Context, machineType string, state warmPoolState, size int32) (*v1.WarmPoolStatus, error) {
+	var list v1.WorkMachineList
+	if err := r.List(ctx, &list); err != nil {
+		return nil, fmt.Errorf("failed to list work machines: %w", err)
+	}
+
+	// Extra warm machines have been deleted and missing ones created
+	ready := min(int32(len(state.ready)), size)
+	status := &v1.WarmPoolStatus{Ready: ready, Provisioning: size - ready}
+
+	var lastReady *metav1.Time
+	for _, wm := range list.Items {
+		claim := wm.Status.WarmPoolClaim
+		if claim == nil || wm.Spec.MachineType != machineType {
+			continue
+		}
+		status.Claimed++
+		if claim.ReadyAt != nil && (lastReady == nil || claim.ReadyAt.After(lastReady.Time)) {
+			lastReady = claim.ReadyAt
+			status.LastClaimLatency = claim.Latency
+		}
+	}
+
+	if status.Ready == 0 && status.Provisioning == 0 && status.Claimed == 0 {
+		return nil, nil
+	}
+	return status, nil
+}
+
+// warmPoolMachineState is the state warm machines settle in
+func warmPoolMachineState(pool *v1.WarmPoolConfig) v1.MachineState {
+	if pool == nil || pool.State == "" {
+		return v1.MachineStateStopped
+	}
+	return pool.State
+}
+
+// splitWarmPool sorts the unclaimed warm machines into ready and provisioning ones, oldest first
+func splitWarmPool(pool *v1.WarmPoolConfig, warm []v1.WorkMachine) warmPoolState {
+	var state warmPoolState
+	desired := warmPoolMachineState(pool)
+
+	sort.SliceStable(warm, func(i, j int) bool {
+		return warm[i].CreationTimestamp.Before(&warm[j].CreationTimestamp)
+	})
+
+	for _, wm := range warm {
+		if wm.DeletionTimestamp != nil || wm.Labels[v1.LabelWarmPoolClaimedBy] != "" {
+			continue
+		}
+		if wm.Status.MachineID != "" && wm.Spec.State == desired && wm.Status.State == desired {
+			state.ready = append(state.ready, wm)
+			continue
+		}
+		state.provisioning = append(state.provisioning, wm)
+	}
+	return state
+}
+
+// newWarmMachine is a warm machine of the pool of machineType
+func newWarmMachine(machineType *v1.MachineType) *v1.WorkMachine {
+	pool := machineType.Spec.WarmPool
+	name := fmt.Sprintf("warm-%s-%s", machineType.Name, rand.String(5))
+
+	volumeSize := defaultWarmPoolVolumeSize
+	if pool.VolumeSize != nil {
+		volumeSize = *pool.VolumeSize
+	}
+
+	return &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{v1.LabelWarmPool: machineType.Name},
+		},
+		Spec: v1.WorkMachineSpec{
+			DisplayName:                 fmt.Sprintf("Warm %s machine", machineType.Name),
+			OwnedBy:                     "system",
+			TargetNamespace:             name,
+			State:                       v1.MachineStateRunning,
+			MachineType:                 machineType.Name,
+			Placement:                   pool.Placement,
+			VolumeSize:                  fn.Ptr(volumeSize),
+			DeleteVolumePostTermination: true,
+			Capacity:                    v1.CapacityOnDemand,
+		},
+	}
+}
+
+// findMachineTypeForWarmMachine maps a warm machine to the MachineType of its pool
+func (r *WarmPoolReconciler) findMachineTypeForWarmMachine(ctx context.Context, obj client.Object) []reconcile.Request {
+	machineType := obj.GetLabels()[v1.LabelWarmPool]
+	if machineType == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: machineType}}}
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *WarmPoolReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("warm-pool").
+		For(&v1.MachineType{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&v1.WorkMachine{},
+			handler.EnqueueRequestsFromMapFunc(r.findMachineTypeForWarmMachine),
+		).
+		Complete(r)
+}
@@ -0,0 +1,181 @@
+package workmachine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/internal/controllerconfig"
+	v1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func warmMachine(name string, state v1.MachineState, volumeSize int32, age time.Duration) v1.WorkMachine {
+	return v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Labels:            map[string]string{v1.LabelWarmPool: "m5-large"},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		},
+		Spec: v1.WorkMachineSpec{MachineType: "m5-large", State: state},
+		Status: v1.WorkMachineStatus{
+			MachineInfo: v1.MachineInfo{MachineID: "i-" + name, State: state, StorageVolumeSize: volumeSize},
+		},
+	}
+}
+
+// TestWarmMachineCandidates tests which warm machines a new WorkMachine may claim, and in which order
+func TestWarmMachineCandidates(t *testing.T) {
+	obj := &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "wm-alice"},
+		Spec:       v1.WorkMachineSpec{MachineType: "m5-large", VolumeSize: fn.Ptr[int32](100)},
+	}
+
+	claimed := warmMachine("claimed", v1.MachineStateRunning, 100, time.Hour)
+	claimed.Labels[v1.LabelWarmPoolClaimedBy] = "wm-bob"
+
+	provisioning := warmMachine("provisioning", v1.MachineStateStopped, 100, time.Hour)
+	provisioning.Status.State = v1.MachineStateStarting
+
+	otherPlacement := warmMachine("other-placement", v1.MachineStateStopped, 100, time.Hour)
+	otherPlacement.Spec.Placement = "eu-west-1"
+
+	warm := []v1.WorkMachine{
+		warmMachine("stopped-old", v1.MachineStateStopped, 100, 2*time.Hour),
+		warmMachine("small", v1.MachineStateRunning, 50, time.Hour),
+		warmMachine("running", v1.MachineStateRunning, 200, time.Minute),
+		warmMachine("stopped-new", v1.MachineStateStopped, 100, time.Minute),
+		claimed,
+		provisioning,
+		otherPlacement,
+	}
+
+	var names []string
+	for _, wm := range warmMachineCandidates(obj, warm) {
+		names = append(names, wm.Name)
+	}
+	assert.Equal(t, []string{"running", "stopped-old", "stopped-new"}, names)
+}
+
+// TestSplitWarmPool tests how warm machines are counted as ready or provisioning
+func TestSplitWarmPool(t *testing.T) {
+	pool := &v1.WarmPoolConfig{Size: 3, State: v1.MachineStateStopped}
+
+	starting := warmMachine("starting", v1.MachineStateRunning, 100, time.Minute)
+	starting.Status.State = v1.MachineStateStarting
+
+	claimed := warmMachine("claimed", v1.MachineStateStopped, 100, time.Hour)
+	claimed.Labels[v1.LabelWarmPoolClaimedBy] = "wm-bob"
+
+	state := splitWarmPool(pool, []v1.WorkMachine{
+		starting,
+		warmMachine("running", v1.MachineStateRunning, 100, 2*time.Minute),
+		warmMachine("stopped", v1.MachineStateStopped, 100, time.Hour),
+		claimed,
+	})
+
+	assert.Len(t, state.ready, 1)
+	assert.Equal(t, "stopped", state.ready[0].Name)
+	assert.Len(t, state.provisioning, 2)
+	assert.Equal(t, "running", state.provisioning[0].Name)
+	assert.Equal(t, "starting", state.provisioning[1].Name)
+}
+
+// TestClaimWarmMachine tests that the warm machine is only deleted once the claim is saved, and
+// that the storage volume of the claimed instance follows the WorkMachine
+func TestClaimWarmMachine(t *testing.T) {
+	ctx := context.Background()
+	cfg := &controllerconfig.ControllerConfig{WorkMachine: controllerconfig.WorkMachineConfig{WarmPoolClaimCheckInterval: time.Second}}
+
+	newWorkMachine := func() *v1.WorkMachine {
+		return &v1.WorkMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "wm-alice"},
+			Spec: v1.WorkMachineSpec{
+				MachineType: "m5-large",
+				VolumeSize:  fn.Ptr[int32](100),
+				State:       v1.MachineStateRunning,
+				OwnedBy:     "alice",
+			},
+		}
+	}
+	newWarmMachine := func() *v1.WorkMachine {
+		warm := warmMachine("warm-1", v1.MachineStateStopped, 100, time.Hour)
+		warm.Spec.DeleteVolumePostTermination = true
+		return &warm
+	}
+
+	t.Run("claim is saved before the warm machine is deleted", func(t *testing.T) {
+		check, obj, c := newTestCheck(t, newWorkMachine(), newWarmMachine())
+		provider := &fakeProvider{machineState: v1.MachineStateStopped}
+		r := &WorkMachineReconciler{Client: c, cloudProviderAPI: provider, env: Env{CloudProvider: v1.AWS}, Cfg: cfg}
+
+		r.createNewMachine(check, obj)
+		assert.Equal(t, "i-warm-1", obj.Status.MachineID)
+		require.NotNil(t, obj.Status.WarmPoolClaim)
+		assert.Equal(t, "warm-1", obj.Status.WarmPoolClaim.From)
+
+		// The claim is saved, the warm machine is kept until the next reconcile
+		saved := &v1.WorkMachine{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "wm-alice"}, saved))
+		assert.Equal(t, "i-warm-1", saved.Status.MachineID)
+
+		warm := &v1.WorkMachine{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "warm-1"}, warm))
+		assert.Equal(t, "wm-alice", warm.Labels[v1.LabelWarmPoolClaimedBy])
+
+		r.completeWarmClaim(check, obj)
+		err := c.Get(ctx, client.ObjectKey{Name: "warm-1"}, warm)
+		assert.True(t, apierrors.IsNotFound(err), "claimed warm machine should be deleted, got %v", err)
+		assert.Equal(t, []bool{false}, provider.volumeDeletions)
+		assert.Equal(t, []string{"SetVolumeDeletion", "GetMachineStatus", "StartMachine"}, provider.calls)
+	})
+
+	t.Run("claim whose status was not saved is resumed", func(t *testing.T) {
+		warm := newWarmMachine()
+		warm.Labels[v1.LabelWarmPoolClaimedBy] = "wm-alice"
+		other := warmMachine("warm-2", v1.MachineStateRunning, 100, 2*time.Hour)
+
+		check, obj, c := newTestCheck(t, newWorkMachine(), warm, &other)
+		r := &WorkMachineReconciler{Client: c, cloudProviderAPI: &fakeProvider{}, env: Env{CloudProvider: v1.AWS}, Cfg: cfg}
+
+		claimed, err := r.claimWarmMachine(check, obj)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		assert.Equal(t, "warm-1", obj.Status.WarmPoolClaim.From)
+
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(&other), &other))
+		assert.Empty(t, other.Labels[v1.LabelWarmPoolClaimedBy])
+	})
+}
+
+// TestEnsureRenameNodePodEscapesNames tests that names are matched and replaced literally by the rename script
+func TestEnsureRenameNodePodEscapesNames(t *testing.T) {
+	obj := &v1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "wm.alice"},
+		Spec:       v1.WorkMachineSpec{TargetNamespace: "wm-alice", OwnedBy: "alice"},
+		Status: v1.WorkMachineStatus{
+			WarmPoolClaim: &v1.WarmPoolClaimStatus{From: "warm.m5-large.1"},
+		},
+	}
+	check, obj, c := newTestCheck(t, obj)
+	r := &WorkMachineReconciler{Client: c}
+
+	require.NoError(t, r.ensureRenameNodePod(check, obj))
+
+	pod := &corev1.Pod{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: renameNodePodName, Namespace: "wm-alice"}, pod))
+	script := pod.Spec.Containers[0].Command[len(pod.Spec.Containers[0].Command)-1]
+	assert.True(t, strings.Contains(script, `s/=warm\.m5-large\.1\b/=wm.alice/g`), script)
+}
+
+func TestSedEscape(t *testing.T) {
+	assert.Equal(t, `a\.b\*\[c\]\/d\^\$\\`, sedEscapePattern(`a.b*[c]/d^$\`))
+	assert.Equal(t, `a.b\&c\/d\\`, sedEscapeReplacement(`a.b&c/d\`))
+}
@@ -0,0 +1,199 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	packagesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/packages/v1"
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workspace/devcontainer"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/kloudlite/kloudlite/api/internal/pkg/statusutil"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// shouldSyncDevcontainer reports whether devcontainer.json needs to be (re-)read for a workspace
+// It is read once after the git repository is cloned, and again whenever spec.devcontainer.syncRequest changes
+func shouldSyncDevcontainer(workspace *workspacev1.Workspace) bool {
+	spec := workspace.Spec.Devcontainer
+	if spec != nil && spec.Disabled {
+		return false
+	}
+	if spec == nil && len(workspaceGitRepositories(workspace)) == 0 {
+		return false
+	}
+
+	status := workspace.Status.Devcontainer
+	if status == nil {
+		return true
+	}
+	return spec != nil && spec.SyncRequest != status.ObservedSyncRequest
+}
+
+// devcontainerPath returns the validated devcontainer.json path relative to the workspace folder
+func devcontainerPath(workspace *workspacev1.Workspace) (string, error) {
+	p := devcontainer.DefaultPath
+	if workspace.Spec.Devcontainer != nil && workspace.Spec.Devcontainer.Path != "" {
+		p = workspace.Spec.Devcontainer.Path
+	}
+
+	cleaned := path.Clean(p)
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("devcontainer path %q must be relative to the workspace folder", p)
+	}
+	return cleaned, nil
+}
+
+// syncDevcontainer reads devcontainer.json from the running workspace pod and merges it into
+// the workspace spec and PackageRequest. The outcome is recorded in status.devcontainer.
+func (r *WorkspaceReconciler) syncDevcontainer(ctx context.Context, workspace *workspacev1.Workspace, pod *corev1.Pod, logger *zap.Logger) error {
+	if !shouldSyncDevcontainer(workspace) {
+		return nil
+	}
+
+	var syncRequest int64
+	if workspace.Spec.Devcontainer != nil {
+		syncRequest = workspace.Spec.Devcontainer.SyncRequest
+	}
+
+	relPath, err := devcontainerPath(workspace)
+	if err != nil {
+		return r.setDevcontainerStatus(ctx, workspace, &workspacev1.DevcontainerStatus{
+			Phase:               workspacev1.DevcontainerPhaseFailed,
+			Message:             err.Error(),
+			ObservedSyncRequest: syncRequest,
+		}, logger)
+	}
+
+	workspaceFolder := fmt.Sprintf("/home/kl/workspaces/%s", workspace.Name)
+	content, err := r.execInPod(ctx, pod, "workspace", []string{"cat", path.Join(workspaceFolder, relPath)})
+	if err != nil {
+		if strings.Contains(err.Error(), "No such file") {
+			logger.Info("No devcontainer.json found in workspace", zap.String("path", relPath))
+			return r.setDevcontainerStatus(ctx, workspace, &workspacev1.DevcontainerStatus{
+				Phase:               workspacev1.DevcontainerPhaseNotFound,
+				Message:             fmt.Sprintf("%s not found in workspace folder", relPath),
+				Path:                relPath,
+				ObservedSyncRequest: syncRequest,
+			}, logger)
+		}
+		return fmt.Errorf("failed to read devcontainer.json: %w", err)
+	}
+
+	cfg, err := devcontainer.Parse([]byte(content))
+	if err != nil {
+		return r.setDevcontainerStatus(ctx, workspace, &workspacev1.DevcontainerStatus{
+			Phase:               workspacev1.DevcontainerPhaseFailed,
+			Message:             err.Error(),
+			Path:                relPath,
+			ObservedSyncRequest: syncRequest,
+		}, logger)
+	}
+
+	plan := devcontainer.BuildPlan(cfg, workspaceFolder)
+	prev := workspace.Status.Devcontainer
+
+	var prevPackages []string
+	if prev != nil {
+		prevPackages = prev.Packages
+	}
+	if err := r.applyPackages(ctx, workspace, plan.Packages, prevPackages); err != nil {
+		return fmt.Errorf("failed to apply devcontainer packages: %w", err)
+	}
+
+	if devcontainer.ApplyToWorkspace(workspace, plan, prev) {
+		if err := r.Update(ctx, workspace); err != nil {
+			return fmt.Errorf("failed to apply devcontainer settings: %w", err)
+		}
+	}
+
+	status := devcontainer.Status(plan, relPath, devcontainer.Hash([]byte(content)))
+	status.ObservedSyncRequest = syncRequest
+	status.Message = fmt.Sprintf("Applied %d packages, %d ports, %d environment variables, %d extensions",
+		len(plan.Packages), len(plan.Ports), len(plan.EnvironmentVariables), len(plan.Extensions))
+	if len(plan.UnsupportedFeatures) > 0 {
+		status.Message += fmt.Sprintf(" (unsupported features: %s)", strings.Join(plan.UnsupportedFeatures, ", "))
+	}
+
+	logger.Info("Applied devcontainer.json",
+		zap.String("path", relPath),
+		zap.String("hash", status.ConfigHash),
+		zap.Strings("packages", status.Packages),
+		zap.Strings("unsupportedFeatures", plan.UnsupportedFeatures))
+
+	return r.setDevcontainerStatus(ctx, workspace, status, logger)
+}
+
+// applyPackages merges devcontainer or template packages into the workspace PackageRequest,
+// creating it with the same name and ownership `kl pkg` uses if it does not exist yet
+func (r *WorkspaceReconciler) applyPackages(ctx context.Context, workspace *workspacev1.Workspace, packages []packagesv1.PackageSpec, prevApplied []string) error {
+	if len(packages) == 0 && len(prevApplied) == 0 {
+		return nil
+	}
+
+	name := fmt.Sprintf("%s-packages", workspace.Name)
+	pkgReq := &packagesv1.PackageRequest{}
+	err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: workspace.Namespace}, pkgReq)
+	if apierrors.IsNotFound(err) {
+		pkgReq = &packagesv1.PackageRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: workspace.Namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: "workspaces.kloudlite.io/v1",
+						Kind:       "Workspace",
+						Name:       workspace.Name,
+						UID:        workspace.UID,
+					},
+				},
+			},
+			Spec: packagesv1.PackageRequestSpec{
+				WorkspaceRef: workspace.Name,
+				Packages:     packages,
+				ProfileName:  name,
+			},
+		}
+		return r.Create(ctx, pkgReq)
+	}
+	if err != nil {
+		return err
+	}
+
+	merged, changed := devcontainer.MergePackages(pkgReq.Spec.Packages, packages, prevApplied)
+	if !changed {
+		return nil
+	}
+
+	original := pkgReq.DeepCopy()
+	pkgReq.Spec.Packages = merged
+	return r.Patch(ctx, pkgReq, client.MergeFrom(original))
+}
+
+// setDevcontainerStatus records the sync outcome in status.devcontainer
+// When nothing was applied, the previously applied entries are carried over so that a
+// later successful sync can still remove them
+func (r *WorkspaceReconciler) setDevcontainerStatus(ctx context.Context, workspace *workspacev1.Workspace, status *workspacev1.DevcontainerStatus, logger *zap.Logger) error {
+	now := metav1.Now()
+	status.AppliedAt = &now
+
+	if prev := workspace.Status.Devcontainer; prev != nil && status.Phase != workspacev1.DevcontainerPhaseApplied {
+		status.ConfigHash = prev.ConfigHash
+		status.Packages = prev.Packages
+		status.Ports = prev.Ports
+		status.EnvironmentVariables = prev.EnvironmentVariables
+		status.Extensions = prev.Extensions
+	}
+
+	connectedEnvironment := workspace.Status.ConnectedEnvironment
+	return statusutil.UpdateStatusWithRetry(ctx, r.Client, workspace, func() error {
+		workspace.Status.ConnectedEnvironment = connectedEnvironment
+		workspace.Status.Devcontainer = status
+		return nil
+	}, logger)
+}
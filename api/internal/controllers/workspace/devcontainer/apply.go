@@ -0,0 +1,202 @@
+package devcontainer
+
+import (
+	"sort"
+
+	packagesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/packages/v1"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+)
+
+// ApplyToWorkspace merges a plan into the workspace spec
+// Entries recorded in prev (the previously applied devcontainer status) that are no longer
+// in the plan are removed; entries the user added by hand are left untouched.
+// Returns true if the spec was modified.
+func ApplyToWorkspace(ws *workspacev1.Workspace, plan *Plan, prev *workspacev1.DevcontainerStatus) bool {
+	var prevPorts []int32
+	var prevEnv, prevExt []string
+	if prev != nil {
+		prevPorts = prev.Ports
+		prevEnv = prev.EnvironmentVariables
+		prevExt = prev.Extensions
+	}
+
+	changed := false
+
+	// forwardPorts -> spec.expose
+	wantPorts := map[int32]bool{}
+	for _, p := range plan.Ports {
+		wantPorts[p] = true
+	}
+	stalePorts := map[int32]bool{}
+	for _, p := range prevPorts {
+		if !wantPorts[p] {
+			stalePorts[p] = true
+		}
+	}
+	expose := make([]workspacev1.ExposedPort, 0, len(ws.Spec.Expose)+len(plan.Ports))
+	havePort := map[int32]bool{}
+	for _, e := range ws.Spec.Expose {
+		if stalePorts[e.Port] {
+			changed = true
+			continue
+		}
+		havePort[e.Port] = true
+		expose = append(expose, e)
+	}
+	for _, p := range plan.Ports {
+		if !havePort[p] {
+			expose = append(expose, workspacev1.ExposedPort{Port: p})
+			changed = true
+		}
+	}
+	if changed {
+		ws.Spec.Expose = expose
+	}
+
+	settings := ws.Spec.Settings
+	if settings == nil {
+		settings = &workspacev1.WorkspaceSettings{}
+	}
+	settingsChanged := false
+
+	// containerEnv/remoteEnv -> settings.environmentVariables
+	for _, k := range prevEnv {
+		if _, ok := plan.EnvironmentVariables[k]; !ok {
+			if _, exists := settings.EnvironmentVariables[k]; exists {
+				delete(settings.EnvironmentVariables, k)
+				settingsChanged = true
+			}
+		}
+	}
+	for k, v := range plan.EnvironmentVariables {
+		if settings.EnvironmentVariables == nil {
+			settings.EnvironmentVariables = map[string]string{}
+		}
+		if cur, ok := settings.EnvironmentVariables[k]; !ok || cur != v {
+			settings.EnvironmentVariables[k] = v
+			settingsChanged = true
+		}
+	}
+
+	// customizations.vscode.extensions -> settings.vscodeExtensions
+	merged := mergeStrings(settings.VSCodeExtensions, plan.Extensions, prevExt)
+	if !equalStrings(merged, settings.VSCodeExtensions) {
+		settings.VSCodeExtensions = merged
+		settingsChanged = true
+	}
+
+	// Lifecycle hooks defined in devcontainer.json replace the workspace's, the others are left as set by the user
+	if plan.PostCreateCommand != nil && settings.PostCreateCommand != *plan.PostCreateCommand {
+		settings.PostCreateCommand = *plan.PostCreateCommand
+		settingsChanged = true
+	}
+	if plan.PostStartCommand != nil && settings.PostStartCommand != *plan.PostStartCommand {
+		settings.PostStartCommand = *plan.PostStartCommand
+		settingsChanged = true
+	}
+
+	if settingsChanged {
+		ws.Spec.Settings = settings
+		changed = true
+	}
+
+	return changed
+}
+
+// MergePackages merges plan packages into the current PackageRequest package list
+// Packages previously applied from devcontainer.json but no longer present are removed,
+// and packages the user installed with `kl pkg` are kept as-is.
+func MergePackages(current []packagesv1.PackageSpec, plan []packagesv1.PackageSpec, prevApplied []string) ([]packagesv1.PackageSpec, bool) {
+	want := map[string]bool{}
+	for _, p := range plan {
+		want[p.Name] = true
+	}
+	stale := map[string]bool{}
+	for _, name := range prevApplied {
+		if !want[name] {
+			stale[name] = true
+		}
+	}
+
+	changed := false
+	have := map[string]bool{}
+	result := make([]packagesv1.PackageSpec, 0, len(current)+len(plan))
+	for _, p := range current {
+		if stale[p.Name] {
+			changed = true
+			continue
+		}
+		have[p.Name] = true
+		result = append(result, p)
+	}
+	for _, p := range plan {
+		if !have[p.Name] {
+			result = append(result, p)
+			changed = true
+		}
+	}
+	return result, changed
+}
+
+// Status builds the status entry recording what a plan applied
+func Status(plan *Plan, path, hash string) *workspacev1.DevcontainerStatus {
+	st := &workspacev1.DevcontainerStatus{
+		Phase:               workspacev1.DevcontainerPhaseApplied,
+		Path:                path,
+		ConfigHash:          hash,
+		Ports:               plan.Ports,
+		Extensions:          plan.Extensions,
+		UnsupportedFeatures: plan.UnsupportedFeatures,
+	}
+	for _, p := range plan.Packages {
+		st.Packages = append(st.Packages, p.Name)
+	}
+	for k := range plan.EnvironmentVariables {
+		st.EnvironmentVariables = append(st.EnvironmentVariables, k)
+	}
+	sort.Strings(st.EnvironmentVariables)
+	return st
+}
+
+// mergeStrings adds want to current and drops entries from prev that are not in want
+func mergeStrings(current, want, prev []string) []string {
+	wantSet := map[string]bool{}
+	for _, w := range want {
+		wantSet[w] = true
+	}
+	stale := map[string]bool{}
+	for _, p := range prev {
+		if !wantSet[p] {
+			stale[p] = true
+		}
+	}
+
+	var result []string
+	have := map[string]bool{}
+	for _, c := range current {
+		if stale[c] || have[c] {
+			continue
+		}
+		have[c] = true
+		result = append(result, c)
+	}
+	for _, w := range want {
+		if !have[w] {
+			have[w] = true
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,316 @@
+package devcontainer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	packagesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/packages/v1"
+)
+
+// DefaultPath is where devcontainer.json is looked up, relative to the workspace folder
+const DefaultPath = ".devcontainer/devcontainer.json"
+
+// DefaultNixpkgsChannel is the channel used for packages derived from devcontainer features
+// Matches the default used by `kl pkg add` so both install paths share the same nixpkgs revision
+const DefaultNixpkgsChannel = "nixos-unstable"
+
+// Config is the subset of the devcontainer.json specification that Kloudlite understands
+// See https://containers.dev/implementors/json_reference/
+type Config struct {
+	Name string `json:"name,omitempty"`
+	// Features maps feature IDs to an options object, or to a version string as a shorthand
+	Features          map[string]interface{} `json:"features,omitempty"`
+	ForwardPorts      []interface{}          `json:"forwardPorts,omitempty"`
+	ContainerEnv      map[string]string      `json:"containerEnv,omitempty"`
+	RemoteEnv         map[string]*string     `json:"remoteEnv,omitempty"`
+	PostCreateCommand interface{}            `json:"postCreateCommand,omitempty"`
+	PostStartCommand  interface{}            `json:"postStartCommand,omitempty"`
+	Customizations    Customizations         `json:"customizations,omitempty"`
+}
+
+// Customizations holds tool-specific devcontainer settings
+type Customizations struct {
+	VSCode    VSCodeCustomizations    `json:"vscode,omitempty"`
+	Kloudlite KloudliteCustomizations `json:"kloudlite,omitempty"`
+}
+
+// VSCodeCustomizations holds the customizations.vscode block
+type VSCodeCustomizations struct {
+	Extensions []string `json:"extensions,omitempty"`
+}
+
+// KloudliteCustomizations holds the customizations.kloudlite block
+// Packages lists Nix packages (nixpkgs attribute names) to install in addition to those derived from features
+type KloudliteCustomizations struct {
+	Packages []string `json:"packages,omitempty"`
+}
+
+// Plan is the Kloudlite view of a devcontainer.json, ready to be merged into a Workspace
+type Plan struct {
+	Packages             []packagesv1.PackageSpec
+	Ports                []int32
+	EnvironmentVariables map[string]string
+	PostCreateCommand    *string // nil when devcontainer.json does not define it
+	PostStartCommand     *string // nil when devcontainer.json does not define it
+	Extensions           []string
+
+	// UnsupportedFeatures lists feature IDs that have no Nix package mapping
+	UnsupportedFeatures []string
+}
+
+// Parse decodes devcontainer.json content, which is JSON with comments and trailing commas (JSONC)
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(StripJSONC(data), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse devcontainer.json: %w", err)
+	}
+	return &cfg, nil
+}
+
+// Hash returns a short content hash used to detect devcontainer.json changes
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// BuildPlan converts a parsed devcontainer.json into the Workspace settings it maps to
+// workspaceFolder is the absolute workspace folder inside the container and is used to
+// expand ${containerWorkspaceFolder} references
+func BuildPlan(cfg *Config, workspaceFolder string) *Plan {
+	plan := &Plan{
+		EnvironmentVariables: map[string]string{},
+	}
+
+	seenPkg := map[string]bool{}
+	addPackage := func(name string) {
+		if name == "" || seenPkg[name] {
+			return
+		}
+		seenPkg[name] = true
+		plan.Packages = append(plan.Packages, packagesv1.PackageSpec{
+			Name:    name,
+			Channel: DefaultNixpkgsChannel,
+		})
+	}
+
+	// Iterate features in a stable order so the resulting PackageRequest does not churn
+	featureIDs := make([]string, 0, len(cfg.Features))
+	for id := range cfg.Features {
+		featureIDs = append(featureIDs, id)
+	}
+	sort.Strings(featureIDs)
+
+	for _, id := range featureIDs {
+		pkgs, ok := packagesForFeature(id, featureOptions(cfg.Features[id]))
+		if !ok {
+			plan.UnsupportedFeatures = append(plan.UnsupportedFeatures, id)
+			continue
+		}
+		for _, p := range pkgs {
+			addPackage(p)
+		}
+	}
+	for _, p := range cfg.Customizations.Kloudlite.Packages {
+		addPackage(strings.TrimSpace(p))
+	}
+
+	seenPort := map[int32]bool{}
+	for _, fp := range cfg.ForwardPorts {
+		port, ok := parseForwardPort(fp)
+		if !ok || seenPort[port] {
+			continue
+		}
+		seenPort[port] = true
+		plan.Ports = append(plan.Ports, port)
+	}
+
+	// containerEnv applies to the whole container, remoteEnv to tools/terminals and wins on conflict
+	for k, v := range cfg.ContainerEnv {
+		if val, ok := expandVariables(v, workspaceFolder); ok {
+			plan.EnvironmentVariables[k] = val
+		}
+	}
+	for k, v := range cfg.RemoteEnv {
+		if v == nil {
+			// A null remoteEnv value unsets the variable
+			delete(plan.EnvironmentVariables, k)
+			continue
+		}
+		if val, ok := expandVariables(*v, workspaceFolder); ok {
+			plan.EnvironmentVariables[k] = val
+		}
+	}
+
+	if cfg.PostCreateCommand != nil {
+		cmd := commandString(cfg.PostCreateCommand)
+		plan.PostCreateCommand = &cmd
+	}
+	if cfg.PostStartCommand != nil {
+		cmd := commandString(cfg.PostStartCommand)
+		plan.PostStartCommand = &cmd
+	}
+
+	seenExt := map[string]bool{}
+	for _, ext := range cfg.Customizations.VSCode.Extensions {
+		ext = strings.TrimSpace(ext)
+		// A leading "-" in devcontainer.json means "do not install"
+		if ext == "" || strings.HasPrefix(ext, "-") || seenExt[ext] {
+			continue
+		}
+		seenExt[ext] = true
+		plan.Extensions = append(plan.Extensions, ext)
+	}
+
+	return plan
+}
+
+// featureOptions normalizes the value of a features entry into its options object
+// A string value is the shorthand for {"version": value}
+func featureOptions(v interface{}) map[string]interface{} {
+	switch o := v.(type) {
+	case map[string]interface{}:
+		return o
+	case string:
+		return map[string]interface{}{"version": o}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// parseForwardPort accepts the numeric form of forwardPorts entries ("3000" or 3000)
+// The "host:port" form refers to other containers in a compose setup and is skipped
+func parseForwardPort(v interface{}) (int32, bool) {
+	var port int
+	switch p := v.(type) {
+	case float64:
+		port = int(p)
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return 0, false
+		}
+		port = n
+	default:
+		return 0, false
+	}
+	if port < 1 || port > 65535 {
+		return 0, false
+	}
+	return int32(port), true
+}
+
+// expandVariables resolves the devcontainer variables that have a meaning inside a workspace
+// Values referencing the developer's local machine (${localEnv:...}) cannot be resolved and are dropped
+func expandVariables(value, workspaceFolder string) (string, bool) {
+	if strings.Contains(value, "${localEnv:") || strings.Contains(value, "${localWorkspaceFolder") {
+		return "", false
+	}
+	value = strings.ReplaceAll(value, "${containerWorkspaceFolderBasename}", path.Base(workspaceFolder))
+	value = strings.ReplaceAll(value, "${containerWorkspaceFolder}", workspaceFolder)
+	return value, true
+}
+
+// commandString normalizes a lifecycle command, which may be a string, an argv array,
+// or an object of named commands, into a single shell command line
+func commandString(v interface{}) string {
+	switch c := v.(type) {
+	case string:
+		return strings.TrimSpace(c)
+	case []interface{}:
+		args := make([]string, 0, len(c))
+		for _, a := range c {
+			if s, ok := a.(string); ok {
+				args = append(args, shellQuote(s))
+			}
+		}
+		return strings.Join(args, " ")
+	case map[string]interface{}:
+		// Named commands run in parallel in the reference implementation; we run them
+		// sequentially in a stable order, which is sufficient for setup commands
+		names := make([]string, 0, len(c))
+		for name := range c {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		cmds := make([]string, 0, len(names))
+		for _, name := range names {
+			if s := commandString(c[name]); s != "" {
+				cmds = append(cmds, s)
+			}
+		}
+		return strings.Join(cmds, " && ")
+	default:
+		return ""
+	}
+}
+
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`&|;<>()*?[]{}~!#") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// StripJSONC removes // and /* */ comments and trailing commas so the content can be
+// decoded with encoding/json. String literals are left untouched.
+func StripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	escaped := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out = append(out, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == '}' || c == ']':
+			// Drop a trailing comma before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,164 @@
+package devcontainer
+
+import (
+	"reflect"
+	"testing"
+
+	packagesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/packages/v1"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+)
+
+const sampleConfig = `{
+	// Node + Python service
+	"name": "api",
+	"features": {
+		"ghcr.io/devcontainers/features/node:1": { "version": "20" },
+		"ghcr.io/devcontainers/features/github-cli:1": {},
+		"ghcr.io/devcontainers/features/python:1": "3.12",
+		"ghcr.io/example/features/unknown-tool:2": {},
+	},
+	/* ports */
+	"forwardPorts": [3000, "8080", "db:5432", 3000],
+	"containerEnv": { "APP_ENV": "dev", "SRC": "${containerWorkspaceFolder}/src" },
+	"remoteEnv": { "APP_ENV": "local", "TOKEN": "${localEnv:TOKEN}", "UNSET": null },
+	"postCreateCommand": ["npm", "install", "--prefix", "my dir"],
+	"postStartCommand": { "b": "echo b", "a": "echo a" },
+	"customizations": {
+		"vscode": { "extensions": ["dbaeumer.vscode-eslint", "-ms-python.python"] },
+		"kloudlite": { "packages": ["postgresql"] }
+	},
+}`
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"line comment", "{\"a\": 1 // c\n}", "{\"a\": 1 \n}"},
+		{"block comment", `{/* x */"a": 1}`, `{"a": 1}`},
+		{"trailing comma", `{"a": [1, 2, ], }`, `{"a": [1, 2 ] }`},
+		{"comment markers in string", `{"url": "http://x/*y*/"}`, `{"url": "http://x/*y*/"}`},
+		{"escaped quote", `{"a": "\"//"}`, `{"a": "\"//"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(StripJSONC([]byte(tt.in))); got != tt.want {
+				t.Errorf("StripJSONC() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPlan(t *testing.T) {
+	cfg, err := Parse([]byte(sampleConfig))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	plan := BuildPlan(cfg, "/home/kl/workspaces/api")
+
+	var pkgs []string
+	for _, p := range plan.Packages {
+		pkgs = append(pkgs, p.Name)
+		if p.Channel != DefaultNixpkgsChannel {
+			t.Errorf("package %s channel = %q, want %q", p.Name, p.Channel, DefaultNixpkgsChannel)
+		}
+	}
+	if want := []string{"gh", "nodejs_20", "python312", "postgresql"}; !reflect.DeepEqual(pkgs, want) {
+		t.Errorf("packages = %v, want %v", pkgs, want)
+	}
+	if want := []string{"ghcr.io/example/features/unknown-tool:2"}; !reflect.DeepEqual(plan.UnsupportedFeatures, want) {
+		t.Errorf("unsupported = %v, want %v", plan.UnsupportedFeatures, want)
+	}
+	if want := []int32{3000, 8080}; !reflect.DeepEqual(plan.Ports, want) {
+		t.Errorf("ports = %v, want %v", plan.Ports, want)
+	}
+
+	wantEnv := map[string]string{"APP_ENV": "local", "SRC": "/home/kl/workspaces/api/src"}
+	if !reflect.DeepEqual(plan.EnvironmentVariables, wantEnv) {
+		t.Errorf("env = %v, want %v", plan.EnvironmentVariables, wantEnv)
+	}
+
+	if want := "npm install --prefix 'my dir'"; plan.PostCreateCommand == nil || *plan.PostCreateCommand != want {
+		t.Errorf("postCreateCommand = %v, want %q", plan.PostCreateCommand, want)
+	}
+	if want := "echo a && echo b"; plan.PostStartCommand == nil || *plan.PostStartCommand != want {
+		t.Errorf("postStartCommand = %v, want %q", plan.PostStartCommand, want)
+	}
+	if want := []string{"dbaeumer.vscode-eslint"}; !reflect.DeepEqual(plan.Extensions, want) {
+		t.Errorf("extensions = %v, want %v", plan.Extensions, want)
+	}
+}
+
+func TestApplyToWorkspaceRemovesStaleEntries(t *testing.T) {
+	ws := &workspacev1.Workspace{
+		Spec: workspacev1.WorkspaceSpec{
+			Expose: []workspacev1.ExposedPort{{Port: 9000}, {Port: 3000}, {Port: 4000}},
+			Settings: &workspacev1.WorkspaceSettings{
+				EnvironmentVariables: map[string]string{"USER_VAR": "1", "OLD": "x"},
+				VSCodeExtensions:     []string{"user.ext", "old.ext"},
+				PostCreateCommand:    "make setup",
+			},
+		},
+	}
+	prev := &workspacev1.DevcontainerStatus{
+		Ports:                []int32{3000, 4000},
+		EnvironmentVariables: []string{"OLD"},
+		Extensions:           []string{"old.ext"},
+	}
+	postStart := "make dev"
+	plan := &Plan{
+		Ports:                []int32{3000, 5000},
+		EnvironmentVariables: map[string]string{"NEW": "y"},
+		Extensions:           []string{"new.ext"},
+		PostStartCommand:     &postStart,
+	}
+
+	if !ApplyToWorkspace(ws, plan, prev) {
+		t.Fatal("ApplyToWorkspace() reported no change")
+	}
+
+	wantPorts := []workspacev1.ExposedPort{{Port: 9000}, {Port: 3000}, {Port: 5000}}
+	if !reflect.DeepEqual(ws.Spec.Expose, wantPorts) {
+		t.Errorf("expose = %v, want %v", ws.Spec.Expose, wantPorts)
+	}
+	wantEnv := map[string]string{"USER_VAR": "1", "NEW": "y"}
+	if !reflect.DeepEqual(ws.Spec.Settings.EnvironmentVariables, wantEnv) {
+		t.Errorf("env = %v, want %v", ws.Spec.Settings.EnvironmentVariables, wantEnv)
+	}
+	if want := []string{"user.ext", "new.ext"}; !reflect.DeepEqual(ws.Spec.Settings.VSCodeExtensions, want) {
+		t.Errorf("extensions = %v, want %v", ws.Spec.Settings.VSCodeExtensions, want)
+	}
+	if ws.Spec.Settings.PostStartCommand != "make dev" {
+		t.Errorf("postStartCommand = %q", ws.Spec.Settings.PostStartCommand)
+	}
+	// Not defined in devcontainer.json, the user's command is kept
+	if ws.Spec.Settings.PostCreateCommand != "make setup" {
+		t.Errorf("postCreateCommand = %q, want the user's", ws.Spec.Settings.PostCreateCommand)
+	}
+
+	// Applying the same plan again is a no-op
+	prev = Status(plan, DefaultPath, "hash")
+	if ApplyToWorkspace(ws, plan, prev) {
+		t.Error("second ApplyToWorkspace() reported a change")
+	}
+}
+
+func TestMergePackages(t *testing.T) {
+	current := []packagesv1.PackageSpec{{Name: "vim"}, {Name: "go"}, {Name: "nodejs_18"}}
+	plan := []packagesv1.PackageSpec{{Name: "nodejs_20"}, {Name: "go"}}
+
+	got, changed := MergePackages(current, plan, []string{"nodejs_18", "go"})
+	if !changed {
+		t.Fatal("MergePackages() reported no change")
+	}
+	want := []packagesv1.PackageSpec{{Name: "vim"}, {Name: "go"}, {Name: "nodejs_20"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergePackages() = %v, want %v", got, want)
+	}
+
+	if _, changed := MergePackages(got, plan, []string{"nodejs_20", "go"}); changed {
+		t.Error("MergePackages() reported a change for an already merged list")
+	}
+}
@@ -0,0 +1,101 @@
+package devcontainer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// featurePackages maps devcontainer feature names (the last path segment of the
+// feature ID, without the version tag) to the Nix packages that provide the same tools
+var featurePackages = map[string][]string{
+	"common-utils":             {"curl", "wget", "jq", "unzip", "zip"},
+	"git":                      {"git"},
+	"git-lfs":                  {"git-lfs"},
+	"github-cli":               {"gh"},
+	"node":                     {"nodejs"},
+	"python":                   {"python3"},
+	"go":                       {"go"},
+	"rust":                     {"rustc", "cargo", "rustfmt", "clippy"},
+	"java":                     {"jdk"},
+	"ruby":                     {"ruby"},
+	"php":                      {"php"},
+	"dotnet":                   {"dotnet-sdk"},
+	"terraform":                {"terraform"},
+	"aws-cli":                  {"awscli2"},
+	"azure-cli":                {"azure-cli"},
+	"gcloud":                   {"google-cloud-sdk"},
+	"kubectl-helm-minikube":    {"kubectl", "kubernetes-helm"},
+	"docker-outside-of-docker": {"docker-client"},
+	"docker-in-docker":         {"docker-client"},
+	"deno":                     {"deno"},
+	"bun":                      {"bun"},
+	"hugo":                     {"hugo"},
+	"powershell":               {"powershell"},
+	"sshd":                     {},
+	"desktop-lite":             {},
+}
+
+// versionedPackages builds a version-specific nixpkgs attribute for features whose
+// "version" option selects a major release (e.g. node 20 -> nodejs_20)
+var versionedPackages = map[string]func(version string) string{
+	"node": func(v string) string {
+		return fmt.Sprintf("nodejs_%s", majorVersion(v))
+	},
+	"python": func(v string) string {
+		parts := strings.SplitN(v, ".", 3)
+		if len(parts) < 2 {
+			return ""
+		}
+		return fmt.Sprintf("python%s%s", parts[0], parts[1])
+	},
+	"java": func(v string) string {
+		return fmt.Sprintf("jdk%s", majorVersion(v))
+	},
+}
+
+// packagesForFeature returns the Nix packages for a devcontainer feature ID such as
+// "ghcr.io/devcontainers/features/node:1". The boolean is false when the feature is unknown.
+func packagesForFeature(id string, options map[string]interface{}) ([]string, bool) {
+	name := featureName(id)
+	pkgs, ok := featurePackages[name]
+	if !ok {
+		return nil, false
+	}
+
+	version, _ := options["version"].(string)
+	if build, ok := versionedPackages[name]; ok && isPinnedVersion(version) {
+		if attr := build(version); attr != "" {
+			// The versioned attribute replaces the default package for the runtime
+			return append([]string{attr}, pkgs[1:]...), true
+		}
+	}
+
+	return pkgs, true
+}
+
+// featureName extracts "node" from "ghcr.io/devcontainers/features/node:1"
+func featureName(id string) string {
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		id = id[i+1:]
+	}
+	if i := strings.IndexAny(id, ":@"); i >= 0 {
+		id = id[:i]
+	}
+	return strings.ToLower(id)
+}
+
+// isPinnedVersion reports whether a feature version option names a concrete release
+func isPinnedVersion(v string) bool {
+	switch strings.ToLower(v) {
+	case "", "latest", "lts", "none", "os-provided", "system":
+		return false
+	}
+	return v[0] >= '0' && v[0] <= '9'
+}
+
+func majorVersion(v string) string {
+	if i := strings.Index(v, "."); i >= 0 {
+		return v[:i]
+	}
+	return v
+}
@@ -0,0 +1,362 @@
+package workspace
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// gitStateDir holds per-repository clone logs and copied credentials inside the kl home
+	gitStateDir = "/home/kl/.kloudlite/git"
+
+	// gitCredentialsMountDir is where credential Secrets are mounted in the git-clone init container
+	gitCredentialsMountDir = "/var/run/kloudlite/git-credentials"
+
+	// defaultGitSSHCommand uses the WorkMachine SSH key mounted from the host
+	defaultGitSSHCommand = "ssh -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -i /root/.ssh/ssh_host_rsa_key"
+)
+
+var unsafeRepoKeyChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// workspaceGitRepositories returns all repositories to clone into the workspace,
+// starting with spec.gitRepository (cloned into the workspace folder unless a path is set)
+func workspaceGitRepositories(workspace *workspacev1.Workspace) []workspacev1.GitRepository {
+	var repos []workspacev1.GitRepository
+	if workspace.Spec.GitRepository != nil && workspace.Spec.GitRepository.URL != "" {
+		repos = append(repos, *workspace.Spec.GitRepository)
+	}
+	for _, repo := range workspace.Spec.GitRepositories {
+		if repo.URL != "" {
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+// gitRepoKey returns a filesystem-safe identifier for a repository: its path, readable, and a
+// hash of its path and URL, so that paths differing only in unsafe characters do not collide
+func gitRepoKey(repo workspacev1.GitRepository) string {
+	p := strings.Trim(path.Clean("/"+repo.Path), "/")
+	sum := sha256.Sum256([]byte(p + "\x00" + repo.URL))
+	name := "root"
+	if p != "" {
+		name = unsafeRepoKeyChars.ReplaceAllString(p, "_")
+	}
+	return name + "-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// gitRepoDepth returns the nesting depth of a repository path, -1 for the workspace folder itself
+func gitRepoDepth(repo workspacev1.GitRepository) int {
+	p := strings.Trim(path.Clean("/"+repo.Path), "/")
+	if p == "" {
+		return -1
+	}
+	return strings.Count(p, "/")
+}
+
+// gitRepoDir returns the absolute clone directory of a repository inside the workspace pod
+func gitRepoDir(workspace *workspacev1.Workspace, repo workspacev1.GitRepository) string {
+	dir := fmt.Sprintf("/home/kl/workspaces/%s", workspace.Name)
+	if p := strings.Trim(path.Clean("/"+repo.Path), "/"); p != "" {
+		dir = path.Join(dir, p)
+	}
+	return dir
+}
+
+func isSSHGitURL(url string) bool {
+	return strings.HasPrefix(url, "git@") || strings.HasPrefix(url, "ssh://")
+}
+
+// gitCredentialSecrets returns the distinct credential Secret names referenced by the repositories
+func gitCredentialSecrets(repos []workspacev1.GitRepository) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, repo := range repos {
+		if repo.CredentialsSecretRef == nil || repo.CredentialsSecretRef.Name == "" {
+			continue
+		}
+		if !seen[repo.CredentialsSecretRef.Name] {
+			seen[repo.CredentialsSecretRef.Name] = true
+			names = append(names, repo.CredentialsSecretRef.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func gitCredentialVolumeName(secretName string) string {
+	return "git-credentials-" + secretName
+}
+
+// gitCredentialVolumes returns the Secret volumes and init container mounts for repository credentials
+func gitCredentialVolumes(repos []workspacev1.GitRepository) ([]corev1.Volume, []corev1.VolumeMount) {
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	for _, name := range gitCredentialSecrets(repos) {
+		volumes = append(volumes, corev1.Volume{
+			Name: gitCredentialVolumeName(name),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  name,
+					DefaultMode: fn.Ptr(int32(0400)),
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      gitCredentialVolumeName(name),
+			MountPath: path.Join(gitCredentialsMountDir, name),
+			ReadOnly:  true,
+		})
+	}
+	return volumes, mounts
+}
+
+// buildGitCloneScript builds the git-clone init container script
+// Each repository is cloned independently so one failing clone does not block the others;
+// its output is written to a per-repository log that the controller surfaces in status.
+// Credentials are copied into the kl home with kl ownership so that later fetches from the
+// workspace (by the user or by the controller on branch change) reuse them via repo-local config.
+// Only the directories the script creates are handed to kl, existing files keep their owner.
+func buildGitCloneScript(workspace *workspacev1.Workspace) string {
+	repos := workspaceGitRepositories(workspace)
+
+	// Clone parents before repositories nested in their folders
+	sort.SliceStable(repos, func(i, j int) bool {
+		return gitRepoDepth(repos[i]) < gitRepoDepth(repos[j])
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "mkdir -p %s/credentials\n", gitStateDir)
+
+	for _, name := range gitCredentialSecrets(repos) {
+		src := path.Join(gitCredentialsMountDir, name)
+		dst := path.Join(gitStateDir, "credentials", name)
+		fmt.Fprintf(&b, "mkdir -p %s && cp -L %s/* %s/ 2>/dev/null; chmod 700 %s; chmod 600 %s/*\n",
+			shellQuote(dst), shellQuote(src), shellQuote(dst), shellQuote(dst), shellQuote(dst))
+	}
+
+	for _, repo := range repos {
+		dir := gitRepoDir(workspace, repo)
+		logFile := path.Join(gitStateDir, gitRepoKey(repo)+".log")
+
+		// Options applied to the clone and persisted in the repository config
+		var config []string
+		args := []string{}
+		if repo.CredentialsSecretRef != nil && repo.CredentialsSecretRef.Name != "" {
+			credDir := path.Join(gitStateDir, "credentials", repo.CredentialsSecretRef.Name)
+			if isSSHGitURL(repo.URL) {
+				config = append(config, "core.sshCommand=ssh -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -o IdentitiesOnly=yes -i "+path.Join(credDir, "ssh-privatekey"))
+			} else {
+				helper := fmt.Sprintf(`!f() { echo "username=$(cat %[1]s/username 2>/dev/null || echo x-access-token)"; echo "password=$(cat %[1]s/token)"; }; f`, credDir)
+				config = append(config, "credential.helper="+helper)
+			}
+		} else if isSSHGitURL(repo.URL) {
+			// The host key is only mounted in the init container, so it is not persisted
+			args = append(args, "GIT_SSH_COMMAND="+shellQuote(defaultGitSSHCommand))
+		}
+
+		args = append(args, "git")
+		for _, c := range config {
+			args = append(args, "-c", shellQuote(c))
+		}
+		args = append(args, "clone")
+		if repo.Branch != "" {
+			args = append(args, "--branch", shellQuote(repo.Branch))
+		}
+		if repo.Depth > 0 {
+			args = append(args, fmt.Sprintf("--depth=%d", repo.Depth), "--no-single-branch")
+		}
+		if repo.Filter != "" {
+			args = append(args, "--filter="+shellQuote(repo.Filter))
+		}
+		if len(repo.SparseCheckout) > 0 {
+			args = append(args, "--sparse")
+		}
+		args = append(args, "--", shellQuote(repo.URL), shellQuote(dir))
+
+		var post []string
+		if len(repo.SparseCheckout) > 0 {
+			paths := make([]string, len(repo.SparseCheckout))
+			for i, p := range repo.SparseCheckout {
+				paths[i] = shellQuote(p)
+			}
+			post = append(post, fmt.Sprintf("git -C %s sparse-checkout set --cone -- %s", shellQuote(dir), strings.Join(paths, " ")))
+		}
+		for _, c := range config {
+			kv := strings.SplitN(c, "=", 2)
+			post = append(post, fmt.Sprintf("git -C %s config %s %s", shellQuote(dir), kv[0], shellQuote(kv[1])))
+		}
+
+		script := fmt.Sprintf("if %s > %s 2>&1; then\n", strings.Join(args, " "), shellQuote(logFile))
+		for _, cmd := range post {
+			script += fmt.Sprintf("    %s >> %s 2>&1\n", cmd, shellQuote(logFile))
+		}
+
+		fmt.Fprintf(&b, `
+if [ -d %[1]s/.git ]; then
+  echo "Repository already cloned" > %[2]s
+elif [ -n "$(ls -A %[1]s 2>/dev/null)" ]; then
+  echo "Target folder is not empty, skipping git clone" > %[2]s
+else
+%[6]s  %[3]s    echo %[4]s
+  else
+    echo %[5]s
+  fi
+  chown -R 1001:1001 %[1]s
+fi
+`, shellQuote(dir), shellQuote(logFile), script,
+			shellQuote("Cloned "+repo.URL+" into "+dir),
+			shellQuote("Failed to clone "+repo.URL+", see "+logFile),
+			mkdirOwned(workspace, dir))
+	}
+
+	fmt.Fprintf(&b, "chown -R 1001:1001 %s\n", gitStateDir)
+	return b.String()
+}
+
+// mkdirOwned returns the script creating dir and its missing parents inside the workspace folder,
+// owned by kl; the directories that exist already are left alone
+func mkdirOwned(workspace *workspacev1.Workspace, dir string) string {
+	base := fmt.Sprintf("/home/kl/workspaces/%s", workspace.Name)
+	dirs := []string{dir}
+	for d := path.Dir(dir); strings.HasPrefix(d, base); d = path.Dir(d) {
+		dirs = append(dirs, d)
+	}
+
+	var b strings.Builder
+	for i := len(dirs) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "  [ -d %[1]s ] || { mkdir -p %[1]s && chown 1001:1001 %[1]s; }\n", shellQuote(dirs[i]))
+	}
+	return b.String()
+}
+
+// shellQuote quotes a string for safe use as a single sh argument
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// syncGitRepositories refreshes status.gitRepositories from the running pod and switches
+// already-cloned repositories to a new branch when spec.branch changes
+func (r *WorkspaceReconciler) syncGitRepositories(ctx context.Context, workspace *workspacev1.Workspace, pod *corev1.Pod, logger *zap.Logger) error {
+	repos := workspaceGitRepositories(workspace)
+	if len(repos) == 0 && len(workspace.Status.GitRepositories) == 0 {
+		return nil
+	}
+
+	previous := map[string]workspacev1.GitRepositoryStatus{}
+	for _, st := range workspace.Status.GitRepositories {
+		previous[st.Path] = st
+	}
+
+	statuses := make([]workspacev1.GitRepositoryStatus, 0, len(repos))
+	changed := len(workspace.Status.GitRepositories) != len(repos)
+
+	for _, repo := range repos {
+		prev, ok := previous[repo.Path]
+		upToDate := ok && prev.URL == repo.URL && prev.Phase == workspacev1.GitRepositoryPhaseCloned &&
+			(repo.Branch == "" || prev.Branch == repo.Branch)
+		if upToDate {
+			statuses = append(statuses, prev)
+			continue
+		}
+
+		st := r.observeGitRepository(ctx, workspace, pod, repo, logger)
+		if !ok || st.Phase != prev.Phase || st.Branch != prev.Branch || st.Commit != prev.Commit || st.Message != prev.Message {
+			changed = true
+		}
+		statuses = append(statuses, st)
+	}
+
+	if !changed {
+		return nil
+	}
+
+	workspace.Status.GitRepositories = statuses
+	return r.updateStatus(ctx, workspace, logger)
+}
+
+// observeGitRepository inspects a repository in the workspace pod and switches branches if needed
+func (r *WorkspaceReconciler) observeGitRepository(ctx context.Context, workspace *workspacev1.Workspace, pod *corev1.Pod, repo workspacev1.GitRepository, logger *zap.Logger) workspacev1.GitRepositoryStatus {
+	now := metav1.Now()
+	st := workspacev1.GitRepositoryStatus{
+		URL:          repo.URL,
+		Path:         repo.Path,
+		LastSyncTime: &now,
+	}
+	dir := gitRepoDir(workspace, repo)
+
+	branch, err := r.gitInPod(ctx, pod, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		// Not cloned: either the clone failed or the repository was added after the pod started
+		logFile := path.Join(gitStateDir, gitRepoKey(repo)+".log")
+		if out, logErr := r.execInPod(ctx, pod, "workspace", []string{"cat", logFile}); logErr == nil && strings.Contains(out, "fatal:") {
+			st.Phase = workspacev1.GitRepositoryPhaseFailed
+			st.Message = lastLine(out)
+			return st
+		}
+		st.Phase = workspacev1.GitRepositoryPhasePending
+		st.Message = "Repository will be cloned when the workspace is restarted"
+		return st
+	}
+	st.Branch = strings.TrimSpace(branch)
+
+	if repo.Branch != "" && st.Branch != repo.Branch {
+		logger.Info("Switching repository branch",
+			zap.String("path", dir),
+			zap.String("from", st.Branch),
+			zap.String("to", repo.Branch))
+
+		refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", repo.Branch, repo.Branch)
+		if _, err := r.gitInPod(ctx, pod, dir, "fetch", "origin", refspec); err != nil {
+			st.Phase = workspacev1.GitRepositoryPhaseFailed
+			st.Message = fmt.Sprintf("failed to fetch branch %s: %v", repo.Branch, err)
+			return st
+		}
+		if _, err := r.gitInPod(ctx, pod, dir, "switch", repo.Branch); err != nil {
+			st.Phase = workspacev1.GitRepositoryPhaseFailed
+			st.Message = fmt.Sprintf("failed to switch to branch %s: %v", repo.Branch, err)
+			return st
+		}
+		st.Branch = repo.Branch
+	}
+
+	commit, err := r.gitInPod(ctx, pod, dir, "rev-parse", "HEAD")
+	if err != nil {
+		st.Phase = workspacev1.GitRepositoryPhaseFailed
+		st.Message = fmt.Sprintf("failed to read HEAD: %v", err)
+		return st
+	}
+	st.Commit = strings.TrimSpace(commit)
+	st.Phase = workspacev1.GitRepositoryPhaseCloned
+	return st
+}
+
+// gitExecPrefix runs git as the kl user owning the repositories, with hooks and fsmonitor disabled,
+// so that nothing planted in a repository runs from an exec of the controller
+var gitExecPrefix = []string{
+	"setpriv", "--reuid=1001", "--regid=1001", "--clear-groups", "env", "HOME=/home/kl",
+	"git", "-c", "core.hooksPath=/dev/null", "-c", "core.fsmonitor=false",
+}
+
+// gitInPod runs git against a repository in the workspace container
+func (r *WorkspaceReconciler) gitInPod(ctx context.Context, pod *corev1.Pod, dir string, args ...string) (string, error) {
+	command := append(append(append([]string{}, gitExecPrefix...), "-C", dir), args...)
+	return r.execInPod(ctx, pod, "workspace", command)
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,69 @@
+package workspace
+
+import (
+	"strings"
+	"testing"
+
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildGitCloneScript(t *testing.T) {
+	workspace := &workspacev1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws"},
+		Spec: workspacev1.WorkspaceSpec{
+			GitRepositories: []workspacev1.GitRepository{
+				{
+					URL:                  "https://github.com/org/svc.git",
+					Path:                 "services/svc",
+					Depth:                1,
+					CredentialsSecretRef: &corev1.LocalObjectReference{Name: "gh-token"},
+				},
+				{
+					URL:            "git@github.com:org/mono.git",
+					Branch:         "main",
+					Filter:         "blob:none",
+					SparseCheckout: []string{"apps/web"},
+				},
+			},
+		},
+	}
+
+	script := buildGitCloneScript(workspace)
+
+	// The repository cloned into the workspace folder comes before nested ones
+	root := strings.Index(script, "'git@github.com:org/mono.git' '/home/kl/workspaces/ws'")
+	nested := strings.Index(script, "'https://github.com/org/svc.git' '/home/kl/workspaces/ws/services/svc'")
+	assert.True(t, root >= 0 && nested > root, "expected root repository to be cloned first:\n%s", script)
+
+	assert.Contains(t, script, "--branch 'main' --filter='blob:none' --sparse")
+	assert.Contains(t, script, "sparse-checkout set --cone -- 'apps/web'")
+	assert.Contains(t, script, "GIT_SSH_COMMAND='"+defaultGitSSHCommand+"' git clone")
+	assert.Contains(t, script, "--depth=1 --no-single-branch")
+	assert.Contains(t, script, "config credential.helper")
+	assert.Contains(t, script, "/home/kl/.kloudlite/git/credentials/gh-token/token")
+
+	// Only the directories created for the clone are handed to kl, not the whole workspace
+	assert.Contains(t, script, "[ -d '/home/kl/workspaces/ws/services' ] || { mkdir -p '/home/kl/workspaces/ws/services' && chown 1001:1001 '/home/kl/workspaces/ws/services'; }")
+	assert.Contains(t, script, "chown -R 1001:1001 '/home/kl/workspaces/ws/services/svc'")
+	assert.NotContains(t, script, "chown -R 1001:1001 /home/kl/workspaces/ws\n")
+
+	volumes, mounts := gitCredentialVolumes(workspaceGitRepositories(workspace))
+	assert.Len(t, volumes, 1)
+	assert.Equal(t, "gh-token", volumes[0].Secret.SecretName)
+	assert.Equal(t, "/var/run/kloudlite/git-credentials/gh-token", mounts[0].MountPath)
+}
+
+func TestGitRepoKey(t *testing.T) {
+	nested := workspacev1.GitRepository{URL: "https://github.com/org/a.git", Path: "a/b"}
+	flat := workspacev1.GitRepository{URL: "https://github.com/org/a.git", Path: "a_b"}
+	other := workspacev1.GitRepository{URL: "https://github.com/org/b.git", Path: "a/b"}
+
+	assert.True(t, strings.HasPrefix(gitRepoKey(nested), "a_b-"))
+	assert.True(t, strings.HasPrefix(gitRepoKey(workspacev1.GitRepository{URL: "x"}), "root-"))
+	assert.NotEqual(t, gitRepoKey(nested), gitRepoKey(flat))
+	assert.NotEqual(t, gitRepoKey(nested), gitRepoKey(other))
+	assert.Equal(t, gitRepoKey(nested), gitRepoKey(workspacev1.GitRepository{URL: nested.URL, Path: "/a/b/"}))
+}
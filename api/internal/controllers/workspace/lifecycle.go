@@ -91,6 +91,12 @@ func (r *WorkspaceReconciler) handleActiveWorkspace(ctx context.Context, workspa
 		return reconcile.Result{}, err
 	}
 
+	// Install the Nix packages of the workspace template
+	if err := r.syncTemplatePackages(ctx, workspace, logger); err != nil {
+		logger.Warn("Failed to sync workspace template packages", zap.Error(err))
+		// Don't fail reconciliation, the sync is retried on next reconciliation
+	}
+
 	// Check if pod already exists
 	podName := getWorkspacePodName(workspace)
 	pod := &corev1.Pod{}
@@ -144,6 +150,22 @@ func (r *WorkspaceReconciler) handleActiveWorkspace(ctx context.Context, workspa
 			}
 		}
 
+		// Report clone results and switch repositories whose branch changed in spec
+		if pod.Status.Phase == corev1.PodRunning {
+			if err := r.syncGitRepositories(ctx, workspace, pod, logger); err != nil {
+				logger.Warn("Failed to sync git repositories", zap.Error(err))
+			}
+		}
+
+		// Apply devcontainer.json from the workspace folder once the repository is cloned,
+		// and again when `kl devcontainer apply` requests a re-sync
+		if pod.Status.Phase == corev1.PodRunning {
+			if err := r.syncDevcontainer(ctx, workspace, pod, logger); err != nil {
+				logger.Warn("Failed to sync devcontainer.json", zap.Error(err))
+				// Don't fail reconciliation, the sync is retried on next reconciliation
+			}
+		}
+
 		// Update workspace status based on pod phase
 		logger.Info("Workspace pod already exists", zap.String("pod", podName), zap.String("podPhase", string(pod.Status.Phase)))
 
@@ -187,6 +209,12 @@ func (r *WorkspaceReconciler) handleActiveWorkspace(ctx context.Context, workspa
 		// Don't fail reconciliation - Docker config is optional for registry auth
 	}
 
+	// Ensure the Secret backing `kl secret` exists so the CLI can update it
+	if err := r.ensureWorkspaceSecret(ctx, workspace, targetNamespace, logger); err != nil {
+		logger.Warn("Failed to create workspace Secret", zap.Error(err))
+		// Don't fail reconciliation - the Secret volume is optional
+	}
+
 	pod, err = r.createWorkspacePod(workspace)
 	if err != nil {
 		logger.Error("Failed to build workspace pod", zap.Error(err))
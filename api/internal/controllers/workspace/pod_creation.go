@@ -297,7 +297,7 @@ cat > /tmp-writable/kloudlite-context.json << 'EOFC'
 %s
 EOFC
 chmod 644 /tmp-writable/kloudlite-context.json
-`, workspace.Name, workspace.Name, workspace.Name, workspace.Namespace, workspace.Spec.OwnedBy, targetNamespace, imageRegistryURL, searchDomains, hostsEntry, contextJSON)
+`, workspace.Name, workspace.Name, workspace.Name, workspace.Namespace, workspace.Spec.OwnedBy, targetNamespace, imageRegistryURL, searchDomains, hostsEntry, contextJSON) + buildSecretFilesScript(workspace)
 							}(),
 						},
 						Env: func() []corev1.EnvVar {
@@ -341,30 +341,12 @@ chmod 644 /tmp-writable/kloudlite-context.json
 					},
 				}
 
-				// Add git clone init container if git repository is specified
-				if workspace.Spec.GitRepository != nil && workspace.Spec.GitRepository.URL != "" {
-					workspaceDir := fmt.Sprintf("/home/kl/workspaces/%s", workspace.Name)
-
-					// Build git clone command with SSH config to disable host key checking
+				// Add git clone init container if any git repository is specified
+				if repos := workspaceGitRepositories(workspace); len(repos) > 0 {
 					// SSH keys are mounted from /var/lib/kloudlite/ssh-config on host to /root/.ssh
-					// Use ssh_host_rsa_key which is the SSH private key available in that directory
-					// This is safe because we're cloning from trusted sources specified by the user
-					sshCommand := "ssh -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -i /root/.ssh/ssh_host_rsa_key"
-
-					// Build git clone command
-					cloneCmd := fmt.Sprintf("GIT_SSH_COMMAND='%s' git clone %s", sshCommand, workspace.Spec.GitRepository.URL)
-					if workspace.Spec.GitRepository.Branch != "" {
-						cloneCmd = fmt.Sprintf("GIT_SSH_COMMAND='%s' git clone -b %s %s", sshCommand, workspace.Spec.GitRepository.Branch, workspace.Spec.GitRepository.URL)
-					}
-					cloneCmd += fmt.Sprintf(" %s && chown -R 1001:1001 %s", workspaceDir, workspaceDir)
-
-					// Only clone if workspace directory is empty
-					fullCmd := fmt.Sprintf(
-						"if [ ! -d %s ] || [ -z \"$(ls -A %s 2>/dev/null)\" ]; then %s; else echo 'Workspace folder is not empty, skipping git clone'; fi",
-						workspaceDir,
-						workspaceDir,
-						cloneCmd,
-					)
+					// and used for SSH URLs without explicit credentials
+					// Credential Secrets are mounted only into this container and copied into the kl home
+					_, credentialMounts := gitCredentialVolumes(repos)
 
 					initContainers = append(initContainers, corev1.Container{
 						Name:  "git-clone",
@@ -372,9 +354,9 @@ chmod 644 /tmp-writable/kloudlite-context.json
 						Command: []string{
 							"sh",
 							"-c",
-							fullCmd,
+							buildGitCloneScript(workspace),
 						},
-						VolumeMounts: []corev1.VolumeMount{
+						VolumeMounts: append([]corev1.VolumeMount{
 							{
 								Name:      "kl-home",
 								MountPath: "/home/kl",
@@ -389,7 +371,7 @@ chmod 644 /tmp-writable/kloudlite-context.json
 								MountPath: "/root/.ssh",
 								ReadOnly:  true,
 							},
-						},
+						}, credentialMounts...),
 					})
 				}
 
@@ -658,6 +640,20 @@ chmod 644 /tmp-writable/kloudlite-context.json
 		},
 	}
 
+	// Git credential Secrets used by the git-clone init container
+	credentialVolumes, _ := gitCredentialVolumes(workspaceGitRepositories(workspace))
+	pod.Spec.Volumes = append(pod.Spec.Volumes, credentialVolumes...)
+
+	// Secrets and ConfigMaps for settings.envFrom, settings.secretFiles and `kl secret`
+	// Mounted as volumes rather than env vars so updates reach the workspace without a restart
+	secretVolumes, secretMounts := buildSecretVolumes(workspace)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, secretVolumes...)
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == "workspace" {
+			pod.Spec.Containers[i].VolumeMounts = append(pod.Spec.Containers[i].VolumeMounts, secretMounts...)
+		}
+	}
+
 	// Disable Kubernetes DNS management completely
 	// DNS will be managed manually via /etc/resolv.conf written by init container to EmptyDir
 	// and configured based on workspace's environment connection. We provide minimal DNSConfig
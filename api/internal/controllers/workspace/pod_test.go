@@ -1098,3 +1098,53 @@ func TestWorkspaceReconciler_CreateWorkspacePod_DNSConfiguration(t *testing.T) {
 	assert.NotNil(t, pod.Spec.DNSConfig)
 	assert.Contains(t, pod.Spec.DNSConfig.Nameservers, "10.43.0.10")
 }
+
+func TestWorkspaceReconciler_CreateWorkspacePod_MigratedWorkspaceNamespace(t *testing.T) {
+	prevCfg := cfg
+	cfg = &ControllerConfig{}
+	t.Cleanup(func() { cfg = prevCfg })
+
+	scheme := testutil.NewTestScheme()
+
+	// Target of a migration from wm-alice, which adopted the namespace of the source
+	workMachine := &machinesv1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "wm-alice-large",
+		},
+		Spec: machinesv1.WorkMachineSpec{
+			TargetNamespace: "wm-alice",
+			OwnedBy:         "alice",
+			MachineType:     "m5.xlarge",
+		},
+	}
+
+	workspace := &workspacev1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dev",
+			Namespace: "wm-alice",
+		},
+		Spec: workspacev1.WorkspaceSpec{
+			DisplayName:     "Dev",
+			OwnedBy:         "alice",
+			Status:          "active",
+			WorkmachineName: "wm-alice-large",
+		},
+	}
+
+	k8sClient := testutil.NewFakeClient(scheme, workspace, workMachine).
+		WithStatusSubresource(&packagesv1.PackageRequest{}, &workspacev1.Workspace{}).
+		Build()
+
+	logger, _ := zap.NewDevelopment()
+	reconciler := &WorkspaceReconciler{
+		Client: k8sClient,
+		Scheme: scheme,
+		Logger: logger,
+	}
+
+	pod, err := reconciler.createWorkspacePod(workspace)
+	if err != nil || pod == nil {
+		t.Fatalf("Failed to create workspace pod: err=%v, pod=%v", err, pod)
+	}
+	assert.Equal(t, workspace.Namespace, pod.Namespace, "pod of a migrated workspace should be created in the namespace of the workspace")
+}
@@ -0,0 +1,186 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// secretEnvMountDir holds one directory per environment source, named <index>_<prefix>
+	// Each file in a directory is a variable; the kl CLI turns them into the shell env file.
+	// Mounted Secrets and ConfigMaps are refreshed by the kubelet, so no pod restart is needed.
+	secretEnvMountDir = "/var/run/kloudlite/env"
+
+	// secretFilesMountDir holds one directory per settings.secretFiles entry
+	// Files in the kl home directory are symlinks into these directories
+	secretFilesMountDir = "/var/run/kloudlite/files"
+
+	// workspaceSecretEnvIndex sorts the workspace Secret last so `kl secret set` wins over envFrom
+	workspaceSecretEnvIndex = 99
+)
+
+// getWorkspaceSecretName returns the name of the Secret managed by `kl secret`
+func getWorkspaceSecretName(workspaceName string) string {
+	return fmt.Sprintf("%s-secrets", workspaceName)
+}
+
+// ensureWorkspaceSecret creates the Secret backing `kl secret` if it does not exist
+// The data is owned by the user, so an existing Secret is never modified
+func (r *WorkspaceReconciler) ensureWorkspaceSecret(ctx context.Context, workspace *workspacev1.Workspace, targetNamespace string, logger *zap.Logger) error {
+	secretName := getWorkspaceSecretName(workspace.Name)
+
+	existing := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Name: secretName, Namespace: targetNamespace}, existing)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get workspace Secret: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: targetNamespace,
+			Labels: map[string]string{
+				"kloudlite.io/workspace-name":    workspace.Name,
+				"kloudlite.io/workspace-secrets": "true",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+
+	// Set workspace as owner for automatic cleanup
+	if err := controllerutil.SetControllerReference(workspace, secret, r.Scheme); err != nil {
+		return fmt.Errorf("failed to set owner reference on workspace Secret: %w", err)
+	}
+
+	if err := r.Create(ctx, secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create workspace Secret: %w", err)
+	}
+
+	logger.Info("Workspace Secret created", zap.String("secret", secretName))
+	return nil
+}
+
+// secretEnvDirName returns the mount directory name for an environment source
+func secretEnvDirName(index int, prefix string) string {
+	return fmt.Sprintf("%02d_%s", index, prefix)
+}
+
+// buildSecretVolumes returns the volumes and workspace container mounts for
+// settings.envFrom, settings.secretFiles and the `kl secret` Secret
+func buildSecretVolumes(workspace *workspacev1.Workspace) ([]corev1.Volume, []corev1.VolumeMount) {
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+
+	addMount := func(volume corev1.Volume, mountPath string) {
+		volumes = append(volumes, volume)
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	settings := workspace.Spec.Settings
+	if settings != nil {
+		for i, source := range settings.EnvFrom {
+			volume := corev1.Volume{Name: fmt.Sprintf("env-from-%d", i)}
+			switch source.Type {
+			case "Secret":
+				volume.VolumeSource = corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName:  source.Name,
+						Optional:    fn.Ptr(source.Optional),
+						DefaultMode: fn.Ptr(int32(0444)),
+					},
+				}
+			case "ConfigMap":
+				volume.VolumeSource = corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: source.Name},
+						Optional:             fn.Ptr(source.Optional),
+						DefaultMode:          fn.Ptr(int32(0444)),
+					},
+				}
+			default:
+				continue
+			}
+			addMount(volume, path.Join(secretEnvMountDir, secretEnvDirName(i, source.Prefix)))
+		}
+
+		for i, file := range settings.SecretFiles {
+			mode := int32(0444)
+			if file.Mode != nil {
+				mode = *file.Mode
+			}
+			addMount(corev1.Volume{
+				Name: fmt.Sprintf("secret-file-%d", i),
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: file.SecretName,
+						Items: []corev1.KeyToPath{
+							{
+								Key:  file.Key,
+								Path: path.Base(secretFileHomePath(file.Path)),
+								Mode: fn.Ptr(mode),
+							},
+						},
+					},
+				},
+			}, path.Join(secretFilesMountDir, fmt.Sprintf("%d", i)))
+		}
+	}
+
+	// Secret managed by `kl secret`, created by the controller before the pod
+	addMount(corev1.Volume{
+		Name: "workspace-secrets",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  getWorkspaceSecretName(workspace.Name),
+				Optional:    fn.Ptr(true),
+				DefaultMode: fn.Ptr(int32(0444)),
+			},
+		},
+	}, path.Join(secretEnvMountDir, secretEnvDirName(workspaceSecretEnvIndex, "")))
+
+	return volumes, mounts
+}
+
+// secretFileHomePath returns the absolute path of a secret file inside the kl home directory
+func secretFileHomePath(p string) string {
+	p = strings.TrimPrefix(p, "~/")
+	return path.Join("/home/kl", path.Clean("/"+p))
+}
+
+// buildSecretFilesScript links settings.secretFiles into the kl home directory
+// Links point into the mounted Secret, so updated values are picked up without a restart.
+// An existing regular file at the target path is kept as <path>.kl-backup.
+func buildSecretFilesScript(workspace *workspacev1.Workspace) string {
+	if workspace.Spec.Settings == nil || len(workspace.Spec.Settings.SecretFiles) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n# Link secret files into the kl home directory\n")
+	for i, file := range workspace.Spec.Settings.SecretFiles {
+		target := secretFileHomePath(file.Path)
+		source := path.Join(secretFilesMountDir, fmt.Sprintf("%d", i), path.Base(target))
+		fmt.Fprintf(&b, "mkdir -p %[1]s && chown 1001:1001 %[1]s\n", shellQuote(path.Dir(target)))
+		fmt.Fprintf(&b, "if [ -f %[1]s ] && [ ! -L %[1]s ]; then mv %[1]s %[2]s; fi\n", shellQuote(target), shellQuote(target+".kl-backup"))
+		fmt.Fprintf(&b, "ln -sfn %s %s\n", shellQuote(source), shellQuote(target))
+	}
+	return b.String()
+}
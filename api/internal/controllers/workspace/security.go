@@ -24,11 +24,12 @@ func (r *WorkspaceReconciler) validateCommandForExec(command []string) error {
 
 	// Define allowed commands and their safe patterns
 	allowedCommands := map[string]bool{
-		"sh":   true,
-		"awk":  true,
-		"wc":   true,
-		"cat":  true,
-		"grep": true,
+		"sh":      true,
+		"awk":     true,
+		"wc":      true,
+		"cat":     true,
+		"grep":    true,
+		"setpriv": true,
 	}
 
 	// Check first argument is an allowed command
@@ -36,6 +37,15 @@ func (r *WorkspaceReconciler) validateCommandForExec(command []string) error {
 		return fmt.Errorf("command not allowed: %s", command[0])
 	}
 
+	// setpriv is only used to run git as the kl user in workspace repositories
+	if command[0] == "setpriv" {
+		n := len(gitExecPrefix)
+		if len(command) < n+3 || strings.Join(command[:n], "\x00") != strings.Join(gitExecPrefix, "\x00") ||
+			command[n] != "-C" || !strings.HasPrefix(command[n+1], "/home/kl/workspaces/") || strings.Contains(command[n+1], "..") {
+			return fmt.Errorf("setpriv arguments not allowed: %s", strings.Join(command[1:], " "))
+		}
+	}
+
 	// For shell commands, validate the script content
 	if command[0] == "sh" && len(command) > 2 && command[1] == "-c" {
 		script := command[2]
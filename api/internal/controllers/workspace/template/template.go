@@ -0,0 +1,194 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+)
+
+// Fields are the WorkspaceSpec fields a template provides
+// Packages are not part of the workspace spec; the workspace controller applies them to the PackageRequest.
+var Fields = []string{"settings", "expose", "environmentConnection", "gitRepositories"}
+
+// atomicFields are replaced as a whole instead of being merged key by key
+var atomicFields = map[string]bool{
+	"environmentConnection": true,
+}
+
+// Applied is the template snapshot recorded on a workspace when a template revision is applied
+type Applied struct {
+	Name     string                            `json:"name"`
+	Revision string                            `json:"revision"`
+	Spec     workspacev1.WorkspaceTemplateSpec `json:"spec"`
+}
+
+// Revision returns a short content hash identifying a template spec
+func Revision(spec *workspacev1.WorkspaceTemplateSpec) string {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// DecodeApplied reads the applied template snapshot from workspace annotations
+// Returns nil if the workspace has never had a template applied
+func DecodeApplied(annotations map[string]string) (*Applied, error) {
+	raw, ok := annotations[workspacev1.WorkspaceTemplateAppliedAnnotation]
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	var applied Applied
+	if err := json.Unmarshal([]byte(raw), &applied); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", workspacev1.WorkspaceTemplateAppliedAnnotation, err)
+	}
+	return &applied, nil
+}
+
+// EncodeApplied returns the annotation value recording that tmpl was applied
+func EncodeApplied(tmpl *workspacev1.WorkspaceTemplate) (string, error) {
+	data, err := json.Marshal(Applied{
+		Name:     tmpl.Name,
+		Revision: Revision(&tmpl.Spec),
+		Spec:     tmpl.Spec,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Merge applies a template spec onto a workspace spec given as decoded JSON
+//
+// base is the template spec applied previously, nil if the workspace never had a template.
+// Without a base, template values only fill in fields the workspace does not set.
+// With a base, a value the workspace still holds unchanged from the base follows the template;
+// a value the user changed (a per-user override) is kept. Objects are merged key by key,
+// lists are replaced as a whole.
+func Merge(spec map[string]interface{}, tmpl, base *workspacev1.WorkspaceTemplateSpec) (map[string]interface{}, error) {
+	theirs, err := toMap(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	var baseMap map[string]interface{}
+	if base != nil {
+		if baseMap, err = toMap(base); err != nil {
+			return nil, err
+		}
+	}
+
+	merged := make(map[string]interface{}, len(spec))
+	for k, v := range spec {
+		merged[k] = v
+	}
+
+	for _, field := range Fields {
+		var value interface{}
+		if atomicFields[field] {
+			value = pick(baseMap[field], spec[field], theirs[field])
+		} else {
+			value = merge3(baseMap[field], spec[field], theirs[field])
+		}
+		if value == nil {
+			delete(merged, field)
+		} else {
+			merged[field] = value
+		}
+	}
+	return merged, nil
+}
+
+// ChangedFields lists the template fields (dot-separated) that differ between two workspace specs
+func ChangedFields(before, after map[string]interface{}) []string {
+	var changed []string
+	for _, field := range Fields {
+		changed = append(changed, diff(field, before[field], after[field])...)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// merge3 merges ours and theirs relative to base; nil means the value is absent
+func merge3(base, ours, theirs interface{}) interface{} {
+	oursMap, oursIsMap := ours.(map[string]interface{})
+	theirsMap, theirsIsMap := theirs.(map[string]interface{})
+	baseMap, baseIsMap := base.(map[string]interface{})
+	if !oursIsMap || (theirs != nil && !theirsIsMap) || (base != nil && !baseIsMap) {
+		return pick(base, ours, theirs)
+	}
+
+	result := map[string]interface{}{}
+	for _, key := range unionKeys(baseMap, oursMap, theirsMap) {
+		if v := merge3(baseMap[key], oursMap[key], theirsMap[key]); v != nil {
+			result[key] = v
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// pick returns theirs unless ours was changed from base
+func pick(base, ours, theirs interface{}) interface{} {
+	if reflect.DeepEqual(ours, base) {
+		return theirs
+	}
+	return ours
+}
+
+func diff(prefix string, before, after interface{}) []string {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if !beforeIsMap || !afterIsMap {
+		if reflect.DeepEqual(before, after) {
+			return nil
+		}
+		return []string{prefix}
+	}
+
+	var changed []string
+	for _, key := range unionKeys(beforeMap, afterMap) {
+		changed = append(changed, diff(prefix+"."+key, beforeMap[key], afterMap[key])...)
+	}
+	return changed
+}
+
+func unionKeys(maps ...map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ToMap converts a WorkspaceSpec to decoded JSON for Merge and ChangedFields
+func ToMap(spec *workspacev1.WorkspaceSpec) (map[string]interface{}, error) {
+	return toMap(spec)
+}
+
+// toMap converts a value to its decoded JSON form so it compares equal to workspace JSON
+func toMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,84 @@
+package template
+
+import (
+	"testing"
+
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeFillsUnsetFields(t *testing.T) {
+	tmpl := &workspacev1.WorkspaceTemplateSpec{
+		DisplayName: "Go service",
+		Settings: &workspacev1.WorkspaceSettings{
+			DotfilesRepo:         "https://github.com/org/dotfiles",
+			EnvironmentVariables: map[string]string{"GOFLAGS": "-mod=mod", "CGO_ENABLED": "0"},
+		},
+		Expose: []workspacev1.ExposedPort{{Port: 8080}},
+	}
+	spec, err := ToMap(&workspacev1.WorkspaceSpec{
+		DisplayName: "mine",
+		Settings: &workspacev1.WorkspaceSettings{
+			EnvironmentVariables: map[string]string{"CGO_ENABLED": "1"},
+		},
+	})
+	require.NoError(t, err)
+
+	merged, err := Merge(spec, tmpl, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "mine", merged["displayName"])
+	settings := merged["settings"].(map[string]interface{})
+	assert.Equal(t, "https://github.com/org/dotfiles", settings["dotfilesRepo"])
+	assert.Equal(t, map[string]interface{}{"GOFLAGS": "-mod=mod", "CGO_ENABLED": "1"}, settings["environmentVariables"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"port": float64(8080)}}, merged["expose"])
+}
+
+func TestMergeRebaseKeepsOverrides(t *testing.T) {
+	base := &workspacev1.WorkspaceTemplateSpec{
+		DisplayName: "Go service",
+		Settings: &workspacev1.WorkspaceSettings{
+			IdleTimeout:  30,
+			DotfilesRepo: "https://github.com/org/dotfiles",
+		},
+		Expose: []workspacev1.ExposedPort{{Port: 8080}},
+	}
+	latest := &workspacev1.WorkspaceTemplateSpec{
+		DisplayName: "Go service",
+		Settings: &workspacev1.WorkspaceSettings{
+			IdleTimeout:  60,
+			DotfilesRepo: "https://github.com/org/dotfiles-v2",
+		},
+	}
+
+	// The user overrode the dotfiles repository after the template was applied
+	before, err := ToMap(&workspacev1.WorkspaceSpec{
+		Settings: &workspacev1.WorkspaceSettings{
+			IdleTimeout:  30,
+			DotfilesRepo: "https://github.com/me/dotfiles",
+		},
+		Expose: []workspacev1.ExposedPort{{Port: 8080}},
+	})
+	require.NoError(t, err)
+
+	after, err := Merge(before, latest, base)
+	require.NoError(t, err)
+
+	settings := after["settings"].(map[string]interface{})
+	assert.Equal(t, float64(60), settings["idleTimeout"])
+	assert.Equal(t, "https://github.com/me/dotfiles", settings["dotfilesRepo"])
+	assert.NotContains(t, after, "expose", "port removed from the template should be removed")
+
+	assert.Equal(t, []string{"expose", "settings.idleTimeout"}, ChangedFields(before, after))
+}
+
+func TestRevisionChangesWithSpec(t *testing.T) {
+	spec := &workspacev1.WorkspaceTemplateSpec{DisplayName: "a"}
+	rev := Revision(spec)
+	assert.Len(t, rev, 12)
+	assert.Equal(t, rev, Revision(spec.DeepCopy()))
+
+	spec.Expose = []workspacev1.ExposedPort{{Port: 3000}}
+	assert.NotEqual(t, rev, Revision(spec))
+}
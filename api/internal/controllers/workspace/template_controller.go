@@ -0,0 +1,135 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	wstemplate "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/template"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/kloudlite/kloudlite/api/internal/pkg/statusutil"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// WorkspaceTemplateReconciler reports the revision of WorkspaceTemplates and the workspaces based on them
+type WorkspaceTemplateReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Logger *zap.Logger
+}
+
+// Reconcile computes the template revision and lists workspaces that are on an older revision
+func (r *WorkspaceTemplateReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := r.Logger.With(zap.String("workspaceTemplate", req.Name))
+
+	tmpl := &workspacev1.WorkspaceTemplate{}
+	if err := r.Get(ctx, req.NamespacedName, tmpl); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Workspaces keep the values merged from the deleted template
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	revision := wstemplate.Revision(&tmpl.Spec)
+
+	workspaces := &workspacev1.WorkspaceList{}
+	if err := r.List(ctx, workspaces, client.MatchingLabels{workspacev1.WorkspaceTemplateLabel: tmpl.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list workspaces for template: %w", err)
+	}
+
+	var count int32
+	var outOfDate []string
+	for i := range workspaces.Items {
+		ws := &workspaces.Items[i]
+		if ws.DeletionTimestamp != nil || ws.Spec.TemplateRef == nil || ws.Spec.TemplateRef.Name != tmpl.Name {
+			continue
+		}
+		count++
+		if ws.Spec.TemplateRef.Revision != revision {
+			outOfDate = append(outOfDate, fmt.Sprintf("%s/%s", ws.Namespace, ws.Name))
+		}
+	}
+	sort.Strings(outOfDate)
+
+	status := tmpl.Status
+	if status.Revision == revision && status.Workspaces == count && slices.Equal(status.OutOfDateWorkspaces, outOfDate) {
+		return reconcile.Result{}, nil
+	}
+
+	if err := statusutil.UpdateStatusWithRetry(ctx, r.Client, tmpl, func() error {
+		now := metav1.Now()
+		tmpl.Status.Revision = revision
+		tmpl.Status.Workspaces = count
+		tmpl.Status.OutOfDateWorkspaces = outOfDate
+		tmpl.Status.LastUpdated = &now
+		return nil
+	}, logger); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	logger.Info("Updated workspace template status",
+		zap.String("revision", revision),
+		zap.Int32("workspaces", count),
+		zap.Strings("outOfDate", outOfDate))
+	return reconcile.Result{}, nil
+}
+
+// findTemplateForWorkspace maps a workspace to the template it is based on
+func (r *WorkspaceTemplateReconciler) findTemplateForWorkspace(ctx context.Context, obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()[workspacev1.WorkspaceTemplateLabel]
+	if name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+}
+
+// workspaceHandler enqueues the template of a workspace and, when the template label changes, the
+// template it was based on before, which no longer finds the workspace by its label
+func (r *WorkspaceTemplateReconciler) workspaceHandler() handler.EventHandler {
+	enqueue := func(ctx context.Context, obj client.Object, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+		for _, req := range r.findTemplateForWorkspace(ctx, obj) {
+			q.Add(req)
+		}
+	}
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.Object, q)
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.ObjectOld, q)
+			enqueue(ctx, e.ObjectNew, q)
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.Object, q)
+		},
+		GenericFunc: func(ctx context.Context, e event.GenericEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.Object, q)
+		},
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *WorkspaceTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&workspacev1.WorkspaceTemplate{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&workspacev1.Workspace{},
+			r.workspaceHandler(),
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{})),
+		).
+		Complete(r)
+}
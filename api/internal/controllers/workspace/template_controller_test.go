@@ -0,0 +1,53 @@
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestWorkspaceTemplateReconciler_workspaceHandler(t *testing.T) {
+	workspace := func(template string) *workspacev1.Workspace {
+		ws := &workspacev1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "ws", Namespace: "default"}}
+		if template != "" {
+			ws.Labels = map[string]string{workspacev1.WorkspaceTemplateLabel: template}
+		}
+		return ws
+	}
+
+	tests := []struct {
+		name string
+		old  *workspacev1.Workspace
+		new  *workspacev1.Workspace
+		want []string
+	}{
+		{name: "template unchanged", old: workspace("go"), new: workspace("go"), want: []string{"go"}},
+		{name: "template changed", old: workspace("go"), new: workspace("node"), want: []string{"go", "node"}},
+		{name: "template label removed", old: workspace("go"), new: workspace(""), want: []string{"go"}},
+		{name: "template label added", old: workspace(""), new: workspace("go"), want: []string{"go"}},
+	}
+
+	r := &WorkspaceTemplateReconciler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+			defer q.ShutDown()
+
+			r.workspaceHandler().Update(context.Background(), event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}, q)
+
+			var got []string
+			for q.Len() > 0 {
+				req, _ := q.Get()
+				got = append(got, req.Name)
+				q.Done(req)
+			}
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	wstemplate "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/template"
+	workspacev1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/kloudlite/kloudlite/api/internal/pkg/statusutil"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// syncTemplatePackages applies the Nix packages of the workspace template to the PackageRequest
+// The webhook records the applied template revision on the workspace; packages are applied once
+// per revision, and removed again when the template drops them or the template is detached.
+func (r *WorkspaceReconciler) syncTemplatePackages(ctx context.Context, workspace *workspacev1.Workspace, logger *zap.Logger) error {
+	ref := workspace.Spec.TemplateRef
+	prev := workspace.Status.Template
+
+	var prevPackages []string
+	if prev != nil {
+		prevPackages = prev.Packages
+	}
+
+	if ref == nil {
+		if prev == nil {
+			return nil
+		}
+		if err := r.applyPackages(ctx, workspace, nil, prevPackages); err != nil {
+			return fmt.Errorf("failed to remove template packages: %w", err)
+		}
+		logger.Info("Removed packages of detached workspace template", zap.String("template", prev.Name))
+		return r.setTemplateStatus(ctx, workspace, nil, logger)
+	}
+
+	if ref.Revision == "" || (prev != nil && prev.Name == ref.Name && prev.Revision == ref.Revision) {
+		return nil
+	}
+
+	applied, err := wstemplate.DecodeApplied(workspace.Annotations)
+	if err != nil {
+		return err
+	}
+	if applied == nil || applied.Revision != ref.Revision {
+		// The webhook sets the annotation together with the revision, this is a stale read
+		return nil
+	}
+
+	if err := r.applyPackages(ctx, workspace, applied.Spec.Packages, prevPackages); err != nil {
+		return fmt.Errorf("failed to apply template packages: %w", err)
+	}
+
+	packages := make([]string, 0, len(applied.Spec.Packages))
+	for _, p := range applied.Spec.Packages {
+		packages = append(packages, p.Name)
+	}
+
+	logger.Info("Applied workspace template packages",
+		zap.String("template", ref.Name),
+		zap.String("revision", ref.Revision),
+		zap.Strings("packages", packages))
+
+	now := metav1.Now()
+	return r.setTemplateStatus(ctx, workspace, &workspacev1.AppliedTemplateStatus{
+		Name:      ref.Name,
+		Revision:  ref.Revision,
+		Packages:  packages,
+		AppliedAt: &now,
+	}, logger)
+}
+
+// setTemplateStatus records the applied template in status.template
+func (r *WorkspaceReconciler) setTemplateStatus(ctx context.Context, workspace *workspacev1.Workspace, status *workspacev1.AppliedTemplateStatus, logger *zap.Logger) error {
+	connectedEnvironment := workspace.Status.ConnectedEnvironment
+	return statusutil.UpdateStatusWithRetry(ctx, r.Client, workspace, func() error {
+		workspace.Status.ConnectedEnvironment = connectedEnvironment
+		workspace.Status.Template = status
+		return nil
+	}, logger)
+}
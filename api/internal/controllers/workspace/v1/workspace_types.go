@@ -30,8 +30,99 @@ type GitRepository struct {
 	URL string `json:"url"`
 
 	// Branch to clone (optional, uses repository default if not specified)
+	// Changing the branch of a cloned repository fetches and switches to it in the running workspace
 	// +optional
 	Branch string `json:"branch,omitempty"`
+
+	// Path is the target directory relative to the workspace folder
+	// Empty means the workspace folder itself (only allowed for one repository)
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Depth creates a shallow clone truncated to the given number of commits
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Depth int32 `json:"depth,omitempty"`
+
+	// Filter is a partial clone filter
+	// - blob:none: blobless clone, file contents are fetched on demand
+	// - tree:0: treeless clone, trees and blobs are fetched on demand
+	// +kubebuilder:validation:Enum=blob:none;tree:0
+	// +optional
+	Filter string `json:"filter,omitempty"`
+
+	// SparseCheckout limits the working tree to these directories (cone mode)
+	// +optional
+	SparseCheckout []string `json:"sparseCheckout,omitempty"`
+
+	// CredentialsSecretRef references a Secret in the WorkMachine target namespace used to clone the repository
+	// For HTTPS URLs the Secret must contain a "token" key (and optionally "username")
+	// For SSH URLs the Secret must contain an "ssh-privatekey" key (deploy key)
+	// When not set, the WorkMachine SSH key is used
+	// +optional
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// GitRepositoryPhase represents the clone state of a repository
+type GitRepositoryPhase string
+
+const (
+	// GitRepositoryPhasePending indicates the repository has not been cloned yet
+	GitRepositoryPhasePending GitRepositoryPhase = "Pending"
+
+	// GitRepositoryPhaseCloned indicates the repository is cloned and on the requested branch
+	GitRepositoryPhaseCloned GitRepositoryPhase = "Cloned"
+
+	// GitRepositoryPhaseFailed indicates cloning or switching branches failed
+	GitRepositoryPhaseFailed GitRepositoryPhase = "Failed"
+)
+
+// GitRepositoryStatus reports the clone state of a single repository
+type GitRepositoryStatus struct {
+	// URL of the repository
+	URL string `json:"url"`
+
+	// Path relative to the workspace folder
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Phase is the clone state of the repository
+	// +kubebuilder:validation:Enum=Pending;Cloned;Failed
+	// +optional
+	Phase GitRepositoryPhase `json:"phase,omitempty"`
+
+	// Branch currently checked out
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Commit is the checked out commit SHA
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// Message provides additional information, such as the clone error
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastSyncTime is when the repository state was last observed
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// DevcontainerSpec controls how .devcontainer/devcontainer.json from the cloned repository is applied
+type DevcontainerSpec struct {
+	// Disabled skips reading devcontainer.json from the workspace folder
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Path to devcontainer.json relative to the workspace folder
+	// Defaults to .devcontainer/devcontainer.json
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// SyncRequest is incremented to ask the controller to re-read devcontainer.json
+	// (e.g. by `kl devcontainer apply` after the file was edited)
+	// +optional
+	SyncRequest int64 `json:"syncRequest,omitempty"`
 }
 
 // WorkspaceSpec defines the desired state of Workspace
@@ -82,6 +173,16 @@ type WorkspaceSpec struct {
 	// +optional
 	GitRepository *GitRepository `json:"gitRepository,omitempty"`
 
+	// GitRepositories defines additional git repositories to clone into the workspace folder
+	// Each repository must have a unique path
+	// +optional
+	GitRepositories []GitRepository `json:"gitRepositories,omitempty"`
+
+	// Devcontainer configures how devcontainer.json from the workspace folder is applied
+	// When a git repository is cloned, its devcontainer.json is applied unless disabled here
+	// +optional
+	Devcontainer *DevcontainerSpec `json:"devcontainer,omitempty"`
+
 	// Settings contains workspace-specific settings
 	// +optional
 	Settings *WorkspaceSettings `json:"settings,omitempty"`
@@ -114,6 +215,11 @@ type WorkspaceSpec struct {
 	// Each port gets an HTTP ingress route with hostname p{port}-{hash}.{subdomain}
 	// +optional
 	Expose []ExposedPort `json:"expose,omitempty"`
+
+	// TemplateRef bases the workspace on a WorkspaceTemplate
+	// Template values are merged in by the webhook; values set on the workspace take precedence
+	// +optional
+	TemplateRef *TemplateRef `json:"templateRef,omitempty"`
 }
 
 // WorkspaceSettings contains workspace-specific configuration
@@ -138,10 +244,32 @@ type WorkspaceSettings struct {
 	// +optional
 	StartupScript string `json:"startupScript,omitempty"`
 
+	// PostCreateCommand runs once after the workspace is first created (devcontainer postCreateCommand)
+	// +optional
+	PostCreateCommand string `json:"postCreateCommand,omitempty"`
+
+	// PostStartCommand runs every time the workspace starts (devcontainer postStartCommand)
+	// +optional
+	PostStartCommand string `json:"postStartCommand,omitempty"`
+
 	// EnvironmentVariables to set in the workspace
 	// +optional
 	EnvironmentVariables map[string]string `json:"environmentVariables,omitempty"`
 
+	// EnvFrom loads environment variables from Secrets and ConfigMaps in the WorkMachine target namespace
+	// Values are read from the mounted objects by the workspace shells and are never copied into the Workspace.
+	// Updates to the referenced objects reach running shells without restarting the workspace;
+	// adding or removing references takes effect on the next workspace start.
+	// Variables set with `kl secret set` take precedence over these sources.
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	EnvFrom []EnvFromSource `json:"envFrom,omitempty"`
+
+	// SecretFiles mounts Secret keys as files in the kl home directory
+	// (for example ~/.aws/credentials or ~/.npmrc)
+	// +optional
+	SecretFiles []SecretFile `json:"secretFiles,omitempty"`
+
 	// GitConfig for workspace git settings
 	// +optional
 	GitConfig *GitConfig `json:"gitConfig,omitempty"`
@@ -155,6 +283,47 @@ type WorkspaceSettings struct {
 	DotfilesRepo string `json:"dotfilesRepo,omitempty"`
 }
 
+// EnvFromSource represents a source for workspace environment variables
+type EnvFromSource struct {
+	// Type specifies the source type (ConfigMap or Secret)
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Type string `json:"type"`
+
+	// Name of the ConfigMap or Secret
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Prefix to prepend to all keys from this source
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Optional allows the workspace to start when the source does not exist
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// SecretFile places the value of a Secret key at a path in the kl home directory
+type SecretFile struct {
+	// Path of the file, relative to /home/kl (a leading ~/ is accepted)
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// SecretName is the Secret in the WorkMachine target namespace holding the file content
+	// +kubebuilder:validation:Required
+	SecretName string `json:"secretName"`
+
+	// Key in the Secret whose value is written to the file
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+
+	// Mode is the file permission bits, defaults to 0444 (the file is owned by root and read by the kl user)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	// +optional
+	Mode *int32 `json:"mode,omitempty"`
+}
+
 // GitConfig contains git configuration for the workspace
 type GitConfig struct {
 	// UserName for git commits
@@ -300,6 +469,82 @@ type WorkspaceStatus struct {
 	// Used for automatic parent lineage tracking when new snapshots are created
 	// +optional
 	LastRestoredSnapshot *WorkspaceLastRestoredSnapshotInfo `json:"lastRestoredSnapshot,omitempty"`
+
+	// Devcontainer tracks the devcontainer.json that was last applied to this workspace
+	// +optional
+	Devcontainer *DevcontainerStatus `json:"devcontainer,omitempty"`
+
+	// GitRepositories reports the clone state of each repository in spec.gitRepository and spec.gitRepositories
+	// +optional
+	GitRepositories []GitRepositoryStatus `json:"gitRepositories,omitempty"`
+
+	// Template tracks the template packages applied to the workspace PackageRequest
+	// +optional
+	Template *AppliedTemplateStatus `json:"template,omitempty"`
+}
+
+// DevcontainerPhase represents the result of the last devcontainer.json sync
+type DevcontainerPhase string
+
+const (
+	// DevcontainerPhaseApplied indicates devcontainer.json was found and applied
+	DevcontainerPhaseApplied DevcontainerPhase = "Applied"
+
+	// DevcontainerPhaseNotFound indicates there is no devcontainer.json in the workspace folder
+	DevcontainerPhaseNotFound DevcontainerPhase = "NotFound"
+
+	// DevcontainerPhaseFailed indicates devcontainer.json could not be read or parsed
+	DevcontainerPhaseFailed DevcontainerPhase = "Failed"
+)
+
+// DevcontainerStatus tracks the devcontainer.json that was last applied
+// The applied lists record which settings came from devcontainer.json so a later sync
+// can remove entries that were deleted from the file without touching user-added ones
+type DevcontainerStatus struct {
+	// Phase is the result of the last sync
+	// +kubebuilder:validation:Enum=Applied;NotFound;Failed
+	// +optional
+	Phase DevcontainerPhase `json:"phase,omitempty"`
+
+	// Message provides additional information about the last sync
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Path of the devcontainer.json that was read, relative to the workspace folder
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// ConfigHash is the content hash of the applied devcontainer.json
+	// +optional
+	ConfigHash string `json:"configHash,omitempty"`
+
+	// ObservedSyncRequest is the spec.devcontainer.syncRequest value that was last handled
+	// +optional
+	ObservedSyncRequest int64 `json:"observedSyncRequest,omitempty"`
+
+	// AppliedAt is when devcontainer.json was last applied
+	// +optional
+	AppliedAt *metav1.Time `json:"appliedAt,omitempty"`
+
+	// Packages are the Nix packages added to the PackageRequest from devcontainer.json
+	// +optional
+	Packages []string `json:"packages,omitempty"`
+
+	// Ports are the forwardPorts added to spec.expose
+	// +optional
+	Ports []int32 `json:"ports,omitempty"`
+
+	// EnvironmentVariables are the names of variables added to settings.environmentVariables
+	// +optional
+	EnvironmentVariables []string `json:"environmentVariables,omitempty"`
+
+	// Extensions are the VS Code extensions added to settings.vscodeExtensions
+	// +optional
+	Extensions []string `json:"extensions,omitempty"`
+
+	// UnsupportedFeatures lists devcontainer features that have no Nix package mapping
+	// +optional
+	UnsupportedFeatures []string `json:"unsupportedFeatures,omitempty"`
 }
 
 // WorkspaceLastRestoredSnapshotInfo tracks the last restored snapshot for lineage
@@ -0,0 +1,131 @@
+package v1
+
+import (
+	packagesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/packages/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// WorkspaceTemplateLabel is set on workspaces created from a template
+	WorkspaceTemplateLabel = "kloudlite.io/workspace-template"
+
+	// WorkspaceTemplateAppliedAnnotation holds the template spec last applied to a workspace
+	// It is the merge base when the workspace is rebased onto a newer template revision
+	WorkspaceTemplateAppliedAnnotation = "workspaces.kloudlite.io/template-applied"
+)
+
+// TemplateRef references the WorkspaceTemplate a workspace is based on
+type TemplateRef struct {
+	// Name of the WorkspaceTemplate
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Revision of the template applied to the workspace
+	// Set by the webhook; clear it to rebase the workspace onto the latest template revision
+	// +optional
+	Revision string `json:"revision,omitempty"`
+}
+
+// AppliedTemplateStatus reports what the workspace controller applied from the template
+type AppliedTemplateStatus struct {
+	// Name of the WorkspaceTemplate
+	Name string `json:"name"`
+
+	// Revision of the template whose packages were applied
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Packages added to the workspace PackageRequest by the template
+	// +optional
+	Packages []string `json:"packages,omitempty"`
+
+	// AppliedAt is when the template packages were last applied
+	// +optional
+	AppliedAt *metav1.Time `json:"appliedAt,omitempty"`
+}
+
+// WorkspaceTemplateSpec defines a standardized workspace setup
+// Field names match WorkspaceSpec so that a template can be merged into a workspace directly
+type WorkspaceTemplateSpec struct {
+	// DisplayName is the human-readable name of the template
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=100
+	DisplayName string `json:"displayName"`
+
+	// Description of the template
+	// +kubebuilder:validation:MaxLength=500
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Packages are Nix packages installed into workspaces created from the template
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	Packages []packagesv1.PackageSpec `json:"packages,omitempty"`
+
+	// Settings are the default workspace settings, including dotfiles
+	// +optional
+	Settings *WorkspaceSettings `json:"settings,omitempty"`
+
+	// Expose lists the ports exposed by default
+	// +optional
+	Expose []ExposedPort `json:"expose,omitempty"`
+
+	// EnvironmentConnection is the default environment connection
+	// +optional
+	EnvironmentConnection *EnvironmentConnectionSpec `json:"environmentConnection,omitempty"`
+
+	// GitRepositories are cloned into workspaces created from the template
+	// +kubebuilder:validation:MaxItems=20
+	// +optional
+	GitRepositories []GitRepository `json:"gitRepositories,omitempty"`
+}
+
+// WorkspaceTemplateStatus defines the observed state of WorkspaceTemplate
+type WorkspaceTemplateStatus struct {
+	// Revision identifies the current template spec
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Workspaces is the number of workspaces based on the template
+	// +optional
+	Workspaces int32 `json:"workspaces,omitempty"`
+
+	// OutOfDateWorkspaces lists workspaces (namespace/name) on an older revision
+	// +optional
+	OutOfDateWorkspaces []string `json:"outOfDateWorkspaces,omitempty"`
+
+	// LastUpdated is when the status was last computed
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=wstpl,categories={kloudlite,workspaces}
+// +kubebuilder:printcolumn:name="Display Name",type=string,JSONPath=`.spec.displayName`
+// +kubebuilder:printcolumn:name="Revision",type=string,JSONPath=`.status.revision`
+// +kubebuilder:printcolumn:name="Workspaces",type=integer,JSONPath=`.status.workspaces`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// WorkspaceTemplate is the Schema for the workspacetemplates API
+type WorkspaceTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WorkspaceTemplateSpec   `json:"spec,omitempty"`
+	Status WorkspaceTemplateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WorkspaceTemplateList contains a list of WorkspaceTemplate
+type WorkspaceTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkspaceTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkspaceTemplate{}, &WorkspaceTemplateList{})
+}
@@ -340,6 +340,14 @@ func (r *WorkspaceReconciler) setupWorkspaceRBAC(ctx context.Context, workspace
 				Resources: []string{"environments/status"},
 				Verbs:     []string{"get"},
 			},
+			{
+				// Allow managing the workspace Secret used by `kl secret`
+				// Other Secrets referenced by settings.envFrom are only mounted, never readable via the API
+				APIGroups:     []string{""},
+				Resources:     []string{"secrets"},
+				ResourceNames: []string{getWorkspaceSecretName(workspaceName)},
+				Verbs:         []string{"get", "update", "patch"},
+			},
 			// Note: PackageRequests are cluster-scoped, so they are granted in the ClusterRole below
 			{
 				// Allow reading pod logs (for streaming nix installation output from host-manager)
@@ -442,6 +450,27 @@ func (r *WorkspaceReconciler) setupWorkspaceRBAC(ctx context.Context, workspace
 				Resources: []string{"packagerequests/status"},
 				Verbs:     []string{"get"},
 			},
+			{
+				// Allow reading WorkspaceTemplates (cluster-scoped resource)
+				// Needed for kl template to compare the workspace with the latest template
+				APIGroups: []string{"workspaces.kloudlite.io"},
+				Resources: []string{"workspacetemplates"},
+				Verbs:     []string{"get", "list"},
+			},
+			{
+				// Allow reading Budgets and WorkMachines (cluster-scoped resources)
+				// Needed for kl usage to report the cost of the current month
+				APIGroups: []string{"machines.kloudlite.io"},
+				Resources: []string{"budgets", "workmachines"},
+				Verbs:     []string{"get", "list"},
+			},
+			{
+				// Allow taking and restoring backups (cluster-scoped resources)
+				// Needed for kl backup, the backup webhook limits them to WorkMachines of the workspace owner
+				APIGroups: []string{"machines.kloudlite.io"},
+				Resources: []string{"backups", "backuprestores"},
+				Verbs:     []string{"get", "list", "create"},
+			},
 		}
 		return nil
 	}); err != nil {
@@ -346,6 +346,31 @@ func TestValidateCommandForExec(t *testing.T) {
 			command:     []string{"sh", "-c", "echo 'hello world'"},
 			expectError: true,
 		},
+		{
+			name:        "valid git as the kl user",
+			command:     append(append([]string{}, gitExecPrefix...), "-C", "/home/kl/workspaces/ws/api", "rev-parse", "HEAD"),
+			expectError: false,
+		},
+		{
+			name:        "invalid git as root",
+			command:     []string{"git", "-C", "/home/kl/workspaces/ws/api", "rev-parse", "HEAD"},
+			expectError: true,
+		},
+		{
+			name:        "invalid setpriv to another user",
+			command:     []string{"setpriv", "--reuid=0", "--regid=0", "--clear-groups", "env", "HOME=/root", "git", "-C", "/home/kl/workspaces/ws/api", "status"},
+			expectError: true,
+		},
+		{
+			name:        "invalid git outside the workspaces",
+			command:     append(append([]string{}, gitExecPrefix...), "-C", "/home/kl/workspaces/../../etc", "status"),
+			expectError: true,
+		},
+		{
+			name:        "invalid chown",
+			command:     []string{"chown", "-R", "1001:1001", "/home/kl/workspaces/ws"},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -38,6 +38,7 @@ func setupWebhookRouter(cfg *config.Config, logger *zap.Logger, k8sClient *k8s.C
 	serviceMutationWebhook := webhooks.NewServiceMutationWebhook(appLogger, k8sClient.RuntimeClient)
 	podMutationWebhook := webhooks.NewPodMutationWebhook(appLogger, k8sClient.RuntimeClient)
 	snapshotWebhook := webhooks.NewSnapshotWebhook(appLogger, k8sClient.RuntimeClient)
+	backupWebhook := webhooks.NewBackupWebhook(appLogger, k8sClient.RuntimeClient)
 
 	// Webhook endpoints (for Kubernetes admission controllers)
 	webhooksGroup := router.Group("/webhooks")
@@ -61,6 +62,8 @@ func setupWebhookRouter(cfg *config.Config, logger *zap.Logger, k8sClient *k8s.C
 		webhooksGroup.POST("/validate/environmentsnapshotrequests", snapshotWebhook.ValidateEnvironmentSnapshotRequest)
 		webhooksGroup.POST("/validate/environmentsnapshotrestores", snapshotWebhook.ValidateEnvironmentSnapshotRestore)
 		webhooksGroup.POST("/validate/snapshots", snapshotWebhook.ValidateSnapshot)
+		webhooksGroup.POST("/validate/backups", backupWebhook.ValidateBackup)
+		webhooksGroup.POST("/validate/backuprestores", backupWebhook.ValidateBackupRestore)
 	}
 
 	// VPN connection endpoints (used by kltun CLI)
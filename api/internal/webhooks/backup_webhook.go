@@ -0,0 +1,224 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	machinesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	workspacesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/kloudlite/kloudlite/api/pkg/logger"
+	admissionv1 "k8s.io/api/admission/v1"
+	authv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// serviceAccountUserPrefix prefixes the usernames of ServiceAccounts: system:serviceaccount:<namespace>:<name>
+const serviceAccountUserPrefix = "system:serviceaccount:"
+
+// BackupWebhook validates Backups and BackupRestores
+//
+// Workspaces can take and restore backups (kl backup) with cluster-wide RBAC, so their
+// ServiceAccounts are limited to the WorkMachines of the workspace owner. Restores are only
+// allowed onto a WorkMachine of the owner of the backed up WorkMachine, whoever requests them.
+type BackupWebhook struct {
+	logger    logger.Logger
+	k8sClient client.Client
+}
+
+func NewBackupWebhook(logger logger.Logger, k8sClient client.Client) *BackupWebhook {
+	return &BackupWebhook{
+		logger:    logger,
+		k8sClient: k8sClient,
+	}
+}
+
+// ValidateBackup handles validation webhook for Backup CRD
+func (w *BackupWebhook) ValidateBackup(c *gin.Context) {
+	w.serve(c, w.handleBackupValidation)
+}
+
+// ValidateBackupRestore handles validation webhook for BackupRestore CRD
+func (w *BackupWebhook) ValidateBackupRestore(c *gin.Context) {
+	w.serve(c, w.handleBackupRestoreValidation)
+}
+
+func (w *BackupWebhook) serve(c *gin.Context, handle func(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		w.logger.Error("Failed to read request body: " + err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	var admissionReview admissionv1.AdmissionReview
+	if err := json.Unmarshal(body, &admissionReview); err != nil {
+		w.logger.Error("Failed to unmarshal admission review: " + err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to unmarshal admission review"})
+		return
+	}
+
+	response := handle(admissionReview.Request)
+	admissionReview.Response = response
+	admissionReview.Response.UID = admissionReview.Request.UID
+
+	c.JSON(http.StatusOK, admissionReview)
+}
+
+func (w *BackupWebhook) handleBackupValidation(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	// Only validate CREATE operations
+	if req.Operation != admissionv1.Create {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	var backup machinesv1.Backup
+	if err := json.Unmarshal(req.Object.Raw, &backup); err != nil {
+		w.logger.Error("Failed to unmarshal Backup: " + err.Error())
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: "Failed to unmarshal Backup object",
+			},
+		}
+	}
+
+	if err := w.validateBackup(context.Background(), &backup, req.UserInfo); err != nil {
+		w.logger.Warn("Backup validation failed: " + err.Error())
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+func (w *BackupWebhook) handleBackupRestoreValidation(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	// Only validate CREATE operations
+	if req.Operation != admissionv1.Create {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	var restore machinesv1.BackupRestore
+	if err := json.Unmarshal(req.Object.Raw, &restore); err != nil {
+		w.logger.Error("Failed to unmarshal BackupRestore: " + err.Error())
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: "Failed to unmarshal BackupRestore object",
+			},
+		}
+	}
+
+	if err := w.validateBackupRestore(context.Background(), &restore, req.UserInfo); err != nil {
+		w.logger.Warn("BackupRestore validation failed: " + err.Error())
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+// validateBackup checks that the WorkMachine exists and belongs to the requesting workspace owner
+func (w *BackupWebhook) validateBackup(ctx context.Context, backup *machinesv1.Backup, userInfo authv1.UserInfo) error {
+	wm, err := w.getWorkMachine(ctx, backup.Spec.WorkMachine)
+	if err != nil {
+		return err
+	}
+
+	if owner := backup.Annotations[machinesv1.AnnotationBackupOwner]; owner != "" && owner != wm.Spec.OwnedBy {
+		return fmt.Errorf("annotation %s must be the owner of WorkMachine %s", machinesv1.AnnotationBackupOwner, wm.Name)
+	}
+
+	return w.checkRequester(ctx, userInfo, wm)
+}
+
+// validateBackupRestore checks that the backup is restored onto a WorkMachine of the owner of the
+// backed up WorkMachine, and that this owner is the requesting workspace owner
+func (w *BackupWebhook) validateBackupRestore(ctx context.Context, restore *machinesv1.BackupRestore, userInfo authv1.UserInfo) error {
+	backup := &machinesv1.Backup{}
+	if err := w.k8sClient.Get(ctx, client.ObjectKey{Name: restore.Spec.Backup}, backup); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("backup %s not found", restore.Spec.Backup)
+		}
+		return fmt.Errorf("failed to get backup %s: %v", restore.Spec.Backup, err)
+	}
+
+	// The WorkMachine of the backup may be deleted, its owner is then read from the backup
+	sourceOwner := backup.Annotations[machinesv1.AnnotationBackupOwner]
+	source := &machinesv1.WorkMachine{}
+	if err := w.k8sClient.Get(ctx, client.ObjectKey{Name: backup.Spec.WorkMachine}, source); err == nil {
+		sourceOwner = source.Spec.OwnedBy
+	} else if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get WorkMachine %s: %v", backup.Spec.WorkMachine, err)
+	}
+	if sourceOwner == "" {
+		return fmt.Errorf("owner of backup %s is unknown", backup.Name)
+	}
+
+	targetName := restore.Spec.WorkMachine
+	if targetName == "" {
+		targetName = backup.Spec.WorkMachine
+	}
+	target, err := w.getWorkMachine(ctx, targetName)
+	if err != nil {
+		return err
+	}
+	if target.Spec.OwnedBy != sourceOwner {
+		return fmt.Errorf("backup %s of %s cannot be restored onto WorkMachine %s of %s", backup.Name, sourceOwner, target.Name, target.Spec.OwnedBy)
+	}
+
+	return w.checkRequester(ctx, userInfo, target)
+}
+
+// checkRequester denies requests of workspace ServiceAccounts for WorkMachines of other users
+// Other requesters are platform components and administrators, limited by RBAC.
+func (w *BackupWebhook) checkRequester(ctx context.Context, userInfo authv1.UserInfo, wm *machinesv1.WorkMachine) error {
+	ws, err := w.requestingWorkspace(ctx, userInfo.Username)
+	if err != nil || ws == nil {
+		return err
+	}
+	if ws.Spec.OwnedBy != wm.Spec.OwnedBy {
+		return fmt.Errorf("workspace %s/%s of %s cannot use backups of WorkMachine %s", ws.Namespace, ws.Name, ws.Spec.OwnedBy, wm.Name)
+	}
+	return nil
+}
+
+// requestingWorkspace returns the workspace whose ServiceAccount is username, nil for other users
+// Workspace ServiceAccounts are named after the workspace, in the namespace of the workspace.
+func (w *BackupWebhook) requestingWorkspace(ctx context.Context, username string) (*workspacesv1.Workspace, error) {
+	namespace, name, ok := strings.Cut(strings.TrimPrefix(username, serviceAccountUserPrefix), ":")
+	if !ok || !strings.HasPrefix(username, serviceAccountUserPrefix) {
+		return nil, nil
+	}
+
+	ws := &workspacesv1.Workspace{}
+	if err := w.k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, ws); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get workspace %s/%s: %v", namespace, name, err)
+	}
+	return ws, nil
+}
+
+func (w *BackupWebhook) getWorkMachine(ctx context.Context, name string) (*machinesv1.WorkMachine, error) {
+	wm := &machinesv1.WorkMachine{}
+	if err := w.k8sClient.Get(ctx, client.ObjectKey{Name: name}, wm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("WorkMachine %s not found", name)
+		}
+		return nil, fmt.Errorf("failed to get WorkMachine %s: %v", name, err)
+	}
+	return wm, nil
+}
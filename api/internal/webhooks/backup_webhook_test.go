@@ -0,0 +1,165 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	machinesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	workspacesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
+	"github.com/kloudlite/kloudlite/api/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newBackupTestWebhook(t *testing.T) *BackupWebhook {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	_ = machinesv1.AddToScheme(scheme)
+	_ = workspacesv1.AddToScheme(scheme)
+
+	objs := []client.Object{
+		&machinesv1.WorkMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "wm-alice"},
+			Spec:       machinesv1.WorkMachineSpec{OwnedBy: "alice"},
+		},
+		&machinesv1.WorkMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "wm-alice-2"},
+			Spec:       machinesv1.WorkMachineSpec{OwnedBy: "alice"},
+		},
+		&machinesv1.WorkMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "wm-bob"},
+			Spec:       machinesv1.WorkMachineSpec{OwnedBy: "bob"},
+		},
+		&workspacesv1.Workspace{
+			ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "wm-alice"},
+			Spec:       workspacesv1.WorkspaceSpec{OwnedBy: "alice"},
+		},
+		&machinesv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "wm-alice-20261019-080000"},
+			Spec:       machinesv1.BackupSpec{WorkMachine: "wm-alice"},
+		},
+		&machinesv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "wm-bob-20261019-080000"},
+			Spec:       machinesv1.BackupSpec{WorkMachine: "wm-bob"},
+		},
+		&machinesv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "wm-gone-20261019-080000",
+				Annotations: map[string]string{machinesv1.AnnotationBackupOwner: "alice"},
+			},
+			Spec: machinesv1.BackupSpec{WorkMachine: "wm-gone"},
+		},
+		&machinesv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "wm-unknown-20261019-080000"},
+			Spec:       machinesv1.BackupSpec{WorkMachine: "wm-unknown"},
+		},
+	}
+
+	k8sClient := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	zapLogger, _ := zap.NewDevelopment()
+	return NewBackupWebhook(logger.NewZapLogger(zapLogger), k8sClient)
+}
+
+// reviewBackup sends obj to the webhook handler as a CREATE by username
+func reviewBackup(t *testing.T, handler gin.HandlerFunc, obj any, username string) *admissionv1.AdmissionResponse {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	raw, _ := json.Marshal(obj)
+	body, _ := json.Marshal(admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+			UserInfo:  authv1.UserInfo{Username: username},
+		},
+	})
+	req, _ := http.NewRequest("POST", "/validate", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	router := gin.New()
+	router.POST("/validate", handler)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response admissionv1.AdmissionReview
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Response
+}
+
+func TestValidateBackup(t *testing.T) {
+	webhook := newBackupTestWebhook(t)
+
+	tests := []struct {
+		name        string
+		workMachine string
+		owner       string
+		username    string
+		allowed     bool
+	}{
+		{name: "workspace of the owner", workMachine: "wm-alice", username: "system:serviceaccount:wm-alice:dev", allowed: true},
+		{name: "workspace of another user", workMachine: "wm-bob", username: "system:serviceaccount:wm-alice:dev", allowed: false},
+		{name: "controller", workMachine: "wm-bob", username: "system:serviceaccount:kloudlite:api-server", allowed: true},
+		{name: "unknown WorkMachine", workMachine: "wm-nope", username: "system:serviceaccount:kloudlite:api-server", allowed: false},
+		{name: "spoofed owner", workMachine: "wm-bob", owner: "alice", username: "system:serviceaccount:kloudlite:api-server", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := &machinesv1.Backup{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup"},
+				Spec:       machinesv1.BackupSpec{WorkMachine: tt.workMachine},
+			}
+			if tt.owner != "" {
+				backup.Annotations = map[string]string{machinesv1.AnnotationBackupOwner: tt.owner}
+			}
+
+			response := reviewBackup(t, webhook.ValidateBackup, backup, tt.username)
+			assert.Equal(t, tt.allowed, response.Allowed, response.Result)
+		})
+	}
+}
+
+func TestValidateBackupRestore(t *testing.T) {
+	webhook := newBackupTestWebhook(t)
+
+	tests := []struct {
+		name        string
+		backup      string
+		workMachine string
+		username    string
+		allowed     bool
+	}{
+		{name: "own backup onto its WorkMachine", backup: "wm-alice-20261019-080000", username: "system:serviceaccount:wm-alice:dev", allowed: true},
+		{name: "own backup onto another own WorkMachine", backup: "wm-alice-20261019-080000", workMachine: "wm-alice-2", username: "system:serviceaccount:wm-alice:dev", allowed: true},
+		{name: "backup of another user", backup: "wm-bob-20261019-080000", username: "system:serviceaccount:wm-alice:dev", allowed: false},
+		{name: "own backup onto a WorkMachine of another user", backup: "wm-alice-20261019-080000", workMachine: "wm-bob", username: "system:serviceaccount:wm-alice:dev", allowed: false},
+		{name: "controller restoring onto another owner", backup: "wm-bob-20261019-080000", workMachine: "wm-alice", username: "system:serviceaccount:kloudlite:api-server", allowed: false},
+		{name: "backup of a deleted WorkMachine", backup: "wm-gone-20261019-080000", workMachine: "wm-alice", username: "system:serviceaccount:wm-alice:dev", allowed: true},
+		{name: "backup of unknown owner", backup: "wm-unknown-20261019-080000", workMachine: "wm-alice", username: "system:serviceaccount:kloudlite:api-server", allowed: false},
+		{name: "unknown backup", backup: "nope", username: "system:serviceaccount:wm-alice:dev", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := &machinesv1.BackupRestore{
+				ObjectMeta: metav1.ObjectMeta{Name: "restore"},
+				Spec:       machinesv1.BackupRestoreSpec{Backup: tt.backup, WorkMachine: tt.workMachine},
+			}
+
+			response := reviewBackup(t, webhook.ValidateBackupRestore, restore, tt.username)
+			assert.Equal(t, tt.allowed, response.Allowed, response.Result)
+		})
+	}
+}
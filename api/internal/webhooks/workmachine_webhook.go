@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kloudlite/kloudlite/api/internal/config"
 	environmentsv1 "github.com/kloudlite/kloudlite/api/internal/controllers/environment/v1"
+	"github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/cost"
 	machinesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
 	"github.com/kloudlite/kloudlite/api/pkg/logger"
 	fn "github.com/kloudlite/kloudlite/api/pkg/operator-toolkit/functions"
@@ -114,6 +117,28 @@ func (w *WorkMachineWebhook) handleValidation(
 		}
 	}
 
+	// Machines can only be provisioned by the controller of the installation
+	if err := w.validatePlacement(&machine, req); err != nil {
+		w.logger.Warn("WorkMachine placement validation failed: " + err.Error())
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+
+	// Machines of users over their budget can't be started
+	if err := w.validateBudget(&machine, req); err != nil {
+		w.logger.Warn("WorkMachine budget validation failed: " + err.Error())
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+
 	return &admissionv1.AdmissionResponse{
 		Allowed: true,
 	}
@@ -345,6 +370,30 @@ func (w *WorkMachineWebhook) validateWorkMachine(
 		}
 	}
 
+	// Validate node pools have unique names and existing, active machine types
+	poolNames := make(map[string]bool, len(machine.Spec.NodePools))
+	for _, pool := range machine.Spec.NodePools {
+		if poolNames[pool.Name] {
+			return fmt.Errorf("node pool %s is defined more than once", pool.Name)
+		}
+		poolNames[pool.Name] = true
+
+		machineType := &machinesv1.MachineType{}
+		if err := w.k8sClient.Get(ctx, client.ObjectKey{Name: pool.MachineType}, machineType); err != nil {
+			return fmt.Errorf("node pool %s: machine type %s not found", pool.Name, pool.MachineType)
+		}
+
+		if !machineType.Spec.Active {
+			return fmt.Errorf("node pool %s: machine type %s is not active", pool.Name, pool.MachineType)
+		}
+	}
+
+	if operation == admissionv1.Create || operation == admissionv1.Update {
+		if err := w.validateNodeNames(ctx, machine); err != nil {
+			return err
+		}
+	}
+
 	// On CREATE, prevent creating machine in stopped state (needs initial setup)
 	if operation == admissionv1.Create && machine.Spec.State == machinesv1.MachineStateStopped {
 		return fmt.Errorf("cannot create a WorkMachine in stopped state; machines must run initial setup on first start")
@@ -384,12 +433,99 @@ func (w *WorkMachineWebhook) validateWorkMachine(
 		}
 	}
 
-	// On DELETE, check if machine is running
+	// On DELETE, check if machine is running, warm machines are deleted by their pool once claimed or in excess
 	if operation == admissionv1.Delete {
-		if machine.Status.State == machinesv1.MachineStateRunning {
+		if machine.Status.State == machinesv1.MachineStateRunning && machine.Labels[machinesv1.LabelWarmPool] == "" {
 			return fmt.Errorf("cannot delete a running machine, please stop it first")
 		}
 	}
 
 	return nil
 }
+
+// validateNodeNames rejects WorkMachines whose Nodes, named after the machine and its node pools,
+// would take the name of a Node of another WorkMachine, e.g. the first node of pool "gpu" of "alice" and WorkMachine "alice-gpu-0"
+func (w *WorkMachineWebhook) validateNodeNames(ctx context.Context, machine *machinesv1.WorkMachine) error {
+	workMachineList := &machinesv1.WorkMachineList{}
+	if err := w.k8sClient.List(ctx, workMachineList); err != nil {
+		return fmt.Errorf("failed to list workmachines: %v", err)
+	}
+
+	nodeNames := machine.NodeNames()
+	for _, wm := range workMachineList.Items {
+		if wm.Name == machine.Name {
+			continue
+		}
+		for _, name := range wm.NodeNames() {
+			if slices.Contains(nodeNames, name) {
+				return fmt.Errorf("node %s is already a node of WorkMachine %s, rename the WorkMachine or its node pool", name, wm.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validatePlacement rejects placements the WorkMachine controller does not provision machines in,
+// and placement changes: the machine of a WorkMachine stays where it was created, a
+// WorkMachineMigration moves it to another placement
+func (w *WorkMachineWebhook) validatePlacement(machine *machinesv1.WorkMachine, req *admissionv1.AdmissionRequest) error {
+	switch req.Operation {
+	case admissionv1.Create:
+	case admissionv1.Update:
+		var oldMachine machinesv1.WorkMachine
+		if err := json.Unmarshal(req.OldObject.Raw, &oldMachine); err != nil {
+			return fmt.Errorf("failed to unmarshal old work machine object")
+		}
+		if oldMachine.Spec.Placement != machine.Spec.Placement {
+			return fmt.Errorf("placement cannot be changed, migrate the WorkMachine with a WorkMachineMigration instead")
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	if machine.Spec.Placement == w.config.WorkMachinePlacement || slices.Contains(w.config.WorkMachinePlacements, machine.Spec.Placement) {
+		return nil
+	}
+	available := append([]string{w.config.WorkMachinePlacement}, w.config.WorkMachinePlacements...)
+	return fmt.Errorf("placement %q is not available, machines are provisioned in %q", machine.Spec.Placement, available)
+}
+
+// validateBudget denies starting a machine while a budget of its owner is exceeded and stops machines
+func (w *WorkMachineWebhook) validateBudget(machine *machinesv1.WorkMachine, req *admissionv1.AdmissionRequest) error {
+	if machine.Spec.State != machinesv1.MachineStateRunning {
+		return nil
+	}
+
+	switch req.Operation {
+	case admissionv1.Create:
+	case admissionv1.Update:
+		var oldMachine machinesv1.WorkMachine
+		if err := json.Unmarshal(req.OldObject.Raw, &oldMachine); err != nil {
+			return fmt.Errorf("failed to unmarshal old work machine object")
+		}
+		if oldMachine.Spec.State == machinesv1.MachineStateRunning {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	budgets := &machinesv1.BudgetList{}
+	if err := w.k8sClient.List(context.Background(), budgets); err != nil {
+		return fmt.Errorf("failed to list budgets: %v", err)
+	}
+
+	period := cost.Period(time.Now())
+	for _, budget := range budgets.Items {
+		if !budget.Spec.StopOnExceeded || budget.Status.Phase != machinesv1.BudgetPhaseExceeded || budget.Status.Period != period {
+			continue
+		}
+		if slices.Contains(budget.Spec.Users, machine.Spec.OwnedBy) {
+			return fmt.Errorf("cannot start the machine, the monthly budget %s is exceeded (%s of %s %s spent)",
+				budget.Name, budget.Status.Spent, budget.Spec.MonthlyLimit, budget.Status.Currency)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kloudlite/kloudlite/api/internal/config"
+	machinesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWorkMachineValidatePlacement(t *testing.T) {
+	webhook := &WorkMachineWebhook{config: &config.Config{
+		WorkMachinePlacement:  "aws-us-east-1",
+		WorkMachinePlacements: []string{"gcp-europe-west1"},
+	}}
+
+	tests := []struct {
+		name         string
+		operation    admissionv1.Operation
+		oldPlacement string
+		placement    string
+		wantErr      bool
+	}{
+		{name: "create in the placement of the installation", operation: admissionv1.Create, placement: "aws-us-east-1"},
+		{name: "create in another placement of the installation", operation: admissionv1.Create, placement: "gcp-europe-west1"},
+		{name: "create in an unknown placement", operation: admissionv1.Create, placement: "azure-westeurope", wantErr: true},
+		{name: "create without placement", operation: admissionv1.Create, wantErr: true},
+		{name: "move to another placement", operation: admissionv1.Update, oldPlacement: "aws-us-east-1", placement: "gcp-europe-west1", wantErr: true},
+		{name: "update of a machine created before", operation: admissionv1.Update, oldPlacement: "azure-westeurope", placement: "azure-westeurope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := &machinesv1.WorkMachine{Spec: machinesv1.WorkMachineSpec{Placement: tt.placement}}
+			oldRaw, _ := json.Marshal(&machinesv1.WorkMachine{Spec: machinesv1.WorkMachineSpec{Placement: tt.oldPlacement}})
+
+			err := webhook.validatePlacement(machine, &admissionv1.AdmissionRequest{
+				Operation: tt.operation,
+				OldObject: runtime.RawExtension{Raw: oldRaw},
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWorkMachineValidateNodeNames(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = machinesv1.AddToScheme(scheme)
+
+	alice := &machinesv1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice"},
+		Spec: machinesv1.WorkMachineSpec{NodePools: []machinesv1.NodePool{
+			{Name: "gpu", MachineType: "g4dn.xlarge", Nodes: 2},
+		}},
+	}
+	bob := &machinesv1.WorkMachine{ObjectMeta: metav1.ObjectMeta{Name: "bob-gpu-0"}}
+	carol := &machinesv1.WorkMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "carol-dev"},
+		Spec: machinesv1.WorkMachineSpec{NodePools: []machinesv1.NodePool{
+			{Name: "gpu", MachineType: "g4dn.xlarge", Nodes: 1},
+		}},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(alice, bob, carol).Build()
+	webhook := &WorkMachineWebhook{k8sClient: k8sClient}
+
+	tests := []struct {
+		name    string
+		machine *machinesv1.WorkMachine
+		wantErr bool
+	}{
+		{
+			name:    "machine named after a pool node of another machine",
+			machine: &machinesv1.WorkMachine{ObjectMeta: metav1.ObjectMeta{Name: "alice-gpu-1"}},
+			wantErr: true,
+		},
+		{
+			name: "pool node named after another machine",
+			machine: &machinesv1.WorkMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "bob"},
+				Spec:       machinesv1.WorkMachineSpec{NodePools: []machinesv1.NodePool{{Name: "gpu", MachineType: "g4dn.xlarge", Nodes: 1}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "pool node named after a pool node of another machine",
+			machine: &machinesv1.WorkMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "carol"},
+				Spec:       machinesv1.WorkMachineSpec{NodePools: []machinesv1.NodePool{{Name: "dev-gpu", MachineType: "g4dn.xlarge", Nodes: 1}}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "machine named after a node beyond the pool size",
+			machine: &machinesv1.WorkMachine{ObjectMeta: metav1.ObjectMeta{Name: "alice-gpu-2"}},
+		},
+		{
+			name: "update of the machine itself",
+			machine: &machinesv1.WorkMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "alice"},
+				Spec:       machinesv1.WorkMachineSpec{NodePools: []machinesv1.NodePool{{Name: "gpu", MachineType: "g4dn.xlarge", Nodes: 3}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := webhook.validateNodeNames(context.Background(), tt.machine)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"path"
 	"regexp"
 	"strings"
 
@@ -13,9 +14,11 @@ import (
 	snapshotv1 "github.com/kloudlite/kloudlite/api/internal/controllers/snapshot/v1"
 	platformv1alpha1 "github.com/kloudlite/kloudlite/api/internal/controllers/user/v1alpha1"
 	machinesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workmachine/v1"
+	wstemplate "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/template"
 	workspacesv1 "github.com/kloudlite/kloudlite/api/internal/controllers/workspace/v1"
 	"github.com/kloudlite/kloudlite/api/pkg/logger"
 	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -235,6 +238,19 @@ func (w *WorkspaceWebhook) handleMutation(req *admissionv1.AdmissionRequest) *ad
 		}
 	}
 
+	// Resolve the workspace template, merging it into the spec
+	templatePatches, err := w.resolveWorkspaceTemplate(ctx, req.Object.Raw, &workspace)
+	if err != nil {
+		w.logger.Error("Failed to resolve workspace template: " + err.Error())
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+	patches = append(patches, templatePatches...)
+
 	// Convert patches to JSON
 	patchBytes, err := json.Marshal(patches)
 	if err != nil {
@@ -256,6 +272,104 @@ func (w *WorkspaceWebhook) handleMutation(req *admissionv1.AdmissionRequest) *ad
 	}
 }
 
+// resolveWorkspaceTemplate returns the patches applying spec.templateRef to a workspace
+// The template is applied when the workspace is created and whenever templateRef.revision is
+// cleared (`kl template rebase`). Values the user changed since the last applied revision are kept.
+func (w *WorkspaceWebhook) resolveWorkspaceTemplate(ctx context.Context, raw []byte, workspace *workspacesv1.Workspace) ([]map[string]interface{}, error) {
+	ref := workspace.Spec.TemplateRef
+	if ref == nil {
+		// Template was detached, stop reporting the workspace as based on it
+		if _, ok := workspace.Labels[workspacesv1.WorkspaceTemplateLabel]; ok {
+			return []map[string]interface{}{
+				{
+					"op":   "remove",
+					"path": "/metadata/labels/kloudlite.io~1workspace-template",
+				},
+			}, nil
+		}
+		return nil, nil
+	}
+
+	applied, err := wstemplate.DecodeApplied(workspace.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Revision != "" && applied != nil {
+		return nil, nil
+	}
+
+	var tmpl workspacesv1.WorkspaceTemplate
+	if err := w.k8sClient.Get(ctx, client.ObjectKey{Name: ref.Name}, &tmpl); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("workspace template %q not found", ref.Name)
+		}
+		return nil, fmt.Errorf("failed to get workspace template %q: %w", ref.Name, err)
+	}
+
+	var object struct {
+		Spec map[string]interface{} `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, fmt.Errorf("failed to decode workspace spec: %w", err)
+	}
+
+	var base *workspacesv1.WorkspaceTemplateSpec
+	if applied != nil {
+		base = &applied.Spec
+	}
+	spec, err := wstemplate.Merge(object.Spec, &tmpl.Spec, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge workspace template %q: %w", ref.Name, err)
+	}
+
+	annotation, err := wstemplate.EncodeApplied(&tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record workspace template %q: %w", ref.Name, err)
+	}
+
+	// Patch only the fields a template provides, so the other spec defaults still apply
+	var patches []map[string]interface{}
+	for _, field := range wstemplate.Fields {
+		if value, ok := spec[field]; ok {
+			patches = append(patches, map[string]interface{}{
+				"op":    "add",
+				"path":  "/spec/" + field,
+				"value": value,
+			})
+		} else if _, ok := object.Spec[field]; ok {
+			patches = append(patches, map[string]interface{}{
+				"op":   "remove",
+				"path": "/spec/" + field,
+			})
+		}
+	}
+	patches = append(patches, map[string]interface{}{
+		"op":    "add",
+		"path":  "/spec/templateRef",
+		"value": workspacesv1.TemplateRef{Name: tmpl.Name, Revision: wstemplate.Revision(&tmpl.Spec)},
+	})
+	if workspace.Annotations == nil {
+		patches = append(patches, map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/annotations",
+			"value": map[string]string{},
+		})
+	}
+	patches = append(patches,
+		map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/annotations/workspaces.kloudlite.io~1template-applied",
+			"value": annotation,
+		},
+		map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/labels/kloudlite.io~1workspace-template",
+			"value": tmpl.Name,
+		},
+	)
+	return patches, nil
+}
+
 func (w *WorkspaceWebhook) validateWorkspace(workspace *workspacesv1.Workspace, operation admissionv1.Operation) error {
 	ctx := context.Background()
 
@@ -337,6 +451,11 @@ func (w *WorkspaceWebhook) validateWorkspace(workspace *workspacesv1.Workspace,
 		}
 	}
 
+	// Validate git repositories if specified
+	if err := validateGitRepositories(workspace); err != nil {
+		return fmt.Errorf("invalid git repositories: %w", err)
+	}
+
 	// Validate state transitions on UPDATE
 	if operation == admissionv1.Update {
 		// For updates, we would need the old object to validate transitions
@@ -451,6 +570,98 @@ func validateWorkspaceSettings(settings *workspacesv1.WorkspaceSettings) error {
 		}
 	}
 
+	// Validate envFrom sources
+	envPrefixRegex := regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	for i, source := range settings.EnvFrom {
+		if source.Type != "Secret" && source.Type != "ConfigMap" {
+			return fmt.Errorf("envFrom[%d].type must be Secret or ConfigMap", i)
+		}
+		if source.Name == "" {
+			return fmt.Errorf("envFrom[%d].name is required", i)
+		}
+		if source.Prefix != "" && !envPrefixRegex.MatchString(source.Prefix) {
+			return fmt.Errorf("envFrom[%d].prefix must be a valid environment variable name prefix", i)
+		}
+	}
+
+	// Validate secret files, which are linked into the kl home directory
+	secretPaths := map[string]bool{}
+	for i, file := range settings.SecretFiles {
+		p := strings.TrimPrefix(file.Path, "~/")
+		if p == "" || strings.HasPrefix(p, "/") {
+			return fmt.Errorf("secretFiles[%d].path must be relative to the home directory", i)
+		}
+		for _, segment := range strings.Split(p, "/") {
+			if segment == ".." {
+				return fmt.Errorf("secretFiles[%d].path must not contain '..'", i)
+			}
+		}
+		cleaned := path.Clean(p)
+		if cleaned == "." || strings.HasPrefix(cleaned, "workspaces/") || cleaned == "workspaces" {
+			return fmt.Errorf("secretFiles[%d].path must point to a file outside the workspaces folder", i)
+		}
+		if secretPaths[cleaned] {
+			return fmt.Errorf("secretFiles[%d].path %q is used more than once", i, file.Path)
+		}
+		secretPaths[cleaned] = true
+		if file.SecretName == "" || file.Key == "" {
+			return fmt.Errorf("secretFiles[%d] must set secretName and key", i)
+		}
+	}
+
+	return nil
+}
+
+// validateGitRepositories validates repository URLs, branches and clone paths
+// Repositories are cloned into the workspace folder, so paths must be relative and unique
+func validateGitRepositories(workspace *workspacesv1.Workspace) error {
+	var repos []workspacesv1.GitRepository
+	if workspace.Spec.GitRepository != nil && workspace.Spec.GitRepository.URL != "" {
+		repos = append(repos, *workspace.Spec.GitRepository)
+	}
+	repos = append(repos, workspace.Spec.GitRepositories...)
+
+	branchRegex := regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+	paths := map[string]bool{}
+	for _, repo := range repos {
+		if !strings.HasPrefix(repo.URL, "http://") &&
+			!strings.HasPrefix(repo.URL, "https://") &&
+			!strings.HasPrefix(repo.URL, "ssh://") &&
+			!strings.HasPrefix(repo.URL, "git@") {
+			return fmt.Errorf("url %q must be a valid git repository URL", repo.URL)
+		}
+
+		if repo.Branch != "" && (strings.HasPrefix(repo.Branch, "-") || strings.Contains(repo.Branch, "..") || !branchRegex.MatchString(repo.Branch)) {
+			return fmt.Errorf("branch %q is not a valid branch name", repo.Branch)
+		}
+
+		if strings.HasPrefix(repo.Path, "/") {
+			return fmt.Errorf("path %q must be relative to the workspace folder", repo.Path)
+		}
+		cleaned := strings.Trim(path.Clean("/"+repo.Path), "/")
+		for _, segment := range strings.Split(repo.Path, "/") {
+			if segment == ".." {
+				return fmt.Errorf("path %q must not contain '..'", repo.Path)
+			}
+		}
+		if paths[cleaned] {
+			if cleaned == "" {
+				return fmt.Errorf("only one repository can be cloned into the workspace folder, set a path for the others")
+			}
+			return fmt.Errorf("path %q is used by more than one repository", repo.Path)
+		}
+		paths[cleaned] = true
+
+		if repo.Depth < 0 {
+			return fmt.Errorf("depth for %q must not be negative", repo.URL)
+		}
+		for _, p := range repo.SparseCheckout {
+			if p == "" || strings.HasPrefix(p, "-") {
+				return fmt.Errorf("sparseCheckout entry %q for %q is not a valid path", p, repo.URL)
+			}
+		}
+	}
+
 	return nil
 }
 
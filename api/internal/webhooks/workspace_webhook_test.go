@@ -2,6 +2,7 @@ package webhooks
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -167,6 +168,10 @@ func TestValidateWorkspaceSettings_Invalid(t *testing.T) {
 		{"max-runtime-too-high", &workspacesv1.WorkspaceSettings{MaxRuntime: 43201}, "maxRuntime must be between 0 and 43200"},
 		{"dotfiles-invalid-url", &workspacesv1.WorkspaceSettings{DotfilesRepo: "ftp://example.com"}, "valid git repository URL"},
 		{"git-config-invalid-email", &workspacesv1.WorkspaceSettings{GitConfig: &workspacesv1.GitConfig{UserEmail: "notanemail"}}, "valid email address"},
+		{"env-from-invalid-type", &workspacesv1.WorkspaceSettings{EnvFrom: []workspacesv1.EnvFromSource{{Type: "Pod", Name: "p"}}}, "must be Secret or ConfigMap"},
+		{"env-from-invalid-prefix", &workspacesv1.WorkspaceSettings{EnvFrom: []workspacesv1.EnvFromSource{{Type: "Secret", Name: "s", Prefix: "1-"}}}, "valid environment variable name prefix"},
+		{"secret-file-absolute", &workspacesv1.WorkspaceSettings{SecretFiles: []workspacesv1.SecretFile{{Path: "/etc/passwd", SecretName: "s", Key: "k"}}}, "relative to the home directory"},
+		{"secret-file-parent", &workspacesv1.WorkspaceSettings{SecretFiles: []workspacesv1.SecretFile{{Path: "~/../root/.ssh/key", SecretName: "s", Key: "k"}}}, "must not contain '..'"},
 	}
 
 	for _, tt := range tests {
@@ -178,6 +183,41 @@ func TestValidateWorkspaceSettings_Invalid(t *testing.T) {
 	}
 }
 
+func TestValidateGitRepositories(t *testing.T) {
+	valid := &workspacesv1.Workspace{
+		Spec: workspacesv1.WorkspaceSpec{
+			GitRepository: &workspacesv1.GitRepository{URL: "https://github.com/org/app.git", Branch: "feature/x"},
+			GitRepositories: []workspacesv1.GitRepository{
+				{URL: "git@github.com:org/lib.git", Path: "libs/lib", Depth: 1},
+				{URL: "https://github.com/org/mono.git", Path: "mono", Filter: "blob:none", SparseCheckout: []string{"services/api"}},
+			},
+		},
+	}
+	assert.NoError(t, validateGitRepositories(valid))
+
+	tests := []struct {
+		name  string
+		repos []workspacesv1.GitRepository
+		error string
+	}{
+		{"invalid-url", []workspacesv1.GitRepository{{URL: "ftp://example.com/repo"}}, "valid git repository URL"},
+		{"option-branch", []workspacesv1.GitRepository{{URL: "https://github.com/org/a", Branch: "--upload-pack=x"}}, "not a valid branch name"},
+		{"absolute-path", []workspacesv1.GitRepository{{URL: "https://github.com/org/a", Path: "/etc"}}, "must be relative"},
+		{"parent-path", []workspacesv1.GitRepository{{URL: "https://github.com/org/a", Path: "a/../../b"}}, "must not contain '..'"},
+		{"duplicate-path", []workspacesv1.GitRepository{{URL: "https://github.com/org/a", Path: "a"}, {URL: "https://github.com/org/b", Path: "a/"}}, "used by more than one repository"},
+		{"duplicate-root", []workspacesv1.GitRepository{{URL: "https://github.com/org/a"}, {URL: "https://github.com/org/b"}}, "only one repository"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := &workspacesv1.Workspace{Spec: workspacesv1.WorkspaceSpec{GitRepositories: tt.repos}}
+			err := validateGitRepositories(ws)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.error)
+		})
+	}
+}
+
 // Test Validation - Valid Workspace
 func TestWorkspaceWebhook_ValidateWorkspace_Success(t *testing.T) {
 	user := &platformv1alpha1.User{
@@ -553,6 +593,52 @@ func TestWorkspaceWebhook_MutateWorkspace_DefaultValues(t *testing.T) {
 	assert.True(t, foundVSCodeVersion)
 }
 
+// Test Mutation - Template resolution
+func TestWorkspaceWebhook_MutateWorkspace_TemplateRef(t *testing.T) {
+	tmpl := &workspacesv1.WorkspaceTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-service"},
+		Spec: workspacesv1.WorkspaceTemplateSpec{
+			DisplayName: "Go service",
+			Settings:    &workspacesv1.WorkspaceSettings{DotfilesRepo: "https://github.com/org/dotfiles"},
+			Expose:      []workspacesv1.ExposedPort{{Port: 8080}},
+		},
+	}
+	webhook := setupWorkspaceWebhookTest(t, tmpl)
+
+	workspace := &workspacesv1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-workspace"},
+		Spec: workspacesv1.WorkspaceSpec{
+			OwnedBy:     "testuser",
+			DisplayName: "Test",
+			Expose:      []workspacesv1.ExposedPort{{Port: 3000}},
+			TemplateRef: &workspacesv1.TemplateRef{Name: "go-service"},
+		},
+	}
+
+	workspaceBytes, _ := json.Marshal(workspace)
+	patches, err := webhook.resolveWorkspaceTemplate(context.Background(), workspaceBytes, workspace)
+	assert.NoError(t, err)
+
+	byPath := map[string]interface{}{}
+	for _, patch := range patches {
+		byPath[patch["path"].(string)] = patch["value"]
+	}
+
+	// Values set on the workspace win over the template
+	assert.Equal(t, []interface{}{map[string]interface{}{"port": float64(3000)}}, byPath["/spec/expose"])
+	assert.Equal(t, map[string]interface{}{"dotfilesRepo": "https://github.com/org/dotfiles"}, byPath["/spec/settings"])
+	assert.Equal(t, "go-service", byPath["/metadata/labels/kloudlite.io~1workspace-template"])
+	assert.Contains(t, byPath, "/metadata/annotations/workspaces.kloudlite.io~1template-applied")
+
+	ref := byPath["/spec/templateRef"].(workspacesv1.TemplateRef)
+	assert.NotEmpty(t, ref.Revision)
+
+	// Missing templates are rejected
+	workspace.Spec.TemplateRef = &workspacesv1.TemplateRef{Name: "missing"}
+	_, err = webhook.resolveWorkspaceTemplate(context.Background(), workspaceBytes, workspace)
+	assert.ErrorContains(t, err, "not found")
+}
+
 // Test Mutation - Labels and Finalizer
 func TestWorkspaceWebhook_MutateWorkspace_LabelsAndFinalizer(t *testing.T) {
 	user := &platformv1alpha1.User{
@@ -0,0 +1,179 @@
+// Package backup stores WorkMachine backups in an S3 compatible bucket (AWS S3, MinIO, ...)
+//
+// Objects are laid out as <prefix>/<workmachine>/<backup>/<volume path>.btrfs, each object being a
+// btrfs send stream, full or incremental to the same volume of an earlier backup.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// PartSize is the size of the parts of a multipart upload, streams are uploaded without being
+// buffered on disk, so a volume of up to PartSize*10000 bytes can be backed up
+const PartSize = 64 << 20
+
+// StoreConfig is the configuration of the bucket backups are stored in
+type StoreConfig struct {
+	// Endpoint of an S3 compatible service, empty for AWS S3
+	Endpoint string
+	Region   string
+	Bucket   string
+	Prefix   string
+
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Store reads and writes backup streams in the bucket
+type Store struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// NewStore creates a store for the bucket of cfg
+func NewStore(cfg StoreConfig) (*Store, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("backup bucket is not configured")
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	client := s3.New(s3.Options{
+		Region:      region,
+		Credentials: credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+	}, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			// MinIO and most S3 compatible services only support path style addressing
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &Store{client: client, bucket: cfg.Bucket, prefix: cfg.Prefix}, nil
+}
+
+// ObjectKey returns the key of the stream of a volume in a backup
+func (s *Store) ObjectKey(workMachine, backupName, volumePath string) string {
+	return path.Join(s.prefix, workMachine, backupName, volumePath+".btrfs")
+}
+
+// Upload streams r to key and returns the number of bytes written
+func (s *Store) Upload(ctx context.Context, key string, r io.Reader) (int64, error) {
+	buf := make([]byte, PartSize)
+
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	// Streams smaller than a part are uploaded in a single request
+	if n < PartSize {
+		if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:        aws.String(s.bucket),
+			Key:           aws.String(key),
+			Body:          bytes.NewReader(buf[:n]),
+			ContentLength: aws.Int64(int64(n)),
+		}); err != nil {
+			return 0, fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+		return int64(n), nil
+	}
+
+	upload, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to start upload of %s: %w", key, err)
+	}
+
+	total, err := s.uploadParts(ctx, key, upload.UploadId, buf, n, r)
+	if err != nil {
+		// Parts of an aborted upload are not billed
+		_, _ = s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(key),
+			UploadId: upload.UploadId,
+		})
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (s *Store) uploadParts(ctx context.Context, key string, uploadID *string, buf []byte, n int, r io.Reader) (int64, error) {
+	var parts []s3Types.CompletedPart
+	var total int64
+
+	for partNumber := int32(1); n > 0; partNumber++ {
+		part, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(s.bucket),
+			Key:           aws.String(key),
+			UploadId:      uploadID,
+			PartNumber:    aws.Int32(partNumber),
+			Body:          bytes.NewReader(buf[:n]),
+			ContentLength: aws.Int64(int64(n)),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to upload part %d of %s: %w", partNumber, key, err)
+		}
+
+		parts = append(parts, s3Types.CompletedPart{ETag: part.ETag, PartNumber: aws.Int32(partNumber)})
+		total += int64(n)
+
+		n, err = io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("failed to read stream: %w", err)
+		}
+	}
+
+	if _, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &s3Types.CompletedMultipartUpload{Parts: parts},
+	}); err != nil {
+		return 0, fmt.Errorf("failed to complete upload of %s: %w", key, err)
+	}
+
+	return total, nil
+}
+
+// Download returns the stream stored at key, the caller closes it
+func (s *Store) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return out.Body, nil
+}
+
+// Delete removes the streams at keys, missing keys are ignored
+func (s *Store) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(key),
+		}); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,507 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/quic-go/quicvarint"
+	"go.uber.org/zap"
+)
+
+// CONNECT-UDP (RFC 9298) over HTTP/3 carries each UDP session as the HTTP datagrams (RFC 9297) of
+// an extended CONNECT request stream, so that a lost packet does not hold up the others as it does
+// over WebSocket. Datagrams too large for the QUIC path are sent as DATAGRAM capsules on the
+// request stream instead.
+const (
+	// ConnectUDPPath is the path of CONNECT-UDP requests, followed by {target_host}/{target_port}/
+	ConnectUDPPath = "/.well-known/masque/udp/"
+
+	// TunnelErrorHeader carries why the server refused a CONNECT-UDP request
+	TunnelErrorHeader = "Tunnel-Error"
+
+	connectUDPProtocol = "connect-udp"
+
+	// capsuleDatagram is the type of DATAGRAM capsules (RFC 9297)
+	capsuleDatagram http3.CapsuleType = 0x00
+
+	// quicBacklog bounds the datagrams received and not read yet
+	quicBacklog = 256
+)
+
+// RefusedError is returned when the server answers a CONNECT-UDP request with an error status
+type RefusedError struct {
+	StatusCode int
+	Reason     string // Value of the Tunnel-Error header, empty when the server did not set it
+}
+
+// Error implements error
+func (e *RefusedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("CONNECT-UDP refused with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("CONNECT-UDP refused with status %d: %s", e.StatusCode, e.Reason)
+}
+
+// quicConfig returns the QUIC configuration of both sides
+func quicConfig(config *Config) *quic.Config {
+	keepAlive := config.PingInterval
+	if keepAlive == 0 {
+		keepAlive = 15 * time.Second
+	}
+	return &quic.Config{
+		EnableDatagrams: true,
+		KeepAlivePeriod: keepAlive,
+		MaxIdleTimeout:  keepAlive + config.PongTimeout,
+	}
+}
+
+// h3DatagramStream is a CONNECT-UDP request stream, of the client (http3.RequestStream) or the
+// server (http3.Stream)
+type h3DatagramStream interface {
+	io.ReadWriteCloser
+	CancelRead(quic.StreamErrorCode)
+	SendDatagram(b []byte) error
+	ReceiveDatagram(ctx context.Context) ([]byte, error)
+}
+
+// quicDatagramConn implements DatagramConn on a CONNECT-UDP request stream
+type quicDatagramConn struct {
+	stream   h3DatagramStream
+	incoming chan []byte
+
+	writeMu   sync.Mutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+// newQUICDatagramConn starts receiving the datagrams and the DATAGRAM capsules of a stream
+func newQUICDatagramConn(stream h3DatagramStream) *quicDatagramConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &quicDatagramConn{
+		stream:   stream,
+		incoming: make(chan []byte, quicBacklog),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	go c.receiveDatagrams()
+	go c.receiveCapsules()
+	return c
+}
+
+func (c *quicDatagramConn) receiveDatagrams() {
+	for {
+		b, err := c.stream.ReceiveDatagram(c.ctx)
+		if err != nil {
+			c.Close()
+			return
+		}
+		c.queue(b)
+	}
+}
+
+func (c *quicDatagramConn) receiveCapsules() {
+	reader := quicvarint.NewReader(c.stream)
+	for {
+		capsuleType, r, err := http3.ParseCapsule(reader)
+		if err != nil {
+			c.Close()
+			return
+		}
+
+		value, err := io.ReadAll(r)
+		if err != nil {
+			c.Close()
+			return
+		}
+		// Other capsules are ignored, as RFC 9297 requires
+		if capsuleType == capsuleDatagram {
+			c.queue(value)
+		}
+	}
+}
+
+// queue queues the UDP payload of a datagram with context ID 0, dropping it when the reader is behind
+func (c *quicDatagramConn) queue(b []byte) {
+	contextID, n, err := quicvarint.Parse(b)
+	if err != nil || contextID != 0 {
+		return
+	}
+
+	select {
+	case c.incoming <- b[n:]:
+	default:
+	}
+}
+
+// SendDatagram sends a UDP payload, as a DATAGRAM capsule when it does not fit a QUIC datagram
+func (c *quicDatagramConn) SendDatagram(p []byte) error {
+	b := make([]byte, 0, 1+len(p))
+	b = quicvarint.Append(b, 0) // Context ID of UDP payloads
+	b = append(b, p...)
+
+	err := c.stream.SendDatagram(b)
+	var tooLarge *quic.DatagramTooLargeError
+	if !errors.As(err, &tooLarge) {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return http3.WriteCapsule(quicvarint.NewWriter(c.stream), capsuleDatagram, b)
+}
+
+// ReceiveDatagram returns the next UDP payload
+func (c *quicDatagramConn) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	select {
+	case b := <-c.incoming:
+		return b, nil
+	case <-c.ctx.Done():
+		return nil, io.EOF
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close closes the request stream
+func (c *quicDatagramConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.cancel()
+		c.stream.CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+		err = c.stream.Close()
+	})
+	return err
+}
+
+// QUICDialer implements DatagramDialer with CONNECT-UDP over HTTP/3
+//
+// The sessions to a server share one QUIC connection, dialed again once it closes.
+type QUICDialer struct {
+	config    *Config
+	tlsConfig *tls.Config
+	headers   http.Header
+	logger    *zap.Logger
+
+	mu    sync.Mutex
+	conns map[string]*http3.ClientConn // By server authority
+}
+
+// NewQUICDialer creates a new CONNECT-UDP dialer, sending headers (e.g., Authorization) with every request
+func NewQUICDialer(config *Config, tlsConfig *tls.Config, headers http.Header, logger *zap.Logger) *QUICDialer {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS13}
+	}
+	return &QUICDialer{
+		config:    config,
+		tlsConfig: tlsConfig,
+		headers:   headers,
+		logger:    logger,
+		conns:     make(map[string]*http3.ClientConn),
+	}
+}
+
+// DialUDP opens a UDP session to target through the server of serverURL (e.g., wss://host/ws,
+// only its host is used)
+func (d *QUICDialer) DialUDP(ctx context.Context, serverURL, target string) (DatagramConn, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q", serverURL)
+	}
+	authority := u.Host
+	if u.Port() == "" {
+		authority = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target %q: %w", target, err)
+	}
+
+	client, err := d.clientConn(ctx, authority)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := client.OpenRequestStream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open request stream: %w", err)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		Proto:  connectUDPProtocol,
+		Host:   u.Host,
+		Header: d.headers.Clone(),
+		URL:    &url.URL{Scheme: "https", Host: u.Host, Path: ConnectUDPPath + url.PathEscape(host) + "/" + port + "/"},
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set(http3.CapsuleProtocolHeader, "?1")
+
+	if err := stream.SendRequestHeader(req); err != nil {
+		stream.Close()
+		return nil, fmt.Errorf("failed to send CONNECT-UDP request: %w", err)
+	}
+
+	resp, err := stream.ReadResponse()
+	if err != nil {
+		stream.Close()
+		return nil, fmt.Errorf("failed to read CONNECT-UDP response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		stream.CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+		stream.Close()
+		return nil, &RefusedError{StatusCode: resp.StatusCode, Reason: resp.Header.Get(TunnelErrorHeader)}
+	}
+
+	return newQUICDatagramConn(stream), nil
+}
+
+// clientConn returns the HTTP/3 connection to a server, dialing it when there is none (or it closed)
+func (d *QUICDialer) clientConn(ctx context.Context, authority string) (*http3.ClientConn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if client := d.conns[authority]; client != nil && client.Context().Err() == nil {
+		return client, nil
+	}
+
+	tlsConfig := d.tlsConfig.Clone()
+	tlsConfig.NextProtos = []string{http3.NextProtoH3}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName, _, _ = net.SplitHostPort(authority)
+	}
+
+	conn, err := quic.DialAddr(ctx, authority, tlsConfig, quicConfig(d.config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial QUIC: %w", err)
+	}
+
+	h3 := &http3.Transport{EnableDatagrams: true}
+	client := h3.NewClientConn(conn)
+
+	select {
+	case <-client.ReceivedSettings():
+	case <-ctx.Done():
+		conn.CloseWithError(0, "")
+		return nil, ctx.Err()
+	}
+	if settings := client.Settings(); !settings.EnableDatagrams || !settings.EnableExtendedConnect {
+		conn.CloseWithError(0, "")
+		return nil, errors.New("server does not support HTTP/3 datagrams and extended CONNECT")
+	}
+
+	d.conns[authority] = client
+	d.logger.Debug("QUIC connection established", zap.String("server", authority))
+	return client, nil
+}
+
+// DatagramRequest is a CONNECT-UDP request waiting for the server to accept or refuse it
+type DatagramRequest struct {
+	target   string
+	identity string
+	writer   http.ResponseWriter
+	streamer http3.HTTPStreamer
+	decided  chan struct{}
+}
+
+// Target returns the requested UDP destination (host:port)
+func (r *DatagramRequest) Target() string {
+	return r.target
+}
+
+// Identity returns the identity of the client, empty when unknown
+func (r *DatagramRequest) Identity() string {
+	return r.identity
+}
+
+// Accept answers the request and returns its session
+func (r *DatagramRequest) Accept() DatagramConn {
+	defer close(r.decided)
+
+	r.writer.Header().Set(http3.CapsuleProtocolHeader, "?1")
+	r.writer.WriteHeader(http.StatusOK)
+	return newQUICDatagramConn(r.streamer.HTTPStream())
+}
+
+// Reject refuses the request with an HTTP status and the reason sent in the Tunnel-Error header
+func (r *DatagramRequest) Reject(statusCode int, reason string) {
+	defer close(r.decided)
+
+	r.writer.Header().Set(TunnelErrorHeader, reason)
+	r.writer.WriteHeader(statusCode)
+}
+
+// QUICListener implements DatagramListener with an HTTP/3 server
+//
+// The server serves the handler of the TLS listener, so that CONNECT-UDP requests go through the
+// same middlewares (e.g., authentication) as WebSocket upgrades. The handler must route
+// ConnectUDPPath to GetConnectUDPHandler.
+type QUICListener struct {
+	server       *http3.Server
+	conn         net.PacketConn
+	logger       *zap.Logger
+	identityFunc IdentityFunc
+	acceptCh     chan *DatagramRequest
+	closeCh      chan struct{}
+	closeOnce    sync.Once
+}
+
+// NewQUICListener listens on the UDP address addr and serves handler over HTTP/3
+func NewQUICListener(addr string, tlsConfig *tls.Config, handler http.Handler, config *Config, logger *zap.Logger) (*QUICListener, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	l := &QUICListener{
+		conn:     conn,
+		logger:   logger,
+		acceptCh: make(chan *DatagramRequest),
+		closeCh:  make(chan struct{}),
+	}
+	l.server = &http3.Server{
+		TLSConfig:       http3.ConfigureTLSConfig(tlsConfig.Clone()),
+		QUICConfig:      quicConfig(config),
+		Handler:         handler,
+		EnableDatagrams: true,
+	}
+
+	go func() {
+		if err := l.server.Serve(conn); err != nil && !l.isClosed() {
+			logger.Error("HTTP/3 server error", zap.Error(err))
+		}
+	}()
+
+	logger.Info("QUIC listener started", zap.String("addr", conn.LocalAddr().String()))
+	return l, nil
+}
+
+// SetIdentityFunc sets how the identity of the clients of CONNECT-UDP requests is determined,
+// must be called before requests are accepted
+func (l *QUICListener) SetIdentityFunc(fn IdentityFunc) {
+	l.identityFunc = fn
+}
+
+// GetConnectUDPHandler returns the handler of CONNECT-UDP requests
+func (l *QUICListener) GetConnectUDPHandler() http.HandlerFunc {
+	return l.handleConnectUDP
+}
+
+func (l *QUICListener) handleConnectUDP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect || r.Proto != connectUDPProtocol {
+		http.Error(w, "CONNECT-UDP over HTTP/3 required", http.StatusBadRequest)
+		return
+	}
+	streamer, ok := w.(http3.HTTPStreamer)
+	if !ok {
+		http.Error(w, "CONNECT-UDP over HTTP/3 required", http.StatusBadRequest)
+		return
+	}
+
+	target, err := parseConnectUDPPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &DatagramRequest{
+		target:   target,
+		writer:   w,
+		streamer: streamer,
+		decided:  make(chan struct{}),
+	}
+	if l.identityFunc != nil {
+		req.identity = l.identityFunc(r)
+	}
+
+	select {
+	case l.acceptCh <- req:
+	case <-l.closeCh:
+		http.Error(w, "server closing", http.StatusServiceUnavailable)
+		return
+	case <-r.Context().Done():
+		return
+	}
+
+	// The response is written by Accept or Reject, the stream outlives the handler once accepted
+	select {
+	case <-req.decided:
+	case <-r.Context().Done():
+	}
+}
+
+// parseConnectUDPPath returns the target of a CONNECT-UDP path (/.well-known/masque/udp/{host}/{port}/)
+func parseConnectUDPPath(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, ConnectUDPPath)
+	if !ok {
+		return "", fmt.Errorf("invalid CONNECT-UDP path %q", path)
+	}
+
+	parts := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid CONNECT-UDP path %q", path)
+	}
+	host, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid CONNECT-UDP target host %q", parts[0])
+	}
+	return net.JoinHostPort(host, parts[1]), nil
+}
+
+// AcceptDatagram waits for the next CONNECT-UDP request
+func (l *QUICListener) AcceptDatagram(ctx context.Context) (*DatagramRequest, error) {
+	select {
+	case req := <-l.acceptCh:
+		return req, nil
+	case <-l.closeCh:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *QUICListener) isClosed() bool {
+	select {
+	case <-l.closeCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops the HTTP/3 server
+func (l *QUICListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.closeCh)
+		err = l.server.Close()
+		l.conn.Close()
+	})
+	return err
+}
+
+// Addr returns the UDP address of the listener
+func (l *QUICListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
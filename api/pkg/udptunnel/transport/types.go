@@ -27,6 +27,21 @@ type Transport interface {
 	SetWriteDeadline(t time.Time) error
 }
 
+// Identified is implemented by transports that know the identity of their client (e.g., the
+// authenticated user of the upgrade request)
+type Identified interface {
+	// Identity returns the identity of the client, empty when unknown
+	Identity() string
+}
+
+// IdentityOf returns the identity of the client of a transport, empty when unknown
+func IdentityOf(t Transport) string {
+	if identified, ok := t.(Identified); ok {
+		return identified.Identity()
+	}
+	return ""
+}
+
 // Dialer creates outbound connections to a remote server
 type Dialer interface {
 	// Dial establishes a connection to the remote server
@@ -45,6 +60,36 @@ type Listener interface {
 	Addr() net.Addr
 }
 
+// DatagramConn carries the datagrams of one UDP session (e.g., a CONNECT-UDP request over HTTP/3)
+type DatagramConn interface {
+	// SendDatagram sends a datagram, which may be lost
+	SendDatagram(p []byte) error
+
+	// ReceiveDatagram waits for and returns the next datagram
+	ReceiveDatagram(ctx context.Context) ([]byte, error)
+
+	// Close closes the session
+	Close() error
+}
+
+// DatagramDialer opens UDP sessions through a remote server
+type DatagramDialer interface {
+	// DialUDP opens a UDP session to target (host:port) through the server
+	DialUDP(ctx context.Context, serverURL, target string) (DatagramConn, error)
+}
+
+// DatagramListener accepts incoming UDP session requests
+type DatagramListener interface {
+	// AcceptDatagram waits for and returns the next session request
+	AcceptDatagram(ctx context.Context) (*DatagramRequest, error)
+
+	// Close closes the listener
+	Close() error
+
+	// Addr returns the listener's network address
+	Addr() net.Addr
+}
+
 // Config contains common configuration for transports
 type Config struct {
 	// PingInterval is the interval between ping messages (0 to disable)
@@ -15,9 +15,10 @@ import (
 
 // WebSocketTransport implements the Transport interface using WebSocket
 type WebSocketTransport struct {
-	conn   *websocket.Conn
-	config *Config
-	logger *zap.Logger
+	conn     *websocket.Conn
+	config   *Config
+	logger   *zap.Logger
+	identity string // Client identity, set by the listener
 
 	// For implementing io.Reader/Writer
 	readMu   sync.Mutex
@@ -170,6 +171,11 @@ func (wst *WebSocketTransport) Close() error {
 	return wst.conn.Close()
 }
 
+// Identity returns the identity of the client, empty when unknown or on the dialing side
+func (wst *WebSocketTransport) Identity() string {
+	return wst.identity
+}
+
 // LocalAddr returns the local network address
 func (wst *WebSocketTransport) LocalAddr() net.Addr {
 	return wst.conn.LocalAddr()
@@ -238,16 +244,27 @@ func (wd *WebSocketDialer) Dial(ctx context.Context, serverURL string) (Transpor
 	return NewWebSocketTransport(conn, wd.config, wd.logger), nil
 }
 
+// IdentityFunc returns the identity of the client of an upgrade request (e.g., the user
+// authenticated by a middleware), empty when unknown
+type IdentityFunc func(r *http.Request) string
+
+// acceptedConn is an upgraded connection with the identity of its client
+type acceptedConn struct {
+	conn     *websocket.Conn
+	identity string
+}
+
 // WebSocketListener implements Listener for WebSocket connections
 type WebSocketListener struct {
-	server    *http.Server
-	upgrader  *websocket.Upgrader
-	config    *Config
-	logger    *zap.Logger
-	acceptCh  chan *websocket.Conn
-	errCh     chan error
-	closeCh   chan struct{}
-	closeOnce sync.Once
+	server       *http.Server
+	upgrader     *websocket.Upgrader
+	config       *Config
+	logger       *zap.Logger
+	identityFunc IdentityFunc
+	acceptCh     chan acceptedConn
+	errCh        chan error
+	closeCh      chan struct{}
+	closeOnce    sync.Once
 }
 
 // NewWebSocketListener creates a new WebSocket listener
@@ -265,7 +282,7 @@ func NewWebSocketListener(addr string, tlsConfig *tls.Config, certFile, keyFile
 		},
 		config:   config,
 		logger:   logger,
-		acceptCh: make(chan *websocket.Conn),
+		acceptCh: make(chan acceptedConn),
 		errCh:    make(chan error, 1),
 		closeCh:  make(chan struct{}),
 	}
@@ -332,7 +349,7 @@ func NewWebSocketListenerWithHandler(addr string, tlsConfig *tls.Config, certFil
 		},
 		config:   config,
 		logger:   logger,
-		acceptCh: make(chan *websocket.Conn),
+		acceptCh: make(chan acceptedConn),
 		errCh:    make(chan error, 1),
 		closeCh:  make(chan struct{}),
 	}
@@ -379,6 +396,12 @@ func NewWebSocketListenerWithHandler(addr string, tlsConfig *tls.Config, certFil
 	return listener, nil
 }
 
+// SetIdentityFunc sets how the identity of the clients of accepted connections is determined,
+// must be called before connections are accepted
+func (wsl *WebSocketListener) SetIdentityFunc(fn IdentityFunc) {
+	wsl.identityFunc = fn
+}
+
 // GetWebSocketUpgradeHandler returns the WebSocket upgrade handler function
 // This can be used to add the WebSocket handler to a custom mux
 func (wsl *WebSocketListener) GetWebSocketUpgradeHandler() http.HandlerFunc {
@@ -391,14 +414,21 @@ func (wsl *WebSocketListener) handleWebSocket(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// The request is gone once upgraded, the identity is taken before
+	accepted := acceptedConn{}
+	if wsl.identityFunc != nil {
+		accepted.identity = wsl.identityFunc(r)
+	}
+
 	conn, err := wsl.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		wsl.logger.Error("websocket upgrade failed", zap.Error(err))
 		return
 	}
+	accepted.conn = conn
 
 	select {
-	case wsl.acceptCh <- conn:
+	case wsl.acceptCh <- accepted:
 	case <-wsl.closeCh:
 		conn.Close()
 	}
@@ -407,8 +437,10 @@ func (wsl *WebSocketListener) handleWebSocket(w http.ResponseWriter, r *http.Req
 // Accept waits for and returns the next WebSocket connection
 func (wsl *WebSocketListener) Accept(ctx context.Context) (Transport, error) {
 	select {
-	case conn := <-wsl.acceptCh:
-		return NewWebSocketTransport(conn, wsl.config, wsl.logger), nil
+	case accepted := <-wsl.acceptCh:
+		wst := NewWebSocketTransport(accepted.conn, wsl.config, wsl.logger)
+		wst.identity = accepted.identity
+		return wst, nil
 	case err := <-wsl.errCh:
 		return nil, err
 	case <-wsl.closeCh:
@@ -2,8 +2,10 @@ package tunnel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 
@@ -18,7 +20,11 @@ const (
 	backoffMultiplier  = 2
 )
 
-// UDPClient handles UDP tunneling from local UDP to remote server via WebSocket
+// UDPClient handles UDP tunneling from local UDP to remote server via WebSocket, or QUIC datagrams
+// when a datagram dialer is set
+//
+// The WebSocket sessions of all client addresses share one multiplexed connection; with servers
+// that do not support it, each session has its own connection.
 type UDPClient struct {
 	localAddr  string
 	serverURL  string
@@ -34,12 +40,34 @@ type UDPClient struct {
 		currentBackoff  time.Duration
 		consecutiveFail int
 	}
+
+	// Refusal of the latest tunnel request, nil once a request succeeds
+	rejection struct {
+		mu  sync.Mutex
+		err *RejectedError
+	}
+
+	// Multiplexed connection shared by the sessions
+	mux struct {
+		mu          sync.Mutex
+		conn        *muxConn
+		unsupported bool // The server refused MUX_UDP, sessions use CONNECT_UDP
+	}
+
+	// QUIC datagram sessions, preferred over WebSocket
+	quic struct {
+		mu      sync.Mutex
+		dialer  transport.DatagramDialer
+		mode    TransportMode
+		retryAt time.Time     // In auto mode, QUIC is not tried again before
+		active  TransportMode // Transport of the latest session
+	}
 }
 
 // udpSession represents a UDP tunnel session
 type udpSession struct {
 	clientAddr *net.UDPAddr
-	tunnelConn transport.Transport
+	conn       datagramConn
 	lastActive time.Time
 	cancel     context.CancelFunc
 	mu         sync.Mutex
@@ -68,6 +96,7 @@ func (c *UDPClient) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to listen on %s: %w", c.localAddr, err)
 	}
 	defer conn.Close()
+	defer c.closeMux()
 
 	c.logger.Info("UDP tunnel client listening",
 		zap.String("local", c.localAddr),
@@ -105,8 +134,8 @@ func (c *UDPClient) Start(ctx context.Context) error {
 			continue
 		}
 
-		// Forward packet through tunnel
-		go c.forwardPacket(session, buffer[:n])
+		// Forward packet through tunnel, the buffer is reused by the next read
+		go c.forwardPacket(session, append([]byte(nil), buffer[:n]...))
 	}
 }
 
@@ -133,68 +162,31 @@ func (c *UDPClient) getOrCreateSession(ctx context.Context, clientAddr *net.UDPA
 	}
 	c.dialBackoff.mu.Unlock()
 
-	// Create new session
-	sessionCtx, cancel := context.WithCancel(ctx)
-
-	// Connect to tunnel server via WebSocket
-	tunnelConn, err := c.dialer.Dial(sessionCtx, c.serverURL)
+	conn, err := c.dialSession(ctx)
 	if err != nil {
-		// Update backoff state on failure
-		c.dialBackoff.mu.Lock()
-		c.dialBackoff.lastFailure = time.Now()
-		c.dialBackoff.consecutiveFail++
-		if c.dialBackoff.currentBackoff == 0 {
-			c.dialBackoff.currentBackoff = initialDialBackoff
-		} else {
-			c.dialBackoff.currentBackoff *= backoffMultiplier
-			if c.dialBackoff.currentBackoff > maxDialBackoff {
-				c.dialBackoff.currentBackoff = maxDialBackoff
-			}
+		// A refused request is retried after the backoff, the server may refuse it again
+		backoff := c.backOff()
+		var rejected *RejectedError
+		if errors.As(err, &rejected) {
+			c.setRejection(rejected)
 		}
-		backoff := c.dialBackoff.currentBackoff
-		c.dialBackoff.mu.Unlock()
-
-		c.logger.Error("failed to dial tunnel server, backing off",
+		c.logger.Error("failed to open UDP tunnel session, backing off",
 			zap.Error(err),
 			zap.Duration("backoff", backoff))
-		cancel()
 		return nil
 	}
 
-	// Reset backoff on successful dial
+	// Reset backoff on successful request
 	c.dialBackoff.mu.Lock()
 	c.dialBackoff.currentBackoff = 0
 	c.dialBackoff.consecutiveFail = 0
 	c.dialBackoff.mu.Unlock()
+	c.setRejection(nil)
 
-	// Send tunnel request header: CONNECT_UDP <remote_addr>\n
-	header := fmt.Sprintf("CONNECT_UDP %s\n", c.remoteAddr)
-	if _, err := tunnelConn.Write([]byte(header)); err != nil {
-		c.logger.Error("failed to send UDP tunnel request", zap.Error(err))
-		tunnelConn.Close()
-		cancel()
-		return nil
-	}
-
-	// Read response: OK\n or ERR\n
-	response := make([]byte, 3)
-	if _, err := tunnelConn.Read(response); err != nil {
-		c.logger.Error("failed to read UDP tunnel response", zap.Error(err))
-		tunnelConn.Close()
-		cancel()
-		return nil
-	}
-
-	if string(response) != "OK\n" {
-		c.logger.Error("UDP tunnel request rejected", zap.String("response", string(response)))
-		tunnelConn.Close()
-		cancel()
-		return nil
-	}
-
+	sessionCtx, cancel := context.WithCancel(ctx)
 	session := &udpSession{
 		clientAddr: clientAddr,
-		tunnelConn: tunnelConn,
+		conn:       conn,
 		lastActive: time.Now(),
 		cancel:     cancel,
 	}
@@ -211,18 +203,151 @@ func (c *UDPClient) getOrCreateSession(ctx context.Context, clientAddr *net.UDPA
 	return session
 }
 
+// dialSession opens a session to the remote address, over QUIC when available, else as a stream
+// of the multiplexed connection or over its own connection when the server does not support
+// multiplexing
+func (c *UDPClient) dialSession(ctx context.Context) (datagramConn, error) {
+	if conn, ok, err := c.dialQUIC(ctx); ok {
+		return conn, err
+	}
+
+	var conn datagramConn
+	mux, err := c.getMux(ctx)
+	switch {
+	case err == nil:
+		conn, err = mux.openStream(c.remoteAddr)
+	case errors.Is(err, errMuxUnsupported):
+		conn, err = c.dialLine(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.setActiveTransport(TransportWebSocket)
+	return conn, nil
+}
+
+// getMux returns the multiplexed connection, dialing it when there is none (or it closed)
+func (c *UDPClient) getMux(ctx context.Context) (*muxConn, error) {
+	c.mux.mu.Lock()
+	defer c.mux.mu.Unlock()
+
+	if c.mux.unsupported {
+		return nil, errMuxUnsupported
+	}
+	if c.mux.conn != nil && !c.mux.conn.isClosed() {
+		return c.mux.conn, nil
+	}
+
+	tunnelConn, err := c.dialer.Dial(ctx, c.serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial tunnel server: %w", err)
+	}
+
+	response, err := c.request(tunnelConn, formatMuxRequest(muxVersion))
+	if err != nil {
+		tunnelConn.Close()
+		var rejected *RejectedError
+		if errors.As(err, &rejected) {
+			c.logger.Info("tunnel server does not support multiplexing, using a connection per session",
+				zap.String("reason", rejected.Error()))
+			c.mux.unsupported = true
+			return nil, errMuxUnsupported
+		}
+		return nil, err
+	}
+
+	if version, err := strconv.Atoi(response); err != nil || version < 1 || version > muxVersion {
+		tunnelConn.Close()
+		return nil, fmt.Errorf("tunnel server answered unsupported multiplexing version %q", response)
+	}
+
+	mux := newMuxConn(tunnelConn, tunnelConn, c.logger)
+	go func() {
+		err := mux.run()
+		c.logger.Debug("multiplexed UDP tunnel closed", zap.Error(err))
+	}()
+
+	c.mux.conn = mux
+	c.logger.Debug("multiplexed UDP tunnel opened", zap.String("server", c.serverURL))
+	return mux, nil
+}
+
+// closeMux closes the multiplexed connection and its sessions
+func (c *UDPClient) closeMux() {
+	c.mux.mu.Lock()
+	defer c.mux.mu.Unlock()
+
+	if c.mux.conn != nil {
+		c.mux.conn.Close()
+		c.mux.conn = nil
+	}
+}
+
+// dialLine opens a session over its own connection with CONNECT_UDP
+func (c *UDPClient) dialLine(ctx context.Context) (datagramConn, error) {
+	tunnelConn, err := c.dialer.Dial(ctx, c.serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial tunnel server: %w", err)
+	}
+
+	if _, err := c.request(tunnelConn, formatRequest(c.remoteAddr)); err != nil {
+		tunnelConn.Close()
+		return nil, err
+	}
+	return &lineConn{transport: tunnelConn, reader: tunnelConn}, nil
+}
+
+// request sends a request line and returns the argument of the OK response
+func (c *UDPClient) request(tunnelConn transport.Transport, line string) (string, error) {
+	if _, err := tunnelConn.Write([]byte(line)); err != nil {
+		return "", fmt.Errorf("failed to send UDP tunnel request: %w", err)
+	}
+
+	// The response line is sent in one message
+	response := make([]byte, maxResponseLength)
+	n, err := tunnelConn.Read(response)
+	if err != nil {
+		return "", fmt.Errorf("failed to read UDP tunnel response: %w", err)
+	}
+	return parseResponse(string(response[:n]))
+}
+
+// backOff delays the next tunnel request after a failed one, returning the delay
+func (c *UDPClient) backOff() time.Duration {
+	c.dialBackoff.mu.Lock()
+	defer c.dialBackoff.mu.Unlock()
+
+	c.dialBackoff.lastFailure = time.Now()
+	c.dialBackoff.consecutiveFail++
+	if c.dialBackoff.currentBackoff == 0 {
+		c.dialBackoff.currentBackoff = initialDialBackoff
+	} else {
+		c.dialBackoff.currentBackoff *= backoffMultiplier
+		if c.dialBackoff.currentBackoff > maxDialBackoff {
+			c.dialBackoff.currentBackoff = maxDialBackoff
+		}
+	}
+	return c.dialBackoff.currentBackoff
+}
+
+func (c *UDPClient) setRejection(err *RejectedError) {
+	c.rejection.mu.Lock()
+	defer c.rejection.mu.Unlock()
+	c.rejection.err = err
+}
+
+// Rejection returns why the tunnel server refused the latest tunnel request, nil when it was accepted
+func (c *UDPClient) Rejection() *RejectedError {
+	c.rejection.mu.Lock()
+	defer c.rejection.mu.Unlock()
+	return c.rejection.err
+}
+
 func (c *UDPClient) forwardPacket(session *udpSession, data []byte) {
 	session.mu.Lock()
 	defer session.mu.Unlock()
 
-	// Write packet length (2 bytes) + data
-	length := uint16(len(data))
-	packet := make([]byte, 2+len(data))
-	packet[0] = byte(length >> 8)
-	packet[1] = byte(length)
-	copy(packet[2:], data)
-
-	if _, err := session.tunnelConn.Write(packet); err != nil {
+	if err := session.conn.WriteDatagram(data); err != nil {
 		c.logger.Error("failed to write to tunnel", zap.Error(err))
 		return
 	}
@@ -232,7 +357,7 @@ func (c *UDPClient) forwardPacket(session *udpSession, data []byte) {
 
 func (c *UDPClient) receiveFromTunnel(ctx context.Context, session *udpSession, localConn *net.UDPConn) {
 	defer func() {
-		session.tunnelConn.Close()
+		session.conn.Close()
 		c.sessions.Delete(session.clientAddr.String())
 		session.cancel()
 	}()
@@ -246,21 +371,7 @@ func (c *UDPClient) receiveFromTunnel(ctx context.Context, session *udpSession,
 		default:
 		}
 
-		// Read packet length (2 bytes)
-		lengthBuf := make([]byte, 2)
-		if _, err := session.tunnelConn.Read(lengthBuf); err != nil {
-			c.logger.Debug("tunnel read error", zap.Error(err))
-			return
-		}
-
-		length := int(lengthBuf[0])<<8 | int(lengthBuf[1])
-		if length > len(buffer) {
-			c.logger.Error("packet too large", zap.Int("length", length))
-			return
-		}
-
-		// Read packet data
-		n, err := session.tunnelConn.Read(buffer[:length])
+		n, err := session.conn.ReadDatagram(buffer)
 		if err != nil {
 			c.logger.Debug("tunnel read error", zap.Error(err))
 			return
@@ -301,7 +412,7 @@ func (c *UDPClient) cleanupSessions(ctx context.Context, timeout time.Duration)
 				if expired {
 					c.logger.Debug("cleaning up expired UDP session", zap.String("client", key.(string)))
 					session.cancel()
-					session.tunnelConn.Close()
+					session.conn.Close()
 					c.sessions.Delete(key)
 				}
 
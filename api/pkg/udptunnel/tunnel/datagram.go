@@ -0,0 +1,201 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/pkg/udptunnel/transport"
+	"go.uber.org/zap"
+)
+
+// Sessions over a transport.DatagramDialer (QUIC) are requested with CONNECT-UDP instead of a request
+// line; a refused request is answered with an HTTP status and the code and message of its
+// RejectedError in the Tunnel-Error header.
+
+// TransportMode selects the transport of the sessions of a UDPClient
+type TransportMode string
+
+const (
+	// TransportAuto prefers QUIC datagrams, falling back to WebSocket when QUIC cannot be reached
+	TransportAuto TransportMode = "auto"
+	// TransportQUIC only uses QUIC datagrams
+	TransportQUIC TransportMode = "quic"
+	// TransportWebSocket only uses WebSocket
+	TransportWebSocket TransportMode = "websocket"
+)
+
+const (
+	// quicProbeTimeout bounds a QUIC session request in auto mode, when UDP/443 is blocked the
+	// request gets no answer at all
+	quicProbeTimeout = 3 * time.Second
+
+	// quicRetryInterval is how long auto mode uses WebSocket after QUIC could not be reached
+	quicRetryInterval = 5 * time.Minute
+)
+
+// ParseTransportMode parses a transport mode, empty for auto
+func ParseTransportMode(s string) (TransportMode, error) {
+	switch mode := TransportMode(s); mode {
+	case "":
+		return TransportAuto, nil
+	case TransportAuto, TransportQUIC, TransportWebSocket:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid transport %q, expected auto, quic or websocket", s)
+	}
+}
+
+// datagramSession is a session over a transport.DatagramConn
+type datagramSession struct {
+	conn transport.DatagramConn
+}
+
+// ReadDatagram reads the next datagram
+func (s *datagramSession) ReadDatagram(p []byte) (int, error) {
+	b, err := s.conn.ReceiveDatagram(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	if len(b) > len(p) {
+		return 0, fmt.Errorf("packet too large: %d bytes", len(b))
+	}
+	return copy(p, b), nil
+}
+
+// WriteDatagram sends a datagram
+func (s *datagramSession) WriteDatagram(p []byte) error {
+	return s.conn.SendDatagram(p)
+}
+
+// Close closes the session
+func (s *datagramSession) Close() error {
+	return s.conn.Close()
+}
+
+// statusOfRejection returns the HTTP status a CONNECT-UDP request is refused with
+func statusOfRejection(code string) int {
+	switch code {
+	case ErrCodeBadRequest:
+		return http.StatusBadRequest
+	case ErrCodeForbiddenDestination:
+		return http.StatusForbidden
+	case ErrCodeSessionLimit:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// ServeDatagrams serves the session requests of a datagram listener until ctx is done or the
+// listener closes, with the policy and the observer of the server
+func (s *UDPServer) ServeDatagrams(ctx context.Context, listener transport.DatagramListener) error {
+	s.logger.Info("UDP datagram tunnel server started", zap.String("addr", listener.Addr().String()))
+
+	for {
+		req, err := listener.AcceptDatagram(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			s.logger.Error("failed to accept datagram session", zap.Error(err))
+			continue
+		}
+
+		go s.handleDatagramRequest(req)
+	}
+}
+
+// handleDatagramRequest connects a datagram session to its destination
+func (s *UDPServer) handleDatagramRequest(req *transport.DatagramRequest) {
+	remoteAddr, identity := req.Target(), req.Identity()
+	s.logger.Debug("handling UDP datagram tunnel request", zap.String("remote", remoteAddr), zap.String("identity", identity))
+
+	destConn, lease, err := s.connect(remoteAddr, identity)
+	if err != nil {
+		rejected := s.rejection(identity, err)
+		req.Reject(statusOfRejection(rejected.Code), rejected.wire())
+		return
+	}
+	defer lease.Release()
+
+	conn := req.Accept()
+	s.logger.Debug("connected datagram session to UDP destination", zap.String("dest", remoteAddr), zap.String("identity", identity))
+	s.forward(&datagramSession{conn: conn}, destConn, lease)
+}
+
+// SetDatagramDialer sets the dialer of QUIC sessions and when to use them, must be called before
+// Start; without one (or in websocket mode) sessions use the transport.Dialer
+func (c *UDPClient) SetDatagramDialer(dialer transport.DatagramDialer, mode TransportMode) {
+	c.quic.mu.Lock()
+	defer c.quic.mu.Unlock()
+	c.quic.dialer = dialer
+	c.quic.mode = mode
+}
+
+// ActiveTransport returns the transport of the latest session: quic or websocket
+func (c *UDPClient) ActiveTransport() TransportMode {
+	c.quic.mu.Lock()
+	defer c.quic.mu.Unlock()
+	if c.quic.active == "" {
+		return TransportWebSocket
+	}
+	return c.quic.active
+}
+
+// dialQUIC opens a session with CONNECT-UDP, returning false when the session is to use WebSocket
+// instead
+func (c *UDPClient) dialQUIC(ctx context.Context) (datagramConn, bool, error) {
+	c.quic.mu.Lock()
+	dialer, mode := c.quic.dialer, c.quic.mode
+	skip := dialer == nil || mode == TransportWebSocket || (mode == TransportAuto && time.Now().Before(c.quic.retryAt))
+	c.quic.mu.Unlock()
+	if skip {
+		return nil, false, nil
+	}
+
+	dialCtx := ctx
+	if mode == TransportAuto {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithTimeout(ctx, quicProbeTimeout)
+		defer cancel()
+	}
+
+	conn, err := dialer.DialUDP(dialCtx, c.serverURL, c.remoteAddr)
+	if err == nil {
+		c.setActiveTransport(TransportQUIC)
+		return &datagramSession{conn: conn}, true, nil
+	}
+
+	// A request refused by the tunnel server would be refused over WebSocket too
+	var refused *transport.RefusedError
+	if errors.As(err, &refused) && refused.Reason != "" {
+		return nil, true, parseRejection(refused.Reason)
+	}
+	if mode == TransportQUIC {
+		return nil, true, err
+	}
+
+	c.logger.Warn("QUIC tunnel unavailable, falling back to WebSocket",
+		zap.Error(err),
+		zap.Duration("retry_in", quicRetryInterval))
+	c.quic.mu.Lock()
+	c.quic.retryAt = time.Now().Add(quicRetryInterval)
+	c.quic.mu.Unlock()
+	return nil, false, nil
+}
+
+func (c *UDPClient) setActiveTransport(mode TransportMode) {
+	c.quic.mu.Lock()
+	defer c.quic.mu.Unlock()
+	if c.quic.active != mode {
+		c.logger.Info("UDP tunnel transport selected", zap.String("transport", string(mode)))
+	}
+	c.quic.active = mode
+}
@@ -0,0 +1,169 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/pkg/udptunnel/transport"
+	"github.com/quic-go/quic-go/http3"
+	"go.uber.org/zap"
+)
+
+// testTLSConfig returns the TLS configuration of a server with a self-signed certificate
+func testTLSConfig(tb testing.TB) *tls.Config {
+	tb.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		tb.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		tb.Fatalf("failed to create certificate: %v", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS13,
+	}
+}
+
+// startQUICTunnel starts a UDPServer on a QUIC listener and returns a client of it to an echo
+// server, with the given transport mode
+func startQUICTunnel(tb testing.TB, policy func(echo string) *Policy, mode TransportMode) *UDPClient {
+	tb.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	echo := startEcho(tb)
+	mux := http.NewServeMux()
+	listener, err := transport.NewQUICListener("127.0.0.1:0", testTLSConfig(tb), mux, nil, zap.NewNop())
+	if err != nil {
+		tb.Fatalf("NewQUICListener() error = %v", err)
+	}
+	mux.Handle(transport.ConnectUDPPath, listener.GetConnectUDPHandler())
+
+	network := newPipeNetwork()
+	server := NewUDPServer(network, zap.NewNop())
+	if policy != nil {
+		server.SetPolicy(policy(echo))
+	}
+	go server.Start(ctx)
+	go server.ServeDatagrams(ctx, listener)
+
+	client := NewUDPClient("127.0.0.1:0", "wss://"+listener.Addr().String()+"/ws", echo, network, zap.NewNop())
+	client.SetDatagramDialer(transport.NewQUICDialer(nil, &tls.Config{InsecureSkipVerify: true}, nil, zap.NewNop()), mode)
+	tb.Cleanup(func() {
+		client.closeMux()
+		cancel()
+		listener.Close()
+		network.Close()
+	})
+	return client
+}
+
+func TestQUICSessionRoundTrip(t *testing.T) {
+	client := startQUICTunnel(t, nil, TransportQUIC)
+	buf := make([]byte, 65536)
+
+	session, err := client.dialSession(context.Background())
+	if err != nil {
+		t.Fatalf("dialSession() error = %v", err)
+	}
+	defer session.Close()
+
+	if _, ok := session.(*datagramSession); !ok {
+		t.Fatalf("session is %T, want a QUIC session", session)
+	}
+	if got := client.ActiveTransport(); got != TransportQUIC {
+		t.Errorf("ActiveTransport() = %s, want quic", got)
+	}
+
+	roundTrip(t, session, []byte("over quic"), buf)
+	// Larger than a QUIC datagram, sent as a DATAGRAM capsule
+	roundTrip(t, session, bytes.Repeat([]byte{0x42}, 4000), buf)
+
+	other, err := client.dialSession(context.Background())
+	if err != nil {
+		t.Fatalf("dialSession() error = %v", err)
+	}
+	defer other.Close()
+	roundTrip(t, other, []byte("second session"), buf)
+}
+
+func TestQUICSessionRejected(t *testing.T) {
+	client := startQUICTunnel(t, func(string) *Policy {
+		policy, _ := NewPolicy(PolicyConfig{Allow: []string{"127.0.0.1:51820"}})
+		return policy
+	}, TransportAuto)
+
+	// A refused request does not fall back to WebSocket
+	var rejected *RejectedError
+	if _, err := client.dialSession(context.Background()); !errors.As(err, &rejected) || rejected.Code != ErrCodeForbiddenDestination {
+		t.Fatalf("dialSession() error = %v, want %s", err, ErrCodeForbiddenDestination)
+	}
+}
+
+func TestQUICFallbackToWebSocket(t *testing.T) {
+	client, network := startTunnel(t, nil)
+
+	// An HTTP/3 server without datagrams, as a proxy in between could be
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	h3 := &http3.Server{TLSConfig: http3.ConfigureTLSConfig(testTLSConfig(t)), Handler: http.NotFoundHandler()}
+	go h3.Serve(conn)
+	defer h3.Close()
+
+	client.serverURL = "wss://" + conn.LocalAddr().String() + "/ws"
+	client.SetDatagramDialer(transport.NewQUICDialer(nil, &tls.Config{InsecureSkipVerify: true}, nil, zap.NewNop()), TransportAuto)
+
+	session, err := client.dialSession(context.Background())
+	if err != nil {
+		t.Fatalf("dialSession() error = %v", err)
+	}
+	defer session.Close()
+
+	roundTrip(t, session, []byte("over websocket"), make([]byte, 65536))
+	if got := client.ActiveTransport(); got != TransportWebSocket {
+		t.Errorf("ActiveTransport() = %s, want websocket", got)
+	}
+	if client.quic.retryAt.IsZero() {
+		t.Error("QUIC is retried on the next session")
+	}
+	if got := network.dials.Load(); got != 1 {
+		t.Errorf("WebSocket dials = %d, want 1", got)
+	}
+
+	// No fallback in quic mode
+	client.SetDatagramDialer(transport.NewQUICDialer(nil, &tls.Config{InsecureSkipVerify: true}, nil, zap.NewNop()), TransportQUIC)
+	if _, err := client.dialSession(context.Background()); err == nil {
+		t.Error("dialSession() in quic mode fell back to WebSocket")
+	}
+}
+
+func TestParseTransportMode(t *testing.T) {
+	for s, want := range map[string]TransportMode{"": TransportAuto, "auto": TransportAuto, "quic": TransportQUIC, "websocket": TransportWebSocket} {
+		if got, err := ParseTransportMode(s); err != nil || got != want {
+			t.Errorf("ParseTransportMode(%q) = %s, %v; want %s", s, got, err, want)
+		}
+	}
+	if _, err := ParseTransportMode("tcp"); err == nil {
+		t.Error("ParseTransportMode(tcp) succeeded, want error")
+	}
+}
@@ -0,0 +1,470 @@
+package tunnel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/pkg/udptunnel/transport"
+	"go.uber.org/zap"
+)
+
+// A multiplexed tunnel carries the sessions of all the UDP source addresses of a client over one
+// transport connection. The client asks for it with "MUX_UDP <version>\n" instead of CONNECT_UDP,
+// the server answers "OK <version>\n" with the version both speak, and both sides then exchange
+// frames:
+//
+//	+----------+----------------+-------------+---------+
+//	| type (1) | stream ID (4)  | length (2)  | payload |
+//	+----------+----------------+-------------+---------+
+//
+// The client opens a stream per session with an open frame carrying the destination, which the
+// server accepts with open-ok or refuses with open-error ("<code> <message>"). Data frames carry
+// one datagram each, and either side ends a stream with a close frame. A side sends at most
+// muxWindow bytes of data on a stream until the receiver grants more with a window frame; datagrams
+// over the window are dropped, as a full UDP socket buffer would. Ping frames, answered with pong
+// frames, keep the connection alive and detect dead ones.
+//
+// Servers that predate multiplexing refuse MUX_UDP with "ERR", the client then falls back to one
+// CONNECT_UDP connection per session.
+const (
+	muxCommand = "MUX_UDP"
+	muxVersion = 1
+
+	muxHeaderLength = 7
+
+	// muxWindow is the data a side may send on a stream before the receiver grants more
+	muxWindow = 256 * 1024
+
+	// muxBacklog bounds the datagrams queued for the reader of a stream
+	muxBacklog = 256
+
+	muxKeepaliveInterval = 15 * time.Second
+	muxKeepaliveTimeout  = 3 * muxKeepaliveInterval
+	muxOpenTimeout       = 10 * time.Second
+)
+
+// frameType is the type of a multiplexed tunnel frame
+type frameType byte
+
+const (
+	frameOpen      frameType = iota + 1 // Client opens a stream, payload is the destination
+	frameOpenOK                         // Server accepted the stream
+	frameOpenError                      // Server refused the stream, payload is "<code> <message>"
+	frameData                           // Payload is a datagram
+	frameClose                          // Stream ended
+	frameWindow                         // Payload is the 4-byte number of bytes granted to the sender
+	framePing                           // Payload is echoed in a pong frame
+	framePong
+)
+
+var (
+	errMuxClosed         = errors.New("multiplexed tunnel closed")
+	errStreamClosed      = errors.New("tunnel stream closed")
+	errKeepaliveTimeout  = errors.New("multiplexed tunnel keepalive timeout")
+	errMuxUnsupported    = errors.New("tunnel server does not support multiplexing")
+	errStreamOpenTimeout = errors.New("timeout opening tunnel stream")
+)
+
+// frame is a frame of a multiplexed tunnel
+type frame struct {
+	typ     frameType
+	stream  uint32
+	payload []byte
+}
+
+// writeFrame writes a frame in a single Write, which the WebSocket transport sends as one message
+func writeFrame(w io.Writer, f frame) error {
+	if len(f.payload) > 0xffff {
+		return fmt.Errorf("frame payload too large: %d bytes", len(f.payload))
+	}
+
+	buf := make([]byte, muxHeaderLength+len(f.payload))
+	buf[0] = byte(f.typ)
+	binary.BigEndian.PutUint32(buf[1:5], f.stream)
+	binary.BigEndian.PutUint16(buf[5:7], uint16(len(f.payload)))
+	copy(buf[muxHeaderLength:], f.payload)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// readFrame reads the next frame
+func readFrame(r io.Reader) (frame, error) {
+	var header [muxHeaderLength]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return frame{}, err
+	}
+
+	f := frame{
+		typ:    frameType(header[0]),
+		stream: binary.BigEndian.Uint32(header[1:5]),
+	}
+	if length := binary.BigEndian.Uint16(header[5:7]); length > 0 {
+		f.payload = make([]byte, length)
+		if _, err := io.ReadFull(r, f.payload); err != nil {
+			return frame{}, err
+		}
+	}
+	return f, nil
+}
+
+// muxConn is one side of a multiplexed tunnel
+type muxConn struct {
+	transport transport.Transport
+	reader    io.Reader // Frames are read from it, the transport or a buffered reader of it
+	logger    *zap.Logger
+
+	// accept handles the open frames of the client, nil on the client side. It runs in its own
+	// goroutine and must answer with the accept or reject method of the stream.
+	accept func(stream *muxStream, remoteAddr string)
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	streams map[uint32]*muxStream
+	nextID  uint32
+
+	lastReceived atomic.Int64 // Unix nanoseconds
+	closed       chan struct{}
+	closeOnce    sync.Once
+	err          error // Why the connection closed, set before closed is
+}
+
+// newMuxConn creates a side of a multiplexed tunnel, started with run
+func newMuxConn(t transport.Transport, reader io.Reader, logger *zap.Logger) *muxConn {
+	m := &muxConn{
+		transport: t,
+		reader:    reader,
+		logger:    logger,
+		streams:   make(map[uint32]*muxStream),
+		closed:    make(chan struct{}),
+	}
+	m.lastReceived.Store(time.Now().UnixNano())
+	return m
+}
+
+// run reads and dispatches frames until the connection closes, with keepalive in the background
+func (m *muxConn) run() error {
+	go m.keepalive()
+
+	for {
+		f, err := readFrame(m.reader)
+		if err != nil {
+			m.close(err)
+			return m.err
+		}
+		m.lastReceived.Store(time.Now().UnixNano())
+		m.dispatch(f)
+	}
+}
+
+// dispatch handles a received frame
+func (m *muxConn) dispatch(f frame) {
+	switch f.typ {
+	case frameOpen:
+		if m.accept == nil || f.stream == 0 {
+			return
+		}
+		stream, ok := m.addStream(f.stream)
+		if !ok {
+			m.writeFrame(frame{typ: frameOpenError, stream: f.stream, payload: []byte(rejectf(ErrCodeBadRequest, "stream %d already open", f.stream).wire())})
+			return
+		}
+		go m.accept(stream, string(f.payload))
+
+	case frameOpenOK, frameOpenError:
+		if stream := m.stream(f.stream); stream != nil {
+			var err error
+			if f.typ == frameOpenError {
+				err = parseRejection(string(f.payload))
+			}
+			select {
+			case stream.opened <- err:
+			default:
+			}
+		}
+
+	case frameData:
+		if stream := m.stream(f.stream); stream != nil {
+			stream.deliver(f.payload)
+		}
+
+	case frameClose:
+		if stream := m.stream(f.stream); stream != nil {
+			stream.shutdown(false)
+		}
+
+	case frameWindow:
+		if stream := m.stream(f.stream); stream != nil && len(f.payload) == 4 {
+			stream.sendWindow.Add(int64(binary.BigEndian.Uint32(f.payload)))
+		}
+
+	case framePing:
+		m.writeFrame(frame{typ: framePong, payload: f.payload})
+
+	case framePong:
+		// lastReceived is all a pong is for
+
+	default:
+		m.logger.Debug("ignoring unknown tunnel frame", zap.Uint8("type", uint8(f.typ)))
+	}
+}
+
+// keepalive pings the other side, closing the connection when it stops answering
+func (m *muxConn) keepalive() {
+	ticker := time.NewTicker(muxKeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.closed:
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, m.lastReceived.Load())) > muxKeepaliveTimeout {
+				m.close(errKeepaliveTimeout)
+				return
+			}
+			m.writeFrame(frame{typ: framePing})
+		}
+	}
+}
+
+// writeFrame sends a frame, closing the connection when it cannot
+func (m *muxConn) writeFrame(f frame) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	select {
+	case <-m.closed:
+		return errMuxClosed
+	default:
+	}
+
+	if err := writeFrame(m.transport, f); err != nil {
+		go m.close(err)
+		return err
+	}
+	return nil
+}
+
+// openStream opens a stream to remoteAddr (client side), failing with a RejectedError when the
+// server refuses it
+func (m *muxConn) openStream(remoteAddr string) (*muxStream, error) {
+	m.mu.Lock()
+	m.nextID++
+	id := m.nextID
+	m.mu.Unlock()
+
+	stream, ok := m.addStream(id)
+	if !ok {
+		return nil, errMuxClosed
+	}
+
+	if err := m.writeFrame(frame{typ: frameOpen, stream: id, payload: []byte(remoteAddr)}); err != nil {
+		m.removeStream(id)
+		return nil, err
+	}
+
+	timer := time.NewTimer(muxOpenTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-stream.opened:
+		if err != nil {
+			m.removeStream(id)
+			return nil, err
+		}
+		return stream, nil
+	case <-stream.closed:
+		return nil, errStreamClosed
+	case <-timer.C:
+		stream.Close()
+		return nil, errStreamOpenTimeout
+	}
+}
+
+// addStream registers a stream, false when the ID is taken or the connection is closed
+func (m *muxConn) addStream(id uint32) (*muxStream, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.isClosed() {
+		return nil, false
+	}
+	if _, exists := m.streams[id]; exists {
+		return nil, false
+	}
+
+	stream := &muxStream{
+		mux:      m,
+		id:       id,
+		opened:   make(chan error, 1),
+		incoming: make(chan []byte, muxBacklog),
+		closed:   make(chan struct{}),
+	}
+	stream.sendWindow.Store(muxWindow)
+	stream.receiveWindow.Store(muxWindow)
+	m.streams[id] = stream
+	return stream, true
+}
+
+func (m *muxConn) stream(id uint32) *muxStream {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.streams[id]
+}
+
+func (m *muxConn) removeStream(id uint32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.streams, id)
+}
+
+// streamCount returns the open streams
+func (m *muxConn) streamCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.streams)
+}
+
+func (m *muxConn) isClosed() bool {
+	select {
+	case <-m.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// close closes the connection and all its streams
+func (m *muxConn) close(err error) {
+	m.closeOnce.Do(func() {
+		m.err = err
+		close(m.closed)
+		m.transport.Close()
+
+		m.mu.Lock()
+		streams := make([]*muxStream, 0, len(m.streams))
+		for _, stream := range m.streams {
+			streams = append(streams, stream)
+		}
+		m.mu.Unlock()
+
+		for _, stream := range streams {
+			stream.shutdown(false)
+		}
+	})
+}
+
+// Close closes the connection and all its streams
+func (m *muxConn) Close() error {
+	m.close(errMuxClosed)
+	return nil
+}
+
+// muxStream is the session of one client UDP address in a multiplexed tunnel
+type muxStream struct {
+	mux      *muxConn
+	id       uint32
+	opened   chan error // Answer of the server to the open frame (client side)
+	incoming chan []byte
+
+	sendWindow    atomic.Int64 // Bytes the other side accepts
+	receiveWindow atomic.Int64 // Bytes granted to the other side
+	consumed      atomic.Int64 // Bytes read since the last window frame
+	dropped       atomic.Uint64
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// accept answers the open frame of the stream (server side)
+func (s *muxStream) accept() error {
+	return s.mux.writeFrame(frame{typ: frameOpenOK, stream: s.id})
+}
+
+// reject refuses the open frame of the stream (server side)
+func (s *muxStream) reject(err *RejectedError) {
+	s.mux.writeFrame(frame{typ: frameOpenError, stream: s.id, payload: []byte(err.wire())})
+	s.shutdown(false)
+}
+
+// deliver queues a received datagram for the reader, dropping it when over the granted window
+func (s *muxStream) deliver(p []byte) {
+	if s.receiveWindow.Add(-int64(len(p))) < 0 {
+		s.receiveWindow.Add(int64(len(p)))
+		s.dropped.Add(1)
+		return
+	}
+
+	select {
+	case s.incoming <- p:
+	default:
+		// The reader is behind, the datagram is lost but its window is granted back
+		s.dropped.Add(1)
+		s.consume(len(p))
+	}
+}
+
+// consume grants read bytes back to the other side, in window frames of at least half a window
+func (s *muxStream) consume(n int) {
+	consumed := s.consumed.Add(int64(n))
+	if consumed < muxWindow/2 || !s.consumed.CompareAndSwap(consumed, 0) {
+		return
+	}
+
+	s.receiveWindow.Add(consumed)
+	var payload [4]byte
+	binary.BigEndian.PutUint32(payload[:], uint32(consumed))
+	s.mux.writeFrame(frame{typ: frameWindow, stream: s.id, payload: payload[:]})
+}
+
+// ReadDatagram reads the next datagram of the stream
+func (s *muxStream) ReadDatagram(p []byte) (int, error) {
+	select {
+	case datagram := <-s.incoming:
+		n := copy(p, datagram)
+		s.consume(len(datagram))
+		return n, nil
+	case <-s.closed:
+		return 0, io.EOF
+	}
+}
+
+// WriteDatagram sends a datagram on the stream, dropped when over the window of the other side
+func (s *muxStream) WriteDatagram(p []byte) error {
+	select {
+	case <-s.closed:
+		return errStreamClosed
+	default:
+	}
+
+	if s.sendWindow.Add(-int64(len(p))) < 0 {
+		s.sendWindow.Add(int64(len(p)))
+		s.dropped.Add(1)
+		return nil
+	}
+	return s.mux.writeFrame(frame{typ: frameData, stream: s.id, payload: p})
+}
+
+// Close ends the stream, telling the other side
+func (s *muxStream) Close() error {
+	s.shutdown(true)
+	return nil
+}
+
+// shutdown ends the stream, telling the other side when notify is set
+func (s *muxStream) shutdown(notify bool) {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.mux.removeStream(s.id)
+		if notify {
+			s.mux.writeFrame(frame{typ: frameClose, stream: s.id})
+		}
+	})
+}
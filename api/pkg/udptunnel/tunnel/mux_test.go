@@ -0,0 +1,369 @@
+package tunnel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kloudlite/kloudlite/api/pkg/udptunnel/transport"
+	"go.uber.org/zap"
+)
+
+// pipeNetwork is an in-memory transport.Dialer and transport.Listener
+type pipeNetwork struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	dials  atomic.Int32
+}
+
+func newPipeNetwork() *pipeNetwork {
+	return &pipeNetwork{conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+func (n *pipeNetwork) Dial(ctx context.Context, _ string) (transport.Transport, error) {
+	n.dials.Add(1)
+	client, server := net.Pipe()
+	select {
+	case n.conns <- server:
+		return client, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (n *pipeNetwork) Accept(ctx context.Context) (transport.Transport, error) {
+	select {
+	case conn := <-n.conns:
+		return conn, nil
+	case <-n.closed:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (n *pipeNetwork) Close() error {
+	close(n.closed)
+	return nil
+}
+
+func (n *pipeNetwork) Addr() net.Addr {
+	return &net.UnixAddr{Name: "pipe", Net: "pipe"}
+}
+
+// startEcho starts a UDP server echoing datagrams
+func startEcho(tb testing.TB) string {
+	tb.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		tb.Fatalf("failed to listen: %v", err)
+	}
+	tb.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteToUDP(buf[:n], addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// startTunnel starts a UDPServer on a pipe network and returns a client of it to an echo server
+func startTunnel(tb testing.TB, policy func(echo string) *Policy) (*UDPClient, *pipeNetwork) {
+	tb.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	echo := startEcho(tb)
+	network := newPipeNetwork()
+	server := NewUDPServer(network, zap.NewNop())
+	if policy != nil {
+		server.SetPolicy(policy(echo))
+	}
+	go server.Start(ctx)
+
+	client := NewUDPClient("127.0.0.1:0", "pipe", echo, network, zap.NewNop())
+	tb.Cleanup(func() {
+		client.closeMux()
+		cancel()
+		network.Close()
+	})
+	return client, network
+}
+
+// roundTrip sends a datagram through a session and reads the echo
+func roundTrip(tb testing.TB, conn datagramConn, payload, buf []byte) {
+	tb.Helper()
+	if err := conn.WriteDatagram(payload); err != nil {
+		tb.Fatalf("WriteDatagram() error = %v", err)
+	}
+	n, err := conn.ReadDatagram(buf)
+	if err != nil {
+		tb.Fatalf("ReadDatagram() error = %v", err)
+	}
+	if !bytes.Equal(buf[:n], payload) {
+		tb.Fatalf("echo = %q, want %q", buf[:n], payload)
+	}
+}
+
+func TestMuxSessionsShareConnection(t *testing.T) {
+	client, network := startTunnel(t, nil)
+	ctx := context.Background()
+	buf := make([]byte, 65536)
+
+	first, err := client.dialSession(ctx)
+	if err != nil {
+		t.Fatalf("dialSession() error = %v", err)
+	}
+	second, err := client.dialSession(ctx)
+	if err != nil {
+		t.Fatalf("dialSession() error = %v", err)
+	}
+
+	roundTrip(t, first, []byte("first"), buf)
+	roundTrip(t, second, []byte("second"), buf)
+	roundTrip(t, first, bytes.Repeat([]byte{0x42}, 1400), buf)
+
+	if got := network.dials.Load(); got != 1 {
+		t.Errorf("dials = %d, want 1 for two sessions", got)
+	}
+
+	first.Close()
+	if _, err := first.ReadDatagram(buf); err == nil {
+		t.Error("ReadDatagram() on closed stream succeeded")
+	}
+	roundTrip(t, second, []byte("still open"), buf)
+}
+
+func TestMuxRedialsAfterConnectionLoss(t *testing.T) {
+	client, network := startTunnel(t, nil)
+	ctx := context.Background()
+	buf := make([]byte, 65536)
+
+	session, err := client.dialSession(ctx)
+	if err != nil {
+		t.Fatalf("dialSession() error = %v", err)
+	}
+
+	client.mux.conn.transport.Close()
+	if _, err := session.ReadDatagram(buf); err == nil {
+		t.Fatal("session survived the loss of its connection")
+	}
+
+	session, err = client.dialSession(ctx)
+	if err != nil {
+		t.Fatalf("dialSession() after loss error = %v", err)
+	}
+	roundTrip(t, session, []byte("again"), buf)
+	if got := network.dials.Load(); got != 2 {
+		t.Errorf("dials = %d, want 2", got)
+	}
+}
+
+func TestMuxStreamRejected(t *testing.T) {
+	client, network := startTunnel(t, func(string) *Policy {
+		policy, _ := NewPolicy(PolicyConfig{Allow: []string{"127.0.0.1:51820"}})
+		return policy
+	})
+
+	for range 2 {
+		var rejected *RejectedError
+		if _, err := client.dialSession(context.Background()); !errors.As(err, &rejected) || rejected.Code != ErrCodeForbiddenDestination {
+			t.Fatalf("dialSession() error = %v, want %s", err, ErrCodeForbiddenDestination)
+		}
+	}
+	// A refused stream leaves the connection open
+	if got := network.dials.Load(); got != 1 {
+		t.Errorf("dials = %d, want 1", got)
+	}
+}
+
+func TestMuxFallbackToLineProtocol(t *testing.T) {
+	echo := startEcho(t)
+	network := newPipeNetwork()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Server of the line protocol only, as before multiplexing
+	go func() {
+		for {
+			conn, err := network.Accept(ctx)
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				line, _ := reader.ReadString('\n')
+				if !strings.HasPrefix(line, connectCommand+" ") {
+					conn.Write([]byte("ERR\n"))
+					return
+				}
+				conn.Write([]byte(responseOK))
+
+				session := &lineConn{transport: conn, reader: reader}
+				buf := make([]byte, 65536)
+				for {
+					n, err := session.ReadDatagram(buf)
+					if err != nil {
+						return
+					}
+					session.WriteDatagram(buf[:n])
+				}
+			}()
+		}
+	}()
+
+	client := NewUDPClient("127.0.0.1:0", "pipe", echo, network, zap.NewNop())
+	session, err := client.dialSession(ctx)
+	if err != nil {
+		t.Fatalf("dialSession() error = %v", err)
+	}
+	defer session.Close()
+
+	if _, ok := session.(*lineConn); !ok {
+		t.Fatalf("session is %T, want a line protocol session", session)
+	}
+	roundTrip(t, session, []byte("legacy"), make([]byte, 65536))
+
+	// The server is not asked for multiplexing again
+	other, err := client.dialSession(ctx)
+	if err != nil {
+		t.Fatalf("dialSession() error = %v", err)
+	}
+	defer other.Close()
+	if got := network.dials.Load(); got != 3 {
+		t.Errorf("dials = %d, want 3 (refused MUX_UDP and two sessions)", got)
+	}
+}
+
+func TestMuxFlowControl(t *testing.T) {
+	local, remote := net.Pipe()
+	m := newMuxConn(local, local, zap.NewNop())
+	go m.run()
+	defer m.Close()
+
+	frames := make(chan frame, 16)
+	go func() {
+		for {
+			f, err := readFrame(remote)
+			if err != nil {
+				return
+			}
+			frames <- f
+		}
+	}()
+
+	stream, _ := m.addStream(1)
+	stream.sendWindow.Store(10)
+
+	if err := stream.WriteDatagram([]byte("12345678")); err != nil {
+		t.Fatalf("WriteDatagram() error = %v", err)
+	}
+	if f := <-frames; f.typ != frameData || string(f.payload) != "12345678" {
+		t.Fatalf("frame = %+v, want data", f)
+	}
+
+	// Over the window, dropped
+	stream.WriteDatagram([]byte("abcdefgh"))
+	if got := stream.dropped.Load(); got != 1 {
+		t.Fatalf("dropped = %d, want 1", got)
+	}
+
+	var grant [4]byte
+	binary.BigEndian.PutUint32(grant[:], 8)
+	if err := writeFrame(remote, frame{typ: frameWindow, stream: 1, payload: grant[:]}); err != nil {
+		t.Fatalf("writeFrame() error = %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for stream.sendWindow.Load() < 8 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := stream.WriteDatagram([]byte("abcdefgh")); err != nil {
+		t.Fatalf("WriteDatagram() error = %v", err)
+	}
+	if f := <-frames; f.typ != frameData || string(f.payload) != "abcdefgh" {
+		t.Fatalf("frame = %+v, want data after window update", f)
+	}
+
+	// A peer sending over the granted window has its datagrams dropped
+	stream.receiveWindow.Store(4)
+	stream.deliver([]byte("too large"))
+	if got := len(stream.incoming); got != 0 {
+		t.Errorf("queued %d datagrams over the receive window", got)
+	}
+}
+
+func TestMuxKeepalive(t *testing.T) {
+	local, remote := net.Pipe()
+	m := newMuxConn(local, local, zap.NewNop())
+	go m.run()
+	defer m.Close()
+
+	go func() {
+		writeFrame(remote, frame{typ: framePing, payload: []byte("ka")})
+	}()
+	f, err := readFrame(remote)
+	if err != nil || f.typ != framePong || string(f.payload) != "ka" {
+		t.Fatalf("answer to ping = %+v, %v; want pong", f, err)
+	}
+}
+
+// The benchmarks compare sessions over their own connection (CONNECT_UDP, as before multiplexing)
+// with streams of one multiplexed connection. Pipes have no TLS handshake, which is what
+// multiplexing saves most on WebSocket connections.
+
+func benchmarkClient(b *testing.B, mode string) *UDPClient {
+	client, _ := startTunnel(b, nil)
+	client.mux.unsupported = mode == "line"
+	return client
+}
+
+func BenchmarkSessionOpen(b *testing.B) {
+	for _, name := range []string{"line", "mux"} {
+		client := benchmarkClient(b, name)
+		b.Run(name, func(b *testing.B) {
+			buf := make([]byte, 65536)
+			payload := []byte("handshake")
+			for b.Loop() {
+				session, err := client.dialSession(context.Background())
+				if err != nil {
+					b.Fatalf("dialSession() error = %v", err)
+				}
+				roundTrip(b, session, payload, buf)
+				session.Close()
+			}
+		})
+	}
+}
+
+func BenchmarkRoundTrip(b *testing.B) {
+	for _, name := range []string{"line", "mux"} {
+		client := benchmarkClient(b, name)
+		b.Run(name, func(b *testing.B) {
+			session, err := client.dialSession(context.Background())
+			if err != nil {
+				b.Fatalf("dialSession() error = %v", err)
+			}
+			defer session.Close()
+
+			buf := make([]byte, 65536)
+			payload := bytes.Repeat([]byte{0x42}, 1280) // WireGuard packet size
+			b.SetBytes(int64(len(payload)))
+			for b.Loop() {
+				roundTrip(b, session, payload, buf)
+			}
+		})
+	}
+}
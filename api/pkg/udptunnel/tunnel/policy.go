@@ -0,0 +1,252 @@
+package tunnel
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// PolicyConfig configures the Policy of a UDPServer
+type PolicyConfig struct {
+	// Allow lists the allowed destinations: "host:port" allows one address, "CIDR" every port of a
+	// network and "CIDR:port" one port of a network. Nothing is allowed when empty.
+	Allow []string
+
+	// MaxSessionsPerUser limits the open sessions of a client identity (0 for no limit)
+	MaxSessionsPerUser int
+
+	// BandwidthPerUser limits the bytes per second forwarded through the sessions of a client
+	// identity, both directions together (0 for no limit). Packets over the limit are dropped.
+	BandwidthPerUser int64
+}
+
+// Policy decides which destinations tunnel requests may reach, and limits the sessions and the
+// bandwidth of each client identity (the authenticated user of the tunnel connection)
+//
+// The destination of a request is resolved once and the session is bound to the resolved address,
+// so that a host name cannot be re-pointed at another address once checked. Clients without an
+// identity are checked against the destination rules only.
+type Policy struct {
+	rules       []destinationRule
+	maxSessions int
+	bandwidth   rate.Limit
+	burst       int
+	resolve     func(addr string) (*net.UDPAddr, error)
+
+	mu    sync.Mutex
+	users map[string]*userUsage
+}
+
+// destinationRule allows a host or a network, on one port or on all of them
+type destinationRule struct {
+	host    string     // Lower-cased host name, for "host:port" rules with a name
+	network *net.IPNet // Network, for CIDR rules and "host:port" rules with an IP
+	port    int        // 0 for any port
+}
+
+// userUsage is what a client identity uses of its limits
+type userUsage struct {
+	sessions int
+	limiter  *rate.Limiter // nil without bandwidth limit
+}
+
+// NewPolicy creates a policy, failing on invalid destination rules
+func NewPolicy(cfg PolicyConfig) (*Policy, error) {
+	p := &Policy{
+		maxSessions: cfg.MaxSessionsPerUser,
+		resolve: func(addr string) (*net.UDPAddr, error) {
+			return net.ResolveUDPAddr("udp", addr)
+		},
+		users: make(map[string]*userUsage),
+	}
+
+	for _, allow := range cfg.Allow {
+		allow = strings.TrimSpace(allow)
+		if allow == "" {
+			continue
+		}
+		rule, err := parseDestinationRule(allow)
+		if err != nil {
+			return nil, err
+		}
+		p.rules = append(p.rules, rule)
+	}
+
+	if cfg.BandwidthPerUser > 0 {
+		p.bandwidth = rate.Limit(cfg.BandwidthPerUser)
+		// The bucket must hold the largest packet, or it would never pass
+		p.burst = int(max(cfg.BandwidthPerUser, 65535))
+	}
+	return p, nil
+}
+
+// parseDestinationRule parses "host:port", "CIDR" or "CIDR:port"
+func parseDestinationRule(s string) (destinationRule, error) {
+	if strings.Contains(s, "/") {
+		if _, network, err := net.ParseCIDR(s); err == nil {
+			return destinationRule{network: network}, nil
+		}
+
+		i := strings.LastIndex(s, ":")
+		if i < 0 {
+			return destinationRule{}, fmt.Errorf("invalid destination rule %q: invalid CIDR", s)
+		}
+		_, network, err := net.ParseCIDR(s[:i])
+		if err != nil {
+			return destinationRule{}, fmt.Errorf("invalid destination rule %q: %w", s, err)
+		}
+		port, err := parsePort(s[i+1:])
+		if err != nil {
+			return destinationRule{}, fmt.Errorf("invalid destination rule %q: %w", s, err)
+		}
+		return destinationRule{network: network, port: port}, nil
+	}
+
+	host, portStr, err := net.SplitHostPort(s)
+	if err != nil {
+		return destinationRule{}, fmt.Errorf("invalid destination rule %q: %w", s, err)
+	}
+	port, err := parsePort(portStr)
+	if err != nil {
+		return destinationRule{}, fmt.Errorf("invalid destination rule %q: %w", s, err)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		bits := 8 * net.IPv6len
+		if ipv4 := ip.To4(); ipv4 != nil {
+			ip, bits = ipv4, 8*net.IPv4len
+		}
+		return destinationRule{network: &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, port: port}, nil
+	}
+	return destinationRule{host: strings.ToLower(host), port: port}, nil
+}
+
+// parsePort parses a port number
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// matches reports whether the rule allows a destination, requested as host and resolved to addr
+func (r destinationRule) matches(host string, addr *net.UDPAddr) bool {
+	if r.port != 0 && r.port != addr.Port {
+		return false
+	}
+	if r.network != nil {
+		return r.network.Contains(addr.IP)
+	}
+	return strings.EqualFold(r.host, host)
+}
+
+// CheckDestination resolves the destination of a tunnel request and checks it against the rules
+//
+// The returned address is the one the session must use. Errors are RejectedErrors.
+func (p *Policy) CheckDestination(remoteAddr string) (*net.UDPAddr, error) {
+	host, portStr, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return nil, rejectf(ErrCodeBadRequest, "invalid destination %s", remoteAddr)
+	}
+	if _, err := parsePort(portStr); err != nil {
+		return nil, rejectf(ErrCodeBadRequest, "invalid destination %s", remoteAddr)
+	}
+
+	addr, err := p.resolve(remoteAddr)
+	if err != nil {
+		return nil, rejectf(ErrCodeDialFailed, "cannot resolve %s", remoteAddr)
+	}
+
+	for _, rule := range p.rules {
+		if rule.matches(host, addr) {
+			return addr, nil
+		}
+	}
+	return nil, rejectf(ErrCodeForbiddenDestination, "destination %s is not allowed", remoteAddr)
+}
+
+// Acquire opens a session of a client identity, failing with a RejectedError when the identity
+// already has the maximum number of sessions
+//
+// The lease must be released when the session ends.
+func (p *Policy) Acquire(identity string) (*Lease, error) {
+	if identity == "" {
+		return &Lease{}, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	usage := p.users[identity]
+	if usage == nil {
+		usage = &userUsage{}
+		if p.bandwidth > 0 {
+			usage.limiter = rate.NewLimiter(p.bandwidth, p.burst)
+		}
+		p.users[identity] = usage
+	}
+
+	if p.maxSessions > 0 && usage.sessions >= p.maxSessions {
+		return nil, rejectf(ErrCodeSessionLimit, "%s already has %d tunnel sessions", identity, usage.sessions)
+	}
+	usage.sessions++
+
+	return &Lease{policy: p, identity: identity, usage: usage}, nil
+}
+
+// Sessions returns the open sessions of a client identity
+func (p *Policy) Sessions(identity string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if usage := p.users[identity]; usage != nil {
+		return usage.sessions
+	}
+	return 0
+}
+
+// release closes a session of a client identity, forgetting identities without sessions
+func (p *Policy) release(identity string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	usage := p.users[identity]
+	if usage == nil {
+		return
+	}
+	usage.sessions--
+	if usage.sessions <= 0 {
+		delete(p.users, identity)
+	}
+}
+
+// Lease is a session opened with a Policy, which accounts its traffic against the limits of its
+// client identity; the zero Lease (and a nil one) has no limits
+type Lease struct {
+	policy   *Policy
+	identity string
+	usage    *userUsage
+	once     sync.Once
+}
+
+// Allow reports whether a packet of n bytes fits the bandwidth limit, consuming it when it does
+func (l *Lease) Allow(n int) bool {
+	if l == nil || l.usage == nil || l.usage.limiter == nil {
+		return true
+	}
+	return l.usage.limiter.AllowN(time.Now(), n)
+}
+
+// Release closes the session, it may be called several times
+func (l *Lease) Release() {
+	if l == nil || l.policy == nil {
+		return
+	}
+	l.once.Do(func() { l.policy.release(l.identity) })
+}
@@ -0,0 +1,154 @@
+package tunnel
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func testPolicy(t *testing.T, cfg PolicyConfig) *Policy {
+	t.Helper()
+	p, err := NewPolicy(cfg)
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+	p.resolve = func(addr string) (*net.UDPAddr, error) {
+		host, port, _ := net.SplitHostPort(addr)
+		if host == "wireguard.local" {
+			host = "127.0.0.1"
+		}
+		return net.ResolveUDPAddr("udp", net.JoinHostPort(host, port))
+	}
+	return p
+}
+
+func TestPolicyCheckDestination(t *testing.T) {
+	p := testPolicy(t, PolicyConfig{Allow: []string{"127.0.0.1:51820", "10.43.0.0/16:53", "fd00::/8", "wireguard.local:51821"}})
+
+	tests := []struct {
+		addr string
+		code string
+	}{
+		{addr: "127.0.0.1:51820"},
+		{addr: "127.0.0.1:22", code: ErrCodeForbiddenDestination},
+		{addr: "10.43.0.10:53"},
+		{addr: "10.43.0.10:5432", code: ErrCodeForbiddenDestination},
+		{addr: "[fd00::1]:9999"},
+		{addr: "wireguard.local:51821"},
+		{addr: "wireguard.local:51820"}, // resolves to the allowed 127.0.0.1:51820
+		{addr: "169.254.169.254:80", code: ErrCodeForbiddenDestination},
+		{addr: "nope", code: ErrCodeBadRequest},
+		{addr: "127.0.0.1:0", code: ErrCodeBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			_, err := p.CheckDestination(tt.addr)
+			var rejected *RejectedError
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("CheckDestination(%q) error = %v", tt.addr, err)
+				}
+				return
+			}
+			if !errors.As(err, &rejected) || rejected.Code != tt.code {
+				t.Errorf("CheckDestination(%q) error = %v, want code %s", tt.addr, err, tt.code)
+			}
+		})
+	}
+}
+
+func TestPolicyEmptyAllowsNothing(t *testing.T) {
+	p := testPolicy(t, PolicyConfig{})
+	if _, err := p.CheckDestination("127.0.0.1:51820"); err == nil {
+		t.Error("expected destination to be refused without rules")
+	}
+}
+
+func TestParseDestinationRuleInvalid(t *testing.T) {
+	for _, rule := range []string{"127.0.0.1", "10.0.0.0/33", "10.0.0.0/8:http", "host:70000"} {
+		if _, err := parseDestinationRule(rule); err == nil {
+			t.Errorf("parseDestinationRule(%q) succeeded, want error", rule)
+		}
+	}
+}
+
+func TestPolicySessionLimit(t *testing.T) {
+	p := testPolicy(t, PolicyConfig{MaxSessionsPerUser: 2})
+
+	first, err := p.Acquire("alice")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if _, err := p.Acquire("alice"); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	var rejected *RejectedError
+	if _, err := p.Acquire("alice"); !errors.As(err, &rejected) || rejected.Code != ErrCodeSessionLimit {
+		t.Fatalf("third Acquire() error = %v, want %s", err, ErrCodeSessionLimit)
+	}
+	if _, err := p.Acquire("bob"); err != nil {
+		t.Errorf("Acquire() of another user error = %v", err)
+	}
+	if _, err := p.Acquire(""); err != nil {
+		t.Errorf("Acquire() without identity error = %v", err)
+	}
+
+	first.Release()
+	first.Release()
+	if got := p.Sessions("alice"); got != 1 {
+		t.Errorf("Sessions() = %d after release, want 1", got)
+	}
+	if _, err := p.Acquire("alice"); err != nil {
+		t.Errorf("Acquire() after release error = %v", err)
+	}
+}
+
+func TestLeaseBandwidth(t *testing.T) {
+	p := testPolicy(t, PolicyConfig{BandwidthPerUser: 100_000})
+
+	a, _ := p.Acquire("alice")
+	b, _ := p.Acquire("alice")
+	if !a.Allow(60_000) {
+		t.Fatal("packet within the burst was dropped")
+	}
+	// Sessions of a user share its bandwidth
+	if b.Allow(60_000) {
+		t.Error("packet over the shared limit was allowed")
+	}
+
+	var unlimited *Lease
+	if !unlimited.Allow(1 << 20) {
+		t.Error("nil lease dropped a packet")
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	if arg, err := parseResponse("OK\n"); arg != "" || err != nil {
+		t.Errorf("parseResponse(OK) = %q, %v", arg, err)
+	}
+	if arg, err := parseResponse("OK 1\n"); arg != "1" || err != nil {
+		t.Errorf("parseResponse(OK 1) = %q, %v", arg, err)
+	}
+
+	tests := []struct {
+		line    string
+		code    string
+		message string
+	}{
+		{line: "ERR\n"},
+		{line: "ERR session_limit alice already has 4 tunnel sessions\n", code: ErrCodeSessionLimit, message: "alice already has 4 tunnel sessions"},
+		{line: formatRejection(rejectf(ErrCodeForbiddenDestination, "destination\n10.0.0.1:22 is not allowed")), code: ErrCodeForbiddenDestination, message: "destination 10.0.0.1:22 is not allowed"},
+	}
+	for _, tt := range tests {
+		var rejected *RejectedError
+		if _, err := parseResponse(tt.line); !errors.As(err, &rejected) || rejected.Code != tt.code || rejected.Message != tt.message {
+			t.Errorf("parseResponse(%q) = %#v, want code %q message %q", tt.line, err, tt.code, tt.message)
+		}
+	}
+
+	if _, err := parseResponse("HTTP/1.1 400\n"); err == nil {
+		t.Error("expected error for unexpected response")
+	}
+}
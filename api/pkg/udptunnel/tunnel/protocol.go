@@ -0,0 +1,148 @@
+package tunnel
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kloudlite/kloudlite/api/pkg/udptunnel/transport"
+)
+
+// A tunnel session starts with the request line "CONNECT_UDP <remote_addr>\n", to which the server
+// answers "OK\n", or "ERR <code> <message>\n" when it refuses the session. Servers that predate the
+// error codes answer a bare "ERR\n". Datagrams then follow, each prefixed with its 2-byte length.
+// Clients ask for a multiplexed tunnel with "MUX_UDP <version>\n" instead (see mux.go).
+const (
+	connectCommand = "CONNECT_UDP"
+	responseOK     = "OK\n"
+
+	// maxResponseLength bounds the response line read by the client
+	maxResponseLength = 512
+)
+
+// Codes of refused tunnel requests
+const (
+	ErrCodeBadRequest           = "bad_request"           // Malformed request line
+	ErrCodeForbiddenDestination = "forbidden_destination" // Destination not allowed by the server policy
+	ErrCodeSessionLimit         = "session_limit"         // Too many sessions for the client identity
+	ErrCodeDialFailed           = "dial_failed"           // Destination could not be reached
+)
+
+// RejectedError is returned when the tunnel server refuses a tunnel request
+type RejectedError struct {
+	Code    string // One of the ErrCode constants, empty for servers without error codes
+	Message string
+}
+
+// Error implements error
+func (e *RejectedError) Error() string {
+	switch {
+	case e.Code == "":
+		return "tunnel request rejected by server"
+	case e.Message == "":
+		return fmt.Sprintf("tunnel request rejected by server: %s", e.Code)
+	default:
+		return fmt.Sprintf("tunnel request rejected by server: %s (%s)", e.Message, e.Code)
+	}
+}
+
+// wire returns the code and the message of the error on one line
+func (e *RejectedError) wire() string {
+	message := strings.Join(strings.Fields(e.Message), " ")
+	return strings.TrimSpace(e.Code + " " + message)
+}
+
+// parseRejection parses "<code> <message>"
+func parseRejection(s string) *RejectedError {
+	code, message, _ := strings.Cut(strings.TrimSpace(s), " ")
+	return &RejectedError{Code: code, Message: message}
+}
+
+// rejectf creates the RejectedError of a refused request
+func rejectf(code, format string, args ...any) *RejectedError {
+	return &RejectedError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// formatRequest returns the request line of a tunnel to remoteAddr
+func formatRequest(remoteAddr string) string {
+	return fmt.Sprintf("%s %s\n", connectCommand, remoteAddr)
+}
+
+// formatMuxRequest returns the request line of a multiplexed tunnel
+func formatMuxRequest(version int) string {
+	return fmt.Sprintf("%s %d\n", muxCommand, version)
+}
+
+// parseRequest returns the command of a request line (CONNECT_UDP or MUX_UDP) and its argument
+func parseRequest(line string) (command, arg string, err error) {
+	parts := strings.Fields(line)
+	if len(parts) != 2 || (parts[0] != connectCommand && parts[0] != muxCommand) {
+		return "", "", rejectf(ErrCodeBadRequest, "expected %s <host:port> or %s <version>", connectCommand, muxCommand)
+	}
+	return parts[0], parts[1], nil
+}
+
+// formatRejection returns the response line of a refused request
+func formatRejection(err *RejectedError) string {
+	return "ERR " + err.wire() + "\n"
+}
+
+// parseResponse returns the argument of an OK response line ("OK <arg>\n", empty for "OK\n"), and
+// the RejectedError of an ERR one
+func parseResponse(line string) (string, error) {
+	fields := strings.TrimSuffix(line, "\n")
+	status, rest, _ := strings.Cut(fields, " ")
+	switch status {
+	case "OK":
+		return rest, nil
+	case "ERR":
+		return "", parseRejection(rest)
+	default:
+		return "", fmt.Errorf("unexpected tunnel response %q", line)
+	}
+}
+
+// datagramConn carries the datagrams of one session, over its own connection or as a stream of a
+// multiplexed tunnel
+type datagramConn interface {
+	ReadDatagram(p []byte) (int, error)
+	WriteDatagram(p []byte) error
+	Close() error
+}
+
+// lineConn is a session over its own connection, opened with CONNECT_UDP
+type lineConn struct {
+	transport transport.Transport
+	reader    io.Reader // Datagrams are read from it, the transport or a buffered reader of it
+}
+
+// ReadDatagram reads the next length-prefixed datagram
+func (c *lineConn) ReadDatagram(p []byte) (int, error) {
+	var lengthBuf [2]byte
+	if _, err := io.ReadFull(c.reader, lengthBuf[:]); err != nil {
+		return 0, err
+	}
+
+	length := int(lengthBuf[0])<<8 | int(lengthBuf[1])
+	if length > len(p) {
+		return 0, fmt.Errorf("packet too large: %d bytes", length)
+	}
+	return io.ReadFull(c.reader, p[:length])
+}
+
+// WriteDatagram writes a length-prefixed datagram in a single Write
+func (c *lineConn) WriteDatagram(p []byte) error {
+	length := uint16(len(p))
+	packet := make([]byte, 2+len(p))
+	packet[0] = byte(length >> 8)
+	packet[1] = byte(length)
+	copy(packet[2:], p)
+
+	_, err := c.transport.Write(packet)
+	return err
+}
+
+// Close closes the connection
+func (c *lineConn) Close() error {
+	return c.transport.Close()
+}
@@ -3,18 +3,32 @@ package tunnel
 import (
 	"bufio"
 	"context"
+	"errors"
+	"fmt"
 	"net"
-	"strings"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/kloudlite/kloudlite/api/pkg/udptunnel/transport"
 	"go.uber.org/zap"
 )
 
+// Observer is notified of the tunnel sessions of a UDPServer and of their traffic, e.g. for accounting
+type Observer interface {
+	SessionStarted()
+	SessionEnded(duration time.Duration)
+	PacketReceived(bytes int) // Received through the tunnel, forwarded to the destination
+	PacketSent(bytes int)     // Received from the destination, sent through the tunnel
+	SessionRejected(code string)
+}
+
 // UDPServer handles incoming UDP tunnel requests via WebSocket
 type UDPServer struct {
 	listener transport.Listener
 	logger   *zap.Logger
+	observer Observer
+	policy   *Policy
 }
 
 // NewUDPServer creates a new UDP tunnel server
@@ -22,7 +36,22 @@ func NewUDPServer(listener transport.Listener, logger *zap.Logger) *UDPServer {
 	return &UDPServer{
 		listener: listener,
 		logger:   logger,
+		observer: noopObserver{},
+	}
+}
+
+// SetObserver sets the observer of tunnel sessions, must be called before Start
+func (s *UDPServer) SetObserver(observer Observer) {
+	if observer == nil {
+		observer = noopObserver{}
 	}
+	s.observer = observer
+}
+
+// SetPolicy sets the policy restricting the destinations and the sessions of clients, must be
+// called before Start; without one any destination is allowed
+func (s *UDPServer) SetPolicy(policy *Policy) {
+	s.policy = policy
 }
 
 // Start starts the UDP tunnel server
@@ -54,7 +83,7 @@ func (s *UDPServer) Start(ctx context.Context) error {
 func (s *UDPServer) handleConnection(ctx context.Context, tunnelConn transport.Transport) {
 	defer tunnelConn.Close()
 
-	// Read tunnel request header: CONNECT_UDP <remote_addr>\n
+	// Read tunnel request header: CONNECT_UDP <remote_addr>\n or MUX_UDP <version>\n
 	reader := bufio.NewReader(tunnelConn)
 	line, err := reader.ReadString('\n')
 	if err != nil {
@@ -62,84 +91,186 @@ func (s *UDPServer) handleConnection(ctx context.Context, tunnelConn transport.T
 		return
 	}
 
-	parts := strings.Fields(line)
-	if len(parts) != 2 || parts[0] != "CONNECT_UDP" {
+	identity := transport.IdentityOf(tunnelConn)
+
+	command, arg, err := parseRequest(line)
+	if err != nil {
 		s.logger.Error("invalid tunnel request", zap.String("request", line))
-		tunnelConn.Write([]byte("ERR\n"))
+		tunnelConn.Write([]byte(formatRejection(s.rejection(identity, err))))
 		return
 	}
 
-	remoteAddr := parts[1]
-	s.logger.Debug("handling UDP tunnel request", zap.String("remote", remoteAddr))
+	if command == muxCommand {
+		s.handleMux(tunnelConn, reader, identity, arg)
+		return
+	}
 
-	// Connect to the actual remote UDP destination
-	destConn, err := net.Dial("udp", remoteAddr)
+	remoteAddr := arg
+	s.logger.Debug("handling UDP tunnel request", zap.String("remote", remoteAddr), zap.String("identity", identity))
+
+	destConn, lease, err := s.connect(remoteAddr, identity)
 	if err != nil {
-		s.logger.Error("failed to connect to UDP destination",
-			zap.String("dest", remoteAddr),
-			zap.Error(err))
-		tunnelConn.Write([]byte("ERR\n"))
+		tunnelConn.Write([]byte(formatRejection(s.rejection(identity, err))))
 		return
 	}
-	defer destConn.Close()
+	defer lease.Release()
 
 	// Send success response
-	if _, err := tunnelConn.Write([]byte("OK\n")); err != nil {
+	if _, err := tunnelConn.Write([]byte(responseOK)); err != nil {
+		destConn.Close()
 		return
 	}
 
-	s.logger.Debug("connected to UDP destination", zap.String("dest", remoteAddr))
+	s.logger.Debug("connected to UDP destination", zap.String("dest", remoteAddr), zap.String("identity", identity))
+	s.forward(&lineConn{transport: tunnelConn, reader: reader}, destConn, lease)
+	s.logger.Debug("UDP tunnel connection closed", zap.String("dest", remoteAddr))
+}
+
+// handleMux serves a multiplexed tunnel, each of its streams being a session
+func (s *UDPServer) handleMux(tunnelConn transport.Transport, reader *bufio.Reader, identity, version string) {
+	v, err := strconv.Atoi(version)
+	if err != nil || v < 1 {
+		tunnelConn.Write([]byte(formatRejection(s.rejection(identity, rejectf(ErrCodeBadRequest, "invalid version %q", version)))))
+		return
+	}
+
+	// Both sides speak the lowest of their versions
+	v = min(v, muxVersion)
+	if _, err := fmt.Fprintf(tunnelConn, "OK %d\n", v); err != nil {
+		return
+	}
+
+	mux := newMuxConn(tunnelConn, reader, s.logger)
+	mux.accept = func(stream *muxStream, remoteAddr string) {
+		s.handleMuxStream(stream, remoteAddr, identity)
+	}
+
+	s.logger.Debug("multiplexed UDP tunnel opened", zap.Int("version", v), zap.String("identity", identity))
+	err = mux.run()
+	s.logger.Debug("multiplexed UDP tunnel closed", zap.Error(err), zap.String("identity", identity))
+}
+
+// handleMuxStream connects a stream of a multiplexed tunnel to its destination
+func (s *UDPServer) handleMuxStream(stream *muxStream, remoteAddr, identity string) {
+	destConn, lease, err := s.connect(remoteAddr, identity)
+	if err != nil {
+		stream.reject(s.rejection(identity, err))
+		return
+	}
+	defer lease.Release()
+
+	if err := stream.accept(); err != nil {
+		destConn.Close()
+		return
+	}
+
+	s.logger.Debug("connected stream to UDP destination", zap.String("dest", remoteAddr), zap.String("identity", identity))
+	s.forward(stream, destConn, lease)
+}
+
+// connect checks a tunnel request against the policy and connects to its destination
+func (s *UDPServer) connect(remoteAddr, identity string) (net.Conn, *Lease, error) {
+	if s.policy == nil {
+		destConn, err := net.Dial("udp", remoteAddr)
+		if err != nil {
+			s.logger.Error("failed to connect to UDP destination", zap.String("dest", remoteAddr), zap.Error(err))
+			return nil, nil, rejectf(ErrCodeDialFailed, "cannot connect to %s", remoteAddr)
+		}
+		return destConn, nil, nil
+	}
+
+	destAddr, err := s.policy.CheckDestination(remoteAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lease, err := s.policy.Acquire(identity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Dial the checked address, not the name again
+	destConn, err := net.DialUDP("udp", nil, destAddr)
+	if err != nil {
+		lease.Release()
+		s.logger.Error("failed to connect to UDP destination", zap.String("dest", remoteAddr), zap.Error(err))
+		return nil, nil, rejectf(ErrCodeDialFailed, "cannot connect to %s", remoteAddr)
+	}
+	return destConn, lease, nil
+}
+
+// rejection returns the RejectedError a tunnel request is refused with, accounting it
+func (s *UDPServer) rejection(identity string, err error) *RejectedError {
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) {
+		rejected = rejectf(ErrCodeDialFailed, "%v", err)
+	}
+
+	s.logger.Warn("refused UDP tunnel request",
+		zap.String("code", rejected.Code),
+		zap.String("reason", rejected.Message),
+		zap.String("identity", identity))
+	s.observer.SessionRejected(rejected.Code)
+	return rejected
+}
+
+// forward forwards the datagrams of a session in both directions until either side closes, then
+// closes both
+func (s *UDPServer) forward(conn datagramConn, destConn net.Conn, lease *Lease) {
+	started := time.Now()
+	s.observer.SessionStarted()
+	defer func() { s.observer.SessionEnded(time.Since(started)) }()
 
-	// Bidirectional forwarding
 	var wg sync.WaitGroup
+	var closeOnce sync.Once
+	closeBoth := func() {
+		closeOnce.Do(func() {
+			conn.Close()
+			destConn.Close()
+		})
+	}
 	wg.Add(2)
 
 	// Forward from tunnel to destination
 	go func() {
 		defer wg.Done()
-		s.forwardTunnelToDestination(tunnelConn, destConn)
+		defer closeBoth()
+		s.forwardTunnelToDestination(conn, destConn, lease)
 	}()
 
 	// Forward from destination to tunnel
 	go func() {
 		defer wg.Done()
-		s.forwardDestinationToTunnel(destConn, tunnelConn)
+		defer closeBoth()
+		s.forwardDestinationToTunnel(destConn, conn, lease)
 	}()
 
 	wg.Wait()
-	s.logger.Debug("UDP tunnel connection closed", zap.String("dest", remoteAddr))
 }
 
-func (s *UDPServer) forwardTunnelToDestination(tunnelConn transport.Transport, destConn net.Conn) {
+func (s *UDPServer) forwardTunnelToDestination(conn datagramConn, destConn net.Conn, lease *Lease) {
 	buffer := make([]byte, 65536)
 
 	for {
-		// Read packet length (2 bytes)
-		lengthBuf := make([]byte, 2)
-		if _, err := tunnelConn.Read(lengthBuf); err != nil {
-			return
-		}
-
-		length := int(lengthBuf[0])<<8 | int(lengthBuf[1])
-		if length > len(buffer) {
-			s.logger.Error("packet too large", zap.Int("length", length))
+		n, err := conn.ReadDatagram(buffer)
+		if err != nil {
 			return
 		}
 
-		// Read packet data
-		n, err := tunnelConn.Read(buffer[:length])
-		if err != nil {
-			return
+		// Drop packets over the bandwidth limit of the client
+		if !lease.Allow(n) {
+			continue
 		}
 
 		// Write to destination
 		if _, err := destConn.Write(buffer[:n]); err != nil {
 			return
 		}
+		s.observer.PacketReceived(n)
 	}
 }
 
-func (s *UDPServer) forwardDestinationToTunnel(destConn net.Conn, tunnelConn transport.Transport) {
+func (s *UDPServer) forwardDestinationToTunnel(destConn net.Conn, conn datagramConn, lease *Lease) {
 	buffer := make([]byte, 65536)
 
 	for {
@@ -148,16 +279,14 @@ func (s *UDPServer) forwardDestinationToTunnel(destConn net.Conn, tunnelConn tra
 			return
 		}
 
-		// Write packet length (2 bytes) + data
-		length := uint16(n)
-		packet := make([]byte, 2+n)
-		packet[0] = byte(length >> 8)
-		packet[1] = byte(length)
-		copy(packet[2:], buffer[:n])
+		if !lease.Allow(n) {
+			continue
+		}
 
-		if _, err := tunnelConn.Write(packet); err != nil {
+		if err := conn.WriteDatagram(buffer[:n]); err != nil {
 			return
 		}
+		s.observer.PacketSent(n)
 	}
 }
 
@@ -165,3 +294,12 @@ func (s *UDPServer) forwardDestinationToTunnel(destConn net.Conn, tunnelConn tra
 func (s *UDPServer) Close() error {
 	return s.listener.Close()
 }
+
+// noopObserver is the observer of servers without one
+type noopObserver struct{}
+
+func (noopObserver) SessionStarted()            {}
+func (noopObserver) SessionEnded(time.Duration) {}
+func (noopObserver) PacketReceived(int)         {}
+func (noopObserver) PacketSent(int)             {}
+func (noopObserver) SessionRejected(string)     {}
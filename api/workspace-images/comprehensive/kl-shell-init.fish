@@ -16,6 +16,19 @@ if test -f /etc/environment
     end < /etc/environment
 end
 
+# Load workspace secrets (kl secret, settings.envFrom) and reload them at each prompt
+# when the content of the env file changes, so updates reach running shells without a restart
+set -g __kl_secrets_sum ""
+function __kl_load_secrets --on-event fish_prompt
+    set -l f /home/kl/.kloudlite/secrets.fish
+    test -r $f; or return 0
+    set -l s (cksum < $f 2>/dev/null)
+    test "$s" = "$__kl_secrets_sum"; and return 0
+    set -g __kl_secrets_sum $s
+    source $f
+end
+__kl_load_secrets
+
 # Ensure /home/kl/.local/bin is first in PATH for user-installed npm packages
 # Remove duplicates and unwanted /games directories
 set -x PATH /home/kl/.local/bin (string split : $PATH | string match -v -r '/games' | string match -v /home/kl/.local/bin)
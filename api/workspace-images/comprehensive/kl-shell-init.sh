@@ -11,6 +11,20 @@ if [ -f /etc/environment ]; then
     done < /etc/environment
 fi
 
+# Load workspace secrets (kl secret, settings.envFrom) and reload them at each prompt
+# when the content of the env file changes, so updates reach running shells without a restart
+__kl_secrets_sum=""
+__kl_load_secrets() {
+    local f=/home/kl/.kloudlite/secrets.env s
+    [ -r "$f" ] || return 0
+    s=$(cksum < "$f" 2>/dev/null)
+    [ "$s" = "$__kl_secrets_sum" ] && return 0
+    __kl_secrets_sum=$s
+    . "$f"
+}
+__kl_load_secrets
+PROMPT_COMMAND="__kl_load_secrets${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+
 # Ensure /home/kl/.local/bin is first in PATH for user-installed npm packages
 # Remove duplicates and unwanted /games directories
 export PATH="/home/kl/.local/bin:$(echo "$PATH" | tr ':' '\n' | grep -v '/games' | grep -v '^/home/kl/.local/bin$' | uniq | tr '\n' ':' | sed 's/:$//')"
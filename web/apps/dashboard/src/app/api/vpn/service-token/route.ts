@@ -0,0 +1,105 @@
+import { NextRequest, NextResponse } from 'next/server'
+import { getSession } from '@/lib/get-session'
+import { SignJWT } from 'jose'
+import { getEnvironment } from '@/app/actions/environment.actions'
+
+const DEFAULT_EXPIRES_IN_DAYS = 90
+const MAX_EXPIRES_IN_DAYS = 365
+
+/**
+ * VPN Service Token API
+ * Issues a long-lived JWT token scoped to one Environment, for `kltun run` in CI jobs
+ * The tunnel server only resolves the services of that Environment for these tokens,
+ * and refuses them on user endpoints (TLS key, other devices)
+ * Requires user to be authenticated via NextAuth and to own the Environment
+ */
+export async function POST(request: NextRequest) {
+  try {
+    // Get authenticated session
+    const session = await getSession()
+
+    if (!session?.user?.email || !session.user.username) {
+      return NextResponse.json({ error: 'Unauthorized - please sign in' }, { status: 401 })
+    }
+
+    const body = await request.json().catch(() => ({}))
+    const { environment, expiresInDays = DEFAULT_EXPIRES_IN_DAYS, description } = body as {
+      environment?: unknown
+      expiresInDays?: unknown
+      description?: unknown
+    }
+
+    if (!environment || typeof environment !== 'string') {
+      return NextResponse.json({ error: 'Environment is required' }, { status: 400 })
+    }
+
+    if (
+      typeof expiresInDays !== 'number' ||
+      !Number.isInteger(expiresInDays) ||
+      expiresInDays < 1 ||
+      expiresInDays > MAX_EXPIRES_IN_DAYS
+    ) {
+      return NextResponse.json(
+        { error: `expiresInDays must be a whole number of days between 1 and ${MAX_EXPIRES_IN_DAYS}` },
+        { status: 400 }
+      )
+    }
+
+    // Tokens are only issued for Environments of the user, never for Environments without owner
+    const result = await getEnvironment(environment)
+    if (!result.success || !result.data) {
+      return NextResponse.json({ error: 'Environment not found' }, { status: 404 })
+    }
+    const ownedBy = result.data.spec?.ownedBy
+    if (!ownedBy) {
+      return NextResponse.json({ error: 'Environment has no owner' }, { status: 403 })
+    }
+    if (ownedBy !== session.user.username) {
+      return NextResponse.json({ error: 'Environment is owned by another user' }, { status: 403 })
+    }
+
+    // Use JWT_SECRET or NEXTAUTH_SECRET for JWT signing
+    const jwtSecret = process.env.JWT_SECRET || process.env.NEXTAUTH_SECRET
+    if (!jwtSecret) {
+      console.error('JWT_SECRET/NEXTAUTH_SECRET environment variable not set')
+      return NextResponse.json({ error: 'Server configuration error' }, { status: 500 })
+    }
+
+    const secret = new TextEncoder().encode(jwtSecret)
+    const tokenId = crypto.randomUUID()
+
+    // The token carries the user it acts for, tunnel server peers of CI jobs count as devices of that user
+    const serviceToken = await new SignJWT({
+      email: session.user.email,
+      name: typeof description === 'string' && description ? description : `CI (${environment})`,
+      username: session.user.username,
+      environment,
+      type: 'vpn-service', // Mark as service VPN token
+    })
+      .setProtectedHeader({ alg: 'HS256' })
+      .setIssuedAt()
+      .setExpirationTime(`${expiresInDays}d`)
+      .setIssuer('kloudlite-vpn')
+      .setSubject(session.user.email)
+      .setJti(tokenId)
+      .sign(secret)
+
+    console.log('[VPN Service Token] Issued token', tokenId, 'for environment', environment, 'of user:', session.user.username)
+
+    // Get server URL from request headers (for multi-tenant support)
+    const protocol = request.headers.get('x-forwarded-proto') || 'https'
+    const host = request.headers.get('host') || request.nextUrl.host
+    const serverUrl = `${protocol}://${host}`
+
+    return NextResponse.json({
+      service_token: serviceToken,
+      token_id: tokenId,
+      environment,
+      expires_at: new Date(Date.now() + expiresInDays * 24 * 60 * 60 * 1000).toISOString(),
+      server_url: serverUrl,
+    })
+  } catch (error) {
+    console.error('Generate service token error:', error)
+    return NextResponse.json({ error: 'Failed to generate service token' }, { status: 500 })
+  }
+}
@@ -52,8 +52,8 @@ export async function GET(request: NextRequest) {
       )
     }
 
-    // Validate token type
-    if (claims.type !== 'vpn-temp' && claims.type !== 'vpn-permanent') {
+    // Validate token type, service tokens of CI jobs connect like users
+    if (claims.type !== 'vpn-temp' && claims.type !== 'vpn-permanent' && claims.type !== 'vpn-service') {
       return NextResponse.json({ error: 'Invalid token type' }, { status: 401 })
     }
 